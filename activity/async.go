@@ -0,0 +1,18 @@
+package activity
+
+import (
+	"context"
+
+	"github.com/cschleiden/go-workflows/internal/activity"
+)
+
+// ErrResultPending can be returned by an activity to signal that it will be completed asynchronously, for example,
+// by an external system once a human has approved a request. The activity task is released and its result is passed
+// in later with Client.CompleteActivity, using the token returned by TaskToken.
+var ErrResultPending = activity.ErrResultPending
+
+// TaskToken returns the token identifying the current activity task. Pass it to Client.CompleteActivity to complete
+// an activity that returned ErrResultPending.
+func TaskToken(ctx context.Context) string {
+	return activity.GetActivityState(ctx).TaskToken
+}
@@ -0,0 +1,13 @@
+package activity
+
+import (
+	"context"
+
+	"github.com/cschleiden/go-workflows/internal/activity"
+)
+
+// Attempt returns the attempt of the current activity execution, starting at 0 for the first attempt. Attempts are
+// counted by the workflow retrying the activity according to its RetryOptions.
+func Attempt(ctx context.Context) int {
+	return activity.GetActivityState(ctx).Attempt
+}
@@ -0,0 +1,53 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/activity"
+	"github.com/cschleiden/go-workflows/internal/converter"
+)
+
+// Checkpoint records the progress of the current activity attempt, for example, the number of chunks uploaded so
+// far. If the attempt fails and the activity is retried, the next attempt can retrieve the checkpoint with
+// LastCheckpoint and resume where the failed attempt stopped. Each call replaces the previous checkpoint.
+//
+// Checkpoints are persisted when an attempt fails. If the worker executing the attempt crashes, the next attempt
+// sees the checkpoint of the last failed attempt.
+func Checkpoint(ctx context.Context, v any) error {
+	as := activity.GetActivityState(ctx)
+
+	p, err := checkpointConverter(as).To(v)
+	if err != nil {
+		return fmt.Errorf("converting checkpoint: %w", err)
+	}
+
+	as.SetCheckpoint(p)
+
+	return nil
+}
+
+// LastCheckpoint returns the most recent checkpoint recorded by the current or a previous attempt of the activity.
+// ok is false if no checkpoint has been recorded.
+func LastCheckpoint[T any](ctx context.Context) (v T, ok bool, err error) {
+	as := activity.GetActivityState(ctx)
+
+	p := as.Checkpoint()
+	if p == nil {
+		return v, false, nil
+	}
+
+	if err := checkpointConverter(as).From(p, &v); err != nil {
+		return v, false, fmt.Errorf("converting checkpoint: %w", err)
+	}
+
+	return v, true, nil
+}
+
+func checkpointConverter(as *activity.ActivityState) converter.Converter {
+	if as.Converter != nil {
+		return as.Converter
+	}
+
+	return converter.DefaultConverter
+}
@@ -0,0 +1,23 @@
+package activity
+
+import (
+	"errors"
+
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// NewApplicationError returns an error with the given message for an activity to return. Non-nil details are
+// attached as payload, the calling workflow can retrieve them with workflow.ErrorPayload. If nonRetryable is set, the
+// activity is not retried, regardless of its RetryOptions.
+func NewApplicationError(message string, details interface{}, nonRetryable bool) error {
+	err := errors.New(message)
+	if details != nil {
+		err = workflow.NewErrorWithPayload(err, details)
+	}
+
+	if nonRetryable {
+		err = workflow.NewNonRetryableError(err)
+	}
+
+	return err
+}
@@ -0,0 +1,30 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/activity"
+	"github.com/cschleiden/go-workflows/internal/payload"
+)
+
+// RecordHeartbeat reports that the current activity attempt is still making progress. Activities with a
+// HeartbeatTimeout have to record heartbeats more often than the timeout, otherwise the attempt fails with a
+// heartbeat timeout and is retried according to its RetryOptions.
+//
+// Non-nil details are recorded like a checkpoint and persisted with the heartbeat, the next attempt can retrieve them
+// with LastCheckpoint.
+func RecordHeartbeat(ctx context.Context, details any) error {
+	as := activity.GetActivityState(ctx)
+
+	var p payload.Payload
+	if details != nil {
+		var err error
+		p, err = checkpointConverter(as).To(details)
+		if err != nil {
+			return fmt.Errorf("converting heartbeat details: %w", err)
+		}
+	}
+
+	return as.Heartbeat(ctx, p)
+}
@@ -21,3 +21,18 @@ func TestActivityTester(t *testing.T) {
 	require.Equal(t, 47, r)
 	require.NoError(t, err)
 }
+
+func TestActivityTester_Checkpoint(t *testing.T) {
+	ctx := WithActivityTestState(context.Background(), "activityID", "instanceID", nil)
+
+	_, ok, err := activity.LastCheckpoint[int](ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, activity.Checkpoint(ctx, 7))
+
+	v, ok, err := activity.LastCheckpoint[int](ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 7, v)
+}
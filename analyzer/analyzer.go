@@ -2,21 +2,19 @@ package analyzer
 
 import (
 	"go/ast"
+	"go/token"
 	"go/types"
 
 	"golang.org/x/tools/go/analysis"
-	"golang.org/x/tools/go/analysis/passes/inspect"
-	"golang.org/x/tools/go/ast/inspector"
 )
 
 var checkPrivateReturnValues bool
 
 func New() *analysis.Analyzer {
 	a := &analysis.Analyzer{
-		Name:     "goworkflows",
-		Doc:      "Checks for common errors when writing workflows",
-		Run:      run,
-		Requires: []*analysis.Analyzer{inspect.Analyzer},
+		Name: "goworkflows",
+		Doc:  "Checks for common errors when writing workflows",
+		Run:  run,
 	}
 
 	a.Flags.BoolVar(&checkPrivateReturnValues, "checkprivatereturnvalues", false, "Check return values of workflows which aren't exported")
@@ -24,178 +22,240 @@ func New() *analysis.Analyzer {
 	return a
 }
 
+type checker struct {
+	pass *analysis.Pass
+
+	// funcs are all functions declared in the current package
+	funcs map[*types.Func]*ast.FuncDecl
+
+	// checked are the functions already checked, either workflows or functions called by workflows
+	checked map[*ast.FuncDecl]bool
+
+	// reported prevents duplicate diagnostics for functions called from multiple workflows
+	reported map[token.Pos]bool
+}
+
 func run(pass *analysis.Pass) (interface{}, error) {
-	inspector := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
-
-	// Expect workflows to be top level functions in a file. Therefore it should be enough to just keep track if the current
-	// AST node is a descendant of a workflow FuncDecl.
-	inWorkflow := true
-
-	workflowImportName := "workflow"
-
-	inspector.Nodes([]ast.Node{
-		(*ast.File)(nil),
-		(*ast.FuncDecl)(nil),
-		(*ast.ImportSpec)(nil),
-		(*ast.RangeStmt)(nil),
-		(*ast.SelectStmt)(nil),
-		(*ast.GoStmt)(nil),
-		(*ast.CallExpr)(nil),
-	}, func(node ast.Node, push bool) bool {
-		if _, ok := node.(*ast.File); ok {
-			// New file, reset state
-			inWorkflow = true
-		}
+	c := &checker{
+		pass:     pass,
+		funcs:    make(map[*types.Func]*ast.FuncDecl),
+		checked:  make(map[*ast.FuncDecl]bool),
+		reported: make(map[token.Pos]bool),
+	}
 
-		if _, ok := node.(*ast.FuncDecl); ok {
-			if !push {
-				// Finished with the current workflow
-				inWorkflow = false
-				return false
-			}
-		} else {
-			if !push {
-				// Only check nodes while descending
-				return false
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Body != nil {
+				if f, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func); ok {
+					c.funcs[f] = funcDecl
+				}
 			}
+		}
+	}
 
-			if !inWorkflow {
-				// Only check nodes while in a top-level workflow func
-				return false
+	// Expect workflows to be top level functions in a file. Functions in the same package called by workflows are
+	// checked as well, following the call graph.
+	for _, file := range pass.Files {
+		workflowImportName := "workflow"
+		for _, imp := range file.Imports {
+			if imp.Path.Value == `"github.com/cschleiden/go-workflows/workflow"` && imp.Name != nil {
+				workflowImportName = imp.Name.Name
 			}
 		}
 
-		switch n := node.(type) {
-		case *ast.ImportSpec:
-			if n.Path.Value == `"github.com/cschleiden/go-workflows/workflow"` {
-				if n.Name != nil {
-					workflowImportName = n.Name.Name
-				} else {
-					workflowImportName = "workflow"
-				}
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Body == nil {
+				continue
 			}
 
-		case *ast.FuncDecl:
 			// Only check functions that look like workflows
-			if !isWorkflow(workflowImportName, n) {
-				return false
+			if !isWorkflow(workflowImportName, funcDecl) {
+				continue
 			}
 
-			inWorkflow = true
-
-			// Check return types
-			if n.Name.IsExported() || checkPrivateReturnValues {
-				if n.Type.Results == nil || len(n.Type.Results.List) == 0 {
-					pass.Reportf(n.Pos(), "workflow `%v` doesn't return anything. needs to return at least `error`", n.Name.Name)
-				} else {
-					if len(n.Type.Results.List) > 2 {
-						pass.Reportf(n.Pos(), "workflow `%v` returns more than two values", n.Name.Name)
-						return true
-					}
-
-					lastResult := n.Type.Results.List[len(n.Type.Results.List)-1]
-					if types.ExprString(lastResult.Type) != "error" {
-						pass.Reportf(n.Pos(), "workflow `%v` doesn't return `error` as last return value", n.Name.Name)
-					}
-				}
-			}
+			c.checkResults(funcDecl)
+			c.checkFunc(funcDecl)
+		}
+	}
 
-			funcScope := pass.TypesInfo.Scopes[n.Type]
-			if funcScope != nil {
-				checkVarsInScope(pass, funcScope)
-			}
+	return nil, nil
+}
 
-			// Continue with the function's children
-			return true
+func (c *checker) reportf(pos token.Pos, format string, args ...interface{}) {
+	if c.reported[pos] {
+		return
+	}
 
-		case *ast.RangeStmt:
-			{
-				t := pass.TypesInfo.TypeOf(n.X)
-				if t == nil {
-					break
-				}
+	c.reported[pos] = true
+	c.pass.Reportf(pos, format, args...)
+}
 
-				switch t.(type) {
-				case *types.Map:
-					pass.Reportf(n.Pos(), "iterating over a `map` is not deterministic and not allowed in workflows")
+func (c *checker) checkResults(n *ast.FuncDecl) {
+	if !n.Name.IsExported() && !checkPrivateReturnValues {
+		return
+	}
 
-				case *types.Chan:
-					pass.Reportf(n.Pos(), "using native channels is not allowed in workflows, use `workflow.Channel` instead")
-				}
-			}
+	if n.Type.Results == nil || len(n.Type.Results.List) == 0 {
+		c.reportf(n.Pos(), "workflow `%v` doesn't return anything. needs to return at least `error`", n.Name.Name)
+		return
+	}
 
-		case *ast.SelectStmt:
-			pass.Reportf(n.Pos(), "`select` statements are not allowed in workflows, use `workflow.Select` instead")
+	if len(n.Type.Results.List) > 2 {
+		c.reportf(n.Pos(), "workflow `%v` returns more than two values", n.Name.Name)
+		return
+	}
 
-		case *ast.GoStmt:
-			pass.Reportf(n.Pos(), "use `workflow.Go` instead of `go` in workflows")
+	lastResult := n.Type.Results.List[len(n.Type.Results.List)-1]
+	if types.ExprString(lastResult.Type) != "error" {
+		c.reportf(n.Pos(), "workflow `%v` doesn't return `error` as last return value", n.Name.Name)
+	}
+}
 
-		case *ast.CallExpr:
-			var pkg *ast.Ident
-			var id *ast.Ident
-			switch fun := n.Fun.(type) {
-			case *ast.SelectorExpr:
-				pkg, _ = fun.X.(*ast.Ident)
-				id = fun.Sel
-			}
+func (c *checker) checkFunc(n *ast.FuncDecl) {
+	if c.checked[n] {
+		return
+	}
 
-			if pkg == nil || id == nil {
-				break
-			}
+	c.checked[n] = true
+
+	funcScope := c.pass.TypesInfo.Scopes[n.Type]
+	if funcScope != nil {
+		c.checkVarsInScope(funcScope)
+	}
+
+	ast.Inspect(n.Body, c.checkNode)
+}
+
+func (c *checker) checkNode(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.RangeStmt:
+		t := c.pass.TypesInfo.TypeOf(n.X)
+		if t == nil {
+			break
+		}
 
-			pkgInfo := pass.TypesInfo.Uses[pkg]
-			pkgName, _ := pkgInfo.(*types.PkgName)
-			if pkgName == nil {
-				break
+		switch t.Underlying().(type) {
+		case *types.Map:
+			c.reportf(n.Pos(), "iterating over a `map` is not deterministic and not allowed in workflows")
+
+		case *types.Chan:
+			c.reportf(n.Pos(), "using native channels is not allowed in workflows, use `workflow.Channel` instead")
+		}
+
+	case *ast.SelectStmt:
+		c.reportf(n.Pos(), "`select` statements are not allowed in workflows, use `workflow.Select` instead")
+
+		// Don't report the individual channel operations of the select statement
+		for _, clause := range n.Body.List {
+			for _, stmt := range clause.(*ast.CommClause).Body {
+				ast.Inspect(stmt, c.checkNode)
 			}
+		}
 
-			switch pkgName.Imported().Path() {
-			case "time":
-				switch id.Name {
-				case "Now":
-					pass.Reportf(n.Pos(), "`time.Now` is not allowed in workflows, use `workflow.Now` instead")
-				case "Sleep":
-					pass.Reportf(n.Pos(), "`time.Sleep` is not allowed in workflows, use `workflow.Sleep` instead")
-				}
+		return false
+
+	case *ast.GoStmt:
+		c.reportf(n.Pos(), "use `workflow.Go` instead of `go` in workflows")
+
+	case *ast.SendStmt:
+		c.reportf(n.Pos(), "using native channels is not allowed in workflows, use `workflow.Channel` instead")
+
+	case *ast.UnaryExpr:
+		if n.Op == token.ARROW {
+			c.reportf(n.Pos(), "using native channels is not allowed in workflows, use `workflow.Channel` instead")
+		}
+
+	case *ast.CallExpr:
+		c.checkCall(n)
+	}
+
+	// Continue with the children
+	return true
+}
+
+func (c *checker) checkCall(n *ast.CallExpr) {
+	var id *ast.Ident
+	switch fun := n.Fun.(type) {
+	case *ast.Ident:
+		id = fun
+
+	case *ast.SelectorExpr:
+		id = fun.Sel
+
+		if pkg, ok := fun.X.(*ast.Ident); ok {
+			if pkgName, ok := c.pass.TypesInfo.Uses[pkg].(*types.PkgName); ok {
+				c.checkPackageCall(n, pkgName.Imported().Path(), fun.Sel.Name)
+				return
 			}
 		}
 
-		// Continue with the children
-		return true
-	})
+	case *ast.IndexExpr:
+		// Generic function call with explicit type argument
+		id, _ = fun.X.(*ast.Ident)
+	}
 
-	return nil, nil
+	if id == nil {
+		return
+	}
+
+	// Follow calls to functions and methods declared in this package
+	if f, ok := c.pass.TypesInfo.Uses[id].(*types.Func); ok {
+		if funcDecl, ok := c.funcs[f.Origin()]; ok {
+			c.checkFunc(funcDecl)
+		}
+	}
+}
+
+func (c *checker) checkPackageCall(n *ast.CallExpr, path, name string) {
+	switch path {
+	case "time":
+		switch name {
+		case "Now", "Since", "Until":
+			c.reportf(n.Pos(), "`time.%v` is not allowed in workflows, use `workflow.Now` instead", name)
+		case "Sleep":
+			c.reportf(n.Pos(), "`time.Sleep` is not allowed in workflows, use `workflow.Sleep` instead")
+		}
+
+	case "math/rand", "math/rand/v2", "crypto/rand":
+		switch name {
+		case "New", "NewSource", "NewPCG", "NewChaCha8", "NewZipf":
+			// Creating a generator with an explicit seed is deterministic
+		default:
+			c.reportf(n.Pos(), "`rand.%v` is not deterministic and not allowed in workflows, use `workflow.SideEffect` instead", name)
+		}
+	}
 }
 
-func checkVarsInScope(pass *analysis.Pass, scope *types.Scope) {
+func (c *checker) checkVarsInScope(scope *types.Scope) {
 	for _, name := range scope.Names() {
 		obj := scope.Lookup(name)
 		switch t := obj.Type().(type) {
 		case *types.Chan:
-			pass.Reportf(obj.Pos(), "using native channels is not allowed in workflows, use `workflow.Channel` instead")
+			c.reportf(obj.Pos(), "using native channels is not allowed in workflows, use `workflow.Channel` instead")
 
 		case *types.Named:
-			checkNamed(pass, obj, t)
+			c.checkNamed(obj, t)
 
 		case *types.Pointer:
 			if named, ok := t.Elem().(*types.Named); ok {
-				checkNamed(pass, obj, named)
+				c.checkNamed(obj, named)
 			}
 		}
 	}
 
 	for i := 0; i < scope.NumChildren(); i++ {
-		checkVarsInScope(pass, scope.Child(i))
+		c.checkVarsInScope(scope.Child(i))
 	}
 }
 
-func checkNamed(pass *analysis.Pass, ref types.Object, named *types.Named) {
+func (c *checker) checkNamed(ref types.Object, named *types.Named) {
 	if obj := named.Obj(); obj != nil {
 		if pkg := obj.Pkg(); pkg != nil {
 			switch pkg.Path() {
 			case "sync":
 				if obj.Name() == "WaitGroup" {
-					pass.Reportf(ref.Pos(), "using `sync.WaitGroup` is not allowed in workflows, use `workflow.WaitGroup` instead")
+					c.reportf(ref.Pos(), "using `sync.WaitGroup` is not allowed in workflows, use `workflow.WaitGroup` instead")
 				}
 			}
 		}
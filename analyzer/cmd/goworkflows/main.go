@@ -0,0 +1,14 @@
+// goworkflows runs the go-workflows analyzer standalone or as a vet tool:
+//
+//	go install github.com/cschleiden/go-workflows/analyzer/cmd/goworkflows
+//	go vet -vettool=$(which goworkflows) ./...
+package main
+
+import (
+	"github.com/cschleiden/go-workflows/analyzer"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.New())
+}
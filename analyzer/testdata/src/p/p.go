@@ -4,6 +4,7 @@ package p
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/cschleiden/go-workflows/workflow"
@@ -67,7 +68,7 @@ func wfChanRange(ctx workflow.Context) error {
 	for range make(chan int, 0) { // want "using native channels is not allowed in workflows, use `workflow.Channel` instead"
 		if ctx == nil {
 			v := make(chan int, 0) // want "using native channels is not allowed in workflows, use `workflow.Channel` instead"
-			<-v
+			<-v                    // want "using native channels is not allowed in workflows, use `workflow.Channel` instead"
 
 			for range make(chan int, 0) { // want "using native channels is not allowed in workflows, use `workflow.Channel` instead"
 			}
@@ -104,6 +105,60 @@ func wfFunctionUsage(ctx workflow.Context) error {
 	return nil
 }
 
+func wfChanOps(ctx workflow.Context) error {
+	c := make(chan int, 1) // want "using native channels is not allowed in workflows, use `workflow.Channel` instead"
+	c <- 42                // want "using native channels is not allowed in workflows, use `workflow.Channel` instead"
+	fmt.Println(<-c)       // want "using native channels is not allowed in workflows, use `workflow.Channel` instead"
+
+	return nil
+}
+
+func wfRand(ctx workflow.Context) error {
+	fmt.Println(rand.Intn(10)) // want "`rand.Intn` is not deterministic and not allowed in workflows, use `workflow.SideEffect` instead"
+
+	// Explicitly seeded generators are deterministic
+	r := rand.New(rand.NewSource(42))
+	fmt.Println(r.Intn(10))
+
+	return nil
+}
+
+func wfCallsHelper(ctx workflow.Context) error {
+	helper(3)
+
+	var s state
+	s.update()
+
+	return nil
+}
+
+func helper(n int) {
+	if n > 0 {
+		helper(n - 1)
+	}
+
+	fmt.Println(time.Since(time.Time{})) // want "`time.Since` is not allowed in workflows, use `workflow.Now` instead"
+	nestedHelper()
+}
+
+func nestedHelper() {
+	go fmt.Println("nested") // want "use `workflow.Go` instead of `go` in workflows"
+}
+
+type state struct {
+	values map[string]int
+}
+
+func (s *state) update() {
+	for k := range s.values { // want "iterating over a `map` is not deterministic and not allowed in workflows"
+		fmt.Println(k)
+	}
+}
+
+func notCalledFromWorkflow() {
+	go fmt.Println("test")
+}
+
 func activity(ctx context.Context) error {
 	go fmt.Println("test")
 
@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// ErrNotArchived is returned by Archiver.GetHistory if no history has been archived for the instance
+var ErrNotArchived = errors.New("history not archived")
+
+// Archiver stores the histories of finished workflow instances outside of the storage of the backend. Backends
+// configured with an archiver move the history of an instance to the archiver once the instance has finished, and
+// read it from the archiver when it's requested via GetWorkflowInstanceHistory.
+type Archiver interface {
+	// ArchiveHistory stores the history of the given instance. Archiving the history of an instance again replaces
+	// the previously archived history.
+	ArchiveHistory(ctx context.Context, namespace string, instance *workflow.Instance, events []*history.Event) error
+
+	// GetHistory returns the archived history of the given instance. It returns ErrNotArchived if the history of the
+	// instance hasn't been archived.
+	GetHistory(ctx context.Context, namespace string, instance *workflow.Instance) ([]*history.Event, error)
+}
+
+// ArchiveRetrier is implemented by backends that can be configured with an archiver via WithArchiver. Backends archive
+// histories when instances finish, histories that couldn't be archived then, for example, because the archiver was
+// unavailable, are kept in the backend and archived by the archive maintenance job. Until their history has been
+// archived, finished instances aren't removed by RemoveFinishedWorkflowInstances.
+type ArchiveRetrier interface {
+	// ArchiveWorkflowInstanceHistories archives the histories of finished workflow instances that haven't been
+	// archived yet. It stops at the first history that can't be archived. Returns the number of archived histories.
+	ArchiveWorkflowInstanceHistories(ctx context.Context) (int, error)
+}
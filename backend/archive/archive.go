@@ -0,0 +1,56 @@
+// Package archive provides archivers storing the histories of finished workflow instances outside of the backend,
+// for example, on the filesystem or in S3-compatible object storage. Configure a backend with backend.WithArchiver to
+// move histories to an archiver once their instances have finished.
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// objectKey returns the key under which the history of the given instance is archived
+func objectKey(namespace string, instance *workflow.Instance) string {
+	return path.Join(
+		segment(namespace),
+		segment(instance.InstanceID),
+		segment(instance.ExecutionID)+".json",
+	)
+}
+
+// segment escapes the given identifier for use as a single path segment
+func segment(s string) string {
+	// Don't let identifiers refer to other directories. Escaped identifiers never contain a literal %, so these
+	// can't collide with other identifiers.
+	switch s {
+	case "":
+		return "%00"
+	case ".", "..":
+		return strings.Repeat("%2E", len(s))
+	}
+
+	return url.PathEscape(s)
+}
+
+func marshalHistory(events []*history.Event) ([]byte, error) {
+	data, err := json.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling history: %w", err)
+	}
+
+	return data, nil
+}
+
+func unmarshalHistory(data []byte) ([]*history.Event, error) {
+	var events []*history.Event
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("unmarshaling history: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,77 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+type fileArchiver struct {
+	dir string
+}
+
+var _ backend.Archiver = (*fileArchiver)(nil)
+
+// NewFileArchiver returns an archiver storing histories as JSON files in the given directory. Histories are stored
+// at <dir>/<namespace>/<instance id>/<execution id>.json.
+func NewFileArchiver(dir string) backend.Archiver {
+	return &fileArchiver{dir: dir}
+}
+
+func (a *fileArchiver) ArchiveHistory(ctx context.Context, namespace string, instance *workflow.Instance, events []*history.Event) error {
+	data, err := marshalHistory(events)
+	if err != nil {
+		return err
+	}
+
+	p := a.path(namespace, instance)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("creating archive directory: %w", err)
+	}
+
+	// Write to a temporary file first, readers never see partially written histories
+	f, err := os.CreateTemp(filepath.Dir(p), ".archive-*")
+	if err != nil {
+		return fmt.Errorf("creating archive file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("writing archive file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing archive file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), p); err != nil {
+		return fmt.Errorf("moving archive file: %w", err)
+	}
+
+	return nil
+}
+
+func (a *fileArchiver) GetHistory(ctx context.Context, namespace string, instance *workflow.Instance) ([]*history.Event, error) {
+	data, err := os.ReadFile(a.path(namespace, instance))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, backend.ErrNotArchived
+		}
+
+		return nil, fmt.Errorf("reading archive file: %w", err)
+	}
+
+	return unmarshalHistory(data)
+}
+
+func (a *fileArchiver) path(namespace string, instance *workflow.Instance) string {
+	return filepath.Join(a.dir, filepath.FromSlash(objectKey(namespace, instance)))
+}
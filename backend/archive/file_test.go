@@ -0,0 +1,36 @@
+package archive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FileArchiver(t *testing.T) {
+	ctx := context.Background()
+	a := NewFileArchiver(t.TempDir())
+
+	instance := core.NewWorkflowInstance("..", "execution")
+
+	_, err := a.GetHistory(ctx, "default", instance)
+	require.ErrorIs(t, err, backend.ErrNotArchived)
+
+	events := []*history.Event{
+		history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{Name: "wf"}),
+	}
+	require.NoError(t, a.ArchiveHistory(ctx, "default", instance, events))
+
+	archived, err := a.GetHistory(ctx, "default", instance)
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+	require.Equal(t, "wf", archived[0].Attributes.(*history.ExecutionStartedAttributes).Name)
+
+	// Histories are scoped to their namespace
+	_, err = a.GetHistory(ctx, "other", instance)
+	require.ErrorIs(t, err, backend.ErrNotArchived)
+}
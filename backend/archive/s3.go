@@ -0,0 +1,242 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+type S3Options struct {
+	// Endpoint of the S3-compatible storage, for example, https://s3.eu-west-1.amazonaws.com or http://localhost:9000
+	Endpoint string
+
+	// Region the bucket is located in, used for signing requests
+	Region string
+
+	Bucket string
+
+	// Prefix is prepended to the keys of archived histories
+	Prefix string
+
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken is required when using temporary credentials
+	SessionToken string
+
+	// VirtualHostedStyle addresses the bucket as subdomain of the endpoint instead of as first segment of the path
+	VirtualHostedStyle bool
+
+	// HTTPClient is used for requests to the storage. If not set, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+type s3Archiver struct {
+	options S3Options
+	client  *http.Client
+	now     func() time.Time
+}
+
+var _ backend.Archiver = (*s3Archiver)(nil)
+
+// NewS3Archiver returns an archiver storing histories as JSON objects in a bucket of an S3-compatible object storage.
+// Requests are signed with AWS Signature Version 4.
+func NewS3Archiver(options S3Options) backend.Archiver {
+	client := options.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &s3Archiver{
+		options: options,
+		client:  client,
+		now:     time.Now,
+	}
+}
+
+func (a *s3Archiver) ArchiveHistory(ctx context.Context, namespace string, instance *workflow.Instance, events []*history.Event) error {
+	data, err := marshalHistory(events)
+	if err != nil {
+		return err
+	}
+
+	res, err := a.do(ctx, http.MethodPut, namespace, instance, data)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return statusError("archiving history", res)
+	}
+
+	return nil
+}
+
+func (a *s3Archiver) GetHistory(ctx context.Context, namespace string, instance *workflow.Instance) ([]*history.Event, error) {
+	res, err := a.do(ctx, http.MethodGet, namespace, instance, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, backend.ErrNotArchived
+	default:
+		return nil, statusError("reading archived history", res)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading archived history: %w", err)
+	}
+
+	return unmarshalHistory(data)
+}
+
+func (a *s3Archiver) do(ctx context.Context, method, namespace string, instance *workflow.Instance, body []byte) (*http.Response, error) {
+	endpoint, err := url.Parse(a.options.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing endpoint: %w", err)
+	}
+
+	key := escapeKey(path.Join(a.options.Prefix, objectKey(namespace, instance)))
+
+	u := *endpoint
+	if a.options.VirtualHostedStyle {
+		u.Host = a.options.Bucket + "." + u.Host
+		u.RawPath = strings.TrimSuffix(u.EscapedPath(), "/") + "/" + key
+	} else {
+		u.RawPath = strings.TrimSuffix(u.EscapedPath(), "/") + "/" + escapeKey(a.options.Bucket) + "/" + key
+	}
+
+	u.Path, err = url.PathUnescape(u.RawPath)
+	if err != nil {
+		return nil, fmt.Errorf("building object url: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	a.sign(req, body)
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+
+	return res, nil
+}
+
+// sign signs the request with AWS Signature Version 4
+func (a *s3Archiver) sign(req *http.Request, body []byte) {
+	now := a.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if a.options.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.options.SessionToken)
+	}
+
+	// Sign the host and all headers set on the request
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + a.options.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+a.options.SecretAccessKey), date)
+	key = hmacSHA256(key, a.options.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%v/%v, SignedHeaders=%v, Signature=%v",
+		a.options.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+// escapeKey URI-encodes the given object key as required for signing, keeping the path separators
+func escapeKey(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func statusError(op string, res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("%v: unexpected status %v: %s", op, res.Status, bytes.TrimSpace(body))
+}
@@ -0,0 +1,29 @@
+package backend
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// ErrAsyncActivitiesNotSupported is returned when an activity is completed asynchronously on a backend that doesn't
+// implement AsyncActivityCompleter
+var ErrAsyncActivitiesNotSupported = errors.New("backend does not support asynchronous activity completion")
+
+// ErrActivityNotFound is returned by CompletePendingActivityTask if the activity task isn't waiting for a result
+var ErrActivityNotFound = errors.New("pending activity not found")
+
+// AsyncActivityCompleter is implemented by backends that support completing activities asynchronously. When an
+// activity returns activity.ErrResultPending, its task is released without a result and kept as pending until the
+// result is passed in, for example, by an external system calling Client.CompleteActivity.
+type AsyncActivityCompleter interface {
+	// SetActivityTaskPending releases the given activity task locked by this worker and records it as waiting for a
+	// result. Pending activity tasks are not handed out to workers again.
+	SetActivityTaskPending(ctx context.Context, instance *workflow.Instance, activityID string) error
+
+	// CompletePendingActivityTask completes a pending activity task with the given result event. It returns
+	// ErrActivityNotFound if the activity task isn't pending, for example, because it was completed already.
+	CompletePendingActivityTask(ctx context.Context, instance *workflow.Instance, activityID string, event *history.Event) error
+}
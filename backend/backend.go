@@ -3,6 +3,7 @@ package backend
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/cschleiden/go-workflows/internal/contextpropagation"
 	"github.com/cschleiden/go-workflows/internal/converter"
@@ -16,9 +17,46 @@ import (
 )
 
 var ErrInstanceNotFound = errors.New("workflow instance not found")
+
+// ErrInstanceAlreadyExists is returned by CreateWorkflowInstance when a workflow instance with the same instance ID
+// already exists and the instance ID reuse policy of the new instance doesn't allow creating it, see
+// CheckInstanceIDReuse
 var ErrInstanceAlreadyExists = errors.New("workflow instance already exists")
 var ErrInstanceNotFinished = errors.New("workflow instance is not finished")
 
+// ErrWorkflowTaskLost is returned by CompleteWorkflowTask if the workflow task cannot be completed anymore, for example
+// because the execution has been terminated by a new execution with the same instance ID in the meantime
+var ErrWorkflowTaskLost = errors.New("workflow task lost")
+
+// ErrConcurrencyLimitReached is returned by CreateWorkflowInstance when the number of active instances of the
+// workflow has reached the limit configured with WithWorkflowConcurrencyLimit
+var ErrConcurrencyLimitReached = errors.New("workflow concurrency limit reached")
+
+// ErrQuotaExceeded matches QuotaExceededError with errors.Is
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaExceededError is returned by CreateWorkflowInstance when the namespace or the tenant of the new instance has
+// reached its limit of active instances, see the quota package
+type QuotaExceededError struct {
+	// Tenant whose quota has been exceeded, empty if the quota of the namespace has been exceeded
+	Tenant string
+
+	// MaxActiveInstances is the limit of active instances that has been reached
+	MaxActiveInstances int
+}
+
+func (e *QuotaExceededError) Error() string {
+	if e.Tenant == "" {
+		return fmt.Sprintf("%v: namespace reached limit of %d active instances", ErrQuotaExceeded, e.MaxActiveInstances)
+	}
+
+	return fmt.Sprintf("%v: tenant %q reached limit of %d active instances", ErrQuotaExceeded, e.Tenant, e.MaxActiveInstances)
+}
+
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
 const TracerName = "go-workflow"
 
 //go:generate mockery --name=Backend --inpackage
@@ -29,17 +67,39 @@ type Backend interface {
 	// CancelWorkflowInstance cancels a running workflow instance
 	CancelWorkflowInstance(ctx context.Context, instance *workflow.Instance, cancelEvent *history.Event) error
 
+	// TerminateWorkflowInstance adds the termination event to a workflow instance. Unlike cancellation, termination
+	// doesn't wait for the workflow to react, the next workflow task ends the instance. A paused or dead-lettered
+	// instance is resumed to process the termination.
+	TerminateWorkflowInstance(ctx context.Context, instance *workflow.Instance, terminateEvent *history.Event) error
+
+	// PauseWorkflowInstance pauses a workflow instance. No workflow tasks are returned for a paused instance, new
+	// events like signals or activity results are held until the instance is resumed.
+	PauseWorkflowInstance(ctx context.Context, instance *workflow.Instance, pauseEvent *history.Event) error
+
+	// ResumeWorkflowInstance resumes a paused workflow instance
+	ResumeWorkflowInstance(ctx context.Context, instance *workflow.Instance, resumeEvent *history.Event) error
+
+	// RetryWorkflowInstance moves a dead-lettered workflow instance out of the dead-letter state. Its pending events
+	// are processed by a new workflow task, starting again at the first attempt. Retrying an instance that isn't
+	// dead-lettered does nothing.
+	RetryWorkflowInstance(ctx context.Context, instance *workflow.Instance) error
+
 	// RemoveWorkflowInstance removes a workflow instance
 	RemoveWorkflowInstance(ctx context.Context, instance *workflow.Instance) error
 
 	// GetWorkflowInstanceState returns the state of the given workflow instance
 	GetWorkflowInstanceState(ctx context.Context, instance *workflow.Instance) (core.WorkflowInstanceState, error)
 
+	// GetWorkflowInstanceInfo returns the stored information about the given workflow instance, including its parent
+	// if it is a sub-workflow. Returns ErrInstanceNotFound if the instance doesn't exist.
+	GetWorkflowInstanceInfo(ctx context.Context, instance *workflow.Instance) (*WorkflowInstanceInfo, error)
+
 	// GetWorkflowInstanceHistory returns the workflow history for the given instance. When lastSequenceID
 	// is given, only events after that event are returned. Otherwise the full history is returned.
 	GetWorkflowInstanceHistory(ctx context.Context, instance *workflow.Instance, lastSequenceID *int64) ([]*history.Event, error)
 
-	// SignalWorkflow signals a running workflow instance
+	// SignalWorkflow signals a running workflow instance. The event is either a received signal or a requested
+	// update, only signals are subject to the signal deduplication window.
 	//
 	// If the given instance does not exist, it will return an error
 	SignalWorkflow(ctx context.Context, instanceID string, event *history.Event) error
@@ -59,6 +119,11 @@ type Backend interface {
 		ctx context.Context, task *task.Workflow, instance *workflow.Instance, state core.WorkflowInstanceState,
 		executedEvents, activityEvents, timerEvents []*history.Event, workflowEvents []history.WorkflowEvent) error
 
+	// DeadLetterWorkflowTask releases a workflow task that failed too many times without completing it, and moves its
+	// instance to the dead-letter state with the given reason. No workflow tasks are returned for a dead-lettered
+	// instance until it's retried or terminated.
+	DeadLetterWorkflowTask(ctx context.Context, task *task.Workflow, reason string) error
+
 	// GetActivityTask returns a pending activity task or nil if there are no pending activities
 	GetActivityTask(ctx context.Context) (*task.Activity, error)
 
@@ -68,6 +133,9 @@ type Backend interface {
 	// ExtendActivityTask extends the lock of an activity task
 	ExtendActivityTask(ctx context.Context, activityID string) error
 
+	// ListWorkflowInstances returns workflow instances matching the given query, most recently created first
+	ListWorkflowInstances(ctx context.Context, query *ListWorkflowInstancesQuery) (*ListWorkflowInstancesResult, error)
+
 	// GetStats returns stats about the backend
 	GetStats(ctx context.Context) (*Stats, error)
 
@@ -0,0 +1,372 @@
+// Package chaos provides a backend decorator that injects faults into an existing backend. It's intended to be used
+// in tests to exercise the resilience of workers, workflows, and activities.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/decorator"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// ErrInjected is returned by backend calls that fail due to an injected fault
+var ErrInjected = errors.New("chaos: injected transient error")
+
+type redelivery struct {
+	task *task.Activity
+	at   time.Time
+}
+
+// redeliveryState tracks an activity task delivered more than once
+type redeliveryState struct {
+	// deliveries is the number of deliveries that haven't been completed or released yet
+	deliveries int
+
+	// completed is true when one of the deliveries has been completed
+	completed bool
+}
+
+type chaosBackend struct {
+	decorator.Base
+
+	options Options
+
+	mu sync.Mutex
+	r  *rand.Rand
+
+	// redeliveries are activity tasks that will be delivered again
+	redeliveries []redelivery
+
+	// redelivered tracks activity tasks delivered more than once. Tasks are removed once all of their deliveries
+	// have been completed or released.
+	redelivered map[string]*redeliveryState
+
+	// completeMu serializes completions of redelivered activity tasks
+	completeMu sync.Mutex
+}
+
+var _ backend.Backend = (*chaosBackend)(nil)
+var _ backend.RateLimiter = (*chaosBackend)(nil)
+var _ backend.Leaser = (*chaosBackend)(nil)
+var _ backend.LoadReporter = (*chaosBackend)(nil)
+var _ backend.Querier = (*chaosBackend)(nil)
+var _ backend.ScheduleStore = (*chaosBackend)(nil)
+var _ backend.AsyncActivityCompleter = (*chaosBackend)(nil)
+var _ backend.ActivityHeartbeater = (*chaosBackend)(nil)
+var _ backend.ActivityTaskReleaser = (*chaosBackend)(nil)
+var _ backend.QueuePoller = (*chaosBackend)(nil)
+var _ backend.Retainer = (*chaosBackend)(nil)
+var _ backend.IDGenerator = (*chaosBackend)(nil)
+var _ backend.InstancePurger = (*chaosBackend)(nil)
+var _ backend.ArchiveRetrier = (*chaosBackend)(nil)
+
+// NewBackend wraps the given backend and injects faults into its operations:
+//
+//   - Artificial latency for all calls
+//   - Transient errors when polling for workflow and activity tasks
+//   - Duplicate deliveries of activity tasks
+//   - Expired activity task locks, which lead to the task being delivered again after a delay
+//
+// Activity tasks delivered more than once are executed more than once, but only the first completion is passed to
+// the wrapped backend, subsequent completions are dropped. This mirrors the at-least-once semantics of activities.
+func NewBackend(b backend.Backend, opts ...Option) backend.Backend {
+	options := DefaultOptions
+	options.Seed = time.Now().UnixNano()
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	cb := &chaosBackend{
+		Base:        decorator.Base{Backend: b},
+		options:     options,
+		r:           rand.New(rand.NewSource(options.Seed)),
+		redelivered: make(map[string]*redeliveryState),
+	}
+	cb.Before = cb.delay
+
+	return cb
+}
+
+func (cb *chaosBackend) CreateWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
+	cb.delay(ctx)
+
+	return cb.Backend.CreateWorkflowInstance(ctx, instance, event)
+}
+
+func (cb *chaosBackend) CancelWorkflowInstance(ctx context.Context, instance *workflow.Instance, cancelEvent *history.Event) error {
+	cb.delay(ctx)
+
+	return cb.Backend.CancelWorkflowInstance(ctx, instance, cancelEvent)
+}
+
+func (cb *chaosBackend) TerminateWorkflowInstance(ctx context.Context, instance *workflow.Instance, terminateEvent *history.Event) error {
+	cb.delay(ctx)
+
+	return cb.Backend.TerminateWorkflowInstance(ctx, instance, terminateEvent)
+}
+
+func (cb *chaosBackend) SignalWorkflow(ctx context.Context, instanceID string, event *history.Event) error {
+	cb.delay(ctx)
+
+	return cb.Backend.SignalWorkflow(ctx, instanceID, event)
+}
+
+func (cb *chaosBackend) GetWorkflowInstanceHistory(ctx context.Context, instance *workflow.Instance, lastSequenceID *int64) ([]*history.Event, error) {
+	cb.delay(ctx)
+
+	return cb.Backend.GetWorkflowInstanceHistory(ctx, instance, lastSequenceID)
+}
+
+func (cb *chaosBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, error) {
+	cb.delay(ctx)
+
+	if cb.chance(cb.options.ErrorRate) {
+		return nil, ErrInjected
+	}
+
+	return cb.Backend.GetWorkflowTask(ctx)
+}
+
+func (cb *chaosBackend) GetWorkflowTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Workflow, error) {
+	qp, ok := cb.Backend.(backend.QueuePoller)
+	if !ok {
+		return nil, backend.ErrQueuesNotSupported
+	}
+
+	cb.delay(ctx)
+
+	if cb.chance(cb.options.ErrorRate) {
+		return nil, ErrInjected
+	}
+
+	return qp.GetWorkflowTaskFromQueues(ctx, queues)
+}
+
+func (cb *chaosBackend) ExtendWorkflowTask(ctx context.Context, taskID string, instance *core.WorkflowInstance) error {
+	cb.delay(ctx)
+
+	return cb.Backend.ExtendWorkflowTask(ctx, taskID, instance)
+}
+
+func (cb *chaosBackend) CompleteWorkflowTask(
+	ctx context.Context, task *task.Workflow, instance *workflow.Instance, state core.WorkflowInstanceState,
+	executedEvents, activityEvents, timerEvents []*history.Event, workflowEvents []history.WorkflowEvent,
+) error {
+	cb.delay(ctx)
+
+	return cb.Backend.CompleteWorkflowTask(ctx, task, instance, state, executedEvents, activityEvents, timerEvents, workflowEvents)
+}
+
+func (cb *chaosBackend) GetActivityTask(ctx context.Context) (*task.Activity, error) {
+	return cb.getActivityTask(ctx, cb.Backend.GetActivityTask)
+}
+
+func (cb *chaosBackend) GetActivityTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Activity, error) {
+	qp, ok := cb.Backend.(backend.QueuePoller)
+	if !ok {
+		return nil, backend.ErrQueuesNotSupported
+	}
+
+	return cb.getActivityTask(ctx, func(ctx context.Context) (*task.Activity, error) {
+		return qp.GetActivityTaskFromQueues(ctx, queues)
+	})
+}
+
+func (cb *chaosBackend) getActivityTask(ctx context.Context, get func(ctx context.Context) (*task.Activity, error)) (*task.Activity, error) {
+	cb.delay(ctx)
+
+	if t := cb.nextRedelivery(); t != nil {
+		return t, nil
+	}
+
+	if cb.chance(cb.options.ErrorRate) {
+		return nil, ErrInjected
+	}
+
+	t, err := get(ctx)
+	if err != nil || t == nil {
+		return t, err
+	}
+
+	if cb.chance(cb.options.DuplicateActivityTaskRate) {
+		cb.scheduleRedelivery(t, 0)
+	} else if cb.chance(cb.options.LockExpirationRate) {
+		cb.scheduleRedelivery(t, cb.options.LockExpirationDelay)
+	}
+
+	return t, nil
+}
+
+func (cb *chaosBackend) ExtendActivityTask(ctx context.Context, activityID string) error {
+	cb.delay(ctx)
+
+	if cb.completed(activityID) {
+		// Another delivery of this task has already been completed, there is no lock left to extend.
+		return nil
+	}
+
+	return cb.Backend.ExtendActivityTask(ctx, activityID)
+}
+
+func (cb *chaosBackend) CompleteActivityTask(ctx context.Context, instance *workflow.Instance, activityID string, event *history.Event) error {
+	cb.delay(ctx)
+
+	return cb.completeOnce(activityID, func() error {
+		return cb.Backend.CompleteActivityTask(ctx, instance, activityID, event)
+	})
+}
+
+// completeOnce calls complete for the given activity task, unless another delivery of the task has already been
+// completed
+func (cb *chaosBackend) completeOnce(activityID string, complete func() error) error {
+	cb.mu.Lock()
+	_, redelivered := cb.redelivered[activityID]
+	cb.mu.Unlock()
+
+	if !redelivered {
+		return complete()
+	}
+
+	cb.completeMu.Lock()
+	defer cb.completeMu.Unlock()
+
+	if cb.completed(activityID) {
+		cb.Logger().Debug("chaos: dropping completion of duplicate activity task", log.ActivityIDKey, activityID)
+		cb.finishDelivery(activityID, false)
+		return nil
+	}
+
+	if err := complete(); err != nil {
+		return err
+	}
+
+	cb.finishDelivery(activityID, true)
+
+	return nil
+}
+
+// completed returns true if one of the deliveries of the given redelivered activity task has been completed
+func (cb *chaosBackend) completed(activityID string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	state, ok := cb.redelivered[activityID]
+	return ok && state.completed
+}
+
+// finishDelivery records that a delivery of the given activity task has been completed or released. Once all of
+// its deliveries are finished, the task is no longer tracked.
+func (cb *chaosBackend) finishDelivery(activityID string, completed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	state, ok := cb.redelivered[activityID]
+	if !ok {
+		return
+	}
+
+	state.completed = state.completed || completed
+	state.deliveries--
+	if state.deliveries <= 0 {
+		delete(cb.redelivered, activityID)
+	}
+}
+
+// SetActivityTaskPending only sets the first delivery of a redelivered activity task to pending, like completions
+func (cb *chaosBackend) SetActivityTaskPending(ctx context.Context, instance *workflow.Instance, activityID string) error {
+	ac, ok := cb.Backend.(backend.AsyncActivityCompleter)
+	if !ok {
+		return backend.ErrAsyncActivitiesNotSupported
+	}
+
+	cb.delay(ctx)
+
+	return cb.completeOnce(activityID, func() error {
+		return ac.SetActivityTaskPending(ctx, instance, activityID)
+	})
+}
+
+func (cb *chaosBackend) ReleaseActivityTask(ctx context.Context, activityID string) error {
+	// The released delivery won't be completed
+	defer cb.finishDelivery(activityID, false)
+
+	return cb.Base.ReleaseActivityTask(ctx, activityID)
+}
+
+func (cb *chaosBackend) scheduleRedelivery(t *task.Activity, delay time.Duration) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.redeliveries = append(cb.redeliveries, redelivery{
+		task: t,
+		at:   time.Now().Add(delay),
+	})
+
+	// Track the delivery of t and the one scheduled here
+	state, ok := cb.redelivered[t.ID]
+	if !ok {
+		state = &redeliveryState{}
+		cb.redelivered[t.ID] = state
+	}
+
+	state.deliveries += 2
+}
+
+func (cb *chaosBackend) nextRedelivery() *task.Activity {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	for i, r := range cb.redeliveries {
+		if r.at.After(now) {
+			continue
+		}
+
+		cb.redeliveries = append(cb.redeliveries[:i], cb.redeliveries[i+1:]...)
+
+		t := *r.task
+		t.Redelivered = true
+		return &t
+	}
+
+	return nil
+}
+
+func (cb *chaosBackend) chance(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.r.Float64() < rate
+}
+
+func (cb *chaosBackend) delay(ctx context.Context) {
+	if cb.options.MaxLatency <= 0 {
+		return
+	}
+
+	cb.mu.Lock()
+	d := time.Duration(cb.r.Int63n(int64(cb.options.MaxLatency)))
+	cb.mu.Unlock()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
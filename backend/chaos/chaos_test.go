@@ -0,0 +1,151 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func runWorkflow(t *testing.T, b backend.Backend, wf interface{}, activities ...interface{}) int {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := worker.New(b, nil)
+	require.NoError(t, w.RegisterWorkflow(wf))
+	for _, a := range activities {
+		require.NoError(t, w.RegisterActivity(a))
+	}
+	require.NoError(t, w.Start(ctx))
+
+	c := client.New(b)
+	instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: uuid.NewString(),
+	}, wf)
+	require.NoError(t, err)
+
+	r, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*20)
+	require.NoError(t, err)
+
+	return r
+}
+
+func Test_ChaosBackend_LatencyAndTransientErrors(t *testing.T) {
+	a := func(ctx context.Context, i int) (int, error) {
+		return i + 1, nil
+	}
+
+	wf := func(ctx workflow.Context) (int, error) {
+		r := 0
+		for i := 0; i < 5; i++ {
+			var err error
+			r, err = workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, a, r).Get(ctx)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		return r, nil
+	}
+
+	b := NewBackend(
+		sqlite.NewInMemoryBackend(backend.WithStickyTimeout(0)),
+		WithSeed(1), WithLatency(time.Millisecond*5), WithErrorRate(0.5),
+	)
+
+	require.Equal(t, 5, runWorkflow(t, b, wf, a))
+}
+
+func Test_ChaosBackend_DuplicateActivityTasks(t *testing.T) {
+	var executions int32
+
+	a := func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&executions, 1)
+		return 42, nil
+	}
+
+	wf := func(ctx workflow.Context) (int, error) {
+		return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, a).Get(ctx)
+	}
+
+	b := NewBackend(sqlite.NewInMemoryBackend(), WithDuplicateActivityTasks(1))
+
+	require.Equal(t, 42, runWorkflow(t, b, wf, a))
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&executions) == 2
+	}, time.Second*5, time.Millisecond*10)
+
+	// Tasks are no longer tracked once both deliveries finished
+	cb := b.(*chaosBackend)
+	require.Eventually(t, func() bool {
+		cb.mu.Lock()
+		defer cb.mu.Unlock()
+
+		return len(cb.redelivered) == 0
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func Test_ChaosBackend_LockExpirations(t *testing.T) {
+	var executions int32
+
+	a := func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&executions, 1)
+		time.Sleep(time.Millisecond * 200)
+		return 42, nil
+	}
+
+	wf := func(ctx workflow.Context) (int, error) {
+		return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, a).Get(ctx)
+	}
+
+	b := NewBackend(sqlite.NewInMemoryBackend(), WithLockExpirations(1, time.Millisecond*50))
+
+	require.Equal(t, 42, runWorkflow(t, b, wf, a))
+	require.Equal(t, int32(2), atomic.LoadInt32(&executions))
+
+	cb := b.(*chaosBackend)
+	require.Eventually(t, func() bool {
+		cb.mu.Lock()
+		defer cb.mu.Unlock()
+
+		return len(cb.redelivered) == 0
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func Test_ChaosBackend_AtMostOnceActivities(t *testing.T) {
+	var executions int32
+
+	a := func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&executions, 1)
+		time.Sleep(time.Millisecond * 200)
+		return 42, nil
+	}
+
+	wf := func(ctx workflow.Context) (int, error) {
+		_, err := workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+			RetryOptions: workflow.DefaultRetryOptions,
+			AtMostOnce:   true,
+		}, a).Get(ctx)
+		if errors.Is(err, workflow.ErrActivityOutcomeUnknown) {
+			return -1, nil
+		}
+
+		return 0, err
+	}
+
+	b := NewBackend(sqlite.NewInMemoryBackend(), WithDuplicateActivityTasks(1))
+
+	// The duplicate delivery is not executed and completes first
+	require.Equal(t, -1, runWorkflow(t, b, wf, a))
+	require.Equal(t, int32(1), atomic.LoadInt32(&executions))
+}
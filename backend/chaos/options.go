@@ -0,0 +1,71 @@
+package chaos
+
+import (
+	"time"
+)
+
+type Options struct {
+	// Seed is the seed for the random number generator used to decide when to inject faults.
+	Seed int64
+
+	// MaxLatency is the maximum artificial latency added to every backend call.
+	MaxLatency time.Duration
+
+	// ErrorRate is the probability (0..1) with which polling for workflow or activity tasks fails with a
+	// transient error.
+	ErrorRate float64
+
+	// DuplicateActivityTaskRate is the probability (0..1) with which an activity task is delivered a second
+	// time, while the first delivery is still being processed.
+	DuplicateActivityTaskRate float64
+
+	// LockExpirationRate is the probability (0..1) with which the lock of an activity task is considered
+	// expired after LockExpirationDelay, and the task is delivered again.
+	LockExpirationRate float64
+
+	// LockExpirationDelay is the time after which an activity task is delivered again when its lock expires.
+	LockExpirationDelay time.Duration
+}
+
+var DefaultOptions = Options{
+	LockExpirationDelay: time.Millisecond * 100,
+}
+
+type Option func(*Options)
+
+// WithSeed sets the seed for the random number generator. Use this to reproduce a specific sequence of faults.
+func WithSeed(seed int64) Option {
+	return func(o *Options) {
+		o.Seed = seed
+	}
+}
+
+// WithLatency adds a random latency between 0 and max to every backend call.
+func WithLatency(max time.Duration) Option {
+	return func(o *Options) {
+		o.MaxLatency = max
+	}
+}
+
+// WithErrorRate sets the probability with which polling for tasks returns a transient error.
+func WithErrorRate(rate float64) Option {
+	return func(o *Options) {
+		o.ErrorRate = rate
+	}
+}
+
+// WithDuplicateActivityTasks sets the probability with which activity tasks are delivered twice.
+func WithDuplicateActivityTasks(rate float64) Option {
+	return func(o *Options) {
+		o.DuplicateActivityTaskRate = rate
+	}
+}
+
+// WithLockExpirations sets the probability with which the lock of an activity task expires after delay, causing
+// the task to be delivered again.
+func WithLockExpirations(rate float64, delay time.Duration) Option {
+	return func(o *Options) {
+		o.LockExpirationRate = rate
+		o.LockExpirationDelay = delay
+	}
+}
@@ -0,0 +1,135 @@
+// Package events provides a change feed of workflow history: hooks that publish every event committed to the history
+// of a workflow instance to a sink, for example, a Kafka topic, a NATS subject, or a webhook. Downstream systems can
+// use the feed to build read models or audit logs. Records are buffered in memory and published in the background, in
+// the order they were committed.
+package events
+
+import (
+	"context"
+
+	"github.com/cschleiden/go-workflows/backend/hooks"
+	"github.com/cschleiden/go-workflows/internal/buffer"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/logger"
+	"github.com/cschleiden/go-workflows/internal/metrickeys"
+	"github.com/cschleiden/go-workflows/internal/metrics"
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// Record is an event committed to the history of a workflow instance
+type Record struct {
+	// Sequence numbers the records of a feed in the order they were committed, starting at 1
+	Sequence uint64 `json:"sequence"`
+
+	InstanceID  string `json:"instance_id"`
+	ExecutionID string `json:"execution_id"`
+
+	// Type is the name of the type of the event, for example, "WorkflowExecutionStarted"
+	Type string `json:"type"`
+
+	Event *history.Event `json:"event"`
+}
+
+// Sink receives the records of a feed
+type Sink interface {
+	// Publish delivers the given records, in order. If it returns an error, the same records are passed again after
+	// the retry interval, downstream consumers need to handle duplicates.
+	Publish(ctx context.Context, records []Record) error
+}
+
+// SinkFunc adapts a function to the Sink interface
+type SinkFunc func(ctx context.Context, records []Record) error
+
+func (f SinkFunc) Publish(ctx context.Context, records []Record) error {
+	return f(ctx, records)
+}
+
+// Feed publishes the events committed to workflow histories to a sink
+type Feed struct {
+	hooks.NoopHooks
+
+	options Options
+
+	records *buffer.Buffer[Record]
+}
+
+var _ hooks.Hooks = (*Feed)(nil)
+var _ hooks.HistoryHooks = (*Feed)(nil)
+
+// New returns a feed publishing history events to the given sink. Pass it to hooks.NewBackend and call Start to begin
+// publishing:
+//
+//	feed := events.New(sink)
+//	b := hooks.NewBackend(sqlite.NewSqliteBackend("workflows.sqlite"), feed)
+//	feed.Start(ctx)
+func New(sink Sink, opts ...Option) *Feed {
+	options := DefaultOptions
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.Logger == nil {
+		options.Logger = logger.NewDefaultLogger()
+	}
+
+	if options.Metrics == nil {
+		options.Metrics = metrics.NewNoopMetricsClient()
+	}
+
+	return &Feed{
+		options: options,
+		records: buffer.New(sink.Publish, buffer.Options{
+			Size:          options.BufferSize,
+			BatchSize:     options.BatchSize,
+			Timeout:       options.BufferTimeout,
+			RetryInterval: options.RetryInterval,
+			Logger:        options.Logger,
+			Message:       "publishing history events",
+		}),
+	}
+}
+
+// Start starts publishing records in the background. To stop, cancel the context passed to Start. Records not
+// published at that point are lost, call Flush before to publish them.
+func (f *Feed) Start(ctx context.Context) error {
+	f.records.Start(ctx)
+
+	return nil
+}
+
+func (f *Feed) WaitForCompletion() error {
+	f.records.WaitForCompletion()
+
+	return nil
+}
+
+// Lag returns the number of records committed but not yet published
+func (f *Feed) Lag() uint64 {
+	return f.records.Lag()
+}
+
+// Flush waits until all records committed so far have been published
+func (f *Feed) Flush(ctx context.Context) error {
+	return f.records.Flush(ctx)
+}
+
+func (f *Feed) OnHistoryCommitted(ctx context.Context, instance *workflow.Instance, events []*history.Event) {
+	// The events have already been committed, drop them if the sink doesn't catch up in time
+	if err := f.records.Reserve(ctx, len(events)); err != nil {
+		f.options.Logger.Error("dropping history events", log.InstanceIDKey, instance.InstanceID, log.ErrorKey, err, "records", len(events))
+		f.options.Metrics.Counter(metrickeys.HistoryEventsDropped, nil, int64(len(events)))
+		return
+	}
+
+	f.records.Add(len(events), func(i int, sequence uint64) Record {
+		return Record{
+			Sequence:    sequence,
+			InstanceID:  instance.InstanceID,
+			ExecutionID: instance.ExecutionID,
+			Type:        events[i].Type.String(),
+			Event:       events[i],
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend/hooks"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/backend/test"
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func feedActivity(ctx context.Context) (int, error) {
+	return 42, nil
+}
+
+func feedWorkflow(ctx workflow.Context) (int, error) {
+	return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, feedActivity).Get(ctx)
+}
+
+func Test_Feed_PublishesHistory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sink := &test.Recorder[Record]{Failures: 1}
+	feed := New(SinkFunc(sink.Publish), WithRetryInterval(time.Millisecond))
+	require.NoError(t, feed.Start(ctx))
+
+	b := hooks.NewBackend(sqlite.NewInMemoryBackend(), feed)
+
+	w := worker.New(b, nil)
+	require.NoError(t, w.RegisterWorkflow(feedWorkflow))
+	require.NoError(t, w.RegisterActivity(feedActivity))
+	require.NoError(t, w.Start(ctx))
+
+	c := client.New(b)
+
+	instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: uuid.NewString(),
+	}, feedWorkflow)
+	require.NoError(t, err)
+
+	_, err = client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+	require.NoError(t, err)
+
+	flushCtx, flushCancel := context.WithTimeout(ctx, time.Second*10)
+	defer flushCancel()
+	require.NoError(t, feed.Flush(flushCtx))
+	require.Zero(t, feed.Lag())
+
+	h, err := b.GetWorkflowInstanceHistory(ctx, instance, nil)
+	require.NoError(t, err)
+
+	records := sink.Items()
+	require.Len(t, records, len(h))
+
+	for i, r := range records {
+		require.Equal(t, uint64(i+1), r.Sequence)
+		require.Equal(t, instance.InstanceID, r.InstanceID)
+		require.Equal(t, instance.ExecutionID, r.ExecutionID)
+		require.Equal(t, h[i].ID, r.Event.ID)
+		require.Equal(t, h[i].Type.String(), r.Type)
+	}
+
+	require.Equal(t, "WorkflowExecutionStarted", records[1].Type)
+	require.Equal(t, "WorkflowExecutionFinished", records[len(records)-1].Type)
+}
+
+func Test_Feed_DropsRecordsWhenBufferIsFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sink := &test.Recorder[Record]{}
+	feed := New(SinkFunc(sink.Publish), WithBufferSize(2), WithBufferTimeout(time.Millisecond*10))
+
+	instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+	event := func() *history.Event {
+		return history.NewPendingEvent(time.Now(), history.EventType_WorkflowExecutionCanceled, &history.ExecutionCanceledAttributes{})
+	}
+
+	// The feed isn't started yet, the second call doesn't fit into the buffer and doesn't block
+	feed.OnHistoryCommitted(ctx, instance, []*history.Event{event(), event()})
+	feed.OnHistoryCommitted(ctx, instance, []*history.Event{event()})
+	require.Equal(t, uint64(2), feed.Lag())
+
+	require.NoError(t, feed.Start(ctx))
+	require.NoError(t, feed.Flush(ctx))
+	require.Len(t, sink.Items(), 2)
+
+	// Space is available again once records have been published
+	feed.OnHistoryCommitted(ctx, instance, []*history.Event{event()})
+	require.NoError(t, feed.Flush(ctx))
+	require.Len(t, sink.Items(), 3)
+}
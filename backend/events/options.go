@@ -0,0 +1,83 @@
+package events
+
+import (
+	"time"
+
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/cschleiden/go-workflows/metrics"
+)
+
+type Options struct {
+	// BufferSize is the maximum number of records committed but not yet published. When the buffer is full, completing
+	// workflow tasks waits up to BufferTimeout for records to be published. Defaults to 1000.
+	BufferSize int
+
+	// BufferTimeout is the maximum time completing a workflow task waits for space in a full buffer. Afterwards, the
+	// records of the task are dropped, logged, and counted in the workflows.events.dropped metric. Defaults to five
+	// seconds.
+	BufferTimeout time.Duration
+
+	// BatchSize is the maximum number of records passed to the sink at once. Defaults to 100.
+	BatchSize int
+
+	// RetryInterval is the time to wait before passing records to the sink again after it returned an error. Defaults
+	// to one second.
+	RetryInterval time.Duration
+
+	// Logger is used to log failures to publish records. Defaults to the default logger.
+	Logger log.Logger
+
+	// Metrics records the number of dropped records. Defaults to a client discarding metrics.
+	Metrics metrics.Client
+}
+
+var DefaultOptions = Options{
+	BufferSize:    1000,
+	BufferTimeout: time.Second * 5,
+	BatchSize:     100,
+	RetryInterval: time.Second,
+}
+
+type Option func(*Options)
+
+// WithBufferSize sets the maximum number of records waiting to be published. See Options.BufferSize.
+func WithBufferSize(size int) Option {
+	return func(o *Options) {
+		o.BufferSize = size
+	}
+}
+
+// WithBufferTimeout sets the maximum time to wait for space in a full buffer. See Options.BufferTimeout.
+func WithBufferTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.BufferTimeout = timeout
+	}
+}
+
+// WithBatchSize sets the maximum number of records passed to the sink at once
+func WithBatchSize(size int) Option {
+	return func(o *Options) {
+		o.BatchSize = size
+	}
+}
+
+// WithRetryInterval sets the time to wait before retrying to publish records after a failure
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.RetryInterval = interval
+	}
+}
+
+// WithLogger sets the logger used to log failures to publish records
+func WithLogger(logger log.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithMetrics sets the client used to record the number of dropped records
+func WithMetrics(client metrics.Client) Option {
+	return func(o *Options) {
+		o.Metrics = client
+	}
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/cschleiden/go-workflows/internal/webhook"
+)
+
+type webhookSink struct {
+	poster *webhook.Poster
+}
+
+var _ Sink = (*webhookSink)(nil)
+
+type WebhookOption func(*webhookSink)
+
+// WithHTTPClient sets the client used to call the webhook. Defaults to http.DefaultClient.
+func WithHTTPClient(client *http.Client) WebhookOption {
+	return func(s *webhookSink) {
+		s.poster.Client = client
+	}
+}
+
+// WithHeader adds a header to requests to the webhook, for example, for authentication
+func WithHeader(key, value string) WebhookOption {
+	return func(s *webhookSink) {
+		s.poster.Headers.Add(key, value)
+	}
+}
+
+// NewWebhookSink returns a sink POSTing each batch of records as a JSON array to the given URL. Responses with a
+// status code other than 2xx are considered failures and retried.
+func NewWebhookSink(url string, opts ...WebhookOption) Sink {
+	s := &webhookSink{
+		poster: webhook.New(url),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *webhookSink) Publish(ctx context.Context, records []Record) error {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(records); err != nil {
+		return fmt.Errorf("encoding records: %w", err)
+	}
+
+	return s.poster.Post(ctx, &body)
+}
@@ -0,0 +1,42 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WebhookSink(t *testing.T) {
+	var got []Record
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer srv.Close()
+
+	s := NewWebhookSink(srv.URL, WithHeader("Authorization", "Bearer token"))
+
+	records := []Record{
+		{Sequence: 1, InstanceID: "instance", ExecutionID: "execution", Type: "WorkflowExecutionStarted"},
+		{Sequence: 2, InstanceID: "instance", ExecutionID: "execution", Type: "WorkflowExecutionFinished"},
+	}
+	require.NoError(t, s.Publish(context.Background(), records))
+	require.Equal(t, records, got)
+}
+
+func Test_WebhookSink_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	s := NewWebhookSink(srv.URL)
+
+	require.ErrorContains(t, s.Publish(context.Background(), []Record{{Sequence: 1}}), "503")
+}
@@ -0,0 +1,16 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/cschleiden/go-workflows/internal/payload"
+)
+
+// ActivityHeartbeater is implemented by backends that persist heartbeats recorded by activities. When the task of an
+// activity is delivered again, for example, because its worker crashed, the task carries the time and details of
+// the last heartbeat so the worker can detect activities whose heartbeats stopped.
+type ActivityHeartbeater interface {
+	// RecordActivityHeartbeat records a heartbeat with the given details for the activity task locked by this worker
+	// and extends the lock of the task.
+	RecordActivityHeartbeat(ctx context.Context, activityID string, details payload.Payload) error
+}
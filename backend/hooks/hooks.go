@@ -0,0 +1,375 @@
+// Package hooks provides a backend decorator that calls hooks for lifecycle events of workflow instances and tasks.
+// Use it to attach cross-cutting concerns like custom metrics or emitting domain events to any backend.
+package hooks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/decorator"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// Hooks are called after the corresponding backend operation succeeded. Hooks are called synchronously on the
+// goroutine calling the backend, long-running work should be moved to a separate goroutine.
+type Hooks interface {
+	// OnInstanceCreated is called after a workflow instance has been created
+	OnInstanceCreated(ctx context.Context, instance *workflow.Instance, workflowName string)
+
+	// OnTaskLocked is called after a worker has locked a workflow or activity task
+	OnTaskLocked(ctx context.Context, t Task)
+
+	// OnTaskCompleted is called after a worker has completed a workflow or activity task
+	OnTaskCompleted(ctx context.Context, t Task)
+
+	// OnInstanceFinished is called after an execution of a workflow instance has finished. continuedAsNew is true if
+	// the instance continues with a new execution.
+	OnInstanceFinished(ctx context.Context, instance *workflow.Instance, continuedAsNew bool)
+}
+
+// OutcomeHooks can be implemented in addition to Hooks to learn how an execution of a workflow instance finished
+type OutcomeHooks interface {
+	// OnInstanceOutcome is called after OnInstanceFinished with the outcome of the execution. err is the error the
+	// execution failed with, if any.
+	OnInstanceOutcome(ctx context.Context, instance *workflow.Instance, outcome Outcome, err error)
+}
+
+// HistoryHooks can be implemented in addition to Hooks to receive the events committed to the history of workflow
+// instances
+type HistoryHooks interface {
+	// OnHistoryCommitted is called after OnTaskCompleted with the events a workflow task added to the history of the
+	// instance, in order
+	OnHistoryCommitted(ctx context.Context, instance *workflow.Instance, events []*history.Event)
+}
+
+// AdmissionHooks can be implemented in addition to Hooks to admit workflow instances and tasks before the backend
+// creates or dispatches them
+type AdmissionHooks interface {
+	// AdmitInstance is called before a workflow instance is created, by a client or by a workflow task scheduling a
+	// sub-workflow or continuing as new. creator is the instance of the workflow task, nil for instances created by
+	// clients. event is the WorkflowExecutionStarted event of the instance, its attributes can be modified, for
+	// example to add search attributes. The returned context is passed to the backend creating instances for clients,
+	// for example, with limits it enforces in the same transaction. Returning an error rejects instances created by
+	// clients, CreateWorkflowInstance returns the error. Instances created by workflow tasks are always created, the
+	// returned context and errors are ignored.
+	AdmitInstance(
+		ctx context.Context, instance *workflow.Instance, event *history.Event, creator *workflow.Instance,
+	) (context.Context, error)
+
+	// AdmitTask is called after a worker has locked a workflow or activity task, before OnTaskLocked. It can block
+	// until the task may be passed to the worker, the task stays locked meanwhile, so the wait needs to be well below
+	// the lock timeout. Returning an error fails locking the task, it is delivered again once its lock expires.
+	AdmitTask(ctx context.Context, t Task) error
+}
+
+// Outcome describes how an execution of a workflow instance finished
+type Outcome int
+
+const (
+	OutcomeCompleted Outcome = iota
+	OutcomeFailed
+	OutcomeCanceled
+	OutcomeContinuedAsNew
+	OutcomeTerminated
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeCompleted:
+		return "completed"
+	case OutcomeFailed:
+		return "failed"
+	case OutcomeCanceled:
+		return "canceled"
+	case OutcomeContinuedAsNew:
+		return "continued_as_new"
+	case OutcomeTerminated:
+		return "terminated"
+	default:
+		return "unknown"
+	}
+}
+
+type TaskType int
+
+const (
+	TaskTypeWorkflow TaskType = iota
+	TaskTypeActivity
+)
+
+func (tt TaskType) String() string {
+	switch tt {
+	case TaskTypeWorkflow:
+		return "workflow"
+	case TaskTypeActivity:
+		return "activity"
+	default:
+		return "unknown"
+	}
+}
+
+// Task describes a workflow or activity task passed to hooks
+type Task struct {
+	Type TaskType
+
+	// ID of the task. For activity tasks, this is the ID of the activity.
+	ID string
+
+	// Instance is the workflow instance the task belongs to
+	Instance *workflow.Instance
+
+	// Metadata propagated to the task, for example by context propagators. Only set for locked tasks.
+	Metadata *workflow.Metadata
+}
+
+// NoopHooks implements all hooks without doing anything. Embed it to implement only some of the hooks.
+type NoopHooks struct{}
+
+var _ Hooks = NoopHooks{}
+
+func (NoopHooks) OnInstanceCreated(ctx context.Context, instance *workflow.Instance, workflowName string) {
+}
+
+func (NoopHooks) OnTaskLocked(ctx context.Context, t Task) {
+}
+
+func (NoopHooks) OnTaskCompleted(ctx context.Context, t Task) {
+}
+
+func (NoopHooks) OnInstanceFinished(ctx context.Context, instance *workflow.Instance, continuedAsNew bool) {
+}
+
+type hooksBackend struct {
+	decorator.Base
+
+	hooks []Hooks
+}
+
+var _ backend.Backend = (*hooksBackend)(nil)
+var _ backend.RateLimiter = (*hooksBackend)(nil)
+var _ backend.Leaser = (*hooksBackend)(nil)
+var _ backend.LoadReporter = (*hooksBackend)(nil)
+var _ backend.Querier = (*hooksBackend)(nil)
+var _ backend.ScheduleStore = (*hooksBackend)(nil)
+var _ backend.AsyncActivityCompleter = (*hooksBackend)(nil)
+var _ backend.ActivityHeartbeater = (*hooksBackend)(nil)
+var _ backend.ActivityTaskReleaser = (*hooksBackend)(nil)
+var _ backend.QueuePoller = (*hooksBackend)(nil)
+var _ backend.Retainer = (*hooksBackend)(nil)
+var _ backend.IDGenerator = (*hooksBackend)(nil)
+var _ backend.InstancePurger = (*hooksBackend)(nil)
+var _ backend.ArchiveRetrier = (*hooksBackend)(nil)
+
+// NewBackend wraps the given backend and calls the given hooks, in order, for lifecycle events of workflow instances
+// and tasks.
+func NewBackend(b backend.Backend, hooks ...Hooks) backend.Backend {
+	return &hooksBackend{
+		Base:  decorator.Base{Backend: b},
+		hooks: hooks,
+	}
+}
+
+func (hb *hooksBackend) CreateWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
+	for _, h := range hb.hooks {
+		if ah, ok := h.(AdmissionHooks); ok {
+			var err error
+			if ctx, err = ah.AdmitInstance(ctx, instance, event, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := hb.Backend.CreateWorkflowInstance(ctx, instance, event); err != nil {
+		return err
+	}
+
+	var workflowName string
+	if a, ok := event.Attributes.(*history.ExecutionStartedAttributes); ok {
+		workflowName = a.Name
+	}
+
+	for _, h := range hb.hooks {
+		h.OnInstanceCreated(ctx, instance, workflowName)
+	}
+
+	return nil
+}
+
+func (hb *hooksBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, error) {
+	t, err := hb.Backend.GetWorkflowTask(ctx)
+	return hb.workflowTaskLocked(ctx, t, err)
+}
+
+func (hb *hooksBackend) GetWorkflowTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Workflow, error) {
+	t, err := hb.Base.GetWorkflowTaskFromQueues(ctx, queues)
+	return hb.workflowTaskLocked(ctx, t, err)
+}
+
+func (hb *hooksBackend) workflowTaskLocked(ctx context.Context, t *task.Workflow, err error) (*task.Workflow, error) {
+	if err != nil || t == nil {
+		return t, err
+	}
+
+	lt := Task{Type: TaskTypeWorkflow, ID: t.ID, Instance: t.WorkflowInstance, Metadata: t.Metadata}
+
+	if err := hb.admitTask(ctx, lt); err != nil {
+		return nil, err
+	}
+
+	for _, h := range hb.hooks {
+		h.OnTaskLocked(ctx, lt)
+	}
+
+	return t, nil
+}
+
+func (hb *hooksBackend) CompleteWorkflowTask(
+	ctx context.Context, t *task.Workflow, instance *workflow.Instance, state core.WorkflowInstanceState,
+	executedEvents, activityEvents, timerEvents []*history.Event, workflowEvents []history.WorkflowEvent,
+) error {
+	for _, we := range workflowEvents {
+		if we.HistoryEvent.Type != history.EventType_WorkflowExecutionStarted {
+			continue
+		}
+
+		for _, h := range hb.hooks {
+			if ah, ok := h.(AdmissionHooks); ok {
+				// Sub-workflows and continued executions can't be rejected anymore
+				_, _ = ah.AdmitInstance(ctx, we.WorkflowInstance, we.HistoryEvent, instance)
+			}
+		}
+	}
+
+	if err := hb.Backend.CompleteWorkflowTask(ctx, t, instance, state, executedEvents, activityEvents, timerEvents, workflowEvents); err != nil {
+		return err
+	}
+
+	for _, h := range hb.hooks {
+		h.OnTaskCompleted(ctx, Task{Type: TaskTypeWorkflow, ID: t.ID, Instance: instance})
+	}
+
+	if len(executedEvents) > 0 {
+		for _, h := range hb.hooks {
+			if hh, ok := h.(HistoryHooks); ok {
+				hh.OnHistoryCommitted(ctx, instance, executedEvents)
+			}
+		}
+	}
+
+	if state != core.WorkflowInstanceStateActive {
+		for _, h := range hb.hooks {
+			h.OnInstanceFinished(ctx, instance, state == core.WorkflowInstanceStateContinuedAsNew)
+		}
+
+		outcome, err := executionOutcome(state, executedEvents)
+		for _, h := range hb.hooks {
+			if oh, ok := h.(OutcomeHooks); ok {
+				oh.OnInstanceOutcome(ctx, instance, outcome, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// executionOutcome determines the outcome of a finished execution from the events of its last workflow task
+func executionOutcome(state core.WorkflowInstanceState, executedEvents []*history.Event) (Outcome, error) {
+	if state == core.WorkflowInstanceStateContinuedAsNew {
+		return OutcomeContinuedAsNew, nil
+	}
+
+	for _, e := range executedEvents {
+		if e.Type == history.EventType_WorkflowExecutionTerminated {
+			return OutcomeTerminated, workflow.ErrTerminated
+		}
+
+		if e.Type != history.EventType_WorkflowExecutionFinished {
+			continue
+		}
+
+		a, ok := e.Attributes.(*history.ExecutionCompletedAttributes)
+		if !ok || a.Error == nil {
+			break
+		}
+
+		if errors.Is(a.Error, workflow.Canceled) {
+			return OutcomeCanceled, a.Error
+		}
+
+		return OutcomeFailed, a.Error
+	}
+
+	return OutcomeCompleted, nil
+}
+
+func (hb *hooksBackend) GetActivityTask(ctx context.Context) (*task.Activity, error) {
+	t, err := hb.Backend.GetActivityTask(ctx)
+	return hb.activityTaskLocked(ctx, t, err)
+}
+
+func (hb *hooksBackend) GetActivityTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Activity, error) {
+	t, err := hb.Base.GetActivityTaskFromQueues(ctx, queues)
+	return hb.activityTaskLocked(ctx, t, err)
+}
+
+func (hb *hooksBackend) activityTaskLocked(ctx context.Context, t *task.Activity, err error) (*task.Activity, error) {
+	if err != nil || t == nil {
+		return t, err
+	}
+
+	lt := Task{Type: TaskTypeActivity, ID: t.ID, Instance: t.WorkflowInstance}
+	if a, ok := t.Event.Attributes.(*history.ActivityScheduledAttributes); ok {
+		lt.Metadata = a.Metadata
+	}
+
+	if err := hb.admitTask(ctx, lt); err != nil {
+		return nil, err
+	}
+
+	for _, h := range hb.hooks {
+		h.OnTaskLocked(ctx, lt)
+	}
+
+	return t, nil
+}
+
+func (hb *hooksBackend) admitTask(ctx context.Context, t Task) error {
+	for _, h := range hb.hooks {
+		if ah, ok := h.(AdmissionHooks); ok {
+			if err := ah.AdmitTask(ctx, t); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (hb *hooksBackend) CompleteActivityTask(ctx context.Context, instance *workflow.Instance, activityID string, event *history.Event) error {
+	if err := hb.Backend.CompleteActivityTask(ctx, instance, activityID, event); err != nil {
+		return err
+	}
+
+	for _, h := range hb.hooks {
+		h.OnTaskCompleted(ctx, Task{Type: TaskTypeActivity, ID: activityID, Instance: instance})
+	}
+
+	return nil
+}
+
+// SetActivityTaskPending reports the activity task as completed, its worker is done with it
+func (hb *hooksBackend) SetActivityTaskPending(ctx context.Context, instance *workflow.Instance, activityID string) error {
+	if err := hb.Base.SetActivityTaskPending(ctx, instance, activityID); err != nil {
+		return err
+	}
+
+	for _, h := range hb.hooks {
+		h.OnTaskCompleted(ctx, Task{Type: TaskTypeActivity, ID: activityID, Instance: instance})
+	}
+
+	return nil
+}
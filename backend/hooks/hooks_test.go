@@ -0,0 +1,170 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHooks struct {
+	NoopHooks
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *recordingHooks) record(event string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.events = append(h.events, event)
+}
+
+func (h *recordingHooks) OnInstanceCreated(ctx context.Context, instance *workflow.Instance, workflowName string) {
+	h.record("created:" + workflowName)
+}
+
+func (h *recordingHooks) OnTaskLocked(ctx context.Context, t Task) {
+	h.record("locked:" + t.Type.String())
+}
+
+func (h *recordingHooks) OnTaskCompleted(ctx context.Context, t Task) {
+	h.record("completed:" + t.Type.String())
+}
+
+func (h *recordingHooks) OnInstanceFinished(ctx context.Context, instance *workflow.Instance, continuedAsNew bool) {
+	h.record("finished")
+}
+
+func hooksActivity(ctx context.Context) (int, error) {
+	return 42, nil
+}
+
+func hooksWorkflow(ctx workflow.Context) (int, error) {
+	return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, hooksActivity).Get(ctx)
+}
+
+func Test_HooksBackend(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := &recordingHooks{}
+	b := NewBackend(sqlite.NewInMemoryBackend(), h)
+
+	w := worker.New(b, nil)
+	require.NoError(t, w.RegisterWorkflow(hooksWorkflow))
+	require.NoError(t, w.RegisterActivity(hooksActivity))
+	require.NoError(t, w.Start(ctx))
+
+	c := client.New(b)
+	instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: uuid.NewString(),
+	}, hooksWorkflow)
+	require.NoError(t, err)
+
+	r, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+	require.NoError(t, err)
+	require.Equal(t, 42, r)
+
+	// Hooks are called after the backend operation, the instance might be reported as finished before they run
+	require.Eventually(t, func() bool {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		return len(h.events) == 8
+	}, time.Second, time.Millisecond*10)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	require.ElementsMatch(t, []string{
+		"created:hooksWorkflow",
+		"locked:workflow",
+		"completed:workflow",
+		"locked:activity",
+		"completed:activity",
+		"locked:workflow",
+		"completed:workflow",
+		"finished",
+	}, h.events)
+}
+
+type rejectingHooks struct {
+	recordingHooks
+}
+
+var errRejected = errors.New("rejected")
+
+func (h *rejectingHooks) AdmitInstance(
+	ctx context.Context, instance *workflow.Instance, event *history.Event, creator *workflow.Instance,
+) (context.Context, error) {
+	return ctx, errRejected
+}
+
+func (h *rejectingHooks) AdmitTask(ctx context.Context, t Task) error {
+	return nil
+}
+
+func Test_HooksBackend_AdmissionRejectsInstance(t *testing.T) {
+	ctx := context.Background()
+
+	h := &rejectingHooks{}
+	b := NewBackend(sqlite.NewInMemoryBackend(), h)
+
+	c := client.New(b)
+	_, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: uuid.NewString(),
+	}, hooksWorkflow)
+	require.ErrorIs(t, err, errRejected)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	require.Empty(t, h.events)
+}
+
+func Test_ExecutionOutcome(t *testing.T) {
+	finished := func(err error) []*history.Event {
+		return []*history.Event{
+			history.NewPendingEvent(time.Now(), history.EventType_WorkflowExecutionFinished, &history.ExecutionCompletedAttributes{
+				Error: workflowerrors.FromError(err),
+			}),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		state   core.WorkflowInstanceState
+		events  []*history.Event
+		outcome Outcome
+		wantErr bool
+	}{
+		{"completed", core.WorkflowInstanceStateFinished, finished(nil), OutcomeCompleted, false},
+		{"failed", core.WorkflowInstanceStateFinished, finished(errors.New("failed")), OutcomeFailed, true},
+		{"canceled", core.WorkflowInstanceStateFinished, finished(workflow.Canceled), OutcomeCanceled, true},
+		{"continued as new", core.WorkflowInstanceStateContinuedAsNew, nil, OutcomeContinuedAsNew, false},
+		{"terminated", core.WorkflowInstanceStateFinished, []*history.Event{
+			history.NewWorkflowTerminationEvent(time.Now(), "reason"),
+		}, OutcomeTerminated, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, err := executionOutcome(tt.state, tt.events)
+			require.Equal(t, tt.outcome, outcome)
+			require.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
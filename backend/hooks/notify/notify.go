@@ -0,0 +1,147 @@
+// Package notify publishes lifecycle events of workflow instances, like instances being started or completed, to
+// external systems. Events are published asynchronously and retried on failure. Use NewWebhookPublisher to POST
+// events to a webhook, or implement Publisher to publish to a message bus.
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend/hooks"
+	"github.com/cschleiden/go-workflows/internal/logger"
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+type EventType string
+
+const (
+	EventInstanceStarted    EventType = "instance.started"
+	EventInstanceCompleted  EventType = "instance.completed"
+	EventInstanceFailed     EventType = "instance.failed"
+	EventInstanceCanceled   EventType = "instance.canceled"
+	EventInstanceTerminated EventType = "instance.terminated"
+)
+
+// Event is a lifecycle event of a workflow instance
+type Event struct {
+	Type EventType `json:"type"`
+
+	InstanceID  string `json:"instance_id"`
+	ExecutionID string `json:"execution_id"`
+
+	// WorkflowName is the name of the workflow, only set for started events
+	WorkflowName string `json:"workflow_name,omitempty"`
+
+	// Error is the message of the error a failed or canceled instance finished with
+	Error string `json:"error,omitempty"`
+
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Publisher delivers events to an external system
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// PublisherFunc adapts a function to the Publisher interface
+type PublisherFunc func(ctx context.Context, event Event) error
+
+func (f PublisherFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+type notifier struct {
+	hooks.NoopHooks
+
+	publisher Publisher
+	options   Options
+}
+
+var _ hooks.Hooks = (*notifier)(nil)
+var _ hooks.OutcomeHooks = (*notifier)(nil)
+
+// New returns hooks publishing lifecycle events to the given publisher. Pass them to hooks.NewBackend:
+//
+//	b := hooks.NewBackend(sqlite.NewSqliteBackend("workflows.sqlite"), notify.New(publisher))
+func New(publisher Publisher, opts ...Option) hooks.Hooks {
+	options := DefaultOptions
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.Logger == nil {
+		options.Logger = logger.NewDefaultLogger()
+	}
+
+	return &notifier{
+		publisher: publisher,
+		options:   options,
+	}
+}
+
+func (n *notifier) OnInstanceCreated(ctx context.Context, instance *workflow.Instance, workflowName string) {
+	n.publish(Event{
+		Type:         EventInstanceStarted,
+		InstanceID:   instance.InstanceID,
+		ExecutionID:  instance.ExecutionID,
+		WorkflowName: workflowName,
+	})
+}
+
+func (n *notifier) OnInstanceOutcome(ctx context.Context, instance *workflow.Instance, outcome hooks.Outcome, err error) {
+	event := Event{
+		InstanceID:  instance.InstanceID,
+		ExecutionID: instance.ExecutionID,
+	}
+
+	switch outcome {
+	case hooks.OutcomeCompleted:
+		event.Type = EventInstanceCompleted
+	case hooks.OutcomeFailed:
+		event.Type = EventInstanceFailed
+	case hooks.OutcomeCanceled:
+		event.Type = EventInstanceCanceled
+	case hooks.OutcomeTerminated:
+		event.Type = EventInstanceTerminated
+	default:
+		// The instance continues with a new execution
+		return
+	}
+
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	n.publish(event)
+}
+
+func (n *notifier) publish(event Event) {
+	if !n.options.publishes(event.Type) {
+		return
+	}
+
+	event.Timestamp = time.Now()
+
+	// Hooks are called synchronously, publish in the background
+	go func() {
+		ctx := context.Background()
+
+		interval := n.options.FirstRetryInterval
+		for attempt := 1; ; attempt++ {
+			err := n.publisher.Publish(ctx, event)
+			if err == nil {
+				return
+			}
+
+			if attempt >= n.options.MaxAttempts {
+				n.options.Logger.Error("publishing lifecycle event", "event", event.Type, log.InstanceIDKey, event.InstanceID, log.ErrorKey, err)
+				return
+			}
+
+			time.Sleep(interval)
+			interval *= 2
+		}
+	}()
+}
@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend/hooks"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/backend/test"
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func notifyWorkflow(ctx workflow.Context, fail bool) error {
+	if fail {
+		return errors.New("workflow failed")
+	}
+
+	return nil
+}
+
+func Test_Notify_LifecycleEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := &test.Recorder[Event]{Failures: 1}
+	b := hooks.NewBackend(sqlite.NewInMemoryBackend(), New(PublisherFunc(p.PublishOne), WithRetries(3, time.Millisecond)))
+
+	w := worker.New(b, nil)
+	require.NoError(t, w.RegisterWorkflow(notifyWorkflow))
+	require.NoError(t, w.Start(ctx))
+
+	c := client.New(b)
+
+	completed, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: uuid.NewString(),
+	}, notifyWorkflow, false)
+	require.NoError(t, err)
+	require.NoError(t, c.WaitForWorkflowInstance(ctx, completed, time.Second*10))
+
+	failed, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: uuid.NewString(),
+	}, notifyWorkflow, true)
+	require.NoError(t, err)
+	require.NoError(t, c.WaitForWorkflowInstance(ctx, failed, time.Second*10))
+
+	require.Eventually(t, func() bool {
+		return len(p.Items()) == 4
+	}, time.Second, time.Millisecond*10)
+
+	types := map[string][]EventType{}
+	for _, e := range p.Items() {
+		types[e.InstanceID] = append(types[e.InstanceID], e.Type)
+
+		if e.Type == EventInstanceStarted {
+			require.Equal(t, "notifyWorkflow", e.WorkflowName)
+		}
+
+		if e.Type == EventInstanceFailed {
+			require.Equal(t, "workflow failed", e.Error)
+		}
+	}
+
+	require.ElementsMatch(t, []EventType{EventInstanceStarted, EventInstanceCompleted}, types[completed.InstanceID])
+	require.ElementsMatch(t, []EventType{EventInstanceStarted, EventInstanceFailed}, types[failed.InstanceID])
+}
+
+func Test_Notify_EventTypes(t *testing.T) {
+	p := &test.Recorder[Event]{}
+	n := New(PublisherFunc(p.PublishOne), WithEventTypes(EventInstanceFailed)).(*notifier)
+
+	instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+	n.OnInstanceCreated(context.Background(), instance, "wf")
+	n.OnInstanceOutcome(context.Background(), instance, hooks.OutcomeCompleted, nil)
+	n.OnInstanceOutcome(context.Background(), instance, hooks.OutcomeFailed, errors.New("failed"))
+
+	require.Eventually(t, func() bool {
+		return len(p.Items()) == 1
+	}, time.Second, time.Millisecond*10)
+
+	require.Equal(t, EventInstanceFailed, p.Items()[0].Type)
+}
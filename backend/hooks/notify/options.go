@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"time"
+
+	"github.com/cschleiden/go-workflows/log"
+)
+
+type Options struct {
+	// EventTypes are the types of events to publish. If empty, all events are published.
+	EventTypes []EventType
+
+	// MaxAttempts is the maximum number of attempts to publish an event, including the first one. Defaults to 5.
+	MaxAttempts int
+
+	// FirstRetryInterval is the time to wait before the first retry, it doubles with every retry. Defaults to one
+	// second.
+	FirstRetryInterval time.Duration
+
+	// Logger is used to log events that could not be published. Defaults to the default logger.
+	Logger log.Logger
+}
+
+var DefaultOptions = Options{
+	MaxAttempts:        5,
+	FirstRetryInterval: time.Second,
+}
+
+type Option func(*Options)
+
+// WithEventTypes only publishes events of the given types
+func WithEventTypes(types ...EventType) Option {
+	return func(o *Options) {
+		o.EventTypes = append(o.EventTypes, types...)
+	}
+}
+
+// WithRetries sets how often publishing an event is attempted, and the interval before the first retry
+func WithRetries(maxAttempts int, firstRetryInterval time.Duration) Option {
+	return func(o *Options) {
+		o.MaxAttempts = maxAttempts
+		o.FirstRetryInterval = firstRetryInterval
+	}
+}
+
+// WithLogger sets the logger used to log events that could not be published
+func WithLogger(logger log.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+func (o *Options) publishes(t EventType) bool {
+	if len(o.EventTypes) == 0 {
+		return true
+	}
+
+	for _, et := range o.EventTypes {
+		if et == t {
+			return true
+		}
+	}
+
+	return false
+}
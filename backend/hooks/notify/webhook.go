@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+
+	"github.com/cschleiden/go-workflows/internal/webhook"
+)
+
+type webhookPublisher struct {
+	poster   *webhook.Poster
+	template *template.Template
+}
+
+var _ Publisher = (*webhookPublisher)(nil)
+
+type WebhookOption func(*webhookPublisher)
+
+// WithHTTPClient sets the client used to call the webhook. Defaults to http.DefaultClient.
+func WithHTTPClient(client *http.Client) WebhookOption {
+	return func(p *webhookPublisher) {
+		p.poster.Client = client
+	}
+}
+
+// WithHeader adds a header to requests to the webhook, for example, for authentication
+func WithHeader(key, value string) WebhookOption {
+	return func(p *webhookPublisher) {
+		p.poster.Headers.Add(key, value)
+	}
+}
+
+// WithPayloadTemplate renders the request body with the given template, which is executed with the Event. By default
+// the event is sent as JSON.
+func WithPayloadTemplate(t *template.Template) WebhookOption {
+	return func(p *webhookPublisher) {
+		p.template = t
+	}
+}
+
+// NewWebhookPublisher returns a publisher POSTing events to the given URL. Responses with a status code other than
+// 2xx are considered failures and retried.
+func NewWebhookPublisher(url string, opts ...WebhookOption) Publisher {
+	p := &webhookPublisher{
+		poster: webhook.New(url),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+func (p *webhookPublisher) Publish(ctx context.Context, event Event) error {
+	var body bytes.Buffer
+	if p.template != nil {
+		if err := p.template.Execute(&body, event); err != nil {
+			return fmt.Errorf("rendering payload: %w", err)
+		}
+	} else if err := json.NewEncoder(&body).Encode(event); err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	return p.poster.Post(ctx, &body)
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WebhookPublisher(t *testing.T) {
+	var got Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer srv.Close()
+
+	p := NewWebhookPublisher(srv.URL, WithHeader("Authorization", "Bearer token"))
+
+	event := Event{
+		Type:        EventInstanceCompleted,
+		InstanceID:  "instance",
+		ExecutionID: "execution",
+		Timestamp:   time.Now().UTC().Truncate(time.Second),
+	}
+	require.NoError(t, p.Publish(context.Background(), event))
+	require.Equal(t, event, got)
+}
+
+func Test_WebhookPublisher_PayloadTemplate(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		body = string(b)
+	}))
+	defer srv.Close()
+
+	tmpl := template.Must(template.New("payload").Parse(`{"text":"{{.InstanceID}} {{.Type}}"}`))
+	p := NewWebhookPublisher(srv.URL, WithPayloadTemplate(tmpl))
+
+	require.NoError(t, p.Publish(context.Background(), Event{Type: EventInstanceFailed, InstanceID: "instance"}))
+	require.Equal(t, `{"text":"instance instance.failed"}`, body)
+}
+
+func Test_WebhookPublisher_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	p := NewWebhookPublisher(srv.URL)
+
+	require.ErrorContains(t, p.Publish(context.Background(), Event{Type: EventInstanceStarted}), "503")
+}
@@ -0,0 +1,20 @@
+package backend
+
+import "github.com/google/uuid"
+
+// IDGenerator is implemented by backends generating the identifiers of workflow instances and executions with the
+// generator configured with WithIDGenerator
+type IDGenerator interface {
+	// NewID returns a new identifier
+	NewID() string
+}
+
+// NewID returns a new identifier generated by the given backend. Backends that don't implement IDGenerator generate
+// UUIDs.
+func NewID(b Backend) string {
+	if g, ok := b.(IDGenerator); ok {
+		return g.NewID()
+	}
+
+	return uuid.NewString()
+}
@@ -0,0 +1,40 @@
+package backend
+
+import "context"
+
+// ActiveInstanceLimit limits the number of workflow instances of the namespace, or of one of its tenants, that
+// haven't finished yet
+type ActiveInstanceLimit struct {
+	// Tenant restricts the limit to the instances of the tenant, empty to limit all instances of the namespace
+	Tenant string
+
+	// TenantSearchAttribute is the string search attribute the tenant of an instance is recorded in
+	TenantSearchAttribute string
+
+	// Max is the maximum number of active instances
+	Max int
+}
+
+// Exceeded returns the error for creating an instance at the limit
+func (l ActiveInstanceLimit) Exceeded() error {
+	return &QuotaExceededError{Tenant: l.Tenant, MaxActiveInstances: l.Max}
+}
+
+type activeInstanceLimitsKey struct{}
+
+// WithActiveInstanceLimits returns a context that enforces the given limits when passed to CreateWorkflowInstance.
+// Backends check the limits in the transaction creating the instance, so that instances created concurrently can't
+// exceed them. Creating an instance at a limit fails with a QuotaExceededError.
+func WithActiveInstanceLimits(ctx context.Context, limits ...ActiveInstanceLimit) context.Context {
+	if len(limits) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, activeInstanceLimitsKey{}, append(ActiveInstanceLimits(ctx), limits...))
+}
+
+// ActiveInstanceLimits returns the limits attached to the context with WithActiveInstanceLimits
+func ActiveInstanceLimits(ctx context.Context) []ActiveInstanceLimit {
+	limits, _ := ctx.Value(activeInstanceLimitsKey{}).([]ActiveInstanceLimit)
+	return limits
+}
@@ -0,0 +1,21 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLeasesNotSupported is returned by backend decorators wrapping a backend that doesn't implement Leaser
+var ErrLeasesNotSupported = errors.New("backend does not support leases")
+
+// Leaser is implemented by backends that support leases. Leases are used to elect a single leader among processes
+// sharing the same storage and namespace, for example, to run maintenance jobs only once per deployment.
+type Leaser interface {
+	// AcquireLease acquires the lease with the given name for holder for the given duration, or extends it if holder
+	// already holds it. It returns false if the lease is held by a different holder.
+	AcquireLease(ctx context.Context, name, holder string, duration time.Duration) (bool, error)
+
+	// ReleaseLease releases the lease with the given name, if it's held by holder
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
@@ -0,0 +1,120 @@
+package backend
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+)
+
+// ErrInvalidPageToken is returned by ListWorkflowInstances if the page token is malformed
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// DefaultListPageSize is the number of instances returned by ListWorkflowInstances if no page size is given
+const DefaultListPageSize = 100
+
+type InstanceStateFilter int
+
+const (
+	// InstanceStateAll matches all workflow instances
+	InstanceStateAll InstanceStateFilter = iota
+
+	// InstanceStateActive matches workflow instances that have not finished yet
+	InstanceStateActive
+
+	// InstanceStateFinished matches workflow instances that have finished or continued as new
+	InstanceStateFinished
+
+	// InstanceStateCanceled matches workflow instances that have been requested to cancel, whether they have
+	// finished since or not
+	InstanceStateCanceled
+
+	// InstanceStateDeadLettered matches workflow instances in the dead-letter state, see
+	// Backend.DeadLetterWorkflowTask
+	InstanceStateDeadLettered
+)
+
+// ListWorkflowInstancesQuery filters workflow instances returned by ListWorkflowInstances
+type ListWorkflowInstancesQuery struct {
+	State InstanceStateFilter
+
+	// CreatedAfter, if set, only matches instances created at or after the given time
+	CreatedAfter time.Time
+
+	// CreatedBefore, if set, only matches instances created before the given time
+	CreatedBefore time.Time
+
+	// SearchAttributes, if set, only matches instances that have all of the given search attributes with equal type
+	// and value
+	SearchAttributes core.SearchAttributes
+
+	// PageSize is the maximum number of instances to return. Defaults to DefaultListPageSize.
+	PageSize int
+
+	// PageToken continues listing after the last instance of a previous page
+	PageToken string
+}
+
+type WorkflowInstanceInfo struct {
+	Instance     *core.WorkflowInstance
+	WorkflowName string
+	State        core.WorkflowInstanceState
+	CreatedAt    time.Time
+	CompletedAt  *time.Time
+
+	// DeadLetterReason is the reason the instance was moved to the dead-letter state, empty if it isn't dead-lettered
+	DeadLetterReason string
+
+	// Memo given when the instance was created
+	Memo core.Memo
+}
+
+type ListWorkflowInstancesResult struct {
+	// Instances matching the query, most recently created first
+	Instances []*WorkflowInstanceInfo
+
+	// NextPageToken retrieves the next page of instances. Empty if there are no more instances.
+	NextPageToken string
+}
+
+// EffectivePageSize returns the page size to use for the query
+func (q *ListWorkflowInstancesQuery) EffectivePageSize() int {
+	if q.PageSize <= 0 {
+		return DefaultListPageSize
+	}
+
+	return q.PageSize
+}
+
+type pageToken struct {
+	InstanceID  string `json:"i"`
+	ExecutionID string `json:"e"`
+}
+
+// EncodePageToken returns a page token continuing listing after the given instance
+func EncodePageToken(instance *core.WorkflowInstance) string {
+	b, _ := json.Marshal(pageToken{InstanceID: instance.InstanceID, ExecutionID: instance.ExecutionID})
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodePageToken returns the instance encoded in the given page token, or nil if the token is empty
+func DecodePageToken(token string) (*core.WorkflowInstance, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	var t pageToken
+	if err := json.Unmarshal(b, &t); err != nil || t.InstanceID == "" {
+		return nil, ErrInvalidPageToken
+	}
+
+	return core.NewWorkflowInstance(t.InstanceID, t.ExecutionID), nil
+}
@@ -0,0 +1,27 @@
+package backend
+
+import (
+	"context"
+	"time"
+)
+
+// LoadReporter is implemented by backends that can report how loaded their datastore is. Workers poll for new tasks
+// less often while the load is high, instead of adding to the load of a degraded datastore.
+type LoadReporter interface {
+	// Load returns the current load of the datastore, from 0 for idle to 1 for overloaded
+	Load(ctx context.Context) (float64, error)
+}
+
+// LatencyLoad maps the round-trip latency to a datastore to a load between 0 and 1. The load grows linearly with the
+// latency and reaches 1 at the latency of an overloaded datastore.
+func LatencyLoad(latency, overloaded time.Duration) float64 {
+	if overloaded <= 0 || latency >= overloaded {
+		return 1
+	}
+
+	if latency <= 0 {
+		return 0
+	}
+
+	return float64(latency) / float64(overloaded)
+}
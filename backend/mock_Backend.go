@@ -116,6 +116,20 @@ func (_m *MockBackend) CreateWorkflowInstance(ctx context.Context, instance *cor
 	return r0
 }
 
+// DeadLetterWorkflowTask provides a mock function with given fields: ctx, _a1, reason
+func (_m *MockBackend) DeadLetterWorkflowTask(ctx context.Context, _a1 *task.Workflow, reason string) error {
+	ret := _m.Called(ctx, _a1, reason)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *task.Workflow, string) error); ok {
+		r0 = rf(ctx, _a1, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ExtendActivityTask provides a mock function with given fields: ctx, activityID
 func (_m *MockBackend) ExtendActivityTask(ctx context.Context, activityID string) error {
 	ret := _m.Called(ctx, activityID)
@@ -222,6 +236,32 @@ func (_m *MockBackend) GetWorkflowInstanceHistory(ctx context.Context, instance
 	return r0, r1
 }
 
+// GetWorkflowInstanceInfo provides a mock function with given fields: ctx, instance
+func (_m *MockBackend) GetWorkflowInstanceInfo(ctx context.Context, instance *core.WorkflowInstance) (*WorkflowInstanceInfo, error) {
+	ret := _m.Called(ctx, instance)
+
+	var r0 *WorkflowInstanceInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *core.WorkflowInstance) (*WorkflowInstanceInfo, error)); ok {
+		return rf(ctx, instance)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *core.WorkflowInstance) *WorkflowInstanceInfo); ok {
+		r0 = rf(ctx, instance)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*WorkflowInstanceInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *core.WorkflowInstance) error); ok {
+		r1 = rf(ctx, instance)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetWorkflowInstanceState provides a mock function with given fields: ctx, instance
 func (_m *MockBackend) GetWorkflowInstanceState(ctx context.Context, instance *core.WorkflowInstance) (core.WorkflowInstanceState, error) {
 	ret := _m.Called(ctx, instance)
@@ -272,6 +312,32 @@ func (_m *MockBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, err
 	return r0, r1
 }
 
+// ListWorkflowInstances provides a mock function with given fields: ctx, query
+func (_m *MockBackend) ListWorkflowInstances(ctx context.Context, query *ListWorkflowInstancesQuery) (*ListWorkflowInstancesResult, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 *ListWorkflowInstancesResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *ListWorkflowInstancesQuery) (*ListWorkflowInstancesResult, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *ListWorkflowInstancesQuery) *ListWorkflowInstancesResult); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ListWorkflowInstancesResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *ListWorkflowInstancesQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Logger provides a mock function with given fields:
 func (_m *MockBackend) Logger() log.Logger {
 	ret := _m.Called()
@@ -304,6 +370,20 @@ func (_m *MockBackend) Metrics() metrics.Client {
 	return r0
 }
 
+// PauseWorkflowInstance provides a mock function with given fields: ctx, instance, pauseEvent
+func (_m *MockBackend) PauseWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance, pauseEvent *history.Event) error {
+	ret := _m.Called(ctx, instance, pauseEvent)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *core.WorkflowInstance, *history.Event) error); ok {
+		r0 = rf(ctx, instance, pauseEvent)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RemoveWorkflowInstance provides a mock function with given fields: ctx, instance
 func (_m *MockBackend) RemoveWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance) error {
 	ret := _m.Called(ctx, instance)
@@ -318,6 +398,34 @@ func (_m *MockBackend) RemoveWorkflowInstance(ctx context.Context, instance *cor
 	return r0
 }
 
+// ResumeWorkflowInstance provides a mock function with given fields: ctx, instance, resumeEvent
+func (_m *MockBackend) ResumeWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance, resumeEvent *history.Event) error {
+	ret := _m.Called(ctx, instance, resumeEvent)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *core.WorkflowInstance, *history.Event) error); ok {
+		r0 = rf(ctx, instance, resumeEvent)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RetryWorkflowInstance provides a mock function with given fields: ctx, instance
+func (_m *MockBackend) RetryWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance) error {
+	ret := _m.Called(ctx, instance)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *core.WorkflowInstance) error); ok {
+		r0 = rf(ctx, instance)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SignalWorkflow provides a mock function with given fields: ctx, instanceID, event
 func (_m *MockBackend) SignalWorkflow(ctx context.Context, instanceID string, event *history.Event) error {
 	ret := _m.Called(ctx, instanceID, event)
@@ -332,6 +440,20 @@ func (_m *MockBackend) SignalWorkflow(ctx context.Context, instanceID string, ev
 	return r0
 }
 
+// TerminateWorkflowInstance provides a mock function with given fields: ctx, instance, terminateEvent
+func (_m *MockBackend) TerminateWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance, terminateEvent *history.Event) error {
+	ret := _m.Called(ctx, instance, terminateEvent)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *core.WorkflowInstance, *history.Event) error); ok {
+		r0 = rf(ctx, instance, terminateEvent)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Tracer provides a mock function with given fields:
 func (_m *MockBackend) Tracer() trace.Tracer {
 	ret := _m.Called()
@@ -0,0 +1,80 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+func (b *mysqlBackend) DeadLetterWorkflowTask(ctx context.Context, t *task.Workflow, reason string) error {
+	res, err := b.db.ExecContext(
+		ctx,
+		"UPDATE `instances` SET dead_letter_reason = ?, locked_until = NULL, sticky_until = NULL WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND worker = ?",
+		reason,
+		b.options.Namespace,
+		t.WorkflowInstance.InstanceID,
+		t.WorkflowInstance.ExecutionID,
+		b.workerName,
+	)
+	if err != nil {
+		return fmt.Errorf("dead-lettering workflow instance: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("checking for dead-lettered workflow instances: %w", err)
+	} else if n != 1 {
+		return errors.New("could not find workflow instance to dead-letter")
+	}
+
+	return nil
+}
+
+func (b *mysqlBackend) RetryWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE `instances` SET dead_letter_reason = NULL, task_attempts = 0 WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND dead_letter_reason IS NOT NULL",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
+	if err != nil {
+		return fmt.Errorf("retrying workflow instance: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		row := tx.QueryRowContext(
+			ctx,
+			"SELECT 1 FROM `instances` WHERE namespace = ? AND instance_id = ? AND execution_id = ? LIMIT 1",
+			b.options.Namespace,
+			instance.InstanceID,
+			instance.ExecutionID,
+		)
+		if err := row.Scan(new(int)); err != nil {
+			if err == sql.ErrNoRows {
+				return backend.ErrInstanceNotFound
+			}
+
+			return err
+		}
+
+		// Not dead-lettered
+		return nil
+	}
+
+	return tx.Commit()
+}
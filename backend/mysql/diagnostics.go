@@ -11,6 +11,10 @@ import (
 
 var _ diag.Backend = (*mysqlBackend)(nil)
 
+func (mb *mysqlBackend) Namespace() string {
+	return mb.options.Namespace
+}
+
 func (mb *mysqlBackend) GetWorkflowInstances(ctx context.Context, afterInstanceID, afterExecutionID string, count int) ([]*diag.WorkflowInstanceRef, error) {
 	var err error
 	tx, err := mb.db.BeginTx(ctx, nil)
@@ -23,23 +27,28 @@ func (mb *mysqlBackend) GetWorkflowInstances(ctx context.Context, afterInstanceI
 	if afterInstanceID != "" {
 		rows, err = tx.QueryContext(
 			ctx,
-			`SELECT i.instance_id, i.execution_id, i.created_at, i.completed_at
+			`SELECT i.instance_id, i.execution_id, i.created_at, i.completed_at, i.dead_letter_reason, i.memo
 			FROM instances i
-			INNER JOIN (SELECT instance_id, created_at FROM instances WHERE id = ? AND execution_id = ?) ii
+			INNER JOIN (SELECT instance_id, created_at FROM instances WHERE namespace = ? AND instance_id = ? AND execution_id = ?) ii
 				ON i.created_at < ii.created_at OR (i.created_at = ii.created_at AND i.instance_id < ii.instance_id)
+			WHERE i.namespace = ?
 			ORDER BY i.created_at DESC, i.instance_id DESC
 			LIMIT ?`,
+			mb.options.Namespace,
 			afterInstanceID,
 			afterExecutionID,
+			mb.options.Namespace,
 			count,
 		)
 	} else {
 		rows, err = tx.QueryContext(
 			ctx,
-			`SELECT i.instance_id, i.execution_id, i.created_at, i.completed_at
+			`SELECT i.instance_id, i.execution_id, i.created_at, i.completed_at, i.dead_letter_reason, i.memo
 			FROM instances i
+			WHERE i.namespace = ?
 			ORDER BY i.created_at DESC, i.instance_id DESC
 			LIMIT ?`,
+			mb.options.Namespace,
 			count,
 		)
 	}
@@ -53,7 +62,13 @@ func (mb *mysqlBackend) GetWorkflowInstances(ctx context.Context, afterInstanceI
 		var id, executionID string
 		var createdAt time.Time
 		var completedAt *time.Time
-		err = rows.Scan(&id, &executionID, &createdAt, &completedAt)
+		var deadLetterReason, memoJson sql.NullString
+		err = rows.Scan(&id, &executionID, &createdAt, &completedAt, &deadLetterReason, &memoJson)
+		if err != nil {
+			return nil, err
+		}
+
+		memo, err := unmarshalMemo(memoJson)
 		if err != nil {
 			return nil, err
 		}
@@ -64,10 +79,12 @@ func (mb *mysqlBackend) GetWorkflowInstances(ctx context.Context, afterInstanceI
 		}
 
 		instances = append(instances, &diag.WorkflowInstanceRef{
-			Instance:    core.NewWorkflowInstance(id, executionID),
-			CreatedAt:   createdAt,
-			CompletedAt: completedAt,
-			State:       state,
+			Instance:         core.NewWorkflowInstance(id, executionID),
+			CreatedAt:        createdAt,
+			CompletedAt:      completedAt,
+			State:            state,
+			DeadLetterReason: deadLetterReason.String,
+			Memo:             memo,
 		})
 	}
 
@@ -83,13 +100,15 @@ func (mb *mysqlBackend) GetWorkflowInstance(ctx context.Context, instance *core.
 
 	res := tx.QueryRowContext(
 		ctx,
-		"SELECT instance_id, execution_id, created_at, completed_at FROM instances WHERE instance_id = ? AND execution_id = ?", instance.InstanceID, instance.ExecutionID)
+		"SELECT instance_id, execution_id, created_at, completed_at, dead_letter_reason, memo FROM instances WHERE namespace = ? AND instance_id = ? AND execution_id = ?",
+		mb.options.Namespace, instance.InstanceID, instance.ExecutionID)
 
 	var id, executionID string
 	var createdAt time.Time
 	var completedAt *time.Time
+	var deadLetterReason, memoJson sql.NullString
 
-	err = res.Scan(&id, &executionID, &createdAt, &completedAt)
+	err = res.Scan(&id, &executionID, &createdAt, &completedAt, &deadLetterReason, &memoJson)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -98,16 +117,23 @@ func (mb *mysqlBackend) GetWorkflowInstance(ctx context.Context, instance *core.
 		return nil, err
 	}
 
+	memo, err := unmarshalMemo(memoJson)
+	if err != nil {
+		return nil, err
+	}
+
 	var state core.WorkflowInstanceState
 	if completedAt != nil {
 		state = core.WorkflowInstanceStateFinished
 	}
 
 	return &diag.WorkflowInstanceRef{
-		Instance:    core.NewWorkflowInstance(id, executionID),
-		CreatedAt:   createdAt,
-		CompletedAt: completedAt,
-		State:       state,
+		Instance:         core.NewWorkflowInstance(id, executionID),
+		CreatedAt:        createdAt,
+		CompletedAt:      completedAt,
+		State:            state,
+		DeadLetterReason: deadLetterReason.String,
+		Memo:             memo,
 	}, nil
 }
 
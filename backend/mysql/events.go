@@ -3,21 +3,22 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"strings"
 
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
 )
 
-func insertPendingEvents(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance, newEvents []*history.Event) error {
-	return insertEvents(ctx, tx, "pending_events", instance, newEvents)
+func insertPendingEvents(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, newEvents []*history.Event) error {
+	return insertEvents(ctx, tx, "pending_events", namespace, instance, newEvents)
 }
 
-func insertHistoryEvents(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance, historyEvents []*history.Event) error {
-	return insertEvents(ctx, tx, "history", instance, historyEvents)
+func insertHistoryEvents(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, historyEvents []*history.Event) error {
+	return insertEvents(ctx, tx, "history", namespace, instance, historyEvents)
 }
 
-func insertEvents(ctx context.Context, tx *sql.Tx, tableName string, instance *core.WorkflowInstance, events []*history.Event) error {
+func insertEvents(ctx context.Context, tx *sql.Tx, tableName string, namespace string, instance *core.WorkflowInstance, events []*history.Event) error {
 	const batchSize = 20
 	for batchStart := 0; batchStart < len(events); batchStart += batchSize {
 		batchEnd := batchStart + batchSize
@@ -27,10 +28,10 @@ func insertEvents(ctx context.Context, tx *sql.Tx, tableName string, instance *c
 		batchEvents := events[batchStart:batchEnd]
 
 		query := "INSERT INTO `" + tableName +
-			"` (event_id, sequence_id, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)" +
-			strings.Repeat(", (?, ?, ?, ?, ?, ?, ?, ?, ?)", len(batchEvents)-1)
+			"` (event_id, namespace, sequence_id, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)" +
+			strings.Repeat(", (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", len(batchEvents)-1)
 
-		args := make([]interface{}, 0, len(batchEvents)*7)
+		args := make([]interface{}, 0, len(batchEvents)*10)
 
 		for _, newEvent := range batchEvents {
 			a, err := history.SerializeAttributes(newEvent.Attributes)
@@ -40,7 +41,7 @@ func insertEvents(ctx context.Context, tx *sql.Tx, tableName string, instance *c
 
 			args = append(
 				args,
-				newEvent.ID, newEvent.SequenceID, instance.InstanceID, instance.ExecutionID, newEvent.Type, newEvent.Timestamp, newEvent.ScheduleEventID, a, newEvent.VisibleAt)
+				newEvent.ID, namespace, newEvent.SequenceID, instance.InstanceID, instance.ExecutionID, newEvent.Type, newEvent.Timestamp, newEvent.ScheduleEventID, a, newEvent.VisibleAt)
 		}
 
 		_, err := tx.ExecContext(
@@ -56,10 +57,11 @@ func insertEvents(ctx context.Context, tx *sql.Tx, tableName string, instance *c
 	return nil
 }
 
-func removeFutureEvent(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance, scheduleEventID int64) error {
+func removeFutureEvent(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, scheduleEventID int64) error {
 	_, err := tx.ExecContext(
 		ctx,
-		"DELETE FROM `pending_events` WHERE instance_id = ? AND execution_id = ? AND schedule_event_id = ? AND visible_at IS NOT NULL",
+		"DELETE FROM `pending_events` WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND schedule_event_id = ? AND visible_at IS NOT NULL",
+		namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 		scheduleEventID,
@@ -67,3 +69,32 @@ func removeFutureEvent(ctx context.Context, tx *sql.Tx, instance *core.WorkflowI
 
 	return err
 }
+
+// scanEvent scans an event selected as event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes,
+// visible_at
+func scanEvent(rows *sql.Rows) (*history.Event, error) {
+	var attributes []byte
+
+	event := &history.Event{}
+
+	if err := rows.Scan(
+		&event.ID,
+		&event.SequenceID,
+		&event.Type,
+		&event.Timestamp,
+		&event.ScheduleEventID,
+		&attributes,
+		&event.VisibleAt,
+	); err != nil {
+		return nil, fmt.Errorf("scanning event: %w", err)
+	}
+
+	a, err := history.DeserializeAttributes(event.Type, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("deserializing attributes: %w", err)
+	}
+
+	event.Attributes = a
+
+	return event, nil
+}
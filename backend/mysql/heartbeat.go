@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/payload"
+)
+
+var _ backend.ActivityHeartbeater = (*mysqlBackend)(nil)
+
+func (b *mysqlBackend) RecordActivityHeartbeat(ctx context.Context, activityID string, details payload.Payload) error {
+	now := b.options.Clock.Now()
+
+	// MySQL doesn't count rows updated with identical values as affected, so repeated heartbeats within the same
+	// second would look like the activity isn't locked anymore. The result is not checked for that reason.
+	if _, err := b.db.ExecContext(
+		ctx,
+		`UPDATE activities SET locked_until = ?, last_heartbeat = ?, heartbeat_details = ? WHERE namespace = ? AND activity_id = ? AND worker = ?`,
+		now.Add(b.options.ActivityLockTimeout),
+		now,
+		[]byte(details),
+		b.options.Namespace,
+		activityID,
+		b.workerName,
+	); err != nil {
+		return fmt.Errorf("recording activity heartbeat: %w", err)
+	}
+
+	return nil
+}
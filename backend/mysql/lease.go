@@ -0,0 +1,79 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.Leaser = (*mysqlBackend)(nil)
+
+func (b *mysqlBackend) AcquireLease(ctx context.Context, name, holder string, duration time.Duration) (bool, error) {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	now := b.options.Clock.Now()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"INSERT IGNORE INTO `leases` (namespace, name, holder, expires_at) VALUES (?, ?, ?, ?)",
+		b.options.Namespace,
+		name,
+		holder,
+		now.Add(duration).UnixNano(),
+	); err != nil {
+		return false, fmt.Errorf("creating lease: %w", err)
+	}
+
+	var currentHolder string
+	var expiresAt int64
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT holder, expires_at FROM `leases` WHERE namespace = ? AND name = ? FOR UPDATE",
+		b.options.Namespace,
+		name,
+	).Scan(&currentHolder, &expiresAt); err != nil {
+		return false, fmt.Errorf("reading lease: %w", err)
+	}
+
+	// Take over the lease if it's held by the same holder or has expired
+	if currentHolder != holder && expiresAt >= now.UnixNano() {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"UPDATE `leases` SET holder = ?, expires_at = ? WHERE namespace = ? AND name = ?",
+		holder,
+		now.Add(duration).UnixNano(),
+		b.options.Namespace,
+		name,
+	); err != nil {
+		return false, fmt.Errorf("updating lease: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (b *mysqlBackend) ReleaseLease(ctx context.Context, name, holder string) error {
+	if _, err := b.db.ExecContext(
+		ctx,
+		"DELETE FROM `leases` WHERE namespace = ? AND name = ? AND holder = ?",
+		b.options.Namespace,
+		name,
+		holder,
+	); err != nil {
+		return fmt.Errorf("releasing lease: %w", err)
+	}
+
+	return nil
+}
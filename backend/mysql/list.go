@@ -0,0 +1,186 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+)
+
+func (mb *mysqlBackend) ListWorkflowInstances(ctx context.Context, query *backend.ListWorkflowInstancesQuery) (*backend.ListWorkflowInstancesResult, error) {
+	after, err := backend.DecodePageToken(query.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	where := []string{"i.namespace = ?"}
+	args := []interface{}{mb.options.Namespace}
+
+	switch query.State {
+	case backend.InstanceStateActive:
+		where = append(where, "i.completed_at IS NULL")
+	case backend.InstanceStateFinished:
+		where = append(where, "i.completed_at IS NOT NULL")
+	case backend.InstanceStateCanceled:
+		// The cancellation event is pending until the next workflow task, and part of the history afterwards
+		where = append(where, `(
+			EXISTS (SELECT 1 FROM pending_events pe WHERE pe.namespace = i.namespace AND pe.instance_id = i.instance_id AND pe.execution_id = i.execution_id AND pe.event_type = ?)
+			OR EXISTS (SELECT 1 FROM history h WHERE h.namespace = i.namespace AND h.instance_id = i.instance_id AND h.execution_id = i.execution_id AND h.event_type = ?))`)
+		args = append(args, history.EventType_WorkflowExecutionCanceled, history.EventType_WorkflowExecutionCanceled)
+	case backend.InstanceStateDeadLettered:
+		where = append(where, "i.dead_letter_reason IS NOT NULL")
+	}
+
+	// created_at is set by CURRENT_TIMESTAMP, compare in UTC
+	if !query.CreatedAfter.IsZero() {
+		where = append(where, "i.created_at >= ?")
+		args = append(args, query.CreatedAfter.UTC())
+	}
+
+	if !query.CreatedBefore.IsZero() {
+		where = append(where, "i.created_at < ?")
+		args = append(args, query.CreatedBefore.UTC())
+	}
+
+	saWhere, saArgs := searchAttributesFilter(query.SearchAttributes)
+	where = append(where, saWhere...)
+	args = append(args, saArgs...)
+
+	if after != nil {
+		where = append(where, `EXISTS (
+			SELECT 1 FROM instances a WHERE a.namespace = i.namespace AND a.instance_id = ? AND a.execution_id = ? AND (
+				i.created_at < a.created_at OR (i.created_at = a.created_at AND (i.instance_id < a.instance_id OR (i.instance_id = a.instance_id AND i.execution_id < a.execution_id)))))`)
+		args = append(args, after.InstanceID, after.ExecutionID)
+	}
+
+	// Query one more instance than requested to determine whether there is another page
+	pageSize := query.EffectivePageSize()
+	args = append(args, pageSize+1)
+
+	rows, err := mb.db.QueryContext(
+		ctx,
+		`SELECT i.instance_id, i.execution_id, i.workflow_name, i.state, i.created_at, i.completed_at, i.dead_letter_reason, i.memo
+		FROM instances i
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY i.created_at DESC, i.instance_id DESC, i.execution_id DESC
+		LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing workflow instances: %w", err)
+	}
+	defer rows.Close()
+
+	result := &backend.ListWorkflowInstancesResult{}
+
+	for rows.Next() {
+		var id, executionID string
+		var workflowName *string
+		var state core.WorkflowInstanceState
+		var createdAt time.Time
+		var completedAt *time.Time
+		var deadLetterReason *string
+		var memoJson sql.NullString
+		if err := rows.Scan(&id, &executionID, &workflowName, &state, &createdAt, &completedAt, &deadLetterReason, &memoJson); err != nil {
+			return nil, fmt.Errorf("scanning workflow instance: %w", err)
+		}
+
+		memo, err := unmarshalMemo(memoJson)
+		if err != nil {
+			return nil, err
+		}
+
+		info := &backend.WorkflowInstanceInfo{
+			Instance:    core.NewWorkflowInstance(id, executionID),
+			State:       state,
+			CreatedAt:   createdAt,
+			CompletedAt: completedAt,
+			Memo:        memo,
+		}
+
+		if workflowName != nil {
+			info.WorkflowName = *workflowName
+		}
+
+		if deadLetterReason != nil {
+			info.DeadLetterReason = *deadLetterReason
+		}
+
+		result.Instances = append(result.Instances, info)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing workflow instances: %w", err)
+	}
+
+	if len(result.Instances) > pageSize {
+		result.Instances = result.Instances[:pageSize]
+		result.NextPageToken = backend.EncodePageToken(result.Instances[pageSize-1].Instance)
+	}
+
+	return result, nil
+}
+
+func (mb *mysqlBackend) GetWorkflowInstanceInfo(ctx context.Context, instance *core.WorkflowInstance) (*backend.WorkflowInstanceInfo, error) {
+	row := mb.db.QueryRowContext(
+		ctx,
+		`SELECT i.instance_id, i.execution_id, i.parent_instance_id, i.parent_execution_id, i.parent_schedule_event_id, i.workflow_name, i.state, i.created_at, i.completed_at, i.dead_letter_reason, i.memo
+		FROM instances i
+		WHERE i.namespace = ? AND i.instance_id = ? AND i.execution_id = ?`,
+		mb.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
+
+	var id, executionID string
+	var parentInstanceID, parentExecutionID *string
+	var parentEventID *int64
+	var workflowName *string
+	var state core.WorkflowInstanceState
+	var createdAt time.Time
+	var completedAt *time.Time
+	var deadLetterReason *string
+	var memoJson sql.NullString
+	if err := row.Scan(
+		&id, &executionID, &parentInstanceID, &parentExecutionID, &parentEventID, &workflowName, &state, &createdAt, &completedAt,
+		&deadLetterReason, &memoJson); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, backend.ErrInstanceNotFound
+		}
+
+		return nil, fmt.Errorf("getting workflow instance: %w", err)
+	}
+
+	memo, err := unmarshalMemo(memoJson)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &backend.WorkflowInstanceInfo{
+		Instance:    core.NewWorkflowInstance(id, executionID),
+		State:       state,
+		CreatedAt:   createdAt,
+		CompletedAt: completedAt,
+		Memo:        memo,
+	}
+
+	if parentInstanceID != nil && parentExecutionID != nil && parentEventID != nil {
+		info.Instance = core.NewSubWorkflowInstance(
+			id, executionID, core.NewWorkflowInstance(*parentInstanceID, *parentExecutionID), *parentEventID)
+	}
+
+	if workflowName != nil {
+		info.WorkflowName = *workflowName
+	}
+
+	if deadLetterReason != nil {
+		info.DeadLetterReason = *deadLetterReason
+	}
+
+	return info, nil
+}
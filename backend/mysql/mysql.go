@@ -21,7 +21,6 @@ import (
 	"github.com/cschleiden/go-workflows/metrics"
 	"github.com/cschleiden/go-workflows/workflow"
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -41,6 +40,10 @@ func NewMysqlBackend(host string, port int, user, password, database string, opt
 		panic(fmt.Errorf("initializing database: %w", err))
 	}
 
+	if err := migrate(db); err != nil {
+		panic(fmt.Errorf("migrating database: %w", err))
+	}
+
 	if err := db.Close(); err != nil {
 		panic(err)
 	}
@@ -50,19 +53,158 @@ func NewMysqlBackend(host string, port int, user, password, database string, opt
 		panic(err)
 	}
 
+	options := backend.ApplyOptions(opts...)
+
 	return &mysqlBackend{
-		db:         db,
-		workerName: fmt.Sprintf("worker-%v", uuid.NewString()),
-		options:    backend.ApplyOptions(opts...),
+		db:                    db,
+		workerName:            fmt.Sprintf("worker-%v", options.IDGenerator()),
+		options:               options,
+		workflowPriorityOrder: backend.NewPriorityOrder(options.PriorityStarvationInterval),
+		activityPriorityOrder: backend.NewPriorityOrder(options.PriorityStarvationInterval),
 	}
 }
 
+// migrate updates databases created by earlier versions of the schema
+func migrate(db *sql.DB) error {
+	for _, column := range []struct{ table, name, definition string }{
+		{"instances", "namespace", "NVARCHAR(128) NOT NULL DEFAULT 'default'"},
+		{"activities", "namespace", "NVARCHAR(128) NOT NULL DEFAULT 'default'"},
+		{"pending_events", "namespace", "NVARCHAR(128) NOT NULL DEFAULT 'default'"},
+		{"history", "namespace", "NVARCHAR(128) NOT NULL DEFAULT 'default'"},
+		{"instances", "priority", "INT NOT NULL DEFAULT 0"},
+		{"activities", "priority", "INT NOT NULL DEFAULT 0"},
+		{"instances", "workflow_name", "NVARCHAR(255) NULL"},
+		{"instances", "paused", "BOOLEAN NOT NULL DEFAULT FALSE"},
+		{"instances", "build_id", "NVARCHAR(255) NULL"},
+		{"activities", "last_heartbeat", "DATETIME NULL"},
+		{"activities", "heartbeat_details", "BLOB NULL"},
+		{"instances", "task_attempts", "INT NOT NULL DEFAULT 0"},
+		{"instances", "dead_letter_reason", "TEXT NULL"},
+		{"instances", "memo", "TEXT NULL"},
+		{"instances", "queue", "NVARCHAR(128) NOT NULL DEFAULT 'default'"},
+		{"activities", "queue", "NVARCHAR(128) NOT NULL DEFAULT 'default'"},
+		{"instances", "archived", "BOOLEAN NOT NULL DEFAULT FALSE"},
+	} {
+		var exists int
+		if err := db.QueryRow(
+			"SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?",
+			column.table, column.name,
+		).Scan(&exists); err != nil {
+			return fmt.Errorf("checking for %v column: %w", column.name, err)
+		}
+
+		if exists == 0 {
+			if _, err := db.Exec(
+				fmt.Sprintf("ALTER TABLE `%v` ADD COLUMN `%v` %v", column.table, column.name, column.definition),
+			); err != nil {
+				return fmt.Errorf("adding %v column: %w", column.name, err)
+			}
+
+			// Events recorded by earlier versions belong to the namespace of their instance
+			if column.name == "namespace" && (column.table == "pending_events" || column.table == "history") {
+				if _, err := db.Exec(fmt.Sprintf(
+					"UPDATE `%v` e INNER JOIN `instances` i ON i.instance_id = e.instance_id AND i.execution_id = e.execution_id SET e.namespace = i.namespace",
+					column.table,
+				)); err != nil {
+					return fmt.Errorf("backfilling %v namespace: %w", column.table, err)
+				}
+			}
+		}
+	}
+
+	for _, index := range []struct {
+		table, name, columns string
+		unique               bool
+	}{
+		{"instances", "idx_instances_namespace_instance_id_state", "`namespace`, `instance_id`, `state`", false},
+		{"activities", "idx_activities_namespace_locked_until", "`namespace`, `locked_until`", false},
+		{"instances", "idx_instances_namespace_workflow_name_state", "`namespace`, `workflow_name`, `state`", false},
+		{"instances", "idx_instances_namespace_instance_id_execution_id", "`namespace`, `instance_id`, `execution_id`", true},
+		{"activities", "idx_activities_namespace_instance_id_execution_id_activity_id_worker", "`namespace`, `instance_id`, `execution_id`, `activity_id`, `worker`", true},
+		{"pending_events", "idx_pending_events_namespace_inid_exid_visible_at", "`namespace`, `instance_id`, `execution_id`, `visible_at`", false},
+		{"history", "idx_history_namespace_instance_id_execution_id_sequence_id", "`namespace`, `instance_id`, `execution_id`, `sequence_id`", false},
+	} {
+		var exists int
+		if err := db.QueryRow(
+			"SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?",
+			index.table, index.name,
+		).Scan(&exists); err != nil {
+			return fmt.Errorf("checking for %v index: %w", index.name, err)
+		}
+
+		if exists == 0 {
+			kind := "INDEX"
+			if index.unique {
+				kind = "UNIQUE INDEX"
+			}
+
+			if _, err := db.Exec(
+				fmt.Sprintf("CREATE %v `%v` ON `%v` (%v)", kind, index.name, index.table, index.columns),
+			); err != nil {
+				return fmt.Errorf("creating %v index: %w", index.name, err)
+			}
+		}
+	}
+
+	// Earlier versions of the schema enforced unique instance and activity IDs across namespaces. These indexes are
+	// still used for lookups, but are no longer unique.
+	for _, index := range []struct{ table, name, columns string }{
+		{"instances", "idx_instances_instance_id_execution_id", "`instance_id`, `execution_id`"},
+		{"activities", "idx_activities_instance_id_execution_id_activity_id_worker", "`instance_id`, `execution_id`, `activity_id`, `worker`"},
+	} {
+		var unique int
+		if err := db.QueryRow(
+			"SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ? AND non_unique = 0",
+			index.table, index.name,
+		).Scan(&unique); err != nil {
+			return fmt.Errorf("checking for %v index: %w", index.name, err)
+		}
+
+		if unique > 0 {
+			if _, err := db.Exec(
+				fmt.Sprintf("ALTER TABLE `%v` DROP INDEX `%v`, ADD INDEX `%v` (%v)", index.table, index.name, index.name, index.columns),
+			); err != nil {
+				return fmt.Errorf("altering %v index: %w", index.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 type mysqlBackend struct {
 	db         *sql.DB
 	workerName string
 	options    backend.Options
+
+	workflowPriorityOrder *backend.PriorityOrder
+	activityPriorityOrder *backend.PriorityOrder
 }
 
+// orderByPriority returns an ORDER BY expression and its arguments for dequeuing tasks in the given priority order
+func orderByPriority(column string, order []core.Priority) (string, []interface{}) {
+	return fmt.Sprintf("CASE %v WHEN ? THEN 0 WHEN ? THEN 1 ELSE 2 END", column), []interface{}{order[0], order[1]}
+}
+
+// inQueues returns an IN expression and its arguments for dequeuing tasks of the given queues, or of the default queue
+// if none are given
+func inQueues(queues []core.Queue) (string, []interface{}) {
+	if len(queues) == 0 {
+		queues = []core.Queue{core.QueueDefault}
+	}
+
+	args := make([]interface{}, 0, len(queues))
+	for _, q := range queues {
+		args = append(args, q.OrDefault())
+	}
+
+	return "IN (?" + strings.Repeat(",?", len(queues)-1) + ")", args
+}
+
+var _ backend.QueuePoller = (*mysqlBackend)(nil)
+var _ backend.Retainer = (*mysqlBackend)(nil)
+var _ backend.IDGenerator = (*mysqlBackend)(nil)
+
 func (b *mysqlBackend) Logger() log.Logger {
 	return b.options.Logger
 }
@@ -72,17 +214,28 @@ func (b *mysqlBackend) Tracer() trace.Tracer {
 }
 
 func (b *mysqlBackend) Metrics() metrics.Client {
-	return b.options.Metrics.WithTags(metrics.Tags{metrickeys.Backend: "mysql"})
+	return b.options.Metrics.WithTags(metrics.Tags{
+		metrickeys.Backend:   "mysql",
+		metrickeys.Namespace: b.options.Namespace,
+	})
 }
 
 func (b *mysqlBackend) Converter() converter.Converter {
 	return b.options.Converter
 }
 
+func (b *mysqlBackend) NewID() string {
+	return b.options.IDGenerator()
+}
+
 func (b *mysqlBackend) ContextPropagators() []contextpropagation.ContextPropagator {
 	return b.options.ContextPropagators
 }
 
+func (b *mysqlBackend) Retention() time.Duration {
+	return b.options.Retention
+}
+
 func (b *mysqlBackend) CreateWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
 	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelReadCommitted,
@@ -92,13 +245,69 @@ func (b *mysqlBackend) CreateWorkflowInstance(ctx context.Context, instance *wor
 	}
 	defer tx.Rollback()
 
+	a := event.Attributes.(*history.ExecutionStartedAttributes)
+
+	// Check for existing executions of the same instance
+	terminated, err := b.checkInstanceIDReuse(ctx, tx, instance.InstanceID, a.InstanceIDReusePolicy)
+	if err != nil {
+		return err
+	}
+
+	// Check the concurrency limit of the workflow
+	if limit, ok := b.options.WorkflowConcurrencyLimits[a.Name]; ok {
+		// Serialize creating instances of the same workflow, so that concurrent creations see each other
+		if _, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO `workflow_concurrency_locks` (namespace, workflow_name) VALUES (?, ?) ON DUPLICATE KEY UPDATE workflow_name = workflow_name",
+			b.options.Namespace,
+			a.Name,
+		); err != nil {
+			return fmt.Errorf("locking workflow concurrency: %w", err)
+		}
+
+		var active int
+		if err := tx.QueryRowContext(
+			ctx,
+			"SELECT COUNT(*) FROM `instances` WHERE namespace = ? AND workflow_name = ? AND state = ?",
+			b.options.Namespace,
+			a.Name,
+			core.WorkflowInstanceStateActive,
+		).Scan(&active); err != nil {
+			return fmt.Errorf("counting active workflow instances: %w", err)
+		}
+
+		if active >= limit {
+			return backend.ErrConcurrencyLimitReached
+		}
+	}
+
+	// Check the active instance limits of the namespace and the tenant
+	if err := quotas.Check(ctx, tx, b.options.Namespace, backend.ActiveInstanceLimits(ctx)); err != nil {
+		return err
+	}
+
 	// Create workflow instance
-	if err := createInstance(ctx, tx, instance, event.Attributes.(*history.ExecutionStartedAttributes).Metadata, false); err != nil {
+	if err := createInstance(ctx, tx, b.options.Namespace, instance, a, false); err != nil {
 		return err
 	}
 
+	// Delayed instances are locked until they are due, so that no workflow task is handed out before. Events like
+	// signals are held until then.
+	if event.VisibleAt != nil {
+		if _, err := tx.ExecContext(
+			ctx,
+			"UPDATE `instances` SET locked_until = ? WHERE namespace = ? AND instance_id = ? AND execution_id = ?",
+			*event.VisibleAt,
+			b.options.Namespace,
+			instance.InstanceID,
+			instance.ExecutionID,
+		); err != nil {
+			return fmt.Errorf("delaying workflow instance: %w", err)
+		}
+	}
+
 	// Initial history is empty, store only new events
-	if err := insertPendingEvents(ctx, tx, instance, []*history.Event{event}); err != nil {
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
 		return fmt.Errorf("inserting new event: %w", err)
 	}
 
@@ -106,6 +315,10 @@ func (b *mysqlBackend) CreateWorkflowInstance(ctx context.Context, instance *wor
 		return fmt.Errorf("creating workflow instance: %w", err)
 	}
 
+	if terminated != nil && b.options.Archiver != nil {
+		b.tryArchiveHistory(ctx, terminated)
+	}
+
 	return nil
 }
 
@@ -116,7 +329,13 @@ func (b *mysqlBackend) RemoveWorkflowInstance(ctx context.Context, instance *cor
 	}
 	defer tx.Rollback()
 
-	row := tx.QueryRowContext(ctx, "SELECT state FROM `instances` WHERE instance_id = ? AND execution_id = ? LIMIT 1", instance.InstanceID, instance.ExecutionID)
+	row := tx.QueryRowContext(
+		ctx,
+		"SELECT state FROM `instances` WHERE namespace = ? AND instance_id = ? AND execution_id = ? LIMIT 1",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
 	var state core.WorkflowInstanceState
 	if err := row.Scan(&state); err != nil {
 		if err == sql.ErrNoRows {
@@ -129,11 +348,27 @@ func (b *mysqlBackend) RemoveWorkflowInstance(ctx context.Context, instance *cor
 	}
 
 	// Delete from instances and history tables
-	if _, err := tx.ExecContext(ctx, "DELETE FROM `instances` WHERE instance_id = ? AND execution_id = ?", instance.InstanceID, instance.ExecutionID); err != nil {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM `instances` WHERE namespace = ? AND instance_id = ? AND execution_id = ?", b.options.Namespace, instance.InstanceID, instance.ExecutionID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM `history` WHERE namespace = ? AND instance_id = ? AND execution_id = ?", b.options.Namespace, instance.InstanceID, instance.ExecutionID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM `pending_events` WHERE namespace = ? AND instance_id = ? AND execution_id = ?", b.options.Namespace, instance.InstanceID, instance.ExecutionID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM `pending_activities` WHERE namespace = ? AND instance_id = ? AND execution_id = ?", b.options.Namespace, instance.InstanceID, instance.ExecutionID); err != nil {
 		return err
 	}
 
-	if _, err := tx.ExecContext(ctx, "DELETE FROM `history` WHERE instance_id = ? AND execution_id = ?", instance.InstanceID, instance.ExecutionID); err != nil {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM `search_attributes` WHERE namespace = ? AND instance_id = ? AND execution_id = ?", b.options.Namespace, instance.InstanceID, instance.ExecutionID); err != nil {
+		return err
+	}
+
+	if err := signals.Prune(ctx, tx, b.options.Namespace, b.options.Clock.Now()); err != nil {
 		return err
 	}
 
@@ -151,7 +386,13 @@ func (b *mysqlBackend) CancelWorkflowInstance(ctx context.Context, instance *wor
 
 	// Cancel workflow instance
 	// TODO: Combine this with the event insertion
-	res := tx.QueryRowContext(ctx, "SELECT 1 FROM `instances` WHERE instance_id = ? AND execution_id = ? LIMIT 1", instance.InstanceID, instance.ExecutionID)
+	res := tx.QueryRowContext(
+		ctx,
+		"SELECT 1 FROM `instances` WHERE namespace = ? AND instance_id = ? AND execution_id = ? LIMIT 1",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
 	if err := res.Scan(new(int)); err != nil {
 		if err == sql.ErrNoRows {
 			return backend.ErrInstanceNotFound
@@ -160,7 +401,7 @@ func (b *mysqlBackend) CancelWorkflowInstance(ctx context.Context, instance *wor
 		return err
 	}
 
-	if err := insertPendingEvents(ctx, tx, instance, []*history.Event{event}); err != nil {
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
 		return fmt.Errorf("inserting cancellation event: %w", err)
 	}
 
@@ -174,11 +415,27 @@ func (b *mysqlBackend) GetWorkflowInstanceHistory(ctx context.Context, instance
 	}
 	defer tx.Rollback()
 
+	h, err := getHistory(ctx, tx, b.options.Namespace, instance, lastSequenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Histories of finished instances might have been moved to the archiver
+	if len(h) == 0 && lastSequenceID == nil && b.options.Archiver != nil {
+		return b.getArchivedHistory(ctx, instance)
+	}
+
+	return h, nil
+}
+
+func getHistory(ctx context.Context, tx *sql.Tx, namespace string, instance *workflow.Instance, lastSequenceID *int64) ([]*history.Event, error) {
+	var err error
 	var historyEvents *sql.Rows
 	if lastSequenceID != nil {
 		historyEvents, err = tx.QueryContext(
 			ctx,
-			"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM `history` WHERE instance_id = ? AND execution_id = ? AND sequence_id > ? ORDER BY sequence_id",
+			"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM `history` WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND sequence_id > ? ORDER BY sequence_id",
+			namespace,
 			instance.InstanceID,
 			instance.ExecutionID,
 			*lastSequenceID,
@@ -186,7 +443,8 @@ func (b *mysqlBackend) GetWorkflowInstanceHistory(ctx context.Context, instance
 	} else {
 		historyEvents, err = tx.QueryContext(
 			ctx,
-			"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM `history` WHERE instance_id = ? AND execution_id = ? ORDER BY sequence_id",
+			"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM `history` WHERE namespace = ? AND instance_id = ? AND execution_id = ? ORDER BY sequence_id",
+			namespace,
 			instance.InstanceID,
 			instance.ExecutionID,
 		)
@@ -230,7 +488,8 @@ func (b *mysqlBackend) GetWorkflowInstanceHistory(ctx context.Context, instance
 func (b *mysqlBackend) GetWorkflowInstanceState(ctx context.Context, instance *workflow.Instance) (core.WorkflowInstanceState, error) {
 	row := b.db.QueryRowContext(
 		ctx,
-		"SELECT state FROM instances WHERE instance_id = ? AND execution_id = ?",
+		"SELECT state FROM instances WHERE namespace = ? AND instance_id = ? AND execution_id = ?",
+		b.options.Namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 	)
@@ -245,7 +504,7 @@ func (b *mysqlBackend) GetWorkflowInstanceState(ctx context.Context, instance *w
 	return state, nil
 }
 
-func createInstance(ctx context.Context, tx *sql.Tx, wfi *workflow.Instance, metadata *workflow.Metadata, ignoreDuplicate bool) error {
+func createInstance(ctx context.Context, tx *sql.Tx, namespace string, wfi *workflow.Instance, a *history.ExecutionStartedAttributes, ignoreDuplicate bool) error {
 	var parentInstanceID, parentExecutionID *string
 	var parentEventID *int64
 	if wfi.SubWorkflow() {
@@ -254,14 +513,20 @@ func createInstance(ctx context.Context, tx *sql.Tx, wfi *workflow.Instance, met
 		parentEventID = &wfi.ParentEventID
 	}
 
-	metadataJson, err := json.Marshal(metadata)
+	metadataJson, err := json.Marshal(a.Metadata)
 	if err != nil {
 		return fmt.Errorf("marshaling metadata: %w", err)
 	}
 
+	memo, err := marshalMemo(a.Memo)
+	if err != nil {
+		return err
+	}
+
 	res, err := tx.ExecContext(
 		ctx,
-		"INSERT IGNORE INTO `instances` (instance_id, execution_id, parent_instance_id, parent_execution_id, parent_schedule_event_id, metadata, state) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		"INSERT IGNORE INTO `instances` (namespace, instance_id, execution_id, parent_instance_id, parent_execution_id, parent_schedule_event_id, metadata, state, priority, workflow_name, memo, queue) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		namespace,
 		wfi.InstanceID,
 		wfi.ExecutionID,
 		parentInstanceID,
@@ -269,20 +534,30 @@ func createInstance(ctx context.Context, tx *sql.Tx, wfi *workflow.Instance, met
 		parentEventID,
 		string(metadataJson),
 		core.WorkflowInstanceStateActive,
+		a.Priority,
+		a.Name,
+		memo,
+		a.Queue.OrDefault(),
 	)
 	if err != nil {
 		return fmt.Errorf("inserting workflow instance: %w", err)
 	}
 
-	if !ignoreDuplicate {
-		rows, err := res.RowsAffected()
-		if err != nil {
-			return err
-		}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
 
-		if rows != 1 {
+	if rows != 1 {
+		if !ignoreDuplicate {
 			return backend.ErrInstanceAlreadyExists
 		}
+
+		return nil
+	}
+
+	if err := upsertSearchAttributes(ctx, tx, namespace, wfi, a.SearchAttributes); err != nil {
+		return fmt.Errorf("inserting search attributes: %w", err)
 	}
 
 	return nil
@@ -299,15 +574,36 @@ func (b *mysqlBackend) SignalWorkflow(ctx context.Context, instanceID string, ev
 	defer tx.Rollback()
 
 	// TODO: Combine this with the event insertion
-	res := tx.QueryRowContext(ctx, "SELECT execution_id FROM `instances` WHERE instance_id = ? AND state = ? LIMIT 1", instanceID, core.WorkflowInstanceStateActive)
+	res := tx.QueryRowContext(
+		ctx,
+		"SELECT execution_id FROM `instances` WHERE namespace = ? AND instance_id = ? AND state = ? LIMIT 1",
+		b.options.Namespace,
+		instanceID,
+		core.WorkflowInstanceStateActive,
+	)
 	var executionID string
 	if err := res.Scan(&executionID); err == sql.ErrNoRows {
 		return backend.ErrInstanceNotFound
 	}
 
+	// Only signals are deduplicated, not updates
+	if window := b.options.SignalDeduplicationWindow; window > 0 && event.Type == history.EventType_SignalReceived {
+		duplicate, err := signals.IsDuplicate(ctx, tx, b.options.Namespace, instanceID, event, window, b.options.Clock.Now())
+		if err != nil {
+			return err
+		}
+
+		if duplicate {
+			b.Logger().Debug("Dropping duplicate signal", log.InstanceIDKey, instanceID,
+				log.SignalNameKey, event.Attributes.(*history.SignalReceivedAttributes).Name)
+
+			return tx.Commit()
+		}
+	}
+
 	instance := core.NewWorkflowInstance(instanceID, executionID)
 
-	if err := insertPendingEvents(ctx, tx, instance, []*history.Event{event}); err != nil {
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
 		return fmt.Errorf("inserting signal event: %w", err)
 	}
 
@@ -316,6 +612,12 @@ func (b *mysqlBackend) SignalWorkflow(ctx context.Context, instanceID string, ev
 
 // GetWorkflowInstance returns a pending workflow task or nil if there are no pending worflow executions
 func (b *mysqlBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, error) {
+	return b.GetWorkflowTaskFromQueues(ctx, nil)
+}
+
+// GetWorkflowTaskFromQueues returns a pending workflow task of an instance in one of the given queues or nil if there
+// are no pending workflow executions
+func (b *mysqlBackend) GetWorkflowTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Workflow, error) {
 	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelReadCommitted,
 	})
@@ -325,23 +627,39 @@ func (b *mysqlBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, err
 	defer tx.Rollback()
 
 	// Lock next workflow task by finding an unlocked instance with new events to process.
-	now := time.Now()
+	now := b.options.Clock.Now()
+	orderBy, orderByArgs := orderByPriority("i.priority", b.workflowPriorityOrder.Next())
+	queueFilter, queueArgs := inQueues(queues)
+	args := []interface{}{
+		b.options.Namespace,
+		now,               // event.visible_at
+		now,               // locked_until
+		now,               // sticky_until
+		b.workerName,      // worker
+		b.options.BuildID, // any build_id
+		b.options.BuildID, // matching build_id
+	}
+	args = append(args, queueArgs...)
+	args = append(args, orderByArgs...)
 	row := tx.QueryRowContext(
 		ctx,
-		`SELECT i.id, i.instance_id, i.execution_id, i.parent_instance_id, i.parent_execution_id, i.parent_schedule_event_id, i.metadata, i.sticky_until
+		`SELECT i.id, i.instance_id, i.execution_id, i.parent_instance_id, i.parent_execution_id, i.parent_schedule_event_id, i.metadata, i.sticky_until, i.task_attempts
 			FROM instances i
-			INNER JOIN pending_events pe ON i.instance_id = pe.instance_id
+			INNER JOIN pending_events pe ON i.namespace = pe.namespace AND i.instance_id = pe.instance_id
 			WHERE
-				i.completed_at IS NULL
+				i.namespace = ?
+				AND i.completed_at IS NULL
+				AND NOT i.paused
+				AND i.dead_letter_reason IS NULL
 				AND (pe.visible_at IS NULL OR pe.visible_at <= ?)
 				AND (i.locked_until IS NULL OR i.locked_until < ?)
 				AND (i.sticky_until IS NULL OR i.sticky_until < ? OR i.worker = ?)
+				AND (? = '' OR i.build_id IS NULL OR i.build_id = ?)
+				AND i.queue `+queueFilter+`
+			ORDER BY `+orderBy+`
 			LIMIT 1
 			FOR UPDATE OF i SKIP LOCKED`,
-		now,          // event.visible_at
-		now,          // locked_until
-		now,          // sticky_until
-		b.workerName, // worker
+		args...,
 	)
 
 	var id int
@@ -350,7 +668,8 @@ func (b *mysqlBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, err
 	var parentEventID *int64
 	var metadataJson sql.NullString
 	var stickyUntil *time.Time
-	if err := row.Scan(&id, &instanceID, &executionID, &parentInstanceID, &parentExecutionID, &parentEventID, &metadataJson, &stickyUntil); err != nil {
+	var attempts int
+	if err := row.Scan(&id, &instanceID, &executionID, &parentInstanceID, &parentExecutionID, &parentEventID, &metadataJson, &stickyUntil, &attempts); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -361,10 +680,11 @@ func (b *mysqlBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, err
 	res, err := tx.ExecContext(
 		ctx,
 		`UPDATE instances i
-			SET locked_until = ?, worker = ?
+			SET locked_until = ?, worker = ?, build_id = COALESCE(build_id, NULLIF(?, '')), task_attempts = task_attempts + 1
 			WHERE id = ?`,
 		now.Add(b.options.WorkflowLockTimeout),
 		b.workerName,
+		b.options.BuildID,
 		id,
 	)
 	if err != nil {
@@ -398,12 +718,14 @@ func (b *mysqlBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, err
 		WorkflowInstanceState: core.WorkflowInstanceStateActive,
 		Metadata:              metadata,
 		NewEvents:             []*history.Event{},
+		Attempt:               attempts + 1,
 	}
 
 	// Get new events
 	events, err := tx.QueryContext(
 		ctx,
-		"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM `pending_events` WHERE instance_id = ? AND execution_id = ? AND (`visible_at` IS NULL OR `visible_at` <= ?) ORDER BY id",
+		"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM `pending_events` WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND (`visible_at` IS NULL OR `visible_at` <= ?) ORDER BY id",
+		b.options.Namespace,
 		instanceID,
 		executionID,
 		now,
@@ -445,7 +767,7 @@ func (b *mysqlBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, err
 	}
 
 	// Get most recent sequence id
-	row = tx.QueryRowContext(ctx, "SELECT sequence_id FROM `history` WHERE instance_id = ? AND execution_id = ? ORDER BY id DESC LIMIT 1", instanceID, executionID)
+	row = tx.QueryRowContext(ctx, "SELECT sequence_id FROM `history` WHERE namespace = ? AND instance_id = ? AND execution_id = ? ORDER BY id DESC LIMIT 1", b.options.Namespace, instanceID, executionID)
 	if err := row.Scan(
 		&t.LastSequenceID,
 	); err != nil {
@@ -485,16 +807,17 @@ func (b *mysqlBackend) CompleteWorkflowTask(
 	// Unlock instance, but keep it sticky to the current worker
 	var completedAt *time.Time
 	if state == core.WorkflowInstanceStateFinished {
-		t := time.Now()
+		t := b.options.Clock.Now()
 		completedAt = &t
 	}
 
 	res, err := tx.ExecContext(
 		ctx,
-		`UPDATE instances SET locked_until = NULL, sticky_until = ?, completed_at = ?, state = ? WHERE instance_id = ? AND execution_id = ? AND worker = ?`,
-		time.Now().Add(b.options.StickyTimeout),
+		`UPDATE instances SET locked_until = NULL, sticky_until = ?, completed_at = ?, state = ?, task_attempts = 0 WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND worker = ?`,
+		b.options.Clock.Now().Add(b.options.StickyTimeout),
 		completedAt,
 		state,
+		b.options.Namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 		b.workerName,
@@ -507,20 +830,20 @@ func (b *mysqlBackend) CompleteWorkflowTask(
 	if err != nil {
 		return fmt.Errorf("checking for unlocked workflow instances: %w", err)
 	} else if changedRows != 1 {
-		return errors.New("could not find workflow instance to unlock")
+		return fmt.Errorf("could not find workflow instance to unlock: %w", backend.ErrWorkflowTaskLost)
 	}
 
 	// Remove handled events from task
 	if len(executedEvents) > 0 {
-		args := make([]interface{}, 0, len(executedEvents)+1)
-		args = append(args, instance.InstanceID, instance.ExecutionID)
+		args := make([]interface{}, 0, len(executedEvents)+3)
+		args = append(args, b.options.Namespace, instance.InstanceID, instance.ExecutionID)
 		for _, e := range executedEvents {
 			args = append(args, e.ID)
 		}
 
 		if _, err := tx.ExecContext(
 			ctx,
-			fmt.Sprintf(`DELETE FROM pending_events WHERE instance_id = ? AND execution_id = ? AND event_id IN (?%v)`, strings.Repeat(",?", len(executedEvents)-1)),
+			fmt.Sprintf(`DELETE FROM pending_events WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND event_id IN (?%v)`, strings.Repeat(",?", len(executedEvents)-1)),
 			args...,
 		); err != nil {
 			return fmt.Errorf("deleting handled new events: %w", err)
@@ -528,28 +851,34 @@ func (b *mysqlBackend) CompleteWorkflowTask(
 	}
 
 	// Insert new events generated during this workflow execution to the history
-	if err := insertHistoryEvents(ctx, tx, instance, executedEvents); err != nil {
+	if err := insertHistoryEvents(ctx, tx, b.options.Namespace, instance, executedEvents); err != nil {
 		return fmt.Errorf("inserting new history events: %w", err)
 	}
 
 	// Schedule activities
 	for _, e := range activityEvents {
-		if err := scheduleActivity(ctx, tx, instance, e); err != nil {
+		if err := scheduleActivity(ctx, tx, b.options.Namespace, instance, e); err != nil {
 			return fmt.Errorf("scheduling activity: %w", err)
 		}
 	}
 
 	// Timer events
-	if err := insertPendingEvents(ctx, tx, instance, timerEvents); err != nil {
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, timerEvents); err != nil {
 		return fmt.Errorf("scheduling timers: %w", err)
 	}
 
 	for _, event := range executedEvents {
 		switch event.Type {
 		case history.EventType_TimerCanceled:
-			if err := removeFutureEvent(ctx, tx, instance, event.ScheduleEventID); err != nil {
+			if err := removeFutureEvent(ctx, tx, b.options.Namespace, instance, event.ScheduleEventID); err != nil {
 				return fmt.Errorf("removing future event: %w", err)
 			}
+
+		case history.EventType_SearchAttributesUpserted:
+			a := event.Attributes.(*history.SearchAttributesUpsertedAttributes)
+			if err := upsertSearchAttributes(ctx, tx, b.options.Namespace, instance, a.SearchAttributes); err != nil {
+				return fmt.Errorf("upserting search attributes: %w", err)
+			}
 		}
 	}
 
@@ -561,7 +890,7 @@ func (b *mysqlBackend) CompleteWorkflowTask(
 			if m.HistoryEvent.Type == history.EventType_WorkflowExecutionStarted {
 				a := m.HistoryEvent.Attributes.(*history.ExecutionStartedAttributes)
 				// Create new instance
-				if err := createInstance(ctx, tx, m.WorkflowInstance, a.Metadata, true); err != nil {
+				if err := createInstance(ctx, tx, b.options.Namespace, m.WorkflowInstance, a, true); err != nil {
 					return err
 				}
 
@@ -574,7 +903,7 @@ func (b *mysqlBackend) CompleteWorkflowTask(
 			historyEvents = append(historyEvents, m.HistoryEvent)
 		}
 
-		if err := insertPendingEvents(ctx, tx, &targetInstance, historyEvents); err != nil {
+		if err := insertPendingEvents(ctx, tx, b.options.Namespace, &targetInstance, historyEvents); err != nil {
 			return fmt.Errorf("inserting messages: %w", err)
 		}
 	}
@@ -583,6 +912,10 @@ func (b *mysqlBackend) CompleteWorkflowTask(
 		return fmt.Errorf("committing complete workflow transaction: %w", err)
 	}
 
+	if (state == core.WorkflowInstanceStateFinished || state == core.WorkflowInstanceStateContinuedAsNew) && b.options.Archiver != nil {
+		b.tryArchiveHistory(ctx, instance)
+	}
+
 	return nil
 }
 
@@ -593,11 +926,12 @@ func (b *mysqlBackend) ExtendWorkflowTask(ctx context.Context, taskID string, in
 	}
 	defer tx.Rollback()
 
-	until := time.Now().Add(b.options.WorkflowLockTimeout)
+	until := b.options.Clock.Now().Add(b.options.WorkflowLockTimeout)
 	res, err := tx.ExecContext(
 		ctx,
-		`UPDATE instances SET locked_until = ? WHERE instance_id = ? AND execution_id = ? AND worker = ?`,
+		`UPDATE instances SET locked_until = ? WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND worker = ?`,
 		until,
+		b.options.Namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 		b.workerName,
@@ -617,6 +951,12 @@ func (b *mysqlBackend) ExtendWorkflowTask(ctx context.Context, taskID string, in
 
 // GetActivityTask returns a pending activity task or nil if there are no pending activities
 func (b *mysqlBackend) GetActivityTask(ctx context.Context) (*task.Activity, error) {
+	return b.GetActivityTaskFromQueues(ctx, nil)
+}
+
+// GetActivityTaskFromQueues returns a pending activity task in one of the given queues or nil if there are no pending
+// activities
+func (b *mysqlBackend) GetActivityTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Activity, error) {
 	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelReadCommitted,
 	})
@@ -626,26 +966,39 @@ func (b *mysqlBackend) GetActivityTask(ctx context.Context) (*task.Activity, err
 	defer tx.Rollback()
 
 	// Lock next activity
-	now := time.Now()
+	now := b.options.Clock.Now()
+	orderBy, orderByArgs := orderByPriority("activities.priority", b.activityPriorityOrder.Next())
+	queueFilter, queueArgs := inQueues(queues)
+
+	args := append([]interface{}{b.options.Namespace, now}, queueArgs...)
+	args = append(args, orderByArgs...)
+
 	res := tx.QueryRowContext(
 		ctx,
 		`SELECT activities.id, activity_id, activities.instance_id, activities.execution_id,
-			event_type, timestamp, schedule_event_id, attributes, visible_at
+			event_type, timestamp, schedule_event_id, attributes, visible_at, activities.locked_until IS NOT NULL,
+			last_heartbeat, heartbeat_details
 			FROM activities
-			WHERE activities.locked_until IS NULL OR activities.locked_until < ?
+			WHERE activities.namespace = ? AND (activities.locked_until IS NULL OR activities.locked_until < ?)
+				AND activities.queue `+queueFilter+`
+			ORDER BY `+orderBy+`
 			LIMIT 1
 			FOR UPDATE SKIP LOCKED`,
-		now,
+		args...,
 	)
 
 	var id int64
 	var instanceID, executionID string
 	var attributes []byte
+	var redelivered bool
+	var lastHeartbeat sql.NullTime
+	var heartbeatDetails []byte
 	event := &history.Event{}
 
 	if err := res.Scan(
 		&id, &event.ID, &instanceID, &executionID, &event.Type,
-		&event.Timestamp, &event.ScheduleEventID, &attributes, &event.VisibleAt); err != nil {
+		&event.Timestamp, &event.ScheduleEventID, &attributes, &event.VisibleAt, &redelivered,
+		&lastHeartbeat, &heartbeatDetails); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -674,6 +1027,9 @@ func (b *mysqlBackend) GetActivityTask(ctx context.Context) (*task.Activity, err
 		ID:               event.ID,
 		WorkflowInstance: core.NewWorkflowInstance(instanceID, executionID),
 		Event:            event,
+		Redelivered:      redelivered,
+		LastHeartbeat:    lastHeartbeat.Time,
+		HeartbeatDetails: heartbeatDetails,
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -696,7 +1052,8 @@ func (b *mysqlBackend) CompleteActivityTask(ctx context.Context, instance *workf
 	// Remove activity
 	if res, err := tx.ExecContext(
 		ctx,
-		`DELETE FROM activities WHERE activity_id = ? AND instance_id = ? AND execution_id = ? AND worker = ?`,
+		`DELETE FROM activities WHERE namespace = ? AND activity_id = ? AND instance_id = ? AND execution_id = ? AND worker = ?`,
+		b.options.Namespace,
 		id,
 		instance.InstanceID,
 		instance.ExecutionID,
@@ -715,7 +1072,7 @@ func (b *mysqlBackend) CompleteActivityTask(ctx context.Context, instance *workf
 	}
 
 	// Insert new event generated during this workflow execution
-	if err := insertPendingEvents(ctx, tx, instance, []*history.Event{event}); err != nil {
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
 		return fmt.Errorf("inserting new events for completed activity: %w", err)
 	}
 
@@ -733,11 +1090,12 @@ func (b *mysqlBackend) ExtendActivityTask(ctx context.Context, activityID string
 	}
 	defer tx.Rollback()
 
-	until := time.Now().Add(b.options.ActivityLockTimeout)
+	until := b.options.Clock.Now().Add(b.options.ActivityLockTimeout)
 	res, err := tx.ExecContext(
 		ctx,
-		`UPDATE activities SET locked_until = ? WHERE activity_id = ? AND worker = ?`,
+		`UPDATE activities SET locked_until = ? WHERE namespace = ? AND activity_id = ? AND worker = ?`,
 		until,
+		b.options.Namespace,
 		activityID,
 		b.workerName,
 	)
@@ -754,17 +1112,24 @@ func (b *mysqlBackend) ExtendActivityTask(ctx context.Context, activityID string
 	return tx.Commit()
 }
 
-func scheduleActivity(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance, event *history.Event) error {
+func scheduleActivity(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, event *history.Event) error {
 	a, err := history.SerializeAttributes(event.Attributes)
 	if err != nil {
 		return err
 	}
 
+	var queue core.Queue
+	if sa, ok := event.Attributes.(*history.ActivityScheduledAttributes); ok {
+		queue = sa.Queue
+	}
+
 	_, err = tx.ExecContext(
 		ctx,
 		`INSERT INTO activities
-			(activity_id, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			(activity_id, namespace, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at, queue, priority)
+			SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, priority FROM instances WHERE namespace = ? AND instance_id = ? AND execution_id = ?`,
 		event.ID,
+		namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 		event.Type,
@@ -772,6 +1137,10 @@ func scheduleActivity(ctx context.Context, tx *sql.Tx, instance *core.WorkflowIn
 		event.ScheduleEventID,
 		a,
 		event.VisibleAt,
+		queue.OrDefault(),
+		namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
 	)
 
 	return err
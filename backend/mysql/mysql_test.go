@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cschleiden/go-workflows/backend"
 	"github.com/cschleiden/go-workflows/backend/test"
+	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
 )
 
 const testUser = "root"
@@ -101,6 +104,65 @@ func TestMySqlBackendE2E(t *testing.T) {
 	})
 }
 
+func Test_MysqlBackend_Namespaces(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := context.Background()
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@/?parseTime=true&interpolateParams=true", testUser, testPassword))
+	require.NoError(t, err)
+	defer db.Close()
+
+	dbName := "test_" + strings.Replace(uuid.NewString(), "-", "", -1)
+	_, err = db.Exec("CREATE DATABASE " + dbName)
+	require.NoError(t, err)
+	defer db.Exec("DROP DATABASE IF EXISTS " + dbName)
+
+	a := NewMysqlBackend("localhost", 3306, testUser, testPassword, dbName, backend.WithNamespace("a"))
+	defer a.db.Close()
+	b := NewMysqlBackend("localhost", 3306, testUser, testPassword, dbName, backend.WithNamespace("b"))
+	defer b.db.Close()
+
+	// Instance and execution IDs only need to be unique within a namespace
+	wfi := core.NewWorkflowInstance("instance", "execution")
+
+	for _, mb := range []*mysqlBackend{a, b} {
+		err := mb.CreateWorkflowInstance(ctx,
+			wfi,
+			history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+		)
+		require.NoError(t, err)
+
+		s, err := mb.GetStats(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), s.ActiveWorkflowInstances)
+	}
+}
+
+func Test_MysqlBackend_Namespaces_SameWorkflow(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@/?parseTime=true&interpolateParams=true", testUser, testPassword))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	dbName := "test_" + strings.Replace(uuid.NewString(), "-", "", -1)
+	_, err = db.Exec("CREATE DATABASE " + dbName)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Exec("DROP DATABASE IF EXISTS " + dbName) })
+
+	test.NamespacesBackendTest(t, func(namespace string) test.TestBackend {
+		b := NewMysqlBackend("localhost", 3306, testUser, testPassword, dbName, backend.WithNamespace(namespace), backend.WithStickyTimeout(0))
+		t.Cleanup(func() { b.db.Close() })
+
+		return b
+	})
+}
+
 var _ test.TestBackend = (*mysqlBackend)(nil)
 
 func (mb *mysqlBackend) GetFutureEvents(ctx context.Context) ([]*history.Event, error) {
@@ -0,0 +1,72 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+func (b *mysqlBackend) PauseWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
+	return b.setPaused(ctx, instance, true, event)
+}
+
+func (b *mysqlBackend) ResumeWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
+	return b.setPaused(ctx, instance, false, event)
+}
+
+// setPaused updates the paused flag of the given instance and records the event. Pausing a paused instance or
+// resuming an instance that isn't paused doesn't record another event.
+func (b *mysqlBackend) setPaused(ctx context.Context, instance *workflow.Instance, paused bool, event *history.Event) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE `instances` SET paused = ? WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND paused = ?",
+		paused,
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+		!paused,
+	)
+	if err != nil {
+		return fmt.Errorf("updating workflow instance: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		row := tx.QueryRowContext(
+			ctx,
+			"SELECT 1 FROM `instances` WHERE namespace = ? AND instance_id = ? AND execution_id = ? LIMIT 1",
+			b.options.Namespace,
+			instance.InstanceID,
+			instance.ExecutionID,
+		)
+		if err := row.Scan(new(int)); err != nil {
+			if err == sql.ErrNoRows {
+				return backend.ErrInstanceNotFound
+			}
+
+			return err
+		}
+
+		// Already in the requested state
+		return nil
+	}
+
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
+		return fmt.Errorf("inserting %v event: %w", event.Type, err)
+	}
+
+	return tx.Commit()
+}
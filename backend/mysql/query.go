@@ -0,0 +1,213 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+)
+
+var _ backend.Querier = (*mysqlBackend)(nil)
+
+func (b *mysqlBackend) QueueQuery(ctx context.Context, q *backend.Query) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := b.removeExpiredQueries(ctx, tx); err != nil {
+		return err
+	}
+
+	var executionID string
+	var parentInstanceID, parentExecutionID *string
+	var parentEventID *int64
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT execution_id, parent_instance_id, parent_execution_id, parent_schedule_event_id FROM `instances` WHERE namespace = ? AND instance_id = ? AND state = ? LIMIT 1",
+		b.options.Namespace,
+		q.InstanceID,
+		core.WorkflowInstanceStateActive,
+	).Scan(&executionID, &parentInstanceID, &parentExecutionID, &parentEventID); err != nil {
+		if err == sql.ErrNoRows {
+			return backend.ErrInstanceNotFound
+		}
+
+		return fmt.Errorf("reading workflow instance: %w", err)
+	}
+
+	instance := core.NewWorkflowInstance(q.InstanceID, executionID)
+	if parentInstanceID != nil {
+		instance = core.NewSubWorkflowInstance(q.InstanceID, executionID, core.NewWorkflowInstance(*parentInstanceID, *parentExecutionID), *parentEventID)
+	}
+
+	instanceJson, err := json.Marshal(instance)
+	if err != nil {
+		return fmt.Errorf("marshaling instance: %w", err)
+	}
+
+	args, err := json.Marshal(q.Args)
+	if err != nil {
+		return fmt.Errorf("marshaling query arguments: %w", err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO `queries` (namespace, query_id, instance, name, args, deadline) VALUES (?, ?, ?, ?, ?, ?)",
+		b.options.Namespace,
+		q.ID,
+		string(instanceJson),
+		q.Name,
+		args,
+		q.Deadline.UnixNano(),
+	); err != nil {
+		return fmt.Errorf("inserting query: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (b *mysqlBackend) GetQueryTask(ctx context.Context) (*backend.QueryTask, error) {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := b.removeExpiredQueries(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT id, query_id, instance, name, args, deadline FROM queries
+			WHERE namespace = ? AND locked = 0
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED`,
+		b.options.Namespace,
+	)
+
+	t := &backend.QueryTask{}
+	var id int64
+	var instanceJson string
+	var args []byte
+	var deadline int64
+	if err := row.Scan(&id, &t.ID, &instanceJson, &t.Name, &args, &deadline); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("finding query to lock: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE `queries` SET locked = 1 WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("locking query: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(instanceJson), &t.Instance); err != nil {
+		return nil, fmt.Errorf("unmarshaling instance: %w", err)
+	}
+
+	if err := json.Unmarshal(args, &t.Args); err != nil {
+		return nil, fmt.Errorf("unmarshaling query arguments: %w", err)
+	}
+
+	t.InstanceID = t.Instance.InstanceID
+	t.Deadline = time.Unix(0, deadline)
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (b *mysqlBackend) CompleteQueryTask(ctx context.Context, queryID string, result *backend.QueryResult) error {
+	r, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshaling query result: %w", err)
+	}
+
+	if _, err := b.db.ExecContext(
+		ctx,
+		"UPDATE `queries` SET result = ? WHERE namespace = ? AND query_id = ?",
+		r,
+		b.options.Namespace,
+		queryID,
+	); err != nil {
+		return fmt.Errorf("storing query result: %w", err)
+	}
+
+	return nil
+}
+
+func (b *mysqlBackend) GetQueryResult(ctx context.Context, queryID string) (*backend.QueryResult, error) {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var r []byte
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT result FROM `queries` WHERE namespace = ? AND query_id = ?",
+		b.options.Namespace,
+		queryID,
+	).Scan(&r); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("reading query result: %w", err)
+	}
+
+	if r == nil {
+		return nil, nil
+	}
+
+	result := &backend.QueryResult{}
+	if err := json.Unmarshal(r, result); err != nil {
+		return nil, fmt.Errorf("unmarshaling query result: %w", err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM `queries` WHERE namespace = ? AND query_id = ?",
+		b.options.Namespace,
+		queryID,
+	); err != nil {
+		return nil, fmt.Errorf("removing query: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (b *mysqlBackend) removeExpiredQueries(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM `queries` WHERE namespace = ? AND deadline < ?",
+		b.options.Namespace,
+		b.options.Clock.Now().UnixNano(),
+	); err != nil {
+		return fmt.Errorf("removing expired queries: %w", err)
+	}
+
+	return nil
+}
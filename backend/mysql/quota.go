@@ -0,0 +1,12 @@
+package mysql
+
+import "github.com/cschleiden/go-workflows/internal/sqlquota"
+
+// quotas checks active instance limits when creating instances
+var quotas = sqlquota.Dialect{
+	Lock:        "INSERT INTO `workflow_concurrency_locks` (namespace, workflow_name) VALUES (?, ?) ON DUPLICATE KEY UPDATE workflow_name = workflow_name",
+	CountActive: "SELECT COUNT(*) FROM `instances` WHERE namespace = ? AND state = ?",
+	CountActiveTenant: `SELECT COUNT(*) FROM instances i INNER JOIN search_attributes sa
+		ON sa.namespace = i.namespace AND sa.instance_id = i.instance_id AND sa.execution_id = i.execution_id
+		WHERE i.namespace = ? AND i.state = ? AND sa.name = ? AND sa.type = ? AND sa.value = ?`,
+}
@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.RateLimiter = (*mysqlBackend)(nil)
+
+func (b *mysqlBackend) AcquireActivityRateLimit(ctx context.Context, activityName string) (time.Duration, error) {
+	limit, ok := b.options.ActivityRateLimits[activityName]
+	if !ok {
+		return 0, nil
+	}
+
+	return b.AcquireRateLimit(ctx, "activity:"+activityName, limit)
+}
+
+func (b *mysqlBackend) AcquireRateLimit(ctx context.Context, name string, limit backend.RateLimit) (time.Duration, error) {
+	if limit.Limit <= 0 || limit.Interval <= 0 {
+		return 0, nil
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := b.options.Clock.Now()
+
+	// Buckets start out full. Create the bucket first, so that concurrent workers can lock the row.
+	if _, err := tx.ExecContext(
+		ctx,
+		"INSERT IGNORE INTO `rate_limits` (namespace, name, tokens, updated_at) VALUES (?, ?, ?, ?)",
+		b.options.Namespace,
+		name,
+		float64(limit.Limit),
+		now.UnixNano(),
+	); err != nil {
+		return 0, fmt.Errorf("creating rate limit: %w", err)
+	}
+
+	var tokens float64
+	var updatedAtNanos int64
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT tokens, updated_at FROM `rate_limits` WHERE namespace = ? AND name = ? FOR UPDATE",
+		b.options.Namespace,
+		name,
+	).Scan(&tokens, &updatedAtNanos); err != nil {
+		return 0, fmt.Errorf("reading rate limit: %w", err)
+	}
+
+	tokens, wait := limit.Take(tokens, time.Unix(0, updatedAtNanos), now)
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"UPDATE `rate_limits` SET tokens = ?, updated_at = ? WHERE namespace = ? AND name = ?",
+		tokens,
+		now.UnixNano(),
+		b.options.Namespace,
+		name,
+	); err != nil {
+		return 0, fmt.Errorf("updating rate limit: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return wait, nil
+}
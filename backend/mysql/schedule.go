@@ -0,0 +1,127 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.ScheduleStore = (*mysqlBackend)(nil)
+
+func (b *mysqlBackend) CreateSchedule(ctx context.Context, s *backend.Schedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshaling schedule: %w", err)
+	}
+
+	res, err := b.db.ExecContext(
+		ctx,
+		"INSERT IGNORE INTO `schedules` (namespace, id, schedule) VALUES (?, ?, ?)",
+		b.options.Namespace,
+		s.ID,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting schedule: %w", err)
+	}
+
+	return expectAffected(res, backend.ErrScheduleAlreadyExists)
+}
+
+func (b *mysqlBackend) UpdateSchedule(ctx context.Context, s *backend.Schedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshaling schedule: %w", err)
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Affected rows don't include rows that were matched but not changed, check for the schedule explicitly
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT 1 FROM `schedules` WHERE namespace = ? AND id = ? FOR UPDATE",
+		b.options.Namespace,
+		s.ID,
+	).Scan(new(int)); err != nil {
+		if err == sql.ErrNoRows {
+			return backend.ErrScheduleNotFound
+		}
+
+		return fmt.Errorf("reading schedule: %w", err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"UPDATE `schedules` SET schedule = ? WHERE namespace = ? AND id = ?",
+		data,
+		b.options.Namespace,
+		s.ID,
+	); err != nil {
+		return fmt.Errorf("updating schedule: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (b *mysqlBackend) DeleteSchedule(ctx context.Context, id string) error {
+	res, err := b.db.ExecContext(
+		ctx,
+		"DELETE FROM `schedules` WHERE namespace = ? AND id = ?",
+		b.options.Namespace,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("deleting schedule: %w", err)
+	}
+
+	return expectAffected(res, backend.ErrScheduleNotFound)
+}
+
+func (b *mysqlBackend) ListSchedules(ctx context.Context) ([]*backend.Schedule, error) {
+	rows, err := b.db.QueryContext(
+		ctx,
+		"SELECT schedule FROM `schedules` WHERE namespace = ? ORDER BY id",
+		b.options.Namespace,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*backend.Schedule
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("scanning schedule: %w", err)
+		}
+
+		var s *backend.Schedule
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("unmarshaling schedule: %w", err)
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+func expectAffected(res sql.Result, notAffected error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return notAffected
+	}
+
+	return nil
+}
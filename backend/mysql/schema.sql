@@ -1,5 +1,6 @@
 CREATE TABLE IF NOT EXISTS `instances` (
   `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `namespace` NVARCHAR(128) NOT NULL DEFAULT 'default',
   `instance_id` NVARCHAR(128) NOT NULL,
   `execution_id` NVARCHAR(128) NOT NULL,
   `parent_instance_id` NVARCHAR(128) NULL,
@@ -12,8 +13,18 @@ CREATE TABLE IF NOT EXISTS `instances` (
   `locked_until` DATETIME NULL,
   `sticky_until` DATETIME NULL,
   `worker` NVARCHAR(64) NULL,
+  `priority` INT NOT NULL DEFAULT 0,
+  `workflow_name` NVARCHAR(255) NULL,
+  `paused` BOOLEAN NOT NULL DEFAULT FALSE,
+  `build_id` NVARCHAR(255) NULL,
+  `task_attempts` INT NOT NULL DEFAULT 0,
+  `dead_letter_reason` TEXT NULL,
+  `memo` TEXT NULL,
+  `queue` NVARCHAR(128) NOT NULL DEFAULT 'default',
+  `archived` BOOLEAN NOT NULL DEFAULT FALSE,
 
-  UNIQUE INDEX `idx_instances_instance_id_execution_id` (`instance_id`, `execution_id`),
+  UNIQUE INDEX `idx_instances_namespace_instance_id_execution_id` (`namespace`, `instance_id`, `execution_id`),
+  INDEX `idx_instances_instance_id_execution_id` (`instance_id`, `execution_id`),
   INDEX `idx_instances_locked_until_completed_at` (`completed_at`, `locked_until`, `sticky_until`, `worker`),
   INDEX `idx_instances_parent_instance_id_parent_execution_id` (`parent_instance_id`, `parent_execution_id`)
 );
@@ -22,6 +33,7 @@ CREATE TABLE IF NOT EXISTS `instances` (
 CREATE TABLE IF NOT EXISTS `pending_events` (
   `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
   `event_id` NVARCHAR(128) NOT NULL,
+  `namespace` NVARCHAR(128) NOT NULL DEFAULT 'default',
   `sequence_id` BIGINT NOT NULL, -- Not used, but keep for now for query compat
   `instance_id` NVARCHAR(128) NOT NULL,
   `execution_id` NVARCHAR(128) NOT NULL,
@@ -32,13 +44,15 @@ CREATE TABLE IF NOT EXISTS `pending_events` (
   `visible_at` DATETIME NULL,
 
   INDEX `idx_pending_events_inid_exid` (`instance_id`, `execution_id`),
-  INDEX `idx_pending_events_inid_exid_visible_at_schedule_event_id` (`instance_id`, `execution_id`, `visible_at`, `schedule_event_id`)
+  INDEX `idx_pending_events_inid_exid_visible_at_schedule_event_id` (`instance_id`, `execution_id`, `visible_at`, `schedule_event_id`),
+  INDEX `idx_pending_events_namespace_inid_exid_visible_at` (`namespace`, `instance_id`, `execution_id`, `visible_at`)
 );
 
 
 CREATE TABLE IF NOT EXISTS `history` (
   `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
   `event_id` NVARCHAR(64) NOT NULL,
+  `namespace` NVARCHAR(128) NOT NULL DEFAULT 'default',
   `sequence_id` BIGINT NOT NULL,
   `instance_id` NVARCHAR(128) NOT NULL,
   `execution_id` NVARCHAR(128) NOT NULL,
@@ -49,13 +63,15 @@ CREATE TABLE IF NOT EXISTS `history` (
   `visible_at` DATETIME NULL, -- Is this required?
 
   INDEX `idx_history_instance_id_execution_id` (`instance_id`, `execution_id`),
-  INDEX `idx_history_instance_id_execution_id_sequence_id` (`instance_id`, `execution_id`, `sequence_id`)
+  INDEX `idx_history_instance_id_execution_id_sequence_id` (`instance_id`, `execution_id`, `sequence_id`),
+  INDEX `idx_history_namespace_instance_id_execution_id_sequence_id` (`namespace`, `instance_id`, `execution_id`, `sequence_id`)
 );
 
 
 CREATE TABLE IF NOT EXISTS `activities` (
   `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
   `activity_id` NVARCHAR(64) NOT NULL,
+  `namespace` NVARCHAR(128) NOT NULL DEFAULT 'default',
   `instance_id` NVARCHAR(128) NOT NULL,
   `execution_id` NVARCHAR(128) NOT NULL,
   `event_type` INT NOT NULL,
@@ -65,7 +81,92 @@ CREATE TABLE IF NOT EXISTS `activities` (
   `visible_at` DATETIME NULL,
   `locked_until` DATETIME NULL,
   `worker` NVARCHAR(64) NULL,
+  `priority` INT NOT NULL DEFAULT 0,
+  `last_heartbeat` DATETIME NULL,
+  `heartbeat_details` BLOB NULL,
+  `queue` NVARCHAR(128) NOT NULL DEFAULT 'default',
 
-  UNIQUE INDEX `idx_activities_instance_id_execution_id_activity_id_worker` (`instance_id`, `execution_id`, `activity_id`, `worker`),
+  UNIQUE INDEX `idx_activities_namespace_instance_id_execution_id_activity_id_worker` (`namespace`, `instance_id`, `execution_id`, `activity_id`, `worker`),
+  INDEX `idx_activities_instance_id_execution_id_activity_id_worker` (`instance_id`, `execution_id`, `activity_id`, `worker`),
   INDEX `idx_activities_locked_until` (`locked_until`)
-);
\ No newline at end of file
+);
+
+CREATE TABLE IF NOT EXISTS `rate_limits` (
+  `namespace` NVARCHAR(128) NOT NULL,
+  `name` NVARCHAR(255) NOT NULL,
+  `tokens` DOUBLE NOT NULL,
+  `updated_at` BIGINT NOT NULL,
+
+  PRIMARY KEY(`namespace`, `name`)
+);
+
+CREATE TABLE IF NOT EXISTS `leases` (
+  `namespace` NVARCHAR(128) NOT NULL,
+  `name` NVARCHAR(255) NOT NULL,
+  `holder` NVARCHAR(255) NOT NULL,
+  `expires_at` BIGINT NOT NULL,
+
+  PRIMARY KEY(`namespace`, `name`)
+);
+
+CREATE TABLE IF NOT EXISTS `signal_deduplication` (
+  `namespace` NVARCHAR(128) NOT NULL,
+  `instance_id` NVARCHAR(128) NOT NULL,
+  `hash` CHAR(64) NOT NULL,
+  `expires_at` BIGINT NOT NULL,
+
+  PRIMARY KEY(`namespace`, `instance_id`, `hash`)
+);
+
+CREATE TABLE IF NOT EXISTS `workflow_concurrency_locks` (
+  `namespace` NVARCHAR(128) NOT NULL,
+  `workflow_name` NVARCHAR(255) NOT NULL,
+
+  PRIMARY KEY(`namespace`, `workflow_name`)
+);
+
+CREATE TABLE IF NOT EXISTS `queries` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `namespace` NVARCHAR(128) NOT NULL,
+  `query_id` NVARCHAR(64) NOT NULL,
+  `instance` TEXT NOT NULL,
+  `name` NVARCHAR(255) NOT NULL,
+  `args` BLOB NOT NULL,
+  `deadline` BIGINT NOT NULL,
+  `locked` TINYINT NOT NULL DEFAULT 0,
+  `result` BLOB NULL,
+
+  UNIQUE INDEX `idx_queries_namespace_query_id` (`namespace`, `query_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `schedules` (
+  `namespace` NVARCHAR(128) NOT NULL,
+  `id` NVARCHAR(128) NOT NULL,
+  `schedule` BLOB NOT NULL,
+
+  PRIMARY KEY(`namespace`, `id`)
+);
+
+CREATE TABLE IF NOT EXISTS `pending_activities` (
+  `namespace` NVARCHAR(128) NOT NULL,
+  `activity_id` NVARCHAR(64) NOT NULL,
+  `instance_id` NVARCHAR(128) NOT NULL,
+  `execution_id` NVARCHAR(128) NOT NULL,
+  `event` BLOB NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY(`namespace`, `activity_id`),
+  INDEX `idx_pending_activities_instance_id_execution_id` (`instance_id`, `execution_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `search_attributes` (
+  `namespace` NVARCHAR(128) NOT NULL,
+  `instance_id` NVARCHAR(128) NOT NULL,
+  `execution_id` NVARCHAR(128) NOT NULL,
+  `name` NVARCHAR(128) NOT NULL,
+  `type` INT NOT NULL,
+  `value` NVARCHAR(255) NOT NULL,
+
+  PRIMARY KEY(`namespace`, `instance_id`, `execution_id`, `name`),
+  INDEX `idx_search_attributes_namespace_name_type_value` (`namespace`, `name`, `type`, `value`)
+);
@@ -0,0 +1,51 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+)
+
+// upsertSearchAttributes stores the given search attributes for the instance, replacing existing values of
+// attributes with the same name
+func upsertSearchAttributes(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, attributes core.SearchAttributes) error {
+	for name, a := range attributes {
+		if _, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO `search_attributes` (namespace, instance_id, execution_id, name, type, value) VALUES (?, ?, ?, ?, ?, ?) "+
+				"ON DUPLICATE KEY UPDATE type = VALUES(type), value = VALUES(value)",
+			namespace,
+			instance.InstanceID,
+			instance.ExecutionID,
+			name,
+			a.Type,
+			a.Value,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// searchAttributesFilter returns conditions matching instances that have all of the given search attributes
+func searchAttributesFilter(attributes core.SearchAttributes) ([]string, []interface{}) {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	where := make([]string, 0, len(names))
+	args := make([]interface{}, 0, len(names)*3)
+	for _, name := range names {
+		where = append(where, `EXISTS (
+			SELECT 1 FROM search_attributes sa WHERE sa.namespace = i.namespace AND sa.instance_id = i.instance_id AND sa.execution_id = i.execution_id
+				AND sa.name = ? AND sa.type = ? AND sa.value = ?)`)
+		args = append(args, name, attributes[name].Type, attributes[name].Value)
+	}
+
+	return where, args
+}
@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+func (b *mysqlBackend) TerminateWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(
+		ctx,
+		"SELECT 1 FROM `instances` WHERE namespace = ? AND instance_id = ? AND execution_id = ? LIMIT 1",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
+	if err := row.Scan(new(int)); err != nil {
+		if err == sql.ErrNoRows {
+			return backend.ErrInstanceNotFound
+		}
+
+		return err
+	}
+
+	// Paused and dead-lettered instances don't get workflow tasks, resume them to process the termination
+	if _, err := tx.ExecContext(
+		ctx,
+		"UPDATE `instances` SET paused = 0, dead_letter_reason = NULL, task_attempts = 0 WHERE namespace = ? AND instance_id = ? AND execution_id = ?",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	); err != nil {
+		return fmt.Errorf("resuming workflow instance: %w", err)
+	}
+
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
+		return fmt.Errorf("inserting termination event: %w", err)
+	}
+
+	return tx.Commit()
+}
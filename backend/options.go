@@ -3,18 +3,29 @@ package backend
 import (
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/cschleiden/go-workflows/internal/contextpropagation"
 	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/fn"
 	"github.com/cschleiden/go-workflows/internal/logger"
 	mi "github.com/cschleiden/go-workflows/internal/metrics"
 	"github.com/cschleiden/go-workflows/internal/tracing"
 	"github.com/cschleiden/go-workflows/log"
 	"github.com/cschleiden/go-workflows/metrics"
 	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// DefaultNamespace is the namespace used when no namespace is configured
+const DefaultNamespace = "default"
+
 type Options struct {
+	// Namespace isolates workflow instances, task queues, and stats of this backend from backends configured with a
+	// different namespace, even when they share the same storage. Clients and workers use the namespace of the backend
+	// they are created with.
+	Namespace string
+
 	Logger log.Logger
 
 	Metrics metrics.Client
@@ -25,9 +36,29 @@ type Options struct {
 	// converter.DefaultConverter is used.
 	Converter converter.Converter
 
+	// PayloadCodecs transform payloads after they have been encoded by the converter, for example to compress or
+	// encrypt them. They apply to workflow and activity inputs and results, and signals. Codecs encode payloads in the
+	// given order, and decode them in reverse order.
+	PayloadCodecs []converter.PayloadCodec
+
 	// ContextPropagators is a list of context propagators to use for passing context into workflows and activities.
 	ContextPropagators []contextpropagation.ContextPropagator
 
+	// Clock is used for lock timeouts, the visibility of future events, and timestamps recorded by the backend. If
+	// not explicitly set, the system clock is used.
+	Clock clock.Clock
+
+	// IDGenerator generates the identifiers created by the backend, like the names of workers holding task locks,
+	// and the identifiers of workflow instances and executions created by clients and workers using the backend, for
+	// example ULIDs or IDs prefixed with a tenant. Generated identifiers must be unique, creating an instance with an
+	// existing instance ID fails with ErrInstanceAlreadyExists. If not explicitly set, random UUIDs are used.
+	IDGenerator func() string
+
+	// BuildID identifies the code version of workers using this backend. Instances are pinned to the build ID of the
+	// worker executing their first workflow task, afterwards only workers with the same build ID receive their workflow
+	// tasks. Backends without a build ID neither pin instances nor are restricted to instances of a build ID.
+	BuildID string
+
 	StickyTimeout time.Duration
 
 	// WorkflowLockTimeout determines how long a workflow task can be locked for. If the workflow task is not completed
@@ -39,29 +70,169 @@ type Options struct {
 	// ActivityLockTimeout determines how long an activity task can be locked for. If the activity task is not completed
 	// by that timeframe, it's considered abandoned and another worker might pick it up
 	ActivityLockTimeout time.Duration
+
+	// PriorityStarvationInterval determines how often lower priority tasks are preferred when dequeuing tasks. Every
+	// n-th dequeue prefers lower priorities, which prevents higher priority tasks from starving lower priority ones.
+	// When set to 0, higher priority tasks are always preferred.
+	PriorityStarvationInterval int
+
+	// ActivityRateLimits limits how often activities are executed, keyed by activity name. Limits are coordinated via
+	// the backend and apply to all workers sharing the same storage and namespace.
+	ActivityRateLimits map[string]RateLimit
+
+	// SignalDeduplicationWindow, if set, drops signals with the same name and payload as a signal delivered to the
+	// same workflow instance within the window. The default is 0, which delivers all signals.
+	SignalDeduplicationWindow time.Duration
+
+	// OverloadedLatency is the round-trip latency to the datastore at which backends implementing LoadReporter report
+	// full load. Defaults to 250 milliseconds.
+	OverloadedLatency time.Duration
+
+	// WorkflowConcurrencyLimits limits the number of active instances per workflow name. Creating an instance of a
+	// workflow at its limit fails with ErrConcurrencyLimitReached. Sub-workflows and continued executions count
+	// towards the limit, but are not rejected.
+	WorkflowConcurrencyLimits map[string]int
+
+	// Archiver, if set, receives the histories of finished workflow instances. The history is removed from the
+	// storage of the backend once it has been archived, GetWorkflowInstanceHistory reads it from the archiver.
+	// Histories that couldn't be archived are retried by maintenance.ArchiveJob, finished instances are only removed
+	// once their history has been archived.
+	Archiver Archiver
+
+	// Retention, if set, removes finished workflow instances, including their histories, once they have been finished
+	// for longer than the given duration. Instances are removed by the retention job of the maintenance runner, see
+	// maintenance.RetentionJob. The default is 0, which keeps finished instances until they are removed explicitly.
+	Retention time.Duration
 }
 
 var DefaultOptions Options = Options{
+	Namespace: DefaultNamespace,
+
 	StickyTimeout:       30 * time.Second,
 	WorkflowLockTimeout: time.Minute,
 	ActivityLockTimeout: time.Minute * 2,
 
+	PriorityStarvationInterval: 10,
+
+	OverloadedLatency: 250 * time.Millisecond,
+
 	Logger:         logger.NewDefaultLogger(),
 	Metrics:        mi.NewNoopMetricsClient(),
 	TracerProvider: trace.NewNoopTracerProvider(),
 	Converter:      converter.DefaultConverter,
 
 	ContextPropagators: []contextpropagation.ContextPropagator{&tracing.TracingContextPropagator{}},
+
+	Clock:       clock.New(),
+	IDGenerator: uuid.NewString,
 }
 
 type BackendOption func(*Options)
 
+// WithNamespace sets the namespace of the backend. Instances, task queues, and stats are scoped to the namespace.
+func WithNamespace(namespace string) BackendOption {
+	return func(o *Options) {
+		o.Namespace = namespace
+	}
+}
+
+// WithBuildID sets the build ID of workers using this backend. See Options.BuildID.
+func WithBuildID(buildID string) BackendOption {
+	return func(o *Options) {
+		o.BuildID = buildID
+	}
+}
+
 func WithStickyTimeout(timeout time.Duration) BackendOption {
 	return func(o *Options) {
 		o.StickyTimeout = timeout
 	}
 }
 
+// WithWorkflowLockTimeout sets the time after which the lock of a workflow task expires if it's not completed or
+// extended. The task is then delivered again.
+func WithWorkflowLockTimeout(timeout time.Duration) BackendOption {
+	return func(o *Options) {
+		o.WorkflowLockTimeout = timeout
+	}
+}
+
+// WithActivityLockTimeout sets the time after which the lock of an activity task expires if it's not extended. The
+// task is then delivered again.
+func WithActivityLockTimeout(timeout time.Duration) BackendOption {
+	return func(o *Options) {
+		o.ActivityLockTimeout = timeout
+	}
+}
+
+// WithPriorityStarvationInterval sets how often lower priority tasks are preferred when dequeuing tasks. See
+// Options.PriorityStarvationInterval.
+func WithPriorityStarvationInterval(interval int) BackendOption {
+	return func(o *Options) {
+		o.PriorityStarvationInterval = interval
+	}
+}
+
+// WithActivityRateLimit limits the given activity to limit executions per interval, across all workers using the
+// same storage and namespace. Activity tasks exceeding the limit wait in the worker until the limit allows them to
+// execute. Rate limits are only enforced by backends implementing RateLimiter.
+func WithActivityRateLimit(activity interface{}, limit int, interval time.Duration) BackendOption {
+	return func(o *Options) {
+		limits := make(map[string]RateLimit, len(o.ActivityRateLimits)+1)
+		for name, l := range o.ActivityRateLimits {
+			limits[name] = l
+		}
+
+		limits[fn.Name(activity)] = RateLimit{Limit: limit, Interval: interval}
+		o.ActivityRateLimits = limits
+	}
+}
+
+// WithSignalDeduplicationWindow drops signals identical to a signal delivered to the same workflow instance within the
+// given window. This is a coarse guard against retries of upstream systems, like webhooks, delivering the same signal
+// more than once. See Options.SignalDeduplicationWindow.
+func WithSignalDeduplicationWindow(window time.Duration) BackendOption {
+	return func(o *Options) {
+		o.SignalDeduplicationWindow = window
+	}
+}
+
+// WithOverloadedLatency sets the round-trip latency to the datastore at which the backend reports full load. See
+// Options.OverloadedLatency.
+func WithOverloadedLatency(latency time.Duration) BackendOption {
+	return func(o *Options) {
+		o.OverloadedLatency = latency
+	}
+}
+
+// WithWorkflowConcurrencyLimit limits the number of simultaneously active instances of the given workflow to max. See
+// Options.WorkflowConcurrencyLimits.
+func WithWorkflowConcurrencyLimit(wf interface{}, max int) BackendOption {
+	return func(o *Options) {
+		limits := make(map[string]int, len(o.WorkflowConcurrencyLimits)+1)
+		for name, l := range o.WorkflowConcurrencyLimits {
+			limits[name] = l
+		}
+
+		limits[fn.Name(wf)] = max
+		o.WorkflowConcurrencyLimits = limits
+	}
+}
+
+// WithArchiver moves the histories of finished workflow instances to the given archiver. See Options.Archiver.
+func WithArchiver(archiver Archiver) BackendOption {
+	return func(o *Options) {
+		o.Archiver = archiver
+	}
+}
+
+// WithRetention removes finished workflow instances after the given duration. See Options.Retention.
+func WithRetention(retention time.Duration) BackendOption {
+	return func(o *Options) {
+		o.Retention = retention
+	}
+}
+
 func WithLogger(logger log.Logger) BackendOption {
 	return func(o *Options) {
 		o.Logger = logger
@@ -86,12 +257,34 @@ func WithConverter(converter converter.Converter) BackendOption {
 	}
 }
 
+// WithPayloadCodecs adds codecs that transform payloads encoded by the converter, for example to encrypt them
+func WithPayloadCodecs(codecs ...converter.PayloadCodec) BackendOption {
+	return func(o *Options) {
+		o.PayloadCodecs = append(o.PayloadCodecs, codecs...)
+	}
+}
+
 func WithContextPropagator(prop workflow.ContextPropagator) BackendOption {
 	return func(o *Options) {
 		o.ContextPropagators = append(o.ContextPropagators, prop)
 	}
 }
 
+// WithClock sets the clock used by the backend. Use a mock clock to make lock timeouts and the visibility of future
+// events deterministic in tests.
+func WithClock(c clock.Clock) BackendOption {
+	return func(o *Options) {
+		o.Clock = c
+	}
+}
+
+// WithIDGenerator sets the function used to generate identifiers in the backend. See Options.IDGenerator.
+func WithIDGenerator(generator func() string) BackendOption {
+	return func(o *Options) {
+		o.IDGenerator = generator
+	}
+}
+
 func ApplyOptions(opts ...BackendOption) Options {
 	options := DefaultOptions
 
@@ -103,5 +296,21 @@ func ApplyOptions(opts ...BackendOption) Options {
 		options.Logger = logger.NewDefaultLogger()
 	}
 
+	if options.Namespace == "" {
+		options.Namespace = DefaultNamespace
+	}
+
+	if options.Clock == nil {
+		options.Clock = clock.New()
+	}
+
+	if len(options.PayloadCodecs) > 0 {
+		options.Converter = converter.NewCodecConverter(options.Converter, options.PayloadCodecs...)
+	}
+
+	if options.IDGenerator == nil {
+		options.IDGenerator = uuid.NewString
+	}
+
 	return options
 }
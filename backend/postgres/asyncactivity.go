@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+var _ backend.AsyncActivityCompleter = (*postgresBackend)(nil)
+
+// SetActivityTaskPending moves the activity task to the pending activities. If a result arrived while the activity was
+// still executing, the result is added to the instance instead.
+func (b *postgresBackend) SetActivityTaskPending(ctx context.Context, instance *workflow.Instance, id string) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Remove activity
+	if res, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM activities WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 AND activity_id = $4 AND worker = $5`,
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+		id,
+		b.workerName,
+	); err != nil {
+		return fmt.Errorf("unlocking activity: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("checking for deleted activities: %w", err)
+	} else if n != 1 {
+		return errors.New("could not find activity to delete")
+	}
+
+	var eventData []byte
+	if err := tx.QueryRowContext(
+		ctx,
+		"DELETE FROM pending_activities WHERE namespace = $1 AND activity_id = $2 RETURNING event",
+		b.options.Namespace,
+		id,
+	).Scan(&eventData); err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("reading result of pending activity: %w", err)
+	}
+
+	if eventData != nil {
+		// The activity has been completed already
+		var event *history.Event
+		if err := json.Unmarshal(eventData, &event); err != nil {
+			return fmt.Errorf("unmarshaling activity result: %w", err)
+		}
+
+		if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
+			return fmt.Errorf("inserting new events for completed activity: %w", err)
+		}
+	} else if _, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO pending_activities (namespace, activity_id, instance_id, execution_id) VALUES ($1, $2, $3, $4)",
+		b.options.Namespace,
+		id,
+		instance.InstanceID,
+		instance.ExecutionID,
+	); err != nil {
+		return fmt.Errorf("inserting pending activity: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// CompletePendingActivityTask adds the result of a pending activity to the instance. If the activity is still
+// executing, the result is stored until its task is set to pending.
+func (b *postgresBackend) CompletePendingActivityTask(ctx context.Context, instance *workflow.Instance, id string, event *history.Event) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the activity task if it's still executing, setting it to pending waits for this completion
+	executing := true
+	var exists int
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT 1 FROM activities WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 AND activity_id = $4 FOR UPDATE",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+		id,
+	).Scan(&exists); err != nil {
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("checking for executing activity: %w", err)
+		}
+
+		executing = false
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM pending_activities WHERE namespace = $1 AND activity_id = $2 AND instance_id = $3 AND execution_id = $4 AND event IS NULL",
+		b.options.Namespace,
+		id,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
+	if err != nil {
+		return fmt.Errorf("removing pending activity: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("checking for removed pending activity: %w", err)
+	} else if n == 1 {
+		if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
+			return fmt.Errorf("inserting new events for completed activity: %w", err)
+		}
+
+		return tx.Commit()
+	}
+
+	if !executing {
+		return backend.ErrActivityNotFound
+	}
+
+	// The activity is still executing, keep the result until its task is set to pending
+	eventData, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshaling activity result: %w", err)
+	}
+
+	if res, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO pending_activities (namespace, activity_id, instance_id, execution_id, event) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING",
+		b.options.Namespace,
+		id,
+		instance.InstanceID,
+		instance.ExecutionID,
+		eventData,
+	); err != nil {
+		return fmt.Errorf("storing activity result: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("checking for stored activity result: %w", err)
+	} else if n == 0 {
+		// A result has been stored already
+		return backend.ErrActivityNotFound
+	}
+
+	return tx.Commit()
+}
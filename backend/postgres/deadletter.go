@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+func (b *postgresBackend) DeadLetterWorkflowTask(ctx context.Context, t *task.Workflow, reason string) error {
+	res, err := b.db.ExecContext(
+		ctx,
+		"UPDATE instances SET dead_letter_reason = $1, locked_until = NULL, sticky_until = NULL WHERE namespace = $2 AND instance_id = $3 AND execution_id = $4 AND worker = $5",
+		reason,
+		b.options.Namespace,
+		t.WorkflowInstance.InstanceID,
+		t.WorkflowInstance.ExecutionID,
+		b.workerName,
+	)
+	if err != nil {
+		return fmt.Errorf("dead-lettering workflow instance: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("checking for dead-lettered workflow instances: %w", err)
+	} else if n != 1 {
+		return errors.New("could not find workflow instance to dead-letter")
+	}
+
+	return nil
+}
+
+func (b *postgresBackend) RetryWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE instances SET dead_letter_reason = NULL, task_attempts = 0 WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 AND dead_letter_reason IS NOT NULL",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
+	if err != nil {
+		return fmt.Errorf("retrying workflow instance: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		if err := instanceExists(ctx, tx, b.options.Namespace, instance); err != nil {
+			return err
+		}
+
+		// Not dead-lettered
+		return nil
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cschleiden/go-workflows/diag"
+	"github.com/cschleiden/go-workflows/internal/core"
+)
+
+var _ diag.Backend = (*postgresBackend)(nil)
+
+func (b *postgresBackend) Namespace() string {
+	return b.options.Namespace
+}
+
+func (b *postgresBackend) GetWorkflowInstances(ctx context.Context, afterInstanceID, afterExecutionID string, count int) ([]*diag.WorkflowInstanceRef, error) {
+	var err error
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var rows *sql.Rows
+	if afterInstanceID != "" {
+		rows, err = tx.QueryContext(
+			ctx,
+			`SELECT i.instance_id, i.execution_id, i.created_at, i.completed_at, i.dead_letter_reason, i.memo
+			FROM instances i
+			INNER JOIN (SELECT instance_id, created_at FROM instances WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3) ii
+				ON i.created_at < ii.created_at OR (i.created_at = ii.created_at AND i.instance_id < ii.instance_id)
+			WHERE i.namespace = $1
+			ORDER BY i.created_at DESC, i.instance_id DESC
+			LIMIT $4`,
+			b.options.Namespace,
+			afterInstanceID,
+			afterExecutionID,
+			count,
+		)
+	} else {
+		rows, err = tx.QueryContext(
+			ctx,
+			`SELECT i.instance_id, i.execution_id, i.created_at, i.completed_at, i.dead_letter_reason, i.memo
+			FROM instances i
+			WHERE i.namespace = $1
+			ORDER BY i.created_at DESC, i.instance_id DESC
+			LIMIT $2`,
+			b.options.Namespace,
+			count,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var instances []*diag.WorkflowInstanceRef
+
+	for rows.Next() {
+		var id, executionID string
+		var createdAt time.Time
+		var completedAt *time.Time
+		var deadLetterReason, memoJson sql.NullString
+		err = rows.Scan(&id, &executionID, &createdAt, &completedAt, &deadLetterReason, &memoJson)
+		if err != nil {
+			return nil, err
+		}
+
+		memo, err := unmarshalMemo(memoJson)
+		if err != nil {
+			return nil, err
+		}
+
+		var state core.WorkflowInstanceState
+		if completedAt != nil {
+			state = core.WorkflowInstanceStateFinished
+		}
+
+		instances = append(instances, &diag.WorkflowInstanceRef{
+			Instance:         core.NewWorkflowInstance(id, executionID),
+			CreatedAt:        createdAt,
+			CompletedAt:      completedAt,
+			State:            state,
+			DeadLetterReason: deadLetterReason.String,
+			Memo:             memo,
+		})
+	}
+
+	return instances, rows.Err()
+}
+
+func (b *postgresBackend) GetWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance) (*diag.WorkflowInstanceRef, error) {
+	res := b.db.QueryRowContext(
+		ctx,
+		"SELECT instance_id, execution_id, created_at, completed_at, dead_letter_reason, memo FROM instances WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3",
+		b.options.Namespace, instance.InstanceID, instance.ExecutionID)
+
+	var id, executionID string
+	var createdAt time.Time
+	var completedAt *time.Time
+	var deadLetterReason, memoJson sql.NullString
+
+	if err := res.Scan(&id, &executionID, &createdAt, &completedAt, &deadLetterReason, &memoJson); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	memo, err := unmarshalMemo(memoJson)
+	if err != nil {
+		return nil, err
+	}
+
+	var state core.WorkflowInstanceState
+	if completedAt != nil {
+		state = core.WorkflowInstanceStateFinished
+	}
+
+	return &diag.WorkflowInstanceRef{
+		Instance:         core.NewWorkflowInstance(id, executionID),
+		CreatedAt:        createdAt,
+		CompletedAt:      completedAt,
+		State:            state,
+		DeadLetterReason: deadLetterReason.String,
+		Memo:             memo,
+	}, nil
+}
+
+func (b *postgresBackend) GetWorkflowTree(ctx context.Context, instance *core.WorkflowInstance) (*diag.WorkflowInstanceTree, error) {
+	itb := diag.NewInstanceTreeBuilder(b)
+	return itb.BuildWorkflowInstanceTree(ctx, instance)
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+)
+
+func insertPendingEvents(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, newEvents []*history.Event) error {
+	return insertEvents(ctx, tx, "pending_events", namespace, instance, newEvents)
+}
+
+func insertHistoryEvents(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, historyEvents []*history.Event) error {
+	return insertEvents(ctx, tx, "history", namespace, instance, historyEvents)
+}
+
+func insertEvents(ctx context.Context, tx *sql.Tx, tableName string, namespace string, instance *core.WorkflowInstance, events []*history.Event) error {
+	const batchSize = 20
+	const columns = 10
+
+	for batchStart := 0; batchStart < len(events); batchStart += batchSize {
+		batchEnd := batchStart + batchSize
+		if batchEnd > len(events) {
+			batchEnd = len(events)
+		}
+		batchEvents := events[batchStart:batchEnd]
+
+		values := make([]string, 0, len(batchEvents))
+		args := make([]interface{}, 0, len(batchEvents)*columns)
+
+		for i, newEvent := range batchEvents {
+			a, err := history.SerializeAttributes(newEvent.Attributes)
+			if err != nil {
+				return err
+			}
+
+			values = append(values, "("+params(i*columns+1, columns)+")")
+			args = append(
+				args,
+				newEvent.ID, namespace, newEvent.SequenceID, instance.InstanceID, instance.ExecutionID, newEvent.Type, newEvent.Timestamp, newEvent.ScheduleEventID, a, newEvent.VisibleAt)
+		}
+
+		query := "INSERT INTO " + tableName +
+			" (event_id, namespace, sequence_id, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at) VALUES " +
+			strings.Join(values, ", ")
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func removeFutureEvent(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, scheduleEventID int64) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM pending_events WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 AND schedule_event_id = $4 AND visible_at IS NOT NULL",
+		namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+		scheduleEventID,
+	)
+
+	return err
+}
+
+// scanEvent scans an event selected as event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes,
+// visible_at
+func scanEvent(rows *sql.Rows) (*history.Event, error) {
+	var attributes []byte
+
+	event := &history.Event{}
+
+	if err := rows.Scan(
+		&event.ID,
+		&event.SequenceID,
+		&event.Type,
+		&event.Timestamp,
+		&event.ScheduleEventID,
+		&attributes,
+		&event.VisibleAt,
+	); err != nil {
+		return nil, fmt.Errorf("scanning event: %w", err)
+	}
+
+	a, err := history.DeserializeAttributes(event.Type, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("deserializing attributes: %w", err)
+	}
+
+	event.Attributes = a
+
+	return event, nil
+}
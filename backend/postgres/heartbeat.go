@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/payload"
+)
+
+var _ backend.ActivityHeartbeater = (*postgresBackend)(nil)
+
+func (b *postgresBackend) RecordActivityHeartbeat(ctx context.Context, activityID string, details payload.Payload) error {
+	now := b.options.Clock.Now()
+
+	res, err := b.db.ExecContext(
+		ctx,
+		`UPDATE activities SET locked_until = $1, last_heartbeat = $2, heartbeat_details = $3 WHERE namespace = $4 AND activity_id = $5 AND worker = $6`,
+		now.Add(b.options.ActivityLockTimeout),
+		now,
+		[]byte(details),
+		b.options.Namespace,
+		activityID,
+		b.workerName,
+	)
+	if err != nil {
+		return fmt.Errorf("recording activity heartbeat: %w", err)
+	}
+
+	if rowsAffected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("determining if activity heartbeat was recorded: %w", err)
+	} else if rowsAffected == 0 {
+		return errors.New("could not record activity heartbeat")
+	}
+
+	return nil
+}
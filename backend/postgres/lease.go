@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.Leaser = (*postgresBackend)(nil)
+
+func (b *postgresBackend) AcquireLease(ctx context.Context, name, holder string, duration time.Duration) (bool, error) {
+	now := b.options.Clock.Now()
+
+	// Take over the lease if it's held by the same holder or has expired
+	res, err := b.db.ExecContext(
+		ctx,
+		`INSERT INTO leases (namespace, name, holder, expires_at) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (namespace, name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+			WHERE leases.holder = EXCLUDED.holder OR leases.expires_at < $5`,
+		b.options.Namespace,
+		name,
+		holder,
+		now.Add(duration).UnixNano(),
+		now.UnixNano(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("acquiring lease: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("acquiring lease: %w", err)
+	}
+
+	return n == 1, nil
+}
+
+func (b *postgresBackend) ReleaseLease(ctx context.Context, name, holder string) error {
+	if _, err := b.db.ExecContext(
+		ctx,
+		"DELETE FROM leases WHERE namespace = $1 AND name = $2 AND holder = $3",
+		b.options.Namespace,
+		name,
+		holder,
+	); err != nil {
+		return fmt.Errorf("releasing lease: %w", err)
+	}
+
+	return nil
+}
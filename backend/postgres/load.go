@@ -0,0 +1,29 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.LoadReporter = (*postgresBackend)(nil)
+
+// Load reports the higher of the latency of a round-trip to the database, and the saturation of the connection pool
+// if it's limited
+func (b *postgresBackend) Load(ctx context.Context) (float64, error) {
+	start := time.Now()
+	if err := b.db.PingContext(ctx); err != nil {
+		return 1, fmt.Errorf("pinging database: %w", err)
+	}
+
+	load := backend.LatencyLoad(time.Since(start), b.options.OverloadedLatency)
+
+	if stats := b.db.Stats(); stats.MaxOpenConnections > 0 {
+		load = math.Max(load, float64(stats.InUse)/float64(stats.MaxOpenConnections))
+	}
+
+	return math.Min(load, 1), nil
+}
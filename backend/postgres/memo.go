@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+)
+
+func marshalMemo(memo core.Memo) (*string, error) {
+	if len(memo) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(memo)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling memo: %w", err)
+	}
+
+	s := string(b)
+	return &s, nil
+}
+
+func unmarshalMemo(s sql.NullString) (core.Memo, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+
+	var memo core.Memo
+	if err := json.Unmarshal([]byte(s.String), &memo); err != nil {
+		return nil, fmt.Errorf("unmarshaling memo: %w", err)
+	}
+
+	return memo, nil
+}
@@ -0,0 +1,137 @@
+CREATE TABLE IF NOT EXISTS instances (
+  id BIGSERIAL PRIMARY KEY,
+  namespace VARCHAR(128) NOT NULL DEFAULT 'default',
+  instance_id VARCHAR(128) NOT NULL,
+  execution_id VARCHAR(128) NOT NULL,
+  parent_instance_id VARCHAR(128) NULL,
+  parent_execution_id VARCHAR(128) NULL,
+  parent_schedule_event_id BIGINT NULL,
+  metadata TEXT NULL,
+  state INT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  completed_at TIMESTAMPTZ NULL,
+  locked_until TIMESTAMPTZ NULL,
+  sticky_until TIMESTAMPTZ NULL,
+  worker VARCHAR(64) NULL,
+  priority INT NOT NULL DEFAULT 0,
+  workflow_name VARCHAR(255) NULL,
+  paused BOOLEAN NOT NULL DEFAULT FALSE,
+  build_id VARCHAR(255) NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_instances_instance_id_execution_id ON instances (instance_id, execution_id);
+CREATE INDEX IF NOT EXISTS idx_instances_locked_until_completed_at ON instances (completed_at, locked_until, sticky_until, worker);
+CREATE INDEX IF NOT EXISTS idx_instances_parent_instance_id_parent_execution_id ON instances (parent_instance_id, parent_execution_id);
+CREATE INDEX IF NOT EXISTS idx_instances_namespace_instance_id_state ON instances (namespace, instance_id, state);
+CREATE INDEX IF NOT EXISTS idx_instances_namespace_workflow_name_state ON instances (namespace, workflow_name, state);
+
+
+CREATE TABLE IF NOT EXISTS pending_events (
+  id BIGSERIAL PRIMARY KEY,
+  event_id VARCHAR(128) NOT NULL,
+  sequence_id BIGINT NOT NULL, -- Not used, but keep for now for query compat
+  instance_id VARCHAR(128) NOT NULL,
+  execution_id VARCHAR(128) NOT NULL,
+  event_type INT NOT NULL,
+  timestamp TIMESTAMPTZ NOT NULL,
+  schedule_event_id BIGINT NOT NULL,
+  attributes BYTEA NOT NULL,
+  visible_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_events_inid_exid ON pending_events (instance_id, execution_id);
+CREATE INDEX IF NOT EXISTS idx_pending_events_inid_exid_visible_at_schedule_event_id ON pending_events (instance_id, execution_id, visible_at, schedule_event_id);
+
+
+CREATE TABLE IF NOT EXISTS history (
+  id BIGSERIAL PRIMARY KEY,
+  event_id VARCHAR(64) NOT NULL,
+  sequence_id BIGINT NOT NULL,
+  instance_id VARCHAR(128) NOT NULL,
+  execution_id VARCHAR(128) NOT NULL,
+  event_type INT NOT NULL,
+  timestamp TIMESTAMPTZ NOT NULL,
+  schedule_event_id BIGINT NOT NULL,
+  attributes BYTEA NOT NULL,
+  visible_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_history_instance_id_execution_id ON history (instance_id, execution_id);
+CREATE INDEX IF NOT EXISTS idx_history_instance_id_execution_id_sequence_id ON history (instance_id, execution_id, sequence_id);
+
+
+CREATE TABLE IF NOT EXISTS activities (
+  id BIGSERIAL PRIMARY KEY,
+  activity_id VARCHAR(64) NOT NULL,
+  namespace VARCHAR(128) NOT NULL DEFAULT 'default',
+  instance_id VARCHAR(128) NOT NULL,
+  execution_id VARCHAR(128) NOT NULL,
+  event_type INT NOT NULL,
+  timestamp TIMESTAMPTZ NOT NULL,
+  schedule_event_id BIGINT NOT NULL,
+  attributes BYTEA NOT NULL,
+  visible_at TIMESTAMPTZ NULL,
+  locked_until TIMESTAMPTZ NULL,
+  worker VARCHAR(64) NULL,
+  priority INT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_activities_instance_id_execution_id_activity_id_worker ON activities (instance_id, execution_id, activity_id, worker);
+CREATE INDEX IF NOT EXISTS idx_activities_namespace_locked_until ON activities (namespace, locked_until);
+
+
+CREATE TABLE IF NOT EXISTS rate_limits (
+  namespace VARCHAR(128) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  tokens DOUBLE PRECISION NOT NULL,
+  updated_at BIGINT NOT NULL,
+
+  PRIMARY KEY(namespace, name)
+);
+
+CREATE TABLE IF NOT EXISTS leases (
+  namespace VARCHAR(128) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  holder VARCHAR(255) NOT NULL,
+  expires_at BIGINT NOT NULL,
+
+  PRIMARY KEY(namespace, name)
+);
+
+CREATE TABLE IF NOT EXISTS signal_deduplication (
+  namespace VARCHAR(128) NOT NULL,
+  instance_id VARCHAR(128) NOT NULL,
+  hash CHAR(64) NOT NULL,
+  expires_at BIGINT NOT NULL,
+
+  PRIMARY KEY(namespace, instance_id, hash)
+);
+
+CREATE TABLE IF NOT EXISTS workflow_concurrency_locks (
+  namespace VARCHAR(128) NOT NULL,
+  workflow_name VARCHAR(255) NOT NULL,
+
+  PRIMARY KEY(namespace, workflow_name)
+);
+
+CREATE TABLE IF NOT EXISTS queries (
+  id BIGSERIAL PRIMARY KEY,
+  namespace VARCHAR(128) NOT NULL,
+  query_id VARCHAR(64) NOT NULL,
+  instance TEXT NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  args BYTEA NOT NULL,
+  deadline BIGINT NOT NULL,
+  locked BOOLEAN NOT NULL DEFAULT FALSE,
+  result BYTEA NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_queries_namespace_query_id ON queries (namespace, query_id);
+
+CREATE TABLE IF NOT EXISTS schedules (
+  namespace VARCHAR(128) NOT NULL,
+  id VARCHAR(128) NOT NULL,
+  schedule BYTEA NOT NULL,
+
+  PRIMARY KEY(namespace, id)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS pending_activities (
+  namespace VARCHAR(128) NOT NULL,
+  activity_id VARCHAR(64) NOT NULL,
+  instance_id VARCHAR(128) NOT NULL,
+  execution_id VARCHAR(128) NOT NULL,
+  event BYTEA NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY(namespace, activity_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_activities_instance_id_execution_id ON pending_activities (instance_id, execution_id);
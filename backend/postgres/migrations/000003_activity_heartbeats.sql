@@ -0,0 +1,2 @@
+ALTER TABLE activities ADD COLUMN IF NOT EXISTS last_heartbeat TIMESTAMPTZ NULL;
+ALTER TABLE activities ADD COLUMN IF NOT EXISTS heartbeat_details BYTEA NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS search_attributes (
+  namespace VARCHAR(128) NOT NULL,
+  instance_id VARCHAR(128) NOT NULL,
+  execution_id VARCHAR(128) NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  type INT NOT NULL,
+  value VARCHAR(255) NOT NULL,
+
+  PRIMARY KEY(namespace, instance_id, execution_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_attributes_namespace_name_type_value ON search_attributes (namespace, name, type, value);
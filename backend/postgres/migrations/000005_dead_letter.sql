@@ -0,0 +1,2 @@
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS task_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS dead_letter_reason TEXT NULL;
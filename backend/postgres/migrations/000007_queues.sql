@@ -0,0 +1,2 @@
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS queue VARCHAR(128) NOT NULL DEFAULT 'default';
+ALTER TABLE activities ADD COLUMN IF NOT EXISTS queue VARCHAR(128) NOT NULL DEFAULT 'default';
@@ -0,0 +1,7 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_instances_namespace_instance_id_execution_id ON instances (namespace, instance_id, execution_id);
+DROP INDEX IF EXISTS idx_instances_instance_id_execution_id;
+CREATE INDEX IF NOT EXISTS idx_instances_instance_id_execution_id ON instances (instance_id, execution_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_activities_namespace_instance_id_execution_id_activity_id_worker ON activities (namespace, instance_id, execution_id, activity_id, worker);
+DROP INDEX IF EXISTS idx_activities_instance_id_execution_id_activity_id_worker;
+CREATE INDEX IF NOT EXISTS idx_activities_instance_id_execution_id_activity_id_worker ON activities (instance_id, execution_id, activity_id, worker);
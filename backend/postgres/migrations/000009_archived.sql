@@ -0,0 +1 @@
+ALTER TABLE instances ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,9 @@
+ALTER TABLE pending_events ADD COLUMN IF NOT EXISTS namespace VARCHAR(128) NOT NULL DEFAULT 'default';
+ALTER TABLE history ADD COLUMN IF NOT EXISTS namespace VARCHAR(128) NOT NULL DEFAULT 'default';
+
+-- Events recorded by earlier versions belong to the namespace of their instance
+UPDATE pending_events e SET namespace = i.namespace FROM instances i WHERE i.instance_id = e.instance_id AND i.execution_id = e.execution_id;
+UPDATE history e SET namespace = i.namespace FROM instances i WHERE i.instance_id = e.instance_id AND i.execution_id = e.execution_id;
+
+CREATE INDEX IF NOT EXISTS idx_pending_events_namespace_inid_exid_visible_at ON pending_events (namespace, instance_id, execution_id, visible_at);
+CREATE INDEX IF NOT EXISTS idx_history_namespace_instance_id_execution_id_sequence_id ON history (namespace, instance_id, execution_id, sequence_id);
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+func (b *postgresBackend) PauseWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
+	return b.setPaused(ctx, instance, true, event)
+}
+
+func (b *postgresBackend) ResumeWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
+	return b.setPaused(ctx, instance, false, event)
+}
+
+// setPaused updates the paused flag of the given instance and records the event. Pausing a paused instance or
+// resuming an instance that isn't paused doesn't record another event.
+func (b *postgresBackend) setPaused(ctx context.Context, instance *workflow.Instance, paused bool, event *history.Event) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE instances SET paused = $1 WHERE namespace = $2 AND instance_id = $3 AND execution_id = $4 AND paused = $5",
+		paused,
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+		!paused,
+	)
+	if err != nil {
+		return fmt.Errorf("updating workflow instance: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		if err := instanceExists(ctx, tx, b.options.Namespace, instance); err != nil {
+			return err
+		}
+
+		// Already in the requested state
+		return nil
+	}
+
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
+		return fmt.Errorf("inserting %v event: %w", event.Type, err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,1077 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/contextpropagation"
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/metrickeys"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/cschleiden/go-workflows/metrics"
+	"github.com/cschleiden/go-workflows/workflow"
+	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// DriverName is the name of the database/sql driver used to connect to Postgres, registered by github.com/lib/pq
+const DriverName = "postgres"
+
+// migrationsLockID identifies the advisory lock serializing migrations of concurrently starting backends
+const migrationsLockID = 0x676f7766
+
+func NewPostgresBackend(host string, port int, user, password, database string, opts ...backend.BackendOption) *postgresBackend {
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, database)
+
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := migrate(db); err != nil {
+		panic(fmt.Errorf("migrating database: %w", err))
+	}
+
+	options := backend.ApplyOptions(opts...)
+
+	return &postgresBackend{
+		db:                    db,
+		workerName:            fmt.Sprintf("worker-%v", options.IDGenerator()),
+		options:               options,
+		workflowPriorityOrder: backend.NewPriorityOrder(options.PriorityStarvationInterval),
+		activityPriorityOrder: backend.NewPriorityOrder(options.PriorityStarvationInterval),
+	}
+}
+
+// migrate applies all migrations that haven't been applied to the database yet. Migrations are numbered SQL files,
+// the applied versions are recorded in the schema_migrations table.
+func migrate(db *sql.DB) error {
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", migrationsLockID); err != nil {
+		return fmt.Errorf("locking migrations: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version INT PRIMARY KEY)"); err != nil {
+		return fmt.Errorf("creating migrations table: %w", err)
+	}
+
+	var current int
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	files, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+
+	type migration struct {
+		version int
+		name    string
+	}
+
+	pending := make([]migration, 0, len(files))
+	for _, f := range files {
+		version, err := strconv.Atoi(strings.SplitN(f.Name(), "_", 2)[0])
+		if err != nil {
+			return fmt.Errorf("parsing version of migration %v: %w", f.Name(), err)
+		}
+
+		if version > current {
+			pending = append(pending, migration{version, f.Name()})
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].version < pending[j].version })
+
+	for _, m := range pending {
+		script, err := migrations.ReadFile(path.Join("migrations", m.name))
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+			return fmt.Errorf("applying migration %v: %w", m.name, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", m.version); err != nil {
+			return fmt.Errorf("recording migration %v: %w", m.name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+type postgresBackend struct {
+	db         *sql.DB
+	workerName string
+	options    backend.Options
+
+	workflowPriorityOrder *backend.PriorityOrder
+	activityPriorityOrder *backend.PriorityOrder
+}
+
+// orderByPriority returns an ORDER BY expression and its arguments for dequeuing tasks in the given priority order.
+// The arguments are referenced as parameters starting with $firstParam.
+func orderByPriority(column string, order []core.Priority, firstParam int) (string, []interface{}) {
+	return fmt.Sprintf("CASE %v WHEN $%d THEN 0 WHEN $%d THEN 1 ELSE 2 END", column, firstParam, firstParam+1),
+		[]interface{}{order[0], order[1]}
+}
+
+// params returns a comma separated list of count parameters, starting with $first
+func params(first, count int) string {
+	p := make([]string, count)
+	for i := range p {
+		p[i] = "$" + strconv.Itoa(first+i)
+	}
+
+	return strings.Join(p, ", ")
+}
+
+// queueValues returns the arguments for dequeuing tasks of the given queues, or of the default queue if none are given
+func queueValues(queues []core.Queue) []interface{} {
+	if len(queues) == 0 {
+		queues = []core.Queue{core.QueueDefault}
+	}
+
+	args := make([]interface{}, 0, len(queues))
+	for _, q := range queues {
+		args = append(args, q.OrDefault())
+	}
+
+	return args
+}
+
+var _ backend.QueuePoller = (*postgresBackend)(nil)
+var _ backend.Retainer = (*postgresBackend)(nil)
+var _ backend.IDGenerator = (*postgresBackend)(nil)
+
+func (b *postgresBackend) Logger() log.Logger {
+	return b.options.Logger
+}
+
+func (b *postgresBackend) Tracer() trace.Tracer {
+	return b.options.TracerProvider.Tracer(backend.TracerName)
+}
+
+func (b *postgresBackend) Metrics() metrics.Client {
+	return b.options.Metrics.WithTags(metrics.Tags{
+		metrickeys.Backend:   "postgres",
+		metrickeys.Namespace: b.options.Namespace,
+	})
+}
+
+func (b *postgresBackend) Converter() converter.Converter {
+	return b.options.Converter
+}
+
+func (b *postgresBackend) NewID() string {
+	return b.options.IDGenerator()
+}
+
+func (b *postgresBackend) ContextPropagators() []contextpropagation.ContextPropagator {
+	return b.options.ContextPropagators
+}
+
+func (b *postgresBackend) Retention() time.Duration {
+	return b.options.Retention
+}
+
+func (b *postgresBackend) CreateWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	a := event.Attributes.(*history.ExecutionStartedAttributes)
+
+	// Check for existing executions of the same instance
+	terminated, err := b.checkInstanceIDReuse(ctx, tx, instance.InstanceID, a.InstanceIDReusePolicy)
+	if err != nil {
+		return err
+	}
+
+	// Check the concurrency limit of the workflow
+	if limit, ok := b.options.WorkflowConcurrencyLimits[a.Name]; ok {
+		// Serialize creating instances of the same workflow, so that concurrent creations see each other
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO workflow_concurrency_locks (namespace, workflow_name) VALUES ($1, $2)
+				ON CONFLICT (namespace, workflow_name) DO UPDATE SET workflow_name = EXCLUDED.workflow_name`,
+			b.options.Namespace,
+			a.Name,
+		); err != nil {
+			return fmt.Errorf("locking workflow concurrency: %w", err)
+		}
+
+		var active int
+		if err := tx.QueryRowContext(
+			ctx,
+			"SELECT COUNT(*) FROM instances WHERE namespace = $1 AND workflow_name = $2 AND state = $3",
+			b.options.Namespace,
+			a.Name,
+			core.WorkflowInstanceStateActive,
+		).Scan(&active); err != nil {
+			return fmt.Errorf("counting active workflow instances: %w", err)
+		}
+
+		if active >= limit {
+			return backend.ErrConcurrencyLimitReached
+		}
+	}
+
+	// Check the active instance limits of the namespace and the tenant
+	if err := quotas.Check(ctx, tx, b.options.Namespace, backend.ActiveInstanceLimits(ctx)); err != nil {
+		return err
+	}
+
+	// Create workflow instance
+	if err := createInstance(ctx, tx, b.options.Namespace, instance, a, false); err != nil {
+		return err
+	}
+
+	// Delayed instances are locked until they are due, so that no workflow task is handed out before. Events like
+	// signals are held until then.
+	if event.VisibleAt != nil {
+		if _, err := tx.ExecContext(
+			ctx,
+			"UPDATE instances SET locked_until = $1 WHERE namespace = $2 AND instance_id = $3 AND execution_id = $4",
+			*event.VisibleAt,
+			b.options.Namespace,
+			instance.InstanceID,
+			instance.ExecutionID,
+		); err != nil {
+			return fmt.Errorf("delaying workflow instance: %w", err)
+		}
+	}
+
+	// Initial history is empty, store only new events
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
+		return fmt.Errorf("inserting new event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("creating workflow instance: %w", err)
+	}
+
+	if terminated != nil && b.options.Archiver != nil {
+		b.tryArchiveHistory(ctx, terminated)
+	}
+
+	return nil
+}
+
+func (b *postgresBackend) RemoveWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance) error {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(
+		ctx,
+		"SELECT state FROM instances WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 LIMIT 1",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
+	var state core.WorkflowInstanceState
+	if err := row.Scan(&state); err != nil {
+		if err == sql.ErrNoRows {
+			return backend.ErrInstanceNotFound
+		}
+
+		return err
+	}
+
+	if state == core.WorkflowInstanceStateActive {
+		return backend.ErrInstanceNotFinished
+	}
+
+	// Delete from instances and history tables
+	if _, err := tx.ExecContext(ctx, "DELETE FROM instances WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3", b.options.Namespace, instance.InstanceID, instance.ExecutionID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM history WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3", b.options.Namespace, instance.InstanceID, instance.ExecutionID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM pending_events WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3", b.options.Namespace, instance.InstanceID, instance.ExecutionID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM pending_activities WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3", b.options.Namespace, instance.InstanceID, instance.ExecutionID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM search_attributes WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3", b.options.Namespace, instance.InstanceID, instance.ExecutionID); err != nil {
+		return err
+	}
+
+	if err := signals.Prune(ctx, tx, b.options.Namespace, b.options.Clock.Now()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (b *postgresBackend) CancelWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := instanceExists(ctx, tx, b.options.Namespace, instance); err != nil {
+		return err
+	}
+
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
+		return fmt.Errorf("inserting cancellation event: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// instanceExists returns backend.ErrInstanceNotFound if the given instance doesn't exist
+func instanceExists(ctx context.Context, tx *sql.Tx, namespace string, instance *workflow.Instance) error {
+	row := tx.QueryRowContext(
+		ctx,
+		"SELECT 1 FROM instances WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 LIMIT 1",
+		namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
+	if err := row.Scan(new(int)); err != nil {
+		if err == sql.ErrNoRows {
+			return backend.ErrInstanceNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (b *postgresBackend) GetWorkflowInstanceHistory(ctx context.Context, instance *workflow.Instance, lastSequenceID *int64) ([]*history.Event, error) {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	h, err := getHistory(ctx, tx, b.options.Namespace, instance, lastSequenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Histories of finished instances might have been moved to the archiver
+	if len(h) == 0 && lastSequenceID == nil && b.options.Archiver != nil {
+		return b.getArchivedHistory(ctx, instance)
+	}
+
+	return h, nil
+}
+
+func getHistory(ctx context.Context, tx *sql.Tx, namespace string, instance *workflow.Instance, lastSequenceID *int64) ([]*history.Event, error) {
+	var err error
+	var historyEvents *sql.Rows
+	if lastSequenceID != nil {
+		historyEvents, err = tx.QueryContext(
+			ctx,
+			"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM history WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 AND sequence_id > $4 ORDER BY sequence_id",
+			namespace,
+			instance.InstanceID,
+			instance.ExecutionID,
+			*lastSequenceID,
+		)
+	} else {
+		historyEvents, err = tx.QueryContext(
+			ctx,
+			"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM history WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 ORDER BY sequence_id",
+			namespace,
+			instance.InstanceID,
+			instance.ExecutionID,
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting history: %w", err)
+	}
+	defer historyEvents.Close()
+
+	h := make([]*history.Event, 0)
+
+	for historyEvents.Next() {
+		historyEvent, err := scanEvent(historyEvents)
+		if err != nil {
+			return nil, err
+		}
+
+		h = append(h, historyEvent)
+	}
+
+	return h, historyEvents.Err()
+}
+
+func (b *postgresBackend) GetWorkflowInstanceState(ctx context.Context, instance *workflow.Instance) (core.WorkflowInstanceState, error) {
+	row := b.db.QueryRowContext(
+		ctx,
+		"SELECT state FROM instances WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
+
+	var state core.WorkflowInstanceState
+	if err := row.Scan(&state); err != nil {
+		if err == sql.ErrNoRows {
+			return core.WorkflowInstanceStateActive, backend.ErrInstanceNotFound
+		}
+
+		return core.WorkflowInstanceStateActive, err
+	}
+
+	return state, nil
+}
+
+func createInstance(ctx context.Context, tx *sql.Tx, namespace string, wfi *workflow.Instance, a *history.ExecutionStartedAttributes, ignoreDuplicate bool) error {
+	var parentInstanceID, parentExecutionID *string
+	var parentEventID *int64
+	if wfi.SubWorkflow() {
+		parentInstanceID = &wfi.Parent.InstanceID
+		parentExecutionID = &wfi.Parent.ExecutionID
+		parentEventID = &wfi.ParentEventID
+	}
+
+	metadataJson, err := json.Marshal(a.Metadata)
+	if err != nil {
+		return fmt.Errorf("marshaling metadata: %w", err)
+	}
+
+	memo, err := marshalMemo(a.Memo)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO instances (namespace, instance_id, execution_id, parent_instance_id, parent_execution_id, parent_schedule_event_id, metadata, state, priority, workflow_name, memo, queue)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			ON CONFLICT DO NOTHING`,
+		namespace,
+		wfi.InstanceID,
+		wfi.ExecutionID,
+		parentInstanceID,
+		parentExecutionID,
+		parentEventID,
+		string(metadataJson),
+		core.WorkflowInstanceStateActive,
+		a.Priority,
+		a.Name,
+		memo,
+		a.Queue.OrDefault(),
+	)
+	if err != nil {
+		return fmt.Errorf("inserting workflow instance: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows != 1 {
+		if !ignoreDuplicate {
+			return backend.ErrInstanceAlreadyExists
+		}
+
+		return nil
+	}
+
+	if err := upsertSearchAttributes(ctx, tx, namespace, wfi, a.SearchAttributes); err != nil {
+		return fmt.Errorf("inserting search attributes: %w", err)
+	}
+
+	return nil
+}
+
+// SignalWorkflow signals a running workflow instance
+func (b *postgresBackend) SignalWorkflow(ctx context.Context, instanceID string, event *history.Event) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res := tx.QueryRowContext(
+		ctx,
+		"SELECT execution_id FROM instances WHERE namespace = $1 AND instance_id = $2 AND state = $3 LIMIT 1",
+		b.options.Namespace,
+		instanceID,
+		core.WorkflowInstanceStateActive,
+	)
+	var executionID string
+	if err := res.Scan(&executionID); err != nil {
+		if err == sql.ErrNoRows {
+			return backend.ErrInstanceNotFound
+		}
+
+		return err
+	}
+
+	// Only signals are deduplicated, not updates
+	if window := b.options.SignalDeduplicationWindow; window > 0 && event.Type == history.EventType_SignalReceived {
+		duplicate, err := signals.IsDuplicate(ctx, tx, b.options.Namespace, instanceID, event, window, b.options.Clock.Now())
+		if err != nil {
+			return err
+		}
+
+		if duplicate {
+			b.Logger().Debug("Dropping duplicate signal", log.InstanceIDKey, instanceID,
+				log.SignalNameKey, event.Attributes.(*history.SignalReceivedAttributes).Name)
+
+			return tx.Commit()
+		}
+	}
+
+	instance := core.NewWorkflowInstance(instanceID, executionID)
+
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
+		return fmt.Errorf("inserting signal event: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetWorkflowTask returns a pending workflow task or nil if there are no pending workflow executions
+func (b *postgresBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, error) {
+	return b.GetWorkflowTaskFromQueues(ctx, nil)
+}
+
+// GetWorkflowTaskFromQueues returns a pending workflow task of an instance in one of the given queues or nil if there
+// are no pending workflow executions
+func (b *postgresBackend) GetWorkflowTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Workflow, error) {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock next workflow task by finding an unlocked instance with new events to process.
+	now := b.options.Clock.Now()
+	queueArgs := queueValues(queues)
+	orderBy, orderByArgs := orderByPriority("i.priority", b.workflowPriorityOrder.Next(), 6+len(queueArgs))
+	args := []interface{}{
+		b.options.Namespace,
+		now,               // visible_at, locked_until, sticky_until
+		b.workerName,      // worker
+		b.options.BuildID, // any build_id
+		b.options.BuildID, // matching build_id
+	}
+	args = append(args, queueArgs...)
+	args = append(args, orderByArgs...)
+	row := tx.QueryRowContext(
+		ctx,
+		`SELECT i.id, i.instance_id, i.execution_id, i.parent_instance_id, i.parent_execution_id, i.parent_schedule_event_id, i.metadata, i.task_attempts
+			FROM instances i
+			INNER JOIN pending_events pe ON i.namespace = pe.namespace AND i.instance_id = pe.instance_id
+			WHERE
+				i.namespace = $1
+				AND i.completed_at IS NULL
+				AND NOT i.paused
+				AND i.dead_letter_reason IS NULL
+				AND (pe.visible_at IS NULL OR pe.visible_at <= $2)
+				AND (i.locked_until IS NULL OR i.locked_until < $2)
+				AND (i.sticky_until IS NULL OR i.sticky_until < $2 OR i.worker = $3)
+				AND ($4 = '' OR i.build_id IS NULL OR i.build_id = $5)
+				AND i.queue IN (`+params(6, len(queueArgs))+`)
+			ORDER BY `+orderBy+`
+			LIMIT 1
+			FOR UPDATE OF i SKIP LOCKED`,
+		args...,
+	)
+
+	var id int64
+	var instanceID, executionID string
+	var parentInstanceID, parentExecutionID *string
+	var parentEventID *int64
+	var metadataJson sql.NullString
+	var attempts int
+	if err := row.Scan(&id, &instanceID, &executionID, &parentInstanceID, &parentExecutionID, &parentEventID, &metadataJson, &attempts); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("scanning workflow instance: %w", err)
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE instances
+			SET locked_until = $1, worker = $2, build_id = COALESCE(build_id, NULLIF($3, '')), task_attempts = task_attempts + 1
+			WHERE id = $4`,
+		now.Add(b.options.WorkflowLockTimeout),
+		b.workerName,
+		b.options.BuildID,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("locking workflow instance: %w", err)
+	}
+
+	if affectedRows, err := res.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("locking workflow instance: %w", err)
+	} else if affectedRows == 0 {
+		// No instance locked?
+		return nil, nil
+	}
+
+	var wfi *workflow.Instance
+	if parentInstanceID != nil {
+		wfi = core.NewSubWorkflowInstance(instanceID, executionID, core.NewWorkflowInstance(*parentInstanceID, *parentExecutionID), *parentEventID)
+	} else {
+		wfi = core.NewWorkflowInstance(instanceID, executionID)
+	}
+
+	var metadata *core.WorkflowMetadata
+	if metadataJson.Valid {
+		if err := json.Unmarshal([]byte(metadataJson.String), &metadata); err != nil {
+			return nil, fmt.Errorf("parsing workflow metadata: %w", err)
+		}
+	}
+
+	t := &task.Workflow{
+		ID:                    wfi.InstanceID,
+		WorkflowInstance:      wfi,
+		WorkflowInstanceState: core.WorkflowInstanceStateActive,
+		Metadata:              metadata,
+		NewEvents:             []*history.Event{},
+		Attempt:               attempts + 1,
+	}
+
+	// Get new events
+	events, err := tx.QueryContext(
+		ctx,
+		"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM pending_events WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 AND (visible_at IS NULL OR visible_at <= $4) ORDER BY id",
+		b.options.Namespace,
+		instanceID,
+		executionID,
+		now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("getting new events: %w", err)
+	}
+
+	for events.Next() {
+		historyEvent, err := scanEvent(events)
+		if err != nil {
+			events.Close()
+			return nil, err
+		}
+
+		t.NewEvents = append(t.NewEvents, historyEvent)
+	}
+
+	events.Close()
+	if err := events.Err(); err != nil {
+		return nil, fmt.Errorf("getting new events: %w", err)
+	}
+
+	// Return if there aren't any new events
+	if len(t.NewEvents) == 0 {
+		return nil, nil
+	}
+
+	// Get most recent sequence id
+	row = tx.QueryRowContext(ctx, "SELECT sequence_id FROM history WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 ORDER BY id DESC LIMIT 1", b.options.Namespace, instanceID, executionID)
+	if err := row.Scan(
+		&t.LastSequenceID,
+	); err != nil {
+		if err != sql.ErrNoRows {
+			return nil, fmt.Errorf("getting most recent sequence id: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// CompleteWorkflowTask completes a workflow task retrieved using GetWorkflowTask
+//
+// This checkpoints the execution. events are new events from the last workflow execution
+// which will be added to the workflow instance history. workflowEvents are new events for the
+// completed or other workflow instances.
+func (b *postgresBackend) CompleteWorkflowTask(
+	ctx context.Context,
+	task *task.Workflow,
+	instance *workflow.Instance,
+	state core.WorkflowInstanceState,
+	executedEvents, activityEvents, timerEvents []*history.Event,
+	workflowEvents []history.WorkflowEvent,
+) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Unlock instance, but keep it sticky to the current worker
+	var completedAt *time.Time
+	if state == core.WorkflowInstanceStateFinished {
+		t := b.options.Clock.Now()
+		completedAt = &t
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE instances SET locked_until = NULL, sticky_until = $1, completed_at = $2, state = $3, task_attempts = 0 WHERE namespace = $4 AND instance_id = $5 AND execution_id = $6 AND worker = $7`,
+		b.options.Clock.Now().Add(b.options.StickyTimeout),
+		completedAt,
+		state,
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+		b.workerName,
+	)
+	if err != nil {
+		return fmt.Errorf("unlocking instance: %w", err)
+	}
+
+	changedRows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking for unlocked workflow instances: %w", err)
+	} else if changedRows != 1 {
+		return fmt.Errorf("could not find workflow instance to unlock: %w", backend.ErrWorkflowTaskLost)
+	}
+
+	// Remove handled events from task
+	if len(executedEvents) > 0 {
+		args := make([]interface{}, 0, len(executedEvents)+3)
+		args = append(args, b.options.Namespace, instance.InstanceID, instance.ExecutionID)
+		for _, e := range executedEvents {
+			args = append(args, e.ID)
+		}
+
+		if _, err := tx.ExecContext(
+			ctx,
+			`DELETE FROM pending_events WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 AND event_id IN (`+params(4, len(executedEvents))+`)`,
+			args...,
+		); err != nil {
+			return fmt.Errorf("deleting handled new events: %w", err)
+		}
+	}
+
+	// Insert new events generated during this workflow execution to the history
+	if err := insertHistoryEvents(ctx, tx, b.options.Namespace, instance, executedEvents); err != nil {
+		return fmt.Errorf("inserting new history events: %w", err)
+	}
+
+	// Schedule activities
+	for _, e := range activityEvents {
+		if err := scheduleActivity(ctx, tx, b.options.Namespace, instance, e); err != nil {
+			return fmt.Errorf("scheduling activity: %w", err)
+		}
+	}
+
+	// Timer events
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, timerEvents); err != nil {
+		return fmt.Errorf("scheduling timers: %w", err)
+	}
+
+	for _, event := range executedEvents {
+		switch event.Type {
+		case history.EventType_TimerCanceled:
+			if err := removeFutureEvent(ctx, tx, b.options.Namespace, instance, event.ScheduleEventID); err != nil {
+				return fmt.Errorf("removing future event: %w", err)
+			}
+
+		case history.EventType_SearchAttributesUpserted:
+			a := event.Attributes.(*history.SearchAttributesUpsertedAttributes)
+			if err := upsertSearchAttributes(ctx, tx, b.options.Namespace, instance, a.SearchAttributes); err != nil {
+				return fmt.Errorf("upserting search attributes: %w", err)
+			}
+		}
+	}
+
+	// Insert new workflow events
+	groupedEvents := history.EventsByWorkflowInstance(workflowEvents)
+
+	for targetInstance, events := range groupedEvents {
+		for _, m := range events {
+			if m.HistoryEvent.Type == history.EventType_WorkflowExecutionStarted {
+				a := m.HistoryEvent.Attributes.(*history.ExecutionStartedAttributes)
+				// Create new instance
+				if err := createInstance(ctx, tx, b.options.Namespace, m.WorkflowInstance, a, true); err != nil {
+					return err
+				}
+
+				break
+			}
+		}
+
+		historyEvents := []*history.Event{}
+		for _, m := range events {
+			historyEvents = append(historyEvents, m.HistoryEvent)
+		}
+
+		if err := insertPendingEvents(ctx, tx, b.options.Namespace, &targetInstance, historyEvents); err != nil {
+			return fmt.Errorf("inserting messages: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing complete workflow transaction: %w", err)
+	}
+
+	if (state == core.WorkflowInstanceStateFinished || state == core.WorkflowInstanceStateContinuedAsNew) && b.options.Archiver != nil {
+		b.tryArchiveHistory(ctx, instance)
+	}
+
+	return nil
+}
+
+func (b *postgresBackend) ExtendWorkflowTask(ctx context.Context, taskID string, instance *core.WorkflowInstance) error {
+	until := b.options.Clock.Now().Add(b.options.WorkflowLockTimeout)
+	res, err := b.db.ExecContext(
+		ctx,
+		`UPDATE instances SET locked_until = $1 WHERE namespace = $2 AND instance_id = $3 AND execution_id = $4 AND worker = $5`,
+		until,
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+		b.workerName,
+	)
+	if err != nil {
+		return fmt.Errorf("extending workflow task lock: %w", err)
+	}
+
+	if rowsAffected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("determining if workflow task was extended: %w", err)
+	} else if rowsAffected == 0 {
+		return errors.New("could not extend workflow task")
+	}
+
+	return nil
+}
+
+// GetActivityTask returns a pending activity task or nil if there are no pending activities
+func (b *postgresBackend) GetActivityTask(ctx context.Context) (*task.Activity, error) {
+	return b.GetActivityTaskFromQueues(ctx, nil)
+}
+
+// GetActivityTaskFromQueues returns a pending activity task in one of the given queues or nil if there are no pending
+// activities
+func (b *postgresBackend) GetActivityTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Activity, error) {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock next activity
+	now := b.options.Clock.Now()
+	queueArgs := queueValues(queues)
+	orderBy, orderByArgs := orderByPriority("priority", b.activityPriorityOrder.Next(), 3+len(queueArgs))
+
+	args := append([]interface{}{b.options.Namespace, now}, queueArgs...)
+	args = append(args, orderByArgs...)
+
+	res := tx.QueryRowContext(
+		ctx,
+		`SELECT id, activity_id, instance_id, execution_id,
+			event_type, timestamp, schedule_event_id, attributes, visible_at, locked_until IS NOT NULL,
+			last_heartbeat, heartbeat_details
+			FROM activities
+			WHERE namespace = $1 AND (locked_until IS NULL OR locked_until < $2)
+				AND queue IN (`+params(3, len(queueArgs))+`)
+			ORDER BY `+orderBy+`
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED`,
+		args...,
+	)
+
+	var id int64
+	var instanceID, executionID string
+	var attributes []byte
+	var redelivered bool
+	var lastHeartbeat sql.NullTime
+	var heartbeatDetails []byte
+	event := &history.Event{}
+
+	if err := res.Scan(
+		&id, &event.ID, &instanceID, &executionID, &event.Type,
+		&event.Timestamp, &event.ScheduleEventID, &attributes, &event.VisibleAt, &redelivered,
+		&lastHeartbeat, &heartbeatDetails); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("finding activity task to lock: %w", err)
+	}
+
+	a, err := history.DeserializeAttributes(event.Type, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("deserializing attributes: %w", err)
+	}
+
+	event.Attributes = a
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE activities SET locked_until = $1, worker = $2 WHERE id = $3`,
+		now.Add(b.options.ActivityLockTimeout),
+		b.workerName,
+		id,
+	); err != nil {
+		return nil, fmt.Errorf("locking activity: %w", err)
+	}
+
+	t := &task.Activity{
+		ID:               event.ID,
+		WorkflowInstance: core.NewWorkflowInstance(instanceID, executionID),
+		Event:            event,
+		Redelivered:      redelivered,
+		LastHeartbeat:    lastHeartbeat.Time,
+		HeartbeatDetails: heartbeatDetails,
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// CompleteActivityTask completes a activity task retrieved using GetActivityTask
+func (b *postgresBackend) CompleteActivityTask(ctx context.Context, instance *workflow.Instance, id string, event *history.Event) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Remove activity
+	res, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM activities WHERE namespace = $1 AND activity_id = $2 AND instance_id = $3 AND execution_id = $4 AND worker = $5`,
+		b.options.Namespace,
+		id,
+		instance.InstanceID,
+		instance.ExecutionID,
+		b.workerName,
+	)
+	if err != nil {
+		return fmt.Errorf("completing activity: %w", err)
+	}
+
+	if affected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("checking for completed activity: %w", err)
+	} else if affected == 0 {
+		return errors.New("could not find locked activity")
+	}
+
+	// Insert new event generated during this workflow execution
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
+		return fmt.Errorf("inserting new events for completed activity: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (b *postgresBackend) ExtendActivityTask(ctx context.Context, activityID string) error {
+	until := b.options.Clock.Now().Add(b.options.ActivityLockTimeout)
+	res, err := b.db.ExecContext(
+		ctx,
+		`UPDATE activities SET locked_until = $1 WHERE namespace = $2 AND activity_id = $3 AND worker = $4`,
+		until,
+		b.options.Namespace,
+		activityID,
+		b.workerName,
+	)
+	if err != nil {
+		return fmt.Errorf("extending activity lock: %w", err)
+	}
+
+	if rowsAffected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("determining if activity was extended: %w", err)
+	} else if rowsAffected == 0 {
+		return errors.New("could not extend activity")
+	}
+
+	return nil
+}
+
+func scheduleActivity(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, event *history.Event) error {
+	a, err := history.SerializeAttributes(event.Attributes)
+	if err != nil {
+		return err
+	}
+
+	var queue core.Queue
+	if sa, ok := event.Attributes.(*history.ActivityScheduledAttributes); ok {
+		queue = sa.Queue
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO activities
+			(activity_id, namespace, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at, queue, priority)
+			SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, priority FROM instances WHERE namespace = $2 AND instance_id = $11 AND execution_id = $12`,
+		event.ID,
+		namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+		event.Type,
+		event.Timestamp,
+		event.ScheduleEventID,
+		a,
+		event.VisibleAt,
+		queue.OrDefault(),
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
+
+	return err
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/test"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/google/uuid"
+)
+
+const testUser = "postgres"
+const testPassword = "root"
+
+// Creating and dropping databases is terribly inefficient, but easiest for complete test isolation, same as for the
+// mysql backend.
+
+func Test_PostgresBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	var dbName string
+
+	test.BackendTest(t, func(options ...backend.BackendOption) test.TestBackend {
+		dbName = createDatabase()
+
+		options = append(options, backend.WithStickyTimeout(0))
+
+		return NewPostgresBackend("localhost", 5432, testUser, testPassword, dbName, options...)
+	}, func(b test.TestBackend) {
+		b.(*postgresBackend).db.Close()
+		dropDatabase(dbName)
+	})
+}
+
+func TestPostgresBackendE2E(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	var dbName string
+
+	test.EndToEndBackendTest(t, func(options ...backend.BackendOption) test.TestBackend {
+		dbName = createDatabase()
+
+		options = append(options, backend.WithStickyTimeout(0))
+
+		return NewPostgresBackend("localhost", 5432, testUser, testPassword, dbName, options...)
+	}, func(b test.TestBackend) {
+		b.(*postgresBackend).db.Close()
+		dropDatabase(dbName)
+	})
+}
+
+func Test_PostgresBackend_Namespaces_SameWorkflow(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbName := createDatabase()
+	t.Cleanup(func() { dropDatabase(dbName) })
+
+	test.NamespacesBackendTest(t, func(namespace string) test.TestBackend {
+		b := NewPostgresBackend("localhost", 5432, testUser, testPassword, dbName, backend.WithNamespace(namespace), backend.WithStickyTimeout(0))
+		t.Cleanup(func() { b.db.Close() })
+
+		return b
+	})
+}
+
+func openServer() *sql.DB {
+	db, err := sql.Open(DriverName, fmt.Sprintf("host=localhost port=5432 user=%s password=%s sslmode=disable", testUser, testPassword))
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
+
+func createDatabase() string {
+	db := openServer()
+	defer db.Close()
+
+	dbName := "test_" + strings.Replace(uuid.NewString(), "-", "", -1)
+	if _, err := db.Exec("CREATE DATABASE " + dbName); err != nil {
+		panic(fmt.Errorf("creating database: %w", err))
+	}
+
+	return dbName
+}
+
+func dropDatabase(dbName string) {
+	db := openServer()
+	defer db.Close()
+
+	if _, err := db.Exec("DROP DATABASE IF EXISTS " + dbName); err != nil {
+		panic(fmt.Errorf("dropping database: %w", err))
+	}
+}
+
+var _ test.TestBackend = (*postgresBackend)(nil)
+
+func (b *postgresBackend) GetFutureEvents(ctx context.Context) ([]*history.Event, error) {
+	// There is no index on `visible_at`, but this is okay for test only usage.
+	futureEvents, err := b.db.QueryContext(
+		ctx,
+		"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM pending_events WHERE visible_at IS NOT NULL",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("getting history: %w", err)
+	}
+	defer futureEvents.Close()
+
+	f := make([]*history.Event, 0)
+
+	for futureEvents.Next() {
+		fe, err := scanEvent(futureEvents)
+		if err != nil {
+			return nil, err
+		}
+
+		f = append(f, fe)
+	}
+
+	return f, futureEvents.Err()
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.InstancePurger = (*postgresBackend)(nil)
+
+// purgeBatchSize is the number of instances removed per transaction
+const purgeBatchSize = 500
+
+func (b *postgresBackend) RemoveFinishedWorkflowInstances(ctx context.Context, finishedBefore time.Time, workflowName string) (int, error) {
+	// Signals recorded for deduplication are otherwise kept for removed instances
+	if err := signals.Prune(ctx, b.db, b.options.Namespace, b.options.Clock.Now()); err != nil {
+		return 0, err
+	}
+
+	removed := 0
+
+	for {
+		n, err := b.removeFinishedWorkflowInstances(ctx, finishedBefore, workflowName)
+		removed += n
+		if err != nil || n < purgeBatchSize {
+			return removed, err
+		}
+	}
+}
+
+// removeFinishedWorkflowInstances removes up to purgeBatchSize finished instances and returns the number of removed
+// instances
+func (b *postgresBackend) removeFinishedWorkflowInstances(ctx context.Context, finishedBefore time.Time, workflowName string) (int, error) {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	where := "namespace = $1 AND completed_at IS NOT NULL AND completed_at < $2"
+	args := []interface{}{b.options.Namespace, finishedBefore}
+
+	if workflowName != "" {
+		where += " AND workflow_name = $3"
+		args = append(args, workflowName)
+	}
+
+	// Instances are kept until their history has been archived
+	if b.options.Archiver != nil {
+		where += " AND archived"
+	}
+
+	args = append(args, purgeBatchSize)
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT instance_id, execution_id FROM instances WHERE "+where+" LIMIT $"+strconv.Itoa(len(args))+" FOR UPDATE SKIP LOCKED", args...)
+	if err != nil {
+		return 0, fmt.Errorf("selecting finished instances: %w", err)
+	}
+
+	var instances []interface{}
+	for rows.Next() {
+		var instanceID, executionID string
+		if err := rows.Scan(&instanceID, &executionID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scanning finished instance: %w", err)
+		}
+
+		instances = append(instances, instanceID, executionID)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("selecting finished instances: %w", err)
+	}
+
+	n := len(instances) / 2
+	if n == 0 {
+		return 0, nil
+	}
+
+	values := make([]string, n)
+	for i := range values {
+		values[i] = "(" + params(2*i+2, 2) + ")"
+	}
+
+	in := strings.Join(values, ", ")
+
+	for _, stmt := range []string{
+		"DELETE FROM instances WHERE namespace = $1 AND (instance_id, execution_id) IN (" + in + ")",
+		"DELETE FROM history WHERE namespace = $1 AND (instance_id, execution_id) IN (" + in + ")",
+		"DELETE FROM pending_events WHERE namespace = $1 AND (instance_id, execution_id) IN (" + in + ")",
+		"DELETE FROM pending_activities WHERE namespace = $1 AND (instance_id, execution_id) IN (" + in + ")",
+		"DELETE FROM search_attributes WHERE namespace = $1 AND (instance_id, execution_id) IN (" + in + ")",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, append([]interface{}{b.options.Namespace}, instances...)...); err != nil {
+			return 0, fmt.Errorf("removing finished instances: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
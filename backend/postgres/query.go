@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+)
+
+var _ backend.Querier = (*postgresBackend)(nil)
+
+func (b *postgresBackend) QueueQuery(ctx context.Context, q *backend.Query) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := b.removeExpiredQueries(ctx, tx); err != nil {
+		return err
+	}
+
+	var executionID string
+	var parentInstanceID, parentExecutionID *string
+	var parentEventID *int64
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT execution_id, parent_instance_id, parent_execution_id, parent_schedule_event_id FROM instances WHERE namespace = $1 AND instance_id = $2 AND state = $3 LIMIT 1",
+		b.options.Namespace,
+		q.InstanceID,
+		core.WorkflowInstanceStateActive,
+	).Scan(&executionID, &parentInstanceID, &parentExecutionID, &parentEventID); err != nil {
+		if err == sql.ErrNoRows {
+			return backend.ErrInstanceNotFound
+		}
+
+		return fmt.Errorf("reading workflow instance: %w", err)
+	}
+
+	instance := core.NewWorkflowInstance(q.InstanceID, executionID)
+	if parentInstanceID != nil {
+		instance = core.NewSubWorkflowInstance(q.InstanceID, executionID, core.NewWorkflowInstance(*parentInstanceID, *parentExecutionID), *parentEventID)
+	}
+
+	instanceJson, err := json.Marshal(instance)
+	if err != nil {
+		return fmt.Errorf("marshaling instance: %w", err)
+	}
+
+	args, err := json.Marshal(q.Args)
+	if err != nil {
+		return fmt.Errorf("marshaling query arguments: %w", err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO queries (namespace, query_id, instance, name, args, deadline) VALUES ($1, $2, $3, $4, $5, $6)",
+		b.options.Namespace,
+		q.ID,
+		string(instanceJson),
+		q.Name,
+		args,
+		q.Deadline.UnixNano(),
+	); err != nil {
+		return fmt.Errorf("inserting query: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (b *postgresBackend) GetQueryTask(ctx context.Context) (*backend.QueryTask, error) {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := b.removeExpiredQueries(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRowContext(
+		ctx,
+		`UPDATE queries SET locked = TRUE
+			WHERE id = (
+				SELECT id FROM queries
+				WHERE namespace = $1 AND NOT locked
+				ORDER BY id
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING query_id, instance, name, args, deadline`,
+		b.options.Namespace,
+	)
+
+	t := &backend.QueryTask{}
+	var instanceJson string
+	var args []byte
+	var deadline int64
+	if err := row.Scan(&t.ID, &instanceJson, &t.Name, &args, &deadline); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("locking query: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(instanceJson), &t.Instance); err != nil {
+		return nil, fmt.Errorf("unmarshaling instance: %w", err)
+	}
+
+	if err := json.Unmarshal(args, &t.Args); err != nil {
+		return nil, fmt.Errorf("unmarshaling query arguments: %w", err)
+	}
+
+	t.InstanceID = t.Instance.InstanceID
+	t.Deadline = time.Unix(0, deadline)
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (b *postgresBackend) CompleteQueryTask(ctx context.Context, queryID string, result *backend.QueryResult) error {
+	r, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshaling query result: %w", err)
+	}
+
+	if _, err := b.db.ExecContext(
+		ctx,
+		"UPDATE queries SET result = $1 WHERE namespace = $2 AND query_id = $3",
+		r,
+		b.options.Namespace,
+		queryID,
+	); err != nil {
+		return fmt.Errorf("storing query result: %w", err)
+	}
+
+	return nil
+}
+
+func (b *postgresBackend) GetQueryResult(ctx context.Context, queryID string) (*backend.QueryResult, error) {
+	// Remove the query once its result has been read
+	var r []byte
+	if err := b.db.QueryRowContext(
+		ctx,
+		"DELETE FROM queries WHERE namespace = $1 AND query_id = $2 AND result IS NOT NULL RETURNING result",
+		b.options.Namespace,
+		queryID,
+	).Scan(&r); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("reading query result: %w", err)
+	}
+
+	result := &backend.QueryResult{}
+	if err := json.Unmarshal(r, result); err != nil {
+		return nil, fmt.Errorf("unmarshaling query result: %w", err)
+	}
+
+	return result, nil
+}
+
+func (b *postgresBackend) removeExpiredQueries(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM queries WHERE namespace = $1 AND deadline < $2",
+		b.options.Namespace,
+		b.options.Clock.Now().UnixNano(),
+	); err != nil {
+		return fmt.Errorf("removing expired queries: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package postgres
+
+import "github.com/cschleiden/go-workflows/internal/sqlquota"
+
+// quotas checks active instance limits when creating instances
+var quotas = sqlquota.Dialect{
+	Lock: `INSERT INTO workflow_concurrency_locks (namespace, workflow_name) VALUES ($1, $2)
+		ON CONFLICT (namespace, workflow_name) DO UPDATE SET workflow_name = EXCLUDED.workflow_name`,
+	CountActive: "SELECT COUNT(*) FROM instances WHERE namespace = $1 AND state = $2",
+	CountActiveTenant: `SELECT COUNT(*) FROM instances i INNER JOIN search_attributes sa
+		ON sa.namespace = i.namespace AND sa.instance_id = i.instance_id AND sa.execution_id = i.execution_id
+		WHERE i.namespace = $1 AND i.state = $2 AND sa.name = $3 AND sa.type = $4 AND sa.value = $5`,
+}
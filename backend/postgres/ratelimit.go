@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.RateLimiter = (*postgresBackend)(nil)
+
+func (b *postgresBackend) AcquireActivityRateLimit(ctx context.Context, activityName string) (time.Duration, error) {
+	limit, ok := b.options.ActivityRateLimits[activityName]
+	if !ok {
+		return 0, nil
+	}
+
+	return b.AcquireRateLimit(ctx, "activity:"+activityName, limit)
+}
+
+func (b *postgresBackend) AcquireRateLimit(ctx context.Context, name string, limit backend.RateLimit) (time.Duration, error) {
+	if limit.Limit <= 0 || limit.Interval <= 0 {
+		return 0, nil
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := b.options.Clock.Now()
+
+	// Buckets start out full. Create the bucket first, so that concurrent workers can lock the row.
+	if _, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO rate_limits (namespace, name, tokens, updated_at) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
+		b.options.Namespace,
+		name,
+		float64(limit.Limit),
+		now.UnixNano(),
+	); err != nil {
+		return 0, fmt.Errorf("creating rate limit: %w", err)
+	}
+
+	var tokens float64
+	var updatedAtNanos int64
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT tokens, updated_at FROM rate_limits WHERE namespace = $1 AND name = $2 FOR UPDATE",
+		b.options.Namespace,
+		name,
+	).Scan(&tokens, &updatedAtNanos); err != nil {
+		return 0, fmt.Errorf("reading rate limit: %w", err)
+	}
+
+	tokens, wait := limit.Take(tokens, time.Unix(0, updatedAtNanos), now)
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"UPDATE rate_limits SET tokens = $1, updated_at = $2 WHERE namespace = $3 AND name = $4",
+		tokens,
+		now.UnixNano(),
+		b.options.Namespace,
+		name,
+	); err != nil {
+		return 0, fmt.Errorf("updating rate limit: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return wait, nil
+}
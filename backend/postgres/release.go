@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.ActivityTaskReleaser = (*postgresBackend)(nil)
+
+// ReleaseActivityTask expires the lock of the activity task instead of clearing it, so the next delivery is marked as
+// redelivered
+func (b *postgresBackend) ReleaseActivityTask(ctx context.Context, activityID string) error {
+	res, err := b.db.ExecContext(
+		ctx,
+		`UPDATE activities SET locked_until = $1 WHERE namespace = $2 AND activity_id = $3 AND worker = $4`,
+		b.options.Clock.Now(),
+		b.options.Namespace,
+		activityID,
+		b.workerName,
+	)
+	if err != nil {
+		return fmt.Errorf("releasing activity task: %w", err)
+	}
+
+	if rowsAffected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("determining if activity task was released: %w", err)
+	} else if rowsAffected == 0 {
+		return errors.New("could not release activity task")
+	}
+
+	return nil
+}
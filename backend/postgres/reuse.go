@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+)
+
+// checkInstanceIDReuse enforces the instance ID reuse policy of a new execution against the most recent execution with
+// the same instance ID. If the policy allows it, an active execution is terminated and returned.
+func (b *postgresBackend) checkInstanceIDReuse(ctx context.Context, tx *sql.Tx, instanceID string, policy core.InstanceIDReusePolicy) (*core.WorkflowInstance, error) {
+	var executionID string
+	var parentInstanceID, parentExecutionID *string
+	var parentEventID *int64
+	var state core.WorkflowInstanceState
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT execution_id, parent_instance_id, parent_execution_id, parent_schedule_event_id, state FROM instances WHERE namespace = $1 AND instance_id = $2 ORDER BY id DESC LIMIT 1 FOR UPDATE",
+		b.options.Namespace,
+		instanceID,
+	).Scan(&executionID, &parentInstanceID, &parentExecutionID, &parentEventID, &state); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("checking for existing workflow instance: %w", err)
+	}
+
+	var existing *core.WorkflowInstance
+	if parentInstanceID != nil {
+		existing = core.NewSubWorkflowInstance(instanceID, executionID, core.NewWorkflowInstance(*parentInstanceID, *parentExecutionID), *parentEventID)
+	} else {
+		existing = core.NewWorkflowInstance(instanceID, executionID)
+	}
+
+	terminate, err := backend.CheckInstanceIDReuse(policy, state, func() (*history.Event, error) {
+		return b.lastHistoryEvent(ctx, tx, existing)
+	})
+	if err != nil || !terminate {
+		return nil, err
+	}
+
+	return existing, b.terminateExisting(ctx, tx, existing)
+}
+
+// queryEvent returns the first event selected by the given query, or nil if there is none
+func queryEvent(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (*history.Event, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+
+	return scanEvent(rows)
+}
+
+func (b *postgresBackend) lastHistoryEvent(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance) (*history.Event, error) {
+	event, err := queryEvent(
+		ctx,
+		tx,
+		"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM history WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 ORDER BY sequence_id DESC LIMIT 1",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("getting last history event: %w", err)
+	}
+
+	// Histories of finished instances might have been moved to the archiver
+	if event == nil && b.options.Archiver != nil {
+		h, err := b.getArchivedHistory(ctx, instance)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(h) > 0 {
+			return h[len(h)-1], nil
+		}
+	}
+
+	return event, nil
+}
+
+// terminateExisting terminates the given active execution in place, see backend.TerminateExecution
+func (b *postgresBackend) terminateExisting(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance) error {
+	var lastSequenceID int64
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT sequence_id FROM history WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 ORDER BY sequence_id DESC LIMIT 1",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	).Scan(&lastSequenceID); err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("getting most recent sequence id: %w", err)
+	}
+
+	var startedEvent *history.Event
+	if lastSequenceID == 0 {
+		var err error
+		startedEvent, err = queryEvent(
+			ctx,
+			tx,
+			"SELECT event_id, sequence_id, event_type, timestamp, schedule_event_id, attributes, visible_at FROM pending_events WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3 AND event_type = $4 LIMIT 1",
+			b.options.Namespace,
+			instance.InstanceID,
+			instance.ExecutionID,
+			history.EventType_WorkflowExecutionStarted,
+		)
+		if err != nil {
+			return fmt.Errorf("getting started event: %w", err)
+		}
+	}
+
+	now := b.options.Clock.Now()
+	executedEvents, parentEvent := backend.TerminateExecution(instance, startedEvent, lastSequenceID, now)
+
+	if err := insertHistoryEvents(ctx, tx, b.options.Namespace, instance, executedEvents); err != nil {
+		return fmt.Errorf("inserting termination events: %w", err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM pending_events WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	); err != nil {
+		return fmt.Errorf("deleting pending events: %w", err)
+	}
+
+	// Unlock the instance, so that a worker currently executing a task for it fails to complete it
+	if _, err := tx.ExecContext(
+		ctx,
+		"UPDATE instances SET state = $1, completed_at = $2, locked_until = NULL, sticky_until = NULL, worker = NULL WHERE namespace = $3 AND instance_id = $4 AND execution_id = $5",
+		core.WorkflowInstanceStateFinished,
+		now,
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	); err != nil {
+		return fmt.Errorf("terminating workflow instance: %w", err)
+	}
+
+	if parentEvent != nil {
+		if err := insertPendingEvents(ctx, tx, b.options.Namespace, parentEvent.WorkflowInstance, []*history.Event{parentEvent.HistoryEvent}); err != nil {
+			return fmt.Errorf("inserting sub-workflow result: %w", err)
+		}
+	}
+
+	return nil
+}
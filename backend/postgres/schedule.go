@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.ScheduleStore = (*postgresBackend)(nil)
+
+func (b *postgresBackend) CreateSchedule(ctx context.Context, s *backend.Schedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshaling schedule: %w", err)
+	}
+
+	res, err := b.db.ExecContext(
+		ctx,
+		"INSERT INTO schedules (namespace, id, schedule) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+		b.options.Namespace,
+		s.ID,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting schedule: %w", err)
+	}
+
+	return expectAffected(res, backend.ErrScheduleAlreadyExists)
+}
+
+func (b *postgresBackend) UpdateSchedule(ctx context.Context, s *backend.Schedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshaling schedule: %w", err)
+	}
+
+	res, err := b.db.ExecContext(
+		ctx,
+		"UPDATE schedules SET schedule = $1 WHERE namespace = $2 AND id = $3",
+		data,
+		b.options.Namespace,
+		s.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("updating schedule: %w", err)
+	}
+
+	return expectAffected(res, backend.ErrScheduleNotFound)
+}
+
+func (b *postgresBackend) DeleteSchedule(ctx context.Context, id string) error {
+	res, err := b.db.ExecContext(
+		ctx,
+		"DELETE FROM schedules WHERE namespace = $1 AND id = $2",
+		b.options.Namespace,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("deleting schedule: %w", err)
+	}
+
+	return expectAffected(res, backend.ErrScheduleNotFound)
+}
+
+func (b *postgresBackend) ListSchedules(ctx context.Context) ([]*backend.Schedule, error) {
+	rows, err := b.db.QueryContext(
+		ctx,
+		"SELECT schedule FROM schedules WHERE namespace = $1 ORDER BY id",
+		b.options.Namespace,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*backend.Schedule
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("scanning schedule: %w", err)
+		}
+
+		var s *backend.Schedule
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("unmarshaling schedule: %w", err)
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+func expectAffected(res sql.Result, notAffected error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return notAffected
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+package postgres
+
+import "github.com/cschleiden/go-workflows/internal/sqlsignals"
+
+// signals deduplicates signals delivered within the signal deduplication window
+var signals = sqlsignals.Dialect{
+	DeleteExpired:    "DELETE FROM signal_deduplication WHERE namespace = $1 AND instance_id = $2 AND expires_at < $3",
+	Insert:           "INSERT INTO signal_deduplication (namespace, instance_id, hash, expires_at) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
+	DeleteAllExpired: "DELETE FROM signal_deduplication WHERE namespace = $1 AND expires_at < $2",
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+func (b *postgresBackend) GetStats(ctx context.Context) (*backend.Stats, error) {
+	s := &backend.Stats{}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := b.options.Clock.Now()
+
+	counts := []struct {
+		name  string
+		dest  *int64
+		query string
+		args  []interface{}
+	}{
+		{
+			name:  "active instances",
+			dest:  &s.ActiveWorkflowInstances,
+			query: "SELECT COUNT(*) FROM instances i WHERE i.namespace = $1 AND i.completed_at IS NULL",
+			args:  []interface{}{b.options.Namespace},
+		},
+		{
+			name: "pending workflow tasks",
+			dest: &s.PendingWorkflowTasks,
+			query: `SELECT COUNT(*) FROM instances i
+				WHERE
+					i.namespace = $1
+					AND (i.locked_until IS NULL OR i.locked_until < $2)
+					AND i.completed_at IS NULL
+					AND NOT i.paused
+					AND i.dead_letter_reason IS NULL
+					AND EXISTS (
+						SELECT 1
+							FROM pending_events
+							WHERE namespace = i.namespace AND instance_id = i.instance_id AND execution_id = i.execution_id AND (visible_at IS NULL OR visible_at <= $3)
+					)`,
+			args: []interface{}{b.options.Namespace, now, now},
+		},
+		{
+			name:  "locked workflow tasks",
+			dest:  &s.LockedWorkflowTasks,
+			query: "SELECT COUNT(*) FROM instances i WHERE i.namespace = $1 AND i.locked_until >= $2 AND i.completed_at IS NULL",
+			args:  []interface{}{b.options.Namespace, now},
+		},
+		{
+			name:  "pending activities",
+			dest:  &s.PendingActivities,
+			query: "SELECT COUNT(*) FROM activities WHERE namespace = $1",
+			args:  []interface{}{b.options.Namespace},
+		},
+		{
+			name:  "pending activity tasks",
+			dest:  &s.PendingActivityTasks,
+			query: "SELECT COUNT(*) FROM activities WHERE namespace = $1 AND (locked_until IS NULL OR locked_until < $2)",
+			args:  []interface{}{b.options.Namespace, now},
+		},
+		{
+			name:  "locked activity tasks",
+			dest:  &s.LockedActivityTasks,
+			query: "SELECT COUNT(*) FROM activities WHERE namespace = $1 AND locked_until >= $2",
+			args:  []interface{}{b.options.Namespace, now},
+		},
+		{
+			name: "pending timers",
+			dest: &s.PendingTimers,
+			query: `SELECT COUNT(*) FROM pending_events pe
+				INNER JOIN instances i ON i.namespace = pe.namespace AND i.instance_id = pe.instance_id AND i.execution_id = pe.execution_id
+				WHERE i.namespace = $1 AND pe.visible_at > $2`,
+			args: []interface{}{b.options.Namespace, now},
+		},
+	}
+
+	for _, c := range counts {
+		row := tx.QueryRowContext(ctx, c.query, c.args...)
+		if err := row.Err(); err != nil {
+			return nil, fmt.Errorf("failed to query %v: %w", c.name, err)
+		}
+
+		if err := row.Scan(c.dest); err != nil {
+			return nil, fmt.Errorf("failed to scan %v: %w", c.name, err)
+		}
+	}
+
+	return s, nil
+}
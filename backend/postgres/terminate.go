@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+func (b *postgresBackend) TerminateWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := instanceExists(ctx, tx, b.options.Namespace, instance); err != nil {
+		return err
+	}
+
+	// Paused and dead-lettered instances don't get workflow tasks, resume them to process the termination
+	if _, err := tx.ExecContext(
+		ctx,
+		"UPDATE instances SET paused = FALSE, dead_letter_reason = NULL, task_attempts = 0 WHERE namespace = $1 AND instance_id = $2 AND execution_id = $3",
+		b.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	); err != nil {
+		return fmt.Errorf("resuming workflow instance: %w", err)
+	}
+
+	if err := insertPendingEvents(ctx, tx, b.options.Namespace, instance, []*history.Event{event}); err != nil {
+		return fmt.Errorf("inserting termination event: %w", err)
+	}
+
+	return tx.Commit()
+}
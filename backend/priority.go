@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"sync/atomic"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+)
+
+// PriorityOrder determines the order in which backends consider priorities when dequeuing tasks.
+//
+// Most dequeues prefer higher priorities. To prevent a steady stream of higher priority tasks from starving lower
+// priority ones, every n-th dequeue prefers lower priorities instead, alternating between normal and low priority.
+type PriorityOrder struct {
+	starvationInterval int64
+	dequeues           atomic.Int64
+}
+
+var (
+	orderPreferNormal = []core.Priority{core.PriorityNormal, core.PriorityLow, core.PriorityHigh}
+	orderPreferLow    = []core.Priority{core.PriorityLow, core.PriorityNormal, core.PriorityHigh}
+)
+
+// NewPriorityOrder creates a new PriorityOrder. A starvationInterval of zero or less always prefers higher priorities.
+func NewPriorityOrder(starvationInterval int) *PriorityOrder {
+	return &PriorityOrder{
+		starvationInterval: int64(starvationInterval),
+	}
+}
+
+// Next returns the priorities in the order they should be considered for the next dequeue
+func (o *PriorityOrder) Next() []core.Priority {
+	if o.starvationInterval <= 0 {
+		return core.Priorities
+	}
+
+	n := o.dequeues.Add(1)
+	if n%o.starvationInterval != 0 {
+		return core.Priorities
+	}
+
+	if (n/o.starvationInterval)%2 == 1 {
+		return orderPreferNormal
+	}
+
+	return orderPreferLow
+}
@@ -0,0 +1,32 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PriorityOrder(t *testing.T) {
+	o := NewPriorityOrder(3)
+
+	preferred := []core.Priority{}
+	for i := 0; i < 12; i++ {
+		preferred = append(preferred, o.Next()[0])
+	}
+
+	require.Equal(t, []core.Priority{
+		core.PriorityHigh, core.PriorityHigh, core.PriorityNormal,
+		core.PriorityHigh, core.PriorityHigh, core.PriorityLow,
+		core.PriorityHigh, core.PriorityHigh, core.PriorityNormal,
+		core.PriorityHigh, core.PriorityHigh, core.PriorityLow,
+	}, preferred)
+}
+
+func Test_PriorityOrder_NoStarvationInterval(t *testing.T) {
+	o := NewPriorityOrder(0)
+
+	for i := 0; i < 10; i++ {
+		require.Equal(t, core.Priorities, o.Next())
+	}
+}
@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/log"
+)
+
+// ErrPurgeNotSupported is returned by backend decorators wrapping a backend that doesn't implement InstancePurger
+var ErrPurgeNotSupported = errors.New("backend does not support removing workflow instances in bulk")
+
+// InstancePurger is implemented by backends that can remove finished workflow instances in bulk, instead of one
+// RemoveWorkflowInstance call per instance.
+type InstancePurger interface {
+	// RemoveFinishedWorkflowInstances removes the workflow instances that finished before the given time, including
+	// their histories. If workflowName is not empty, only instances of that workflow are removed. Returns the number
+	// of removed instances.
+	RemoveFinishedWorkflowInstances(ctx context.Context, finishedBefore time.Time, workflowName string) (int, error)
+}
+
+// RemoveFinishedWorkflowInstances removes the workflow instances of the given backend that finished before the given
+// time, optionally only the ones of the given workflow. Backends implementing InstancePurger remove the instances in
+// bulk, for other backends the finished instances are listed and removed one by one.
+func RemoveFinishedWorkflowInstances(ctx context.Context, b Backend, finishedBefore time.Time, workflowName string) (int, error) {
+	if p, ok := b.(InstancePurger); ok {
+		removed, err := p.RemoveFinishedWorkflowInstances(ctx, finishedBefore, workflowName)
+		if !errors.Is(err, ErrPurgeNotSupported) {
+			return removed, err
+		}
+	}
+
+	query := &ListWorkflowInstancesQuery{
+		State: InstanceStateFinished,
+	}
+
+	removed := 0
+
+	for {
+		r, err := b.ListWorkflowInstances(ctx, query)
+		if err != nil {
+			return removed, fmt.Errorf("listing finished workflow instances: %w", err)
+		}
+
+		for _, i := range r.Instances {
+			if i.CompletedAt == nil || !i.CompletedAt.Before(finishedBefore) ||
+				(workflowName != "" && i.WorkflowName != workflowName) {
+				// Continue the next page after the last instance that is kept, page tokens refer to existing instances
+				query.PageToken = EncodePageToken(i.Instance)
+				continue
+			}
+
+			if err := b.RemoveWorkflowInstance(ctx, i.Instance); err != nil {
+				if errors.Is(err, ErrInstanceNotFound) {
+					// Removed concurrently
+					continue
+				}
+
+				if ctx.Err() != nil {
+					return removed, ctx.Err()
+				}
+
+				b.Logger().Error("removing finished workflow instance", log.InstanceIDKey, i.Instance.InstanceID,
+					log.ExecutionIDKey, i.Instance.ExecutionID, log.ErrorKey, err)
+
+				query.PageToken = EncodePageToken(i.Instance)
+				continue
+			}
+
+			removed++
+		}
+
+		if r.NextPageToken == "" {
+			return removed, nil
+		}
+	}
+}
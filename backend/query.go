@@ -0,0 +1,103 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+)
+
+// ErrQueriesNotSupported is returned when querying a workflow instance on a backend that doesn't implement Querier
+var ErrQueriesNotSupported = errors.New("backend does not support queries")
+
+// ErrQueryNotAnswered is returned when a query hasn't been answered before its deadline
+var ErrQueryNotAnswered = errors.New("query was not answered in time")
+
+// DefaultQueryTimeout is the time to wait for the answer to a query if the caller doesn't set an earlier deadline
+const DefaultQueryTimeout = 10 * time.Second
+
+// Query asks a workflow worker to evaluate a query handler of a workflow instance
+type Query struct {
+	ID         string            `json:"id"`
+	InstanceID string            `json:"instance_id"`
+	Name       string            `json:"name"`
+	Args       []payload.Payload `json:"args,omitempty"`
+
+	// Deadline is the time after which the query is discarded, whether or not it has been answered
+	Deadline time.Time `json:"deadline"`
+}
+
+// QueryTask is a query handed out to a workflow worker
+type QueryTask struct {
+	Query
+
+	// Instance is the execution of the workflow instance the query is evaluated for
+	Instance *core.WorkflowInstance `json:"instance"`
+}
+
+// QueryResult is the answer to a query
+type QueryResult struct {
+	Result payload.Payload       `json:"result,omitempty"`
+	Error  *workflowerrors.Error `json:"error,omitempty"`
+}
+
+// Querier is implemented by backends that support workflow queries. Queries aren't recorded in the history of a
+// workflow instance, they are passed from the client to a workflow worker and back through the backend. Queries are
+// delivered at most once, if a worker fails while evaluating a query, the client waits until the deadline of the
+// query.
+type Querier interface {
+	// QueueQuery queues the given query for the active execution of the workflow instance with q.InstanceID. It
+	// returns ErrInstanceNotFound if the instance doesn't have an active execution.
+	QueueQuery(ctx context.Context, q *Query) error
+
+	// GetQueryTask returns the next queued query, or nil if there is none. Queries past their deadline are
+	// discarded.
+	GetQueryTask(ctx context.Context) (*QueryTask, error)
+
+	// CompleteQueryTask stores the result of the given query
+	CompleteQueryTask(ctx context.Context, queryID string, result *QueryResult) error
+
+	// GetQueryResult returns the result of the given query and removes the query, or nil if the query hasn't been
+	// answered yet
+	GetQueryResult(ctx context.Context, queryID string) (*QueryResult, error)
+}
+
+// RunQuery queues the given query and polls for its result until the deadline of the query
+func RunQuery(ctx context.Context, q Querier, clock clock.Clock, query *Query) (*QueryResult, error) {
+	if err := q.QueueQuery(ctx, query); err != nil {
+		return nil, fmt.Errorf("queueing query: %w", err)
+	}
+
+	b := backoff.ExponentialBackOff{
+		InitialInterval:     time.Millisecond * 1,
+		MaxInterval:         time.Millisecond * 100,
+		Multiplier:          1.5,
+		RandomizationFactor: 0.5,
+		MaxElapsedTime:      query.Deadline.Sub(clock.Now()),
+		Stop:                backoff.Stop,
+		Clock:               clock,
+	}
+	b.Reset()
+
+	ticker := backoff.NewTicker(&b)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r, err := q.GetQueryResult(ctx, query.ID)
+		if err != nil {
+			return nil, fmt.Errorf("getting query result: %w", err)
+		}
+
+		if r != nil {
+			return r, nil
+		}
+	}
+
+	return nil, ErrQueryNotAnswered
+}
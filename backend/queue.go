@@ -0,0 +1,24 @@
+package backend
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/task"
+)
+
+// ErrQueuesNotSupported is returned when starting a worker polling other queues than the default queue with a backend
+// that doesn't implement QueuePoller
+var ErrQueuesNotSupported = errors.New("backend does not support task queues")
+
+// QueuePoller is implemented by backends that route workflow and activity tasks to named queues. GetWorkflowTask and
+// GetActivityTask of these backends only return tasks of the default queue.
+type QueuePoller interface {
+	// GetWorkflowTaskFromQueues returns a pending workflow task of an instance in one of the given queues, or nil if
+	// there is none
+	GetWorkflowTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Workflow, error)
+
+	// GetActivityTaskFromQueues returns a pending activity task in one of the given queues, or nil if there is none
+	GetActivityTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Activity, error)
+}
@@ -0,0 +1,115 @@
+package quota
+
+import (
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+// DefaultTenantKey is the metadata key and search attribute identifying the tenant of a workflow instance by default
+const DefaultTenantKey = "tenant"
+
+// Limits of a namespace or tenant. Zero values mean no limit.
+type Limits struct {
+	// MaxActiveInstances limits the number of workflow instances that haven't finished yet. Creating an instance at
+	// the limit fails with a backend.QuotaExceededError. Sub-workflows and continued executions count towards the
+	// limit, but are not rejected. The backend checks the limit in the transaction creating the instance.
+	MaxActiveInstances int
+
+	// DispatchRate limits how often workflow and activity tasks are passed to workers. Tasks exceeding the rate wait
+	// in the worker that locked them until the rate allows them, up to MaxDispatchWait. Backends implementing
+	// backend.RateLimiter enforce the rate across all processes sharing the namespace, otherwise it's enforced per
+	// process.
+	DispatchRate backend.RateLimit
+}
+
+type Options struct {
+	// Namespace limits all workflow instances and tasks of the backend
+	Namespace Limits
+
+	// Tenants limits the workflow instances and tasks of individual tenants, in addition to the namespace limits
+	Tenants map[string]Limits
+
+	// DefaultTenant limits tenants without an entry in Tenants
+	DefaultTenant Limits
+
+	// TenantKey is the metadata key identifying the tenant of a workflow instance. Metadata is propagated from the
+	// context passed to CreateWorkflowInstance to sub-workflows and activities by context propagators. Defaults to
+	// DefaultTenantKey.
+	TenantKey string
+
+	// TenantSearchAttribute is the search attribute the tenant is recorded in when an instance is created, to count
+	// the active instances of the tenant. Defaults to DefaultTenantKey.
+	TenantSearchAttribute string
+
+	// MaxDispatchWait is the maximum time a locked task waits for the dispatch rates. Tasks the rates don't allow
+	// within this time fail to lock with ErrDispatchRateExceeded, and are delivered again once their lock expires.
+	// Keep it well below the lock timeouts of the backend. Defaults to ten seconds.
+	MaxDispatchWait time.Duration
+
+	// Clock is used for dispatch rates. Defaults to the system clock. Backends storing the rates use their own clock.
+	Clock clock.Clock
+}
+
+var DefaultOptions = Options{
+	TenantKey:             DefaultTenantKey,
+	TenantSearchAttribute: DefaultTenantKey,
+	MaxDispatchWait:       time.Second * 10,
+}
+
+type Option func(*Options)
+
+// WithNamespaceLimits sets the limits of all workflow instances and tasks of the backend
+func WithNamespaceLimits(limits Limits) Option {
+	return func(o *Options) {
+		o.Namespace = limits
+	}
+}
+
+// WithTenantLimits sets the limits of the given tenant
+func WithTenantLimits(tenant string, limits Limits) Option {
+	return func(o *Options) {
+		if o.Tenants == nil {
+			o.Tenants = make(map[string]Limits)
+		}
+
+		o.Tenants[tenant] = limits
+	}
+}
+
+// WithDefaultTenantLimits sets the limits of tenants without limits of their own
+func WithDefaultTenantLimits(limits Limits) Option {
+	return func(o *Options) {
+		o.DefaultTenant = limits
+	}
+}
+
+// WithTenantKey sets the metadata key identifying the tenant of a workflow instance. See Options.TenantKey.
+func WithTenantKey(key string) Option {
+	return func(o *Options) {
+		o.TenantKey = key
+	}
+}
+
+// WithTenantSearchAttribute sets the search attribute the tenant of a workflow instance is recorded in. See
+// Options.TenantSearchAttribute.
+func WithTenantSearchAttribute(name string) Option {
+	return func(o *Options) {
+		o.TenantSearchAttribute = name
+	}
+}
+
+// WithMaxDispatchWait sets the maximum time a locked task waits for the dispatch rates. See Options.MaxDispatchWait.
+func WithMaxDispatchWait(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxDispatchWait = d
+	}
+}
+
+// WithClock sets the clock used for dispatch rates
+func WithClock(c clock.Clock) Option {
+	return func(o *Options) {
+		o.Clock = c
+	}
+}
@@ -0,0 +1,78 @@
+package quota
+
+import (
+	"context"
+
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a context for the given tenant. Workflow instances created with the context belong to the tenant
+// if the backend propagates it with a TenantPropagator.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// Tenant returns the tenant of the given context, for example the context of an activity. Empty if the context
+// doesn't belong to a tenant.
+func Tenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// WorkflowTenant returns the tenant of the given workflow context. Empty if the workflow instance doesn't belong to a
+// tenant.
+func WorkflowTenant(ctx workflow.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+type tenantPropagator struct {
+	key string
+}
+
+var _ workflow.ContextPropagator = (*tenantPropagator)(nil)
+
+// NewTenantPropagator returns a context propagator recording the tenant of the context passed to the client in the
+// metadata of workflow instances under the given key, and passing it on to sub-workflows and activities. Use the same
+// key as the quotas, by default DefaultTenantKey.
+func NewTenantPropagator(key string) workflow.ContextPropagator {
+	if key == "" {
+		key = DefaultTenantKey
+	}
+
+	return &tenantPropagator{key: key}
+}
+
+func (p *tenantPropagator) Inject(ctx context.Context, metadata *workflow.Metadata) error {
+	if tenant := Tenant(ctx); tenant != "" {
+		metadata.Set(p.key, tenant)
+	}
+
+	return nil
+}
+
+func (p *tenantPropagator) Extract(ctx context.Context, metadata *workflow.Metadata) (context.Context, error) {
+	if tenant := metadata.Get(p.key); tenant != "" {
+		ctx = WithTenant(ctx, tenant)
+	}
+
+	return ctx, nil
+}
+
+func (p *tenantPropagator) InjectFromWorkflow(ctx workflow.Context, metadata *workflow.Metadata) error {
+	if tenant := WorkflowTenant(ctx); tenant != "" {
+		metadata.Set(p.key, tenant)
+	}
+
+	return nil
+}
+
+func (p *tenantPropagator) ExtractToWorkflow(ctx workflow.Context, metadata *workflow.Metadata) (workflow.Context, error) {
+	if tenant := metadata.Get(p.key); tenant != "" {
+		ctx = workflow.WithValue(ctx, tenantKey{}, tenant)
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,200 @@
+// Package quota limits the active workflow instances and the task dispatch rate of a namespace and of its tenants.
+// Tenants are identified by the metadata of workflow instances, which is propagated from clients to sub-workflows and
+// activities.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/hooks"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// ErrDispatchRateExceeded is returned when admitting a task would have to wait longer than MaxDispatchWait. The task
+// stays locked and is delivered again once its lock expires.
+var ErrDispatchRateExceeded = errors.New("dispatch rate exceeded")
+
+// Quotas enforce the configured limits via hooks. Pass them to hooks.NewBackend wrapping the same backend:
+//
+//	q := quota.New(b, quota.WithDefaultTenantLimits(quota.Limits{MaxActiveInstances: 100}))
+//	b = hooks.NewBackend(b, q)
+type Quotas struct {
+	hooks.NoopHooks
+
+	b       backend.Backend
+	options Options
+
+	mu sync.Mutex
+
+	// buckets hold the dispatch rate tokens of the namespace and of tenants, if the backend doesn't store them
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+var _ hooks.Hooks = (*Quotas)(nil)
+var _ hooks.AdmissionHooks = (*Quotas)(nil)
+
+// New returns quotas for the given backend, which stores the dispatch rate tokens if it's a backend.RateLimiter
+func New(b backend.Backend, opts ...Option) *Quotas {
+	options := DefaultOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.TenantKey == "" {
+		options.TenantKey = DefaultTenantKey
+	}
+
+	if options.TenantSearchAttribute == "" {
+		options.TenantSearchAttribute = DefaultTenantKey
+	}
+
+	if options.Clock == nil {
+		options.Clock = clock.New()
+	}
+
+	return &Quotas{
+		b:       b,
+		options: options,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// AdmitInstance records the tenant of the new instance and attaches the active instance limits of the namespace and
+// the tenant to the context. The backend checks them in the transaction creating the instance.
+func (q *Quotas) AdmitInstance(
+	ctx context.Context, instance *workflow.Instance, event *history.Event, creator *workflow.Instance,
+) (context.Context, error) {
+	a, ok := event.Attributes.(*history.ExecutionStartedAttributes)
+	if !ok {
+		return ctx, nil
+	}
+
+	tenant := q.tenant(a.Metadata)
+	if tenant != "" {
+		if a.SearchAttributes == nil {
+			a.SearchAttributes = make(core.SearchAttributes, 1)
+		}
+
+		a.SearchAttributes[q.options.TenantSearchAttribute] = core.NewStringSearchAttribute(tenant)
+	}
+
+	if creator != nil {
+		// Instances created by workflow tasks can't be rejected
+		return ctx, nil
+	}
+
+	limits := []backend.ActiveInstanceLimit{{Max: q.options.Namespace.MaxActiveInstances}}
+
+	if tenant != "" {
+		limits = append(limits, backend.ActiveInstanceLimit{
+			Tenant:                tenant,
+			TenantSearchAttribute: q.options.TenantSearchAttribute,
+			Max:                   q.tenantLimits(tenant).MaxActiveInstances,
+		})
+	}
+
+	return backend.WithActiveInstanceLimits(ctx, limits...), nil
+}
+
+// AdmitTask waits until the dispatch rates of the namespace and of the tenant of the task allow passing it to the
+// worker. If the rates don't allow it within MaxDispatchWait, it fails with ErrDispatchRateExceeded.
+func (q *Quotas) AdmitTask(ctx context.Context, t hooks.Task) error {
+	if err := q.wait(ctx, "namespace", q.options.Namespace.DispatchRate); err != nil {
+		return err
+	}
+
+	tenant := q.tenant(t.Metadata)
+	if tenant == "" {
+		return nil
+	}
+
+	return q.wait(ctx, "tenant:"+tenant, q.tenantLimits(tenant).DispatchRate)
+}
+
+func (q *Quotas) tenant(metadata *core.WorkflowMetadata) string {
+	if metadata == nil {
+		return ""
+	}
+
+	return metadata.Get(q.options.TenantKey)
+}
+
+func (q *Quotas) tenantLimits(tenant string) Limits {
+	if l, ok := q.options.Tenants[tenant]; ok {
+		return l
+	}
+
+	return q.options.DefaultTenant
+}
+
+// wait takes a token from the bucket with the given key, waiting up to MaxDispatchWait until one is available
+func (q *Quotas) wait(ctx context.Context, key string, limit backend.RateLimit) error {
+	if limit.Limit <= 0 || limit.Interval <= 0 {
+		return nil
+	}
+
+	deadline := q.options.Clock.Now().Add(q.options.MaxDispatchWait)
+
+	for {
+		d, err := q.take(ctx, key, limit)
+		if err != nil {
+			return fmt.Errorf("acquiring dispatch rate: %w", err)
+		}
+
+		if d == 0 {
+			return nil
+		}
+
+		if q.options.Clock.Now().Add(d).After(deadline) {
+			return ErrDispatchRateExceeded
+		}
+
+		t := q.options.Clock.Timer(d)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// take takes a token from the bucket with the given key. Buckets are stored by the backend if it's a
+// backend.RateLimiter, so that rates hold across processes, otherwise in memory.
+func (q *Quotas) take(ctx context.Context, key string, limit backend.RateLimit) (time.Duration, error) {
+	if rl, ok := q.b.(backend.RateLimiter); ok {
+		return rl.AcquireRateLimit(ctx, "quota:"+key, limit)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.options.Clock.Now()
+
+	b, ok := q.buckets[key]
+	if !ok {
+		// Buckets start full
+		b = &bucket{tokens: float64(limit.Limit), updatedAt: now}
+		q.buckets[key] = b
+	}
+
+	var d time.Duration
+	b.tokens, d = limit.Take(b.tokens, b.updatedAt, now)
+	b.updatedAt = now
+
+	return d, nil
+}
@@ -0,0 +1,163 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/hooks"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func quotaWorkflow(ctx workflow.Context) error {
+	return nil
+}
+
+func Test_Quotas_MaxActiveInstancesPerTenant(t *testing.T) {
+	ctx := context.Background()
+
+	b := sqlite.NewInMemoryBackend(backend.WithContextPropagator(NewTenantPropagator("")))
+	c := client.New(hooks.NewBackend(b, New(b, WithTenantLimits("a", Limits{MaxActiveInstances: 1}))))
+
+	// Without a worker, created instances stay active
+	_, err := c.CreateWorkflowInstance(WithTenant(ctx, "a"), client.WorkflowInstanceOptions{}, quotaWorkflow)
+	require.NoError(t, err)
+
+	_, err = c.CreateWorkflowInstance(WithTenant(ctx, "a"), client.WorkflowInstanceOptions{}, quotaWorkflow)
+	require.ErrorIs(t, err, backend.ErrQuotaExceeded)
+
+	var qerr *backend.QuotaExceededError
+	require.True(t, errors.As(err, &qerr))
+	require.Equal(t, "a", qerr.Tenant)
+	require.Equal(t, 1, qerr.MaxActiveInstances)
+
+	// Other tenants and instances without tenant are not limited
+	_, err = c.CreateWorkflowInstance(WithTenant(ctx, "b"), client.WorkflowInstanceOptions{}, quotaWorkflow)
+	require.NoError(t, err)
+
+	_, err = c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{}, quotaWorkflow)
+	require.NoError(t, err)
+}
+
+func Test_Quotas_MaxActiveInstancesPerNamespace(t *testing.T) {
+	ctx := context.Background()
+
+	b := sqlite.NewInMemoryBackend()
+	c := client.New(hooks.NewBackend(b, New(b, WithNamespaceLimits(Limits{MaxActiveInstances: 2}))))
+
+	for i := 0; i < 2; i++ {
+		_, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{}, quotaWorkflow)
+		require.NoError(t, err)
+	}
+
+	_, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{}, quotaWorkflow)
+	require.ErrorIs(t, err, backend.ErrQuotaExceeded)
+}
+
+func tenantActivity(ctx context.Context) (string, error) {
+	return Tenant(ctx), nil
+}
+
+func tenantSubWorkflow(ctx workflow.Context) (string, error) {
+	return workflow.ExecuteActivity[string](ctx, workflow.DefaultActivityOptions, tenantActivity).Get(ctx)
+}
+
+func tenantWorkflow(ctx workflow.Context) (string, error) {
+	return workflow.CreateSubWorkflowInstance[string](ctx, workflow.DefaultSubWorkflowOptions, tenantSubWorkflow).Get(ctx)
+}
+
+func Test_Quotas_PropagatesTenant(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := sqlite.NewInMemoryBackend(backend.WithContextPropagator(NewTenantPropagator("")))
+	hb := hooks.NewBackend(b, New(b))
+
+	w := worker.New(hb, nil)
+	require.NoError(t, w.RegisterWorkflow(tenantWorkflow))
+	require.NoError(t, w.RegisterWorkflow(tenantSubWorkflow))
+	require.NoError(t, w.RegisterActivity(tenantActivity))
+	require.NoError(t, w.Start(ctx))
+
+	c := client.New(hb)
+
+	instance, err := c.CreateWorkflowInstance(WithTenant(ctx, "a"), client.WorkflowInstanceOptions{}, tenantWorkflow)
+	require.NoError(t, err)
+
+	tenant, err := client.GetWorkflowResult[string](ctx, c, instance, time.Second*10)
+	require.NoError(t, err)
+	require.Equal(t, "a", tenant)
+
+	// The tenant is recorded for the sub-workflow, too
+	r, err := b.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{
+		SearchAttributes: workflow.SearchAttributes{
+			DefaultTenantKey: workflow.StringAttribute("a"),
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, r.Instances, 2)
+}
+
+func Test_Quotas_DispatchRate(t *testing.T) {
+	ctx := context.Background()
+
+	// The backend stores the rates, with the same clock
+	c := clock.NewMock()
+	b := sqlite.NewInMemoryBackend(backend.WithClock(c))
+	q := New(b, WithClock(c), WithMaxDispatchWait(time.Minute*2), WithDefaultTenantLimits(Limits{
+		DispatchRate: backend.RateLimit{Limit: 1, Interval: time.Minute},
+	}))
+
+	task := hooks.Task{Metadata: &workflow.Metadata{DefaultTenantKey: "a"}}
+
+	require.NoError(t, q.AdmitTask(ctx, task))
+
+	// Tasks of other tenants are not delayed
+	require.NoError(t, q.AdmitTask(ctx, hooks.Task{Metadata: &workflow.Metadata{DefaultTenantKey: "b"}}))
+
+	admitted := make(chan error, 1)
+	go func() {
+		admitted <- q.AdmitTask(ctx, task)
+	}()
+
+	select {
+	case <-admitted:
+		require.FailNow(t, "task admitted before the rate allows it")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.Add(time.Minute)
+
+	select {
+	case err := <-admitted:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "task not admitted")
+	}
+
+	// Waiting tasks give up when the context is canceled
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, q.AdmitTask(cctx, task), context.Canceled)
+}
+
+func Test_Quotas_DispatchRate_MaxWait(t *testing.T) {
+	ctx := context.Background()
+
+	b := sqlite.NewInMemoryBackend()
+	limits := Limits{DispatchRate: backend.RateLimit{Limit: 1, Interval: time.Hour}}
+
+	task := hooks.Task{Metadata: &workflow.Metadata{DefaultTenantKey: "a"}}
+
+	require.NoError(t, New(b, WithDefaultTenantLimits(limits)).AdmitTask(ctx, task))
+
+	// Quotas of another process share the rates stored by the backend, and don't wait longer than the maximum
+	require.ErrorIs(t, New(b, WithDefaultTenantLimits(limits)).AdmitTask(ctx, task), ErrDispatchRateExceeded)
+}
@@ -0,0 +1,55 @@
+package backend
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// RateLimit limits how often an operation can be performed. It's enforced as a token bucket holding up to Limit
+// tokens, which is refilled at a rate of Limit tokens per Interval.
+type RateLimit struct {
+	// Limit is the number of operations allowed per Interval
+	Limit int
+
+	// Interval is the interval the limit applies to
+	Interval time.Duration
+}
+
+// RateLimiter is implemented by backends that store rate limit state, so that the rate limits configured via
+// WithActivityRateLimit, and those of other packages like quota, hold across all workers sharing the same storage.
+type RateLimiter interface {
+	// AcquireActivityRateLimit tries to take a token from the rate limit configured for the given activity. If a
+	// token was taken or no rate limit is configured, the returned duration is zero. Otherwise, the returned duration
+	// is the time to wait before trying again.
+	AcquireActivityRateLimit(ctx context.Context, activityName string) (time.Duration, error)
+
+	// AcquireRateLimit tries to take a token from the named rate limit, like AcquireActivityRateLimit. The bucket is
+	// shared by all callers passing the same name in the namespace, names are prefixed by their owner, like
+	// "activity:" for activity rate limits.
+	AcquireRateLimit(ctx context.Context, name string, limit RateLimit) (time.Duration, error)
+}
+
+// Take refills a bucket holding the given number of tokens, last updated at updatedAt, and tries to take a token
+// from it. It returns the tokens remaining in the bucket and, if no token could be taken, the time until the next
+// token is available.
+//
+// Backends persist the remaining tokens together with now as the new update time.
+func (l RateLimit) Take(tokens float64, updatedAt, now time.Time) (float64, time.Duration) {
+	if l.Limit <= 0 || l.Interval <= 0 {
+		return tokens, 0
+	}
+
+	// Tokens per nanosecond
+	rate := float64(l.Limit) / float64(l.Interval)
+
+	if elapsed := now.Sub(updatedAt); elapsed > 0 {
+		tokens = math.Min(float64(l.Limit), tokens+float64(elapsed)*rate)
+	}
+
+	if tokens >= 1 {
+		return tokens - 1, 0
+	}
+
+	return tokens, time.Duration(math.Ceil((1 - tokens) / rate))
+}
@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RateLimit_Take(t *testing.T) {
+	l := RateLimit{Limit: 2, Interval: time.Second}
+	now := time.Now()
+
+	tokens, wait := l.Take(2, now, now)
+	require.Equal(t, float64(1), tokens)
+	require.Zero(t, wait)
+
+	tokens, wait = l.Take(tokens, now, now)
+	require.Equal(t, float64(0), tokens)
+	require.Zero(t, wait)
+
+	// Bucket is empty, next token is available after half the interval
+	tokens, wait = l.Take(tokens, now, now)
+	require.Equal(t, float64(0), tokens)
+	require.Equal(t, time.Millisecond*500, wait)
+
+	tokens, wait = l.Take(tokens, now, now.Add(time.Millisecond*500))
+	require.Equal(t, float64(0), tokens)
+	require.Zero(t, wait)
+
+	// Refilling never exceeds the limit
+	tokens, wait = l.Take(tokens, now, now.Add(time.Hour))
+	require.Equal(t, float64(1), tokens)
+	require.Zero(t, wait)
+}
+
+func Test_RateLimit_Take_NoLimit(t *testing.T) {
+	now := time.Now()
+
+	_, wait := RateLimit{}.Take(0, now, now)
+	require.Zero(t, wait)
+}
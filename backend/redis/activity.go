@@ -2,14 +2,21 @@ package redis
 
 import (
 	"context"
+	"errors"
 
+	"github.com/cschleiden/go-workflows/backend"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
 	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/redis/go-redis/v9"
 )
 
 func (rb *redisBackend) GetActivityTask(ctx context.Context) (*task.Activity, error) {
-	activityTask, err := rb.activityQueue.Dequeue(ctx, rb.rdb, rb.options.ActivityLockTimeout, rb.options.BlockTimeout)
+	return rb.GetActivityTaskFromQueues(ctx, nil)
+}
+
+func (rb *redisBackend) GetActivityTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Activity, error) {
+	activityTask, err := rb.activityQueue.Dequeue(ctx, rb.rdb, queues, rb.options.ActivityLockTimeout, rb.options.BlockTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -18,11 +25,21 @@ func (rb *redisBackend) GetActivityTask(ctx context.Context) (*task.Activity, er
 		return nil, nil
 	}
 
-	return &task.Activity{
+	t := &task.Activity{
 		WorkflowInstance: activityTask.Data.Instance,
 		ID:               activityTask.TaskID, // Use the queue generated ID here
 		Event:            activityTask.Data.Event,
-	}, nil
+		Redelivered:      activityTask.Recovered,
+	}
+
+	// Only tasks handed out before can have recorded heartbeats
+	if activityTask.Recovered {
+		if err := rb.readActivityHeartbeat(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
 }
 
 func (rb *redisBackend) ExtendActivityTask(ctx context.Context, activityID string) error {
@@ -37,17 +54,54 @@ func (rb *redisBackend) ExtendActivityTask(ctx context.Context, activityID strin
 }
 
 func (rb *redisBackend) CompleteActivityTask(ctx context.Context, instance *core.WorkflowInstance, activityID string, event *history.Event) error {
-	p := rb.rdb.TxPipeline()
-
-	if err := rb.addWorkflowInstanceEventP(ctx, p, instance, event); err != nil {
+	r, err := rb.activityWorkflowRoute(ctx, instance, activityID)
+	if err != nil {
 		return err
 	}
 
-	// Unlock activity
-	if _, err := rb.activityQueue.Complete(ctx, p, activityID); err != nil {
-		return err
-	}
+	_, err = rb.txPipelined(ctx, nil, "", func(p redis.Pipeliner) error {
+		if err := rb.addWorkflowInstanceEventP(ctx, p, instance, r, event); err != nil {
+			return err
+		}
+
+		// Unlock activity
+		if _, err := rb.activityQueue.Complete(ctx, p, activityID); err != nil {
+			return err
+		}
+
+		p.HDel(ctx, rb.keys.activityHeartbeatsKey(), activityID)
+
+		return nil
+	})
 
-	_, err := p.Exec(ctx)
 	return err
 }
+
+// activityRoute returns the route of a scheduled activity's task. Activities are queued with the priority of their
+// workflow instance, in the named queue they have been scheduled to.
+func activityRoute(instanceState *instanceState, event *history.Event) route {
+	r := instanceState.route()
+
+	if a, ok := event.Attributes.(*history.ActivityScheduledAttributes); ok && a.Queue != "" {
+		r.queue = a.Queue
+	}
+
+	return r
+}
+
+// activityWorkflowRoute returns the route of the workflow tasks of the instance the given activity task belongs to.
+// Activities can be routed to another queue than their instance, so the route is read from the instance.
+func (rb *redisBackend) activityWorkflowRoute(ctx context.Context, instance *core.WorkflowInstance, activityID string) (route, error) {
+	instanceState, err := readInstance(ctx, rb.rdb, rb.keys.instanceKey(instance))
+	if err != nil {
+		if errors.Is(err, backend.ErrInstanceNotFound) {
+			// Keep the priority of the task, so the event is still added for the instance
+			r, _ := parseTaskID(activityID)
+			return route{priority: r.priority}, nil
+		}
+
+		return route{}, err
+	}
+
+	return instanceState.route(), nil
+}
@@ -0,0 +1,158 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ backend.ArchiveRetrier = (*redisBackend)(nil)
+
+// archiveHistory moves the history of the given finished instance to the archiver and marks the instance as archived.
+// If archiving fails, the history is kept in redis.
+func (rb *redisBackend) archiveHistory(ctx context.Context, instance *core.WorkflowInstance) error {
+	key := rb.keys.instanceKey(instance)
+
+	return rb.watch(ctx, func(tx *watchedTx) error {
+		state, err := readInstance(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		if state.Archived {
+			return nil
+		}
+
+		h, err := rb.readHistory(ctx, instance, "-")
+		if err != nil {
+			return fmt.Errorf("getting workflow history: %w", err)
+		}
+
+		// Histories moved by earlier versions aren't archived again, that would replace them with an empty history
+		if len(h) > 0 {
+			if err := rb.options.Archiver.ArchiveHistory(ctx, rb.options.Namespace, instance, h); err != nil {
+				return fmt.Errorf("archiving workflow history: %w", err)
+			}
+		}
+
+		state.Archived = true
+		b, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("marshaling instance state: %w", err)
+		}
+
+		if _, err := tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			// Keep the expiration of finished instances
+			p.SetArgs(ctx, key, string(b), redis.SetArgs{KeepTTL: true})
+			p.Del(ctx, rb.keys.historyKey(instance))
+
+			return nil
+		}); err != nil {
+			return fmt.Errorf("deleting archived history: %w", err)
+		}
+
+		return nil
+	}, key)
+}
+
+// tryArchiveHistory archives the history of the given finished instance. Failures are logged, the history is archived
+// by ArchiveWorkflowInstanceHistories later.
+func (rb *redisBackend) tryArchiveHistory(ctx context.Context, instance *core.WorkflowInstance) {
+	if err := rb.archiveHistory(ctx, instance); err != nil {
+		rb.Logger().Error("could not archive workflow instance history", log.InstanceIDKey, instance.InstanceID,
+			log.ExecutionIDKey, instance.ExecutionID, log.ErrorKey, err)
+	}
+}
+
+func (rb *redisBackend) ArchiveWorkflowInstanceHistories(ctx context.Context) (int, error) {
+	if rb.options.Archiver == nil {
+		return 0, nil
+	}
+
+	archived := 0
+
+	var cursor uint64
+	for {
+		// Members of the index are returned alternating with their scores
+		entries, next, err := rb.rdb.ZScan(ctx, rb.keys.instancesByCreation(), cursor, "", purgeScanCount).Result()
+		if err != nil {
+			return archived, fmt.Errorf("scanning instances: %w", err)
+		}
+
+		segments := make([]string, 0, len(entries)/2)
+		for i := 0; i < len(entries); i += 2 {
+			segments = append(segments, entries[i])
+		}
+
+		n, err := rb.archiveInstances(ctx, segments)
+		archived += n
+		if err != nil {
+			return archived, err
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return archived, nil
+		}
+	}
+}
+
+// archiveInstances archives the histories of the finished, unarchived instances with the given segments
+func (rb *redisBackend) archiveInstances(ctx context.Context, segments []string) (int, error) {
+	if len(segments) == 0 {
+		return 0, nil
+	}
+
+	p := rb.rdb.Pipeline()
+	instanceCmds := rb.readInstancesP(ctx, p, segments)
+
+	// Errors are checked when checking the cmds
+	_, _ = p.Exec(ctx)
+
+	archived := 0
+
+	for _, cmd := range instanceCmds {
+		state, err := readInstancePipelineCmd(cmd)
+		if err != nil {
+			// Instance might have expired or been removed since scanning the index
+			if err == backend.ErrInstanceNotFound {
+				continue
+			}
+
+			return archived, fmt.Errorf("reading instances: %w", err)
+		}
+
+		if state.CompletedAt == nil || state.Archived {
+			continue
+		}
+
+		if err := rb.archiveHistory(ctx, state.Instance); err != nil {
+			return archived, err
+		}
+
+		archived++
+	}
+
+	return archived, nil
+}
+
+// getArchivedHistory returns the history of the given instance from the archiver, if it has been archived
+func (rb *redisBackend) getArchivedHistory(ctx context.Context, instance *core.WorkflowInstance) ([]*history.Event, error) {
+	h, err := rb.options.Archiver.GetHistory(ctx, rb.options.Namespace, instance)
+	if err != nil {
+		if errors.Is(err, backend.ErrNotArchived) {
+			return []*history.Event{}, nil
+		}
+
+		return nil, fmt.Errorf("getting archived workflow history: %w", err)
+	}
+
+	return h, nil
+}
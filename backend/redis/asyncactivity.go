@@ -0,0 +1,140 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ backend.AsyncActivityCompleter = (*redisBackend)(nil)
+
+// completionAttempts bounds the retries of transactions on pending activities that conflicted with a concurrent
+// change
+const completionAttempts = 10
+
+// Pending activities are stored in a hash per instance. The value is empty while the activity waits for its result,
+// or the result if it arrived while the activity was still executing. Both operations watch the hash, so that a
+// completion and the activity task being set to pending don't interleave.
+
+// SetActivityTaskPending moves the activity task to the pending activities. If a result arrived while the activity was
+// still executing, the result is added to the instance instead.
+func (rb *redisBackend) SetActivityTaskPending(ctx context.Context, instance *core.WorkflowInstance, activityID string) error {
+	key := rb.keys.pendingActivitiesKey(instance)
+
+	r, err := rb.activityWorkflowRoute(ctx, instance, activityID)
+	if err != nil {
+		return err
+	}
+
+	return rb.retryTx(ctx, key, func(tx *watchedTx) error {
+		eventData, err := tx.HGet(ctx, key, activityID).Result()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("reading pending activity: %w", err)
+		}
+
+		var event *history.Event
+		if eventData != "" {
+			if err := json.Unmarshal([]byte(eventData), &event); err != nil {
+				return fmt.Errorf("unmarshaling activity result: %w", err)
+			}
+		}
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if event != nil {
+				// The activity has been completed already
+				p.HDel(ctx, key, activityID)
+
+				if err := rb.addWorkflowInstanceEventP(ctx, p, instance, r, event); err != nil {
+					return err
+				}
+			} else {
+				p.HSet(ctx, key, activityID, "")
+			}
+
+			p.HDel(ctx, rb.keys.activityHeartbeatsKey(), activityID)
+
+			// Unlock activity
+			_, err := rb.activityQueue.Complete(ctx, p, activityID)
+			return err
+		})
+
+		return err
+	})
+}
+
+// CompletePendingActivityTask adds the result of a pending activity to the instance. If the activity is still
+// executing, the result is stored until its task is set to pending.
+func (rb *redisBackend) CompletePendingActivityTask(ctx context.Context, instance *core.WorkflowInstance, activityID string, event *history.Event) error {
+	key := rb.keys.pendingActivitiesKey(instance)
+
+	r, err := rb.activityWorkflowRoute(ctx, instance, activityID)
+	if err != nil {
+		return err
+	}
+
+	activityRoute, msgID := parseTaskID(activityID)
+
+	return rb.retryTx(ctx, key, func(tx *watchedTx) error {
+		eventData, err := tx.HGet(ctx, key, activityID).Result()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("reading pending activity: %w", err)
+		}
+
+		if err == nil {
+			if eventData != "" {
+				// A result has been stored already
+				return backend.ErrActivityNotFound
+			}
+
+			_, err := tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+				p.HDel(ctx, key, activityID)
+
+				return rb.addWorkflowInstanceEventP(ctx, p, instance, r, event)
+			})
+
+			return err
+		}
+
+		// Check whether the activity is still executing. The task queue is in another hash slot in cluster mode, it's
+		// read outside of the transaction.
+		msgs, err := rb.rdb.XRange(ctx, rb.activityQueue.streamKey(activityRoute), msgID, msgID).Result()
+		if err != nil {
+			return fmt.Errorf("checking for executing activity: %w", err)
+		}
+
+		if len(msgs) == 0 {
+			return backend.ErrActivityNotFound
+		}
+
+		d, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshaling activity result: %w", err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.HSet(ctx, key, activityID, string(d))
+
+			return nil
+		})
+
+		return err
+	})
+}
+
+// retryTx runs fn in a transaction watching the given key, retrying if the key was changed concurrently
+func (rb *redisBackend) retryTx(ctx context.Context, key string, fn func(tx *watchedTx) error) error {
+	for i := 0; i < completionAttempts; i++ {
+		err := rb.rdb.Watch(ctx, rb.watched(fn, key), key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+
+	return redis.TxFailedErr
+}
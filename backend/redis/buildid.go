@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// requeueForBuildID pins the instance of the given workflow task to the build ID of the backend, if it isn't pinned
+// yet. If the instance is pinned to a different build ID, the task is queued again for another worker and true is
+// returned.
+func (rb *redisBackend) requeueForBuildID(ctx context.Context, taskID string, instanceState *instanceState) (bool, error) {
+	if rb.options.BuildID == "" {
+		return false, nil
+	}
+
+	segment := instanceSegment(instanceState.Instance)
+
+	if err := rb.rdb.HSetNX(ctx, rb.keys.instanceBuildIDsKey(), segment, rb.options.BuildID).Err(); err != nil {
+		return false, fmt.Errorf("pinning instance to build ID: %w", err)
+	}
+
+	buildID, err := rb.rdb.HGet(ctx, rb.keys.instanceBuildIDsKey(), segment).Result()
+	if err != nil {
+		return false, fmt.Errorf("reading build ID of instance: %w", err)
+	}
+
+	if buildID == rb.options.BuildID {
+		return false, nil
+	}
+
+	// Hand the task to a worker of the pinned build ID
+	if _, err := rb.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		if _, err := rb.workflowQueue.Complete(ctx, p, taskID); err != nil {
+			return err
+		}
+
+		return rb.workflowQueue.Enqueue(ctx, p, instanceState.route(), segment, nil)
+	}); err != nil {
+		return false, fmt.Errorf("queueing task for build ID %q: %w", buildID, err)
+	}
+
+	return true, nil
+}
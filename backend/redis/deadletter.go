@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/redis/go-redis/v9"
+)
+
+func (rb *redisBackend) DeadLetterWorkflowTask(ctx context.Context, t *task.Workflow, reason string) error {
+	if _, err := rb.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, rb.keys.deadLetteredInstancesKey(), instanceSegment(t.WorkflowInstance), reason)
+
+		// Complete the task without touching the instance, its pending events are processed once it's retried
+		_, err := rb.workflowQueue.Complete(ctx, p, t.ID)
+		return err
+	}); err != nil {
+		return fmt.Errorf("dead-lettering workflow instance: %w", err)
+	}
+
+	return nil
+}
+
+func (rb *redisBackend) RetryWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance) error {
+	instanceState, err := readInstance(ctx, rb.rdb, rb.keys.instanceKey(instance))
+	if err != nil {
+		return err
+	}
+
+	segment := instanceSegment(instance)
+
+	removed, err := rb.rdb.HDel(ctx, rb.keys.deadLetteredInstancesKey(), segment).Result()
+	if err != nil {
+		return fmt.Errorf("retrying workflow instance: %w", err)
+	}
+
+	if removed == 0 {
+		// Not dead-lettered
+		return nil
+	}
+
+	if _, err := rb.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.HDel(ctx, rb.keys.instanceTaskAttemptsKey(), segment)
+
+		return rb.workflowQueue.Enqueue(ctx, p, instanceState.route(), segment, nil)
+	}); err != nil {
+		return fmt.Errorf("queueing retried workflow instance: %w", err)
+	}
+
+	return nil
+}
+
+// dropDeadLetteredTask completes the given workflow task if its instance is dead-lettered. Retrying the instance
+// queues a new task. Returns true if the task was dropped.
+func (rb *redisBackend) dropDeadLetteredTask(ctx context.Context, taskID string, instanceState *instanceState) (bool, error) {
+	deadLettered, err := rb.rdb.HExists(ctx, rb.keys.deadLetteredInstancesKey(), instanceSegment(instanceState.Instance)).Result()
+	if err != nil {
+		return false, fmt.Errorf("checking if instance is dead-lettered: %w", err)
+	}
+
+	if !deadLettered {
+		return false, nil
+	}
+
+	if _, err := rb.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		_, err := rb.workflowQueue.Complete(ctx, p, taskID)
+		return err
+	}); err != nil {
+		return false, fmt.Errorf("dropping task of dead-lettered instance: %w", err)
+	}
+
+	return true, nil
+}
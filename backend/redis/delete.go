@@ -11,25 +11,64 @@ import (
 // KEYS[1] - instance key
 // KEYS[2] - pending events key
 // KEYS[3] - history key
-// KEYS[4] - instances-by-creation key
+// KEYS[4] - pending activities key
+// KEYS[5] - latest instance execution key
 // ARGV[1] - instance segment
 var deleteCmd = redis.NewScript(
-	`redis.call("DEL", KEYS[1], KEYS[2], KEYS[3])
-	return redis.call("ZREM", KEYS[4], ARGV[1])`)
+	`redis.call("DEL", KEYS[1], KEYS[2], KEYS[3], KEYS[4])
+	if redis.call("GET", KEYS[5]) == ARGV[1] then
+		redis.call("DEL", KEYS[5])
+	end
+	return true`)
+
+// Remove an instance from the indexes of the namespace. The keys of the instance are in different hash slots in
+// cluster mode, so they are deleted separately by deleteCmd.
+//
+// KEYS[1] - instances-by-creation key
+// KEYS[2] - paused instances key
+// KEYS[3] - canceled instances key
+// KEYS[4] - dead-lettered instances key
+// KEYS[5] - instance task attempts key
+// ARGV[1] - instance segment
+var deleteIndexesCmd = redis.NewScript(
+	`redis.call("SREM", KEYS[2], ARGV[1])
+	redis.call("SREM", KEYS[3], ARGV[1])
+	redis.call("HDEL", KEYS[4], ARGV[1])
+	redis.call("HDEL", KEYS[5], ARGV[1])
+	return redis.call("ZREM", KEYS[1], ARGV[1])`)
 
 // deleteInstance deletes an instance from Redis. It does not attempt to remove any future events or pending
 // workflow tasks. It's assumed that the instance is in the finished state.
 //
 // Note: might want to revisit this in the future if we want to support removing hung instances.
-func deleteInstance(ctx context.Context, rdb redis.UniversalClient, instance *core.WorkflowInstance) error {
-	if err := deleteCmd.Run(ctx, rdb, []string{
-		instanceKey(instance),
-		pendingEventsKey(instance),
-		historyKey(instance),
-		instancesByCreation(),
-	}, instanceSegment(instance)).Err(); err != nil {
+func (rb *redisBackend) deleteInstance(ctx context.Context, instance *core.WorkflowInstance) error {
+	if _, err := rb.txPipelined(ctx, nil, "", func(p redis.Pipeliner) error {
+		rb.deleteInstanceP(ctx, p, instance)
+		return nil
+	}); err != nil {
 		return fmt.Errorf("failed to delete instance: %w", err)
 	}
 
 	return nil
 }
+
+// deleteInstanceP runs the scripts deleting the given instance, use it to delete instances in a pipeline
+func (rb *redisBackend) deleteInstanceP(ctx context.Context, rdb redis.Scripter, instance *core.WorkflowInstance) {
+	segment := instanceSegment(instance)
+
+	deleteCmd.Run(ctx, rdb, []string{
+		rb.keys.instanceKey(instance),
+		rb.keys.pendingEventsKey(instance),
+		rb.keys.historyKey(instance),
+		rb.keys.pendingActivitiesKey(instance),
+		rb.keys.latestInstanceExecutionKey(instance.InstanceID),
+	}, segment)
+
+	deleteIndexesCmd.Run(ctx, rdb, []string{
+		rb.keys.instancesByCreation(),
+		rb.keys.pausedInstancesKey(),
+		rb.keys.instancesCanceled(),
+		rb.keys.deadLetteredInstancesKey(),
+		rb.keys.instanceTaskAttemptsKey(),
+	}, segment)
+}
@@ -2,9 +2,9 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
+	"github.com/cschleiden/go-workflows/backend"
 	"github.com/cschleiden/go-workflows/diag"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/log"
@@ -13,12 +13,16 @@ import (
 
 var _ diag.Backend = (*redisBackend)(nil)
 
+func (rb *redisBackend) Namespace() string {
+	return rb.options.Namespace
+}
+
 func (rb *redisBackend) GetWorkflowInstances(ctx context.Context, afterInstanceID, afterExecutionID string, count int) ([]*diag.WorkflowInstanceRef, error) {
 	max := "+inf"
 
 	if afterInstanceID != "" {
 		afterID := instanceSegment(core.NewWorkflowInstance(afterInstanceID, afterExecutionID))
-		scores, err := rb.rdb.ZMScore(ctx, instancesByCreation(), afterID).Result()
+		scores, err := rb.rdb.ZMScore(ctx, rb.keys.instancesByCreation(), afterID).Result()
 		if err != nil {
 			return nil, fmt.Errorf("getting instance score for %v: %w", afterID, err)
 		}
@@ -35,7 +39,7 @@ func (rb *redisBackend) GetWorkflowInstances(ctx context.Context, afterInstanceI
 	}
 
 	result, err := rb.rdb.ZRangeArgs(ctx, redis.ZRangeArgs{
-		Key:     instancesByCreation(),
+		Key:     rb.keys.instancesByCreation(),
 		Stop:    max,
 		Start:   "-inf",
 		ByScore: true,
@@ -46,42 +50,56 @@ func (rb *redisBackend) GetWorkflowInstances(ctx context.Context, afterInstanceI
 		return nil, fmt.Errorf("getting instances after %v: %w", max, err)
 	}
 
-	instanceKeys := make([]string, 0)
-	for _, r := range result {
-		instanceSegment := r
-		instanceKeys = append(instanceKeys, instanceKeyFromSegment(instanceSegment))
-	}
+	p := rb.rdb.Pipeline()
+	instanceCmds := rb.readInstancesP(ctx, p, result)
 
-	instances, err := rb.rdb.MGet(ctx, instanceKeys...).Result()
+	// Errors are checked when checking the cmds
+	_, _ = p.Exec(ctx)
+
+	deadLetterReasons, err := rb.rdb.HMGet(ctx, rb.keys.deadLetteredInstancesKey(), result...).Result()
 	if err != nil {
-		return nil, fmt.Errorf("getting instances: %w", err)
+		return nil, fmt.Errorf("getting dead-letter reasons: %w", err)
 	}
 
 	var instanceRefs []*diag.WorkflowInstanceRef
-	for _, instance := range instances {
-		var state instanceState
-		if err := json.Unmarshal([]byte(instance.(string)), &state); err != nil {
-			return nil, fmt.Errorf("unmarshaling instance state: %w", err)
+	for i, cmd := range instanceCmds {
+		state, err := readInstancePipelineCmd(cmd)
+		if err != nil {
+			// Instance might have expired or been removed since reading the index
+			if err == backend.ErrInstanceNotFound {
+				continue
+			}
+
+			return nil, fmt.Errorf("getting instances: %w", err)
 		}
 
-		instanceRefs = append(instanceRefs, &diag.WorkflowInstanceRef{
-			Instance:    state.Instance,
-			CreatedAt:   state.CreatedAt,
-			CompletedAt: state.CompletedAt,
-			State:       state.State,
-		})
+		ref := mapWorkflowInstance(state)
+		if reason, ok := deadLetterReasons[i].(string); ok {
+			ref.DeadLetterReason = reason
+		}
+
+		instanceRefs = append(instanceRefs, ref)
 	}
 
 	return instanceRefs, nil
 }
 
 func (rb *redisBackend) GetWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance) (*diag.WorkflowInstanceRef, error) {
-	instanceState, err := readInstance(ctx, rb.rdb, instanceKey(instance))
+	instanceState, err := readInstance(ctx, rb.rdb, rb.keys.instanceKey(instance))
 	if err != nil {
 		return nil, err
 	}
 
-	return mapWorkflowInstance(instanceState), nil
+	ref := mapWorkflowInstance(instanceState)
+
+	reason, err := rb.rdb.HGet(ctx, rb.keys.deadLetteredInstancesKey(), instanceSegment(instance)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("getting dead-letter reason: %w", err)
+	}
+
+	ref.DeadLetterReason = reason
+
+	return ref, nil
 }
 
 func (rb *redisBackend) GetWorkflowTree(ctx context.Context, instance *core.WorkflowInstance) (*diag.WorkflowInstanceTree, error) {
@@ -95,5 +113,6 @@ func mapWorkflowInstance(instance *instanceState) *diag.WorkflowInstanceRef {
 		CreatedAt:   instance.CreatedAt,
 		CompletedAt: instance.CompletedAt,
 		State:       instance.State,
+		Memo:        instance.Memo,
 	}
 }
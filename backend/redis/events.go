@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"strconv"
+	"time"
 
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
@@ -61,28 +62,46 @@ func addEventsToHistoryStreamP(ctx context.Context, p redis.Pipeliner, streamKey
 // ARGV[1] - timestamp
 // ARGV[2] - Instance segment
 // ARGV[3] - event payload
+// ARGV[4] - workflow task queue stream for the instance
 var addFutureEventCmd = redis.NewScript(`
 	redis.call("ZADD", KEYS[1], ARGV[1], KEYS[2])
-	return redis.call("HSET", KEYS[2], "instance", ARGV[2], "event", ARGV[3])
+	return redis.call("HSET", KEYS[2], "instance", ARGV[2], "event", ARGV[3], "stream", ARGV[4])
 `)
 
-func addFutureEventP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance, event *history.Event) error {
+func (rb *redisBackend) addFutureEventP(
+	ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance, r route, event *history.Event,
+) error {
 	eventData, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
 
+	key := rb.keys.futureEventKey(instance, event.ScheduleEventID)
+	if event.Type == history.EventType_SignalReceived {
+		// Delayed signals are not correlated with a scheduling event
+		key = rb.keys.futureSignalKey(instance, event.ID)
+	}
+
 	addFutureEventCmd.Run(
 		ctx, p,
-		[]string{futureEventsKey(), futureEventKey(instance, event.ScheduleEventID)},
+		[]string{rb.keys.futureEventsKey(), key},
 		strconv.FormatInt(event.VisibleAt.UnixMilli(), 10),
 		instanceSegment(instance),
 		string(eventData),
+		rb.workflowQueue.Keys(r).StreamKey,
 	)
 
 	return nil
 }
 
+// addFutureStartP queues the first workflow task of a delayed instance once it's due
+func (rb *redisBackend) addFutureStartP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance, r route, at time.Time) {
+	key := rb.keys.futureStartKey(instance)
+
+	p.ZAdd(ctx, rb.keys.futureEventsKey(), redis.Z{Member: key, Score: float64(at.UnixMilli())})
+	p.HSet(ctx, key, "instance", instanceSegment(instance), "stream", rb.workflowQueue.Keys(r).StreamKey)
+}
+
 // KEYS[1] - future event zset key
 // KEYS[2] - future event key
 var removeFutureEventCmd = redis.NewScript(`
@@ -91,7 +110,7 @@ var removeFutureEventCmd = redis.NewScript(`
 `)
 
 // removeFutureEvent removes a scheduled future event for the given event. Events are associated via their ScheduleEventID
-func removeFutureEventP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance, event *history.Event) {
-	key := futureEventKey(instance, event.ScheduleEventID)
-	removeFutureEventCmd.Run(ctx, p, []string{futureEventsKey(), key})
+func (rb *redisBackend) removeFutureEventP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance, event *history.Event) {
+	key := rb.keys.futureEventKey(instance, event.ScheduleEventID)
+	removeFutureEventCmd.Run(ctx, p, []string{rb.keys.futureEventsKey(), key})
 }
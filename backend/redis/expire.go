@@ -11,53 +11,81 @@ import (
 
 // We can't have events for redis..we do not want to do it in-process..
 
-// Set the given expiration time on all keys passed in
+// Set the given expiration time on all keys of an instance
+// KEYS[1] - latest instance execution key
+// KEYS[2] - instance key
+// KEYS[3] - pending events key
+// KEYS[4] - history key
+// KEYS[5] - pending activities key
+// ARGV[1] - expiration time in seconds
+// ARGV[2] - instance segment
+var expireCmd = redis.NewScript(
+	`-- Expire the latest execution of the instance ID only if no newer execution has been created
+	if redis.call("GET", KEYS[1]) == ARGV[2] then
+		redis.call("EXPIRE", KEYS[1], ARGV[1])
+	end
+
+	-- Set expiration on all instance keys
+	for i = 2, #KEYS do
+		redis.call("EXPIRE", KEYS[i], ARGV[1])
+	end
+
+	return 0
+	`,
+)
+
+// Track the expiration of an instance in the indexes of the namespace
 // KEYS[1] - instances-by-creation key
 // KEYS[2] - instances-expiring key
-// KEYS[3] - instance key
-// KEYS[4] - pending events key
-// KEYS[5] - history key
+// KEYS[3] - instances-canceled key
 // ARGV[1] - current timestamp
-// ARGV[2] - expiration time in seconds
-// ARGV[3] - expiration timestamp in unix milliseconds
-// ARGV[4] - instance segment
-var expireCmd = redis.NewScript(
+// ARGV[2] - expiration timestamp in unix milliseconds
+// ARGV[3] - instance segment
+var expireIndexesCmd = redis.NewScript(
 	`-- Find instances which have already expired and remove from the index set
 	local expiredInstances = redis.call("ZRANGE", KEYS[2], "-inf", ARGV[1], "BYSCORE")
 	for i = 1, #expiredInstances do
 		local instanceSegment = expiredInstances[i]
 		redis.call("ZREM", KEYS[1], instanceSegment) -- index set
 		redis.call("ZREM", KEYS[2], instanceSegment) -- expiration set
+		redis.call("SREM", KEYS[3], instanceSegment) -- canceled set
 	end
 
 	-- Add expiration time for future cleanup
-	redis.call("ZADD", KEYS[2], ARGV[3], ARGV[4])
-
-	-- Set expiration on all keys
-	for i = 3, #KEYS do
-		redis.call("EXPIRE", KEYS[i], ARGV[2])
-	end
+	redis.call("ZADD", KEYS[2], ARGV[2], ARGV[3])
 
 	return 0
 	`,
 )
 
-func setWorkflowInstanceExpiration(ctx context.Context, rdb redis.UniversalClient, instance *core.WorkflowInstance, expiration time.Duration) error {
-	now := time.Now().UnixMilli()
+// setWorkflowInstanceExpiration sets the expiration of the given instance. The keys of the instance and the indexes
+// of the namespace are in different hash slots in cluster mode, so they are updated by separate scripts.
+func (rb *redisBackend) setWorkflowInstanceExpiration(ctx context.Context, rdb redis.Scripter, instance *core.WorkflowInstance, expiration time.Duration) error {
+	now := rb.options.Clock.Now().UnixMilli()
 	nowStr := strconv.FormatInt(now, 10)
 
-	exp := time.Now().Add(expiration).UnixMilli()
+	exp := rb.options.Clock.Now().Add(expiration).UnixMilli()
 	expStr := strconv.FormatInt(exp, 10)
 
-	return expireCmd.Run(ctx, rdb, []string{
-		instancesByCreation(),
-		instancesExpiring(),
-		instanceKey(instance),
-		pendingEventsKey(instance),
-		historyKey(instance),
+	if err := expireCmd.Run(ctx, rdb, []string{
+		rb.keys.latestInstanceExecutionKey(instance.InstanceID),
+		rb.keys.instanceKey(instance),
+		rb.keys.pendingEventsKey(instance),
+		rb.keys.historyKey(instance),
+		rb.keys.pendingActivitiesKey(instance),
 	},
-		nowStr,
 		expiration.Seconds(),
+		instanceSegment(instance),
+	).Err(); err != nil {
+		return err
+	}
+
+	return expireIndexesCmd.Run(ctx, rdb, []string{
+		rb.keys.instancesByCreation(),
+		rb.keys.instancesExpiring(),
+		rb.keys.instancesCanceled(),
+	},
+		nowStr,
 		expStr,
 		instanceSegment(instance),
 	).Err()
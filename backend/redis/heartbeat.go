@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ backend.ActivityHeartbeater = (*redisBackend)(nil)
+
+type activityHeartbeat struct {
+	At      time.Time       `json:"at"`
+	Details payload.Payload `json:"details,omitempty"`
+}
+
+func (rb *redisBackend) RecordActivityHeartbeat(ctx context.Context, activityID string, details payload.Payload) error {
+	d, err := json.Marshal(&activityHeartbeat{
+		At:      rb.options.Clock.Now(),
+		Details: details,
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling activity heartbeat: %w", err)
+	}
+
+	p := rb.rdb.TxPipeline()
+
+	if err := rb.activityQueue.Extend(ctx, p, activityID); err != nil {
+		return err
+	}
+
+	p.HSet(ctx, rb.keys.activityHeartbeatsKey(), activityID, string(d))
+
+	_, err = p.Exec(ctx)
+	return err
+}
+
+// readActivityHeartbeat sets the last heartbeat recorded by a previous delivery of the given task
+func (rb *redisBackend) readActivityHeartbeat(ctx context.Context, t *task.Activity) error {
+	d, err := rb.rdb.HGet(ctx, rb.keys.activityHeartbeatsKey(), t.ID).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil
+		}
+
+		return fmt.Errorf("reading activity heartbeat: %w", err)
+	}
+
+	var heartbeat activityHeartbeat
+	if err := json.Unmarshal([]byte(d), &heartbeat); err != nil {
+		return fmt.Errorf("unmarshaling activity heartbeat: %w", err)
+	}
+
+	t.LastHeartbeat = heartbeat.At
+	t.HeartbeatDetails = heartbeat.Details
+
+	return nil
+}
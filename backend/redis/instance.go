@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -13,45 +14,157 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// Add an instance to a set of active instances, like the set of its workflow, unless the set has reached the limit
+//
+// KEYS[1] - set of active instances
+// ARGV[1] - concurrency limit
+// ARGV[2] - instance segment
+//
+// Returns 1 if the instance was added, 0 if the limit has been reached.
+var reserveConcurrencySlotCmd = redis.NewScript(`
+	if redis.call("SISMEMBER", KEYS[1], ARGV[2]) == 1 then
+		return 1
+	end
+
+	if redis.call("SCARD", KEYS[1]) >= tonumber(ARGV[1]) then
+		return 0
+	end
+
+	redis.call("SADD", KEYS[1], ARGV[2])
+	return 1
+`)
+
 func (rb *redisBackend) CreateWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
-	state, err := readInstance(ctx, rb.rdb, instanceKey(instance))
-	if err != nil && err != backend.ErrInstanceNotFound {
-		return err
-	}
+	a := event.Attributes.(*history.ExecutionStartedAttributes)
 
-	if state != nil {
-		return backend.ErrInstanceAlreadyExists
-	}
+	// Execution terminated to create the instance, if any
+	var terminated *core.WorkflowInstance
 
-	p := rb.rdb.TxPipeline()
+	// Watch the keys of the instance, so that concurrent creations of the same instance don't both succeed
+	err := rb.watch(ctx, func(tx *watchedTx) error {
+		state, err := readInstance(ctx, tx, rb.keys.instanceKey(instance))
+		if err != nil && err != backend.ErrInstanceNotFound {
+			return err
+		}
 
-	if err := createInstanceP(ctx, p, instance, event.Attributes.(*history.ExecutionStartedAttributes).Metadata, false); err != nil {
-		return err
-	}
+		if state != nil {
+			return backend.ErrInstanceAlreadyExists
+		}
 
-	// Create event stream
-	eventData, err := json.Marshal(event)
-	if err != nil {
-		return err
-	}
+		// Check for existing executions of the same instance
+		terminateExisting, existing, err := rb.checkInstanceIDReuse(ctx, tx.Tx, instance.InstanceID, a.InstanceIDReusePolicy)
+		if err != nil {
+			return err
+		}
 
-	p.XAdd(ctx, &redis.XAddArgs{
-		Stream: pendingEventsKey(instance),
-		ID:     "*",
-		Values: map[string]interface{}{
-			"event": string(eventData),
-		},
-	})
+		terminated = existing
 
-	// Queue workflow instance task
-	if err := rb.workflowQueue.Enqueue(ctx, p, instanceSegment(instance), nil); err != nil {
-		return fmt.Errorf("queueing workflow task: %w", err)
-	}
+		// Slots reserved in sets of active instances, released if the instance isn't created
+		var reserved []string
+		release := func() {
+			for _, set := range reserved {
+				rb.rdb.SRem(ctx, set, instanceSegment(instance))
+			}
+		}
+
+		// Reserve a slot if the workflow has a concurrency limit
+		if limit, ok := rb.options.WorkflowConcurrencyLimits[a.Name]; ok {
+			set := rb.keys.instancesActiveByWorkflow(a.Name)
+			ok, err := reserveConcurrencySlotCmd.Run(ctx, rb.rdb, []string{set}, limit, instanceSegment(instance)).Int()
+			if err != nil {
+				return fmt.Errorf("reserving concurrency slot: %w", err)
+			}
+
+			if ok == 0 {
+				return backend.ErrConcurrencyLimitReached
+			}
+
+			reserved = append(reserved, set)
+		}
+
+		// Reserve slots for the active instance limits of the namespace and the tenant
+		for _, l := range backend.ActiveInstanceLimits(ctx) {
+			if l.Max <= 0 {
+				continue
+			}
+
+			set := rb.keys.instancesActive()
+			if l.Tenant != "" {
+				set = rb.keys.instancesActiveBySearchAttribute(l.TenantSearchAttribute, l.Tenant)
+			}
+
+			ok, err := reserveConcurrencySlotCmd.Run(ctx, rb.rdb, []string{set}, l.Max, instanceSegment(instance)).Int()
+			if err != nil {
+				release()
+				return fmt.Errorf("reserving quota slot: %w", err)
+			}
+
+			if ok == 0 {
+				release()
+				return l.Exceeded()
+			}
+
+			reserved = append(reserved, set)
+		}
+
+		if _, err := tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if terminateExisting != nil {
+				if err := terminateExisting(p); err != nil {
+					return err
+				}
+			}
+
+			if err := rb.createInstanceP(ctx, p, instance, a, event.VisibleAt, false); err != nil {
+				return err
+			}
+
+			// Create event stream
+			eventData, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+
+			p.XAdd(ctx, &redis.XAddArgs{
+				Stream: rb.keys.pendingEventsKey(instance),
+				ID:     "*",
+				Values: map[string]interface{}{
+					"event": string(eventData),
+				},
+			})
+
+			if event.VisibleAt != nil {
+				// Queue the workflow task once the instance is due
+				rb.addFutureStartP(ctx, p, instance, route{queue: a.Queue, priority: a.Priority}, *event.VisibleAt)
+				return nil
+			}
+
+			// Queue workflow instance task
+			if err := rb.workflowQueue.Enqueue(ctx, p, route{queue: a.Queue, priority: a.Priority}, instanceSegment(instance), nil); err != nil {
+				return fmt.Errorf("queueing workflow task: %w", err)
+			}
+
+			return nil
+		}); err != nil {
+			release()
+
+			return err
+		}
+
+		return nil
+	}, rb.keys.instanceKey(instance), rb.keys.activeInstanceExecutionKey(instance.InstanceID), rb.keys.latestInstanceExecutionKey(instance.InstanceID))
+	if err != nil {
+		if errors.Is(err, backend.ErrInstanceAlreadyExists) || errors.Is(err, backend.ErrConcurrencyLimitReached) ||
+			errors.Is(err, backend.ErrQuotaExceeded) {
+			return err
+		}
 
-	if _, err := p.Exec(ctx); err != nil {
 		return fmt.Errorf("creating workflow instance: %w", err)
 	}
 
+	if terminated != nil && rb.options.Archiver != nil {
+		rb.tryArchiveHistory(ctx, terminated)
+	}
+
 	rb.options.Logger.Debug("Created new workflow instance")
 
 	return nil
@@ -64,7 +177,22 @@ func (rb *redisBackend) GetWorkflowInstanceHistory(ctx context.Context, instance
 		start = "(" + historyID(*lastSequenceID)
 	}
 
-	msgs, err := rb.rdb.XRange(ctx, historyKey(instance), start, "+").Result()
+	events, err := rb.readHistory(ctx, instance, start)
+	if err != nil {
+		return nil, err
+	}
+
+	// Histories of finished instances might have been moved to the archiver
+	if len(events) == 0 && lastSequenceID == nil && rb.options.Archiver != nil {
+		return rb.getArchivedHistory(ctx, instance)
+	}
+
+	return events, nil
+}
+
+// readHistory reads the history of the given instance, starting at the given stream ID
+func (rb *redisBackend) readHistory(ctx context.Context, instance *core.WorkflowInstance, start string) ([]*history.Event, error) {
+	msgs, err := rb.rdb.XRange(ctx, rb.keys.historyKey(instance), start, "+").Result()
 	if err != nil {
 		return nil, err
 	}
@@ -83,7 +211,7 @@ func (rb *redisBackend) GetWorkflowInstanceHistory(ctx context.Context, instance
 }
 
 func (rb *redisBackend) GetWorkflowInstanceState(ctx context.Context, instance *core.WorkflowInstance) (core.WorkflowInstanceState, error) {
-	instanceState, err := readInstance(ctx, rb.rdb, instanceKey(instance))
+	instanceState, err := readInstance(ctx, rb.rdb, rb.keys.instanceKey(instance))
 	if err != nil {
 		return core.WorkflowInstanceStateActive, err
 	}
@@ -93,16 +221,18 @@ func (rb *redisBackend) GetWorkflowInstanceState(ctx context.Context, instance *
 
 func (rb *redisBackend) CancelWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance, event *history.Event) error {
 	// Read the instance to check if it exists
-	_, err := readInstance(ctx, rb.rdb, instanceKey(instance))
+	instanceState, err := readInstance(ctx, rb.rdb, rb.keys.instanceKey(instance))
 	if err != nil {
 		return err
 	}
 
-	// Cancel instance
-	if cmds, err := rb.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
-		return rb.addWorkflowInstanceEventP(ctx, p, instance, event)
+	// Cancel instance. Sub-workflows are canceled by the workflow executor once it has processed the cancellation.
+	if _, err := rb.txPipelined(ctx, nil, "", func(p redis.Pipeliner) error {
+		// Track canceled instances for listing
+		p.SAdd(ctx, rb.keys.instancesCanceled(), instanceSegment(instance))
+
+		return rb.addWorkflowInstanceEventP(ctx, p, instance, instanceState.route(), event)
 	}); err != nil {
-		fmt.Println(cmds)
 		return fmt.Errorf("adding cancellation event to workflow instance: %w", err)
 	}
 
@@ -110,7 +240,7 @@ func (rb *redisBackend) CancelWorkflowInstance(ctx context.Context, instance *co
 }
 
 func (rb *redisBackend) RemoveWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance) error {
-	i, err := readInstance(ctx, rb.rdb, instanceKey(instance))
+	i, err := readInstance(ctx, rb.rdb, rb.keys.instanceKey(instance))
 	if err != nil {
 		return err
 	}
@@ -120,7 +250,7 @@ func (rb *redisBackend) RemoveWorkflowInstance(ctx context.Context, instance *co
 		return backend.ErrInstanceNotFinished
 	}
 
-	return deleteInstance(ctx, rb.rdb, instance)
+	return rb.deleteInstance(ctx, instance)
 }
 
 type instanceState struct {
@@ -133,18 +263,56 @@ type instanceState struct {
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 
 	LastSequenceID int64 `json:"last_sequence_id,omitempty"`
+
+	Priority core.Priority `json:"priority,omitempty"`
+
+	Queue core.Queue `json:"queue,omitempty"`
+
+	WorkflowName string `json:"workflow_name,omitempty"`
+
+	SearchAttributes core.SearchAttributes `json:"search_attributes,omitempty"`
+
+	// ActiveSets are the sets of active instances by search attribute the instance is a member of until it finishes
+	ActiveSets []string `json:"active_sets,omitempty"`
+
+	Memo core.Memo `json:"memo,omitempty"`
+
+	// StartAt is set for delayed instances, no workflow task is executed before
+	StartAt *time.Time `json:"start_at,omitempty"`
+
+	// Archived is set once the history of the finished instance has been moved to the archiver
+	Archived bool `json:"archived,omitempty"`
+}
+
+// route returns the route of the instance's workflow tasks
+func (s *instanceState) route() route {
+	return route{queue: s.Queue, priority: s.Priority}
 }
 
-func createInstanceP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance, metadata *core.WorkflowMetadata, ignoreDuplicate bool) error {
-	key := instanceKey(instance)
+func (rb *redisBackend) createInstanceP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance, a *history.ExecutionStartedAttributes, startAt *time.Time, ignoreDuplicate bool) error {
+	key := rb.keys.instanceKey(instance)
 
-	createdAt := time.Now()
+	createdAt := rb.options.Clock.Now()
+
+	var activeSets []string
+	for name, sa := range a.SearchAttributes {
+		if sa.Type == core.SearchAttributeTypeString {
+			activeSets = append(activeSets, rb.keys.instancesActiveBySearchAttribute(name, sa.Value))
+		}
+	}
 
 	b, err := json.Marshal(&instanceState{
-		Instance:  instance,
-		State:     core.WorkflowInstanceStateActive,
-		Metadata:  metadata,
-		CreatedAt: createdAt,
+		Instance:         instance,
+		State:            core.WorkflowInstanceStateActive,
+		Metadata:         a.Metadata,
+		CreatedAt:        createdAt,
+		Priority:         a.Priority,
+		Queue:            a.Queue,
+		WorkflowName:     a.Name,
+		SearchAttributes: a.SearchAttributes,
+		ActiveSets:       activeSets,
+		Memo:             a.Memo,
+		StartAt:          startAt,
 	})
 	if err != nil {
 		return fmt.Errorf("marshaling instance state: %w", err)
@@ -153,20 +321,29 @@ func createInstanceP(ctx context.Context, p redis.Pipeliner, instance *core.Work
 	p.SetNX(ctx, key, string(b), 0)
 
 	// The newly created instance is going to be the active execution
-	setActiveInstanceExecutionP(ctx, p, instance)
+	rb.setActiveInstanceExecutionP(ctx, p, instance)
+	p.Set(ctx, rb.keys.latestInstanceExecutionKey(instance.InstanceID), instanceSegment(instance), 0)
 
-	p.ZAdd(ctx, instancesByCreation(), redis.Z{
+	p.ZAdd(ctx, rb.keys.instancesByCreation(), redis.Z{
 		Member: instanceSegment(instance),
 		Score:  float64(createdAt.UnixMilli()),
 	})
 
-	p.SAdd(ctx, instancesActive(), instanceSegment(instance))
+	p.SAdd(ctx, rb.keys.instancesActive(), instanceSegment(instance))
+
+	if a.Name != "" {
+		p.SAdd(ctx, rb.keys.instancesActiveByWorkflow(a.Name), instanceSegment(instance))
+	}
+
+	for _, set := range activeSets {
+		p.SAdd(ctx, set, instanceSegment(instance))
+	}
 
 	return nil
 }
 
-func updateInstanceP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance, state *instanceState) error {
-	key := instanceKey(instance)
+func (rb *redisBackend) updateInstanceP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance, state *instanceState) error {
+	key := rb.keys.instanceKey(instance)
 
 	b, err := json.Marshal(state)
 	if err != nil {
@@ -176,7 +353,15 @@ func updateInstanceP(ctx context.Context, p redis.Pipeliner, instance *core.Work
 	p.Set(ctx, key, string(b), 0)
 
 	if state.State != core.WorkflowInstanceStateActive {
-		p.SRem(ctx, instancesActive(), instanceSegment(instance))
+		p.SRem(ctx, rb.keys.instancesActive(), instanceSegment(instance))
+
+		if state.WorkflowName != "" {
+			p.SRem(ctx, rb.keys.instancesActiveByWorkflow(state.WorkflowName), instanceSegment(instance))
+		}
+
+		for _, set := range state.ActiveSets {
+			p.SRem(ctx, set, instanceSegment(instance))
+		}
 	}
 
 	// CreatedAt does not change, so skip updating the instancesByCreation() ZSET
@@ -184,7 +369,7 @@ func updateInstanceP(ctx context.Context, p redis.Pipeliner, instance *core.Work
 	return nil
 }
 
-func readInstance(ctx context.Context, rdb redis.UniversalClient, instanceKey string) (*instanceState, error) {
+func readInstance(ctx context.Context, rdb redis.Cmdable, instanceKey string) (*instanceState, error) {
 	p := rdb.Pipeline()
 
 	cmd := readInstanceP(ctx, p, instanceKey)
@@ -199,6 +384,17 @@ func readInstanceP(ctx context.Context, p redis.Pipeliner, instanceKey string) *
 	return p.Get(ctx, instanceKey)
 }
 
+// readInstancesP queues reading the instances with the given segments. The keys of instances are in different hash
+// slots in cluster mode, so instances are read one by one instead of via MGET.
+func (rb *redisBackend) readInstancesP(ctx context.Context, p redis.Pipeliner, segments []string) []*redis.StringCmd {
+	cmds := make([]*redis.StringCmd, 0, len(segments))
+	for _, segment := range segments {
+		cmds = append(cmds, readInstanceP(ctx, p, rb.keys.instanceKeyFromSegment(segment)))
+	}
+
+	return cmds
+}
+
 func readInstancePipelineCmd(cmd *redis.StringCmd) (*instanceState, error) {
 	val, err := cmd.Result()
 	if err != nil {
@@ -217,8 +413,8 @@ func readInstancePipelineCmd(cmd *redis.StringCmd) (*instanceState, error) {
 	return &state, nil
 }
 
-func readActiveInstanceExecution(ctx context.Context, rdb redis.UniversalClient, instanceID string) (*core.WorkflowInstance, error) {
-	val, err := rdb.Get(ctx, activeInstanceExecutionKey(instanceID)).Result()
+func (rb *redisBackend) readActiveInstanceExecution(ctx context.Context, rdb redis.Cmdable, instanceID string) (*core.WorkflowInstance, error) {
+	val, err := rdb.Get(ctx, rb.keys.activeInstanceExecutionKey(instanceID)).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, nil
@@ -235,8 +431,8 @@ func readActiveInstanceExecution(ctx context.Context, rdb redis.UniversalClient,
 	return instance, nil
 }
 
-func setActiveInstanceExecutionP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance) error {
-	key := activeInstanceExecutionKey(instance.InstanceID)
+func (rb *redisBackend) setActiveInstanceExecutionP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance) error {
+	key := rb.keys.activeInstanceExecutionKey(instance.InstanceID)
 
 	b, err := json.Marshal(instance)
 	if err != nil {
@@ -246,8 +442,8 @@ func setActiveInstanceExecutionP(ctx context.Context, p redis.Pipeliner, instanc
 	return p.Set(ctx, key, string(b), 0).Err()
 }
 
-func removeActiveInstanceExecutionP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance) error {
-	key := activeInstanceExecutionKey(instance.InstanceID)
+func (rb *redisBackend) removeActiveInstanceExecutionP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance) error {
+	key := rb.keys.activeInstanceExecutionKey(instance.InstanceID)
 
 	return p.Del(ctx, key).Err()
 }
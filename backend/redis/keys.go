@@ -2,57 +2,242 @@ package redis
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/cschleiden/go-workflows/backend"
 	"github.com/cschleiden/go-workflows/internal/core"
 )
 
+// keys builds the redis keys for a namespace. Keys of the default namespace are not prefixed, to stay compatible
+// with data written before namespaces were introduced.
+//
+// In cluster mode, keys are hash tagged so that Redis Cluster distributes instances across hash slots: the keys of an
+// instance use the instance ID as hash tag, all executions of an instance share a slot. The task queues, indexes, and
+// future events of the namespace use the namespace as hash tag.
+type keys struct {
+	// prefix is the prefix of the keys of the namespace
+	prefix string
+
+	// instancePrefix is the prefix of the keys of instances
+	instancePrefix string
+
+	clusterMode bool
+}
+
+func newKeys(namespace string, clusterMode bool) keys {
+	if namespace == "" {
+		namespace = backend.DefaultNamespace
+	}
+
+	if clusterMode {
+		return keys{
+			prefix:         fmt.Sprintf("{%v}:", namespace),
+			instancePrefix: fmt.Sprintf("%v:", namespace),
+			clusterMode:    true,
+		}
+	}
+
+	if namespace == backend.DefaultNamespace {
+		return keys{}
+	}
+
+	prefix := fmt.Sprintf("%v:", namespace)
+	return keys{prefix: prefix, instancePrefix: prefix}
+}
+
+// namespaceTag returns the hash tag of the keys of the namespace
+func (k keys) namespaceTag() string {
+	return hashTag(k.prefix)
+}
+
+// instanceID returns the given instance ID as used in the keys of the instance. In cluster mode, it's the hash tag
+// of the keys.
+func (k keys) instanceID(instanceID string) string {
+	if k.clusterMode {
+		return "{" + instanceID + "}"
+	}
+
+	return instanceID
+}
+
+// segment returns the given instance segment as used in the keys of the instance
+func (k keys) segment(segment string) string {
+	if !k.clusterMode {
+		return segment
+	}
+
+	// Execution IDs don't contain colons, instance IDs might
+	i := strings.LastIndex(segment, ":")
+	if i < 0 {
+		return k.instanceID(segment)
+	}
+
+	return k.instanceID(segment[:i]) + segment[i:]
+}
+
 // activeInstanceExecutionKey returns the key for the latest execution of the given instance
-func activeInstanceExecutionKey(instanceID string) string {
-	return fmt.Sprintf("active-instance-execution:%v", instanceID)
+func (k keys) activeInstanceExecutionKey(instanceID string) string {
+	return fmt.Sprintf("%vactive-instance-execution:%v", k.instancePrefix, k.instanceID(instanceID))
+}
+
+// latestInstanceExecutionKey returns the key for the segment of the most recently created execution of the given
+// instance, active or not
+func (k keys) latestInstanceExecutionKey(instanceID string) string {
+	return fmt.Sprintf("%vlatest-instance-execution:%v", k.instancePrefix, k.instanceID(instanceID))
 }
 
 func instanceSegment(instance *core.WorkflowInstance) string {
 	return fmt.Sprintf("%v:%v", instance.InstanceID, instance.ExecutionID)
 }
 
-func instanceKey(instance *core.WorkflowInstance) string {
-	return instanceKeyFromSegment(instanceSegment(instance))
+func (k keys) instanceKey(instance *core.WorkflowInstance) string {
+	return k.instanceKeyFromSegment(instanceSegment(instance))
 }
 
-func instanceKeyFromSegment(segment string) string {
-	return fmt.Sprintf("instance:%v", segment)
+func (k keys) instanceKeyFromSegment(segment string) string {
+	return fmt.Sprintf("%vinstance:%v", k.instancePrefix, k.segment(segment))
 }
 
 // instancesByCreation returns the key for the ZSET that contains all instances sorted by creation date. The score is the
 // creation time. Used for listing all workflow instances in the diagnostics UI.
-func instancesByCreation() string {
-	return "instances-by-creation"
+func (k keys) instancesByCreation() string {
+	return k.prefix + "instances-by-creation"
+}
+
+func (k keys) instancesActive() string {
+	return k.prefix + "instances-active"
+}
+
+// pausedInstancesKey returns the key for the SET of paused instances
+func (k keys) pausedInstancesKey() string {
+	return k.prefix + "instances-paused"
+}
+
+// instancesCanceled returns the key for the SET of instances that have been requested to cancel
+func (k keys) instancesCanceled() string {
+	return k.prefix + "instances-canceled"
+}
+
+// instanceBuildIDsKey returns the key for the HASH of the build IDs instances are pinned to
+func (k keys) instanceBuildIDsKey() string {
+	return k.prefix + "instances-build-id"
+}
+
+// deadLetteredInstancesKey returns the key for the HASH of the dead-letter reasons of dead-lettered instances
+func (k keys) deadLetteredInstancesKey() string {
+	return k.prefix + "instances-dead-lettered"
+}
+
+// instanceTaskAttemptsKey returns the key for the HASH of the number of workflow task attempts of instances since
+// their last completed task
+func (k keys) instanceTaskAttemptsKey() string {
+	return k.prefix + "instances-task-attempts"
 }
 
-func instancesActive() string {
-	return "instances-active"
+// instancesActiveByWorkflow returns the key for the SET of active instances of the given workflow
+func (k keys) instancesActiveByWorkflow(workflowName string) string {
+	return fmt.Sprintf("%vinstances-active-by-workflow:%v", k.prefix, workflowName)
 }
 
-func instancesExpiring() string {
-	return "instances-expiring"
+// instancesActiveBySearchAttribute returns the key for the SET of active instances created with the given string
+// search attribute value, used to count the active instances of tenants
+func (k keys) instancesActiveBySearchAttribute(name, value string) string {
+	return fmt.Sprintf("%vinstances-active-by-search-attribute:%v:%v", k.prefix, name, value)
 }
 
-func pendingEventsKey(instance *core.WorkflowInstance) string {
-	return fmt.Sprintf("pending-events:%v", instanceSegment(instance))
+func (k keys) instancesExpiring() string {
+	return k.prefix + "instances-expiring"
 }
 
-func historyKey(instance *core.WorkflowInstance) string {
-	return fmt.Sprintf("history:%v", instanceSegment(instance))
+// pendingEventsKeyPrefix returns the prefix of all pending events keys, used in scripts to build pending events keys.
+// Not used in cluster mode, where the keys need to be declared.
+func (k keys) pendingEventsKeyPrefix() string {
+	return k.instancePrefix + "pending-events:"
+}
+
+func (k keys) pendingEventsKey(instance *core.WorkflowInstance) string {
+	return k.pendingEventsKeyFromSegment(instanceSegment(instance))
+}
+
+func (k keys) pendingEventsKeyFromSegment(segment string) string {
+	return k.pendingEventsKeyPrefix() + k.segment(segment)
+}
+
+// pendingActivitiesKey returns the key for the HASH of activity tasks of the given instance waiting to be completed
+// asynchronously
+func (k keys) pendingActivitiesKey(instance *core.WorkflowInstance) string {
+	return fmt.Sprintf("%vpending-activities:%v", k.instancePrefix, k.segment(instanceSegment(instance)))
+}
+
+// activityHeartbeatsKey returns the key for the HASH of the last heartbeats recorded by executing activity tasks
+func (k keys) activityHeartbeatsKey() string {
+	return k.prefix + "activity-heartbeats"
+}
+
+func (k keys) historyKey(instance *core.WorkflowInstance) string {
+	return fmt.Sprintf("%vhistory:%v", k.instancePrefix, k.segment(instanceSegment(instance)))
 }
 
 func historyID(sequenceID int64) string {
 	return fmt.Sprintf("%v-0", sequenceID)
 }
 
-func futureEventsKey() string {
-	return "future-events"
+func (k keys) futureEventsKey() string {
+	return k.prefix + "future-events"
+}
+
+func (k keys) futureEventKey(instance *core.WorkflowInstance, scheduleEventID int64) string {
+	return fmt.Sprintf("%vfuture-event:%v:%v:%v", k.prefix, instance.InstanceID, instance.ExecutionID, scheduleEventID)
+}
+
+// futureStartKey returns the key of the future event queueing the first workflow task of a delayed instance
+func (k keys) futureStartKey(instance *core.WorkflowInstance) string {
+	return fmt.Sprintf("%vfuture-start:%v:%v", k.prefix, instance.InstanceID, instance.ExecutionID)
+}
+
+func (k keys) futureSignalKey(instance *core.WorkflowInstance, eventID string) string {
+	return fmt.Sprintf("%vfuture-signal:%v:%v:%v", k.prefix, instance.InstanceID, instance.ExecutionID, eventID)
 }
 
-func futureEventKey(instance *core.WorkflowInstance, scheduleEventID int64) string {
-	return fmt.Sprintf("future-event:%v:%v:%v", instance.InstanceID, instance.ExecutionID, scheduleEventID)
+func (k keys) rateLimitKey(name string) string {
+	return fmt.Sprintf("%vrate-limit:%v", k.prefix, name)
+}
+
+func (k keys) leaseKey(name string) string {
+	return fmt.Sprintf("%vlease:%v", k.prefix, name)
+}
+
+func (k keys) signalDeduplicationKey(instanceID, hash string) string {
+	return fmt.Sprintf("%vsignal-deduplication:%v:%v", k.instancePrefix, k.instanceID(instanceID), hash)
+}
+
+func (k keys) pendingQueriesKey() string {
+	return fmt.Sprintf("%vqueries-pending", k.prefix)
+}
+
+func (k keys) queryKey(queryID string) string {
+	return fmt.Sprintf("%vquery:%v", k.prefix, queryID)
+}
+
+func (k keys) queryResultKey(queryID string) string {
+	return fmt.Sprintf("%vquery-result:%v", k.prefix, queryID)
+}
+
+func (k keys) schedulesKey() string {
+	return fmt.Sprintf("%vschedules", k.prefix)
+}
+
+// hashTag returns the part of the key Redis Cluster uses to determine the hash slot
+func hashTag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+
+	return key[start+1 : start+1+end]
 }
@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Keys_ClusterMode(t *testing.T) {
+	instance := core.NewWorkflowInstance("instanceID", "executionID")
+
+	tests := []struct {
+		name        string
+		namespace   string
+		clusterMode bool
+		want        string
+	}{
+		{name: "default namespace", namespace: "default", want: "instance:instanceID:executionID"},
+		{name: "namespace", namespace: "tenant", want: "tenant:instance:instanceID:executionID"},
+		{name: "cluster mode default namespace", namespace: "default", clusterMode: true, want: "default:instance:{instanceID}:executionID"},
+		{name: "cluster mode namespace", namespace: "tenant", clusterMode: true, want: "tenant:instance:{instanceID}:executionID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newKeys(tt.namespace, tt.clusterMode)
+			require.Equal(t, tt.want, k.instanceKey(instance))
+		})
+	}
+}
+
+func Test_Keys_ClusterMode_HashTags(t *testing.T) {
+	k := newKeys("default", true)
+
+	for _, instanceID := range []string{"instanceID", "instance:ID", "instance{ID}"} {
+		instance := core.NewWorkflowInstance(instanceID, "executionID")
+		other := core.NewWorkflowInstance(instanceID, "otherExecutionID")
+
+		// All executions of an instance share the hash slot of the instance, instance IDs containing braces or colons
+		// must not change it
+		tag := hashTag(k.instanceKey(instance))
+		for _, key := range []string{
+			k.instanceKeyFromSegment(instanceSegment(instance)),
+			k.activeInstanceExecutionKey(instance.InstanceID),
+			k.latestInstanceExecutionKey(instance.InstanceID),
+			k.pendingEventsKey(instance),
+			k.pendingEventsKeyFromSegment(instanceSegment(instance)),
+			k.pendingActivitiesKey(instance),
+			k.historyKey(instance),
+			k.signalDeduplicationKey(instance.InstanceID, "hash"),
+			k.instanceKey(other),
+			k.historyKey(other),
+		} {
+			require.Equal(t, tag, hashTag(key), key)
+		}
+
+		require.NotEqual(t, k.namespaceTag(), tag)
+
+		// Keys of the namespace share the namespace's hash slot
+		for _, key := range []string{
+			k.futureEventKey(instance, 1),
+			k.futureStartKey(instance),
+			k.instancesByCreation(),
+			k.futureEventsKey(),
+			k.deadLetteredInstancesKey(),
+			"task-set:" + k.prefix + "workflows",
+		} {
+			require.Equal(t, "default", hashTag(key), key)
+		}
+	}
+}
+
+func Test_Keys_WithoutClusterMode_NoHashTags(t *testing.T) {
+	k := newKeys("tenant", false)
+	instance := core.NewWorkflowInstance("instanceID", "executionID")
+
+	require.Equal(t, "tenant:pending-events:instanceID:executionID", k.pendingEventsKey(instance))
+	require.Equal(t, "tenant:active-instance-execution:instanceID", k.activeInstanceExecutionKey(instance.InstanceID))
+	require.Equal(t, "tenant:future-events", k.futureEventsKey())
+}
@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ backend.Leaser = (*redisBackend)(nil)
+
+// Acquire or extend a lease, if it's not held by a different holder
+//
+// KEYS[1] - lease key
+// ARGV[1] - holder
+// ARGV[2] - lease duration in milliseconds
+var acquireLeaseCmd = redis.NewScript(`
+	local holder = redis.call("GET", KEYS[1])
+	if holder and holder ~= ARGV[1] then
+		return 0
+	end
+
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+`)
+
+// Release a lease, if it's held by the given holder
+//
+// KEYS[1] - lease key
+// ARGV[1] - holder
+var releaseLeaseCmd = redis.NewScript(`
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[1])
+	end
+
+	return 0
+`)
+
+func (rb *redisBackend) AcquireLease(ctx context.Context, name, holder string, duration time.Duration) (bool, error) {
+	acquired, err := acquireLeaseCmd.Run(ctx, rb.rdb, []string{rb.keys.leaseKey(name)}, holder, duration.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	return acquired == 1, nil
+}
+
+func (rb *redisBackend) ReleaseLease(ctx context.Context, name, holder string) error {
+	return releaseLeaseCmd.Run(ctx, rb.rdb, []string{rb.keys.leaseKey(name)}, holder).Err()
+}
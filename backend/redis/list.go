@@ -0,0 +1,227 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// listChunkSize is the number of instances read from the creation index at once while filtering
+const listChunkSize = 100
+
+func (rb *redisBackend) ListWorkflowInstances(ctx context.Context, query *backend.ListWorkflowInstancesQuery) (*backend.ListWorkflowInstancesResult, error) {
+	after, err := backend.DecodePageToken(query.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Instances are read from the creation index, newest first
+	var start int64
+
+	if after != nil {
+		rank, err := rb.rdb.ZRevRank(ctx, rb.keys.instancesByCreation(), instanceSegment(after)).Result()
+		if err != nil {
+			if err == redis.Nil {
+				// Instance of the page token has been removed in the meantime
+				return nil, backend.ErrInvalidPageToken
+			}
+
+			return nil, fmt.Errorf("getting rank of %v: %w", after, err)
+		}
+
+		start = rank + 1
+	}
+
+	if !query.CreatedBefore.IsZero() {
+		newer, err := rb.rdb.ZCount(
+			ctx, rb.keys.instancesByCreation(), strconv.FormatInt(query.CreatedBefore.UnixMilli(), 10), "+inf").Result()
+		if err != nil {
+			return nil, fmt.Errorf("counting instances created before %v: %w", query.CreatedBefore, err)
+		}
+
+		if newer > start {
+			start = newer
+		}
+	}
+
+	var createdAfter int64
+	if !query.CreatedAfter.IsZero() {
+		createdAfter = query.CreatedAfter.UnixMilli()
+	}
+
+	// Collect one more instance than requested to determine whether there is another page
+	pageSize := query.EffectivePageSize()
+	result := &backend.ListWorkflowInstancesResult{}
+
+	for len(result.Instances) <= pageSize {
+		entries, err := rb.rdb.ZRangeArgsWithScores(ctx, redis.ZRangeArgs{
+			Key:   rb.keys.instancesByCreation(),
+			Start: start,
+			Stop:  start + listChunkSize - 1,
+			Rev:   true,
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("reading instances: %w", err)
+		}
+
+		segments := make([]string, 0, len(entries))
+		for _, e := range entries {
+			if createdAfter != 0 && int64(e.Score) < createdAfter {
+				break
+			}
+
+			segments = append(segments, e.Member.(string))
+		}
+
+		instances, err := rb.listInstances(ctx, segments, query)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Instances = append(result.Instances, instances...)
+
+		if len(segments) < listChunkSize {
+			// Reached the end of the index or the end of the created-at range
+			break
+		}
+
+		start += listChunkSize
+	}
+
+	if len(result.Instances) > pageSize {
+		result.Instances = result.Instances[:pageSize]
+		result.NextPageToken = backend.EncodePageToken(result.Instances[pageSize-1].Instance)
+	}
+
+	return result, nil
+}
+
+// listInstances reads the instances for the given segments and returns the ones matching the state and search
+// attribute filters of the query
+func (rb *redisBackend) listInstances(ctx context.Context, segments []string, query *backend.ListWorkflowInstancesQuery) ([]*backend.WorkflowInstanceInfo, error) {
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	p := rb.rdb.Pipeline()
+
+	instanceCmds := rb.readInstancesP(ctx, p, segments)
+	deadLetterCmd := p.HMGet(ctx, rb.keys.deadLetteredInstancesKey(), segments...)
+
+	var canceledCmd *redis.BoolSliceCmd
+	if query.State == backend.InstanceStateCanceled {
+		members := make([]interface{}, 0, len(segments))
+		for _, segment := range segments {
+			members = append(members, segment)
+		}
+
+		canceledCmd = p.SMIsMember(ctx, rb.keys.instancesCanceled(), members...)
+	}
+
+	// Missing instances fail their cmd with redis.Nil, errors are checked when checking the cmds
+	_, _ = p.Exec(ctx)
+
+	if err := deadLetterCmd.Err(); err != nil {
+		return nil, fmt.Errorf("reading dead-letter reasons: %w", err)
+	}
+
+	if canceledCmd != nil {
+		if err := canceledCmd.Err(); err != nil {
+			return nil, fmt.Errorf("reading canceled instances: %w", err)
+		}
+	}
+
+	var canceled []bool
+	if canceledCmd != nil {
+		canceled = canceledCmd.Val()
+	}
+
+	deadLetterReasons := deadLetterCmd.Val()
+
+	var r []*backend.WorkflowInstanceInfo
+	for i, cmd := range instanceCmds {
+		state, err := readInstancePipelineCmd(cmd)
+		if err != nil {
+			// Instance might have expired or been removed since reading the index
+			if err == backend.ErrInstanceNotFound {
+				continue
+			}
+
+			return nil, fmt.Errorf("reading instances: %w", err)
+		}
+
+		switch query.State {
+		case backend.InstanceStateActive:
+			if state.CompletedAt != nil {
+				continue
+			}
+		case backend.InstanceStateFinished:
+			if state.CompletedAt == nil {
+				continue
+			}
+		case backend.InstanceStateCanceled:
+			if !canceled[i] {
+				continue
+			}
+		case backend.InstanceStateDeadLettered:
+			if deadLetterReasons[i] == nil {
+				continue
+			}
+		}
+
+		if !state.SearchAttributes.Matches(query.SearchAttributes) {
+			continue
+		}
+
+		info := &backend.WorkflowInstanceInfo{
+			Instance:     state.Instance,
+			WorkflowName: state.WorkflowName,
+			State:        state.State,
+			CreatedAt:    state.CreatedAt,
+			CompletedAt:  state.CompletedAt,
+			Memo:         state.Memo,
+		}
+
+		if reason, ok := deadLetterReasons[i].(string); ok {
+			info.DeadLetterReason = reason
+		}
+
+		r = append(r, info)
+	}
+
+	return r, nil
+}
+
+func (rb *redisBackend) GetWorkflowInstanceInfo(ctx context.Context, instance *core.WorkflowInstance) (*backend.WorkflowInstanceInfo, error) {
+	p := rb.rdb.Pipeline()
+
+	instanceCmd := readInstanceP(ctx, p, rb.keys.instanceKey(instance))
+	deadLetterCmd := p.HGet(ctx, rb.keys.deadLetteredInstancesKey(), instanceSegment(instance))
+
+	// Errors are checked when checking the cmds
+	_, _ = p.Exec(ctx)
+
+	state, err := readInstancePipelineCmd(instanceCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	reason, err := deadLetterCmd.Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("reading dead-letter reason: %w", err)
+	}
+
+	return &backend.WorkflowInstanceInfo{
+		Instance:         state.Instance,
+		WorkflowName:     state.WorkflowName,
+		State:            state.State,
+		CreatedAt:        state.CreatedAt,
+		CompletedAt:      state.CompletedAt,
+		DeadLetterReason: reason,
+		Memo:             state.Memo,
+	}, nil
+}
@@ -0,0 +1,21 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.LoadReporter = (*redisBackend)(nil)
+
+// Load reports the latency of a round-trip to Redis
+func (rb *redisBackend) Load(ctx context.Context) (float64, error) {
+	start := time.Now()
+	if err := rb.rdb.Ping(ctx).Err(); err != nil {
+		return 1, fmt.Errorf("pinging redis: %w", err)
+	}
+
+	return backend.LatencyLoad(time.Since(start), rb.options.OverloadedLatency), nil
+}
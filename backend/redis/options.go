@@ -12,6 +12,8 @@ type RedisOptions struct {
 	BlockTimeout time.Duration
 
 	AutoExpiration time.Duration
+
+	ClusterMode bool
 }
 
 type RedisBackendOption func(*RedisOptions)
@@ -37,3 +39,12 @@ func WithAutoExpiration(expireFinishedRunsAfter time.Duration) RedisBackendOptio
 		o.AutoExpiration = expireFinishedRunsAfter
 	}
 }
+
+// WithClusterMode enables support for Redis Cluster. The keys of instances are distributed across hash slots by
+// instance ID, the task queues and indexes of the backend's namespace share a hash slot. Enabling it changes the keys,
+// data written without cluster mode is not visible to the backend.
+func WithClusterMode() RedisBackendOption {
+	return func(o *RedisOptions) {
+		o.ClusterMode = true
+	}
+}
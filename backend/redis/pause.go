@@ -0,0 +1,129 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/redis/go-redis/v9"
+)
+
+func (rb *redisBackend) PauseWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance, event *history.Event) error {
+	// Mark the instance as paused and record the event in a single transaction. The set of paused instances is a
+	// namespace key and can't be watched in cluster mode, watching the pending events instead makes concurrent pauses
+	// and resumes retry, so that every change of the paused state records exactly one event.
+	return rb.watch(ctx, func(tx *watchedTx) error {
+		if _, err := readInstance(ctx, tx, rb.keys.instanceKey(instance)); err != nil {
+			return err
+		}
+
+		paused, err := rb.rdb.SIsMember(ctx, rb.keys.pausedInstancesKey(), instanceSegment(instance)).Result()
+		if err != nil {
+			return fmt.Errorf("checking if instance is paused: %w", err)
+		}
+
+		if paused {
+			return nil
+		}
+
+		// Record the event without queueing a workflow task, it's picked up once the instance is resumed
+		if _, err := tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if err := addEventToStreamP(ctx, p, rb.keys.pendingEventsKey(instance), event); err != nil {
+				return err
+			}
+
+			p.SAdd(ctx, rb.keys.pausedInstancesKey(), instanceSegment(instance))
+
+			return nil
+		}); err != nil {
+			return fmt.Errorf("pausing workflow instance: %w", err)
+		}
+
+		return nil
+	}, rb.keys.instanceKey(instance), rb.keys.pendingEventsKey(instance))
+}
+
+func (rb *redisBackend) ResumeWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance, event *history.Event) error {
+	return rb.watch(ctx, func(tx *watchedTx) error {
+		instanceState, err := readInstance(ctx, tx, rb.keys.instanceKey(instance))
+		if err != nil {
+			return err
+		}
+
+		paused, err := rb.rdb.SIsMember(ctx, rb.keys.pausedInstancesKey(), instanceSegment(instance)).Result()
+		if err != nil {
+			return fmt.Errorf("checking if instance is paused: %w", err)
+		}
+
+		if !paused {
+			return nil
+		}
+
+		if _, err := tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.SRem(ctx, rb.keys.pausedInstancesKey(), instanceSegment(instance))
+
+			return rb.addWorkflowInstanceEventP(ctx, p, instance, instanceState.route(), event)
+		}); err != nil {
+			return fmt.Errorf("resuming workflow instance: %w", err)
+		}
+
+		return nil
+	}, rb.keys.instanceKey(instance), rb.keys.pendingEventsKey(instance))
+}
+
+// dropPausedTask completes the given workflow task if its instance is paused. Resuming the instance queues a new
+// task. Returns true if the task was dropped.
+func (rb *redisBackend) dropPausedTask(ctx context.Context, taskID string, instanceState *instanceState) (bool, error) {
+	segment := instanceSegment(instanceState.Instance)
+
+	paused, err := rb.rdb.SIsMember(ctx, rb.keys.pausedInstancesKey(), segment).Result()
+	if err != nil {
+		return false, fmt.Errorf("checking if instance is paused: %w", err)
+	}
+
+	if !paused {
+		return false, nil
+	}
+
+	if _, err := rb.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		_, err := rb.workflowQueue.Complete(ctx, p, taskID)
+		return err
+	}); err != nil {
+		return false, fmt.Errorf("dropping task of paused instance: %w", err)
+	}
+
+	// The instance might have been resumed while the task was dropped, make sure it's queued again in that case
+	paused, err = rb.rdb.SIsMember(ctx, rb.keys.pausedInstancesKey(), segment).Result()
+	if err != nil {
+		return true, fmt.Errorf("checking if instance is paused: %w", err)
+	}
+
+	if !paused {
+		if _, err := rb.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+			return rb.workflowQueue.Enqueue(ctx, p, instanceState.route(), segment, nil)
+		}); err != nil {
+			return true, fmt.Errorf("queueing resumed instance: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+// dropDelayedTask completes the given workflow task if its instance has a delayed start that isn't due yet. This
+// happens when events like signals are added before the instance has started, the task for the start queues the
+// instance again. Returns true if the task was dropped.
+func (rb *redisBackend) dropDelayedTask(ctx context.Context, taskID string, instanceState *instanceState) (bool, error) {
+	if instanceState.StartAt == nil || instanceState.LastSequenceID > 0 || !rb.options.Clock.Now().Before(*instanceState.StartAt) {
+		return false, nil
+	}
+
+	if _, err := rb.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		_, err := rb.workflowQueue.Complete(ctx, p, taskID)
+		return err
+	}); err != nil {
+		return false, fmt.Errorf("dropping task of delayed instance: %w", err)
+	}
+
+	return true, nil
+}
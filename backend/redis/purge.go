@@ -0,0 +1,98 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.InstancePurger = (*redisBackend)(nil)
+
+// purgeScanCount is the number of instances read from the creation index per scan
+const purgeScanCount = 500
+
+func (rb *redisBackend) RemoveFinishedWorkflowInstances(ctx context.Context, finishedBefore time.Time, workflowName string) (int, error) {
+	removed := 0
+
+	var cursor uint64
+	for {
+		// Members of the index are returned alternating with their scores
+		entries, next, err := rb.rdb.ZScan(ctx, rb.keys.instancesByCreation(), cursor, "", purgeScanCount).Result()
+		if err != nil {
+			return removed, fmt.Errorf("scanning instances: %w", err)
+		}
+
+		segments := make([]string, 0, len(entries)/2)
+		for i := 0; i < len(entries); i += 2 {
+			segments = append(segments, entries[i])
+		}
+
+		n, err := rb.removeFinishedInstances(ctx, segments, finishedBefore, workflowName)
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return removed, nil
+		}
+	}
+}
+
+// removeFinishedInstances removes the instances with the given segments that finished before the given time
+func (rb *redisBackend) removeFinishedInstances(ctx context.Context, segments []string, finishedBefore time.Time, workflowName string) (int, error) {
+	if len(segments) == 0 {
+		return 0, nil
+	}
+
+	rp := rb.rdb.Pipeline()
+	instanceCmds := rb.readInstancesP(ctx, rp, segments)
+
+	// Errors are checked when checking the cmds
+	_, _ = rp.Exec(ctx)
+
+	p := rb.rdb.Pipeline()
+	removed := 0
+
+	for _, cmd := range instanceCmds {
+		state, err := readInstancePipelineCmd(cmd)
+		if err != nil {
+			// Instance might have expired or been removed since scanning the index
+			if err == backend.ErrInstanceNotFound {
+				continue
+			}
+
+			return 0, fmt.Errorf("reading instances: %w", err)
+		}
+
+		if state.CompletedAt == nil || !state.CompletedAt.Before(finishedBefore) {
+			continue
+		}
+
+		if workflowName != "" && state.WorkflowName != workflowName {
+			continue
+		}
+
+		// Instances are kept until their history has been archived
+		if rb.options.Archiver != nil && !state.Archived {
+			continue
+		}
+
+		rb.deleteInstanceP(ctx, p, state.Instance)
+
+		removed++
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+
+	if _, err := p.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("removing finished instances: %w", err)
+	}
+
+	return removed, nil
+}
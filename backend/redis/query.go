@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ backend.Querier = (*redisBackend)(nil)
+
+// Queries are stored in keys expiring at their deadline, the pending queries list only holds their IDs. IDs of
+// expired queries are skipped when reading the list.
+func (rb *redisBackend) QueueQuery(ctx context.Context, q *backend.Query) error {
+	instance, err := rb.readActiveInstanceExecution(ctx, rb.rdb, q.InstanceID)
+	if err != nil {
+		return fmt.Errorf("reading active instance execution: %w", err)
+	}
+
+	if instance == nil {
+		return backend.ErrInstanceNotFound
+	}
+
+	t, err := json.Marshal(&backend.QueryTask{Query: *q, Instance: instance})
+	if err != nil {
+		return fmt.Errorf("marshaling query: %w", err)
+	}
+
+	if _, err := rb.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, rb.keys.queryKey(q.ID), string(t), 0)
+		p.ExpireAt(ctx, rb.keys.queryKey(q.ID), q.Deadline)
+		p.RPush(ctx, rb.keys.pendingQueriesKey(), q.ID)
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("queueing query: %w", err)
+	}
+
+	return nil
+}
+
+func (rb *redisBackend) GetQueryTask(ctx context.Context) (*backend.QueryTask, error) {
+	for {
+		queryID, err := rb.rdb.LPop(ctx, rb.keys.pendingQueriesKey()).Result()
+		if err != nil {
+			if err == redis.Nil {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("reading pending query: %w", err)
+		}
+
+		val, err := rb.rdb.Get(ctx, rb.keys.queryKey(queryID)).Result()
+		if err != nil {
+			if err == redis.Nil {
+				// Query expired before it was picked up
+				continue
+			}
+
+			return nil, fmt.Errorf("reading query: %w", err)
+		}
+
+		var t *backend.QueryTask
+		if err := json.Unmarshal([]byte(val), &t); err != nil {
+			return nil, fmt.Errorf("unmarshaling query: %w", err)
+		}
+
+		return t, nil
+	}
+}
+
+func (rb *redisBackend) CompleteQueryTask(ctx context.Context, queryID string, result *backend.QueryResult) error {
+	val, err := rb.rdb.Get(ctx, rb.keys.queryKey(queryID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			// Query expired, nobody is waiting for the result anymore
+			return nil
+		}
+
+		return fmt.Errorf("reading query: %w", err)
+	}
+
+	var t *backend.QueryTask
+	if err := json.Unmarshal([]byte(val), &t); err != nil {
+		return fmt.Errorf("unmarshaling query: %w", err)
+	}
+
+	r, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshaling query result: %w", err)
+	}
+
+	if _, err := rb.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, rb.keys.queryResultKey(queryID), string(r), 0)
+		p.ExpireAt(ctx, rb.keys.queryResultKey(queryID), t.Deadline)
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("storing query result: %w", err)
+	}
+
+	return nil
+}
+
+func (rb *redisBackend) GetQueryResult(ctx context.Context, queryID string) (*backend.QueryResult, error) {
+	val, err := rb.rdb.Get(ctx, rb.keys.queryResultKey(queryID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("reading query result: %w", err)
+	}
+
+	result := &backend.QueryResult{}
+	if err := json.Unmarshal([]byte(val), result); err != nil {
+		return nil, fmt.Errorf("unmarshaling query result: %w", err)
+	}
+
+	if err := rb.rdb.Del(ctx, rb.keys.queryKey(queryID), rb.keys.queryResultKey(queryID)).Err(); err != nil {
+		return nil, fmt.Errorf("removing query: %w", err)
+	}
+
+	return result, nil
+}
@@ -4,18 +4,33 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/redis/go-redis/v9"
 )
 
+// taskQueue is a queue of tasks with a stream per named queue and priority. All streams share a single set to prevent
+// duplicate tasks regardless of their queue and priority.
 type taskQueue[T any] struct {
 	tasktype   string
 	setKey     string
-	streamKey  string
+	queuesKey  string
 	groupName  string
 	workerName string
+	order      *backend.PriorityOrder
+
+	// groups are the named queues the consumer groups have been created for
+	groups sync.Map
+}
+
+// route identifies the stream of a task by the named queue it's routed to and its priority
+type route struct {
+	queue    core.Queue
+	priority core.Priority
 }
 
 type TaskItem[T any] struct {
@@ -27,6 +42,13 @@ type TaskItem[T any] struct {
 
 	// Optional data stored with a task, needs to be serializable
 	Data T
+
+	// Recovered is true if the task was abandoned by another worker and has been recovered
+	Recovered bool
+
+	// Queue and Priority of the stream the task was read from
+	Queue    core.Queue
+	Priority core.Priority
 }
 
 type KeyInfo struct {
@@ -34,13 +56,14 @@ type KeyInfo struct {
 	SetKey    string
 }
 
-func newTaskQueue[T any](rdb redis.UniversalClient, tasktype string) (*taskQueue[T], error) {
+func newTaskQueue[T any](rdb redis.UniversalClient, tasktype string, order *backend.PriorityOrder, workerName string) (*taskQueue[T], error) {
 	tq := &taskQueue[T]{
 		tasktype:   tasktype,
 		setKey:     "task-set:" + tasktype,
-		streamKey:  "task-stream:" + tasktype,
+		queuesKey:  "task-queues:" + tasktype,
 		groupName:  "task-workers",
-		workerName: uuid.NewString(),
+		workerName: workerName,
+		order:      order,
 	}
 
 	// Pre-load script
@@ -58,24 +81,161 @@ func newTaskQueue[T any](rdb redis.UniversalClient, tasktype string) (*taskQueue
 		}
 	}
 
-	// Create the consumer group
-	err := createGroupCmd.Run(context.Background(), rdb, []string{tq.streamKey, tq.groupName}).Err()
-	if err != nil {
+	// Create the consumer groups of the default queue
+	if err := tq.createGroups(context.Background(), rdb, core.QueueDefault); err != nil {
 		return nil, fmt.Errorf("creating task queue: %w", err)
 	}
 
 	return tq, nil
 }
 
-func (q *taskQueue[T]) Keys() KeyInfo {
+// createGroups creates the consumer groups for the streams of the given named queue, if they haven't been created by
+// this queue before
+func (q *taskQueue[T]) createGroups(ctx context.Context, rdb redis.UniversalClient, queue core.Queue) error {
+	if _, ok := q.groups.Load(queue); ok {
+		return nil
+	}
+
+	for _, priority := range core.Priorities {
+		streamKey := q.streamKey(route{queue: queue, priority: priority})
+		if err := createGroupCmd.Run(ctx, rdb, []string{streamKey}, q.groupName).Err(); err != nil {
+			return err
+		}
+	}
+
+	q.groups.Store(queue, struct{}{})
+
+	return nil
+}
+
+// Keys returns the keys of the stream for the given route and the set of the queue
+func (q *taskQueue[T]) Keys(r route) KeyInfo {
 	return KeyInfo{
-		StreamKey: q.streamKey,
+		StreamKey: q.streamKey(r),
 		SetKey:    q.setKey,
 	}
 }
 
+// streamKey returns the key of the stream for the given route. The keys of the default queue are kept for
+// compatibility with queues created before named queues, and the key of its normal priority stream for
+// compatibility with queues created before priorities.
+func (q *taskQueue[T]) streamKey(r route) string {
+	key := "task-stream:" + q.tasktype
+
+	if queue := r.queue.OrDefault(); queue != core.QueueDefault {
+		key += ":queue:" + string(queue)
+	}
+
+	switch r.priority {
+	case core.PriorityHigh:
+		key += ":high"
+	case core.PriorityLow:
+		key += ":low"
+	}
+
+	return key
+}
+
+// taskID returns the ID of a task in the given route's stream. The IDs of tasks of the default queue with normal
+// priority are the stream message IDs, other priorities and queues are appended to the message ID.
+func taskID(r route, msgID string) string {
+	id := msgID
+
+	if r.priority != core.PriorityNormal {
+		id += "@" + r.priority.String()
+	}
+
+	if queue := r.queue.OrDefault(); queue != core.QueueDefault {
+		id += "#" + string(queue)
+	}
+
+	return id
+}
+
+// parseTaskID returns the route and stream message ID of the given task ID
+func parseTaskID(taskID string) (route, string) {
+	r := route{queue: core.QueueDefault, priority: core.PriorityNormal}
+
+	taskID, queue, found := strings.Cut(taskID, "#")
+	if found {
+		r.queue = core.Queue(queue)
+	}
+
+	msgID, priority, found := strings.Cut(taskID, "@")
+	if !found {
+		return r, taskID
+	}
+
+	for _, p := range core.Priorities {
+		if p.String() == priority {
+			r.priority = p
+		}
+	}
+
+	return r, msgID
+}
+
+// queues returns the named queues tasks have been enqueued to, including the default queue
+func (q *taskQueue[T]) queues(ctx context.Context, rdb redis.UniversalClient) ([]core.Queue, error) {
+	names, err := rdb.SMembers(ctx, q.queuesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading task queues: %w", err)
+	}
+
+	queues := []core.Queue{core.QueueDefault}
+	for _, name := range names {
+		queues = append(queues, core.Queue(name))
+	}
+
+	return queues, nil
+}
+
 func (q *taskQueue[T]) Size(ctx context.Context, rdb redis.UniversalClient) (int64, error) {
-	return rdb.XLen(ctx, q.streamKey).Result()
+	queues, err := q.queues(ctx, rdb)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, queue := range queues {
+		for _, priority := range core.Priorities {
+			l, err := rdb.XLen(ctx, q.streamKey(route{queue: queue, priority: priority})).Result()
+			if err != nil {
+				return 0, err
+			}
+
+			size += l
+		}
+	}
+
+	return size, nil
+}
+
+// Locked returns the number of tasks that have been read by a worker and not completed yet
+func (q *taskQueue[T]) Locked(ctx context.Context, rdb redis.UniversalClient) (int64, error) {
+	queues, err := q.queues(ctx, rdb)
+	if err != nil {
+		return 0, err
+	}
+
+	var locked int64
+	for _, queue := range queues {
+		// Tasks might have been enqueued to a named queue no worker has polled yet
+		if err := q.createGroups(ctx, rdb, queue); err != nil {
+			return 0, err
+		}
+
+		for _, priority := range core.Priorities {
+			pending, err := rdb.XPending(ctx, q.streamKey(route{queue: queue, priority: priority}), q.groupName).Result()
+			if err != nil {
+				return 0, err
+			}
+
+			locked += pending.Count
+		}
+	}
+
+	return locked, nil
 }
 
 // KEYS[1] = set
@@ -92,9 +252,11 @@ var enqueueCmd = redis.NewScript(
 	return true
 `)
 
+// KEYS[1] = stream
+// ARGV[1] = group
 var createGroupCmd = redis.NewScript(`
     local streamKey = KEYS[1]
-    local groupName = KEYS[2]
+    local groupName = ARGV[1]
     local exists = false
     local res = redis.pcall('XINFO', 'GROUPS', streamKey)
 
@@ -113,38 +275,90 @@ var createGroupCmd = redis.NewScript(`
     end
 
     if not exists then
-        redis.call('XGROUP', 'CREATE', streamKey, groupName, '$', 'MKSTREAM')
+        -- Start at the beginning, tasks might have been added to the stream before the group is created
+        redis.call('XGROUP', 'CREATE', streamKey, groupName, '0', 'MKSTREAM')
     end
 
     return true
 `)
 
-
-func (q *taskQueue[T]) Enqueue(ctx context.Context, p redis.Pipeliner, id string, data *T) error {
+func (q *taskQueue[T]) Enqueue(ctx context.Context, p redis.Pipeliner, r route, id string, data *T) error {
 	ds, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
 
-	enqueueCmd.Run(ctx, p, []string{q.setKey, q.streamKey}, id, string(ds))
+	if queue := r.queue.OrDefault(); queue != core.QueueDefault {
+		p.SAdd(ctx, q.queuesKey, string(queue))
+	}
+
+	enqueueCmd.Run(ctx, p, []string{q.setKey, q.streamKey(r)}, id, string(ds))
 
 	return nil
 }
 
-func (q *taskQueue[T]) Dequeue(ctx context.Context, rdb redis.UniversalClient, lockTimeout, timeout time.Duration) (*TaskItem[T], error) {
+// Dequeue returns the next task of the given named queues, or of the default queue if none are given, considering
+// the priorities in the order determined by the queue's priority order.
+func (q *taskQueue[T]) Dequeue(
+	ctx context.Context, rdb redis.UniversalClient, queues []core.Queue, lockTimeout, timeout time.Duration,
+) (*TaskItem[T], error) {
+	if len(queues) == 0 {
+		queues = []core.Queue{core.QueueDefault}
+	}
+
+	for _, queue := range queues {
+		if err := q.createGroups(ctx, rdb, queue.OrDefault()); err != nil {
+			return nil, fmt.Errorf("creating task queue: %w", err)
+		}
+	}
+
+	// Routes of all streams to read from, in priority order
+	order := make([]route, 0, len(queues)*len(core.Priorities))
+	for _, priority := range q.order.Next() {
+		for _, queue := range queues {
+			order = append(order, route{queue: queue.OrDefault(), priority: priority})
+		}
+	}
+
 	// Try to recover abandoned messages
-	task, err := q.recover(ctx, rdb, lockTimeout)
-	if err != nil {
-		return nil, fmt.Errorf("checking for abandoned tasks: %w", err)
+	for _, r := range order {
+		task, err := q.recover(ctx, rdb, r, lockTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("checking for abandoned tasks: %w", err)
+		}
+
+		if task != nil {
+			return task, nil
+		}
 	}
 
-	if task != nil {
-		return task, nil
+	// Check for new tasks without blocking, in priority order
+	for _, r := range order {
+		task, err := q.read(ctx, rdb, order, []route{r}, -1)
+		if err != nil || task != nil {
+			return task, err
+		}
+	}
+
+	// Wait for new tasks of any queue and priority
+	return q.read(ctx, rdb, order, order, timeout)
+}
+
+// read reads new tasks from the streams of the given routes. If tasks of multiple routes are read, the task with the
+// first route in order is returned and the others are added back to the end of their streams.
+func (q *taskQueue[T]) read(
+	ctx context.Context, rdb redis.UniversalClient, order, routes []route, timeout time.Duration,
+) (*TaskItem[T], error) {
+	streams := make([]string, 0, len(routes)*2)
+	for _, r := range routes {
+		streams = append(streams, q.streamKey(r))
+	}
+	for range routes {
+		streams = append(streams, ">")
 	}
 
-	// Check for new tasks
-	ids, err := rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
-		Streams:  []string{q.streamKey, ">"},
+	res, err := rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Streams:  streams,
 		Group:    q.groupName,
 		Consumer: q.workerName,
 		Count:    1,
@@ -154,22 +368,68 @@ func (q *taskQueue[T]) Dequeue(ctx context.Context, rdb redis.UniversalClient, l
 		return nil, fmt.Errorf("dequeueing task: %w", err)
 	}
 
-	if len(ids) == 0 || len(ids[0].Messages) == 0 || err == redis.Nil {
+	if err == redis.Nil {
 		return nil, nil
 	}
 
-	msg := ids[0].Messages[0]
-	return msgToTaskItem[T](&msg)
+	msgs := map[route]*redis.XMessage{}
+	for _, stream := range res {
+		if len(stream.Messages) == 0 {
+			continue
+		}
+
+		for _, r := range routes {
+			if q.streamKey(r) == stream.Stream {
+				msgs[r] = &stream.Messages[0]
+			}
+		}
+	}
+
+	var task *TaskItem[T]
+	for _, r := range order {
+		msg, ok := msgs[r]
+		if !ok {
+			continue
+		}
+
+		if task == nil {
+			task, err = msgToTaskItem[T](r, msg)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		// Return the message to the end of its stream
+		if _, err := rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			streamKey := q.streamKey(r)
+			p.XAck(ctx, streamKey, q.groupName, msg.ID)
+			p.XDel(ctx, streamKey, msg.ID)
+			p.XAdd(ctx, &redis.XAddArgs{
+				Stream: streamKey,
+				ID:     "*",
+				Values: msg.Values,
+			})
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("returning task to queue: %w", err)
+		}
+	}
+
+	return task, nil
 }
 
 func (q *taskQueue[T]) Extend(ctx context.Context, p redis.Pipeliner, taskID string) error {
+	r, msgID := parseTaskID(taskID)
+
 	// Claiming a message resets the idle timer. Don't use the `JUSTID` variant, we
 	// want to increase the retry counter.
 	_, err := p.XClaim(ctx, &redis.XClaimArgs{
-		Stream:   q.streamKey,
+		Stream:   q.streamKey(r),
 		Group:    q.groupName,
 		Consumer: q.workerName,
-		Messages: []string{taskID},
+		Messages: []string{msgID},
 		MinIdle:  0, // Always claim this message
 	}).Result()
 	if err != nil && err != redis.Nil {
@@ -179,6 +439,20 @@ func (q *taskQueue[T]) Extend(ctx context.Context, p redis.Pipeliner, taskID str
 	return nil
 }
 
+// Release makes the task look abandoned, so the next dequeue by any worker recovers it. Messages can't be returned to
+// the stream, instead their idle time is set to the lock timeout.
+func (q *taskQueue[T]) Release(ctx context.Context, rdb redis.UniversalClient, taskID string, lockTimeout time.Duration) error {
+	r, msgID := parseTaskID(taskID)
+
+	err := rdb.Do(ctx, "XCLAIM", q.streamKey(r), q.groupName, q.workerName, 0, msgID,
+		"IDLE", lockTimeout.Milliseconds(), "JUSTID").Err()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("releasing task: %w", err)
+	}
+
+	return nil
+}
+
 // We need TaskIDs for the stream and caller provided IDs for the set. So first look up
 // the ID in the stream using the TaskID, then remove from the set and the stream
 // KEYS[1] = set
@@ -201,7 +475,9 @@ var completeCmd = redis.NewScript(
 `)
 
 func (q *taskQueue[T]) Complete(ctx context.Context, p redis.Pipeliner, taskID string) (*redis.Cmd, error) {
-	cmd := completeCmd.Run(ctx, p, []string{q.setKey, q.streamKey}, taskID, q.groupName)
+	r, msgID := parseTaskID(taskID)
+
+	cmd := completeCmd.Run(ctx, p, []string{q.setKey, q.streamKey(r)}, msgID, q.groupName)
 	if err := cmd.Err(); err != nil && err != redis.Nil {
 		return nil, fmt.Errorf("completing task: %w", err)
 	}
@@ -210,19 +486,21 @@ func (q *taskQueue[T]) Complete(ctx context.Context, p redis.Pipeliner, taskID s
 }
 
 func (q *taskQueue[T]) Data(ctx context.Context, p redis.Pipeliner, taskID string) (*TaskItem[T], error) {
-	msg, err := p.XRange(ctx, q.streamKey, taskID, taskID).Result()
+	r, msgID := parseTaskID(taskID)
+
+	msg, err := p.XRange(ctx, q.streamKey(r), msgID, msgID).Result()
 	if err != nil && err != redis.Nil {
 		return nil, fmt.Errorf("finding task: %w", err)
 	}
 
-	return msgToTaskItem[T](&msg[0])
+	return msgToTaskItem[T](r, &msg[0])
 }
 
-func (q *taskQueue[T]) recover(ctx context.Context, rdb redis.UniversalClient, idleTimeout time.Duration) (*TaskItem[T], error) {
+func (q *taskQueue[T]) recover(ctx context.Context, rdb redis.UniversalClient, r route, idleTimeout time.Duration) (*TaskItem[T], error) {
 	// Ignore the start argument, we are deleting tasks as they are completed, so we'll always
 	// start this scan from the beginning.
 	msgs, _, err := rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
-		Stream:   q.streamKey,
+		Stream:   q.streamKey(r),
 		Group:    q.groupName,
 		Consumer: q.workerName,
 		MinIdle:  idleTimeout,
@@ -238,10 +516,17 @@ func (q *taskQueue[T]) recover(ctx context.Context, rdb redis.UniversalClient, i
 		return nil, nil
 	}
 
-	return msgToTaskItem[T](&msgs[0])
+	task, err := msgToTaskItem[T](r, &msgs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	task.Recovered = true
+
+	return task, nil
 }
 
-func msgToTaskItem[T any](msg *redis.XMessage) (*TaskItem[T], error) {
+func msgToTaskItem[T any](r route, msg *redis.XMessage) (*TaskItem[T], error) {
 	id := msg.Values["id"].(string)
 	data := msg.Values["data"].(string)
 
@@ -253,8 +538,10 @@ func msgToTaskItem[T any](msg *redis.XMessage) (*TaskItem[T], error) {
 	}
 
 	return &TaskItem[T]{
-		TaskID: msg.ID,
-		ID:     id,
-		Data:   t,
+		TaskID:   taskID(r, msg.ID),
+		ID:       id,
+		Data:     t,
+		Queue:    r.queue,
+		Priority: r.priority,
 	}, nil
 }
@@ -6,6 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 )
@@ -33,7 +36,7 @@ func Test_TaskQueue(t *testing.T) {
 		{
 			name: "Create queue",
 			f: func(t *testing.T) {
-				q, err := newTaskQueue[any](client, "test")
+				q, err := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
 				require.NoError(t, err)
 				require.NotNil(t, q)
 			},
@@ -41,17 +44,17 @@ func Test_TaskQueue(t *testing.T) {
 		{
 			name: "Simple enqueue/dequeue",
 			f: func(t *testing.T) {
-				q, err := newTaskQueue[any](client, "test")
+				q, err := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
 				require.NoError(t, err)
 
 				ctx := context.Background()
 
 				_, err = client.Pipelined(ctx, func(p redis.Pipeliner) error {
-					return q.Enqueue(ctx, p, "t1", nil)
+					return q.Enqueue(ctx, p, route{priority: core.PriorityNormal}, "t1", nil)
 				})
 				require.NoError(t, err)
 
-				task, err := q.Dequeue(ctx, client, lockTimeout, blockTimeout)
+				task, err := q.Dequeue(ctx, client, nil, lockTimeout, blockTimeout)
 				require.NoError(t, err)
 				require.NotNil(t, task)
 				require.Equal(t, "t1", task.ID)
@@ -60,22 +63,22 @@ func Test_TaskQueue(t *testing.T) {
 		{
 			name: "Guarantee uniqueness",
 			f: func(t *testing.T) {
-				q, err := newTaskQueue[any](client, "test")
+				q, err := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
 				require.NoError(t, err)
 
 				ctx := context.Background()
 
 				_, err = client.Pipelined(ctx, func(p redis.Pipeliner) error {
-					return q.Enqueue(ctx, p, "t1", nil)
+					return q.Enqueue(ctx, p, route{priority: core.PriorityNormal}, "t1", nil)
 				})
 				require.NoError(t, err)
 
 				_, err = client.Pipelined(ctx, func(p redis.Pipeliner) error {
-					return q.Enqueue(ctx, p, "t1", nil)
+					return q.Enqueue(ctx, p, route{priority: core.PriorityNormal}, "t1", nil)
 				})
 				require.NoError(t, err)
 
-				task, err := q.Dequeue(ctx, client, lockTimeout, blockTimeout)
+				task, err := q.Dequeue(ctx, client, nil, lockTimeout, blockTimeout)
 				require.NoError(t, err)
 				require.NotNil(t, task)
 
@@ -86,7 +89,7 @@ func Test_TaskQueue(t *testing.T) {
 				require.NoError(t, err)
 
 				_, err = client.Pipelined(ctx, func(p redis.Pipeliner) error {
-					return q.Enqueue(ctx, p, "t1", nil)
+					return q.Enqueue(ctx, p, route{priority: core.PriorityNormal}, "t1", nil)
 				})
 				require.NoError(t, err)
 			},
@@ -101,18 +104,18 @@ func Test_TaskQueue(t *testing.T) {
 
 				ctx := context.Background()
 
-				q, err := newTaskQueue[foo](client, "test")
+				q, err := newTaskQueue[foo](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
 				require.NoError(t, err)
 
 				_, err = client.Pipelined(ctx, func(p redis.Pipeliner) error {
-					return q.Enqueue(ctx, p, "t1", &foo{
+					return q.Enqueue(ctx, p, route{priority: core.PriorityNormal}, "t1", &foo{
 						Count: 1,
 						Name:  "bar",
 					})
 				})
 				require.NoError(t, err)
 
-				task, err := q.Dequeue(ctx, client, lockTimeout, blockTimeout)
+				task, err := q.Dequeue(ctx, client, nil, lockTimeout, blockTimeout)
 				require.NoError(t, err)
 				require.NotNil(t, task)
 				require.Equal(t, "t1", task.ID)
@@ -123,20 +126,20 @@ func Test_TaskQueue(t *testing.T) {
 		{
 			name: "Simple enqueue/dequeue different worker",
 			f: func(t *testing.T) {
-				q, _ := newTaskQueue[any](client, "test")
+				q, _ := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
 
 				ctx := context.Background()
 
 				_, err := client.Pipelined(ctx, func(p redis.Pipeliner) error {
-					return q.Enqueue(ctx, p, "t1", nil)
+					return q.Enqueue(ctx, p, route{priority: core.PriorityNormal}, "t1", nil)
 				})
 				require.NoError(t, err)
 
-				q2, _ := newTaskQueue[any](client, "test")
+				q2, _ := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
 				require.NoError(t, err)
 
 				// Dequeue using second worker
-				task, err := q2.Dequeue(ctx, client, lockTimeout, blockTimeout)
+				task, err := q2.Dequeue(ctx, client, nil, lockTimeout, blockTimeout)
 				require.NoError(t, err)
 				require.NotNil(t, task)
 				require.Equal(t, "t1", task.ID)
@@ -145,17 +148,17 @@ func Test_TaskQueue(t *testing.T) {
 		{
 			name: "Complete removes task",
 			f: func(t *testing.T) {
-				q, _ := newTaskQueue[any](client, "test")
-				q2, _ := newTaskQueue[any](client, "test")
+				q, _ := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
+				q2, _ := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
 
 				ctx := context.Background()
 
 				_, err := client.Pipelined(ctx, func(p redis.Pipeliner) error {
-					return q.Enqueue(ctx, p, "t1", nil)
+					return q.Enqueue(ctx, p, route{priority: core.PriorityNormal}, "t1", nil)
 				})
 				require.NoError(t, err)
 
-				task, err := q.Dequeue(ctx, client, lockTimeout, blockTimeout)
+				task, err := q.Dequeue(ctx, client, nil, lockTimeout, blockTimeout)
 				require.NoError(t, err)
 				require.NotNil(t, task)
 
@@ -169,7 +172,7 @@ func Test_TaskQueue(t *testing.T) {
 				time.Sleep(time.Millisecond * 10)
 
 				// Try to recover using second worker
-				task2, err := q2.Dequeue(ctx, client, lockTimeout, blockTimeout)
+				task2, err := q2.Dequeue(ctx, client, nil, lockTimeout, blockTimeout)
 				require.NoError(t, err)
 				require.Nil(t, task2)
 			},
@@ -177,19 +180,19 @@ func Test_TaskQueue(t *testing.T) {
 		{
 			name: "Recover task",
 			f: func(t *testing.T) {
-				q, _ := newTaskQueue[any](client, "test")
+				q, _ := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
 
 				ctx := context.Background()
 
 				_, err := client.Pipelined(ctx, func(p redis.Pipeliner) error {
-					return q.Enqueue(ctx, p, "t1", nil)
+					return q.Enqueue(ctx, p, route{priority: core.PriorityNormal}, "t1", nil)
 				})
 				require.NoError(t, err)
 
-				q2, _ := newTaskQueue[any](client, "test")
+				q2, _ := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
 				require.NoError(t, err)
 
-				task, err := q2.Dequeue(ctx, client, lockTimeout, blockTimeout)
+				task, err := q2.Dequeue(ctx, client, nil, lockTimeout, blockTimeout)
 				require.NoError(t, err)
 				require.NotNil(t, task)
 				require.Equal(t, "t1", task.ID)
@@ -197,29 +200,32 @@ func Test_TaskQueue(t *testing.T) {
 				time.Sleep(time.Millisecond * 10)
 
 				// Assume q2 crashed, recover from other worker
-				recoveredTask, err := q.Dequeue(ctx, client, time.Millisecond*1, blockTimeout)
+				recoveredTask, err := q.Dequeue(ctx, client, nil, time.Millisecond*1, blockTimeout)
 				require.NoError(t, err)
 				require.NotNil(t, task)
-				require.Equal(t, task, recoveredTask)
+				require.False(t, task.Recovered)
+				require.True(t, recoveredTask.Recovered)
+				require.Equal(t, task.TaskID, recoveredTask.TaskID)
+				require.Equal(t, task.ID, recoveredTask.ID)
 			},
 		},
 		{
 			name: "Extending task prevents recovering",
 			f: func(t *testing.T) {
-				q, _ := newTaskQueue[any](client, "test")
+				q, _ := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
 
 				ctx := context.Background()
 
 				_, err := client.Pipelined(ctx, func(p redis.Pipeliner) error {
-					return q.Enqueue(ctx, p, "t1", nil)
+					return q.Enqueue(ctx, p, route{priority: core.PriorityNormal}, "t1", nil)
 				})
 				require.NoError(t, err)
 
 				// Create second worker (with different name)
-				q2, _ := newTaskQueue[any](client, "test")
+				q2, _ := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
 				require.NoError(t, err)
 
-				task, err := q2.Dequeue(ctx, client, lockTimeout, blockTimeout)
+				task, err := q2.Dequeue(ctx, client, nil, lockTimeout, blockTimeout)
 				require.NoError(t, err)
 				require.NotNil(t, task)
 				require.Equal(t, "t1", task.ID)
@@ -232,11 +238,90 @@ func Test_TaskQueue(t *testing.T) {
 				require.NoError(t, err)
 
 				// Use large lock timeout
-				recoveredTask, err := q.Dequeue(ctx, client, time.Second*2, blockTimeout)
+				recoveredTask, err := q.Dequeue(ctx, client, nil, time.Second*2, blockTimeout)
 				require.NoError(t, err)
 				require.Nil(t, recoveredTask)
 			},
 		},
+		{
+			name: "Dequeue by priority",
+			f: func(t *testing.T) {
+				q, err := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
+				require.NoError(t, err)
+
+				ctx := context.Background()
+
+				_, err = client.Pipelined(ctx, func(p redis.Pipeliner) error {
+					if err := q.Enqueue(ctx, p, route{priority: core.PriorityLow}, "low", nil); err != nil {
+						return err
+					}
+
+					if err := q.Enqueue(ctx, p, route{priority: core.PriorityNormal}, "normal", nil); err != nil {
+						return err
+					}
+
+					return q.Enqueue(ctx, p, route{priority: core.PriorityHigh}, "high", nil)
+				})
+				require.NoError(t, err)
+
+				for _, expected := range []string{"high", "normal", "low"} {
+					task, err := q.Dequeue(ctx, client, nil, time.Second, blockTimeout)
+					require.NoError(t, err)
+					require.NotNil(t, task)
+					require.Equal(t, expected, task.ID)
+
+					_, err = client.Pipelined(ctx, func(p redis.Pipeliner) error {
+						_, err := q.Complete(ctx, p, task.TaskID)
+						return err
+					})
+					require.NoError(t, err)
+				}
+			},
+		},
+		{
+			name: "Dequeue from named queues",
+			f: func(t *testing.T) {
+				q, err := newTaskQueue[any](client, "test", backend.NewPriorityOrder(0), uuid.NewString())
+				require.NoError(t, err)
+
+				ctx := context.Background()
+
+				_, err = client.Pipelined(ctx, func(p redis.Pipeliner) error {
+					if err := q.Enqueue(ctx, p, route{}, "default", nil); err != nil {
+						return err
+					}
+
+					return q.Enqueue(ctx, p, route{queue: "gpu", priority: core.PriorityHigh}, "gpu", nil)
+				})
+				require.NoError(t, err)
+
+				size, err := q.Size(ctx, client)
+				require.NoError(t, err)
+				require.Equal(t, int64(2), size)
+
+				task, err := q.Dequeue(ctx, client, []core.Queue{"gpu"}, lockTimeout, blockTimeout)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.Equal(t, "gpu", task.ID)
+				require.Equal(t, core.Queue("gpu"), task.Queue)
+
+				_, err = client.Pipelined(ctx, func(p redis.Pipeliner) error {
+					_, err := q.Complete(ctx, p, task.TaskID)
+					return err
+				})
+				require.NoError(t, err)
+
+				// Tasks of the default queue are not returned for other queues
+				task, err = q.Dequeue(ctx, client, []core.Queue{"gpu"}, lockTimeout, blockTimeout)
+				require.NoError(t, err)
+				require.Nil(t, task)
+
+				task, err = q.Dequeue(ctx, client, nil, lockTimeout, blockTimeout)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.Equal(t, "default", task.ID)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
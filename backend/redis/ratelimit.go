@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ backend.RateLimiter = (*redisBackend)(nil)
+
+// Take a token from a token bucket. Mirrors backend.RateLimit.Take, time values are in microseconds. Buckets start
+// out full and expire once they would have been refilled completely.
+//
+// KEYS[1] - rate limit key
+// ARGV[1] - limit
+// ARGV[2] - interval
+// ARGV[3] - current time
+//
+// Returns the time to wait before trying again, 0 if a token was taken.
+var acquireRateLimitCmd = redis.NewScript(`
+	local limit = tonumber(ARGV[1])
+	local interval = tonumber(ARGV[2])
+	local now = tonumber(ARGV[3])
+
+	local tokens = limit
+	local state = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+	if state[1] and state[2] then
+		tokens = tonumber(state[1])
+		local elapsed = now - tonumber(state[2])
+		if elapsed > 0 then
+			tokens = math.min(limit, tokens + elapsed * limit / interval)
+		end
+	end
+
+	local wait = 0
+	if tokens >= 1 then
+		tokens = tokens - 1
+	else
+		wait = math.ceil((1 - tokens) * interval / limit)
+	end
+
+	redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated_at", ARGV[3])
+	redis.call("PEXPIRE", KEYS[1], math.ceil(interval / 1000))
+
+	return wait
+`)
+
+func (rb *redisBackend) AcquireActivityRateLimit(ctx context.Context, activityName string) (time.Duration, error) {
+	limit, ok := rb.options.ActivityRateLimits[activityName]
+	if !ok {
+		return 0, nil
+	}
+
+	return rb.AcquireRateLimit(ctx, "activity:"+activityName, limit)
+}
+
+func (rb *redisBackend) AcquireRateLimit(ctx context.Context, name string, limit backend.RateLimit) (time.Duration, error) {
+	if limit.Limit <= 0 || limit.Interval <= 0 {
+		return 0, nil
+	}
+
+	wait, err := acquireRateLimitCmd.Run(ctx, rb.rdb,
+		[]string{rb.keys.rateLimitKey(name)},
+		limit.Limit,
+		limit.Interval.Microseconds(),
+		rb.options.Clock.Now().UnixMicro(),
+	).Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(wait) * time.Microsecond, nil
+}
@@ -18,18 +18,11 @@ import (
 )
 
 var _ backend.Backend = (*redisBackend)(nil)
+var _ backend.Retainer = (*redisBackend)(nil)
+var _ backend.QueuePoller = (*redisBackend)(nil)
+var _ backend.IDGenerator = (*redisBackend)(nil)
 
 func NewRedisBackend(client redis.UniversalClient, opts ...RedisBackendOption) (*redisBackend, error) {
-	workflowQueue, err := newTaskQueue[any](client, "workflows")
-	if err != nil {
-		return nil, fmt.Errorf("creating workflow task queue: %w", err)
-	}
-
-	activityQueue, err := newTaskQueue[activityData](client, "activities")
-	if err != nil {
-		return nil, fmt.Errorf("creating activity task queue: %w", err)
-	}
-
 	// Default options
 	options := &RedisOptions{
 		Options:      backend.ApplyOptions(),
@@ -40,26 +33,55 @@ func NewRedisBackend(client redis.UniversalClient, opts ...RedisBackendOption) (
 		opt(options)
 	}
 
+	if options.Namespace == "" {
+		options.Namespace = backend.DefaultNamespace
+	}
+
+	if len(options.PayloadCodecs) > 0 {
+		options.Converter = converter.NewCodecConverter(options.Converter, options.PayloadCodecs...)
+	}
+
+	keys := newKeys(options.Namespace, options.ClusterMode)
+
+	workflowQueue, err := newTaskQueue[any](client, keys.prefix+"workflows", backend.NewPriorityOrder(options.PriorityStarvationInterval), options.IDGenerator())
+	if err != nil {
+		return nil, fmt.Errorf("creating workflow task queue: %w", err)
+	}
+
+	activityQueue, err := newTaskQueue[activityData](client, keys.prefix+"activities", backend.NewPriorityOrder(options.PriorityStarvationInterval), options.IDGenerator())
+	if err != nil {
+		return nil, fmt.Errorf("creating activity task queue: %w", err)
+	}
+
 	rb := &redisBackend{
 		rdb:     client,
 		options: options,
+		keys:    keys,
 
 		workflowQueue: workflowQueue,
 		activityQueue: activityQueue,
 	}
 
+	if options.ClusterMode {
+		rb.collector = newCollector()
+	}
+
 	// Preload scripts here. Usually redis-go attempts to execute them first, and if redis doesn't know
 	// them, loads them. This doesn't work when using (transactional) pipelines, so eagerly load them on startup.
 	ctx := context.Background()
 	cmds := map[string]*redis.StringCmd{
 		"addEventsToStreamCmd":   addEventsToStreamCmd.Load(ctx, rb.rdb),
+		"acquireRateLimitCmd":    acquireRateLimitCmd.Load(ctx, rb.rdb),
 		"addFutureEventCmd":      addFutureEventCmd.Load(ctx, rb.rdb),
 		"futureEventsCmd":        futureEventsCmd.Load(ctx, rb.rdb),
+		"claimFutureEventsCmd":   claimFutureEventsCmd.Load(ctx, rb.rdb),
 		"removeFutureEventCmd":   removeFutureEventCmd.Load(ctx, rb.rdb),
 		"removePendingEventsCmd": removePendingEventsCmd.Load(ctx, rb.rdb),
 		"requeueInstanceCmd":     requeueInstanceCmd.Load(ctx, rb.rdb),
 		"deleteInstanceCmd":      deleteCmd.Load(ctx, rb.rdb),
+		"deleteIndexesCmd":       deleteIndexesCmd.Load(ctx, rb.rdb),
 		"expireInstanceCmd":      expireCmd.Load(ctx, rb.rdb),
+		"expireIndexesCmd":       expireIndexesCmd.Load(ctx, rb.rdb),
 	}
 	for name, cmd := range cmds {
 		// fmt.Println(name, cmd.Val())
@@ -75,9 +97,13 @@ func NewRedisBackend(client redis.UniversalClient, opts ...RedisBackendOption) (
 type redisBackend struct {
 	rdb     redis.UniversalClient
 	options *RedisOptions
+	keys    keys
 
 	workflowQueue *taskQueue[any]
 	activityQueue *taskQueue[activityData]
+
+	// collector collects the commands of transactions in cluster mode, see txPipelined
+	collector *redis.Client
 }
 
 type activityData struct {
@@ -91,7 +117,10 @@ func (rb *redisBackend) Logger() log.Logger {
 }
 
 func (rb *redisBackend) Metrics() metrics.Client {
-	return rb.options.Metrics.WithTags(metrics.Tags{metrickeys.Backend: "redis"})
+	return rb.options.Metrics.WithTags(metrics.Tags{
+		metrickeys.Backend:   "redis",
+		metrickeys.Namespace: rb.options.Namespace,
+	})
 }
 
 func (rb *redisBackend) Tracer() trace.Tracer {
@@ -102,10 +131,22 @@ func (rb *redisBackend) Converter() converter.Converter {
 	return rb.options.Converter
 }
 
+func (rb *redisBackend) NewID() string {
+	return rb.options.IDGenerator()
+}
+
 func (rb *redisBackend) ContextPropagators() []contextpropagation.ContextPropagator {
 	return rb.options.ContextPropagators
 }
 
+func (rb *redisBackend) Retention() time.Duration {
+	return rb.options.Retention
+}
+
 func (rb *redisBackend) Close() error {
+	if rb.collector != nil {
+		rb.collector.Close()
+	}
+
 	return rb.rdb.Close()
 }
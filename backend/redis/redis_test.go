@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/cschleiden/go-workflows/backend/test"
 	"github.com/cschleiden/go-workflows/internal/history"
 	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -42,6 +44,125 @@ func Test_EndToEndRedisBackend(t *testing.T) {
 	test.EndToEndBackendTest(t, setup, nil)
 }
 
+func Test_RedisBackend_Namespaces_SameWorkflow(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	client := getClient()
+	require.NoError(t, client.FlushDB(context.Background()).Err())
+
+	test.NamespacesBackendTest(t, func(namespace string) test.TestBackend {
+		b, err := NewRedisBackend(client, WithBlockTimeout(time.Millisecond*10), WithBackendOptions(backend.WithNamespace(namespace)))
+		require.NoError(t, err)
+
+		return b
+	})
+}
+
+// Run the suites in cluster mode against a single server, checking that every command, script, and transaction only
+// accesses keys of a single hash slot, like Redis Cluster requires. Keys scripts access without declaring them are not
+// checked.
+func Test_RedisBackend_ClusterMode(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	client := getClient()
+	slots := newSlotCheck()
+	client.AddHook(slots)
+
+	test.BackendTest(t, getCreateBackend(client, WithClusterMode()), nil)
+
+	require.Empty(t, slots.violations())
+}
+
+func Test_EndToEndRedisBackend_ClusterMode(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	client := getClient()
+	slots := newSlotCheck()
+	client.AddHook(slots)
+
+	test.EndToEndBackendTest(t, getCreateBackend(client, WithClusterMode()), nil)
+
+	require.Empty(t, slots.violations())
+}
+
+// slotCheck is a hook recording commands and transactions accessing keys in multiple hash slots. Keys of commands are
+// looked up via COMMAND GETKEYS.
+type slotCheck struct {
+	rdb redis.UniversalClient
+
+	mu   sync.Mutex
+	errs []string
+}
+
+func newSlotCheck() *slotCheck {
+	return &slotCheck{rdb: getClient()}
+}
+
+func (c *slotCheck) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (c *slotCheck) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		c.check(ctx, []redis.Cmder{cmd})
+		return next(ctx, cmd)
+	}
+}
+
+func (c *slotCheck) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if len(cmds) > 0 && cmds[0].Name() == "multi" {
+			// All commands of a transaction need to be in the same slot
+			c.check(ctx, cmds)
+		} else {
+			for _, cmd := range cmds {
+				c.check(ctx, []redis.Cmder{cmd})
+			}
+		}
+
+		return next(ctx, cmds)
+	}
+}
+
+func (c *slotCheck) check(ctx context.Context, cmds []redis.Cmder) {
+	tags := map[string]bool{}
+	var names []string
+
+	for _, cmd := range cmds {
+		// Commands without keys fail
+		keys, err := c.rdb.Do(ctx, append([]interface{}{"command", "getkeys"}, cmd.Args()...)...).StringSlice()
+		if err != nil {
+			continue
+		}
+
+		for _, key := range keys {
+			tags[hashTag(key)] = true
+		}
+
+		names = append(names, fmt.Sprint(cmd.Args()))
+	}
+
+	if len(tags) > 1 {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.errs = append(c.errs, strings.Join(names, ", "))
+	}
+}
+
+func (c *slotCheck) violations() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.errs
+}
+
 func getClient() redis.UniversalClient {
 	client := redis.NewUniversalClient(&redis.UniversalOptions{
 		Addrs:    []string{address},
@@ -89,7 +210,7 @@ var _ test.TestBackend = (*redisBackend)(nil)
 
 // GetFutureEvents
 func (rb *redisBackend) GetFutureEvents(ctx context.Context) ([]*history.Event, error) {
-	r, err := rb.rdb.ZRangeByScore(ctx, futureEventsKey(), &redis.ZRangeBy{
+	r, err := rb.rdb.ZRangeByScore(ctx, rb.keys.futureEventsKey(), &redis.ZRangeBy{
 		Min: "-inf",
 		Max: "+inf",
 	}).Result()
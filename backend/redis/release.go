@@ -0,0 +1,13 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.ActivityTaskReleaser = (*redisBackend)(nil)
+
+func (rb *redisBackend) ReleaseActivityTask(ctx context.Context, activityID string) error {
+	return rb.activityQueue.Release(ctx, rb.rdb, activityID, rb.options.ActivityLockTimeout)
+}
@@ -0,0 +1,150 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// checkInstanceIDReuse enforces the instance ID reuse policy of a new execution against the most recent execution with
+// the same instance ID. The keys of the existing execution are watched by the given transaction. If the policy allows
+// terminating an active execution, the returned function adds its termination to the pipeline creating the new one,
+// and the execution is returned.
+func (rb *redisBackend) checkInstanceIDReuse(
+	ctx context.Context, tx *redis.Tx, instanceID string, policy core.InstanceIDReusePolicy,
+) (func(p redis.Pipeliner) error, *core.WorkflowInstance, error) {
+	segment, err := tx.Get(ctx, rb.keys.latestInstanceExecutionKey(instanceID)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, nil, fmt.Errorf("reading latest execution: %w", err)
+	}
+
+	if err == redis.Nil {
+		// Instances created before the latest execution was tracked only have their active execution
+		active, err := rb.readActiveInstanceExecution(ctx, tx, instanceID)
+		if err != nil || active == nil {
+			return nil, nil, err
+		}
+
+		segment = instanceSegment(active)
+	}
+
+	instanceKey := rb.keys.instanceKeyFromSegment(segment)
+	if err := tx.Watch(ctx, instanceKey).Err(); err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := readInstance(ctx, tx, instanceKey)
+	if err != nil {
+		// The latest execution might have been removed or expired
+		if err == backend.ErrInstanceNotFound {
+			return nil, nil, nil
+		}
+
+		return nil, nil, err
+	}
+
+	terminate, err := backend.CheckInstanceIDReuse(policy, existing.State, func() (*history.Event, error) {
+		return rb.lastHistoryEvent(ctx, tx, existing.Instance)
+	})
+	if err != nil || !terminate {
+		return nil, nil, err
+	}
+
+	var startedEvent *history.Event
+	if existing.LastSequenceID == 0 {
+		msgs, err := tx.XRangeN(ctx, rb.keys.pendingEventsKey(existing.Instance), "-", "+", 1).Result()
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading pending events: %w", err)
+		}
+
+		if len(msgs) > 0 {
+			if err := json.Unmarshal([]byte(msgs[0].Values["event"].(string)), &startedEvent); err != nil {
+				return nil, nil, fmt.Errorf("unmarshaling event: %w", err)
+			}
+		}
+	}
+
+	return func(p redis.Pipeliner) error {
+		return rb.terminateExistingP(ctx, p, existing, startedEvent)
+	}, existing.Instance, nil
+}
+
+func (rb *redisBackend) lastHistoryEvent(ctx context.Context, rdb redis.Cmdable, instance *core.WorkflowInstance) (*history.Event, error) {
+	msgs, err := rdb.XRevRangeN(ctx, rb.keys.historyKey(instance), "+", "-", 1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading last history event: %w", err)
+	}
+
+	if len(msgs) == 0 {
+		// Histories of finished instances might have been moved to the archiver
+		if rb.options.Archiver != nil {
+			h, err := rb.getArchivedHistory(ctx, instance)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(h) > 0 {
+				return h[len(h)-1], nil
+			}
+		}
+
+		return nil, nil
+	}
+
+	var event *history.Event
+	if err := json.Unmarshal([]byte(msgs[0].Values["event"].(string)), &event); err != nil {
+		return nil, fmt.Errorf("unmarshaling event: %w", err)
+	}
+
+	return event, nil
+}
+
+// terminateExistingP terminates the given active execution in place, see backend.TerminateExecution. The pending
+// events of the execution are discarded by the next workflow task, like for any finished instance.
+func (rb *redisBackend) terminateExistingP(ctx context.Context, p redis.Pipeliner, state *instanceState, startedEvent *history.Event) error {
+	instance := state.Instance
+	now := rb.options.Clock.Now()
+
+	executedEvents, parentEvent := backend.TerminateExecution(instance, startedEvent, state.LastSequenceID, now)
+
+	if err := addEventsToHistoryStreamP(ctx, p, rb.keys.historyKey(instance), executedEvents); err != nil {
+		return fmt.Errorf("adding termination events: %w", err)
+	}
+
+	// Updating the state makes a worker currently executing a task for the instance fail to complete it
+	state.State = core.WorkflowInstanceStateFinished
+	state.CompletedAt = &now
+	state.LastSequenceID = executedEvents[len(executedEvents)-1].SequenceID
+
+	if err := rb.updateInstanceP(ctx, p, instance, state); err != nil {
+		return fmt.Errorf("updating workflow instance: %w", err)
+	}
+
+	p.HDel(ctx, rb.keys.instanceBuildIDsKey(), instanceSegment(instance))
+	p.HDel(ctx, rb.keys.deadLetteredInstancesKey(), instanceSegment(instance))
+	p.HDel(ctx, rb.keys.instanceTaskAttemptsKey(), instanceSegment(instance))
+
+	if rb.options.AutoExpiration > 0 {
+		if err := rb.setWorkflowInstanceExpiration(ctx, p, instance, rb.options.AutoExpiration); err != nil {
+			return fmt.Errorf("setting workflow instance expiration: %w", err)
+		}
+	}
+
+	if parentEvent != nil {
+		if err := addEventToStreamP(ctx, p, rb.keys.pendingEventsKey(parentEvent.WorkflowInstance), parentEvent.HistoryEvent); err != nil {
+			return err
+		}
+
+		// Sub-workflows inherit the priority of their parent
+		if err := rb.workflowQueue.Enqueue(ctx, p, state.route(), instanceSegment(parentEvent.WorkflowInstance), nil); err != nil {
+			return fmt.Errorf("queueing workflow task: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/cschleiden/go-workflows/backend"
+	redis "github.com/redis/go-redis/v9"
+)
+
+var _ backend.ScheduleStore = (*redisBackend)(nil)
+
+// KEYS[1] - schedules key
+// ARGV[1] - schedule id
+// ARGV[2] - schedule
+var updateScheduleCmd = redis.NewScript(
+	`if redis.call("HEXISTS", KEYS[1], ARGV[1]) == 0 then
+		return 0
+	end
+	redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+	return 1`)
+
+func (rb *redisBackend) CreateSchedule(ctx context.Context, s *backend.Schedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshaling schedule: %w", err)
+	}
+
+	created, err := rb.rdb.HSetNX(ctx, rb.keys.schedulesKey(), s.ID, string(data)).Result()
+	if err != nil {
+		return fmt.Errorf("storing schedule: %w", err)
+	}
+
+	if !created {
+		return backend.ErrScheduleAlreadyExists
+	}
+
+	return nil
+}
+
+func (rb *redisBackend) UpdateSchedule(ctx context.Context, s *backend.Schedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshaling schedule: %w", err)
+	}
+
+	updated, err := updateScheduleCmd.Run(ctx, rb.rdb, []string{rb.keys.schedulesKey()}, s.ID, string(data)).Int()
+	if err != nil {
+		return fmt.Errorf("updating schedule: %w", err)
+	}
+
+	if updated == 0 {
+		return backend.ErrScheduleNotFound
+	}
+
+	return nil
+}
+
+func (rb *redisBackend) DeleteSchedule(ctx context.Context, id string) error {
+	removed, err := rb.rdb.HDel(ctx, rb.keys.schedulesKey(), id).Result()
+	if err != nil {
+		return fmt.Errorf("deleting schedule: %w", err)
+	}
+
+	if removed == 0 {
+		return backend.ErrScheduleNotFound
+	}
+
+	return nil
+}
+
+func (rb *redisBackend) ListSchedules(ctx context.Context) ([]*backend.Schedule, error) {
+	values, err := rb.rdb.HGetAll(ctx, rb.keys.schedulesKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("listing schedules: %w", err)
+	}
+
+	schedules := make([]*backend.Schedule, 0, len(values))
+	for _, v := range values {
+		var s *backend.Schedule
+		if err := json.Unmarshal([]byte(v), &s); err != nil {
+			return nil, fmt.Errorf("unmarshaling schedule: %w", err)
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].ID < schedules[j].ID
+	})
+
+	return schedules, nil
+}
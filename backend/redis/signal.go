@@ -15,7 +15,7 @@ import (
 
 func (rb *redisBackend) SignalWorkflow(ctx context.Context, instanceID string, event *history.Event) error {
 	// Get current execution of the instance
-	instance, err := readActiveInstanceExecution(ctx, rb.rdb, instanceID)
+	instance, err := rb.readActiveInstanceExecution(ctx, rb.rdb, instanceID)
 	if err != nil {
 		return fmt.Errorf("reading active instance execution: %w", err)
 	}
@@ -24,7 +24,7 @@ func (rb *redisBackend) SignalWorkflow(ctx context.Context, instanceID string, e
 		return backend.ErrInstanceNotFound
 	}
 
-	instanceState, err := readInstance(ctx, rb.rdb, instanceKey(instance))
+	instanceState, err := readInstance(ctx, rb.rdb, rb.keys.instanceKey(instance))
 	if err != nil {
 		return err
 	}
@@ -34,20 +34,76 @@ func (rb *redisBackend) SignalWorkflow(ctx context.Context, instanceID string, e
 		rb.Logger().Error("extracting tracing context", log.ErrorKey, err)
 	}
 
-	a := event.Attributes.(*history.SignalReceivedAttributes)
-	ctx, span := rb.Tracer().Start(ctx, fmt.Sprintf("SignalWorkflow: %s", a.Name), trace.WithAttributes(
+	// Updates are delivered like signals
+	name := event.Type.String()
+	a, isSignal := event.Attributes.(*history.SignalReceivedAttributes)
+	if isSignal {
+		name = a.Name
+	}
+
+	ctx, span := rb.Tracer().Start(ctx, fmt.Sprintf("SignalWorkflow: %s", name), trace.WithAttributes(
 		attribute.String(log.InstanceIDKey, instanceID),
-		attribute.String(log.SignalNameKey, event.Attributes.(*history.SignalReceivedAttributes).Name),
+		attribute.String(log.SignalNameKey, name),
 	))
 	defer span.End()
 
-	if _, err = rb.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
-		if err := rb.addWorkflowInstanceEventP(ctx, p, instanceState.Instance, event); err != nil {
-			return fmt.Errorf("adding event to stream: %w", err)
+	// Record the signal, drop it if an identical signal was delivered within the deduplication window
+	var deduplicationKey string
+	if window := rb.options.SignalDeduplicationWindow; window > 0 && isSignal {
+		deduplicationKey = rb.keys.signalDeduplicationKey(instanceID, backend.SignalHash(a))
+
+		recorded, err := rb.rdb.SetNX(ctx, deduplicationKey, event.ID, window).Result()
+		if err != nil {
+			return fmt.Errorf("recording signal: %w", err)
+		}
+
+		if !recorded {
+			rb.Logger().Debug("Dropping duplicate signal", log.InstanceIDKey, instanceID, log.SignalNameKey, a.Name)
+			return nil
+		}
+	}
+
+	// Watch the active execution, so that the signal isn't added to an execution that has just finished or continued
+	// as new
+	if err := rb.watch(ctx, func(tx *watchedTx) error {
+		instance, err := rb.readActiveInstanceExecution(ctx, tx, instanceID)
+		if err != nil {
+			return fmt.Errorf("reading active instance execution: %w", err)
+		}
+
+		if instance == nil {
+			return backend.ErrInstanceNotFound
+		}
+
+		instanceState, err := readInstance(ctx, tx, rb.keys.instanceKey(instance))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if event.VisibleAt != nil {
+				// Hold delayed signals until they are due
+				if err := rb.addFutureEventP(ctx, p, instanceState.Instance, instanceState.route(), event); err != nil {
+					return fmt.Errorf("adding future event: %w", err)
+				}
+
+				return nil
+			}
+
+			if err := rb.addWorkflowInstanceEventP(ctx, p, instanceState.Instance, instanceState.route(), event); err != nil {
+				return fmt.Errorf("adding event to stream: %w", err)
+			}
+
+			return nil
+		})
+
+		return err
+	}, rb.keys.activeInstanceExecutionKey(instanceID)); err != nil {
+		if deduplicationKey != "" {
+			// Signal wasn't delivered, allow it to be retried
+			rb.rdb.Del(ctx, deduplicationKey)
 		}
 
-		return nil
-	}); err != nil {
 		return err
 	}
 
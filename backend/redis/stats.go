@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/cschleiden/go-workflows/backend"
 )
@@ -13,13 +14,26 @@ func (rb *redisBackend) GetStats(ctx context.Context) (*backend.Stats, error) {
 	s := &backend.Stats{}
 
 	// get workflow instances
-	activeInstances, err := rb.rdb.SCard(ctx, instancesActive()).Result()
+	activeInstances, err := rb.rdb.SCard(ctx, rb.keys.instancesActive()).Result()
 	if err != nil {
 		return nil, fmt.Errorf("getting active instances: %w", err)
 	}
 
 	s.ActiveWorkflowInstances = activeInstances
 
+	// get workflow tasks, the queue contains locked tasks until they are completed
+	workflowTasks, err := rb.workflowQueue.Size(ctx, rb.rdb)
+	if err != nil {
+		return nil, fmt.Errorf("getting workflow tasks: %w", err)
+	}
+
+	s.LockedWorkflowTasks, err = rb.workflowQueue.Locked(ctx, rb.rdb)
+	if err != nil {
+		return nil, fmt.Errorf("getting locked workflow tasks: %w", err)
+	}
+
+	s.PendingWorkflowTasks = workflowTasks - s.LockedWorkflowTasks
+
 	// get pending activities
 	pendingActivities, err := rb.activityQueue.Size(ctx, rb.rdb)
 	if err != nil {
@@ -28,5 +42,18 @@ func (rb *redisBackend) GetStats(ctx context.Context) (*backend.Stats, error) {
 
 	s.PendingActivities = pendingActivities
 
+	s.LockedActivityTasks, err = rb.activityQueue.Locked(ctx, rb.rdb)
+	if err != nil {
+		return nil, fmt.Errorf("getting locked activity tasks: %w", err)
+	}
+
+	s.PendingActivityTasks = pendingActivities - s.LockedActivityTasks
+
+	// get future events that are not due yet
+	s.PendingTimers, err = rb.rdb.ZCount(ctx, rb.keys.futureEventsKey(), "("+strconv.FormatInt(rb.options.Clock.Now().UnixMilli(), 10), "+inf").Result()
+	if err != nil {
+		return nil, fmt.Errorf("getting pending timers: %w", err)
+	}
+
 	return s, nil
 }
@@ -0,0 +1,30 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/redis/go-redis/v9"
+)
+
+func (rb *redisBackend) TerminateWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance, event *history.Event) error {
+	instanceState, err := readInstance(ctx, rb.rdb, rb.keys.instanceKey(instance))
+	if err != nil {
+		return err
+	}
+
+	if _, err := rb.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		// Paused and dead-lettered instances don't get workflow tasks, resume them to process the termination
+		p.SRem(ctx, rb.keys.pausedInstancesKey(), instanceSegment(instance))
+		p.HDel(ctx, rb.keys.deadLetteredInstancesKey(), instanceSegment(instance))
+		p.HDel(ctx, rb.keys.instanceTaskAttemptsKey(), instanceSegment(instance))
+
+		return rb.addWorkflowInstanceEventP(ctx, p, instance, instanceState.route(), event)
+	}); err != nil {
+		return fmt.Errorf("adding termination event to workflow instance: %w", err)
+	}
+
+	return nil
+}
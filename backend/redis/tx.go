@@ -0,0 +1,178 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxWatchAttempts is how often an optimistic transaction is attempted when watched keys are modified concurrently
+const maxWatchAttempts = 3
+
+// watch runs fn in an optimistic transaction watching the given keys, which need to be in the same hash slot.
+// Transactions started via tx.TxPipelined only apply if none of the keys have been modified since they were watched,
+// otherwise fn is retried.
+func (rb *redisBackend) watch(ctx context.Context, fn func(tx *watchedTx) error, keys ...string) error {
+	var err error
+	for attempt := 0; attempt < maxWatchAttempts; attempt++ {
+		err = rb.rdb.Watch(ctx, rb.watched(fn, keys[0]), keys...)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (rb *redisBackend) watched(fn func(tx *watchedTx) error, key string) func(tx *redis.Tx) error {
+	return func(tx *redis.Tx) error {
+		return fn(&watchedTx{Tx: tx, rb: rb, key: key})
+	}
+}
+
+// watchedTx is a transaction watching keys in the hash slot of key
+type watchedTx struct {
+	*redis.Tx
+
+	rb  *redisBackend
+	key string
+}
+
+// TxPipelined executes the commands queued by fn in the transaction, see txPipelined
+func (tx *watchedTx) TxPipelined(ctx context.Context, fn func(p redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return tx.rb.txPipelined(ctx, tx.Tx, tx.key, fn)
+}
+
+// txPipelined executes the commands queued by fn in a transaction. If tx is given, the transaction only applies if the
+// keys watched by tx, which are in the hash slot of key, haven't been modified.
+//
+// Redis Cluster only executes transactions whose keys are all in the same hash slot. In cluster mode, the commands are
+// grouped by hash tag instead, and every group is executed in its own transaction: first the group of key, the only
+// one depending on the watched keys, then the groups of other instances, and last the group of the namespace, as its
+// task queues and indexes refer to the data of instances. This isn't atomic: if executing a group fails, the groups
+// before it have been applied.
+func (rb *redisBackend) txPipelined(ctx context.Context, tx *redis.Tx, key string, fn func(p redis.Pipeliner) error) ([]redis.Cmder, error) {
+	if !rb.options.ClusterMode {
+		if tx != nil {
+			return tx.TxPipelined(ctx, fn)
+		}
+
+		return rb.rdb.TxPipelined(ctx, fn)
+	}
+
+	p := rb.collector.Pipeline()
+	if err := fn(p); err != nil {
+		return nil, err
+	}
+
+	// The collector only returns the queued commands
+	cmds, _ := p.Exec(ctx)
+
+	watchedTag := hashTag(key)
+	groups := map[string][]redis.Cmder{}
+	tags := []string{}
+	if tx != nil {
+		// Always execute the group of the watched keys, so that the transaction fails if they have been modified
+		tags = append(tags, watchedTag)
+	}
+
+	for _, cmd := range cmds {
+		tag := hashTag(cmdKey(cmd))
+		if _, ok := groups[tag]; !ok && (tx == nil || tag != watchedTag) {
+			tags = append(tags, tag)
+		}
+
+		groups[tag] = append(groups[tag], cmd)
+	}
+
+	rank := func(tag string) int {
+		switch {
+		case tx != nil && tag == watchedTag:
+			return 0
+		case tag == rb.keys.namespaceTag():
+			return 2
+		default:
+			return 1
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return rank(tags[i]) < rank(tags[j])
+	})
+
+	for _, tag := range tags {
+		group := groups[tag]
+		queue := func(p redis.Pipeliner) error {
+			if len(group) == 0 {
+				// EXEC of an empty transaction isn't sent, check a watched key instead
+				p.Exists(ctx, key)
+			}
+
+			for _, cmd := range group {
+				if err := p.Process(ctx, cmd); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		var err error
+		if tx != nil && tag == watchedTag {
+			_, err = tx.TxPipelined(ctx, queue)
+		} else {
+			_, err = rb.rdb.TxPipelined(ctx, queue)
+		}
+
+		if err != nil {
+			return cmds, err
+		}
+	}
+
+	return cmds, nil
+}
+
+// cmdKey returns the first key of the given command. Commands queued in transactions start with their key, scripts
+// list their keys after the script and the number of keys.
+func cmdKey(cmd redis.Cmder) string {
+	args := cmd.Args()
+
+	i := 1
+	switch cmd.Name() {
+	case "eval", "evalsha":
+		i = 3
+	}
+
+	if len(args) <= i {
+		return ""
+	}
+
+	return fmt.Sprint(args[i])
+}
+
+// newCollector returns a client whose pipelines only collect the queued commands, they are never sent to Redis
+func newCollector() *redis.Client {
+	c := redis.NewClient(&redis.Options{})
+	c.AddHook(collectHook{})
+
+	return c
+}
+
+type collectHook struct{}
+
+func (collectHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (collectHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return next
+}
+
+func (collectHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		return nil
+	}
+}
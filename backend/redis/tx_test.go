@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// recordHook records the transactions of a client without sending any commands to Redis
+type recordHook struct {
+	txs [][]string
+}
+
+func (h *recordHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *recordHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		return nil
+	}
+}
+
+func (h *recordHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		var tx []string
+		for _, cmd := range cmds {
+			if name := cmd.Name(); name != "multi" && name != "exec" {
+				tx = append(tx, fmt.Sprintf("%v %v", name, cmdKey(cmd)))
+			}
+		}
+
+		h.txs = append(h.txs, tx)
+		return nil
+	}
+}
+
+func Test_TxPipelined_ClusterMode(t *testing.T) {
+	ctx := context.Background()
+
+	client := redis.NewClient(&redis.Options{})
+	h := &recordHook{}
+	client.AddHook(h)
+
+	keys := newKeys("default", true)
+	rb := &redisBackend{
+		rdb:       client,
+		options:   &RedisOptions{ClusterMode: true},
+		keys:      keys,
+		collector: newCollector(),
+	}
+
+	instance := core.NewWorkflowInstance("a", "1")
+	other := core.NewWorkflowInstance("b", "1")
+
+	queue := func(p redis.Pipeliner) error {
+		p.SAdd(ctx, keys.instancesActive(), "a")
+		p.Set(ctx, keys.instanceKey(other), "", 0)
+		deleteCmd.Run(ctx, p, []string{keys.historyKey(instance)}, "a")
+		p.Set(ctx, keys.instanceKey(instance), "", 0)
+
+		return nil
+	}
+
+	t.Run("groups by hash tag", func(t *testing.T) {
+		h.txs = nil
+
+		_, err := rb.txPipelined(ctx, nil, "", queue)
+		require.NoError(t, err)
+
+		// Namespace keys are updated last
+		require.Equal(t, [][]string{
+			{"set " + keys.instanceKey(other)},
+			{"evalsha " + keys.historyKey(instance), "set " + keys.instanceKey(instance)},
+			{"sadd " + keys.instancesActive()},
+		}, h.txs)
+	})
+
+	t.Run("watched keys first", func(t *testing.T) {
+		h.txs = nil
+
+		err := rb.watch(ctx, func(tx *watchedTx) error {
+			_, err := tx.TxPipelined(ctx, queue)
+			return err
+		}, keys.instanceKey(instance))
+		require.NoError(t, err)
+
+		require.Equal(t, [][]string{
+			{"evalsha " + keys.historyKey(instance), "set " + keys.instanceKey(instance)},
+			{"set " + keys.instanceKey(other)},
+			{"sadd " + keys.instancesActive()},
+		}, h.txs)
+	})
+
+	t.Run("without cluster mode", func(t *testing.T) {
+		h.txs = nil
+		rb := &redisBackend{rdb: client, options: &RedisOptions{}, keys: newKeys("default", false)}
+
+		_, err := rb.txPipelined(ctx, nil, "", queue)
+		require.NoError(t, err)
+		require.Len(t, h.txs, 1)
+	})
+}
@@ -5,8 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
-	"time"
 
+	"github.com/cschleiden/go-workflows/backend"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
 	"github.com/cschleiden/go-workflows/internal/task"
@@ -24,26 +24,35 @@ import (
 // - Remove event from future event set and delete event data
 //
 // KEYS[1] - future event set key
-// KEYS[2] - workflow task queue stream
+// KEYS[2] - workflow task queue stream, used for events without a stored stream
 // KEYS[3] - workflow task queue set
 // ARGV[1] - current timestamp for zrange
+// ARGV[2] - pending events key prefix
 //
-// Note: this does not work with Redis Cluster since not all keys are passed into the script.
+// Note: not all keys are passed into the script, which Redis Cluster doesn't allow. In cluster mode, due events are
+// claimed with claimFutureEventsCmd instead.
 var futureEventsCmd = redis.NewScript(`
 	-- Find events which should become visible now
 	local events = redis.call("ZRANGE", KEYS[1], "-inf", ARGV[1], "BYSCORE")
 	for i = 1, #events do
 		local instanceSegment = redis.call("HGET", events[i], "instance")
 
-		-- Add event to pending event stream
+		-- Add event to pending event stream. Delayed starts don't have an event, their started event is already pending
 		local eventData = redis.call("HGET", events[i], "event")
-		local pending_events_key = "pending-events:" .. instanceSegment
-		redis.call("XADD", pending_events_key, "*", "event", eventData)
+		if eventData then
+			local pending_events_key = ARGV[2] .. instanceSegment
+			redis.call("XADD", pending_events_key, "*", "event", eventData)
+		end
+
+		-- Try to queue workflow task in the stream for the instance's priority
+		local stream = redis.call("HGET", events[i], "stream")
+		if not stream then
+			stream = KEYS[2]
+		end
 
-		-- Try to queue workflow task
 		local already_queued = redis.call("SADD", KEYS[3], instanceSegment)
 		if already_queued ~= 0 then
-			redis.call("XADD", KEYS[2], "*", "id", instanceSegment, "data", "")
+			redis.call("XADD", stream, "*", "id", instanceSegment, "data", "")
 		end
 
 		-- Delete event hash data
@@ -54,23 +63,114 @@ var futureEventsCmd = redis.NewScript(`
 	return #events
 `)
 
-func (rb *redisBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, error) {
-	// Check for future events
-	now := time.Now().UnixMilli()
+// Claim due future events and return them. Claimed events become due again after the claim timeout, so that they are
+// not lost if the claiming worker fails before it has moved them to their instances.
+//
+// KEYS[1] - future event set key
+// ARGV[1] - current timestamp for zrange
+// ARGV[2] - claim timeout timestamp
+//
+// Returns the future event key, instance segment, event data, and workflow task queue stream of every event.
+var claimFutureEventsCmd = redis.NewScript(`
+	local events = redis.call("ZRANGE", KEYS[1], "-inf", ARGV[1], "BYSCORE")
+	local claimed = {}
+	for i = 1, #events do
+		local data = redis.call("HMGET", events[i], "instance", "event", "stream")
+		redis.call("ZADD", KEYS[1], ARGV[2], events[i])
+
+		table.insert(claimed, events[i])
+		table.insert(claimed, data[1] or "")
+		table.insert(claimed, data[2] or "")
+		table.insert(claimed, data[3] or "")
+	end
+
+	return claimed
+`)
+
+// scheduleFutureEvents moves due future events to the pending events of their instances and queues workflow tasks
+// for them
+func (rb *redisBackend) scheduleFutureEvents(ctx context.Context) error {
+	now := rb.options.Clock.Now().UnixMilli()
 	nowStr := strconv.FormatInt(now, 10)
 
-	queueKeys := rb.workflowQueue.Keys()
+	queueKeys := rb.workflowQueue.Keys(route{})
 
-	if _, err := futureEventsCmd.Run(ctx, rb.rdb, []string{
-		futureEventsKey(),
-		queueKeys.StreamKey,
-		queueKeys.SetKey,
-	}, nowStr).Result(); err != nil && err != redis.Nil {
+	if !rb.options.ClusterMode {
+		if _, err := futureEventsCmd.Run(ctx, rb.rdb, []string{
+			rb.keys.futureEventsKey(),
+			queueKeys.StreamKey,
+			queueKeys.SetKey,
+		}, nowStr, rb.keys.pendingEventsKeyPrefix()).Result(); err != nil && err != redis.Nil {
+			return err
+		}
+
+		return nil
+	}
+
+	// The pending events of the instances are in other hash slots than the future events. Claim the due events first,
+	// then move them one by one. If this fails midway, the remaining events are claimed again once the claim expires,
+	// moved events might be added to their instance twice.
+	claimTimeout := strconv.FormatInt(now+rb.options.WorkflowLockTimeout.Milliseconds(), 10)
+	claimed, err := claimFutureEventsCmd.Run(ctx, rb.rdb, []string{rb.keys.futureEventsKey()}, nowStr, claimTimeout).StringSlice()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	for i := 0; i+3 < len(claimed); i += 4 {
+		key, segment, eventData, stream := claimed[i], claimed[i+1], claimed[i+2], claimed[i+3]
+
+		if segment == "" {
+			// The event data has been removed, drop the event
+			if err := rb.rdb.ZRem(ctx, rb.keys.futureEventsKey(), key).Err(); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// Delayed starts don't have an event, their started event is already pending
+		if eventData != "" {
+			if err := rb.rdb.XAdd(ctx, &redis.XAddArgs{
+				Stream: rb.keys.pendingEventsKeyFromSegment(segment),
+				ID:     "*",
+				Values: map[string]interface{}{
+					"event": eventData,
+				},
+			}).Err(); err != nil {
+				return err
+			}
+		}
+
+		if stream == "" {
+			stream = queueKeys.StreamKey
+		}
+
+		if _, err := rb.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			enqueueCmd.Run(ctx, p, []string{queueKeys.SetKey, stream}, segment, "")
+			p.Del(ctx, key)
+			p.ZRem(ctx, rb.keys.futureEventsKey(), key)
+
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rb *redisBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, error) {
+	return rb.GetWorkflowTaskFromQueues(ctx, nil)
+}
+
+func (rb *redisBackend) GetWorkflowTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Workflow, error) {
+	// Check for future events
+	if err := rb.scheduleFutureEvents(ctx); err != nil {
 		return nil, fmt.Errorf("checking future events: %w", err)
 	}
 
 	// Try to get a workflow task, this locks the instance when it dequeues one
-	instanceTask, err := rb.workflowQueue.Dequeue(ctx, rb.rdb, rb.options.WorkflowLockTimeout, rb.options.BlockTimeout)
+	instanceTask, err := rb.workflowQueue.Dequeue(ctx, rb.rdb, queues, rb.options.WorkflowLockTimeout, rb.options.BlockTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -79,13 +179,29 @@ func (rb *redisBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, er
 		return nil, nil
 	}
 
-	instanceState, err := readInstance(ctx, rb.rdb, instanceKeyFromSegment(instanceTask.ID))
+	instanceState, err := readInstance(ctx, rb.rdb, rb.keys.instanceKeyFromSegment(instanceTask.ID))
 	if err != nil {
 		return nil, fmt.Errorf("reading workflow instance: %w", err)
 	}
 
+	if dropped, err := rb.dropPausedTask(ctx, instanceTask.TaskID, instanceState); err != nil || dropped {
+		return nil, err
+	}
+
+	if dropped, err := rb.dropDeadLetteredTask(ctx, instanceTask.TaskID, instanceState); err != nil || dropped {
+		return nil, err
+	}
+
+	if dropped, err := rb.dropDelayedTask(ctx, instanceTask.TaskID, instanceState); err != nil || dropped {
+		return nil, err
+	}
+
+	if requeued, err := rb.requeueForBuildID(ctx, instanceTask.TaskID, instanceState); err != nil || requeued {
+		return nil, err
+	}
+
 	// Read all pending events for this instance
-	msgs, err := rb.rdb.XRange(ctx, pendingEventsKey(instanceState.Instance), "-", "+").Result()
+	msgs, err := rb.rdb.XRange(ctx, rb.keys.pendingEventsKey(instanceState.Instance), "-", "+").Result()
 	if err != nil {
 		return nil, fmt.Errorf("reading event stream: %w", err)
 	}
@@ -101,6 +217,24 @@ func (rb *redisBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, er
 		newEvents = append(newEvents, event)
 	}
 
+	if len(msgs) == 0 {
+		// In cluster mode, tasks can be left without pending events when the completion of an earlier task was only
+		// partially applied
+		if _, err := rb.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+			_, err := rb.workflowQueue.Complete(ctx, p, instanceTask.TaskID)
+			return err
+		}); err != nil {
+			return nil, fmt.Errorf("dropping task without pending events: %w", err)
+		}
+
+		return nil, nil
+	}
+
+	attempt, err := rb.rdb.HIncrBy(ctx, rb.keys.instanceTaskAttemptsKey(), instanceTask.ID, 1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("counting workflow task attempts: %w", err)
+	}
+
 	return &task.Workflow{
 		ID:                    instanceTask.TaskID,
 		WorkflowInstance:      instanceState.Instance,
@@ -109,6 +243,7 @@ func (rb *redisBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, er
 		LastSequenceID:        instanceState.LastSequenceID,
 		NewEvents:             newEvents,
 		CustomData:            msgs[len(msgs)-1].ID, // Id of last pending message in stream at this point
+		Attempt:               int(attempt),
 	}, nil
 }
 
@@ -146,6 +281,25 @@ var requeueInstanceCmd = redis.NewScript(`
 	return true
 `)
 
+// requeueInstance queues a workflow task for the given instance if it has pending events. It needs to run after the
+// task of the instance has been completed, events added while the task was locked didn't queue another task.
+func (rb *redisBackend) requeueInstance(ctx context.Context, instance *core.WorkflowInstance, r route) error {
+	pending, err := rb.rdb.XLen(ctx, rb.keys.pendingEventsKey(instance)).Result()
+	if err != nil {
+		return fmt.Errorf("reading pending events: %w", err)
+	}
+
+	if pending == 0 {
+		return nil
+	}
+
+	_, err = rb.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		return rb.workflowQueue.Enqueue(ctx, p, r, instanceSegment(instance), nil)
+	})
+
+	return err
+}
+
 func (rb *redisBackend) CompleteWorkflowTask(
 	ctx context.Context,
 	task *task.Workflow,
@@ -154,115 +308,159 @@ func (rb *redisBackend) CompleteWorkflowTask(
 	executedEvents, activityEvents, timerEvents []*history.Event,
 	workflowEvents []history.WorkflowEvent,
 ) error {
-	instanceState, err := readInstance(ctx, rb.rdb, instanceKey(instance))
-	if err != nil {
-		return err
-	}
-
-	// Check-point the workflow. We guarantee that no other worker is working on this workflow instance at this point via the
-	// task queue, so we don't need to WATCH the keys, we just need to make sure all commands are executed atomically to prevent
-	// a worker crashing in the middle of this execution.
-	p := rb.rdb.TxPipeline()
-
-	// Add executed events to the history
-	if err := addEventsToHistoryStreamP(ctx, p, historyKey(instance), executedEvents); err != nil {
-		return fmt.Errorf("serializing : %w", err)
-	}
+	var (
+		instanceState *instanceState
+		completeCmd   *redis.Cmd
+		executedCmds  []redis.Cmder
+	)
 
-	for _, event := range executedEvents {
-		switch event.Type {
-		case history.EventType_TimerCanceled:
-			removeFutureEventP(ctx, p, instance, event)
+	// Watch the instance, its state is updated by every completed workflow task. This prevents applying the same task
+	// twice, if the task lock expired and another worker has picked up the task in the meantime.
+	err := rb.watch(ctx, func(tx *watchedTx) error {
+		var err error
+		instanceState, err = readInstance(ctx, tx, rb.keys.instanceKey(instance))
+		if err != nil {
+			return err
 		}
-	}
 
-	// Schedule timers
-	for _, timerEvent := range timerEvents {
-		if err := addFutureEventP(ctx, p, instance, timerEvent); err != nil {
-			return err
+		// The task has already been completed, for example by another worker after the lock of this worker expired
+		if instanceState.LastSequenceID != task.LastSequenceID {
+			return fmt.Errorf("workflow instance has been updated since the workflow task was started: %w", backend.ErrWorkflowTaskLost)
 		}
-	}
 
-	// Send new workflow events to the respective streams
-	groupedEvents := history.EventsByWorkflowInstance(workflowEvents)
-	for targetInstance, events := range groupedEvents {
-		// Insert pending events for target instance
-		for _, m := range events {
-			m := m
-
-			if m.HistoryEvent.Type == history.EventType_WorkflowExecutionStarted {
-				// Create new instance
-				a := m.HistoryEvent.Attributes.(*history.ExecutionStartedAttributes)
-				if err := createInstanceP(ctx, p, m.WorkflowInstance, a.Metadata, true); err != nil {
+		// Check-point the workflow. All commands are executed atomically to prevent a worker crashing in the middle of
+		// this execution from leaving partial state. In cluster mode, only the commands for the instance are.
+		executedCmds, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			// Add executed events to the history
+			if err := addEventsToHistoryStreamP(ctx, p, rb.keys.historyKey(instance), executedEvents); err != nil {
+				return fmt.Errorf("serializing : %w", err)
+			}
+
+			for _, event := range executedEvents {
+				switch event.Type {
+				case history.EventType_TimerCanceled:
+					rb.removeFutureEventP(ctx, p, instance, event)
+				}
+			}
+
+			// Schedule timers
+			for _, timerEvent := range timerEvents {
+				if err := rb.addFutureEventP(ctx, p, instance, instanceState.route(), timerEvent); err != nil {
 					return err
 				}
 			}
 
-			// Add pending event to stream
-			if err := addEventToStreamP(ctx, p, pendingEventsKey(&targetInstance), m.HistoryEvent); err != nil {
+			// Send new workflow events to the respective streams
+			groupedEvents := history.EventsByWorkflowInstance(workflowEvents)
+			targetRoutes, err := rb.targetInstanceRoutes(ctx, instance, groupedEvents)
+			if err != nil {
 				return err
 			}
-		}
 
-		// Try to enqueue workflow task
-		if targetInstance.InstanceID != instance.InstanceID || targetInstance.ExecutionID != instance.ExecutionID {
-			if err := rb.workflowQueue.Enqueue(ctx, p, instanceSegment(&targetInstance), nil); err != nil {
-				return fmt.Errorf("enqueuing workflow task: %w", err)
+			for targetInstance, events := range groupedEvents {
+				// Insert pending events for target instance
+				for _, m := range events {
+					m := m
+
+					if m.HistoryEvent.Type == history.EventType_WorkflowExecutionStarted {
+						// Create new instance
+						a := m.HistoryEvent.Attributes.(*history.ExecutionStartedAttributes)
+						if err := rb.createInstanceP(ctx, p, m.WorkflowInstance, a, nil, true); err != nil {
+							return err
+						}
+
+						targetRoutes[targetInstance] = route{queue: a.Queue, priority: a.Priority}
+					}
+
+					// Add pending event to stream
+					if err := addEventToStreamP(ctx, p, rb.keys.pendingEventsKey(&targetInstance), m.HistoryEvent); err != nil {
+						return err
+					}
+				}
+
+				// Try to enqueue workflow task
+				if targetInstance.InstanceID != instance.InstanceID || targetInstance.ExecutionID != instance.ExecutionID {
+					if err := rb.workflowQueue.Enqueue(ctx, p, targetRoutes[targetInstance], instanceSegment(&targetInstance), nil); err != nil {
+						return fmt.Errorf("enqueuing workflow task: %w", err)
+					}
+				}
 			}
-		}
-	}
 
-	instanceState.State = state
+			instanceState.State = state
 
-	if state == core.WorkflowInstanceStateFinished || state == core.WorkflowInstanceStateContinuedAsNew {
-		t := time.Now()
-		instanceState.CompletedAt = &t
+			p.HDel(ctx, rb.keys.instanceTaskAttemptsKey(), instanceSegment(instance))
 
-		removeActiveInstanceExecutionP(ctx, p, instance)
-	}
+			if state == core.WorkflowInstanceStateFinished || state == core.WorkflowInstanceStateContinuedAsNew {
+				t := rb.options.Clock.Now()
+				instanceState.CompletedAt = &t
 
-	if len(executedEvents) > 0 {
-		instanceState.LastSequenceID = executedEvents[len(executedEvents)-1].SequenceID
-	}
+				rb.removeActiveInstanceExecutionP(ctx, p, instance)
 
-	if err := updateInstanceP(ctx, p, instance, instanceState); err != nil {
-		return fmt.Errorf("updating workflow instance: %w", err)
-	}
+				// Finished instances don't receive workflow tasks anymore, drop their build ID pin
+				p.HDel(ctx, rb.keys.instanceBuildIDsKey(), instanceSegment(instance))
+			}
 
-	// Store activity data
-	for _, activityEvent := range activityEvents {
-		if err := rb.activityQueue.Enqueue(ctx, p, activityEvent.ID, &activityData{
-			Instance: instance,
-			ID:       activityEvent.ID,
-			Event:    activityEvent,
-		}); err != nil {
-			return fmt.Errorf("queueing activity task: %w", err)
-		}
-	}
+			if len(executedEvents) > 0 {
+				instanceState.LastSequenceID = executedEvents[len(executedEvents)-1].SequenceID
+			}
 
-	// Remove executed pending events
-	if task.CustomData != nil {
-		lastPendingEventMessageID := task.CustomData.(string)
-		removePendingEventsCmd.Run(ctx, p, []string{pendingEventsKey(instance)}, lastPendingEventMessageID)
-	}
+			for _, event := range executedEvents {
+				if event.Type == history.EventType_SearchAttributesUpserted {
+					a := event.Attributes.(*history.SearchAttributesUpsertedAttributes)
+					if instanceState.SearchAttributes == nil {
+						instanceState.SearchAttributes = make(core.SearchAttributes, len(a.SearchAttributes))
+					}
 
-	// Complete workflow task and unlock instance.
-	completeCmd, err := rb.workflowQueue.Complete(ctx, p, task.ID)
-	if err != nil {
-		return fmt.Errorf("completing workflow task: %w", err)
-	}
+					for name, sa := range a.SearchAttributes {
+						instanceState.SearchAttributes[name] = sa
+					}
+				}
+			}
 
-	// If there are pending events, queue the instance again
-	keyInfo := rb.workflowQueue.Keys()
-	requeueInstanceCmd.Run(ctx, p,
-		[]string{pendingEventsKey(instance), keyInfo.StreamKey, keyInfo.SetKey},
-		instanceSegment(instance),
-	)
+			if err := rb.updateInstanceP(ctx, p, instance, instanceState); err != nil {
+				return fmt.Errorf("updating workflow instance: %w", err)
+			}
 
-	// Commit transaction
-	executedCmds, err := p.Exec(ctx)
+			// Store activity data
+			for _, activityEvent := range activityEvents {
+				if err := rb.activityQueue.Enqueue(ctx, p, activityRoute(instanceState, activityEvent), activityEvent.ID, &activityData{
+					Instance: instance,
+					ID:       activityEvent.ID,
+					Event:    activityEvent,
+				}); err != nil {
+					return fmt.Errorf("queueing activity task: %w", err)
+				}
+			}
+
+			// Remove executed pending events
+			if task.CustomData != nil {
+				lastPendingEventMessageID := task.CustomData.(string)
+				removePendingEventsCmd.Run(ctx, p, []string{rb.keys.pendingEventsKey(instance)}, lastPendingEventMessageID)
+			}
+
+			// Complete workflow task and unlock instance.
+			completeCmd, err = rb.workflowQueue.Complete(ctx, p, task.ID)
+			if err != nil {
+				return fmt.Errorf("completing workflow task: %w", err)
+			}
+
+			// If there are pending events, queue the instance again. The pending events and the task queue are in
+			// different hash slots in cluster mode, the instance is queued after the transaction then.
+			if !rb.options.ClusterMode {
+				keyInfo := rb.workflowQueue.Keys(instanceState.route())
+				requeueInstanceCmd.Run(ctx, p,
+					[]string{rb.keys.pendingEventsKey(instance), keyInfo.StreamKey, keyInfo.SetKey},
+					instanceSegment(instance),
+				)
+			}
+
+			return nil
+		})
+
+		return err
+	}, rb.keys.instanceKey(instance))
 	if err != nil {
-		if err := completeCmd.Err(); err != nil && err == redis.Nil {
+		if completeCmd != nil && completeCmd.Err() == redis.Nil {
 			return fmt.Errorf("could not complete workflow task: %w", err)
 		}
 
@@ -275,6 +473,12 @@ func (rb *redisBackend) CompleteWorkflowTask(
 		return fmt.Errorf("completing workflow task: %w", err)
 	}
 
+	if rb.options.ClusterMode {
+		if err := rb.requeueInstance(ctx, instance, instanceState.route()); err != nil {
+			return fmt.Errorf("queueing workflow task: %w", err)
+		}
+	}
+
 	if state == core.WorkflowInstanceStateFinished || state == core.WorkflowInstanceStateContinuedAsNew {
 		// Trace workflow completion
 		ctx, err = (&tracing.TracingContextPropagator{}).Extract(ctx, instanceState.Metadata)
@@ -289,23 +493,62 @@ func (rb *redisBackend) CompleteWorkflowTask(
 		span.End()
 
 		if rb.options.AutoExpiration > 0 {
-			if err := setWorkflowInstanceExpiration(ctx, rb.rdb, instance, rb.options.AutoExpiration); err != nil {
+			if err := rb.setWorkflowInstanceExpiration(ctx, rb.rdb, instance, rb.options.AutoExpiration); err != nil {
 				return fmt.Errorf("setting workflow instance expiration: %w", err)
 			}
 		}
+
+		if rb.options.Archiver != nil {
+			rb.tryArchiveHistory(ctx, instance)
+		}
 	}
 
 	return nil
 }
 
-func (rb *redisBackend) addWorkflowInstanceEventP(ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance, event *history.Event) error {
+// targetInstanceRoutes returns the routes of the workflow tasks of existing workflow instances receiving events from
+// the given instance
+func (rb *redisBackend) targetInstanceRoutes(
+	ctx context.Context, instance *core.WorkflowInstance, groupedEvents map[core.WorkflowInstance][]history.WorkflowEvent,
+) (map[core.WorkflowInstance]route, error) {
+	routes := make(map[core.WorkflowInstance]route, len(groupedEvents))
+
+	for targetInstance, events := range groupedEvents {
+		if targetInstance.InstanceID == instance.InstanceID && targetInstance.ExecutionID == instance.ExecutionID {
+			continue
+		}
+
+		if len(events) > 0 && events[0].HistoryEvent.Type == history.EventType_WorkflowExecutionStarted {
+			// New instances are created with the queue and priority of the started event
+			continue
+		}
+
+		targetInstance := targetInstance
+		state, err := readInstance(ctx, rb.rdb, rb.keys.instanceKey(&targetInstance))
+		if err != nil {
+			if err == backend.ErrInstanceNotFound {
+				continue
+			}
+
+			return nil, fmt.Errorf("reading target workflow instance: %w", err)
+		}
+
+		routes[targetInstance] = state.route()
+	}
+
+	return routes, nil
+}
+
+func (rb *redisBackend) addWorkflowInstanceEventP(
+	ctx context.Context, p redis.Pipeliner, instance *core.WorkflowInstance, r route, event *history.Event,
+) error {
 	// Add event to pending events for instance
-	if err := addEventToStreamP(ctx, p, pendingEventsKey(instance), event); err != nil {
+	if err := addEventToStreamP(ctx, p, rb.keys.pendingEventsKey(instance), event); err != nil {
 		return err
 	}
 
 	// Queue workflow task
-	if err := rb.workflowQueue.Enqueue(ctx, p, instanceSegment(instance), nil); err != nil {
+	if err := rb.workflowQueue.Enqueue(ctx, p, r, instanceSegment(instance), nil); err != nil {
 		return fmt.Errorf("queueing workflow: %w", err)
 	}
 
@@ -0,0 +1,11 @@
+package backend
+
+import "context"
+
+// ActivityTaskReleaser is implemented by backends that can release the lock of an activity task before it expires.
+// Released tasks are delivered again right away and are marked as redelivered. Workers release the tasks of activities
+// that are still running when a graceful shutdown times out.
+type ActivityTaskReleaser interface {
+	// ReleaseActivityTask releases the lock of the activity task locked by this worker
+	ReleaseActivityTask(ctx context.Context, activityID string) error
+}
@@ -0,0 +1,105 @@
+package replication
+
+import (
+	"context"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+type ChangeType int
+
+const (
+	ChangeTypeInstanceCreated ChangeType = iota
+	ChangeTypeInstanceCanceled
+	ChangeTypeInstancePaused
+	ChangeTypeInstanceResumed
+	ChangeTypeInstanceRemoved
+	ChangeTypeSignalReceived
+	ChangeTypeWorkflowTaskCompleted
+	ChangeTypeActivityTaskCompleted
+	ChangeTypeInstanceTerminated
+	ChangeTypeActivityTaskPending
+	ChangeTypeInstanceDeadLettered
+	ChangeTypeInstanceRetried
+)
+
+func (ct ChangeType) String() string {
+	switch ct {
+	case ChangeTypeInstanceCreated:
+		return "InstanceCreated"
+	case ChangeTypeInstanceCanceled:
+		return "InstanceCanceled"
+	case ChangeTypeInstancePaused:
+		return "InstancePaused"
+	case ChangeTypeInstanceResumed:
+		return "InstanceResumed"
+	case ChangeTypeInstanceRemoved:
+		return "InstanceRemoved"
+	case ChangeTypeSignalReceived:
+		return "SignalReceived"
+	case ChangeTypeWorkflowTaskCompleted:
+		return "WorkflowTaskCompleted"
+	case ChangeTypeActivityTaskCompleted:
+		return "ActivityTaskCompleted"
+	case ChangeTypeInstanceTerminated:
+		return "InstanceTerminated"
+	case ChangeTypeActivityTaskPending:
+		return "ActivityTaskPending"
+	case ChangeTypeInstanceDeadLettered:
+		return "InstanceDeadLettered"
+	case ChangeTypeInstanceRetried:
+		return "InstanceRetried"
+	default:
+		return "Unknown"
+	}
+}
+
+// Change is a change of a workflow instance committed by the primary backend
+type Change struct {
+	// Sequence numbers the changes of a backend in the order they were recorded, starting at 1
+	Sequence uint64
+
+	Type ChangeType
+
+	// Instance is the workflow instance that changed. For signals, only the instance ID is known.
+	Instance *workflow.Instance
+
+	// Event is the event added by the change, for example, the started event of a created instance or the result of
+	// a completed activity. Not set for removed instances and completed workflow tasks.
+	Event *history.Event
+
+	// ActivityID is the ID of the completed or pending activity task
+	ActivityID string
+
+	// DeadLetterReason is the reason a dead-lettered instance was moved to the dead-letter state
+	DeadLetterReason string
+
+	// State is the state of the instance after a completed workflow task
+	State core.WorkflowInstanceState
+
+	// ExecutedEvents are the events added to the history of the instance by a completed workflow task
+	ExecutedEvents []*history.Event
+
+	// ActivityEvents and TimerEvents are the activities and timers scheduled by a completed workflow task
+	ActivityEvents []*history.Event
+	TimerEvents    []*history.Event
+
+	// WorkflowEvents are the events for this or other instances created by a completed workflow task
+	WorkflowEvents []history.WorkflowEvent
+}
+
+// Replica receives the changes committed by the primary backend
+type Replica interface {
+	// Replicate applies the given changes, in order. If it returns an error, the same changes are passed again after
+	// the retry interval, implementations need to be idempotent.
+	Replicate(ctx context.Context, changes []Change) error
+}
+
+// ReplicaFunc adapts a function to the Replica interface
+type ReplicaFunc func(ctx context.Context, changes []Change) error
+
+func (f ReplicaFunc) Replicate(ctx context.Context, changes []Change) error {
+	return f(ctx, changes)
+}
@@ -0,0 +1,59 @@
+package replication
+
+import "time"
+
+type Options struct {
+	// BufferSize is the maximum number of changes recorded but not yet replicated. When the buffer is full, backend
+	// operations wait until changes have been replicated. This bounds the data lost when the primary fails. Defaults
+	// to 1000.
+	BufferSize int
+
+	// BufferTimeout is the maximum time backend operations wait for space in a full buffer. Afterwards, they fail with
+	// ErrBufferFull without being applied. Defaults to five seconds.
+	BufferTimeout time.Duration
+
+	// BatchSize is the maximum number of changes passed to the replica at once. Defaults to 100.
+	BatchSize int
+
+	// RetryInterval is the time to wait before passing changes to the replica again after it returned an error.
+	// Defaults to one second.
+	RetryInterval time.Duration
+}
+
+var DefaultOptions = Options{
+	BufferSize:    1000,
+	BufferTimeout: time.Second * 5,
+	BatchSize:     100,
+	RetryInterval: time.Second,
+}
+
+type Option func(*Options)
+
+// WithBufferSize sets the maximum number of changes waiting to be replicated. See Options.BufferSize.
+func WithBufferSize(size int) Option {
+	return func(o *Options) {
+		o.BufferSize = size
+	}
+}
+
+// WithBufferTimeout sets the maximum time backend operations wait for space in a full buffer. See
+// Options.BufferTimeout.
+func WithBufferTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.BufferTimeout = timeout
+	}
+}
+
+// WithBatchSize sets the maximum number of changes passed to the replica at once
+func WithBatchSize(size int) Option {
+	return func(o *Options) {
+		o.BatchSize = size
+	}
+}
+
+// WithRetryInterval sets the time to wait before retrying to replicate changes after a failure
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.RetryInterval = interval
+	}
+}
@@ -0,0 +1,313 @@
+// Package replication provides a backend decorator that asynchronously streams the committed changes of workflow
+// instances to a replica, for example, a service shipping them to another region. Changes are buffered in memory and
+// replicated in the background, the amount of data lost when the primary fails is bounded by the buffer size.
+//
+// The package doesn't apply changes to another backend: completing tasks requires holding their locks, which the
+// backend interface only hands out to workers. Replicas are implemented by the application, for example, by
+// persisting the changes in storage that is replicated across regions already.
+package replication
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/buffer"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/decorator"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// ErrDemoted is returned for changes to a backend that has been demoted
+var ErrDemoted = errors.New("backend has been demoted")
+
+// ErrBufferFull is returned for changes that couldn't be recorded within the buffer timeout because replication
+// doesn't keep up, or hasn't been started. These changes are not applied to the backend.
+var ErrBufferFull = errors.New("replication buffer is full")
+
+type Backend struct {
+	decorator.Base
+
+	options Options
+
+	changes *buffer.Buffer[Change]
+
+	// fence is held for reading while a change is committed and recorded, and for writing to demote the backend
+	fence   sync.RWMutex
+	demoted atomic.Bool
+}
+
+var _ backend.Backend = (*Backend)(nil)
+var _ backend.RateLimiter = (*Backend)(nil)
+var _ backend.Leaser = (*Backend)(nil)
+var _ backend.LoadReporter = (*Backend)(nil)
+var _ backend.Querier = (*Backend)(nil)
+var _ backend.ScheduleStore = (*Backend)(nil)
+var _ backend.AsyncActivityCompleter = (*Backend)(nil)
+var _ backend.ActivityHeartbeater = (*Backend)(nil)
+var _ backend.QueuePoller = (*Backend)(nil)
+var _ backend.Retainer = (*Backend)(nil)
+var _ backend.IDGenerator = (*Backend)(nil)
+var _ backend.InstancePurger = (*Backend)(nil)
+var _ backend.ArchiveRetrier = (*Backend)(nil)
+
+// NewBackend wraps the given backend and replicates its committed changes to the given replica. Call Start to begin
+// replicating, pass the wrapped backend to both clients and workers.
+func NewBackend(b backend.Backend, replica Replica, opts ...Option) *Backend {
+	options := DefaultOptions
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &Backend{
+		Base:    decorator.Base{Backend: b},
+		options: options,
+		changes: buffer.New(replica.Replicate, buffer.Options{
+			Size:          options.BufferSize,
+			BatchSize:     options.BatchSize,
+			Timeout:       options.BufferTimeout,
+			RetryInterval: options.RetryInterval,
+			Logger:        b.Logger(),
+			Message:       "replicating changes",
+		}),
+	}
+}
+
+// Start starts replicating changes in the background. To stop, cancel the context passed to Start. Changes not
+// replicated at that point are lost, call Flush before to replicate them.
+func (rb *Backend) Start(ctx context.Context) error {
+	rb.changes.Start(ctx)
+
+	return nil
+}
+
+func (rb *Backend) WaitForCompletion() error {
+	rb.changes.WaitForCompletion()
+
+	return nil
+}
+
+// Lag returns the number of changes recorded but not yet replicated
+func (rb *Backend) Lag() uint64 {
+	return rb.changes.Lag()
+}
+
+// Flush waits until all changes recorded so far have been replicated
+func (rb *Backend) Flush(ctx context.Context) error {
+	return rb.changes.Flush(ctx)
+}
+
+// Demote prepares failing over to the replica. Afterwards, changes to the backend fail with ErrDemoted and no more
+// tasks are handed out. Demote then waits until all committed changes have been replicated, once it returns, the
+// replica can be promoted and workers started against it. Stop the workers of this backend first, tasks they are
+// executing cannot be completed anymore.
+func (rb *Backend) Demote(ctx context.Context) error {
+	rb.fence.Lock()
+	rb.demoted.Store(true)
+	rb.fence.Unlock()
+
+	return rb.Flush(ctx)
+}
+
+// commit runs the given operation and records the resulting change if it succeeds. Space in the buffer is reserved
+// before running the operation, so that a committed change never waits for replication, and the fence is only held
+// while the operation runs.
+func (rb *Backend) commit(ctx context.Context, change Change, op func() error) error {
+	if rb.demoted.Load() {
+		return ErrDemoted
+	}
+
+	if err := rb.changes.Reserve(ctx, 1); err != nil {
+		if errors.Is(err, buffer.ErrFull) {
+			return ErrBufferFull
+		}
+
+		return err
+	}
+
+	rb.fence.RLock()
+	defer rb.fence.RUnlock()
+
+	if rb.demoted.Load() {
+		rb.changes.Release(1)
+		return ErrDemoted
+	}
+
+	if err := op(); err != nil {
+		rb.changes.Release(1)
+		return err
+	}
+
+	rb.changes.Add(1, func(_ int, sequence uint64) Change {
+		change.Sequence = sequence
+		return change
+	})
+
+	return nil
+}
+
+func (rb *Backend) CreateWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
+	return rb.commit(ctx, Change{Type: ChangeTypeInstanceCreated, Instance: instance, Event: event}, func() error {
+		return rb.Backend.CreateWorkflowInstance(ctx, instance, event)
+	})
+}
+
+func (rb *Backend) CancelWorkflowInstance(ctx context.Context, instance *workflow.Instance, cancelEvent *history.Event) error {
+	return rb.commit(ctx, Change{Type: ChangeTypeInstanceCanceled, Instance: instance, Event: cancelEvent}, func() error {
+		return rb.Backend.CancelWorkflowInstance(ctx, instance, cancelEvent)
+	})
+}
+
+func (rb *Backend) TerminateWorkflowInstance(ctx context.Context, instance *workflow.Instance, terminateEvent *history.Event) error {
+	return rb.commit(ctx, Change{Type: ChangeTypeInstanceTerminated, Instance: instance, Event: terminateEvent}, func() error {
+		return rb.Backend.TerminateWorkflowInstance(ctx, instance, terminateEvent)
+	})
+}
+
+func (rb *Backend) PauseWorkflowInstance(ctx context.Context, instance *workflow.Instance, pauseEvent *history.Event) error {
+	return rb.commit(ctx, Change{Type: ChangeTypeInstancePaused, Instance: instance, Event: pauseEvent}, func() error {
+		return rb.Backend.PauseWorkflowInstance(ctx, instance, pauseEvent)
+	})
+}
+
+func (rb *Backend) ResumeWorkflowInstance(ctx context.Context, instance *workflow.Instance, resumeEvent *history.Event) error {
+	return rb.commit(ctx, Change{Type: ChangeTypeInstanceResumed, Instance: instance, Event: resumeEvent}, func() error {
+		return rb.Backend.ResumeWorkflowInstance(ctx, instance, resumeEvent)
+	})
+}
+
+func (rb *Backend) RetryWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	return rb.commit(ctx, Change{Type: ChangeTypeInstanceRetried, Instance: instance}, func() error {
+		return rb.Backend.RetryWorkflowInstance(ctx, instance)
+	})
+}
+
+func (rb *Backend) RemoveWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	return rb.commit(ctx, Change{Type: ChangeTypeInstanceRemoved, Instance: instance}, func() error {
+		return rb.Backend.RemoveWorkflowInstance(ctx, instance)
+	})
+}
+
+func (rb *Backend) SignalWorkflow(ctx context.Context, instanceID string, event *history.Event) error {
+	instance := core.NewWorkflowInstance(instanceID, "")
+
+	return rb.commit(ctx, Change{Type: ChangeTypeSignalReceived, Instance: instance, Event: event}, func() error {
+		return rb.Backend.SignalWorkflow(ctx, instanceID, event)
+	})
+}
+
+func (rb *Backend) GetWorkflowTask(ctx context.Context) (*task.Workflow, error) {
+	if rb.demoted.Load() {
+		return nil, nil
+	}
+
+	return rb.Backend.GetWorkflowTask(ctx)
+}
+
+func (rb *Backend) GetWorkflowTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Workflow, error) {
+	qp, ok := rb.Backend.(backend.QueuePoller)
+	if !ok {
+		return nil, backend.ErrQueuesNotSupported
+	}
+
+	if rb.demoted.Load() {
+		return nil, nil
+	}
+
+	return qp.GetWorkflowTaskFromQueues(ctx, queues)
+}
+
+func (rb *Backend) CompleteWorkflowTask(
+	ctx context.Context, t *task.Workflow, instance *workflow.Instance, state core.WorkflowInstanceState,
+	executedEvents, activityEvents, timerEvents []*history.Event, workflowEvents []history.WorkflowEvent,
+) error {
+	change := Change{
+		Type:           ChangeTypeWorkflowTaskCompleted,
+		Instance:       instance,
+		State:          state,
+		ExecutedEvents: executedEvents,
+		ActivityEvents: activityEvents,
+		TimerEvents:    timerEvents,
+		WorkflowEvents: workflowEvents,
+	}
+
+	return rb.commit(ctx, change, func() error {
+		return rb.Backend.CompleteWorkflowTask(ctx, t, instance, state, executedEvents, activityEvents, timerEvents, workflowEvents)
+	})
+}
+
+func (rb *Backend) DeadLetterWorkflowTask(ctx context.Context, t *task.Workflow, reason string) error {
+	change := Change{Type: ChangeTypeInstanceDeadLettered, Instance: t.WorkflowInstance, DeadLetterReason: reason}
+
+	return rb.commit(ctx, change, func() error {
+		return rb.Backend.DeadLetterWorkflowTask(ctx, t, reason)
+	})
+}
+
+func (rb *Backend) GetActivityTask(ctx context.Context) (*task.Activity, error) {
+	if rb.demoted.Load() {
+		return nil, nil
+	}
+
+	return rb.Backend.GetActivityTask(ctx)
+}
+
+func (rb *Backend) GetActivityTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Activity, error) {
+	qp, ok := rb.Backend.(backend.QueuePoller)
+	if !ok {
+		return nil, backend.ErrQueuesNotSupported
+	}
+
+	if rb.demoted.Load() {
+		return nil, nil
+	}
+
+	return qp.GetActivityTaskFromQueues(ctx, queues)
+}
+
+func (rb *Backend) CompleteActivityTask(ctx context.Context, instance *workflow.Instance, activityID string, event *history.Event) error {
+	change := Change{Type: ChangeTypeActivityTaskCompleted, Instance: instance, ActivityID: activityID, Event: event}
+
+	return rb.commit(ctx, change, func() error {
+		return rb.Backend.CompleteActivityTask(ctx, instance, activityID, event)
+	})
+}
+
+func (rb *Backend) SetActivityTaskPending(ctx context.Context, instance *workflow.Instance, activityID string) error {
+	ac, ok := rb.Backend.(backend.AsyncActivityCompleter)
+	if !ok {
+		return backend.ErrAsyncActivitiesNotSupported
+	}
+
+	change := Change{Type: ChangeTypeActivityTaskPending, Instance: instance, ActivityID: activityID}
+
+	return rb.commit(ctx, change, func() error {
+		return ac.SetActivityTaskPending(ctx, instance, activityID)
+	})
+}
+
+// CompletePendingActivityTask replicates completions like those of activity tasks completed by workers
+func (rb *Backend) CompletePendingActivityTask(ctx context.Context, instance *workflow.Instance, activityID string, event *history.Event) error {
+	ac, ok := rb.Backend.(backend.AsyncActivityCompleter)
+	if !ok {
+		return backend.ErrAsyncActivitiesNotSupported
+	}
+
+	change := Change{Type: ChangeTypeActivityTaskCompleted, Instance: instance, ActivityID: activityID, Event: event}
+
+	return rb.commit(ctx, change, func() error {
+		return ac.CompletePendingActivityTask(ctx, instance, activityID, event)
+	})
+}
+
+// RemoveFinishedWorkflowInstances doesn't pass bulk removal through, backend.RemoveFinishedWorkflowInstances then
+// removes instances one by one, which records their removal
+func (rb *Backend) RemoveFinishedWorkflowInstances(ctx context.Context, finishedBefore time.Time, workflowName string) (int, error) {
+	return 0, backend.ErrPurgeNotSupported
+}
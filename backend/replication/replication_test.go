@@ -0,0 +1,140 @@
+package replication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/backend/test"
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func replicationActivity(ctx context.Context) (int, error) {
+	return 42, nil
+}
+
+func replicationWorkflow(ctx workflow.Context) (int, error) {
+	return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, replicationActivity).Get(ctx)
+}
+
+func Test_ReplicationBackend(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	replica := &test.Recorder[Change]{Failures: 1}
+	b := NewBackend(sqlite.NewInMemoryBackend(), ReplicaFunc(replica.Publish), WithRetryInterval(time.Millisecond*10))
+	require.NoError(t, b.Start(ctx))
+
+	w := worker.New(b, nil)
+	require.NoError(t, w.RegisterWorkflow(replicationWorkflow))
+	require.NoError(t, w.RegisterActivity(replicationActivity))
+	require.NoError(t, w.Start(ctx))
+
+	c := client.New(b)
+	instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: uuid.NewString(),
+	}, replicationWorkflow)
+	require.NoError(t, err)
+
+	r, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+	require.NoError(t, err)
+	require.Equal(t, 42, r)
+
+	require.NoError(t, b.Flush(ctx))
+	require.Zero(t, b.Lag())
+
+	changes := replica.Items()
+
+	types := make([]ChangeType, 0, len(changes))
+	for i, c := range changes {
+		require.Equal(t, uint64(i+1), c.Sequence)
+		types = append(types, c.Type)
+	}
+
+	require.Equal(t, []ChangeType{
+		ChangeTypeInstanceCreated,
+		ChangeTypeWorkflowTaskCompleted,
+		ChangeTypeActivityTaskCompleted,
+		ChangeTypeWorkflowTaskCompleted,
+	}, types)
+
+	last := changes[len(changes)-1]
+	require.Equal(t, core.WorkflowInstanceStateFinished, last.State)
+	require.Equal(t, instance.InstanceID, last.Instance.InstanceID)
+}
+
+func Test_ReplicationBackend_Demote(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	replica := &test.Recorder[Change]{}
+	b := NewBackend(sqlite.NewInMemoryBackend(), ReplicaFunc(replica.Publish))
+	require.NoError(t, b.Start(ctx))
+
+	c := client.New(b)
+	_, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: uuid.NewString(),
+	}, replicationWorkflow)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Demote(ctx))
+	require.Zero(t, b.Lag())
+
+	_, err = c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: uuid.NewString(),
+	}, replicationWorkflow)
+	require.ErrorIs(t, err, ErrDemoted)
+
+	task, err := b.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.Nil(t, task)
+
+	require.Len(t, replica.Items(), 1)
+}
+
+func Test_ReplicationBackend_BufferFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Replication is not started, so the buffer doesn't drain
+	b := NewBackend(sqlite.NewInMemoryBackend(), ReplicaFunc((&test.Recorder[Change]{}).Publish), WithBufferSize(1), WithBufferTimeout(time.Millisecond*50))
+
+	c := client.New(b)
+	_, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: uuid.NewString(),
+	}, replicationWorkflow)
+	require.NoError(t, err)
+
+	instanceID := uuid.NewString()
+	_, err = c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: instanceID,
+	}, replicationWorkflow)
+	require.ErrorIs(t, err, ErrBufferFull)
+
+	// The rejected change has not been applied
+	_, err = b.GetWorkflowInstanceState(ctx, core.NewWorkflowInstance(instanceID, ""))
+	require.ErrorIs(t, err, backend.ErrInstanceNotFound)
+	require.Equal(t, uint64(1), b.Lag())
+
+	// Demoting doesn't wait for operations waiting for the buffer
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+			InstanceID: uuid.NewString(),
+		}, replicationWorkflow)
+		waiting <- err
+	}()
+
+	dctx, dcancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer dcancel()
+	require.ErrorIs(t, b.Demote(dctx), context.DeadlineExceeded)
+
+	require.Error(t, <-waiting)
+}
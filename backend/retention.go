@@ -0,0 +1,11 @@
+package backend
+
+import "time"
+
+// Retainer is implemented by backends that can be configured with a retention period via WithRetention. Finished
+// workflow instances are removed by the retention maintenance job once the period has passed.
+type Retainer interface {
+	// Retention returns how long finished workflow instances are kept. Zero keeps them until they are removed
+	// explicitly.
+	Retention() time.Duration
+}
@@ -0,0 +1,108 @@
+package backend
+
+import (
+	"time"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// InstanceIDReusedReason is the termination reason of executions terminated by a new execution with the same
+// instance ID
+const InstanceIDReusedReason = "terminated by new execution with the same instance ID"
+
+// CheckInstanceIDReuse determines whether CreateWorkflowInstance can create a new execution for an instance ID, given
+// the policy of the new execution and the state of the most recent existing execution. lastEvent returns the last
+// event in the history of the existing execution, it's only called if the policy depends on its outcome.
+//
+// Returns ErrInstanceAlreadyExists if the new execution must not be created. If terminate is true, the existing
+// execution is active and must be terminated in the same transaction that creates the new one, see
+// TerminateExecution.
+func CheckInstanceIDReuse(
+	policy core.InstanceIDReusePolicy, state core.WorkflowInstanceState, lastEvent func() (*history.Event, error),
+) (terminate bool, err error) {
+	if state == core.WorkflowInstanceStateActive {
+		if policy == core.InstanceIDReusePolicyTerminateExisting {
+			return true, nil
+		}
+
+		return false, ErrInstanceAlreadyExists
+	}
+
+	switch policy {
+	case core.InstanceIDReusePolicyRejectDuplicate:
+		return false, ErrInstanceAlreadyExists
+
+	case core.InstanceIDReusePolicyAllowDuplicateFailedOnly:
+		event, err := lastEvent()
+		if err != nil {
+			return false, err
+		}
+
+		if !executionFailed(event) {
+			return false, ErrInstanceAlreadyExists
+		}
+	}
+
+	return false, nil
+}
+
+func executionFailed(lastEvent *history.Event) bool {
+	if lastEvent == nil {
+		return false
+	}
+
+	switch lastEvent.Type {
+	case history.EventType_WorkflowExecutionFinished:
+		return lastEvent.Attributes.(*history.ExecutionCompletedAttributes).Error != nil
+
+	case history.EventType_WorkflowExecutionCanceled, history.EventType_WorkflowExecutionTerminated:
+		return true
+	}
+
+	return false
+}
+
+// TerminateExecution returns the events for terminating an active execution in place, without waiting for a workflow
+// task. executedEvents continue the history of the execution after lastSequenceID. startedEvent is the pending started
+// event of an execution that hasn't executed a workflow task yet, nil otherwise.
+//
+// If the execution is a sub-workflow, parentEvent has to be added to the pending events of its parent. Sub-workflows
+// of the terminated execution are not terminated.
+func TerminateExecution(
+	instance *workflow.Instance, startedEvent *history.Event, lastSequenceID int64, now time.Time,
+) (executedEvents []*history.Event, parentEvent *history.WorkflowEvent) {
+	executedEvents = []*history.Event{
+		history.NewPendingEvent(now, history.EventType_WorkflowTaskStarted, &history.WorkflowTaskStartedAttributes{}),
+	}
+
+	// Keep the history well-formed for executions terminated before their first workflow task
+	if startedEvent != nil {
+		executedEvents = append(executedEvents, startedEvent)
+	}
+
+	executedEvents = append(executedEvents, history.NewWorkflowTerminationEvent(now, InstanceIDReusedReason))
+
+	for i := range executedEvents {
+		lastSequenceID++
+		executedEvents[i].SequenceID = lastSequenceID
+	}
+
+	if instance.SubWorkflow() {
+		parentEvent = &history.WorkflowEvent{
+			WorkflowInstance: instance.Parent,
+			HistoryEvent: history.NewPendingEvent(
+				now,
+				history.EventType_SubWorkflowFailed,
+				&history.SubWorkflowFailedAttributes{
+					Error: workflowerrors.FromError(workflowerrors.ErrTerminated),
+				},
+				history.ScheduleEventID(instance.ParentEventID),
+			),
+		}
+	}
+
+	return executedEvents, parentEvent
+}
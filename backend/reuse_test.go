@@ -0,0 +1,50 @@
+package backend
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CheckInstanceIDReuse(t *testing.T) {
+	completed := history.NewPendingEvent(time.Now(), history.EventType_WorkflowExecutionFinished, &history.ExecutionCompletedAttributes{})
+	failed := history.NewPendingEvent(time.Now(), history.EventType_WorkflowExecutionFinished, &history.ExecutionCompletedAttributes{
+		Error: workflowerrors.FromError(errors.New("failed")),
+	})
+	terminated := history.NewWorkflowTerminationEvent(time.Now(), "")
+
+	tests := []struct {
+		name          string
+		policy        core.InstanceIDReusePolicy
+		state         core.WorkflowInstanceState
+		lastEvent     *history.Event
+		wantTerminate bool
+		wantErr       error
+	}{
+		{"allow duplicate, active", core.InstanceIDReusePolicyAllowDuplicate, core.WorkflowInstanceStateActive, nil, false, ErrInstanceAlreadyExists},
+		{"allow duplicate, finished", core.InstanceIDReusePolicyAllowDuplicate, core.WorkflowInstanceStateFinished, completed, false, nil},
+		{"failed only, active", core.InstanceIDReusePolicyAllowDuplicateFailedOnly, core.WorkflowInstanceStateActive, nil, false, ErrInstanceAlreadyExists},
+		{"failed only, completed", core.InstanceIDReusePolicyAllowDuplicateFailedOnly, core.WorkflowInstanceStateFinished, completed, false, ErrInstanceAlreadyExists},
+		{"failed only, failed", core.InstanceIDReusePolicyAllowDuplicateFailedOnly, core.WorkflowInstanceStateFinished, failed, false, nil},
+		{"failed only, terminated", core.InstanceIDReusePolicyAllowDuplicateFailedOnly, core.WorkflowInstanceStateFinished, terminated, false, nil},
+		{"reject duplicate, finished", core.InstanceIDReusePolicyRejectDuplicate, core.WorkflowInstanceStateFinished, completed, false, ErrInstanceAlreadyExists},
+		{"terminate existing, active", core.InstanceIDReusePolicyTerminateExisting, core.WorkflowInstanceStateActive, nil, true, nil},
+		{"terminate existing, finished", core.InstanceIDReusePolicyTerminateExisting, core.WorkflowInstanceStateFinished, completed, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terminate, err := CheckInstanceIDReuse(tt.policy, tt.state, func() (*history.Event, error) {
+				return tt.lastEvent, nil
+			})
+
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.wantTerminate, terminate)
+		})
+	}
+}
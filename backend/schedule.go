@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/cron"
+	"github.com/cschleiden/go-workflows/internal/payload"
+)
+
+// ErrScheduleAlreadyExists is returned when creating a schedule with the ID of an existing schedule
+var ErrScheduleAlreadyExists = errors.New("schedule already exists")
+
+// ErrScheduleNotFound is returned when updating or deleting a schedule that doesn't exist
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// ErrSchedulesNotSupported is returned when scheduling a workflow on a backend that doesn't implement ScheduleStore
+var ErrSchedulesNotSupported = errors.New("backend does not support schedules")
+
+// OverlapPolicy determines what happens when a schedule is due while the instance it started last is still active
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips the run
+	OverlapSkip OverlapPolicy = iota
+
+	// OverlapBuffer starts the run as soon as the previous instance has finished. Runs due while a run is buffered
+	// are skipped.
+	OverlapBuffer
+
+	// OverlapCancelPrevious cancels the previous instance and starts the run
+	OverlapCancelPrevious
+)
+
+func (p OverlapPolicy) String() string {
+	switch p {
+	case OverlapSkip:
+		return "skip"
+	case OverlapBuffer:
+		return "buffer"
+	case OverlapCancelPrevious:
+		return "cancel_previous"
+	default:
+		return "unknown"
+	}
+}
+
+// Schedule starts instances of a workflow periodically, either following a cron expression or at a fixed interval
+type Schedule struct {
+	// ID of the schedule. Instances started by the schedule get the ID "<schedule id>-<unix time of the run>".
+	ID string `json:"id"`
+
+	// Cron is a five-field cron expression, evaluated in UTC
+	Cron string `json:"cron,omitempty"`
+
+	// Interval between runs, if Cron is empty
+	Interval time.Duration `json:"interval,omitempty"`
+
+	OverlapPolicy OverlapPolicy `json:"overlap_policy"`
+
+	WorkflowName string                 `json:"workflow_name"`
+	Inputs       []payload.Payload      `json:"inputs,omitempty"`
+	Metadata     *core.WorkflowMetadata `json:"metadata,omitempty"`
+	Priority     core.Priority          `json:"priority,omitempty"`
+	Queue        core.Queue             `json:"queue,omitempty"`
+
+	// ExecutionTimeout of the instances started by the schedule, see client.WorkflowInstanceOptions.ExecutionTimeout
+	ExecutionTimeout time.Duration `json:"execution_timeout,omitempty"`
+
+	// Memo of the instances started by the schedule, see client.WorkflowInstanceOptions.Memo
+	Memo core.Memo `json:"memo,omitempty"`
+
+	// NextRunAt is the time the schedule is due next
+	NextRunAt time.Time `json:"next_run_at"`
+
+	// LastInstance is the instance started by the last run, if any
+	LastInstance *core.WorkflowInstance `json:"last_instance,omitempty"`
+}
+
+// Validate checks that exactly one of Cron and Interval is set and that the cron expression is valid
+func (s *Schedule) Validate() error {
+	switch {
+	case s.Cron != "" && s.Interval != 0:
+		return errors.New("schedule must have either a cron expression or an interval, not both")
+
+	case s.Cron != "":
+		_, err := cron.Parse(s.Cron)
+		return err
+
+	case s.Interval < 0:
+		return errors.New("schedule interval must be positive")
+
+	case s.Interval == 0:
+		return errors.New("schedule must have a cron expression or an interval")
+	}
+
+	return nil
+}
+
+// Next returns the first run of the schedule after the given time. For interval schedules, runs are aligned to
+// NextRunAt, or to the given time if NextRunAt is zero.
+func (s *Schedule) Next(after time.Time) (time.Time, error) {
+	if s.Cron != "" {
+		e, err := cron.Parse(s.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return e.Next(after.UTC())
+	}
+
+	if s.Interval <= 0 {
+		return time.Time{}, errors.New("schedule interval must be positive")
+	}
+
+	if s.NextRunAt.IsZero() || s.NextRunAt.After(after) {
+		return after.Add(s.Interval), nil
+	}
+
+	// Skip runs missed in the meantime
+	missed := after.Sub(s.NextRunAt) / s.Interval
+	return s.NextRunAt.Add((missed + 1) * s.Interval), nil
+}
+
+// ScheduleStore is implemented by backends that persist workflow schedules. Schedules are evaluated by a maintenance
+// job, see maintenance.SchedulesJob.
+type ScheduleStore interface {
+	// CreateSchedule stores a new schedule. It returns ErrScheduleAlreadyExists if a schedule with the same ID exists.
+	CreateSchedule(ctx context.Context, s *Schedule) error
+
+	// UpdateSchedule replaces an existing schedule. It returns ErrScheduleNotFound if the schedule doesn't exist.
+	UpdateSchedule(ctx context.Context, s *Schedule) error
+
+	// DeleteSchedule removes a schedule. Instances already started by the schedule are not affected. It returns
+	// ErrScheduleNotFound if the schedule doesn't exist.
+	DeleteSchedule(ctx context.Context, id string) error
+
+	// ListSchedules returns all schedules
+	ListSchedules(ctx context.Context) ([]*Schedule, error)
+}
@@ -0,0 +1,19 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/cschleiden/go-workflows/internal/history"
+)
+
+// SignalHash returns a hash of the name and payload of a signal. Backends use it to detect duplicate signals when a
+// signal deduplication window is configured.
+func SignalHash(a *history.SignalReceivedAttributes) string {
+	h := sha256.New()
+	h.Write([]byte(a.Name))
+	h.Write([]byte{0})
+	h.Write(a.Arg)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,16 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignalHash(t *testing.T) {
+	h := SignalHash(&history.SignalReceivedAttributes{Name: "signal", Arg: []byte("1")})
+
+	require.Equal(t, h, SignalHash(&history.SignalReceivedAttributes{Name: "signal", Arg: []byte("1")}))
+	require.NotEqual(t, h, SignalHash(&history.SignalReceivedAttributes{Name: "signal", Arg: []byte("2")}))
+	require.NotEqual(t, h, SignalHash(&history.SignalReceivedAttributes{Name: "signal1"}))
+}
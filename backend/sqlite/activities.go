@@ -8,17 +8,26 @@ import (
 	"github.com/cschleiden/go-workflows/internal/history"
 )
 
-func scheduleActivity(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance, event *history.Event) error {
+func scheduleActivity(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, event *history.Event) error {
 	attributes, err := history.SerializeAttributes(event.Attributes)
 	if err != nil {
 		return err
 	}
 
+	var queue core.Queue
+	if a, ok := event.Attributes.(*history.ActivityScheduledAttributes); ok {
+		queue = a.Queue
+	}
+
 	_, err = tx.ExecContext(
 		ctx,
 		`INSERT INTO activities
-			(id, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			(id, namespace, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at, queue, priority) VALUES (
+				?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+				(SELECT priority FROM instances WHERE namespace = ? AND id = ? AND execution_id = ?)
+			)`,
 		event.ID,
+		namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 		event.Type,
@@ -26,6 +35,10 @@ func scheduleActivity(ctx context.Context, tx *sql.Tx, instance *core.WorkflowIn
 		event.ScheduleEventID,
 		attributes,
 		event.VisibleAt,
+		queue.OrDefault(),
+		namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
 	)
 
 	return err
@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/log"
+)
+
+var _ backend.ArchiveRetrier = (*sqliteBackend)(nil)
+
+// archiveBatchSize is the number of unarchived instances read per query
+const archiveBatchSize = 100
+
+// archiveHistory moves the history of the given finished instance to the archiver and marks the instance as archived.
+// If archiving fails, the history is kept in the database.
+func (sb *sqliteBackend) archiveHistory(ctx context.Context, instance *core.WorkflowInstance) error {
+	tx, err := sb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	h, err := getHistory(ctx, tx, sb.options.Namespace, instance, nil)
+	if err != nil {
+		return fmt.Errorf("getting workflow history: %w", err)
+	}
+
+	// Histories moved by earlier versions aren't archived again, that would replace them with an empty history
+	if len(h) > 0 {
+		if err := sb.options.Archiver.ArchiveHistory(ctx, sb.options.Namespace, instance, h); err != nil {
+			return fmt.Errorf("archiving workflow history: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM `history` WHERE namespace = ? AND instance_id = ? AND execution_id = ?",
+		sb.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	); err != nil {
+		return fmt.Errorf("deleting archived history: %w", err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"UPDATE `instances` SET archived = 1 WHERE namespace = ? AND id = ? AND execution_id = ?",
+		sb.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	); err != nil {
+		return fmt.Errorf("marking workflow instance as archived: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// tryArchiveHistory archives the history of the given finished instance. Failures are logged, the history is archived
+// by ArchiveWorkflowInstanceHistories later.
+func (sb *sqliteBackend) tryArchiveHistory(ctx context.Context, instance *core.WorkflowInstance) {
+	if err := sb.archiveHistory(ctx, instance); err != nil {
+		sb.Logger().Error("could not archive workflow instance history", log.InstanceIDKey, instance.InstanceID,
+			log.ExecutionIDKey, instance.ExecutionID, log.ErrorKey, err)
+	}
+}
+
+func (sb *sqliteBackend) ArchiveWorkflowInstanceHistories(ctx context.Context) (int, error) {
+	if sb.options.Archiver == nil {
+		return 0, nil
+	}
+
+	archived := 0
+
+	for {
+		instances, err := sb.unarchivedInstances(ctx)
+		if err != nil {
+			return archived, err
+		}
+
+		for _, instance := range instances {
+			if err := sb.archiveHistory(ctx, instance); err != nil {
+				return archived, err
+			}
+
+			archived++
+		}
+
+		if len(instances) < archiveBatchSize {
+			return archived, nil
+		}
+	}
+}
+
+// unarchivedInstances returns up to archiveBatchSize finished instances whose history hasn't been archived
+func (sb *sqliteBackend) unarchivedInstances(ctx context.Context) ([]*core.WorkflowInstance, error) {
+	rows, err := sb.db.QueryContext(
+		ctx,
+		"SELECT id, execution_id FROM `instances` WHERE namespace = ? AND completed_at IS NOT NULL AND archived = 0 LIMIT ?",
+		sb.options.Namespace,
+		archiveBatchSize,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("selecting unarchived instances: %w", err)
+	}
+	defer rows.Close()
+
+	var instances []*core.WorkflowInstance
+	for rows.Next() {
+		var instanceID, executionID string
+		if err := rows.Scan(&instanceID, &executionID); err != nil {
+			return nil, fmt.Errorf("scanning unarchived instance: %w", err)
+		}
+
+		instances = append(instances, core.NewWorkflowInstance(instanceID, executionID))
+	}
+
+	return instances, rows.Err()
+}
+
+// getArchivedHistory returns the history of the given instance from the archiver, if it has been archived
+func (sb *sqliteBackend) getArchivedHistory(ctx context.Context, instance *core.WorkflowInstance) ([]*history.Event, error) {
+	h, err := sb.options.Archiver.GetHistory(ctx, sb.options.Namespace, instance)
+	if err != nil {
+		if errors.Is(err, backend.ErrNotArchived) {
+			return []*history.Event{}, nil
+		}
+
+		return nil, fmt.Errorf("getting archived workflow history: %w", err)
+	}
+
+	return h, nil
+}
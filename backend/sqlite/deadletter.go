@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+func (sb *sqliteBackend) DeadLetterWorkflowTask(ctx context.Context, t *task.Workflow, reason string) error {
+	res, err := sb.db.ExecContext(
+		ctx,
+		"UPDATE `instances` SET dead_letter_reason = ?, locked_until = NULL, sticky_until = NULL WHERE namespace = ? AND id = ? AND execution_id = ? AND worker = ?",
+		reason,
+		sb.options.Namespace,
+		t.WorkflowInstance.InstanceID,
+		t.WorkflowInstance.ExecutionID,
+		sb.workerName,
+	)
+	if err != nil {
+		return fmt.Errorf("dead-lettering workflow instance: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("checking for dead-lettered workflow instances: %w", err)
+	} else if n != 1 {
+		return errors.New("could not find workflow instance to dead-letter")
+	}
+
+	return nil
+}
+
+func (sb *sqliteBackend) RetryWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	tx, err := sb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE `instances` SET dead_letter_reason = NULL, task_attempts = 0 WHERE namespace = ? AND id = ? AND execution_id = ? AND dead_letter_reason IS NOT NULL",
+		sb.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	)
+	if err != nil {
+		return fmt.Errorf("retrying workflow instance: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		row := tx.QueryRowContext(
+			ctx,
+			"SELECT 1 FROM `instances` WHERE namespace = ? AND id = ? AND execution_id = ? LIMIT 1",
+			sb.options.Namespace,
+			instance.InstanceID,
+			instance.ExecutionID,
+		)
+		if err := row.Scan(new(int)); err != nil {
+			if err == sql.ErrNoRows {
+				return backend.ErrInstanceNotFound
+			}
+
+			return err
+		}
+
+		// Not dead-lettered
+		return nil
+	}
+
+	return tx.Commit()
+}
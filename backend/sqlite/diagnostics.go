@@ -11,6 +11,10 @@ import (
 
 var _ diag.Backend = (*sqliteBackend)(nil)
 
+func (sb *sqliteBackend) Namespace() string {
+	return sb.options.Namespace
+}
+
 func (sb *sqliteBackend) GetWorkflowInstances(ctx context.Context, afterInstanceID, afterExecutionID string, count int) ([]*diag.WorkflowInstanceRef, error) {
 	var err error
 	tx, err := sb.db.BeginTx(ctx, nil)
@@ -23,23 +27,28 @@ func (sb *sqliteBackend) GetWorkflowInstances(ctx context.Context, afterInstance
 	if afterInstanceID != "" {
 		rows, err = tx.QueryContext(
 			ctx,
-			`SELECT i.id, i.execution_id, i.created_at, i.completed_at
+			`SELECT i.id, i.execution_id, i.created_at, i.completed_at, i.dead_letter_reason, i.memo
 			FROM instances i
-			INNER JOIN (SELECT id, created_at FROM instances WHERE id = ? AND execution_id = ?) ii
+			INNER JOIN (SELECT id, created_at FROM instances WHERE namespace = ? AND id = ? AND execution_id = ?) ii
 				ON i.created_at < ii.created_at OR (i.created_at = ii.created_at AND i.id < ii.id)
+			WHERE i.namespace = ?
 			ORDER BY i.created_at DESC, i.id DESC
 			LIMIT ?`,
+			sb.options.Namespace,
 			afterInstanceID,
 			afterExecutionID,
+			sb.options.Namespace,
 			count,
 		)
 	} else {
 		rows, err = tx.QueryContext(
 			ctx,
-			`SELECT i.id, i.execution_id, i.created_at, i.completed_at
+			`SELECT i.id, i.execution_id, i.created_at, i.completed_at, i.dead_letter_reason, i.memo
 			FROM instances i
+			WHERE i.namespace = ?
 			ORDER BY i.created_at DESC, i.id DESC
 			LIMIT ?`,
+			sb.options.Namespace,
 			count,
 		)
 	}
@@ -53,7 +62,13 @@ func (sb *sqliteBackend) GetWorkflowInstances(ctx context.Context, afterInstance
 		var id, executionID string
 		var createdAt time.Time
 		var completedAt *time.Time
-		err = rows.Scan(&id, &executionID, &createdAt, &completedAt)
+		var deadLetterReason, memoJson sql.NullString
+		err = rows.Scan(&id, &executionID, &createdAt, &completedAt, &deadLetterReason, &memoJson)
+		if err != nil {
+			return nil, err
+		}
+
+		memo, err := unmarshalMemo(memoJson)
 		if err != nil {
 			return nil, err
 		}
@@ -64,10 +79,12 @@ func (sb *sqliteBackend) GetWorkflowInstances(ctx context.Context, afterInstance
 		}
 
 		instances = append(instances, &diag.WorkflowInstanceRef{
-			Instance:    core.NewWorkflowInstance(id, executionID),
-			CreatedAt:   createdAt,
-			CompletedAt: completedAt,
-			State:       state,
+			Instance:         core.NewWorkflowInstance(id, executionID),
+			CreatedAt:        createdAt,
+			CompletedAt:      completedAt,
+			State:            state,
+			DeadLetterReason: deadLetterReason.String,
+			Memo:             memo,
 		})
 	}
 
@@ -81,13 +98,14 @@ func (sb *sqliteBackend) GetWorkflowInstance(ctx context.Context, instance *core
 	}
 	defer tx.Rollback()
 
-	res := tx.QueryRowContext(ctx, "SELECT id, execution_id, created_at, completed_at FROM instances WHERE id = ? AND execution_id = ?", instance.InstanceID, instance.ExecutionID)
+	res := tx.QueryRowContext(ctx, "SELECT id, execution_id, created_at, completed_at, dead_letter_reason, memo FROM instances WHERE namespace = ? AND id = ? AND execution_id = ?", sb.options.Namespace, instance.InstanceID, instance.ExecutionID)
 
 	var id, executionID string
 	var createdAt time.Time
 	var completedAt *time.Time
+	var deadLetterReason, memoJson sql.NullString
 
-	err = res.Scan(&id, &executionID, &createdAt, &completedAt)
+	err = res.Scan(&id, &executionID, &createdAt, &completedAt, &deadLetterReason, &memoJson)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -96,16 +114,23 @@ func (sb *sqliteBackend) GetWorkflowInstance(ctx context.Context, instance *core
 		return nil, err
 	}
 
+	memo, err := unmarshalMemo(memoJson)
+	if err != nil {
+		return nil, err
+	}
+
 	var state core.WorkflowInstanceState
 	if completedAt != nil {
 		state = core.WorkflowInstanceStateFinished
 	}
 
 	return &diag.WorkflowInstanceRef{
-		Instance:    core.NewWorkflowInstance(id, executionID),
-		CreatedAt:   createdAt,
-		CompletedAt: completedAt,
-		State:       state,
+		Instance:         core.NewWorkflowInstance(id, executionID),
+		CreatedAt:        createdAt,
+		CompletedAt:      completedAt,
+		State:            state,
+		DeadLetterReason: deadLetterReason.String,
+		Memo:             memo,
 	}, nil
 }
 
@@ -11,11 +11,14 @@ import (
 	"github.com/cschleiden/go-workflows/internal/history"
 )
 
-func getPendingEvents(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance) ([]*history.Event, error) {
-	now := time.Now()
+// eventColumns are the columns of the pending_events and history tables read by scanEvent
+const eventColumns = "id, sequence_id, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at"
+
+func getPendingEvents(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, now time.Time) ([]*history.Event, error) {
 	events, err := tx.QueryContext(
 		ctx,
-		"SELECT * FROM `pending_events` WHERE instance_id = ? AND execution_id = ? AND (`visible_at` IS NULL OR `visible_at` <= ?)",
+		"SELECT "+eventColumns+" FROM `pending_events` WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND (`visible_at` IS NULL OR `visible_at` <= ?) ORDER BY rowid",
+		namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 		now,
@@ -40,15 +43,17 @@ func getPendingEvents(ctx context.Context, tx *sql.Tx, instance *core.WorkflowIn
 	return pendingEvents, nil
 }
 
-func getHistory(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance, lastSequenceID *int64) ([]*history.Event, error) {
+func getHistory(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, lastSequenceID *int64) ([]*history.Event, error) {
 	var historyEvents *sql.Rows
 	var err error
 	if lastSequenceID != nil {
 		historyEvents, err = tx.QueryContext(
-			ctx, "SELECT * FROM `history` WHERE instance_id = ? AND execution_id = ? AND sequence_id > ?", instance.InstanceID, instance.ExecutionID, *lastSequenceID)
+			ctx, "SELECT "+eventColumns+" FROM `history` WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND sequence_id > ?",
+			namespace, instance.InstanceID, instance.ExecutionID, *lastSequenceID)
 	} else {
 		historyEvents, err = tx.QueryContext(
-			ctx, "SELECT * FROM `history` WHERE instance_id = ? AND execution_id = ?", instance.InstanceID, instance.ExecutionID)
+			ctx, "SELECT "+eventColumns+" FROM `history` WHERE namespace = ? AND instance_id = ? AND execution_id = ?",
+			namespace, instance.InstanceID, instance.ExecutionID)
 	}
 	defer historyEvents.Close()
 	if err != nil {
@@ -103,15 +108,15 @@ func scanEvent(row Scanner) (*history.Event, error) {
 	return historyEvent, nil
 }
 
-func insertPendingEvents(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance, newEvents []*history.Event) error {
-	return insertEvents(ctx, tx, "pending_events", instance, newEvents)
+func insertPendingEvents(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, newEvents []*history.Event) error {
+	return insertEvents(ctx, tx, "pending_events", namespace, instance, newEvents)
 }
 
-func insertHistoryEvents(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance, historyEvents []*history.Event) error {
-	return insertEvents(ctx, tx, "history", instance, historyEvents)
+func insertHistoryEvents(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, historyEvents []*history.Event) error {
+	return insertEvents(ctx, tx, "history", namespace, instance, historyEvents)
 }
 
-func insertEvents(ctx context.Context, tx *sql.Tx, tableName string, instance *core.WorkflowInstance, events []*history.Event) error {
+func insertEvents(ctx context.Context, tx *sql.Tx, tableName string, namespace string, instance *core.WorkflowInstance, events []*history.Event) error {
 	const batchSize = 20
 	for batchStart := 0; batchStart < len(events); batchStart += batchSize {
 		batchEnd := batchStart + batchSize
@@ -120,10 +125,10 @@ func insertEvents(ctx context.Context, tx *sql.Tx, tableName string, instance *c
 		}
 		batchEvents := events[batchStart:batchEnd]
 
-		query := "INSERT INTO `" + tableName + "` (id, sequence_id, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)" +
-			strings.Repeat(", (?, ?, ?, ?, ?, ?, ?, ?, ?)", len(batchEvents)-1)
+		query := "INSERT INTO `" + tableName + "` (namespace, id, sequence_id, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)" +
+			strings.Repeat(", (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", len(batchEvents)-1)
 
-		args := make([]interface{}, 0, len(batchEvents)*7)
+		args := make([]interface{}, 0, len(batchEvents)*10)
 
 		for _, newEvent := range batchEvents {
 			a, err := history.SerializeAttributes(newEvent.Attributes)
@@ -132,7 +137,7 @@ func insertEvents(ctx context.Context, tx *sql.Tx, tableName string, instance *c
 			}
 
 			args = append(
-				args, newEvent.ID, newEvent.SequenceID, instance.InstanceID, instance.ExecutionID, newEvent.Type, newEvent.Timestamp, newEvent.ScheduleEventID, a, newEvent.VisibleAt)
+				args, namespace, newEvent.ID, newEvent.SequenceID, instance.InstanceID, instance.ExecutionID, newEvent.Type, newEvent.Timestamp, newEvent.ScheduleEventID, a, newEvent.VisibleAt)
 		}
 
 		_, err := tx.ExecContext(
@@ -148,10 +153,11 @@ func insertEvents(ctx context.Context, tx *sql.Tx, tableName string, instance *c
 	return nil
 }
 
-func removeFutureEvent(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance, scheduleEventID int64) error {
+func removeFutureEvent(ctx context.Context, tx *sql.Tx, namespace string, instance *core.WorkflowInstance, scheduleEventID int64) error {
 	_, err := tx.ExecContext(
 		ctx,
-		"DELETE FROM `pending_events` WHERE instance_id = ? AND execution_id = ? AND schedule_event_id = ? AND visible_at IS NOT NULL",
+		"DELETE FROM `pending_events` WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND schedule_event_id = ? AND visible_at IS NOT NULL",
+		namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 		scheduleEventID,
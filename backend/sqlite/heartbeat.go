@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/payload"
+)
+
+var _ backend.ActivityHeartbeater = (*sqliteBackend)(nil)
+
+func (sb *sqliteBackend) RecordActivityHeartbeat(ctx context.Context, activityID string, details payload.Payload) error {
+	now := sb.options.Clock.Now()
+
+	res, err := sb.db.ExecContext(
+		ctx,
+		`UPDATE activities SET locked_until = ?, last_heartbeat = ?, heartbeat_details = ? WHERE namespace = ? AND id = ? AND worker = ?`,
+		now.Add(sb.options.ActivityLockTimeout),
+		now,
+		[]byte(details),
+		sb.options.Namespace,
+		activityID,
+		sb.workerName,
+	)
+	if err != nil {
+		return fmt.Errorf("recording activity heartbeat: %w", err)
+	}
+
+	if rowsAffected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("determining if activity heartbeat was recorded: %w", err)
+	} else if rowsAffected == 0 {
+		return errors.New("could not record activity heartbeat")
+	}
+
+	return nil
+}
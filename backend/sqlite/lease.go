@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.Leaser = (*sqliteBackend)(nil)
+
+func (sb *sqliteBackend) AcquireLease(ctx context.Context, name, holder string, duration time.Duration) (bool, error) {
+	now := sb.options.Clock.Now()
+
+	// Take over the lease if it's held by the same holder or has expired
+	res, err := sb.db.ExecContext(
+		ctx,
+		`INSERT INTO leases (namespace, name, holder, expires_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (namespace, name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+			WHERE leases.holder = excluded.holder OR leases.expires_at < ?`,
+		sb.options.Namespace,
+		name,
+		holder,
+		now.Add(duration).UnixNano(),
+		now.UnixNano(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("acquiring lease: %w", err)
+	}
+
+	changed, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("acquiring lease: %w", err)
+	}
+
+	return changed == 1, nil
+}
+
+func (sb *sqliteBackend) ReleaseLease(ctx context.Context, name, holder string) error {
+	if _, err := sb.db.ExecContext(
+		ctx,
+		"DELETE FROM `leases` WHERE namespace = ? AND name = ? AND holder = ?",
+		sb.options.Namespace,
+		name,
+		holder,
+	); err != nil {
+		return fmt.Errorf("releasing lease: %w", err)
+	}
+
+	return nil
+}
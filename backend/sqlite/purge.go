@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.InstancePurger = (*sqliteBackend)(nil)
+
+// purgeBatchSize is the number of instances removed per transaction
+const purgeBatchSize = 500
+
+func (sb *sqliteBackend) RemoveFinishedWorkflowInstances(ctx context.Context, finishedBefore time.Time, workflowName string) (int, error) {
+	// Signals recorded for deduplication are otherwise kept for removed instances
+	if err := signals.Prune(ctx, sb.db, sb.options.Namespace, sb.options.Clock.Now()); err != nil {
+		return 0, err
+	}
+
+	removed := 0
+
+	for {
+		n, err := sb.removeFinishedWorkflowInstances(ctx, finishedBefore, workflowName)
+		removed += n
+		if err != nil || n < purgeBatchSize {
+			return removed, err
+		}
+	}
+}
+
+// removeFinishedWorkflowInstances removes up to purgeBatchSize finished instances and returns the number of removed
+// instances
+func (sb *sqliteBackend) removeFinishedWorkflowInstances(ctx context.Context, finishedBefore time.Time, workflowName string) (int, error) {
+	tx, err := sb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	where := "namespace = ? AND completed_at IS NOT NULL AND julianday(completed_at) < julianday(?)"
+	args := []interface{}{sb.options.Namespace, finishedBefore}
+
+	if workflowName != "" {
+		where += " AND workflow_name = ?"
+		args = append(args, workflowName)
+	}
+
+	// Instances are kept until their history has been archived
+	if sb.options.Archiver != nil {
+		where += " AND archived = 1"
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, execution_id FROM `instances` WHERE "+where+" LIMIT ?", append(args, purgeBatchSize)...)
+	if err != nil {
+		return 0, fmt.Errorf("selecting finished instances: %w", err)
+	}
+
+	var instances []interface{}
+	for rows.Next() {
+		var instanceID, executionID string
+		if err := rows.Scan(&instanceID, &executionID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scanning finished instance: %w", err)
+		}
+
+		instances = append(instances, instanceID, executionID)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("selecting finished instances: %w", err)
+	}
+
+	n := len(instances) / 2
+	if n == 0 {
+		return 0, nil
+	}
+
+	in := strings.Repeat("(?, ?), ", n-1) + "(?, ?)"
+
+	for _, stmt := range []string{
+		"DELETE FROM `instances` WHERE namespace = ? AND (id, execution_id) IN (VALUES " + in + ")",
+		"DELETE FROM `history` WHERE namespace = ? AND (instance_id, execution_id) IN (VALUES " + in + ")",
+		"DELETE FROM `pending_events` WHERE namespace = ? AND (instance_id, execution_id) IN (VALUES " + in + ")",
+		"DELETE FROM `pending_activities` WHERE namespace = ? AND (instance_id, execution_id) IN (VALUES " + in + ")",
+		"DELETE FROM `search_attributes` WHERE namespace = ? AND (instance_id, execution_id) IN (VALUES " + in + ")",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, append([]interface{}{sb.options.Namespace}, instances...)...); err != nil {
+			return 0, fmt.Errorf("removing finished instances: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
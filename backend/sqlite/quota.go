@@ -0,0 +1,12 @@
+package sqlite
+
+import "github.com/cschleiden/go-workflows/internal/sqlquota"
+
+// quotas checks active instance limits when creating instances. Write transactions are serialized, by the single
+// connection of in-memory databases or by _txlock=immediate, so no lock is needed.
+var quotas = sqlquota.Dialect{
+	CountActive: "SELECT COUNT(*) FROM `instances` WHERE namespace = ? AND state = ?",
+	CountActiveTenant: `SELECT COUNT(*) FROM instances i INNER JOIN search_attributes sa
+		ON sa.namespace = i.namespace AND sa.instance_id = i.id AND sa.execution_id = i.execution_id
+		WHERE i.namespace = ? AND i.state = ? AND sa.name = ? AND sa.type = ? AND sa.value = ?`,
+}
@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.RateLimiter = (*sqliteBackend)(nil)
+
+func (sb *sqliteBackend) AcquireActivityRateLimit(ctx context.Context, activityName string) (time.Duration, error) {
+	limit, ok := sb.options.ActivityRateLimits[activityName]
+	if !ok {
+		return 0, nil
+	}
+
+	return sb.AcquireRateLimit(ctx, "activity:"+activityName, limit)
+}
+
+func (sb *sqliteBackend) AcquireRateLimit(ctx context.Context, name string, limit backend.RateLimit) (time.Duration, error) {
+	if limit.Limit <= 0 || limit.Interval <= 0 {
+		return 0, nil
+	}
+
+	tx, err := sb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := sb.options.Clock.Now()
+
+	// Buckets start out full
+	tokens := float64(limit.Limit)
+	updatedAt := now
+
+	var updatedAtNanos int64
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT tokens, updated_at FROM `rate_limits` WHERE namespace = ? AND name = ?",
+		sb.options.Namespace,
+		name,
+	).Scan(&tokens, &updatedAtNanos); err != nil {
+		if err != sql.ErrNoRows {
+			return 0, fmt.Errorf("reading rate limit: %w", err)
+		}
+	} else {
+		updatedAt = time.Unix(0, updatedAtNanos)
+	}
+
+	tokens, wait := limit.Take(tokens, updatedAt, now)
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO rate_limits (namespace, name, tokens, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (namespace, name) DO UPDATE SET tokens = excluded.tokens, updated_at = excluded.updated_at`,
+		sb.options.Namespace,
+		name,
+		tokens,
+		now.UnixNano(),
+	); err != nil {
+		return 0, fmt.Errorf("updating rate limit: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return wait, nil
+}
@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.ActivityTaskReleaser = (*sqliteBackend)(nil)
+
+// ReleaseActivityTask expires the lock of the activity task instead of clearing it, so the next delivery is marked as
+// redelivered
+func (sb *sqliteBackend) ReleaseActivityTask(ctx context.Context, activityID string) error {
+	res, err := sb.db.ExecContext(
+		ctx,
+		`UPDATE activities SET locked_until = ? WHERE namespace = ? AND id = ? AND worker = ?`,
+		sb.options.Clock.Now(),
+		sb.options.Namespace,
+		activityID,
+		sb.workerName,
+	)
+	if err != nil {
+		return fmt.Errorf("releasing activity task: %w", err)
+	}
+
+	if rowsAffected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("determining if activity task was released: %w", err)
+	} else if rowsAffected == 0 {
+		return errors.New("could not release activity task")
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+)
+
+// checkInstanceIDReuse enforces the instance ID reuse policy of a new execution against the most recent execution with
+// the same instance ID. If the policy allows it, an active execution is terminated and returned.
+func (sb *sqliteBackend) checkInstanceIDReuse(ctx context.Context, tx *sql.Tx, instanceID string, policy core.InstanceIDReusePolicy) (*core.WorkflowInstance, error) {
+	var executionID string
+	var parentInstanceID, parentExecutionID *string
+	var parentEventID *int64
+	var state core.WorkflowInstanceState
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT execution_id, parent_instance_id, parent_execution_id, parent_schedule_event_id, state FROM `instances` WHERE namespace = ? AND id = ? ORDER BY rowid DESC LIMIT 1",
+		sb.options.Namespace,
+		instanceID,
+	).Scan(&executionID, &parentInstanceID, &parentExecutionID, &parentEventID, &state); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("checking for existing workflow instance: %w", err)
+	}
+
+	var existing *core.WorkflowInstance
+	if parentInstanceID != nil {
+		existing = core.NewSubWorkflowInstance(instanceID, executionID, core.NewWorkflowInstance(*parentInstanceID, *parentExecutionID), *parentEventID)
+	} else {
+		existing = core.NewWorkflowInstance(instanceID, executionID)
+	}
+
+	terminate, err := backend.CheckInstanceIDReuse(policy, state, func() (*history.Event, error) {
+		return sb.lastHistoryEvent(ctx, tx, existing)
+	})
+	if err != nil || !terminate {
+		return nil, err
+	}
+
+	return existing, sb.terminateExisting(ctx, tx, existing)
+}
+
+func (sb *sqliteBackend) lastHistoryEvent(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance) (*history.Event, error) {
+	event, err := scanEvent(tx.QueryRowContext(
+		ctx,
+		"SELECT "+eventColumns+" FROM `history` WHERE namespace = ? AND instance_id = ? AND execution_id = ? ORDER BY sequence_id DESC LIMIT 1",
+		sb.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	))
+	if err == nil {
+		return event, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("getting last history event: %w", err)
+	}
+
+	// Histories of finished instances might have been moved to the archiver
+	if sb.options.Archiver != nil {
+		h, err := sb.getArchivedHistory(ctx, instance)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(h) > 0 {
+			return h[len(h)-1], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// terminateExisting terminates the given active execution in place, see backend.TerminateExecution
+func (sb *sqliteBackend) terminateExisting(ctx context.Context, tx *sql.Tx, instance *core.WorkflowInstance) error {
+	var lastSequenceID int64
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT sequence_id FROM `history` WHERE namespace = ? AND instance_id = ? AND execution_id = ? ORDER BY sequence_id DESC LIMIT 1",
+		sb.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	).Scan(&lastSequenceID); err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("getting most recent sequence id: %w", err)
+	}
+
+	var startedEvent *history.Event
+	if lastSequenceID == 0 {
+		event, err := scanEvent(tx.QueryRowContext(
+			ctx,
+			"SELECT "+eventColumns+" FROM `pending_events` WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND event_type = ? LIMIT 1",
+			sb.options.Namespace,
+			instance.InstanceID,
+			instance.ExecutionID,
+			history.EventType_WorkflowExecutionStarted,
+		))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("getting started event: %w", err)
+		} else if err == nil {
+			startedEvent = event
+		}
+	}
+
+	now := sb.options.Clock.Now()
+	executedEvents, parentEvent := backend.TerminateExecution(instance, startedEvent, lastSequenceID, now)
+
+	if err := insertHistoryEvents(ctx, tx, sb.options.Namespace, instance, executedEvents); err != nil {
+		return fmt.Errorf("inserting termination events: %w", err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM `pending_events` WHERE namespace = ? AND instance_id = ? AND execution_id = ?",
+		sb.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	); err != nil {
+		return fmt.Errorf("deleting pending events: %w", err)
+	}
+
+	// Unlock the instance, so that a worker currently executing a task for it fails to complete it
+	if _, err := tx.ExecContext(
+		ctx,
+		"UPDATE `instances` SET state = ?, completed_at = ?, locked_until = NULL, sticky_until = NULL, worker = NULL WHERE namespace = ? AND id = ? AND execution_id = ?",
+		core.WorkflowInstanceStateFinished,
+		now,
+		sb.options.Namespace,
+		instance.InstanceID,
+		instance.ExecutionID,
+	); err != nil {
+		return fmt.Errorf("terminating workflow instance: %w", err)
+	}
+
+	if parentEvent != nil {
+		if err := insertPendingEvents(ctx, tx, sb.options.Namespace, parentEvent.WorkflowInstance, []*history.Event{parentEvent.HistoryEvent}); err != nil {
+			return fmt.Errorf("inserting sub-workflow result: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+var _ backend.ScheduleStore = (*sqliteBackend)(nil)
+
+func (sb *sqliteBackend) CreateSchedule(ctx context.Context, s *backend.Schedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshaling schedule: %w", err)
+	}
+
+	res, err := sb.db.ExecContext(
+		ctx,
+		"INSERT OR IGNORE INTO `schedules` (namespace, id, schedule) VALUES (?, ?, ?)",
+		sb.options.Namespace,
+		s.ID,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting schedule: %w", err)
+	}
+
+	return expectAffected(res, backend.ErrScheduleAlreadyExists)
+}
+
+func (sb *sqliteBackend) UpdateSchedule(ctx context.Context, s *backend.Schedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshaling schedule: %w", err)
+	}
+
+	res, err := sb.db.ExecContext(
+		ctx,
+		"UPDATE `schedules` SET schedule = ? WHERE namespace = ? AND id = ?",
+		data,
+		sb.options.Namespace,
+		s.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("updating schedule: %w", err)
+	}
+
+	return expectAffected(res, backend.ErrScheduleNotFound)
+}
+
+func (sb *sqliteBackend) DeleteSchedule(ctx context.Context, id string) error {
+	res, err := sb.db.ExecContext(
+		ctx,
+		"DELETE FROM `schedules` WHERE namespace = ? AND id = ?",
+		sb.options.Namespace,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("deleting schedule: %w", err)
+	}
+
+	return expectAffected(res, backend.ErrScheduleNotFound)
+}
+
+func (sb *sqliteBackend) ListSchedules(ctx context.Context) ([]*backend.Schedule, error) {
+	rows, err := sb.db.QueryContext(
+		ctx,
+		"SELECT schedule FROM `schedules` WHERE namespace = ? ORDER BY id",
+		sb.options.Namespace,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*backend.Schedule
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("scanning schedule: %w", err)
+		}
+
+		var s *backend.Schedule
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("unmarshaling schedule: %w", err)
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+func expectAffected(res sql.Result, notAffected error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return notAffected
+	}
+
+	return nil
+}
@@ -1,4 +1,5 @@
 CREATE TABLE IF NOT EXISTS `instances` (
+  `namespace` TEXT NOT NULL DEFAULT 'default',
   `id` TEXT NOT NULL,
   `execution_id` TEXT NOT NULL,
   `parent_instance_id` TEXT NULL,
@@ -11,7 +12,16 @@ CREATE TABLE IF NOT EXISTS `instances` (
   `locked_until` DATETIME NULL,
   `sticky_until` DATETIME NULL,
   `worker` TEXT NULL,
-  PRIMARY KEY(`id`, `execution_id`)
+  `priority` INTEGER NOT NULL DEFAULT 0,
+  `workflow_name` TEXT NULL,
+  `paused` INTEGER NOT NULL DEFAULT 0,
+  `build_id` TEXT NULL,
+  `task_attempts` INTEGER NOT NULL DEFAULT 0,
+  `dead_letter_reason` TEXT NULL,
+  `memo` TEXT NULL,
+  `queue` TEXT NOT NULL DEFAULT 'default',
+  `archived` INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY(`namespace`, `id`, `execution_id`)
 );
 
 CREATE INDEX IF NOT EXISTS `idx_instances_id_execution_id` ON `instances` (`id`, `execution_id`);
@@ -19,6 +29,7 @@ CREATE INDEX IF NOT EXISTS `idx_instances_locked_until_completed_at` ON `instanc
 CREATE INDEX IF NOT EXISTS `idx_instances_parent_instance_id_parent_execution_id` ON `instances` (`parent_instance_id`, `parent_execution_id`);
 
 CREATE TABLE IF NOT EXISTS `pending_events` (
+  `namespace` TEXT NOT NULL DEFAULT 'default',
   `id` TEXT,
   `sequence_id` INTEGER NOT NULL, -- not used but keep for now for query compat
   `instance_id` TEXT NOT NULL,
@@ -28,12 +39,13 @@ CREATE TABLE IF NOT EXISTS `pending_events` (
   `schedule_event_id` INT NOT NULL,
   `attributes` BLOB NOT NULL,
   `visible_at` DATETIME NULL,
-  PRIMARY KEY(`id`, `instance_id`)
+  PRIMARY KEY(`namespace`, `id`, `instance_id`)
 );
 
 CREATE INDEX IF NOT EXISTS `idx_pending_events_instance_id_execution_id_visible_at_schedule_event_id` ON `pending_events` (`instance_id`, `execution_id`, `visible_at`, `schedule_event_id`);
 
 CREATE TABLE IF NOT EXISTS `history` (
+  `namespace` TEXT NOT NULL DEFAULT 'default',
   `id` TEXT,
   `sequence_id` INTEGER NOT NULL,
   `instance_id` TEXT NOT NULL,
@@ -43,13 +55,14 @@ CREATE TABLE IF NOT EXISTS `history` (
   `schedule_event_id` INT NOT NULL,
   `attributes` BLOB NOT NULL,
   `visible_at` DATETIME NULL,
-  PRIMARY KEY(`id`, `instance_id`)
+  PRIMARY KEY(`namespace`, `id`, `instance_id`)
 );
 
 CREATE INDEX IF NOT EXISTS `idx_history_instance_sequence_id` ON `history` (`instance_id`, `execution_id`, `sequence_id`);
 
 CREATE TABLE IF NOT EXISTS `activities` (
-  `id` TEXT PRIMARY KEY,
+  `id` TEXT NOT NULL,
+  `namespace` TEXT NOT NULL DEFAULT 'default',
   `instance_id` TEXT NOT NULL,
   `execution_id` TEXT NOT NULL,
   `event_type` INTEGER NOT NULL,
@@ -58,5 +71,74 @@ CREATE TABLE IF NOT EXISTS `activities` (
   `attributes` BLOB NOT NULL,
   `visible_at` DATETIME NULL,
   `locked_until` DATETIME NULL,
-  `worker` TEXT NULL
-);
\ No newline at end of file
+  `worker` TEXT NULL,
+  `priority` INTEGER NOT NULL DEFAULT 0,
+  `last_heartbeat` DATETIME NULL,
+  `heartbeat_details` BLOB NULL,
+  `queue` TEXT NOT NULL DEFAULT 'default',
+  PRIMARY KEY(`namespace`, `id`)
+);
+CREATE TABLE IF NOT EXISTS `rate_limits` (
+  `namespace` TEXT NOT NULL,
+  `name` TEXT NOT NULL,
+  `tokens` REAL NOT NULL,
+  `updated_at` INTEGER NOT NULL,
+  PRIMARY KEY(`namespace`, `name`)
+);
+
+CREATE TABLE IF NOT EXISTS `leases` (
+  `namespace` TEXT NOT NULL,
+  `name` TEXT NOT NULL,
+  `holder` TEXT NOT NULL,
+  `expires_at` INTEGER NOT NULL,
+  PRIMARY KEY(`namespace`, `name`)
+);
+
+CREATE TABLE IF NOT EXISTS `signal_deduplication` (
+  `namespace` TEXT NOT NULL,
+  `instance_id` TEXT NOT NULL,
+  `hash` TEXT NOT NULL,
+  `expires_at` INTEGER NOT NULL,
+  PRIMARY KEY(`namespace`, `instance_id`, `hash`)
+);
+
+CREATE TABLE IF NOT EXISTS `queries` (
+  `namespace` TEXT NOT NULL,
+  `id` TEXT NOT NULL,
+  `instance` TEXT NOT NULL,
+  `name` TEXT NOT NULL,
+  `args` BLOB NOT NULL,
+  `deadline` INTEGER NOT NULL,
+  `locked` INTEGER NOT NULL DEFAULT 0,
+  `result` BLOB NULL,
+  PRIMARY KEY(`namespace`, `id`)
+);
+
+CREATE TABLE IF NOT EXISTS `schedules` (
+  `namespace` TEXT NOT NULL,
+  `id` TEXT NOT NULL,
+  `schedule` BLOB NOT NULL,
+  PRIMARY KEY(`namespace`, `id`)
+);
+
+CREATE TABLE IF NOT EXISTS `pending_activities` (
+  `namespace` TEXT NOT NULL,
+  `id` TEXT NOT NULL,
+  `instance_id` TEXT NOT NULL,
+  `execution_id` TEXT NOT NULL,
+  `event` BLOB NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY(`namespace`, `id`)
+);
+
+CREATE TABLE IF NOT EXISTS `search_attributes` (
+  `namespace` TEXT NOT NULL,
+  `instance_id` TEXT NOT NULL,
+  `execution_id` TEXT NOT NULL,
+  `name` TEXT NOT NULL,
+  `type` INTEGER NOT NULL,
+  `value` TEXT NOT NULL,
+  PRIMARY KEY(`namespace`, `instance_id`, `execution_id`, `name`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_search_attributes_namespace_name_type_value` ON `search_attributes` (`namespace`, `name`, `type`, `value`);
@@ -0,0 +1,10 @@
+package sqlite
+
+import "github.com/cschleiden/go-workflows/internal/sqlsignals"
+
+// signals deduplicates signals delivered within the signal deduplication window
+var signals = sqlsignals.Dialect{
+	DeleteExpired:    "DELETE FROM `signal_deduplication` WHERE namespace = ? AND instance_id = ? AND expires_at < ?",
+	Insert:           "INSERT OR IGNORE INTO `signal_deduplication` (namespace, instance_id, hash, expires_at) VALUES (?, ?, ?, ?)",
+	DeleteAllExpired: "DELETE FROM `signal_deduplication` WHERE namespace = ? AND expires_at < ?",
+}
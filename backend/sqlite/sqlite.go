@@ -30,7 +30,22 @@ import (
 var schema string
 
 func NewInMemoryBackend(opts ...backend.BackendOption) *sqliteBackend {
-	b := newSqliteBackend("file::memory:?_mode=memory", opts...)
+	// The pool discards its connection when a context is canceled during a transaction, for example, when a worker
+	// shuts down while polling. Use a named in-memory database and keep another connection to it open, so a new
+	// connection sees the same data instead of an empty database.
+	dsn := fmt.Sprintf("file:%v?mode=memory&cache=shared", uuid.NewString())
+
+	keepAlive, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := keepAlive.Ping(); err != nil {
+		panic(err)
+	}
+
+	b := newSqliteBackend(dsn, opts...)
+	b.keepAlive = keepAlive
 
 	b.db.SetMaxOpenConns(1)
 
@@ -38,7 +53,9 @@ func NewInMemoryBackend(opts ...backend.BackendOption) *sqliteBackend {
 }
 
 func NewSqliteBackend(path string, opts ...backend.BackendOption) *sqliteBackend {
-	return newSqliteBackend(fmt.Sprintf("file:%v?_mutex=no&_journal=wal", path), opts...)
+	// Begin transactions with the write lock, so connections sharing the file, for example, backends of different
+	// namespaces, wait for each other instead of failing to upgrade their read lock with "database is locked".
+	return newSqliteBackend(fmt.Sprintf("file:%v?_mutex=no&_journal=wal&_txlock=immediate", path), opts...)
 }
 
 func newSqliteBackend(dsn string, opts ...backend.BackendOption) *sqliteBackend {
@@ -52,27 +69,209 @@ func newSqliteBackend(dsn string, opts ...backend.BackendOption) *sqliteBackend
 		panic(err)
 	}
 
+	if err := migrate(db); err != nil {
+		panic(err)
+	}
+
+	options := backend.ApplyOptions(opts...)
+
 	return &sqliteBackend{
-		db:         db,
-		workerName: fmt.Sprintf("worker-%v", uuid.NewString()),
-		options:    backend.ApplyOptions(opts...),
+		db:                    db,
+		workerName:            fmt.Sprintf("worker-%v", options.IDGenerator()),
+		options:               options,
+		workflowPriorityOrder: backend.NewPriorityOrder(options.PriorityStarvationInterval),
+		activityPriorityOrder: backend.NewPriorityOrder(options.PriorityStarvationInterval),
+	}
+}
+
+// migrate updates databases created by earlier versions of the schema
+func migrate(db *sql.DB) error {
+	for _, column := range []struct{ table, name, definition string }{
+		{"instances", "namespace", "TEXT NOT NULL DEFAULT 'default'"},
+		{"activities", "namespace", "TEXT NOT NULL DEFAULT 'default'"},
+		{"instances", "priority", "INTEGER NOT NULL DEFAULT 0"},
+		{"activities", "priority", "INTEGER NOT NULL DEFAULT 0"},
+		{"instances", "workflow_name", "TEXT NULL"},
+		{"instances", "paused", "INTEGER NOT NULL DEFAULT 0"},
+		{"instances", "build_id", "TEXT NULL"},
+		{"activities", "last_heartbeat", "DATETIME NULL"},
+		{"activities", "heartbeat_details", "BLOB NULL"},
+		{"instances", "task_attempts", "INTEGER NOT NULL DEFAULT 0"},
+		{"instances", "dead_letter_reason", "TEXT NULL"},
+		{"instances", "memo", "TEXT NULL"},
+		{"instances", "queue", "TEXT NOT NULL DEFAULT 'default'"},
+		{"activities", "queue", "TEXT NOT NULL DEFAULT 'default'"},
+		{"instances", "archived", "INTEGER NOT NULL DEFAULT 0"},
+		{"pending_events", "namespace", "TEXT NOT NULL DEFAULT 'default'"},
+		{"history", "namespace", "TEXT NOT NULL DEFAULT 'default'"},
+	} {
+		var exists int
+		if err := db.QueryRow(
+			"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", column.table, column.name,
+		).Scan(&exists); err != nil {
+			return fmt.Errorf("checking for %v column: %w", column.name, err)
+		}
+
+		if exists == 0 {
+			if _, err := db.Exec(
+				fmt.Sprintf("ALTER TABLE `%v` ADD COLUMN `%v` %v", column.table, column.name, column.definition),
+			); err != nil {
+				return fmt.Errorf("adding %v column: %w", column.name, err)
+			}
+		}
+	}
+
+	// Primary keys can't be altered, tables whose primary key doesn't include the namespace are recreated
+	for _, table := range []string{"instances", "activities", "pending_events", "history"} {
+		var pk int
+		if err := db.QueryRow(
+			"SELECT pk FROM pragma_table_info(?) WHERE name = 'namespace'", table,
+		).Scan(&pk); err != nil {
+			return fmt.Errorf("checking primary key of %v table: %w", table, err)
+		}
+
+		if pk == 0 {
+			if table == "pending_events" || table == "history" {
+				// Events were recorded without namespace, take it from their instance
+				if _, err := db.Exec(fmt.Sprintf(
+					"UPDATE `%v` SET namespace = COALESCE((SELECT i.namespace FROM `instances` i WHERE i.id = `%v`.instance_id AND i.execution_id = `%v`.execution_id), namespace)",
+					table, table, table,
+				)); err != nil {
+					return fmt.Errorf("setting namespace of %v: %w", table, err)
+				}
+			}
+
+			if err := recreateTable(db, table); err != nil {
+				return fmt.Errorf("recreating %v table: %w", table, err)
+			}
+		}
+	}
+
+	if _, err := db.Exec(
+		"CREATE INDEX IF NOT EXISTS `idx_instances_namespace_id_state` ON `instances` (`namespace`, `id`, `state`);" +
+			"CREATE INDEX IF NOT EXISTS `idx_activities_namespace_locked_until` ON `activities` (`namespace`, `locked_until`);" +
+			"CREATE INDEX IF NOT EXISTS `idx_instances_namespace_workflow_name_state` ON `instances` (`namespace`, `workflow_name`, `state`);",
+	); err != nil {
+		return fmt.Errorf("creating indexes: %w", err)
+	}
+
+	return nil
+}
+
+// recreateTable creates the given table and its indexes as defined by the schema and copies the rows of the existing
+// table to it
+func recreateTable(db *sql.DB, table string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	old := table + "_old"
+	if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE `%v` RENAME TO `%v`", table, old)); err != nil {
+		return fmt.Errorf("renaming table: %w", err)
+	}
+
+	// Indexes are renamed with their table, drop them so that the schema creates them for the new table
+	indexes, err := queryNames(tx, "SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", old)
+	if err != nil {
+		return fmt.Errorf("reading indexes: %w", err)
+	}
+
+	for _, index := range indexes {
+		if _, err := tx.Exec(fmt.Sprintf("DROP INDEX `%v`", index)); err != nil {
+			return fmt.Errorf("dropping index %v: %w", index, err)
+		}
+	}
+
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("creating table: %w", err)
+	}
+
+	columns, err := queryNames(tx, "SELECT name FROM pragma_table_info(?)", old)
+	if err != nil {
+		return fmt.Errorf("reading columns: %w", err)
 	}
+
+	c := "`" + strings.Join(columns, "`, `") + "`"
+	if _, err := tx.Exec(fmt.Sprintf("INSERT INTO `%v` (%v) SELECT %v FROM `%v`", table, c, c, old)); err != nil {
+		return fmt.Errorf("copying rows: %w", err)
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("DROP TABLE `%v`", old)); err != nil {
+		return fmt.Errorf("dropping table: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func queryNames(tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
 }
 
 type sqliteBackend struct {
 	db         *sql.DB
 	workerName string
-	options    backend.Options
+
+	// keepAlive holds a connection to in-memory databases, which are dropped when their last connection is closed
+	keepAlive *sql.DB
+
+	options backend.Options
+
+	workflowPriorityOrder *backend.PriorityOrder
+	activityPriorityOrder *backend.PriorityOrder
+}
+
+// orderByPriority returns an ORDER BY expression and its arguments for dequeuing tasks in the given priority order
+func orderByPriority(order []core.Priority) (string, []interface{}) {
+	return "CASE priority WHEN ? THEN 0 WHEN ? THEN 1 ELSE 2 END", []interface{}{order[0], order[1]}
+}
+
+// inQueues returns an IN expression and its arguments for dequeuing tasks of the given queues, or of the default queue
+// if none are given
+func inQueues(queues []core.Queue) (string, []interface{}) {
+	if len(queues) == 0 {
+		queues = []core.Queue{core.QueueDefault}
+	}
+
+	args := make([]interface{}, 0, len(queues))
+	for _, q := range queues {
+		args = append(args, q.OrDefault())
+	}
+
+	return "IN (?" + strings.Repeat(",?", len(queues)-1) + ")", args
 }
 
 var _ backend.Backend = (*sqliteBackend)(nil)
+var _ backend.Retainer = (*sqliteBackend)(nil)
+var _ backend.QueuePoller = (*sqliteBackend)(nil)
+var _ backend.IDGenerator = (*sqliteBackend)(nil)
 
 func (sb *sqliteBackend) Logger() log.Logger {
 	return sb.options.Logger
 }
 
 func (sb *sqliteBackend) Metrics() metrics.Client {
-	return sb.options.Metrics.WithTags(metrics.Tags{metrickeys.Backend: "sqlite"})
+	return sb.options.Metrics.WithTags(metrics.Tags{
+		metrickeys.Backend:   "sqlite",
+		metrickeys.Namespace: sb.options.Namespace,
+	})
 }
 
 func (sb *sqliteBackend) Tracer() trace.Tracer {
@@ -83,10 +282,18 @@ func (sb *sqliteBackend) Converter() converter.Converter {
 	return sb.options.Converter
 }
 
+func (sb *sqliteBackend) NewID() string {
+	return sb.options.IDGenerator()
+}
+
 func (sb *sqliteBackend) ContextPropagators() []contextpropagation.ContextPropagator {
 	return sb.options.ContextPropagators
 }
 
+func (sb *sqliteBackend) Retention() time.Duration {
+	return sb.options.Retention
+}
+
 func (sb *sqliteBackend) CreateWorkflowInstance(ctx context.Context, instance *workflow.Instance, event *history.Event) error {
 	tx, err := sb.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -94,12 +301,58 @@ func (sb *sqliteBackend) CreateWorkflowInstance(ctx context.Context, instance *w
 	}
 	defer tx.Rollback()
 
+	a := event.Attributes.(*history.ExecutionStartedAttributes)
+
+	// Check for existing executions of the same instance
+	terminated, err := sb.checkInstanceIDReuse(ctx, tx, instance.InstanceID, a.InstanceIDReusePolicy)
+	if err != nil {
+		return err
+	}
+
+	// Check the concurrency limit of the workflow
+	if limit, ok := sb.options.WorkflowConcurrencyLimits[a.Name]; ok {
+		var active int
+		if err := tx.QueryRowContext(
+			ctx,
+			"SELECT COUNT(*) FROM `instances` WHERE namespace = ? AND workflow_name = ? AND state = ?",
+			sb.options.Namespace,
+			a.Name,
+			core.WorkflowInstanceStateActive,
+		).Scan(&active); err != nil {
+			return fmt.Errorf("counting active workflow instances: %w", err)
+		}
+
+		if active >= limit {
+			return backend.ErrConcurrencyLimitReached
+		}
+	}
+
+	// Check the active instance limits of the namespace and the tenant
+	if err := quotas.Check(ctx, tx, sb.options.Namespace, backend.ActiveInstanceLimits(ctx)); err != nil {
+		return err
+	}
+
 	// Create workflow instance
-	if err := createInstance(ctx, tx, instance, event.Attributes.(*history.ExecutionStartedAttributes).Metadata, false); err != nil {
+	if err := createInstance(ctx, tx, sb.options.Namespace, instance, a, false); err != nil {
 		return err
 	}
 
-	if err := insertPendingEvents(ctx, tx, instance, []*history.Event{event}); err != nil {
+	// Delayed instances are locked until they are due, so that no workflow task is handed out before. Events like
+	// signals are held until then.
+	if event.VisibleAt != nil {
+		if _, err := tx.ExecContext(
+			ctx,
+			"UPDATE `instances` SET locked_until = ? WHERE namespace = ? AND id = ? AND execution_id = ?",
+			*event.VisibleAt,
+			sb.options.Namespace,
+			instance.InstanceID,
+			instance.ExecutionID,
+		); err != nil {
+			return fmt.Errorf("delaying workflow instance: %w", err)
+		}
+	}
+
+	if err := insertPendingEvents(ctx, tx, sb.options.Namespace, instance, []*history.Event{event}); err != nil {
 		return fmt.Errorf("inserting new event: %w", err)
 	}
 
@@ -107,10 +360,14 @@ func (sb *sqliteBackend) CreateWorkflowInstance(ctx context.Context, instance *w
 		return fmt.Errorf("creating workflow instance: %w", err)
 	}
 
+	if terminated != nil && sb.options.Archiver != nil {
+		sb.tryArchiveHistory(ctx, terminated)
+	}
+
 	return nil
 }
 
-func createInstance(ctx context.Context, tx *sql.Tx, wfi *workflow.Instance, metadata *workflow.Metadata, ignoreDuplicate bool) error {
+func createInstance(ctx context.Context, tx *sql.Tx, namespace string, wfi *workflow.Instance, a *history.ExecutionStartedAttributes, ignoreDuplicate bool) error {
 	var parentInstanceID, parentExecutionID *string
 	var parentEventID *int64
 	if wfi.SubWorkflow() {
@@ -119,14 +376,20 @@ func createInstance(ctx context.Context, tx *sql.Tx, wfi *workflow.Instance, met
 		parentEventID = &wfi.ParentEventID
 	}
 
-	metadataJson, err := json.Marshal(metadata)
+	metadataJson, err := json.Marshal(a.Metadata)
 	if err != nil {
 		return fmt.Errorf("marshaling metadata: %w", err)
 	}
 
+	memo, err := marshalMemo(a.Memo)
+	if err != nil {
+		return err
+	}
+
 	res, err := tx.ExecContext(
 		ctx,
-		"INSERT OR IGNORE INTO `instances` (id, execution_id, parent_instance_id, parent_execution_id, parent_schedule_event_id, metadata, state) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		"INSERT OR IGNORE INTO `instances` (namespace, id, execution_id, parent_instance_id, parent_execution_id, parent_schedule_event_id, metadata, state, priority, workflow_name, memo, queue) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		namespace,
 		wfi.InstanceID,
 		wfi.ExecutionID,
 		parentInstanceID,
@@ -134,20 +397,30 @@ func createInstance(ctx context.Context, tx *sql.Tx, wfi *workflow.Instance, met
 		parentEventID,
 		string(metadataJson),
 		core.WorkflowInstanceStateActive,
+		a.Priority,
+		a.Name,
+		memo,
+		a.Queue.OrDefault(),
 	)
 	if err != nil {
 		return fmt.Errorf("inserting workflow instance: %w", err)
 	}
 
-	if !ignoreDuplicate {
-		rows, err := res.RowsAffected()
-		if err != nil {
-			return err
-		}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
 
-		if rows != 1 {
+	if rows != 1 {
+		if !ignoreDuplicate {
 			return backend.ErrInstanceAlreadyExists
 		}
+
+		return nil
+	}
+
+	if err := upsertSearchAttributes(ctx, tx, namespace, wfi, a.SearchAttributes); err != nil {
+		return fmt.Errorf("inserting search attributes: %w", err)
 	}
 
 	return nil
@@ -164,7 +437,13 @@ func (sb *sqliteBackend) RemoveWorkflowInstance(ctx context.Context, instance *c
 	executionID := instance.ExecutionID
 
 	// Check status of the instance
-	row := tx.QueryRowContext(ctx, "SELECT state FROM `instances` WHERE id = ? AND execution_id = ? LIMIT 1", instanceID, executionID)
+	row := tx.QueryRowContext(
+		ctx,
+		"SELECT state FROM `instances` WHERE namespace = ? AND id = ? AND execution_id = ? LIMIT 1",
+		sb.options.Namespace,
+		instanceID,
+		executionID,
+	)
 	var state core.WorkflowInstanceState
 	if err := row.Scan(&state); err != nil {
 		if err == sql.ErrNoRows {
@@ -177,11 +456,27 @@ func (sb *sqliteBackend) RemoveWorkflowInstance(ctx context.Context, instance *c
 	}
 
 	// Delete from instances and history tables
-	if _, err := tx.ExecContext(ctx, "DELETE FROM `instances` WHERE id = ? AND execution_id = ?", instanceID, executionID); err != nil {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM `instances` WHERE namespace = ? AND id = ? AND execution_id = ?", sb.options.Namespace, instanceID, executionID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM `history` WHERE namespace = ? AND instance_id = ? AND execution_id = ?", sb.options.Namespace, instanceID, executionID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM `pending_events` WHERE namespace = ? AND instance_id = ? AND execution_id = ?", sb.options.Namespace, instanceID, executionID); err != nil {
 		return err
 	}
 
-	if _, err := tx.ExecContext(ctx, "DELETE FROM `history` WHERE instance_id = ? AND execution_id = ?", instanceID, executionID); err != nil {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM `pending_activities` WHERE namespace = ? AND instance_id = ? AND execution_id = ?", sb.options.Namespace, instanceID, executionID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM `search_attributes` WHERE namespace = ? AND instance_id = ? AND execution_id = ?", sb.options.Namespace, instanceID, executionID); err != nil {
+		return err
+	}
+
+	if err := signals.Prune(ctx, tx, sb.options.Namespace, sb.options.Clock.Now()); err != nil {
 		return err
 	}
 
@@ -199,7 +494,13 @@ func (sb *sqliteBackend) CancelWorkflowInstance(ctx context.Context, instance *w
 	executionID := instance.ExecutionID
 
 	// TODO: Combine with event insertion
-	res := tx.QueryRowContext(ctx, "SELECT 1 FROM `instances` WHERE id = ? AND execution_id = ? LIMIT 1", instanceID, executionID)
+	res := tx.QueryRowContext(
+		ctx,
+		"SELECT 1 FROM `instances` WHERE namespace = ? AND id = ? AND execution_id = ? LIMIT 1",
+		sb.options.Namespace,
+		instanceID,
+		executionID,
+	)
 	if err := res.Scan(new(int)); err != nil {
 		if err == sql.ErrNoRows {
 			return backend.ErrInstanceNotFound
@@ -208,7 +509,7 @@ func (sb *sqliteBackend) CancelWorkflowInstance(ctx context.Context, instance *w
 		return err
 	}
 
-	if err := insertPendingEvents(ctx, tx, instance, []*history.Event{event}); err != nil {
+	if err := insertPendingEvents(ctx, tx, sb.options.Namespace, instance, []*history.Event{event}); err != nil {
 		return fmt.Errorf("inserting cancellation event: %w", err)
 	}
 
@@ -222,18 +523,24 @@ func (sb *sqliteBackend) GetWorkflowInstanceHistory(ctx context.Context, instanc
 	}
 	defer tx.Rollback()
 
-	h, err := getHistory(ctx, tx, instance, lastSequenceID)
+	h, err := getHistory(ctx, tx, sb.options.Namespace, instance, lastSequenceID)
 	if err != nil {
 		return nil, fmt.Errorf("getting workflow history: %w", err)
 	}
 
+	// Histories of finished instances might have been moved to the archiver
+	if len(h) == 0 && lastSequenceID == nil && sb.options.Archiver != nil {
+		return sb.getArchivedHistory(ctx, instance)
+	}
+
 	return h, nil
 }
 
 func (s *sqliteBackend) GetWorkflowInstanceState(ctx context.Context, instance *workflow.Instance) (core.WorkflowInstanceState, error) {
 	row := s.db.QueryRowContext(
 		ctx,
-		"SELECT state FROM instances WHERE id = ? AND execution_id = ?",
+		"SELECT state FROM instances WHERE namespace = ? AND id = ? AND execution_id = ?",
+		s.options.Namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 	)
@@ -257,12 +564,33 @@ func (sb *sqliteBackend) SignalWorkflow(ctx context.Context, instanceID string,
 
 	// TODO: Combine this with the event insertion
 	var executionID string
-	res := tx.QueryRowContext(ctx, "SELECT execution_id FROM `instances` WHERE id = ? AND state = ? LIMIT 1", instanceID, core.WorkflowInstanceStateActive)
+	res := tx.QueryRowContext(
+		ctx,
+		"SELECT execution_id FROM `instances` WHERE namespace = ? AND id = ? AND state = ? LIMIT 1",
+		sb.options.Namespace,
+		instanceID,
+		core.WorkflowInstanceStateActive,
+	)
 	if err := res.Scan(&executionID); err == sql.ErrNoRows {
 		return backend.ErrInstanceNotFound
 	}
 
-	if err := insertPendingEvents(ctx, tx, core.NewWorkflowInstance(instanceID, executionID), []*history.Event{event}); err != nil {
+	// Only signals are deduplicated, not updates
+	if window := sb.options.SignalDeduplicationWindow; window > 0 && event.Type == history.EventType_SignalReceived {
+		duplicate, err := signals.IsDuplicate(ctx, tx, sb.options.Namespace, instanceID, event, window, sb.options.Clock.Now())
+		if err != nil {
+			return err
+		}
+
+		if duplicate {
+			sb.Logger().Debug("Dropping duplicate signal", log.InstanceIDKey, instanceID,
+				log.SignalNameKey, event.Attributes.(*history.SignalReceivedAttributes).Name)
+
+			return tx.Commit()
+		}
+	}
+
+	if err := insertPendingEvents(ctx, tx, sb.options.Namespace, core.NewWorkflowInstance(instanceID, executionID), []*history.Event{event}); err != nil {
 		return fmt.Errorf("inserting signal event: %w", err)
 	}
 
@@ -270,6 +598,10 @@ func (sb *sqliteBackend) SignalWorkflow(ctx context.Context, instanceID string,
 }
 
 func (sb *sqliteBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, error) {
+	return sb.GetWorkflowTaskFromQueues(ctx, nil)
+}
+
+func (sb *sqliteBackend) GetWorkflowTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Workflow, error) {
 	tx, err := sb.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -278,30 +610,47 @@ func (sb *sqliteBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, e
 
 	// Lock next workflow task by finding an unlocked instance with new events to process
 	// (work around missing LIMIT support in sqlite driver for UPDATE statements by using sub-query)
-	now := time.Now()
+	now := sb.options.Clock.Now()
+	orderBy, orderByArgs := orderByPriority(sb.workflowPriorityOrder.Next())
+	queueFilter, queueArgs := inQueues(queues)
+	args := []interface{}{
+		now.Add(sb.options.WorkflowLockTimeout), // new locked_until
+		sb.workerName,
+		sb.options.BuildID, // pin build_id
+		sb.options.Namespace,
+		now,                // locked_until
+		now,                // sticky_until
+		sb.workerName,      // worker
+		sb.options.BuildID, // any build_id
+		sb.options.BuildID, // matching build_id
+	}
+	args = append(args, queueArgs...)
+	args = append(args, now) // event.visible_at
+	args = append(args, orderByArgs...)
 	row := tx.QueryRowContext(
 		ctx,
 		`UPDATE instances
-			SET locked_until = ?, worker = ?
+			SET locked_until = ?, worker = ?, build_id = COALESCE(build_id, NULLIF(?, '')), task_attempts = task_attempts + 1
 			WHERE rowid = (
 				SELECT rowid FROM instances i
 					WHERE
-						(locked_until IS NULL OR locked_until < ?)
+						namespace = ?
+						AND (locked_until IS NULL OR locked_until < ?)
 						AND (sticky_until IS NULL OR sticky_until < ? OR worker = ?)
 						AND completed_at IS NULL
+						AND paused = 0
+						AND dead_letter_reason IS NULL
+						AND (? = '' OR build_id IS NULL OR build_id = ?)
+						AND queue `+queueFilter+`
 						AND EXISTS (
 							SELECT 1
 								FROM pending_events
-								WHERE instance_id = i.id AND execution_id = i.execution_id AND (visible_at IS NULL OR visible_at <= ?)
+								WHERE namespace = i.namespace AND instance_id = i.id AND execution_id = i.execution_id AND (visible_at IS NULL OR visible_at <= ?)
 						)
+					ORDER BY `+orderBy+`
 					LIMIT 1
-			) RETURNING id, execution_id, parent_instance_id, parent_execution_id, parent_schedule_event_id, metadata, sticky_until`,
-		now.Add(sb.options.WorkflowLockTimeout), // new locked_until
-		sb.workerName,
-		now,           // locked_until
-		now,           // sticky_until
-		sb.workerName, // worker
-		now,           // event.visible_at
+			) RETURNING id, execution_id, parent_instance_id, parent_execution_id, parent_schedule_event_id, metadata, sticky_until, task_attempts`,
+		args...,
 	)
 
 	var instanceID, executionID string
@@ -309,7 +658,8 @@ func (sb *sqliteBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, e
 	var parentEventID *int64
 	var metadataJson sql.NullString
 	var stickyUntil *time.Time
-	if err := row.Scan(&instanceID, &executionID, &parentInstanceID, &parentExecutionID, &parentEventID, &metadataJson, &stickyUntil); err != nil {
+	var attempt int
+	if err := row.Scan(&instanceID, &executionID, &parentInstanceID, &parentExecutionID, &parentEventID, &metadataJson, &stickyUntil, &attempt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -337,10 +687,11 @@ func (sb *sqliteBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, e
 		WorkflowInstanceState: core.WorkflowInstanceStateActive,
 		Metadata:              metadata,
 		NewEvents:             []*history.Event{},
+		Attempt:               attempt,
 	}
 
 	// Get new events
-	pendingEvents, err := getPendingEvents(ctx, tx, wfi)
+	pendingEvents, err := getPendingEvents(ctx, tx, sb.options.Namespace, wfi, now)
 	if err != nil {
 		return nil, fmt.Errorf("getting pending events: %w", err)
 	}
@@ -354,7 +705,8 @@ func (sb *sqliteBackend) GetWorkflowTask(ctx context.Context) (*task.Workflow, e
 
 	// Get only most recent sequence ID
 	// TODO: Denormalize to instances table
-	row = tx.QueryRowContext(ctx, "SELECT sequence_id FROM `history` WHERE instance_id = ? AND execution_id = ? ORDER BY rowid DESC LIMIT 1", instanceID, executionID)
+	row = tx.QueryRowContext(ctx, "SELECT sequence_id FROM `history` WHERE namespace = ? AND instance_id = ? AND execution_id = ? ORDER BY rowid DESC LIMIT 1",
+		sb.options.Namespace, instanceID, executionID)
 	if err := row.Scan(&t.LastSequenceID); err != nil {
 		if err != sql.ErrNoRows {
 			return nil, fmt.Errorf("getting most recent sequence id: %w", err)
@@ -384,17 +736,18 @@ func (sb *sqliteBackend) CompleteWorkflowTask(
 
 	var completedAt *time.Time
 	if state == core.WorkflowInstanceStateFinished || state == core.WorkflowInstanceStateContinuedAsNew {
-		t := time.Now()
+		t := sb.options.Clock.Now()
 		completedAt = &t
 	}
 
 	// Unlock instance, but keep it sticky to the current worker
 	if res, err := tx.ExecContext(
 		ctx,
-		`UPDATE instances SET locked_until = NULL, sticky_until = ?, completed_at = ?, state = ? WHERE id = ? AND execution_id = ? AND worker = ?`,
-		time.Now().Add(sb.options.StickyTimeout),
+		`UPDATE instances SET locked_until = NULL, sticky_until = ?, completed_at = ?, state = ?, task_attempts = 0 WHERE namespace = ? AND id = ? AND execution_id = ? AND worker = ?`,
+		sb.options.Clock.Now().Add(sb.options.StickyTimeout),
 		completedAt,
 		state,
+		sb.options.Namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 		sb.workerName,
@@ -403,20 +756,20 @@ func (sb *sqliteBackend) CompleteWorkflowTask(
 	} else if n, err := res.RowsAffected(); err != nil {
 		return fmt.Errorf("checking for unlocked workflow instances: %w", err)
 	} else if n != 1 {
-		return errors.New("could not find workflow instance to unlock")
+		return fmt.Errorf("could not find workflow instance to unlock: %w", backend.ErrWorkflowTaskLost)
 	}
 
 	// Remove handled events from task
 	if len(executedEvents) > 0 {
-		args := make([]interface{}, 0, len(executedEvents)+1)
-		args = append(args, instance.InstanceID, instance.ExecutionID)
+		args := make([]interface{}, 0, len(executedEvents)+3)
+		args = append(args, sb.options.Namespace, instance.InstanceID, instance.ExecutionID)
 		for _, e := range executedEvents {
 			args = append(args, e.ID)
 		}
 
 		if _, err := tx.ExecContext(
 			ctx,
-			fmt.Sprintf(`DELETE FROM pending_events WHERE instance_id = ? AND execution_id = ? AND id IN (?%v)`, strings.Repeat(",?", len(executedEvents)-1)),
+			fmt.Sprintf(`DELETE FROM pending_events WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND id IN (?%v)`, strings.Repeat(",?", len(executedEvents)-1)),
 			args...,
 		); err != nil {
 			return fmt.Errorf("deleting handled new events: %w", err)
@@ -424,28 +777,34 @@ func (sb *sqliteBackend) CompleteWorkflowTask(
 	}
 
 	// Add events from last execution to history
-	if err := insertHistoryEvents(ctx, tx, instance, executedEvents); err != nil {
+	if err := insertHistoryEvents(ctx, tx, sb.options.Namespace, instance, executedEvents); err != nil {
 		return fmt.Errorf("inserting new history events: %w", err)
 	}
 
 	// Schedule activities
 	for _, event := range activityEvents {
-		if err := scheduleActivity(ctx, tx, instance, event); err != nil {
+		if err := scheduleActivity(ctx, tx, sb.options.Namespace, instance, event); err != nil {
 			return fmt.Errorf("scheduling activity: %w", err)
 		}
 	}
 
 	// Timer events
-	if err := insertPendingEvents(ctx, tx, instance, timerEvents); err != nil {
+	if err := insertPendingEvents(ctx, tx, sb.options.Namespace, instance, timerEvents); err != nil {
 		return fmt.Errorf("scheduling timers: %w", err)
 	}
 
 	for _, event := range executedEvents {
 		switch event.Type {
 		case history.EventType_TimerCanceled:
-			if err := removeFutureEvent(ctx, tx, instance, event.ScheduleEventID); err != nil {
+			if err := removeFutureEvent(ctx, tx, sb.options.Namespace, instance, event.ScheduleEventID); err != nil {
 				return fmt.Errorf("removing future event: %w", err)
 			}
+
+		case history.EventType_SearchAttributesUpserted:
+			a := event.Attributes.(*history.SearchAttributesUpsertedAttributes)
+			if err := upsertSearchAttributes(ctx, tx, sb.options.Namespace, instance, a.SearchAttributes); err != nil {
+				return fmt.Errorf("upserting search attributes: %w", err)
+			}
 		}
 	}
 
@@ -457,7 +816,7 @@ func (sb *sqliteBackend) CompleteWorkflowTask(
 			if m.HistoryEvent.Type == history.EventType_WorkflowExecutionStarted {
 				a := m.HistoryEvent.Attributes.(*history.ExecutionStartedAttributes)
 				// Create new instance
-				if err := createInstance(ctx, tx, m.WorkflowInstance, a.Metadata, true); err != nil {
+				if err := createInstance(ctx, tx, sb.options.Namespace, m.WorkflowInstance, a, true); err != nil {
 					return err
 				}
 
@@ -470,12 +829,20 @@ func (sb *sqliteBackend) CompleteWorkflowTask(
 		for _, m := range events {
 			historyEvents = append(historyEvents, m.HistoryEvent)
 		}
-		if err := insertPendingEvents(ctx, tx, &targetInstance, historyEvents); err != nil {
+		if err := insertPendingEvents(ctx, tx, sb.options.Namespace, &targetInstance, historyEvents); err != nil {
 			return fmt.Errorf("inserting messages: %w", err)
 		}
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if completedAt != nil && sb.options.Archiver != nil {
+		sb.tryArchiveHistory(ctx, instance)
+	}
+
+	return nil
 }
 
 func (sb *sqliteBackend) ExtendWorkflowTask(ctx context.Context, taskID string, instance *workflow.Instance) error {
@@ -485,11 +852,12 @@ func (sb *sqliteBackend) ExtendWorkflowTask(ctx context.Context, taskID string,
 	}
 	defer tx.Rollback()
 
-	until := time.Now().Add(sb.options.WorkflowLockTimeout)
+	until := sb.options.Clock.Now().Add(sb.options.WorkflowLockTimeout)
 	res, err := tx.ExecContext(
 		ctx,
-		`UPDATE instances SET locked_until = ? WHERE id = ? AND execution_id = ? AND worker = ?`,
+		`UPDATE instances SET locked_until = ? WHERE namespace = ? AND id = ? AND execution_id = ? AND worker = ?`,
 		until,
+		sb.options.Namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 		sb.workerName,
@@ -508,32 +876,56 @@ func (sb *sqliteBackend) ExtendWorkflowTask(ctx context.Context, taskID string,
 }
 
 func (sb *sqliteBackend) GetActivityTask(ctx context.Context) (*task.Activity, error) {
+	return sb.GetActivityTaskFromQueues(ctx, nil)
+}
+
+func (sb *sqliteBackend) GetActivityTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Activity, error) {
 	tx, err := sb.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	// Lock next activity
-	// (work around missing LIMIT support in sqlite driver for UPDATE statements by using sub-query)
-	now := time.Now()
+	// Find next activity. Activities that have been locked before are delivered again.
+	now := sb.options.Clock.Now()
+
+	orderBy, orderByArgs := orderByPriority(sb.activityPriorityOrder.Next())
+	queueFilter, queueArgs := inQueues(queues)
+
+	args := append([]interface{}{sb.options.Namespace, now}, queueArgs...)
+	args = append(args, orderByArgs...)
+
+	var rowid int64
+	var redelivered bool
+	if err := tx.QueryRowContext(
+		ctx,
+		"SELECT rowid, locked_until IS NOT NULL FROM activities WHERE namespace = ? AND (locked_until IS NULL OR locked_until < ?) AND queue "+queueFilter+" ORDER BY "+orderBy+" LIMIT 1",
+		args...,
+	).Scan(&rowid, &redelivered); err != nil {
+		if err == sql.ErrNoRows {
+			// No activity available, just return
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("finding activity task to lock: %w", err)
+	}
+
+	// Lock activity
 	row := tx.QueryRowContext(
 		ctx,
 		`UPDATE activities
 			SET locked_until = ?, worker = ?
-			WHERE rowid = (
-				SELECT rowid FROM activities WHERE locked_until IS NULL OR locked_until < ? LIMIT 1
-			) RETURNING id, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at`,
+			WHERE rowid = ?
+			RETURNING id, instance_id, execution_id, event_type, timestamp, schedule_event_id, attributes, visible_at, last_heartbeat, heartbeat_details`,
 		now.Add(sb.options.ActivityLockTimeout),
 		sb.workerName,
-		now,
+		rowid,
 	)
-	if err != nil {
-		return nil, err
-	}
 
 	var instanceID, executionID string
 	var attributes []byte
+	var lastHeartbeat sql.NullTime
+	var heartbeatDetails []byte
 	event := &history.Event{}
 
 	if err := row.Scan(
@@ -545,6 +937,8 @@ func (sb *sqliteBackend) GetActivityTask(ctx context.Context) (*task.Activity, e
 		&event.ScheduleEventID,
 		&attributes,
 		&event.VisibleAt,
+		&lastHeartbeat,
+		&heartbeatDetails,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			// No rows locked, just return
@@ -562,7 +956,8 @@ func (sb *sqliteBackend) GetActivityTask(ctx context.Context) (*task.Activity, e
 	event.Attributes = a
 
 	var metadataJson sql.NullString
-	if err := tx.QueryRowContext(ctx, "SELECT metadata FROM instances WHERE id = ?", instanceID).Scan(&metadataJson); err != nil {
+	if err := tx.QueryRowContext(ctx, "SELECT metadata FROM instances WHERE namespace = ? AND id = ? AND execution_id = ?",
+		sb.options.Namespace, instanceID, executionID).Scan(&metadataJson); err != nil {
 		return nil, fmt.Errorf("scanning metadata: %w", err)
 	}
 
@@ -575,6 +970,9 @@ func (sb *sqliteBackend) GetActivityTask(ctx context.Context) (*task.Activity, e
 		ID:               event.ID,
 		WorkflowInstance: core.NewWorkflowInstance(instanceID, executionID),
 		Event:            event,
+		Redelivered:      redelivered,
+		LastHeartbeat:    lastHeartbeat.Time,
+		HeartbeatDetails: heartbeatDetails,
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -594,7 +992,8 @@ func (sb *sqliteBackend) CompleteActivityTask(ctx context.Context, instance *wor
 	// Remove activity
 	if res, err := tx.ExecContext(
 		ctx,
-		`DELETE FROM activities WHERE instance_id = ? AND execution_id = ? AND id = ? AND worker = ?`,
+		`DELETE FROM activities WHERE namespace = ? AND instance_id = ? AND execution_id = ? AND id = ? AND worker = ?`,
+		sb.options.Namespace,
 		instance.InstanceID,
 		instance.ExecutionID,
 		id,
@@ -608,7 +1007,7 @@ func (sb *sqliteBackend) CompleteActivityTask(ctx context.Context, instance *wor
 	}
 
 	// Insert new event generated during this workflow execution
-	if err := insertPendingEvents(ctx, tx, instance, []*history.Event{event}); err != nil {
+	if err := insertPendingEvents(ctx, tx, sb.options.Namespace, instance, []*history.Event{event}); err != nil {
 		return fmt.Errorf("inserting new events for completed activity: %w", err)
 	}
 
@@ -622,11 +1021,12 @@ func (sb *sqliteBackend) ExtendActivityTask(ctx context.Context, activityID stri
 	}
 	defer tx.Rollback()
 
-	until := time.Now().Add(sb.options.ActivityLockTimeout)
+	until := sb.options.Clock.Now().Add(sb.options.ActivityLockTimeout)
 	res, err := tx.ExecContext(
 		ctx,
-		`UPDATE activities SET locked_until = ? WHERE id = ? AND worker = ?`,
+		`UPDATE activities SET locked_until = ? WHERE namespace = ? AND id = ? AND worker = ?`,
 		until,
+		sb.options.Namespace,
 		activityID,
 		sb.workerName,
 	)
@@ -2,12 +2,19 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/cschleiden/go-workflows/backend"
 	"github.com/cschleiden/go-workflows/backend/test"
+	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_SqliteBackend(t *testing.T) {
@@ -36,6 +43,314 @@ func Test_EndToEndSqliteBackend(t *testing.T) {
 	}, nil)
 }
 
+func Test_SqliteBackend_Namespaces(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "namespaces.sqlite")
+	a := NewSqliteBackend(path, backend.WithNamespace("a"))
+	b := NewSqliteBackend(path, backend.WithNamespace("b"))
+
+	instanceID := uuid.NewString()
+
+	err := a.CreateWorkflowInstance(ctx,
+		core.NewWorkflowInstance(instanceID, uuid.NewString()),
+		history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+	)
+	require.NoError(t, err)
+
+	// The same instance ID can be used in a different namespace
+	wfi := core.NewWorkflowInstance(instanceID, uuid.NewString())
+	err = b.CreateWorkflowInstance(ctx,
+		wfi,
+		history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+	)
+	require.NoError(t, err)
+
+	_, err = a.GetWorkflowInstanceState(ctx, wfi)
+	require.ErrorIs(t, err, backend.ErrInstanceNotFound)
+
+	tk, err := b.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, tk)
+	require.Equal(t, wfi, tk.WorkflowInstance)
+
+	tk, err = b.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.Nil(t, tk)
+
+	s, err := a.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), s.ActiveWorkflowInstances)
+
+	instances, err := b.GetWorkflowInstances(ctx, "", "", 10)
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	require.Equal(t, wfi.ExecutionID, instances[0].Instance.ExecutionID)
+}
+
+func Test_SqliteBackend_Namespaces_SameIDs(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "namespaces.sqlite")
+	a := NewSqliteBackend(path, backend.WithNamespace("a"))
+	b := NewSqliteBackend(path, backend.WithNamespace("b"))
+
+	// Instance and execution IDs only need to be unique within a namespace
+	wfi := core.NewWorkflowInstance("instance", "execution")
+
+	for _, sb := range []*sqliteBackend{a, b} {
+		err := sb.CreateWorkflowInstance(ctx,
+			wfi,
+			history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+		)
+		require.NoError(t, err)
+
+		s, err := sb.GetStats(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), s.ActiveWorkflowInstances)
+	}
+
+	err := a.CreateWorkflowInstance(ctx,
+		wfi,
+		history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+	)
+	require.ErrorIs(t, err, backend.ErrInstanceAlreadyExists)
+}
+
+func Test_SqliteBackend_MigratesPrimaryKeys(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "migrate.sqlite")
+
+	// Create the tables with the primary keys of earlier versions of the schema
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%v", path))
+	require.NoError(t, err)
+
+	_, err = db.Exec(
+		"CREATE TABLE `instances` (`id` TEXT NOT NULL, `execution_id` TEXT NOT NULL, `parent_instance_id` TEXT NULL, " +
+			"`parent_execution_id` TEXT NULL, `parent_schedule_event_id` INTEGER NULL, `metadata` TEXT NULL, `state` INTEGER NOT NULL, " +
+			"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, `completed_at` DATETIME NULL, `locked_until` DATETIME NULL, " +
+			"`sticky_until` DATETIME NULL, `worker` TEXT NULL, PRIMARY KEY(`id`, `execution_id`));" +
+			"CREATE INDEX `idx_instances_id_execution_id` ON `instances` (`id`, `execution_id`);" +
+			"CREATE TABLE `activities` (`id` TEXT PRIMARY KEY, `instance_id` TEXT NOT NULL, `execution_id` TEXT NOT NULL, " +
+			"`event_type` INTEGER NOT NULL, `timestamp` DATETIME NOT NULL, `schedule_event_id` INT NOT NULL, `attributes` BLOB NOT NULL, " +
+			"`visible_at` DATETIME NULL, `locked_until` DATETIME NULL, `worker` TEXT NULL);" +
+			"CREATE TABLE `history` (`id` TEXT, `sequence_id` INTEGER NOT NULL, `instance_id` TEXT NOT NULL, " +
+			"`execution_id` TEXT NOT NULL, `event_type` INTEGER NOT NULL, `timestamp` DATETIME NOT NULL, `schedule_event_id` INT NOT NULL, " +
+			"`attributes` BLOB NOT NULL, `visible_at` DATETIME NULL, PRIMARY KEY(`id`, `instance_id`));" +
+			"INSERT INTO `instances` (`id`, `execution_id`, `state`) VALUES ('instance', 'execution', 0);" +
+			"INSERT INTO `history` (`id`, `sequence_id`, `instance_id`, `execution_id`, `event_type`, `timestamp`, `schedule_event_id`, `attributes`) " +
+			"VALUES ('event', 1, 'instance', 'execution', 1, CURRENT_TIMESTAMP, 0, '{}');",
+	)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	sb := NewSqliteBackend(path)
+
+	for _, table := range []string{"instances", "activities", "pending_events", "history"} {
+		var pk int
+		require.NoError(t, sb.db.QueryRow("SELECT pk FROM pragma_table_info(?) WHERE name = 'namespace'", table).Scan(&pk))
+		require.Equal(t, 1, pk, table)
+	}
+
+	// Existing rows are kept
+	s, err := sb.GetWorkflowInstanceState(ctx, core.NewWorkflowInstance("instance", "execution"))
+	require.NoError(t, err)
+	require.Equal(t, core.WorkflowInstanceStateActive, s)
+
+	h, err := sb.GetWorkflowInstanceHistory(ctx, core.NewWorkflowInstance("instance", "execution"), nil)
+	require.NoError(t, err)
+	require.Len(t, h, 1)
+
+	// The same IDs can be used in other namespaces
+	err = NewSqliteBackend(path, backend.WithNamespace("b")).CreateWorkflowInstance(ctx,
+		core.NewWorkflowInstance("instance", "execution"),
+		history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+	)
+	require.NoError(t, err)
+}
+
+func Test_SqliteBackend_Priorities(t *testing.T) {
+	ctx := context.Background()
+
+	b := NewInMemoryBackend(backend.WithPriorityStarvationInterval(3))
+
+	create := func(priority core.Priority) *core.WorkflowInstance {
+		wfi := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+		err := b.CreateWorkflowInstance(ctx,
+			wfi,
+			history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+				Priority: priority,
+			}),
+		)
+		require.NoError(t, err)
+
+		return wfi
+	}
+
+	low := create(core.PriorityLow)
+	normal := create(core.PriorityNormal)
+	high1 := create(core.PriorityHigh)
+	high2 := create(core.PriorityHigh)
+
+	dequeued := []*core.WorkflowInstance{}
+	for i := 0; i < 4; i++ {
+		tk, err := b.GetWorkflowTask(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, tk)
+		dequeued = append(dequeued, tk.WorkflowInstance)
+	}
+
+	// Every third dequeue prefers lower priorities so that they are not starved
+	require.ElementsMatch(t, []*core.WorkflowInstance{high1, high2}, dequeued[:2])
+	require.Equal(t, []*core.WorkflowInstance{normal, low}, dequeued[2:])
+}
+
+func Test_SqliteBackend_Clock(t *testing.T) {
+	ctx := context.Background()
+
+	c := clock.NewMock()
+	b := NewInMemoryBackend(
+		backend.WithClock(c),
+		backend.WithIDGenerator(func() string { return "worker" }),
+		backend.WithStickyTimeout(0),
+	)
+
+	wfi := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+	err := b.CreateWorkflowInstance(ctx,
+		wfi,
+		history.NewHistoryEvent(1, c.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+	)
+	require.NoError(t, err)
+
+	tk, err := b.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, tk)
+
+	// Task is locked until the lock timeout passes on the backend's clock
+	tk, err = b.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.Nil(t, tk)
+
+	c.Add(backend.DefaultOptions.WorkflowLockTimeout + time.Second)
+
+	tk, err = b.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, tk)
+	require.Equal(t, "worker-worker", b.workerName)
+
+	// Future events become visible when the backend's clock reaches them
+	err = b.CompleteWorkflowTask(ctx, tk, wfi, core.WorkflowInstanceStateActive, tk.NewEvents, nil, nil, nil)
+	require.NoError(t, err)
+
+	err = b.SignalWorkflow(ctx, wfi.InstanceID, history.NewPendingEvent(
+		c.Now(), history.EventType_SignalReceived, &history.SignalReceivedAttributes{Name: "signal"}, history.VisibleAt(c.Now().Add(time.Hour)),
+	))
+	require.NoError(t, err)
+
+	tk, err = b.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.Nil(t, tk)
+
+	c.Add(time.Hour)
+
+	tk, err = b.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, tk)
+	require.Len(t, tk.NewEvents, 1)
+}
+
+func Test_SqliteBackend_PrunesSignalDeduplication(t *testing.T) {
+	ctx := context.Background()
+
+	c := clock.NewMock()
+	b := NewInMemoryBackend(backend.WithClock(c), backend.WithSignalDeduplicationWindow(time.Minute))
+
+	wfi := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+	err := b.CreateWorkflowInstance(ctx,
+		wfi,
+		history.NewHistoryEvent(1, c.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+	)
+	require.NoError(t, err)
+
+	err = b.SignalWorkflow(ctx, wfi.InstanceID, history.NewPendingEvent(
+		c.Now(), history.EventType_SignalReceived, &history.SignalReceivedAttributes{Name: "signal"},
+	))
+	require.NoError(t, err)
+
+	signals := func() int {
+		var n int
+		require.NoError(t, b.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM `signal_deduplication`").Scan(&n))
+		return n
+	}
+
+	require.Equal(t, 1, signals())
+
+	// Signals are kept within the deduplication window
+	_, err = b.RemoveFinishedWorkflowInstances(ctx, c.Now(), "")
+	require.NoError(t, err)
+	require.Equal(t, 1, signals())
+
+	// Expired signals are removed even if their instance never receives another signal
+	c.Add(time.Minute * 2)
+
+	_, err = b.RemoveFinishedWorkflowInstances(ctx, c.Now(), "")
+	require.NoError(t, err)
+	require.Equal(t, 0, signals())
+}
+
+func Test_SqliteBackend_BuildID(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "buildid.sqlite")
+	v1 := NewSqliteBackend(path, backend.WithBuildID("v1"), backend.WithStickyTimeout(0))
+	v2 := NewSqliteBackend(path, backend.WithBuildID("v2"), backend.WithStickyTimeout(0))
+
+	wfi := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+	err := v1.CreateWorkflowInstance(ctx,
+		wfi,
+		history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+	)
+	require.NoError(t, err)
+
+	// The first workflow task pins the instance to the build ID of the worker
+	tk, err := v1.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, tk)
+
+	err = v1.CompleteWorkflowTask(ctx, tk, wfi, core.WorkflowInstanceStateActive, tk.NewEvents, nil, nil, nil)
+	require.NoError(t, err)
+
+	err = v2.SignalWorkflow(ctx, wfi.InstanceID, history.NewPendingEvent(
+		time.Now(), history.EventType_SignalReceived, &history.SignalReceivedAttributes{Name: "signal"},
+	))
+	require.NoError(t, err)
+
+	tk, err = v2.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.Nil(t, tk)
+
+	tk, err = v1.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, tk)
+	require.Equal(t, wfi, tk.WorkflowInstance)
+
+	// Backends without a build ID receive tasks of all instances
+	unversioned := NewSqliteBackend(path, backend.WithStickyTimeout(0))
+
+	wfi2 := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+	err = v2.CreateWorkflowInstance(ctx,
+		wfi2,
+		history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+	)
+	require.NoError(t, err)
+
+	tk, err = unversioned.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, tk)
+	require.Equal(t, wfi2, tk.WorkflowInstance)
+}
+
 var _ test.TestBackend = (*sqliteBackend)(nil)
 
 func (sb *sqliteBackend) GetFutureEvents(ctx context.Context) ([]*history.Event, error) {
@@ -88,3 +403,15 @@ func (sb *sqliteBackend) GetFutureEvents(ctx context.Context) ([]*history.Event,
 
 	return f, nil
 }
+
+func Test_SqliteBackend_Namespaces_SameWorkflow(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	path := filepath.Join(t.TempDir(), "namespaces.sqlite")
+
+	test.NamespacesBackendTest(t, func(namespace string) test.TestBackend {
+		return NewSqliteBackend(path, backend.WithNamespace(namespace), backend.WithStickyTimeout(0))
+	})
+}
@@ -19,35 +19,81 @@ func (b *sqliteBackend) GetStats(ctx context.Context) (*backend.Stats, error) {
 	}
 	defer tx.Rollback()
 
-	row := tx.QueryRowContext(
-		ctx,
-		"SELECT COUNT(*) FROM instances i WHERE i.completed_at IS NULL",
-	)
-	if err := row.Err(); err != nil {
-		return nil, fmt.Errorf("failed to query active instances: %w", err)
-	}
+	now := b.options.Clock.Now()
 
-	var activeInstances int64
-	if err := row.Scan(&activeInstances); err != nil {
-		return nil, fmt.Errorf("failed to scan active instances: %w", err)
+	counts := []struct {
+		name  string
+		dest  *int64
+		query string
+		args  []interface{}
+	}{
+		{
+			name:  "active instances",
+			dest:  &s.ActiveWorkflowInstances,
+			query: "SELECT COUNT(*) FROM instances i WHERE i.namespace = ? AND i.completed_at IS NULL",
+			args:  []interface{}{b.options.Namespace},
+		},
+		{
+			name: "pending workflow tasks",
+			dest: &s.PendingWorkflowTasks,
+			query: `SELECT COUNT(*) FROM instances i
+				WHERE
+					i.namespace = ?
+					AND (i.locked_until IS NULL OR i.locked_until < ?)
+					AND i.completed_at IS NULL
+					AND i.paused = 0
+					AND i.dead_letter_reason IS NULL
+					AND EXISTS (
+						SELECT 1
+							FROM pending_events
+							WHERE namespace = i.namespace AND instance_id = i.id AND execution_id = i.execution_id AND (visible_at IS NULL OR visible_at <= ?)
+					)`,
+			args: []interface{}{b.options.Namespace, now, now},
+		},
+		{
+			name:  "locked workflow tasks",
+			dest:  &s.LockedWorkflowTasks,
+			query: "SELECT COUNT(*) FROM instances i WHERE i.namespace = ? AND i.locked_until >= ? AND i.completed_at IS NULL",
+			args:  []interface{}{b.options.Namespace, now},
+		},
+		{
+			name:  "pending activities",
+			dest:  &s.PendingActivities,
+			query: "SELECT COUNT(*) FROM activities WHERE namespace = ?",
+			args:  []interface{}{b.options.Namespace},
+		},
+		{
+			name:  "pending activity tasks",
+			dest:  &s.PendingActivityTasks,
+			query: "SELECT COUNT(*) FROM activities WHERE namespace = ? AND (locked_until IS NULL OR locked_until < ?)",
+			args:  []interface{}{b.options.Namespace, now},
+		},
+		{
+			name:  "locked activity tasks",
+			dest:  &s.LockedActivityTasks,
+			query: "SELECT COUNT(*) FROM activities WHERE namespace = ? AND locked_until >= ?",
+			args:  []interface{}{b.options.Namespace, now},
+		},
+		{
+			name: "pending timers",
+			dest: &s.PendingTimers,
+			query: `SELECT COUNT(*) FROM pending_events pe
+				INNER JOIN instances i ON i.namespace = pe.namespace AND i.id = pe.instance_id AND i.execution_id = pe.execution_id
+				WHERE i.namespace = ? AND pe.visible_at > ?`,
+			args: []interface{}{b.options.Namespace, now},
+		},
 	}
 
-	s.ActiveWorkflowInstances = activeInstances
-
-	// Get pending activities
-	row = tx.QueryRowContext(
-		ctx,
-		"SELECT COUNT(*) FROM activities")
-	if err := row.Err(); err != nil {
-		return nil, fmt.Errorf("failed to query active activities: %w", err)
-	}
+	for _, c := range counts {
+		row := tx.QueryRowContext(ctx, c.query, c.args...)
+		if err := row.Err(); err != nil {
+			return nil, fmt.Errorf("failed to query %v: %w", c.name, err)
+		}
 
-	var pendingActivities int64
-	if err := row.Scan(&pendingActivities); err != nil {
-		return nil, fmt.Errorf("failed to scan active activities: %w", err)
+		if err := row.Scan(c.dest); err != nil {
+			return nil, fmt.Errorf("failed to scan %v: %w", c.name, err)
+		}
 	}
 
-	s.PendingActivities = pendingActivities
-
 	return s, nil
 }
@@ -3,5 +3,23 @@ package backend
 type Stats struct {
 	ActiveWorkflowInstances int64
 
+	// PendingActivities is the number of activity tasks that have been scheduled and not completed yet, including
+	// locked ones
 	PendingActivities int64
+
+	// PendingWorkflowTasks is the number of workflow instances with new events that are waiting for a worker
+	PendingWorkflowTasks int64
+
+	// LockedWorkflowTasks is the number of workflow tasks currently locked by a worker
+	LockedWorkflowTasks int64
+
+	// PendingActivityTasks is the number of activity tasks waiting for a worker
+	PendingActivityTasks int64
+
+	// LockedActivityTasks is the number of activity tasks currently locked by a worker
+	LockedActivityTasks int64
+
+	// PendingTimers is the number of timers and other future events, like execution timeouts and delayed starts, that
+	// have not fired yet
+	PendingTimers int64
 }
@@ -3,24 +3,35 @@ package test
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/archive"
 	"github.com/cschleiden/go-workflows/client"
 	"github.com/cschleiden/go-workflows/diag"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
 	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/maintenance"
 	"github.com/cschleiden/go-workflows/workflow"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
 func BackendTest(t *testing.T, setup func(options ...backend.BackendOption) TestBackend, teardown func(b TestBackend)) {
+	archiver := archive.NewFileArchiver(t.TempDir())
+	unavailableArchiver := &flakyArchiver{Archiver: archive.NewFileArchiver(t.TempDir())}
+	retentionClock := clock.NewMock()
+	purgeClock := clock.NewMock()
+
 	tests := []struct {
-		name string
-		f    func(t *testing.T, ctx context.Context, b backend.Backend)
+		name    string
+		options []backend.BackendOption
+		f       func(t *testing.T, ctx context.Context, b backend.Backend)
 	}{
 		{
 			name: "CreateWorkflowInstance_DoesNotError",
@@ -56,6 +67,132 @@ func BackendTest(t *testing.T, setup func(options ...backend.BackendOption) Test
 				require.ErrorIs(t, err, backend.ErrInstanceAlreadyExists)
 			},
 		},
+		{
+			name: "CreateWorkflowInstance_ActiveInstanceIDErrors",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				instanceID := uuid.NewString()
+
+				err := b.CreateWorkflowInstance(ctx,
+					core.NewWorkflowInstance(instanceID, uuid.NewString()),
+					history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+				)
+				require.NoError(t, err)
+
+				// Different execution of the same, still active, instance
+				err = b.CreateWorkflowInstance(
+					ctx,
+					core.NewWorkflowInstance(instanceID, uuid.NewString()),
+					history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+				)
+				require.ErrorIs(t, err, backend.ErrInstanceAlreadyExists)
+			},
+		},
+		{
+			name: "CreateWorkflowInstance_ConcurrentCreatesOfSameInstance",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				instanceID := uuid.NewString()
+
+				const creates = 5
+				errs := make(chan error, creates)
+				for i := 0; i < creates; i++ {
+					go func() {
+						errs <- b.CreateWorkflowInstance(ctx,
+							core.NewWorkflowInstance(instanceID, uuid.NewString()),
+							history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+						)
+					}()
+				}
+
+				created := 0
+				for i := 0; i < creates; i++ {
+					if err := <-errs; err == nil {
+						created++
+					}
+				}
+
+				require.Equal(t, 1, created)
+			},
+		},
+		{
+			name: "CreateWorkflowInstance_RejectDuplicate",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				instanceID := uuid.NewString()
+				finishWorkflow(t, ctx, b, core.NewWorkflowInstance(instanceID, uuid.NewString()))
+
+				err := b.CreateWorkflowInstance(ctx,
+					core.NewWorkflowInstance(instanceID, uuid.NewString()),
+					history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+						InstanceIDReusePolicy: core.InstanceIDReusePolicyRejectDuplicate,
+					}),
+				)
+				require.ErrorIs(t, err, backend.ErrInstanceAlreadyExists)
+
+				// Instance IDs of finished executions can be reused by default
+				err = b.CreateWorkflowInstance(ctx,
+					core.NewWorkflowInstance(instanceID, uuid.NewString()),
+					history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+				)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "CreateWorkflowInstance_TerminateExisting",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				instanceID := uuid.NewString()
+				existing := core.NewWorkflowInstance(instanceID, uuid.NewString())
+				startWorkflow(t, ctx, b, nil, existing)
+
+				wfi := core.NewWorkflowInstance(instanceID, uuid.NewString())
+				err := b.CreateWorkflowInstance(ctx,
+					wfi,
+					history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+						InstanceIDReusePolicy: core.InstanceIDReusePolicyTerminateExisting,
+					}),
+				)
+				require.NoError(t, err)
+
+				state, err := b.GetWorkflowInstanceState(ctx, existing)
+				require.NoError(t, err)
+				require.Equal(t, core.WorkflowInstanceStateFinished, state)
+
+				h, err := b.GetWorkflowInstanceHistory(ctx, existing, nil)
+				require.NoError(t, err)
+				require.Equal(t, history.EventType_WorkflowExecutionTerminated, h[len(h)-1].Type)
+
+				task, err := b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.Equal(t, wfi.ExecutionID, task.WorkflowInstance.ExecutionID)
+			},
+		},
+		{
+			name: "CreateWorkflowInstance_TerminateExistingBeforeStart",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				instanceID := uuid.NewString()
+				existing := core.NewWorkflowInstance(instanceID, uuid.NewString())
+				err := b.CreateWorkflowInstance(ctx,
+					existing,
+					history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}),
+				)
+				require.NoError(t, err)
+
+				err = b.CreateWorkflowInstance(ctx,
+					core.NewWorkflowInstance(instanceID, uuid.NewString()),
+					history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+						InstanceIDReusePolicy: core.InstanceIDReusePolicyTerminateExisting,
+					}),
+				)
+				require.NoError(t, err)
+
+				// The history of the terminated execution is well-formed
+				h, err := b.GetWorkflowInstanceHistory(ctx, existing, nil)
+				require.NoError(t, err)
+				require.Len(t, h, 3)
+				require.Equal(t, history.EventType_WorkflowTaskStarted, h[0].Type)
+				require.Equal(t, history.EventType_WorkflowExecutionStarted, h[1].Type)
+				require.Equal(t, history.EventType_WorkflowExecutionTerminated, h[2].Type)
+			},
+		},
 		{
 			name: "CreateWorkflowInstance_Metadata",
 			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
@@ -308,6 +445,311 @@ func BackendTest(t *testing.T, setup func(options ...backend.BackendOption) Test
 				require.Equal(t, backend.ErrInstanceNotFound, err)
 			},
 		},
+		{
+			name:    "CreateWorkflowInstance_ConcurrencyLimit",
+			options: []backend.BackendOption{backend.WithWorkflowConcurrencyLimit(concurrencyLimitedWorkflow, 1)},
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				create := func(name string) (*core.WorkflowInstance, error) {
+					instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+					return instance, b.CreateWorkflowInstance(ctx, instance, history.NewHistoryEvent(
+						1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{Name: name}))
+				}
+
+				instance, err := create("concurrencyLimitedWorkflow")
+				require.NoError(t, err)
+
+				_, err = create("concurrencyLimitedWorkflow")
+				require.ErrorIs(t, err, backend.ErrConcurrencyLimitReached)
+
+				// Other workflows are not limited
+				_, err = create("otherWorkflow")
+				require.NoError(t, err)
+
+				// Finish the limited instance to free up its slot
+				var wfTask *task.Workflow
+				for wfTask == nil || wfTask.WorkflowInstance.InstanceID != instance.InstanceID {
+					wfTask, err = b.GetWorkflowTask(ctx)
+					require.NoError(t, err)
+					require.NotNil(t, wfTask)
+				}
+
+				require.NoError(t, b.CompleteWorkflowTask(
+					ctx, wfTask, instance, core.WorkflowInstanceStateFinished, wfTask.NewEvents, []*history.Event{}, []*history.Event{}, []history.WorkflowEvent{}))
+
+				_, err = create("concurrencyLimitedWorkflow")
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "CreateWorkflowInstance_ActiveInstanceLimits",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				create := func(ctx context.Context, tenant string) (*core.WorkflowInstance, error) {
+					instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+					return instance, b.CreateWorkflowInstance(ctx, instance, history.NewHistoryEvent(
+						1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+							Name: "limitedWorkflow",
+							SearchAttributes: core.SearchAttributes{
+								"tenant": core.NewStringSearchAttribute(tenant),
+							},
+						}))
+				}
+
+				tenantCtx := func(tenant string) context.Context {
+					return backend.WithActiveInstanceLimits(ctx, backend.ActiveInstanceLimit{
+						Tenant: tenant, TenantSearchAttribute: "tenant", Max: 1,
+					})
+				}
+
+				// Concurrent creations don't exceed the limit
+				type result struct {
+					instance *core.WorkflowInstance
+					err      error
+				}
+
+				results := make(chan result, 5)
+				for i := 0; i < cap(results); i++ {
+					go func() {
+						instance, err := create(tenantCtx("a"), "a")
+						results <- result{instance, err}
+					}()
+				}
+
+				var instance *core.WorkflowInstance
+				for i := 0; i < cap(results); i++ {
+					r := <-results
+					if r.err != nil {
+						require.ErrorIs(t, r.err, backend.ErrQuotaExceeded)
+						continue
+					}
+
+					require.Nil(t, instance, "limit exceeded by concurrent creations")
+					instance = r.instance
+				}
+				require.NotNil(t, instance)
+
+				_, err := create(tenantCtx("a"), "a")
+				var qerr *backend.QuotaExceededError
+				require.ErrorAs(t, err, &qerr)
+				require.Equal(t, "a", qerr.Tenant)
+
+				// Other tenants are not limited
+				_, err = create(tenantCtx("b"), "b")
+				require.NoError(t, err)
+
+				// The namespace limit counts all active instances
+				namespaceCtx := backend.WithActiveInstanceLimits(ctx, backend.ActiveInstanceLimit{Max: 3})
+				_, err = create(namespaceCtx, "c")
+				require.NoError(t, err)
+
+				_, err = create(namespaceCtx, "d")
+				require.ErrorAs(t, err, &qerr)
+				require.Empty(t, qerr.Tenant)
+
+				// Finish the instance of the tenant to free up its slot
+				var wfTask *task.Workflow
+				for wfTask == nil || wfTask.WorkflowInstance.InstanceID != instance.InstanceID {
+					wfTask, err = b.GetWorkflowTask(ctx)
+					require.NoError(t, err)
+					require.NotNil(t, wfTask)
+				}
+
+				require.NoError(t, b.CompleteWorkflowTask(
+					ctx, wfTask, instance, core.WorkflowInstanceStateFinished, wfTask.NewEvents, []*history.Event{}, []*history.Event{}, []history.WorkflowEvent{}))
+
+				_, err = create(tenantCtx("a"), "a")
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:    "SignalWorkflow_DropsDuplicateSignals",
+			options: []backend.BackendOption{backend.WithSignalDeduplicationWindow(time.Minute)},
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+				instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				startWorkflow(t, ctx, b, c, instance)
+
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", 1))
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", 1))
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", 2))
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "other-signal", 1))
+
+				task, err := b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.Len(t, task.NewEvents, 3)
+			},
+		},
+		{
+			name: "SignalWorkflow_HoldsDelayedSignals",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+				instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				startWorkflow(t, ctx, b, c, instance)
+
+				err := c.SignalWorkflow(ctx, instance.InstanceID, "signal", 1, client.WithDeliverAt(time.Now().Add(time.Millisecond*200)))
+				require.NoError(t, err)
+
+				tctx, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+				defer cancel()
+
+				task, _ := b.GetWorkflowTask(tctx)
+				require.Nil(t, task)
+
+				time.Sleep(time.Millisecond * 200)
+
+				task, err = b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.Len(t, task.NewEvents, 1)
+				require.Equal(t, history.EventType_SignalReceived, task.NewEvents[0].Type)
+			},
+		},
+		{
+			name: "PauseWorkflow_HoldsTasksUntilResumed",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+				instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				startWorkflow(t, ctx, b, c, instance)
+
+				require.NoError(t, c.PauseWorkflowInstance(ctx, instance))
+				// Pausing again doesn't record another event
+				require.NoError(t, c.PauseWorkflowInstance(ctx, instance))
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", 1))
+
+				tctx, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+				defer cancel()
+
+				task, _ := b.GetWorkflowTask(tctx)
+				require.Nil(t, task)
+
+				require.NoError(t, c.ResumeWorkflowInstance(ctx, instance))
+
+				task, err := b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.Len(t, task.NewEvents, 3)
+				require.Equal(t, history.EventType_WorkflowExecutionPaused, task.NewEvents[0].Type)
+				require.Equal(t, history.EventType_SignalReceived, task.NewEvents[1].Type)
+				require.Equal(t, history.EventType_WorkflowExecutionResumed, task.NewEvents[2].Type)
+			},
+		},
+		{
+			name:    "GetWorkflowTask_CountsAttempts",
+			options: []backend.BackendOption{backend.WithWorkflowLockTimeout(time.Millisecond * 100)},
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+				instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				require.NoError(t, b.CreateWorkflowInstance(
+					ctx, instance, history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{})))
+
+				task, err := b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.Equal(t, 1, task.Attempt)
+
+				// Wait for the lock to expire
+				time.Sleep(time.Millisecond * 200)
+
+				task, err = b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.Equal(t, 2, task.Attempt)
+
+				require.NoError(t, b.CompleteWorkflowTask(
+					ctx, task, instance, core.WorkflowInstanceStateActive, task.NewEvents, []*history.Event{}, []*history.Event{}, []history.WorkflowEvent{}))
+
+				// Completing a task resets the attempts
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", 1))
+
+				task, err = b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.Equal(t, 1, task.Attempt)
+			},
+		},
+		{
+			name: "DeadLetterWorkflowTask_HoldsTasksUntilRetried",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+				instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				startWorkflow(t, ctx, b, c, instance)
+
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", 1))
+
+				task, err := b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.NoError(t, b.DeadLetterWorkflowTask(ctx, task, "poison"))
+
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", 2))
+
+				tctx, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+				defer cancel()
+
+				deadLettered, _ := b.GetWorkflowTask(tctx)
+				require.Nil(t, deadLettered)
+
+				r, err := c.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{State: backend.InstanceStateDeadLettered})
+				require.NoError(t, err)
+				require.Len(t, r.Instances, 1)
+				require.Equal(t, instance.InstanceID, r.Instances[0].Instance.InstanceID)
+				require.Equal(t, "poison", r.Instances[0].DeadLetterReason)
+
+				require.NoError(t, c.RetryWorkflowInstance(ctx, instance))
+				// Retrying again doesn't do anything
+				require.NoError(t, c.RetryWorkflowInstance(ctx, instance))
+
+				task, err = b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.Equal(t, 1, task.Attempt)
+				require.Len(t, task.NewEvents, 2)
+				require.Equal(t, history.EventType_SignalReceived, task.NewEvents[0].Type)
+				require.Equal(t, history.EventType_SignalReceived, task.NewEvents[1].Type)
+
+				r, err = c.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{State: backend.InstanceStateDeadLettered})
+				require.NoError(t, err)
+				require.Empty(t, r.Instances)
+			},
+		},
+		{
+			name: "DeadLetterWorkflowTask_TerminateResumesInstance",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+				instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				startWorkflow(t, ctx, b, c, instance)
+
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", 1))
+
+				task, err := b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.NoError(t, b.DeadLetterWorkflowTask(ctx, task, "poison"))
+
+				require.NoError(t, c.TerminateWorkflowInstance(ctx, instance, "giving up"))
+
+				task, err = b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.Equal(t, history.EventType_WorkflowExecutionTerminated, task.NewEvents[len(task.NewEvents)-1].Type)
+			},
+		},
+		{
+			name: "RetryWorkflowInstance_ErrorWhenInstanceDoesNotExist",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+				err := c.RetryWorkflowInstance(ctx, core.NewWorkflowInstance(uuid.NewString(), uuid.NewString()))
+				require.ErrorIs(t, err, backend.ErrInstanceNotFound)
+			},
+		},
+		{
+			name: "PauseWorkflow_ErrorWhenInstanceDoesNotExist",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+				err := c.PauseWorkflowInstance(ctx, core.NewWorkflowInstance(uuid.NewString(), uuid.NewString()))
+				require.ErrorIs(t, err, backend.ErrInstanceNotFound)
+			},
+		},
 		{
 			name: "CancelWorkflow_ErrorWhenInstanceDoesNotExist",
 			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
@@ -334,21 +776,622 @@ func BackendTest(t *testing.T, setup func(options ...backend.BackendOption) Test
 			},
 		},
 		{
-			name: "GetActivityTask_ReturnsNilWhenTimeout",
+			name: "Schedules_CreateUpdateDelete",
 			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
-				ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
-				defer cancel()
+				ss, ok := b.(backend.ScheduleStore)
+				require.True(t, ok)
 
-				task, _ := b.GetActivityTask(ctx)
-				require.Nil(t, task)
-			},
-		},
-	}
+				s := &backend.Schedule{
+					ID:            uuid.NewString(),
+					Interval:      time.Minute,
+					OverlapPolicy: backend.OverlapBuffer,
+					WorkflowName:  "wf",
+					NextRunAt:     time.Now().Add(time.Minute).Truncate(time.Second).UTC(),
+				}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			b := setup()
-			ctx := context.Background()
+				require.NoError(t, ss.CreateSchedule(ctx, s))
+				require.ErrorIs(t, ss.CreateSchedule(ctx, s), backend.ErrScheduleAlreadyExists)
+
+				find := func() *backend.Schedule {
+					schedules, err := ss.ListSchedules(ctx)
+					require.NoError(t, err)
+
+					for _, found := range schedules {
+						if found.ID == s.ID {
+							return found
+						}
+					}
+
+					return nil
+				}
+
+				require.Equal(t, s, find())
+
+				s.NextRunAt = s.NextRunAt.Add(time.Minute)
+				s.LastInstance = core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				require.NoError(t, ss.UpdateSchedule(ctx, s))
+				// Updating without changes succeeds as well
+				require.NoError(t, ss.UpdateSchedule(ctx, s))
+				require.Equal(t, s, find())
+
+				require.NoError(t, ss.DeleteSchedule(ctx, s.ID))
+				require.Nil(t, find())
+
+				require.ErrorIs(t, ss.DeleteSchedule(ctx, s.ID), backend.ErrScheduleNotFound)
+				require.ErrorIs(t, ss.UpdateSchedule(ctx, s), backend.ErrScheduleNotFound)
+			},
+		},
+		{
+			name: "ListWorkflowInstances_Paginates",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+
+				created := map[string]bool{}
+				for i := 0; i < 5; i++ {
+					instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+					startWorkflow(t, ctx, b, c, instance)
+					created[instance.InstanceID] = true
+				}
+
+				listed := map[string]bool{}
+				query := &backend.ListWorkflowInstancesQuery{PageSize: 2}
+				pages := 0
+				for {
+					r, err := c.ListWorkflowInstances(ctx, query)
+					require.NoError(t, err)
+					require.LessOrEqual(t, len(r.Instances), 2)
+
+					for _, i := range r.Instances {
+						require.False(t, listed[i.Instance.InstanceID], "instance listed twice")
+						listed[i.Instance.InstanceID] = true
+					}
+
+					pages++
+					if r.NextPageToken == "" {
+						break
+					}
+
+					query.PageToken = r.NextPageToken
+				}
+
+				require.Equal(t, 3, pages)
+				require.Equal(t, created, listed)
+
+				_, err := c.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{PageToken: "invalid"})
+				require.ErrorIs(t, err, backend.ErrInvalidPageToken)
+			},
+		},
+		{
+			name:    "RetentionJob_RemovesExpiredInstances",
+			options: []backend.BackendOption{backend.WithRetention(time.Hour), backend.WithClock(retentionClock)},
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				retentionClock.Set(time.Now().Add(-2 * time.Hour))
+				expired := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				finishWorkflow(t, ctx, b, expired)
+
+				retentionClock.Set(time.Now())
+				finished := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				finishWorkflow(t, ctx, b, finished)
+
+				require.NoError(t, maintenance.RetentionJob(time.Minute).Run(ctx, b))
+
+				_, err := b.GetWorkflowInstanceState(ctx, expired)
+				require.ErrorIs(t, err, backend.ErrInstanceNotFound)
+
+				state, err := b.GetWorkflowInstanceState(ctx, finished)
+				require.NoError(t, err)
+				require.Equal(t, core.WorkflowInstanceStateFinished, state)
+			},
+		},
+		{
+			name:    "RemoveFinishedWorkflowInstances_RemovesInBulk",
+			options: []backend.BackendOption{backend.WithClock(purgeClock)},
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				purgeClock.Set(time.Now().Add(-2 * time.Hour))
+				old := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				finishNamedWorkflow(t, ctx, b, old, "wf1")
+				oldOther := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				finishNamedWorkflow(t, ctx, b, oldOther, "wf2")
+
+				purgeClock.Set(time.Now())
+				recent := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				finishNamedWorkflow(t, ctx, b, recent, "wf1")
+
+				removed, err := backend.RemoveFinishedWorkflowInstances(ctx, b, time.Now().Add(-time.Hour), "wf1")
+				require.NoError(t, err)
+				require.Equal(t, 1, removed)
+
+				_, err = b.GetWorkflowInstanceState(ctx, old)
+				require.ErrorIs(t, err, backend.ErrInstanceNotFound)
+
+				for _, i := range []*core.WorkflowInstance{oldOther, recent} {
+					state, err := b.GetWorkflowInstanceState(ctx, i)
+					require.NoError(t, err)
+					require.Equal(t, core.WorkflowInstanceStateFinished, state)
+				}
+
+				removed, err = backend.RemoveFinishedWorkflowInstances(ctx, b, time.Now().Add(-time.Hour), "")
+				require.NoError(t, err)
+				require.Equal(t, 1, removed)
+
+				_, err = b.GetWorkflowInstanceState(ctx, oldOther)
+				require.ErrorIs(t, err, backend.ErrInstanceNotFound)
+			},
+		},
+		{
+			name: "ListWorkflowInstances_FiltersByState",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+
+				finished := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				finishWorkflow(t, ctx, b, finished)
+
+				active := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				startWorkflow(t, ctx, b, c, active)
+
+				canceled := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				startWorkflow(t, ctx, b, c, canceled)
+				require.NoError(t, c.CancelWorkflowInstance(ctx, canceled))
+
+				list := func(state backend.InstanceStateFilter) []string {
+					r, err := c.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{State: state})
+					require.NoError(t, err)
+
+					ids := []string{}
+					for _, i := range r.Instances {
+						ids = append(ids, i.Instance.InstanceID)
+					}
+
+					return ids
+				}
+
+				require.ElementsMatch(t, []string{finished.InstanceID, active.InstanceID, canceled.InstanceID}, list(backend.InstanceStateAll))
+				require.ElementsMatch(t, []string{active.InstanceID, canceled.InstanceID}, list(backend.InstanceStateActive))
+				require.ElementsMatch(t, []string{finished.InstanceID}, list(backend.InstanceStateFinished))
+				require.ElementsMatch(t, []string{canceled.InstanceID}, list(backend.InstanceStateCanceled))
+			},
+		},
+		{
+			name: "ListWorkflowInstances_FiltersByCreatedAt",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+
+				instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				startWorkflow(t, ctx, b, c, instance)
+
+				now := time.Now()
+
+				r, err := c.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{
+					CreatedAfter:  now.Add(-time.Hour),
+					CreatedBefore: now.Add(time.Hour),
+				})
+				require.NoError(t, err)
+				require.Len(t, r.Instances, 1)
+				require.Equal(t, instance.InstanceID, r.Instances[0].Instance.InstanceID)
+				require.False(t, r.Instances[0].CreatedAt.IsZero())
+
+				r, err = c.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{CreatedBefore: now.Add(-time.Hour)})
+				require.NoError(t, err)
+				require.Empty(t, r.Instances)
+
+				r, err = c.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{CreatedAfter: now.Add(time.Hour)})
+				require.NoError(t, err)
+				require.Empty(t, r.Instances)
+			},
+		},
+		{
+			name: "ListWorkflowInstances_FiltersBySearchAttributes",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+
+				contoso := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				require.NoError(t, b.CreateWorkflowInstance(ctx, contoso, history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+					SearchAttributes: core.SearchAttributes{
+						"customer": core.NewStringSearchAttribute("contoso"),
+						"tier":     core.NewIntSearchAttribute(1),
+					},
+				})))
+
+				// Upsert attributes from the workflow
+				task, err := b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+
+				events := append(task.NewEvents,
+					history.NewHistoryEvent(-1, time.Now(), history.EventType_SearchAttributesUpserted, &history.SearchAttributesUpsertedAttributes{
+						SearchAttributes: core.SearchAttributes{
+							"tier": core.NewIntSearchAttribute(2),
+							"vip":  core.NewBoolSearchAttribute(true),
+						},
+					}))
+
+				require.NoError(t, b.CompleteWorkflowTask(
+					ctx, task, contoso, core.WorkflowInstanceStateActive, events, []*history.Event{}, []*history.Event{}, []history.WorkflowEvent{}))
+
+				fabrikam := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				require.NoError(t, b.CreateWorkflowInstance(ctx, fabrikam, history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+					SearchAttributes: core.SearchAttributes{
+						"customer": core.NewStringSearchAttribute("fabrikam"),
+						"tier":     core.NewIntSearchAttribute(2),
+					},
+				})))
+
+				list := func(attributes core.SearchAttributes) []string {
+					r, err := c.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{SearchAttributes: attributes})
+					require.NoError(t, err)
+
+					ids := []string{}
+					for _, i := range r.Instances {
+						ids = append(ids, i.Instance.InstanceID)
+					}
+
+					return ids
+				}
+
+				require.ElementsMatch(t, []string{contoso.InstanceID}, list(core.SearchAttributes{"customer": core.NewStringSearchAttribute("contoso")}))
+				require.ElementsMatch(t, []string{contoso.InstanceID, fabrikam.InstanceID}, list(core.SearchAttributes{"tier": core.NewIntSearchAttribute(2)}))
+				require.ElementsMatch(t, []string{contoso.InstanceID}, list(core.SearchAttributes{
+					"tier": core.NewIntSearchAttribute(2),
+					"vip":  core.NewBoolSearchAttribute(true),
+				}))
+				require.Empty(t, list(core.SearchAttributes{"tier": core.NewIntSearchAttribute(1)}))
+				require.Empty(t, list(core.SearchAttributes{"tier": core.NewStringSearchAttribute("2")}))
+			},
+		},
+		{
+			name: "ListWorkflowInstances_ReturnsMemo",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+
+				instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				require.NoError(t, b.CreateWorkflowInstance(ctx, instance, history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+					Memo: core.Memo{"owner": "team-a", "ticket": "1234"},
+				})))
+
+				other := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				require.NoError(t, b.CreateWorkflowInstance(ctx, other, history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{})))
+
+				r, err := c.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{})
+				require.NoError(t, err)
+
+				memos := map[string]core.Memo{}
+				for _, i := range r.Instances {
+					memos[i.Instance.InstanceID] = i.Memo
+				}
+
+				require.Equal(t, core.Memo{"owner": "team-a", "ticket": "1234"}, memos[instance.InstanceID])
+				require.Empty(t, memos[other.InstanceID])
+
+				if db, ok := b.(diag.Backend); ok {
+					ref, err := db.GetWorkflowInstance(ctx, instance)
+					require.NoError(t, err)
+					require.Equal(t, core.Memo{"owner": "team-a", "ticket": "1234"}, ref.Memo)
+				}
+			},
+		},
+		{
+			name: "GetWorkflowInstanceInfo_ReturnsParent",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				parent := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				require.NoError(t, b.CreateWorkflowInstance(ctx, parent, history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+					Name: "parent",
+				})))
+
+				child := core.NewSubWorkflowInstance(uuid.NewString(), uuid.NewString(), parent, 42)
+				require.NoError(t, b.CreateWorkflowInstance(ctx, child, history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+					Name: "child",
+					Memo: core.Memo{"owner": "team-a"},
+				})))
+
+				info, err := b.GetWorkflowInstanceInfo(ctx, parent)
+				require.NoError(t, err)
+				require.Equal(t, parent.InstanceID, info.Instance.InstanceID)
+				require.Nil(t, info.Instance.Parent)
+				require.Equal(t, "parent", info.WorkflowName)
+				require.Equal(t, core.WorkflowInstanceStateActive, info.State)
+				require.Nil(t, info.CompletedAt)
+
+				info, err = b.GetWorkflowInstanceInfo(ctx, child)
+				require.NoError(t, err)
+				require.Equal(t, child.InstanceID, info.Instance.InstanceID)
+				require.Equal(t, child.ExecutionID, info.Instance.ExecutionID)
+				require.NotNil(t, info.Instance.Parent)
+				require.Equal(t, parent.InstanceID, info.Instance.Parent.InstanceID)
+				require.Equal(t, parent.ExecutionID, info.Instance.Parent.ExecutionID)
+				require.Equal(t, int64(42), info.Instance.ParentEventID)
+				require.Equal(t, "child", info.WorkflowName)
+				require.Equal(t, core.Memo{"owner": "team-a"}, info.Memo)
+
+				_, err = b.GetWorkflowInstanceInfo(ctx, core.NewWorkflowInstance(uuid.NewString(), uuid.NewString()))
+				require.ErrorIs(t, err, backend.ErrInstanceNotFound)
+			},
+		},
+		{
+			name: "GetActivityTask_ReturnsNilWhenTimeout",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+				defer cancel()
+
+				task, _ := b.GetActivityTask(ctx)
+				require.Nil(t, task)
+			},
+		},
+		{
+			name:    "GetActivityTask_RedeliversExpiredTasks",
+			options: []backend.BackendOption{backend.WithActivityLockTimeout(time.Millisecond * 100)},
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				c := client.New(b)
+				instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				startWorkflow(t, ctx, b, c, instance)
+
+				// Schedule activity
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", nil))
+				wfTask, err := b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+
+				activityScheduled := history.NewPendingEvent(time.Now(), history.EventType_ActivityScheduled, &history.ActivityScheduledAttributes{
+					Name: "activity",
+				}, history.ScheduleEventID(1))
+				activityScheduled.SequenceID = 3
+				require.NoError(t, b.CompleteWorkflowTask(
+					ctx, wfTask, instance, core.WorkflowInstanceStateActive, append(wfTask.NewEvents, activityScheduled), []*history.Event{activityScheduled}, []*history.Event{}, []history.WorkflowEvent{}))
+
+				task, err := b.GetActivityTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.False(t, task.Redelivered)
+
+				// Wait for the lock to expire
+				time.Sleep(time.Millisecond * 200)
+
+				redelivered, err := b.GetActivityTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, redelivered)
+				require.True(t, redelivered.Redelivered)
+				require.Equal(t, task.ID, redelivered.ID)
+			},
+		},
+		{
+			name:    "Archiver_MovesHistoryOfFinishedInstances",
+			options: []backend.BackendOption{backend.WithArchiver(archiver)},
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				active := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				startWorkflow(t, ctx, b, client.New(b), active)
+
+				_, err := archiver.GetHistory(ctx, backend.DefaultNamespace, active)
+				require.ErrorIs(t, err, backend.ErrNotArchived)
+
+				finished := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				finishWorkflow(t, ctx, b, finished)
+
+				archived, err := archiver.GetHistory(ctx, backend.DefaultNamespace, finished)
+				require.NoError(t, err)
+				require.Len(t, archived, 2)
+
+				// Archived histories are still returned by the backend
+				h, err := b.GetWorkflowInstanceHistory(ctx, finished, nil)
+				require.NoError(t, err)
+				require.Len(t, h, 2)
+				require.Equal(t, archived[0].ID, h[0].ID)
+				require.Equal(t, archived[1].ID, h[1].ID)
+			},
+		},
+		{
+			name:    "Archiver_ArchivesTerminatedExecutions",
+			options: []backend.BackendOption{backend.WithArchiver(archiver)},
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				instanceID := uuid.NewString()
+				existing := core.NewWorkflowInstance(instanceID, uuid.NewString())
+				startWorkflow(t, ctx, b, nil, existing)
+
+				err := b.CreateWorkflowInstance(ctx,
+					core.NewWorkflowInstance(instanceID, uuid.NewString()),
+					history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+						InstanceIDReusePolicy: core.InstanceIDReusePolicyTerminateExisting,
+					}),
+				)
+				require.NoError(t, err)
+
+				archived, err := archiver.GetHistory(ctx, backend.DefaultNamespace, existing)
+				require.NoError(t, err)
+				require.Equal(t, history.EventType_WorkflowExecutionTerminated, archived[len(archived)-1].Type)
+			},
+		},
+		{
+			name:    "ArchiveJob_RetriesFailedArchives",
+			options: []backend.BackendOption{backend.WithArchiver(unavailableArchiver)},
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				unavailableArchiver.unavailable.Store(true)
+				defer unavailableArchiver.unavailable.Store(false)
+
+				instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				finishWorkflow(t, ctx, b, instance)
+
+				_, err := unavailableArchiver.GetHistory(ctx, backend.DefaultNamespace, instance)
+				require.ErrorIs(t, err, backend.ErrNotArchived)
+
+				// Instances are kept until their history has been archived
+				removed, err := backend.RemoveFinishedWorkflowInstances(ctx, b, time.Now().Add(time.Hour), "")
+				require.NoError(t, err)
+				require.Zero(t, removed)
+
+				require.Error(t, maintenance.ArchiveJob(time.Minute).Run(ctx, b))
+
+				unavailableArchiver.unavailable.Store(false)
+				require.NoError(t, maintenance.ArchiveJob(time.Minute).Run(ctx, b))
+
+				archived, err := unavailableArchiver.GetHistory(ctx, backend.DefaultNamespace, instance)
+				require.NoError(t, err)
+				require.Len(t, archived, 2)
+
+				h, err := b.GetWorkflowInstanceHistory(ctx, instance, nil)
+				require.NoError(t, err)
+				require.Len(t, h, 2)
+
+				removed, err = backend.RemoveFinishedWorkflowInstances(ctx, b, time.Now().Add(time.Hour), "")
+				require.NoError(t, err)
+				require.Equal(t, 1, removed)
+			},
+		},
+		{
+			name:    "RecordActivityHeartbeat_PersistsHeartbeat",
+			options: []backend.BackendOption{backend.WithActivityLockTimeout(time.Millisecond * 100)},
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				ah, ok := b.(backend.ActivityHeartbeater)
+				if !ok {
+					t.Skip("backend does not support activity heartbeats")
+				}
+
+				c := client.New(b)
+				instance := core.NewWorkflowInstance(uuid.NewString(), uuid.NewString())
+				startWorkflow(t, ctx, b, c, instance)
+
+				// Schedule activity
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", nil))
+				wfTask, err := b.GetWorkflowTask(ctx)
+				require.NoError(t, err)
+
+				activityScheduled := history.NewPendingEvent(time.Now(), history.EventType_ActivityScheduled, &history.ActivityScheduledAttributes{
+					Name: "activity",
+				}, history.ScheduleEventID(1))
+				activityScheduled.SequenceID = 3
+				require.NoError(t, b.CompleteWorkflowTask(
+					ctx, wfTask, instance, core.WorkflowInstanceStateActive, append(wfTask.NewEvents, activityScheduled), []*history.Event{activityScheduled}, []*history.Event{}, []history.WorkflowEvent{}))
+
+				task, err := b.GetActivityTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, task)
+				require.True(t, task.LastHeartbeat.IsZero())
+				require.Nil(t, task.HeartbeatDetails)
+
+				require.NoError(t, ah.RecordActivityHeartbeat(ctx, task.ID, []byte(`"details"`)))
+
+				// Wait for the lock to expire
+				time.Sleep(time.Millisecond * 200)
+
+				redelivered, err := b.GetActivityTask(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, redelivered)
+				require.True(t, redelivered.Redelivered)
+				require.False(t, redelivered.LastHeartbeat.IsZero())
+				require.Equal(t, []byte(`"details"`), []byte(redelivered.HeartbeatDetails))
+			},
+		},
+		{
+			name:    "AcquireActivityRateLimit_LimitsAcquisitions",
+			options: []backend.BackendOption{backend.WithActivityRateLimit(rateLimitedActivity, 2, time.Minute)},
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				rl, ok := b.(backend.RateLimiter)
+				if !ok {
+					t.Skip("backend does not support rate limits")
+				}
+
+				for i := 0; i < 2; i++ {
+					wait, err := rl.AcquireActivityRateLimit(ctx, "rateLimitedActivity")
+					require.NoError(t, err)
+					require.Zero(t, wait)
+				}
+
+				wait, err := rl.AcquireActivityRateLimit(ctx, "rateLimitedActivity")
+				require.NoError(t, err)
+				require.Greater(t, wait, time.Duration(0))
+				require.LessOrEqual(t, wait, time.Second*30)
+
+				// Activities without a rate limit are not limited
+				wait, err = rl.AcquireActivityRateLimit(ctx, "otherActivity")
+				require.NoError(t, err)
+				require.Zero(t, wait)
+			},
+		},
+		{
+			name: "AcquireRateLimit_SharesBucketsByName",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				rl, ok := b.(backend.RateLimiter)
+				if !ok {
+					t.Skip("backend does not support rate limits")
+				}
+
+				limit := backend.RateLimit{Limit: 1, Interval: time.Minute}
+
+				wait, err := rl.AcquireRateLimit(ctx, "test:a", limit)
+				require.NoError(t, err)
+				require.Zero(t, wait)
+
+				wait, err = rl.AcquireRateLimit(ctx, "test:a", limit)
+				require.NoError(t, err)
+				require.Greater(t, wait, time.Duration(0))
+
+				// Other names have buckets of their own
+				wait, err = rl.AcquireRateLimit(ctx, "test:b", limit)
+				require.NoError(t, err)
+				require.Zero(t, wait)
+			},
+		},
+		{
+			name: "AcquireLease_ExclusiveUntilReleased",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				l, ok := b.(backend.Leaser)
+				if !ok {
+					t.Skip("backend does not support leases")
+				}
+
+				acquired, err := l.AcquireLease(ctx, "lease", "a", time.Minute)
+				if errors.Is(err, backend.ErrLeasesNotSupported) {
+					t.Skip("backend does not support leases")
+				}
+				require.NoError(t, err)
+				require.True(t, acquired)
+
+				acquired, err = l.AcquireLease(ctx, "lease", "b", time.Minute)
+				require.NoError(t, err)
+				require.False(t, acquired)
+
+				// Holder can extend its lease
+				acquired, err = l.AcquireLease(ctx, "lease", "a", time.Minute)
+				require.NoError(t, err)
+				require.True(t, acquired)
+
+				// Releasing a lease held by a different holder has no effect
+				require.NoError(t, l.ReleaseLease(ctx, "lease", "b"))
+
+				acquired, err = l.AcquireLease(ctx, "lease", "b", time.Minute)
+				require.NoError(t, err)
+				require.False(t, acquired)
+
+				require.NoError(t, l.ReleaseLease(ctx, "lease", "a"))
+
+				acquired, err = l.AcquireLease(ctx, "lease", "b", time.Minute)
+				require.NoError(t, err)
+				require.True(t, acquired)
+			},
+		},
+		{
+			name: "AcquireLease_TakesOverExpiredLease",
+			f: func(t *testing.T, ctx context.Context, b backend.Backend) {
+				l, ok := b.(backend.Leaser)
+				if !ok {
+					t.Skip("backend does not support leases")
+				}
+
+				acquired, err := l.AcquireLease(ctx, "lease", "a", time.Millisecond*50)
+				if errors.Is(err, backend.ErrLeasesNotSupported) {
+					t.Skip("backend does not support leases")
+				}
+				require.NoError(t, err)
+				require.True(t, acquired)
+
+				time.Sleep(time.Millisecond * 100)
+
+				acquired, err = l.AcquireLease(ctx, "lease", "b", time.Minute)
+				require.NoError(t, err)
+				require.True(t, acquired)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := setup(tt.options...)
+			ctx := context.Background()
 
 			t.Cleanup(func() {
 				if teardown != nil {
@@ -361,6 +1404,29 @@ func BackendTest(t *testing.T, setup func(options ...backend.BackendOption) Test
 	}
 }
 
+func concurrencyLimitedWorkflow(ctx workflow.Context) error {
+	return nil
+}
+
+func rateLimitedActivity(ctx context.Context) error {
+	return nil
+}
+
+// flakyArchiver fails to archive histories while unavailable is set
+type flakyArchiver struct {
+	backend.Archiver
+
+	unavailable atomic.Bool
+}
+
+func (a *flakyArchiver) ArchiveHistory(ctx context.Context, namespace string, instance *workflow.Instance, events []*history.Event) error {
+	if a.unavailable.Load() {
+		return errors.New("archiver unavailable")
+	}
+
+	return a.Archiver.ArchiveHistory(ctx, namespace, instance, events)
+}
+
 func startWorkflow(t *testing.T, ctx context.Context, b backend.Backend, c client.Client, instance *core.WorkflowInstance) {
 	err := b.CreateWorkflowInstance(
 		ctx, instance, history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{}))
@@ -374,3 +1440,23 @@ func startWorkflow(t *testing.T, ctx context.Context, b backend.Backend, c clien
 		ctx, task, instance, core.WorkflowInstanceStateActive, task.NewEvents, []*history.Event{}, []*history.Event{}, []history.WorkflowEvent{})
 	require.NoError(t, err)
 }
+
+func finishWorkflow(t *testing.T, ctx context.Context, b backend.Backend, instance *core.WorkflowInstance) {
+	finishNamedWorkflow(t, ctx, b, instance, "")
+}
+
+func finishNamedWorkflow(t *testing.T, ctx context.Context, b backend.Backend, instance *core.WorkflowInstance, name string) {
+	err := b.CreateWorkflowInstance(
+		ctx, instance, history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{Name: name}))
+	require.NoError(t, err)
+
+	task, err := b.GetWorkflowTask(ctx)
+	require.NoError(t, err)
+
+	events := append(task.NewEvents,
+		history.NewHistoryEvent(-1, time.Now(), history.EventType_WorkflowExecutionFinished, &history.ExecutionCompletedAttributes{}))
+
+	err = b.CompleteWorkflowTask(
+		ctx, task, instance, core.WorkflowInstanceStateFinished, events, []*history.Event{}, []*history.Event{}, []history.WorkflowEvent{})
+	require.NoError(t, err)
+}
@@ -2,14 +2,17 @@ package test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/cschleiden/go-workflows/activity"
 	"github.com/cschleiden/go-workflows/backend"
 	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/converter"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
 	internalwf "github.com/cschleiden/go-workflows/internal/workflow"
@@ -64,6 +67,187 @@ func EndToEndBackendTest(t *testing.T, setup func(options ...backend.BackendOpti
 				require.Equal(t, int64(0), events[2].ScheduleEventID)
 			},
 		},
+		{
+			name: "CreateWorkflowInstance_ActiveInstanceIDErrors",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				wf := func(ctx workflow.Context) error {
+					workflow.NewSignalChannel[string](ctx, "done").Receive(ctx)
+					return nil
+				}
+				register(t, ctx, w, []interface{}{wf}, nil)
+
+				instanceID := uuid.NewString()
+				instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{InstanceID: instanceID}, wf)
+				require.NoError(t, err)
+
+				_, err = c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{InstanceID: instanceID}, wf)
+				require.ErrorIs(t, err, client.ErrInstanceAlreadyExists)
+
+				require.NoError(t, c.SignalWorkflow(ctx, instanceID, "done", ""))
+				require.NoError(t, c.WaitForWorkflowInstance(ctx, instance, time.Second*10))
+
+				// Instance ID can be reused once the previous execution has finished
+				_, err = c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{InstanceID: instanceID}, wf)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "CreateWorkflowInstance_AllowDuplicateFailedOnly",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				wf := func(ctx workflow.Context, fail bool) error {
+					if fail {
+						return errors.New("failed")
+					}
+
+					return nil
+				}
+				register(t, ctx, w, []interface{}{wf}, nil)
+
+				options := client.WorkflowInstanceOptions{
+					InstanceID:            uuid.NewString(),
+					InstanceIDReusePolicy: workflow.InstanceIDReusePolicyAllowDuplicateFailedOnly,
+				}
+
+				instance, err := c.CreateWorkflowInstance(ctx, options, wf, true)
+				require.NoError(t, err)
+				_, err = client.GetWorkflowResult[any](ctx, c, instance, time.Second*10)
+				require.Error(t, err)
+
+				// The previous execution failed, the instance ID can be reused
+				instance, err = c.CreateWorkflowInstance(ctx, options, wf, false)
+				require.NoError(t, err)
+				_, err = client.GetWorkflowResult[any](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+
+				// The previous execution completed successfully
+				_, err = c.CreateWorkflowInstance(ctx, options, wf, false)
+				require.ErrorIs(t, err, client.ErrInstanceAlreadyExists)
+			},
+		},
+		{
+			name: "CreateWorkflowInstance_TerminateExisting",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				wf := func(ctx workflow.Context) (string, error) {
+					v, _ := workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+					return v, nil
+				}
+				register(t, ctx, w, []interface{}{wf}, nil)
+
+				instanceID := uuid.NewString()
+				existing, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{InstanceID: instanceID}, wf)
+				require.NoError(t, err)
+
+				instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+					InstanceID:            instanceID,
+					InstanceIDReusePolicy: workflow.InstanceIDReusePolicyTerminateExisting,
+				}, wf)
+				require.NoError(t, err)
+
+				_, err = client.GetWorkflowResult[string](ctx, c, existing, time.Second*10)
+				require.ErrorIs(t, err, client.ErrWorkflowTerminated)
+
+				// Signals are delivered to the new execution
+				require.NoError(t, c.SignalWorkflow(ctx, instanceID, "signal", "hello"))
+
+				r, err := client.GetWorkflowResult[string](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+				require.Equal(t, "hello", r)
+			},
+		},
+		{
+			name: "CreateWorkflowInstance_StartDelay",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				wf := func(ctx workflow.Context) (time.Time, error) {
+					workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+					return workflow.Now(ctx), nil
+				}
+				register(t, ctx, w, []interface{}{wf}, nil)
+
+				delay := time.Second * 2
+				start := time.Now()
+
+				instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+					InstanceID: uuid.NewString(),
+					StartDelay: delay,
+				}, wf)
+				require.NoError(t, err)
+
+				// Signals sent before the instance has started are delivered once it starts
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", "hello"))
+
+				startedAt, err := client.GetWorkflowResult[time.Time](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+				require.False(t, startedAt.Before(start.Add(delay)), "instance started before delay elapsed")
+			},
+		},
+		{
+			name: "CreateWorkflowInstance_ExecutionTimeout",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				wf := func(ctx workflow.Context) (string, error) {
+					v, _ := workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+					return v, nil
+				}
+				register(t, ctx, w, []interface{}{wf}, nil)
+
+				instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+					InstanceID:       uuid.NewString(),
+					ExecutionTimeout: time.Millisecond * 500,
+				}, wf)
+				require.NoError(t, err)
+
+				_, err = client.GetWorkflowResult[string](ctx, c, instance, time.Second*10)
+				require.ErrorIs(t, err, client.ErrWorkflowTimedOut)
+
+				var terr *workflow.TimeoutError
+				require.True(t, errors.As(err, &terr))
+				require.Equal(t, workflow.TimeoutKindExecution, terr.Kind)
+			},
+		},
+		{
+			name: "SubWorkflow_ExecutionTimeout",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				swf := func(ctx workflow.Context) (string, error) {
+					v, _ := workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+					return v, nil
+				}
+				wf := func(ctx workflow.Context) (workflow.TimeoutKind, error) {
+					_, err := workflow.CreateSubWorkflowInstance[string](ctx, workflow.SubWorkflowOptions{
+						ExecutionTimeout: time.Millisecond * 500,
+					}, swf).Get(ctx)
+
+					var terr *workflow.TimeoutError
+					if !errors.As(err, &terr) {
+						return "", fmt.Errorf("expected timeout error, got %v", err)
+					}
+
+					return terr.Kind, nil
+				}
+				register(t, ctx, w, []interface{}{wf, swf}, nil)
+
+				kind, err := runWorkflowWithResult[workflow.TimeoutKind](t, ctx, c, wf)
+				require.NoError(t, err)
+				require.Equal(t, workflow.TimeoutKindExecution, kind)
+			},
+		},
+		{
+			name: "CreateWorkflowInstance_ExecutionTimeoutNotExceeded",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				wf := func(ctx workflow.Context) (string, error) {
+					return "done", nil
+				}
+				register(t, ctx, w, []interface{}{wf}, nil)
+
+				instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+					InstanceID:       uuid.NewString(),
+					ExecutionTimeout: time.Minute,
+				}, wf)
+				require.NoError(t, err)
+
+				r, err := client.GetWorkflowResult[string](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+				require.Equal(t, "done", r)
+			},
+		},
 		{
 			name: "UnregisteredWorkflow_Errors",
 			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
@@ -132,6 +316,110 @@ func EndToEndBackendTest(t *testing.T, setup func(options ...backend.BackendOpti
 				require.ErrorContains(t, err, "mismatched argument count: expected 2, got 1")
 			},
 		},
+		{
+			name: "Activity_RetriesRecordAttempts",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				a := func(ctx context.Context) (int, error) {
+					attempt := activity.Attempt(ctx)
+					if attempt < 2 {
+						return 0, errors.New("not yet")
+					}
+
+					return attempt, nil
+				}
+				wf := func(ctx workflow.Context) (int, error) {
+					return workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+						RetryOptions: workflow.RetryOptions{
+							MaxAttempts:        3,
+							FirstRetryInterval: time.Millisecond * 10,
+						},
+					}, a).Get(ctx)
+				}
+				register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+				instance := runWorkflow(t, ctx, c, wf)
+				output, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+				require.Equal(t, 2, output)
+
+				attempts := []int{}
+				historyIterate(ctx, t, b, instance, func(event *history.Event) bool {
+					if a, ok := event.Attributes.(*history.ActivityScheduledAttributes); ok {
+						attempts = append(attempts, a.Attempt)
+					}
+
+					return true
+				})
+				require.Equal(t, []int{0, 1, 2}, attempts)
+			},
+		},
+		{
+			name: "Activity_CompletedAsynchronously",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				tokens := make(chan string, 1)
+				completed := make(chan struct{})
+				a := func(ctx context.Context) (int, error) {
+					tokens <- activity.TaskToken(ctx)
+
+					// Return only after the result has been passed in
+					<-completed
+
+					return 0, activity.ErrResultPending
+				}
+				wf := func(ctx workflow.Context) (int, error) {
+					return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, a).Get(ctx)
+				}
+				register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+				instance := runWorkflow(t, ctx, c, wf)
+
+				var token string
+				select {
+				case token = <-tokens:
+				case <-time.After(time.Second * 10):
+					t.Fatal("activity was not executed")
+				}
+
+				require.NoError(t, c.CompleteActivity(ctx, token, 42, nil))
+				close(completed)
+
+				output, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+				require.Equal(t, 42, output)
+
+				// An activity can only be completed once
+				err = c.CompleteActivity(ctx, token, 23, nil)
+				require.ErrorIs(t, err, backend.ErrActivityNotFound)
+			},
+		},
+		{
+			name: "Activity_FailedAsynchronously",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				tokens := make(chan string, 1)
+				a := func(ctx context.Context) error {
+					tokens <- activity.TaskToken(ctx)
+
+					return activity.ErrResultPending
+				}
+				wf := func(ctx workflow.Context) error {
+					_, err := workflow.ExecuteActivity[any](ctx, workflow.ActivityOptions{
+						RetryOptions: workflow.RetryOptions{
+							MaxAttempts: 1,
+						},
+					}, a).Get(ctx)
+					return err
+				}
+				register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+				instance := runWorkflow(t, ctx, c, wf)
+
+				token := <-tokens
+				require.NoError(t, c.CompleteActivity(ctx, token, nil, errors.New("rejected")))
+
+				_, err := client.GetWorkflowResult[any](ctx, c, instance, time.Second*10)
+				require.ErrorContains(t, err, "rejected")
+			},
+		},
 		{
 			name: "SideEffect_Simple",
 			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
@@ -178,6 +466,36 @@ func EndToEndBackendTest(t *testing.T, setup func(options ...backend.BackendOpti
 				require.ErrorIs(t, err, backend.ErrInstanceNotFound)
 			},
 		},
+		{
+			name: "Signal_BeforeNewSignalChannel",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				wf := func(ctx workflow.Context) (string, error) {
+					// Signals arrive before the workflow creates the signal channel
+					workflow.Sleep(ctx, time.Millisecond*200)
+
+					sc := workflow.NewSignalChannel[string](ctx, "signal")
+
+					r := ""
+					for i := 0; i < 3; i++ {
+						v, _ := sc.Receive(ctx)
+						r += v
+					}
+
+					return r, nil
+				}
+				register(t, ctx, w, []interface{}{wf}, nil)
+
+				instance := runWorkflow(t, ctx, c, wf)
+
+				for _, v := range []string{"a", "b", "c"} {
+					require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", v))
+				}
+
+				r, err := client.GetWorkflowResult[string](ctx, c, instance, time.Second*20)
+				require.NoError(t, err)
+				require.Equal(t, "abc", r)
+			},
+		},
 		{
 			name: "SubWorkflow_Simple",
 			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
@@ -265,6 +583,89 @@ func EndToEndBackendTest(t *testing.T, setup func(options ...backend.BackendOpti
 				require.Equal(t, 6, r)
 			},
 		},
+		{
+			name: "SubWorkflow_ParentClosePolicyAbandon",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				swf := func(ctx workflow.Context) (int, error) {
+					if err := workflow.Sleep(ctx, time.Millisecond*500); err != nil {
+						return 0, err
+					}
+
+					return 42, nil
+				}
+
+				ch := make(chan struct{}, 10)
+
+				wf := func(ctx workflow.Context) (bool, error) {
+					f := workflow.CreateSubWorkflowInstance[int](ctx, workflow.SubWorkflowOptions{
+						ParentClosePolicy: workflow.ParentClosePolicyAbandon,
+					}, swf)
+
+					ch <- struct{}{}
+
+					// The canceled parent doesn't wait for the abandoned sub-workflow
+					_, subErr := f.Get(ctx)
+
+					// Keep running while the sub-workflow finishes, its result is discarded
+					if err := workflow.Sleep(workflow.NewDisconnectedContext(ctx), time.Second); err != nil {
+						return false, err
+					}
+
+					return subErr == workflow.Canceled, nil
+				}
+				register(t, ctx, w, []interface{}{wf, swf}, nil)
+
+				instance := runWorkflow(t, ctx, c, wf)
+
+				<-ch
+
+				require.NoError(t, c.CancelWorkflowInstance(ctx, instance))
+
+				canceled, err := client.GetWorkflowResult[bool](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+				require.True(t, canceled)
+
+				// The sub-workflow wasn't canceled and runs to completion
+				r, err := client.GetWorkflowResult[int](ctx, c, scheduledSubWorkflow(ctx, t, b, instance), time.Second*10)
+				require.NoError(t, err)
+				require.Equal(t, 42, r)
+			},
+		},
+		{
+			name: "SubWorkflow_ParentClosePolicyWait",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				swf := func(ctx workflow.Context) (int, error) {
+					if err := workflow.Sleep(ctx, time.Millisecond*500); err != nil {
+						return 0, err
+					}
+
+					return 42, nil
+				}
+
+				ch := make(chan struct{}, 10)
+
+				wf := func(ctx workflow.Context) (int, error) {
+					f := workflow.CreateSubWorkflowInstance[int](ctx, workflow.SubWorkflowOptions{
+						ParentClosePolicy: workflow.ParentClosePolicyWait,
+					}, swf)
+
+					ch <- struct{}{}
+
+					return f.Get(ctx)
+				}
+				register(t, ctx, w, []interface{}{wf, swf}, nil)
+
+				instance := runWorkflow(t, ctx, c, wf)
+
+				<-ch
+
+				require.NoError(t, c.CancelWorkflowInstance(ctx, instance))
+
+				r, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+				require.Equal(t, 42, r)
+			},
+		},
 		{
 			name: "SubWorkflow_CancelBeforeStarting",
 			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
@@ -369,6 +770,340 @@ func EndToEndBackendTest(t *testing.T, setup func(options ...backend.BackendOpti
 				require.ErrorContains(t, err, backend.ErrInstanceNotFound.Error())
 			},
 		},
+		{
+			name: "TerminateWorkflowInstance_TerminatesSubWorkflows",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				// Workflow might be executed multiple times, but the test will wait only once. Create buffered channel
+				started := make(chan struct{}, 10)
+
+				swf := func(ctx workflow.Context) (int, error) {
+					started <- struct{}{}
+
+					workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+
+					return 1, nil
+				}
+				wf := func(ctx workflow.Context) (int, error) {
+					return workflow.CreateSubWorkflowInstance[int](ctx, workflow.DefaultSubWorkflowOptions, swf).Get(ctx)
+				}
+				register(t, ctx, w, []interface{}{wf, swf}, nil)
+
+				instance := runWorkflow(t, ctx, c, wf)
+
+				<-started
+
+				subInstance := scheduledSubWorkflow(ctx, t, b, instance)
+
+				require.NoError(t, c.TerminateWorkflowInstance(ctx, instance, "test"))
+
+				_, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+				require.ErrorIs(t, err, client.ErrWorkflowTerminated)
+
+				_, err = client.GetWorkflowResult[int](ctx, c, subInstance, time.Second*10)
+				require.ErrorIs(t, err, client.ErrWorkflowTerminated)
+
+				historyIterate(ctx, t, b, instance, func(event *history.Event) bool {
+					if event.Type == history.EventType_WorkflowExecutionTerminated {
+						require.Equal(t, "test", event.Attributes.(*history.ExecutionTerminatedAttributes).Reason)
+					}
+
+					return true
+				})
+			},
+		},
+		{
+			name: "TerminateWorkflowInstance_SubWorkflowFailsParent",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				started := make(chan struct{}, 10)
+
+				swf := func(ctx workflow.Context) (int, error) {
+					started <- struct{}{}
+
+					workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+
+					return 1, nil
+				}
+				wf := func(ctx workflow.Context) (bool, error) {
+					_, err := workflow.CreateSubWorkflowInstance[int](ctx, workflow.DefaultSubWorkflowOptions, swf).Get(ctx)
+
+					return errors.Is(err, workflow.ErrTerminated), nil
+				}
+				register(t, ctx, w, []interface{}{wf, swf}, nil)
+
+				instance := runWorkflow(t, ctx, c, wf)
+
+				<-started
+
+				require.NoError(t, c.TerminateWorkflowInstance(ctx, scheduledSubWorkflow(ctx, t, b, instance), ""))
+
+				terminated, err := client.GetWorkflowResult[bool](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+				require.True(t, terminated)
+			},
+		},
+		{
+			name: "TerminateWorkflowInstance_Paused",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				wf := func(ctx workflow.Context) (int, error) {
+					workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+
+					return 1, nil
+				}
+				register(t, ctx, w, []interface{}{wf}, nil)
+
+				instance := runWorkflow(t, ctx, c, wf)
+
+				require.NoError(t, c.PauseWorkflowInstance(ctx, instance))
+				require.NoError(t, c.TerminateWorkflowInstance(ctx, instance, ""))
+
+				_, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+				require.ErrorIs(t, err, client.ErrWorkflowTerminated)
+			},
+		},
+		{
+			name: "QueryWorkflow_ReturnsWorkflowState",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				a := func(ctx context.Context) (int, error) {
+					return 42, nil
+				}
+				wf := func(ctx workflow.Context) (int, error) {
+					status := "started"
+					if err := workflow.SetQueryHandler(ctx, "status", func(prefix string) (string, error) {
+						return prefix + status, nil
+					}); err != nil {
+						return 0, err
+					}
+
+					r, err := workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, a).Get(ctx)
+					if err != nil {
+						return 0, err
+					}
+
+					status = fmt.Sprintf("waiting with %d", r)
+
+					workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+
+					return r, nil
+				}
+				register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+				instance := runWorkflow(t, ctx, c, wf)
+
+				require.Eventually(t, func() bool {
+					v, err := c.QueryWorkflow(ctx, instance.InstanceID, "status", "status: ")
+					if err != nil {
+						return false
+					}
+
+					var status string
+					require.NoError(t, v.Get(&status))
+
+					return status == "status: waiting with 42"
+				}, time.Second*10, time.Millisecond*50)
+
+				_, err := c.QueryWorkflow(ctx, instance.InstanceID, "unknown")
+				require.ErrorContains(t, err, "unknown query")
+
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", "done"))
+
+				r, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+				require.Equal(t, 42, r)
+
+				_, err = c.QueryWorkflow(ctx, instance.InstanceID, "status", "")
+				require.ErrorIs(t, err, backend.ErrInstanceNotFound)
+			},
+		},
+		{
+			name: "QuerySubWorkflow_ReturnsSubWorkflowState",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				swf := func(ctx workflow.Context) (int, error) {
+					progress := 0
+					if err := workflow.SetQueryHandler(ctx, "progress", func() (int, error) {
+						return progress, nil
+					}); err != nil {
+						return 0, err
+					}
+
+					progress = 50
+
+					workflow.NewSignalChannel[string](ctx, "continue").Receive(ctx)
+
+					return 100, nil
+				}
+				wf := func(ctx workflow.Context) (int, error) {
+					subID := workflow.WorkflowInstance(ctx).InstanceID + "-sub"
+
+					f := workflow.CreateSubWorkflowInstance[int](ctx, workflow.SubWorkflowOptions{
+						InstanceID: subID,
+					}, swf)
+
+					// The sub-workflow might not have started yet
+					var progress int
+					for i := 0; i < 50; i++ {
+						p, err := workflow.QuerySubWorkflow[int](ctx, subID, "progress").Get(ctx)
+						if err == nil && p == 50 {
+							progress = p
+							break
+						}
+
+						if err := workflow.Sleep(ctx, time.Millisecond*100); err != nil {
+							return 0, err
+						}
+					}
+
+					if _, err := workflow.SignalWorkflow(ctx, subID, "continue", "").Get(ctx); err != nil {
+						return 0, err
+					}
+
+					if _, err := workflow.QuerySubWorkflow[int](ctx, subID, "unknown").Get(ctx); err == nil {
+						return 0, errors.New("unknown query answered")
+					}
+
+					r, err := f.Get(ctx)
+					if err != nil {
+						return 0, err
+					}
+
+					return progress + r, nil
+				}
+				register(t, ctx, w, []interface{}{wf, swf}, nil)
+
+				r, err := runWorkflowWithResult[int](t, ctx, c, wf)
+				require.NoError(t, err)
+				require.Equal(t, 150, r)
+			},
+		},
+		{
+			name: "SearchAttributes_UpsertedByWorkflow",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				a := func(ctx context.Context) (int, error) {
+					return 42, nil
+				}
+				wf := func(ctx workflow.Context) (int, error) {
+					if err := workflow.UpsertSearchAttributes(ctx, workflow.SearchAttributes{
+						"status": workflow.StringAttribute("running"),
+					}); err != nil {
+						return 0, err
+					}
+
+					r, err := workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, a).Get(ctx)
+					if err != nil {
+						return 0, err
+					}
+
+					if err := workflow.UpsertSearchAttributes(ctx, workflow.SearchAttributes{
+						"status": workflow.StringAttribute("done"),
+						"result": workflow.IntAttribute(int64(r)),
+					}); err != nil {
+						return 0, err
+					}
+
+					return r, nil
+				}
+				register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+				instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+					InstanceID: uuid.NewString(),
+					SearchAttributes: workflow.SearchAttributes{
+						"customer": workflow.StringAttribute("contoso"),
+					},
+				}, wf)
+				require.NoError(t, err)
+
+				_, err = client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+
+				r, err := c.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{
+					SearchAttributes: workflow.SearchAttributes{
+						"customer": workflow.StringAttribute("contoso"),
+						"status":   workflow.StringAttribute("done"),
+						"result":   workflow.IntAttribute(42),
+					},
+				})
+				require.NoError(t, err)
+				require.Len(t, r.Instances, 1)
+				require.Equal(t, instance.InstanceID, r.Instances[0].Instance.InstanceID)
+
+				r, err = c.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{
+					SearchAttributes: workflow.SearchAttributes{"status": workflow.StringAttribute("running")},
+				})
+				require.NoError(t, err)
+				require.Empty(t, r.Instances)
+			},
+		},
+		{
+			name: "DescribeWorkflowInstance_ReturnsPendingWork",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				release := make(chan struct{})
+				a := func(ctx context.Context) error {
+					<-release
+					return nil
+				}
+				wf := func(ctx workflow.Context) error {
+					if err := workflow.UpsertSearchAttributes(ctx, workflow.SearchAttributes{
+						"status": workflow.StringAttribute("running"),
+					}); err != nil {
+						return err
+					}
+
+					tctx, cancel := workflow.WithCancel(ctx)
+					workflow.ScheduleTimer(tctx, time.Hour)
+
+					if _, err := workflow.ExecuteActivity[any](ctx, workflow.DefaultActivityOptions, a).Get(ctx); err != nil {
+						return err
+					}
+
+					cancel()
+
+					return nil
+				}
+				register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+				instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+					InstanceID: uuid.NewString(),
+					SearchAttributes: workflow.SearchAttributes{
+						"customer": workflow.StringAttribute("contoso"),
+					},
+				}, wf)
+				require.NoError(t, err)
+
+				var d *client.WorkflowInstanceDescription
+				require.Eventually(t, func() bool {
+					d, err = c.DescribeWorkflowInstance(ctx, instance)
+					require.NoError(t, err)
+
+					return len(d.PendingActivities) == 1 && len(d.PendingTimers) == 1
+				}, time.Second*10, time.Millisecond*50)
+
+				require.Equal(t, instance.InstanceID, d.Instance.InstanceID)
+				require.Nil(t, d.Instance.Parent)
+				require.Equal(t, core.WorkflowInstanceStateActive, d.State)
+				require.Nil(t, d.CompletedAt)
+				require.Equal(t, workflow.SearchAttributes{
+					"customer": workflow.StringAttribute("contoso"),
+					"status":   workflow.StringAttribute("running"),
+				}, d.SearchAttributes)
+				require.Equal(t, 0, d.PendingActivities[0].Attempt)
+				require.NotEmpty(t, d.PendingActivities[0].ActivityID)
+				require.WithinDuration(t, d.PendingTimers[0].ScheduledAt.Add(time.Hour), d.PendingTimers[0].FireAt, time.Second)
+
+				close(release)
+
+				_, err = client.GetWorkflowResult[any](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+
+				d, err = c.DescribeWorkflowInstance(ctx, instance)
+				require.NoError(t, err)
+				require.Equal(t, core.WorkflowInstanceStateFinished, d.State)
+				require.NotNil(t, d.CompletedAt)
+				require.Empty(t, d.PendingActivities)
+				require.Empty(t, d.PendingTimers)
+
+				_, err = c.DescribeWorkflowInstance(ctx, core.NewWorkflowInstance(uuid.NewString(), uuid.NewString()))
+				require.ErrorIs(t, err, backend.ErrInstanceNotFound)
+			},
+		},
 		{
 			name: "Timer_CancelWorkflowInstance",
 			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
@@ -659,10 +1394,146 @@ func EndToEndBackendTest(t *testing.T, setup func(options ...backend.BackendOpti
 				require.Equal(t, 3, r)
 			},
 		},
+		{
+			name: "ContinueAsNew_CarriesPendingSignals",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				wf := func(ctx workflow.Context, run int) (int, error) {
+					if run == 0 {
+						// Give the signals time to arrive
+						workflow.Sleep(ctx, time.Millisecond*500)
+
+						workflow.NewSignalChannel[int](ctx, "signal").Receive(ctx)
+						return 0, workflow.ContinueAsNew(ctx, run+1)
+					}
+
+					v, _ := workflow.NewSignalChannel[int](ctx, "signal").Receive(ctx)
+					return v, nil
+				}
+				register(t, ctx, w, []interface{}{wf}, nil)
+
+				instance := runWorkflow(t, ctx, c, wf, 0)
+
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", 1))
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", 2))
+
+				// Wait for the first execution to continue as new
+				_, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+
+				h, err := b.GetWorkflowInstanceHistory(ctx, instance, nil)
+				require.NoError(t, err)
+
+				var continued *workflow.Instance
+				for _, e := range h {
+					if e.Type == history.EventType_WorkflowExecutionContinuedAsNew {
+						a := e.Attributes.(*history.ExecutionContinuedAsNewAttributes)
+						continued = core.NewWorkflowInstance(instance.InstanceID, a.ContinuedExecutionID)
+					}
+				}
+				require.NotNil(t, continued)
+
+				r, err := client.GetWorkflowResult[int](ctx, c, continued, time.Second*10)
+				require.NoError(t, err)
+				require.Equal(t, 2, r)
+			},
+		},
+		{
+			name: "ContinueAsNew_InheritsExecutionTimeout",
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				wf := func(ctx workflow.Context, run int) (int, error) {
+					if run < 1 {
+						return run, workflow.ContinueAsNew(ctx, run+1)
+					}
+
+					v, _ := workflow.NewSignalChannel[int](ctx, "signal").Receive(ctx)
+					return v, nil
+				}
+				register(t, ctx, w, []interface{}{wf}, nil)
+
+				instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+					InstanceID:       uuid.NewString(),
+					ExecutionTimeout: time.Millisecond * 500,
+				}, wf, 0)
+				require.NoError(t, err)
+
+				// Wait for the first execution to continue as new
+				_, err = client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+
+				h, err := b.GetWorkflowInstanceHistory(ctx, instance, nil)
+				require.NoError(t, err)
+
+				var continued *workflow.Instance
+				for _, e := range h {
+					if e.Type == history.EventType_WorkflowExecutionContinuedAsNew {
+						a := e.Attributes.(*history.ExecutionContinuedAsNewAttributes)
+						continued = core.NewWorkflowInstance(instance.InstanceID, a.ContinuedExecutionID)
+					}
+				}
+				require.NotNil(t, continued)
+
+				_, err = client.GetWorkflowResult[int](ctx, c, continued, time.Second*10)
+				require.ErrorIs(t, err, client.ErrWorkflowTimedOut)
+			},
+		},
+		{
+			name:    "PayloadCodecs_EncryptPayloads",
+			options: []backend.BackendOption{backend.WithPayloadCodecs(testEncryptionCodec(t))},
+			f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+				a := func(ctx context.Context, msg string) (string, error) {
+					return msg + " world", nil
+				}
+
+				wf := func(ctx workflow.Context, msg string) (string, error) {
+					suffix, _ := workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+
+					r, err := workflow.ExecuteActivity[string](ctx, workflow.DefaultActivityOptions, a, msg).Get(ctx)
+					return r + suffix, err
+				}
+				register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+				instance := runWorkflow(t, ctx, c, wf, "secret")
+				require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "signal", "!"))
+
+				r, err := client.GetWorkflowResult[string](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+				require.Equal(t, "secret world!", r)
+
+				// Payloads are stored encrypted
+				h, err := b.GetWorkflowInstanceHistory(ctx, instance, nil)
+				require.NoError(t, err)
+
+				var payloads []workflow.Payload
+				for _, e := range h {
+					switch a := e.Attributes.(type) {
+					case *history.ExecutionStartedAttributes:
+						payloads = append(payloads, a.Inputs...)
+					case *history.SignalReceivedAttributes:
+						payloads = append(payloads, a.Arg)
+					case *history.ActivityScheduledAttributes:
+						payloads = append(payloads, a.Inputs...)
+					case *history.ActivityCompletedAttributes:
+						payloads = append(payloads, a.Result)
+					case *history.ExecutionCompletedAttributes:
+						payloads = append(payloads, a.Result)
+					}
+				}
+				require.Len(t, payloads, 5)
+
+				for _, p := range payloads {
+					var v string
+					require.Error(t, converter.DefaultConverter.From(p, &v), "payload is not encrypted: %s", p)
+				}
+			},
+		},
 	}
 
 	tests = append(tests, e2eActivityTests...)
 	tests = append(tests, e2eStatsTests...)
+	tests = append(tests, e2eShutdownTests...)
+	tests = append(tests, e2eWorkerTests...)
+	tests = append(tests, e2eBatchTests...)
+	tests = append(tests, e2eUpdateTests...)
 
 	run := func(suffix string, workerOptions *worker.Options) {
 		for _, tt := range tests {
@@ -708,6 +1579,13 @@ func EndToEndBackendTest(t *testing.T, setup func(options ...backend.BackendOpti
 	run("_without_cache", &options)
 }
 
+func testEncryptionCodec(t *testing.T) converter.PayloadCodec {
+	codec, err := converter.NewAESGCMCodec("key", map[string][]byte{"key": []byte("0123456789abcdef")})
+	require.NoError(t, err)
+
+	return codec
+}
+
 type noopWorkflowExecutorCache struct {
 }
 
@@ -718,6 +1596,11 @@ func (*noopWorkflowExecutorCache) Get(ctx context.Context, instance *core.Workfl
 	return nil, false, nil
 }
 
+// Evict implements workflow.ExecutorCache
+func (*noopWorkflowExecutorCache) Evict(ctx context.Context, instance *core.WorkflowInstance) error {
+	return nil
+}
+
 // StartEviction implements workflow.ExecutorCache
 func (*noopWorkflowExecutorCache) StartEviction(ctx context.Context) {
 }
@@ -764,6 +1647,23 @@ func historyIterate(ctx context.Context, t *testing.T, b TestBackend, instance *
 	}
 }
 
+// scheduledSubWorkflow returns the instance of the first sub-workflow scheduled by the given instance
+func scheduledSubWorkflow(ctx context.Context, t *testing.T, b TestBackend, instance *workflow.Instance) *workflow.Instance {
+	var subInstance *workflow.Instance
+	historyIterate(ctx, t, b, instance, func(event *history.Event) bool {
+		if a, ok := event.Attributes.(*history.SubWorkflowScheduledAttributes); ok {
+			subInstance = a.SubWorkflowInstance
+			return false
+		}
+
+		return true
+	})
+
+	require.NotNil(t, subInstance, "no sub-workflow scheduled")
+
+	return subInstance
+}
+
 // historyContains ensure the history contains all of the given event types in the given order
 func historyContains(ctx context.Context, t *testing.T, b TestBackend, instance *workflow.Instance, eventTypes ...history.EventType) {
 	historyIterate(ctx, t, b, instance, func(event *history.Event) bool {
@@ -3,8 +3,11 @@ package test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/cschleiden/go-workflows/activity"
 	"github.com/cschleiden/go-workflows/client"
 	"github.com/cschleiden/go-workflows/worker"
 	"github.com/cschleiden/go-workflows/workflow"
@@ -19,6 +22,20 @@ func (e *CustomError) Error() string {
 	return e.msg
 }
 
+var errSentinel = errors.New("sentinel")
+
+type DetailedError struct {
+	Code int `json:"code"`
+}
+
+func (e *DetailedError) Error() string {
+	return fmt.Sprintf("detailed error %d", e.Code)
+}
+
+func (e *DetailedError) Unwrap() error {
+	return errSentinel
+}
+
 var e2eActivityTests = []backendTest{
 	{
 		name: "Activity_Panic",
@@ -45,6 +62,56 @@ var e2eActivityTests = []backendTest{
 			require.NoError(t, err)
 		},
 	},
+	{
+		name: "Activity_Panic_FailWorkflowPolicy",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			attempts := 0
+			a := func(context.Context) error {
+				attempts++
+				panic("activity panic")
+			}
+
+			wf := func(ctx workflow.Context) error {
+				_, err := workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+					RetryOptions: workflow.RetryOptions{
+						MaxAttempts:        3,
+						FirstRetryInterval: time.Millisecond,
+					},
+				}, a).Get(ctx)
+
+				return err
+			}
+
+			require.NoError(t, w.RegisterWorkflow(wf))
+			require.NoError(t, w.RegisterActivity(a, worker.WithPanicPolicy(worker.PanicPolicyFailWorkflow)))
+			require.NoError(t, w.Start(ctx))
+
+			_, err := runWorkflowWithResult[any](t, ctx, c, wf)
+
+			var perr *workflow.PanicError
+			require.ErrorAs(t, err, &perr)
+			require.Contains(t, perr.Error(), "activity panic")
+			require.Equal(t, 1, attempts, "activity should not be retried")
+		},
+	},
+	{
+		name: "Workflow_Panic_Stack",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			wf := func(ctx workflow.Context) error {
+				var s []int
+				_ = s[1]
+				return nil
+			}
+			register(t, ctx, w, []interface{}{wf}, nil)
+
+			_, err := runWorkflowWithResult[any](t, ctx, c, wf)
+
+			var perr *workflow.PanicError
+			require.ErrorAs(t, err, &perr)
+			require.Contains(t, perr.Error(), "index out of range")
+			require.Contains(t, perr.Stack(), "e2e_activity.go")
+		},
+	},
 	{
 		name: "Activity_CustomError",
 		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
@@ -73,5 +140,192 @@ var e2eActivityTests = []backendTest{
 			require.True(t, output, "error should be PanicError")
 			require.NoError(t, err)
 		},
+	}, {
+		name: "Activity_ErrorChain",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			a := func(context.Context) error {
+				return fmt.Errorf("activity: %w", &DetailedError{Code: 42})
+			}
+
+			wf := func(ctx workflow.Context) error {
+				_, err := workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+					RetryOptions: workflow.RetryOptions{
+						MaxAttempts: 1,
+					},
+				}, a).Get(ctx)
+
+				return fmt.Errorf("workflow: %w", err)
+			}
+			register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+			_, err := runWorkflowWithResult[any](t, ctx, c, wf)
+			require.EqualError(t, err, "workflow: activity: detailed error 42")
+
+			var de *DetailedError
+			require.ErrorAs(t, err, &de)
+			require.Equal(t, 42, de.Code)
+			require.ErrorIs(t, err, errSentinel)
+		},
+	}, {
+		name: "Activity_StartToCloseTimeout",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			a := func(context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			}
+
+			wf := func(ctx workflow.Context) error {
+				_, err := workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+					RetryOptions: workflow.RetryOptions{
+						MaxAttempts: 1,
+					},
+					StartToCloseTimeout: time.Millisecond * 50,
+				}, a).Get(ctx)
+
+				return err
+			}
+			register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+			_, err := runWorkflowWithResult[any](t, ctx, c, wf)
+
+			var terr *workflow.TimeoutError
+			require.ErrorAs(t, err, &terr)
+			require.Equal(t, workflow.TimeoutKindStartToClose, terr.Kind)
+		},
+	},
+	{
+		name: "Activity_ScheduleToStartTimeout",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			executed := false
+			a := func(context.Context) error {
+				executed = true
+				return nil
+			}
+
+			wf := func(ctx workflow.Context) error {
+				_, err := workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+					RetryOptions: workflow.RetryOptions{
+						MaxAttempts: 1,
+					},
+					ScheduleToStartTimeout: time.Nanosecond,
+				}, a).Get(ctx)
+
+				return err
+			}
+			register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+			_, err := runWorkflowWithResult[any](t, ctx, c, wf)
+
+			var terr *workflow.TimeoutError
+			require.ErrorAs(t, err, &terr)
+			require.Equal(t, workflow.TimeoutKindScheduleToStart, terr.Kind)
+			require.False(t, executed)
+		},
+	},
+	{
+		name: "Activity_CheckpointAcrossRetries",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			a := func(ctx context.Context) (int, error) {
+				done, _, err := activity.LastCheckpoint[int](ctx)
+				if err != nil {
+					return 0, err
+				}
+
+				// Process one chunk per attempt, fail until all chunks are done
+				done++
+				if err := activity.Checkpoint(ctx, done); err != nil {
+					return 0, err
+				}
+
+				if done < 3 {
+					return 0, errors.New("chunk failed")
+				}
+
+				return done, nil
+			}
+
+			wf := func(ctx workflow.Context) (int, error) {
+				return workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+					RetryOptions: workflow.RetryOptions{
+						MaxAttempts: 3,
+					},
+				}, a).Get(ctx)
+			}
+			register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+			output, err := runWorkflowWithResult[int](t, ctx, c, wf)
+
+			require.NoError(t, err)
+			require.Equal(t, 3, output)
+		},
+	},
+	{
+		name: "Activity_HeartbeatTimeout",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			a := func(ctx context.Context) (string, error) {
+				details, ok, err := activity.LastCheckpoint[string](ctx)
+				if err != nil {
+					return "", err
+				}
+
+				if ok {
+					return details, nil
+				}
+
+				if err := activity.RecordHeartbeat(ctx, "halfway"); err != nil {
+					return "", err
+				}
+
+				// Stop heartbeating, the attempt is canceled once the heartbeat timeout expires
+				<-ctx.Done()
+				return "", ctx.Err()
+			}
+
+			wf := func(ctx workflow.Context) (string, error) {
+				return workflow.ExecuteActivity[string](ctx, workflow.ActivityOptions{
+					RetryOptions: workflow.RetryOptions{
+						MaxAttempts: 2,
+					},
+					HeartbeatTimeout: time.Millisecond * 100,
+				}, a).Get(ctx)
+			}
+			register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+			output, err := runWorkflowWithResult[string](t, ctx, c, wf)
+
+			require.NoError(t, err)
+			require.Equal(t, "halfway", output)
+		},
+	},
+	{
+		name: "Activity_HeartbeatKeepsActivityRunning",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			a := func(ctx context.Context) (int, error) {
+				for i := 0; i < 5; i++ {
+					time.Sleep(time.Millisecond * 50)
+
+					if err := activity.RecordHeartbeat(ctx, i); err != nil {
+						return 0, err
+					}
+				}
+
+				return activity.Attempt(ctx), nil
+			}
+
+			wf := func(ctx workflow.Context) (int, error) {
+				return workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+					RetryOptions: workflow.RetryOptions{
+						MaxAttempts: 1,
+					},
+					HeartbeatTimeout: time.Millisecond * 150,
+				}, a).Get(ctx)
+			}
+			register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+			output, err := runWorkflowWithResult[int](t, ctx, c, wf)
+
+			require.NoError(t, err)
+			require.Equal(t, 0, output)
+		},
 	},
 }
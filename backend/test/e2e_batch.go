@@ -0,0 +1,88 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/internal/fn"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+var e2eBatchTests = []backendTest{
+	{
+		name: "Batch_SignalByWorkflowName",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			wf := func(ctx workflow.Context) (string, error) {
+				v, _ := workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+				return v, nil
+			}
+			other := func(ctx workflow.Context) (string, error) {
+				return workflow.WaitForSignal[string](ctx, "signal", time.Hour)
+			}
+			register(t, ctx, w, []interface{}{wf, other}, nil)
+
+			instances := []*workflow.Instance{
+				runWorkflow(t, ctx, c, wf),
+				runWorkflow(t, ctx, c, wf),
+			}
+			otherInstance := runWorkflow(t, ctx, c, other)
+
+			job, err := c.BatchSignal(ctx, &client.BatchQuery{
+				State:        backend.InstanceStateActive,
+				WorkflowName: fn.Name(wf),
+			}, "signal", "batch", client.WithBatchRateLimit(1, time.Millisecond))
+			require.NoError(t, err)
+
+			p, err := job.Wait(ctx)
+			require.NoError(t, err)
+			require.Equal(t, 2, p.Matched)
+			require.Equal(t, 2, p.Succeeded)
+			require.Zero(t, p.Failed)
+
+			for _, instance := range instances {
+				r, err := client.GetWorkflowResult[string](ctx, c, instance, time.Second*10)
+				require.NoError(t, err)
+				require.Equal(t, "batch", r)
+			}
+
+			// Instances of other workflows are not matched
+			err = c.WaitForWorkflowInstance(ctx, otherInstance, time.Millisecond*100)
+			require.Error(t, err)
+		},
+	},
+	{
+		name: "Batch_Terminate",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			wf := func(ctx workflow.Context) error {
+				return workflow.Sleep(ctx, time.Hour)
+			}
+			register(t, ctx, w, []interface{}{wf}, nil)
+
+			instances := []*workflow.Instance{
+				runWorkflow(t, ctx, c, wf),
+				runWorkflow(t, ctx, c, wf),
+				runWorkflow(t, ctx, c, wf),
+			}
+
+			job, err := c.BatchTerminate(ctx, &client.BatchQuery{
+				State:        backend.InstanceStateActive,
+				WorkflowName: fn.Name(wf),
+			}, "cleanup")
+			require.NoError(t, err)
+
+			p, err := job.Wait(ctx)
+			require.NoError(t, err)
+			require.Equal(t, 3, p.Succeeded)
+
+			for _, instance := range instances {
+				_, err := client.GetWorkflowResult[any](ctx, c, instance, time.Second*10)
+				require.ErrorIs(t, err, client.ErrWorkflowTerminated)
+			}
+		},
+	},
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+// NamespacesBackendTest runs the same workflow with the same instance and execution IDs in two namespaces. setup
+// returns a backend for the given namespace, all backends it returns need to share their storage.
+func NamespacesBackendTest(t *testing.T, setup func(namespace string) TestBackend) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := func(ctx context.Context, msg string) (string, error) {
+		return msg + " activity", nil
+	}
+	wf := func(ctx workflow.Context, msg string) (string, error) {
+		signal, _ := workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+
+		if _, err := workflow.ScheduleTimer(ctx, time.Millisecond*10).Get(ctx); err != nil {
+			return "", err
+		}
+
+		return workflow.ExecuteActivity[string](ctx, workflow.DefaultActivityOptions, a, msg+" "+signal).Get(ctx)
+	}
+
+	namespaces := []string{"a", "b"}
+	backends := make([]TestBackend, len(namespaces))
+	clients := make([]client.Client, len(namespaces))
+	instances := make([]*workflow.Instance, len(namespaces))
+
+	for i, namespace := range namespaces {
+		backends[i] = setup(namespace)
+
+		// Every client generates the same execution IDs
+		var id int
+		clients[i] = client.New(backends[i], client.WithIDGenerator(func() string {
+			id++
+			return fmt.Sprintf("execution-%d", id)
+		}))
+
+		startWorker(t, worker.New(backends[i], nil), []interface{}{wf}, []interface{}{a})
+
+		instance, err := clients[i].CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+			InstanceID: "instance",
+		}, wf, namespace)
+		require.NoError(t, err)
+
+		instances[i] = instance
+	}
+
+	require.Equal(t, *instances[0], *instances[1])
+
+	// Both instances are waiting for their signal, with their pending events and histories side by side
+	for i, namespace := range namespaces {
+		require.NoError(t, clients[i].SignalWorkflow(ctx, instances[i].InstanceID, "signal", "signal-"+namespace))
+	}
+
+	for i, namespace := range namespaces {
+		r, err := client.GetWorkflowResult[string](ctx, clients[i], instances[i], time.Second*10)
+		require.NoError(t, err)
+		require.Equal(t, namespace+" signal-"+namespace+" activity", r)
+
+		events, err := backends[i].GetWorkflowInstanceHistory(ctx, instances[i], nil)
+		require.NoError(t, err)
+
+		started := 0
+		for _, event := range events {
+			if event.Type == history.EventType_WorkflowExecutionStarted {
+				started++
+			}
+		}
+
+		require.Equal(t, 1, started, "history contains events of another namespace")
+	}
+}
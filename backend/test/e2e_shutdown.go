@@ -0,0 +1,98 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+var e2eShutdownTests = []backendTest{
+	{
+		name: "Shutdown_WaitsForRunningActivities",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			started := make(chan struct{})
+			finish := make(chan struct{})
+
+			a := func(ctx context.Context) (int, error) {
+				close(started)
+				<-finish
+
+				return 42, nil
+			}
+			wf := func(ctx workflow.Context) (int, error) {
+				return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, a).Get(ctx)
+			}
+			register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+			runWorkflow(t, ctx, c, wf)
+
+			<-started
+
+			shutdown := make(chan error, 1)
+			go func() {
+				shutdown <- w.Shutdown(context.Background())
+			}()
+
+			select {
+			case <-shutdown:
+				require.Fail(t, "shutdown returned while the activity was running")
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			close(finish)
+			require.NoError(t, <-shutdown)
+
+			// The result of the activity has been recorded before the worker stopped
+			task, err := b.GetWorkflowTask(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, task)
+
+			var completed bool
+			for _, e := range task.NewEvents {
+				if e.Type == history.EventType_ActivityCompleted {
+					completed = true
+				}
+			}
+			require.True(t, completed)
+		},
+	},
+	{
+		name: "Shutdown_ReleasesRunningActivities",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			started := make(chan struct{})
+
+			a := func(ctx context.Context) (int, error) {
+				close(started)
+
+				// Block until the activity is aborted
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			wf := func(ctx workflow.Context) (int, error) {
+				return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, a).Get(ctx)
+			}
+			register(t, ctx, w, []interface{}{wf}, []interface{}{a})
+
+			runWorkflow(t, ctx, c, wf)
+
+			<-started
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			require.ErrorIs(t, w.Shutdown(shutdownCtx), context.DeadlineExceeded)
+
+			// The task has been released, it can be picked up again without waiting for the lock to expire
+			task, err := b.GetActivityTask(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, task)
+			require.True(t, task.Redelivered)
+		},
+	},
+}
@@ -50,6 +50,8 @@ var e2eStatsTests = []backendTest{
 			require.NoError(t, err)
 			require.Equal(t, int64(1), s.ActiveWorkflowInstances)
 			require.Equal(t, int64(1), s.PendingActivities)
+			require.Equal(t, int64(0), s.PendingActivityTasks)
+			require.Equal(t, int64(1), s.LockedActivityTasks)
 
 			af <- true
 
@@ -63,6 +65,28 @@ var e2eStatsTests = []backendTest{
 			require.NoError(t, err)
 			require.Equal(t, int64(0), s.ActiveWorkflowInstances)
 			require.Equal(t, int64(0), s.PendingActivities)
+			require.Equal(t, int64(0), s.PendingWorkflowTasks)
+			require.Equal(t, int64(0), s.LockedWorkflowTasks)
+		},
+	},
+	{
+		name: "Stats_PendingTimers",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			wf := func(ctx workflow.Context) error {
+				workflow.ScheduleTimer(ctx, time.Hour).Get(ctx)
+
+				return nil
+			}
+			register(t, ctx, w, []interface{}{wf}, nil)
+
+			runWorkflow(t, ctx, c, wf)
+
+			require.Eventually(t, func() bool {
+				s, err := b.GetStats(ctx)
+				require.NoError(t, err)
+
+				return s.PendingTimers == 1 && s.PendingWorkflowTasks == 0 && s.LockedWorkflowTasks == 0
+			}, time.Second*10, time.Millisecond*50)
 		},
 	},
 }
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+var e2eUpdateTests = []backendTest{
+	{
+		name: "Update_AppliesAndRejects",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			wf := func(ctx workflow.Context) (int, error) {
+				total := 0
+
+				if err := workflow.SetUpdateHandler(ctx, "add", func(n int) (int, error) {
+					total += n
+					return total, nil
+				}, workflow.WithUpdateValidator(func(n int) error {
+					if n < 0 {
+						return errors.New("cannot add negative numbers")
+					}
+
+					return nil
+				})); err != nil {
+					return 0, err
+				}
+
+				workflow.NewSignalChannel[any](ctx, "done").Receive(ctx)
+
+				return total, nil
+			}
+			register(t, ctx, w, []interface{}{wf}, nil)
+
+			instance := runWorkflow(t, ctx, c, wf)
+
+			update := func(n int) (int, error) {
+				v, err := c.UpdateWorkflow(ctx, instance, "add", n)
+				if err != nil {
+					return 0, err
+				}
+
+				var total int
+				require.NoError(t, v.Get(&total))
+				return total, nil
+			}
+
+			total, err := update(2)
+			require.NoError(t, err)
+			require.Equal(t, 2, total)
+
+			_, err = update(-1)
+			require.ErrorContains(t, err, "cannot add negative numbers")
+
+			total, err = update(3)
+			require.NoError(t, err)
+			require.Equal(t, 5, total)
+
+			_, err = c.UpdateWorkflow(ctx, instance, "unknown")
+			require.ErrorContains(t, err, "unknown update")
+
+			require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "done", nil))
+
+			r, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+			require.NoError(t, err)
+			require.Equal(t, 5, r)
+		},
+	},
+}
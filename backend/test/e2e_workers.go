@@ -0,0 +1,125 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// startWorker starts the given worker, it's stopped when the test finishes
+func startWorker(t *testing.T, w worker.Worker, workflows []interface{}, activities []interface{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, w.WaitForCompletion())
+	})
+
+	register(t, ctx, w, workflows, activities)
+}
+
+var e2eWorkerTests = []backendTest{
+	{
+		name: "Workers_SeparateWorkflowAndActivityWorkers",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			a := func(ctx context.Context, x int) (int, error) {
+				return x * 2, nil
+			}
+			wf := func(ctx workflow.Context, x int) (int, error) {
+				return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, a, x).Get(ctx)
+			}
+
+			// Each worker only knows about the tasks it executes, the workflow fails if the workflow worker picks up
+			// the activity task
+			startWorker(t, worker.NewWorkflowWorker(b, nil), []interface{}{wf}, nil)
+			startWorker(t, worker.NewActivityWorker(b, nil), nil, []interface{}{a})
+
+			instance := runWorkflow(t, ctx, c, wf, 21)
+
+			r, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+			require.NoError(t, err)
+			require.Equal(t, 42, r)
+		},
+	},
+	{
+		name: "Workers_ActivityWorkerDoesNotExecuteWorkflows",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			wf := func(ctx workflow.Context) (int, error) {
+				return 42, nil
+			}
+
+			startWorker(t, worker.NewActivityWorker(b, nil), []interface{}{wf}, nil)
+
+			instance := runWorkflow(t, ctx, c, wf)
+
+			_, err := client.GetWorkflowResult[int](ctx, c, instance, time.Millisecond*500)
+			require.Error(t, err)
+
+			// The workflow task is still waiting for a workflow worker
+			s, err := b.GetStats(ctx)
+			require.NoError(t, err)
+			require.Equal(t, int64(1), s.PendingWorkflowTasks)
+		},
+	},
+	{
+		name: "Workers_RoutesActivitiesToQueues",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			a := func(ctx context.Context, x int) (int, error) {
+				return x * 2, nil
+			}
+			wf := func(ctx workflow.Context, x int) (int, error) {
+				return workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+					Queue:        "gpu",
+					RetryOptions: workflow.RetryOptions{MaxAttempts: 1},
+				}, a, x).Get(ctx)
+			}
+
+			// The default worker doesn't know the activity, the workflow fails if it picks up the activity task
+			register(t, ctx, w, []interface{}{wf}, nil)
+			startWorker(t, worker.NewActivityWorker(b, &worker.Options{
+				ActivityPollers: 1,
+				Queues:          []workflow.Queue{"gpu"},
+			}), nil, []interface{}{a})
+
+			r, err := runWorkflowWithResult[int](t, ctx, c, wf, 21)
+			require.NoError(t, err)
+			require.Equal(t, 42, r)
+		},
+	},
+	{
+		name: "Workers_RoutesWorkflowsToQueues",
+		f: func(t *testing.T, ctx context.Context, c client.Client, w worker.Worker, b TestBackend) {
+			a := func(ctx context.Context) (int, error) {
+				return 42, nil
+			}
+			wf := func(ctx workflow.Context) (int, error) {
+				// Activities inherit the queue of their workflow
+				return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, a).Get(ctx)
+			}
+
+			// The default worker doesn't know the workflow, it fails if it picks up a task of the instance
+			register(t, ctx, w, nil, nil)
+			startWorker(t, worker.New(b, &worker.Options{
+				WorkflowPollers: 1,
+				ActivityPollers: 1,
+				Queues:          []workflow.Queue{"gpu"},
+			}), []interface{}{wf}, []interface{}{a})
+
+			instance, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+				InstanceID: uuid.NewString(),
+				Queue:      "gpu",
+			}, wf)
+			require.NoError(t, err)
+
+			r, err := client.GetWorkflowResult[int](ctx, c, instance, time.Second*10)
+			require.NoError(t, err)
+			require.Equal(t, 42, r)
+		},
+	},
+}
@@ -0,0 +1,47 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrRecorderUnavailable is returned by a Recorder while it is failing
+var ErrRecorderUnavailable = errors.New("unavailable")
+
+// Recorder records the items published to it, for testing sinks, replicas, and publishers. Pass its Publish method to
+// the Func adapter of the respective interface.
+type Recorder[T any] struct {
+	mu    sync.Mutex
+	items []T
+
+	// Failures is the number of calls to fail before items are recorded
+	Failures int
+}
+
+func (r *Recorder[T]) Publish(ctx context.Context, items []T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Failures > 0 {
+		r.Failures--
+		return ErrRecorderUnavailable
+	}
+
+	r.items = append(r.items, items...)
+
+	return nil
+}
+
+// PublishOne records a single item, see Publish
+func (r *Recorder[T]) PublishOne(ctx context.Context, item T) error {
+	return r.Publish(ctx, []T{item})
+}
+
+// Items returns the items recorded so far
+func (r *Recorder[T]) Items() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]T(nil), r.items...)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+type latencies struct {
+	mu sync.Mutex
+	d  []time.Duration
+}
+
+func newLatencies() *latencies {
+	return &latencies{
+		d: make([]time.Duration, 0),
+	}
+}
+
+func (l *latencies) Add(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.d = append(l.d, d)
+}
+
+// Percentile returns the p-th percentile (0-100) of the recorded latencies, using the nearest-rank method.
+func (l *latencies) Percentile(p float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return percentile(l.d, p)
+}
+
+func percentile(d []time.Duration, p float64) time.Duration {
+	if len(d) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(d))
+	copy(sorted, d)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+
+	return sorted[rank-1]
+}
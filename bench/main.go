@@ -19,7 +19,7 @@ import (
 	redisv8 "github.com/redis/go-redis/v9"
 )
 
-var b = flag.String("backend", "redis", "Backend to use. Supported backends are:\n- redis\n- mysql\n- sqlite\n")
+var b = flag.String("backend", "redis", "Backend to use. Supported backends are:\n- redis\n- mysql\n- sqlite\n- memory\n")
 var timeout = flag.Duration("timeout", time.Second*30, "Timeout for the benchmark run")
 var scenario = flag.String("scenario", "basic", "Scenario to run. Support scenarios are:\n- basic\n")
 var runs = flag.Int("runs", 1, "Number of root workflows to start")
@@ -30,6 +30,7 @@ var activities = flag.Int("activities", 2, "Number of activities to execute per
 var resultSize = flag.Int("resultsize", 100, "Size of activity result payload in bytes")
 var format = flag.String("format", "text", "Output format. Supported formats are:\n- text\n- csv\n")
 var cacheSize = flag.Int("cachesize", 128, "Size of the workflow executor cache")
+var rate = flag.Float64("rate", 0, "Number of root workflows to start per second. 0 starts all root workflows at once")
 
 func main() {
 	flag.Parse()
@@ -55,9 +56,24 @@ func main() {
 
 	c := client.New(ba)
 
+	var startTicker <-chan time.Time
+	if *rate > 0 {
+		t := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+		defer t.Stop()
+		startTicker = t.C
+	}
+
+	latencies := newLatencies()
+
 	start := time.Now()
 	wg := sync.WaitGroup{}
 	for i := 0; i < *runs; i++ {
+		if startTicker != nil && i > 0 {
+			<-startTicker
+		}
+
+		instanceStart := time.Now()
+
 		i, err := c.CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
 			InstanceID: fmt.Sprintf("root-%d", i),
 		}, Root, &MidInput{
@@ -80,6 +96,8 @@ func main() {
 			if err != nil {
 				panic(fmt.Errorf("Workflow instance %s failed: %w", i.InstanceID, err))
 			}
+
+			latencies.Add(time.Since(instanceStart))
 		}()
 	}
 
@@ -87,15 +105,30 @@ func main() {
 
 	end := time.Now()
 
+	stats, err := ba.GetStats(ctx)
+	if err != nil {
+		panic(fmt.Errorf("getting backend stats: %w", err))
+	}
+
+	duration := end.Sub(start)
+	throughput := float64(*runs) / duration.Seconds()
+
 	switch *format {
 	case "text":
-		log.Println("Ran", *runs, "root workflows in", end.Sub(start).Seconds(), "seconds")
+		log.Println("Ran", *runs, "root workflows in", duration.Seconds(), "seconds")
+		log.Printf("Throughput: %.2f root workflows/s", throughput)
+		log.Printf("Root workflow latency: p50=%v p90=%v p99=%v max=%v",
+			latencies.Percentile(50), latencies.Percentile(90), latencies.Percentile(99), latencies.Percentile(100))
+		log.Printf("Backend stats: active workflow instances=%d pending activities=%d",
+			stats.ActiveWorkflowInstances, stats.PendingActivities)
 		mm.Print()
 
 	case "csv":
 		fmt.Printf(
-			"%s,%v,%s,%d,%d,%d,%d,%d,%d\n",
-			*b, end.Sub(start).Seconds(), *scenario, *runs, *depth, *fanOut, *leafFanOut, *activities, *resultSize)
+			"%s,%v,%s,%d,%d,%d,%d,%d,%d,%v,%v,%v,%v,%v\n",
+			*b, duration.Seconds(), *scenario, *runs, *depth, *fanOut, *leafFanOut, *activities, *resultSize,
+			*rate, throughput,
+			latencies.Percentile(50).Seconds(), latencies.Percentile(90).Seconds(), latencies.Percentile(99).Seconds())
 	}
 }
 
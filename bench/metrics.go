@@ -12,7 +12,9 @@ import (
 
 type store struct {
 	counters *sync.Map
-	timers   map[string][]time.Duration
+
+	mu     sync.Mutex
+	timers map[string][]time.Duration
 }
 
 type memMetrics struct {
@@ -36,6 +38,20 @@ func (m *memMetrics) Print() {
 
 		return true
 	})
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	keys := make([]string, 0, len(m.s.timers))
+	for k := range m.s.timers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		d := m.s.timers[k]
+		fmt.Printf("%s: count=%d p50=%v p90=%v p99=%v\n", k, len(d), percentile(d, 50), percentile(d, 90), percentile(d, 99))
+	}
 }
 
 // Counter implements metrics.Client
@@ -59,6 +75,12 @@ func (m *memMetrics) Gauge(name string, tags metrics.Tags, value int64) {
 
 // Timing implements metrics.Client
 func (m *memMetrics) Timing(name string, tags metrics.Tags, duration time.Duration) {
+	k := key(name, mergeTags(m.tags, tags))
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	m.s.timers[k] = append(m.s.timers[k], duration)
 }
 
 // WithTags implements metrics.Client
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/activity"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+	"github.com/cschleiden/go-workflows/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (c *client) CompleteActivity(ctx context.Context, taskToken string, result interface{}, err error) error {
+	info := &CompleteActivityInfo{TaskToken: taskToken, Result: result, Err: err}
+
+	return interceptErr(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) error) error {
+		return i.CompleteActivity(ctx, info, next)
+	}, func(ctx context.Context) error {
+		return c.completeActivity(ctx, info.TaskToken, info.Result, info.Err)
+	})
+}
+
+func (c *client) completeActivity(ctx context.Context, taskToken string, result interface{}, err error) error {
+	ac, ok := c.backend.(backend.AsyncActivityCompleter)
+	if !ok {
+		return backend.ErrAsyncActivitiesNotSupported
+	}
+
+	token, terr := activity.DecodeTaskToken(taskToken)
+	if terr != nil {
+		return terr
+	}
+
+	ctx, span := c.backend.Tracer().Start(ctx, "CompleteActivity", trace.WithAttributes(
+		attribute.String(log.InstanceIDKey, token.Instance.InstanceID),
+		attribute.String(log.ActivityIDKey, token.ActivityID),
+	))
+	defer span.End()
+
+	var event *history.Event
+	if err != nil {
+		event = history.NewPendingEvent(
+			c.clock.Now(),
+			history.EventType_ActivityFailed,
+			&history.ActivityFailedAttributes{
+				Error: workflowerrors.FromError(err),
+			},
+			history.ScheduleEventID(token.ScheduleEventID),
+		)
+	} else {
+		r, err := c.backend.Converter().To(result)
+		if err != nil {
+			return fmt.Errorf("converting activity result: %w", err)
+		}
+
+		event = history.NewPendingEvent(
+			c.clock.Now(),
+			history.EventType_ActivityCompleted,
+			&history.ActivityCompletedAttributes{
+				Result: r,
+			},
+			history.ScheduleEventID(token.ScheduleEventID),
+		)
+	}
+
+	if err := ac.CompletePendingActivityTask(ctx, token.Instance, token.ActivityID, event); err != nil {
+		return fmt.Errorf("completing activity: %w", err)
+	}
+
+	return nil
+}
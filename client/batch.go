@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// DefaultBatchRateLimit is the rate at which batch operations are applied to workflow instances if no rate limit is
+// given
+var DefaultBatchRateLimit = backend.RateLimit{Limit: 50, Interval: time.Second}
+
+// BatchQuery selects the workflow instances a batch operation applies to. Instances have to match all given
+// criteria.
+type BatchQuery struct {
+	State backend.InstanceStateFilter
+
+	// WorkflowName, if set, only matches instances of the workflow with the given name
+	WorkflowName string
+
+	// CreatedAfter, if set, only matches instances created at or after the given time
+	CreatedAfter time.Time
+
+	// CreatedBefore, if set, only matches instances created before the given time
+	CreatedBefore time.Time
+
+	// SearchAttributes, if set, only matches instances that have all of the given search attributes
+	SearchAttributes workflow.SearchAttributes
+}
+
+type BatchOptions struct {
+	// RateLimit limits how many workflow instances the operation is applied to per interval. Defaults to
+	// DefaultBatchRateLimit.
+	RateLimit backend.RateLimit
+}
+
+type BatchOption func(*BatchOptions)
+
+// WithBatchRateLimit applies the batch operation to at most limit workflow instances per interval
+func WithBatchRateLimit(limit int, interval time.Duration) BatchOption {
+	return func(o *BatchOptions) {
+		o.RateLimit = backend.RateLimit{Limit: limit, Interval: interval}
+	}
+}
+
+// BatchFailure is a workflow instance the batch operation couldn't be applied to
+type BatchFailure struct {
+	Instance *workflow.Instance
+	Err      error
+}
+
+type BatchProgress struct {
+	// Matched is the number of workflow instances matching the query
+	Matched int
+
+	// Succeeded is the number of instances the operation has been applied to
+	Succeeded int
+
+	// Failed is the number of instances the operation failed for, see Failures
+	Failed int
+
+	Failures []BatchFailure
+}
+
+// BatchJob tracks a batch operation started by BatchCancel, BatchSignal, or BatchTerminate. The operation runs in the
+// background until it has been applied to all matching instances, or the job or the context it was started with is
+// canceled.
+type BatchJob struct {
+	mu       sync.Mutex
+	progress BatchProgress
+	err      error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Progress returns the progress of the batch operation so far
+func (j *BatchJob) Progress() BatchProgress {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	p := j.progress
+	p.Failures = append([]BatchFailure(nil), j.progress.Failures...)
+
+	return p
+}
+
+// Done returns a channel that's closed when the batch operation has stopped
+func (j *BatchJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// Cancel stops the batch operation. Instances the operation has already been applied to are not affected.
+func (j *BatchJob) Cancel() {
+	j.cancel()
+}
+
+// Wait blocks until the batch operation has stopped and returns its final progress. The returned error is not nil if
+// the matching instances couldn't be listed, or the job was stopped before it was applied to all of them. Failures for
+// individual instances are reported in the progress.
+func (j *BatchJob) Wait(ctx context.Context) (BatchProgress, error) {
+	select {
+	case <-ctx.Done():
+		return j.Progress(), ctx.Err()
+	case <-j.done:
+	}
+
+	j.mu.Lock()
+	err := j.err
+	j.mu.Unlock()
+
+	return j.Progress(), err
+}
+
+func (j *BatchJob) record(instance *workflow.Instance, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err != nil {
+		j.progress.Failed++
+		j.progress.Failures = append(j.progress.Failures, BatchFailure{Instance: instance, Err: err})
+	} else {
+		j.progress.Succeeded++
+	}
+}
+
+// BatchCancel cancels all workflow instances matching the given query
+func (c *client) BatchCancel(ctx context.Context, query *BatchQuery, opts ...BatchOption) (*BatchJob, error) {
+	return c.batch(ctx, query, opts, func(ctx context.Context, instance *workflow.Instance) error {
+		return c.CancelWorkflowInstance(ctx, instance)
+	})
+}
+
+// BatchSignal sends the given signal to all workflow instances matching the given query
+func (c *client) BatchSignal(ctx context.Context, query *BatchQuery, name string, arg interface{}, opts ...BatchOption) (*BatchJob, error) {
+	return c.batch(ctx, query, opts, func(ctx context.Context, instance *workflow.Instance) error {
+		return c.SignalWorkflow(ctx, instance.InstanceID, name, arg)
+	})
+}
+
+// BatchTerminate terminates all workflow instances matching the given query
+func (c *client) BatchTerminate(ctx context.Context, query *BatchQuery, reason string, opts ...BatchOption) (*BatchJob, error) {
+	return c.batch(ctx, query, opts, func(ctx context.Context, instance *workflow.Instance) error {
+		return c.TerminateWorkflowInstance(ctx, instance, reason)
+	})
+}
+
+func (c *client) batch(ctx context.Context, query *BatchQuery, opts []BatchOption, op func(ctx context.Context, instance *workflow.Instance) error) (*BatchJob, error) {
+	options := BatchOptions{
+		RateLimit: DefaultBatchRateLimit,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if query == nil {
+		query = &BatchQuery{}
+	}
+
+	// Determine all matches up front, applying the operation might change whether instances match the query
+	instances, err := c.batchInstances(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	job := &BatchJob{
+		progress: BatchProgress{Matched: len(instances)},
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	go func() {
+		defer close(job.done)
+		defer cancel()
+
+		tokens := float64(options.RateLimit.Limit)
+		updatedAt := c.clock.Now()
+
+		for _, instance := range instances {
+			for {
+				var wait time.Duration
+				now := c.clock.Now()
+				tokens, wait = options.RateLimit.Take(tokens, updatedAt, now)
+				updatedAt = now
+
+				if wait == 0 {
+					break
+				}
+
+				t := c.clock.Timer(wait)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+				case <-t.C:
+				}
+
+				if ctx.Err() != nil {
+					break
+				}
+			}
+
+			if err := ctx.Err(); err != nil {
+				job.mu.Lock()
+				job.err = err
+				job.mu.Unlock()
+
+				return
+			}
+
+			job.record(instance, op(ctx, instance))
+		}
+	}()
+
+	return job, nil
+}
+
+func (c *client) batchInstances(ctx context.Context, query *BatchQuery) ([]*workflow.Instance, error) {
+	q := &backend.ListWorkflowInstancesQuery{
+		State:            query.State,
+		CreatedAfter:     query.CreatedAfter,
+		CreatedBefore:    query.CreatedBefore,
+		SearchAttributes: query.SearchAttributes,
+	}
+
+	var instances []*workflow.Instance
+
+	for {
+		r, err := c.ListWorkflowInstances(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range r.Instances {
+			if query.WorkflowName != "" && i.WorkflowName != query.WorkflowName {
+				continue
+			}
+
+			instances = append(instances, i.Instance)
+		}
+
+		if r.NextPageToken == "" {
+			return instances, nil
+		}
+
+		q.PageToken = r.NextPageToken
+	}
+}
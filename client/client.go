@@ -18,48 +18,211 @@ import (
 	"github.com/cschleiden/go-workflows/log"
 	"github.com/cschleiden/go-workflows/metrics"
 	"github.com/cschleiden/go-workflows/workflow"
-	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrInstanceAlreadyExists is returned by CreateWorkflowInstance if an instance with the same instance ID already
+// exists and the instance ID reuse policy doesn't allow creating another one
+var ErrInstanceAlreadyExists = backend.ErrInstanceAlreadyExists
+
+// ErrConcurrencyLimitReached is returned by CreateWorkflowInstance if the workflow has reached the concurrency limit
+// configured in the backend
+var ErrConcurrencyLimitReached = backend.ErrConcurrencyLimitReached
+
 var ErrWorkflowCanceled = errors.New("workflow canceled")
-var ErrWorkflowTerminated = errors.New("workflow terminated")
+var ErrWorkflowTerminated = workflowerrors.ErrTerminated
+
+// ErrWorkflowTimedOut matches the *workflow.TimeoutError of kind TimeoutKindExecution returned by GetWorkflowResult
+// if the workflow instance exceeded its execution timeout
+var ErrWorkflowTimedOut = workflowerrors.ErrExecutionTimedOut
 
 type WorkflowInstanceOptions struct {
+	// InstanceID of the workflow instance. If empty, an instance ID is generated from the template configured with
+	// WithInstanceIDTemplate.
 	InstanceID string
+
+	// Priority of the workflow instance. Tasks of higher priority workflow instances and their activities are handed
+	// out to workers first.
+	Priority workflow.Priority
+
+	// Queue routes the tasks of the workflow instance to the workers polling the queue. Its sub-workflows and
+	// activities inherit the queue unless their options specify another one. Defaults to workflow.QueueDefault.
+	Queue workflow.Queue
+
+	// SearchAttributes are indexed by the backend and can be used to filter ListWorkflowInstances. The workflow can
+	// update them via workflow.UpsertSearchAttributes.
+	SearchAttributes workflow.SearchAttributes
+
+	// Memo is stored with the instance and returned when listing workflow instances. It's not indexed and not passed
+	// to the workflow. Executions continued as new inherit the memo.
+	Memo workflow.Memo
+
+	// InstanceIDReusePolicy determines whether the instance can be created if an instance with the same instance ID
+	// already exists. Defaults to allowing it, as long as no execution with the instance ID is active.
+	InstanceIDReusePolicy workflow.InstanceIDReusePolicy
+
+	// StartDelay delays the start of the workflow instance. The instance is created right away, but its first
+	// workflow task is only scheduled once the delay has passed. Signals and cancellation requests sent in the meantime
+	// are delivered once the instance has started.
+	StartDelay time.Duration
+
+	// ExecutionTimeout limits how long the workflow instance can run, measured from its start and including executions
+	// continued as new. When exceeded, the instance is terminated and GetWorkflowResult returns a TimeoutError
+	// matching ErrWorkflowTimedOut.
+	// Zero means no timeout.
+	ExecutionTimeout time.Duration
+
+	// WaitForConcurrencySlot determines whether CreateWorkflowInstance waits until the concurrency limit of the
+	// workflow allows the instance to start, instead of returning ErrConcurrencyLimitReached. Waiting can be bounded
+	// via the context.
+	WaitForConcurrencySlot bool
+
+	// Cron schedules the workflow instead of starting it once. New instances are started following the given
+	// five-field cron expression, evaluated in UTC. See backend.Schedule.
+	Cron string
+
+	// Interval schedules the workflow instead of starting it once. New instances are started at the given interval.
+	Interval time.Duration
+
+	// OverlapPolicy determines what happens when a scheduled run is due while the instance started by the previous
+	// run is still active. Defaults to skipping the run.
+	OverlapPolicy backend.OverlapPolicy
 }
 
 type Client interface {
+	// CreateWorkflowInstance starts a new instance of the given workflow. The workflow can be passed as a function, or
+	// by its registered name. If a cron expression or interval is given in
+	// the options, it creates a schedule starting instances periodically instead, the returned instance then only
+	// has its InstanceID set to the ID of the schedule.
 	CreateWorkflowInstance(ctx context.Context, options WorkflowInstanceOptions, wf workflow.Workflow, args ...interface{}) (*workflow.Instance, error)
 
+	// DeleteSchedule removes the schedule with the given ID. Instances already started by the schedule are not
+	// affected.
+	DeleteSchedule(ctx context.Context, scheduleID string) error
+
 	CancelWorkflowInstance(ctx context.Context, instance *workflow.Instance) error
 
+	// TerminateWorkflowInstance forcibly ends the given workflow instance. Unlike cancellation, the workflow doesn't
+	// get a chance to clean up. Running sub-workflows are terminated as well.
+	TerminateWorkflowInstance(ctx context.Context, instance *workflow.Instance, reason string) error
+
+	// PauseWorkflowInstance stops the execution of workflow tasks for the given instance. Signals, activity results, and
+	// other events are held until the instance is resumed.
+	PauseWorkflowInstance(ctx context.Context, instance *workflow.Instance) error
+
+	// ResumeWorkflowInstance resumes a paused workflow instance
+	ResumeWorkflowInstance(ctx context.Context, instance *workflow.Instance) error
+
+	// RetryWorkflowInstance retries a workflow instance whose workflow task failed too often and that has been moved to
+	// the dead-letter state, see worker.Options.MaxWorkflowTaskAttempts. List the instances in the dead-letter state
+	// with ListWorkflowInstances and backend.InstanceStateDeadLettered. To give up on a dead-lettered instance,
+	// terminate it.
+	RetryWorkflowInstance(ctx context.Context, instance *workflow.Instance) error
+
+	// BatchCancel cancels all workflow instances matching the given query. The instances are canceled in the
+	// background, use the returned job to track progress.
+	BatchCancel(ctx context.Context, query *BatchQuery, opts ...BatchOption) (*BatchJob, error)
+
+	// BatchSignal sends the given signal to all workflow instances matching the given query. The signals are sent in
+	// the background, use the returned job to track progress.
+	BatchSignal(ctx context.Context, query *BatchQuery, name string, arg interface{}, opts ...BatchOption) (*BatchJob, error)
+
+	// BatchTerminate terminates all workflow instances matching the given query. The instances are terminated in the
+	// background, use the returned job to track progress.
+	BatchTerminate(ctx context.Context, query *BatchQuery, reason string, opts ...BatchOption) (*BatchJob, error)
+
 	RemoveWorkflowInstance(ctx context.Context, instance *workflow.Instance) error
 
+	// RemoveFinishedWorkflowInstances removes all workflow instances that finished more than olderThan ago, including
+	// their histories. If workflowName is not empty, only instances of that workflow are removed. Returns the number
+	// of removed instances.
+	RemoveFinishedWorkflowInstances(ctx context.Context, olderThan time.Duration, workflowName string) (int, error)
+
 	WaitForWorkflowInstance(ctx context.Context, instance *workflow.Instance, timeout time.Duration) error
 
-	SignalWorkflow(ctx context.Context, instanceID string, name string, arg interface{}) error
+	SignalWorkflow(ctx context.Context, instanceID string, name string, arg interface{}, opts ...SignalOption) error
+
+	// QueryWorkflow evaluates the query handler with the given name, registered via workflow.SetQueryHandler, against
+	// the current state of the active execution of the given workflow instance. Queries are answered by workflow
+	// workers and are not recorded in the history of the instance.
+	QueryWorkflow(ctx context.Context, instanceID string, queryName string, args ...interface{}) (*QueryValue, error)
+
+	// UpdateWorkflow sends the update with the given name to the workflow instance and waits until the handler
+	// registered via workflow.SetUpdateHandler has applied it. Returns the result of the handler, or the error of a
+	// rejected update.
+	UpdateWorkflow(ctx context.Context, instance *workflow.Instance, name string, args ...interface{}) (*UpdateValue, error)
 
+	// CompleteActivity completes an activity that returned activity.ErrResultPending, identified by the token
+	// returned by activity.TaskToken. If err is not nil, the activity fails with err, otherwise result is the result
+	// of the activity.
+	CompleteActivity(ctx context.Context, taskToken string, result interface{}, err error) error
+
+	// ListWorkflowInstances returns a page of workflow instances matching the given query, newest first. Pass the
+	// NextPageToken of the result in the next query to retrieve the following page.
+	ListWorkflowInstances(ctx context.Context, query *backend.ListWorkflowInstancesQuery) (*backend.ListWorkflowInstancesResult, error)
+
+	// DescribeWorkflowInstance returns the state, parent, search attributes, and pending activities and timers of the
+	// given workflow instance. Returns backend.ErrInstanceNotFound if the instance doesn't exist.
+	DescribeWorkflowInstance(ctx context.Context, instance *workflow.Instance) (*WorkflowInstanceDescription, error)
+
+	// GetStats returns the number of active workflow instances, the depth of the workflow and activity task queues, and
+	// the number of pending timers of the backend
 	GetStats(ctx context.Context) (*backend.Stats, error)
 }
 
 type client struct {
 	backend backend.Backend
 	clock   clock.Clock
+	options Options
 }
 
-func New(backend backend.Backend) Client {
-	return &client{
-		backend: backend,
+func New(b backend.Backend, opts ...Option) Client {
+	c := &client{
+		backend: b,
 		clock:   clock.New(),
 	}
+
+	for _, opt := range opts {
+		opt(&c.options)
+	}
+
+	if c.options.IDGenerator == nil {
+		c.options.IDGenerator = func() string {
+			return backend.NewID(b)
+		}
+	}
+
+	return c
 }
 
 func (c *client) CreateWorkflowInstance(ctx context.Context, options WorkflowInstanceOptions, wf workflow.Workflow, args ...interface{}) (*workflow.Instance, error) {
-	// Check arguments
-	if err := a.ParamsMatch(wf, args...); err != nil {
-		return nil, err
+	workflowName, byName := wf.(string)
+	if !byName {
+		workflowName = fn.Name(wf)
+	}
+
+	info := &CreateWorkflowInstanceInfo{
+		Options:      &options,
+		WorkflowName: workflowName,
+		Args:         args,
+	}
+
+	return intercept(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) (*workflow.Instance, error)) (*workflow.Instance, error) {
+		return i.CreateWorkflowInstance(ctx, info, next)
+	}, func(ctx context.Context) (*workflow.Instance, error) {
+		return c.createWorkflowInstance(ctx, wf, info)
+	})
+}
+
+func (c *client) createWorkflowInstance(ctx context.Context, wf workflow.Workflow, info *CreateWorkflowInstanceInfo) (*workflow.Instance, error) {
+	options, workflowName, args := *info.Options, info.WorkflowName, info.Args
+
+	// Workflows started by name cannot be checked for matching arguments
+	if _, byName := wf.(string); !byName {
+		if err := a.ParamsMatch(wf, args...); err != nil {
+			return nil, err
+		}
 	}
 
 	inputs, err := a.ArgsToInputs(c.backend.Converter(), args...)
@@ -67,10 +230,21 @@ func (c *client) CreateWorkflowInstance(ctx context.Context, options WorkflowIns
 		return nil, fmt.Errorf("converting arguments: %w", err)
 	}
 
-	wfi := core.NewWorkflowInstance(options.InstanceID, uuid.NewString())
-	metadata := &workflow.Metadata{}
+	if err := options.SearchAttributes.Validate(); err != nil {
+		return nil, err
+	}
+
+	instanceID, err := c.options.instanceID(options.InstanceID, workflowName)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Cron != "" || options.Interval != 0 {
+		return c.createSchedule(ctx, options, instanceID, workflowName, inputs)
+	}
 
-	workflowName := fn.Name(wf)
+	wfi := core.NewWorkflowInstance(instanceID, c.options.IDGenerator())
+	metadata := &workflow.Metadata{}
 
 	// Start new span for the workflow instance
 	ctx, span := c.backend.Tracer().Start(ctx, fmt.Sprintf("CreateWorkflowInstance: %s", workflowName), trace.WithAttributes(
@@ -83,16 +257,34 @@ func (c *client) CreateWorkflowInstance(ctx context.Context, options WorkflowIns
 		propagator.Inject(ctx, metadata)
 	}
 
+	var eventOpts []history.HistoryEventOption
+	if options.StartDelay > 0 {
+		// Backends only schedule the first workflow task once the started event becomes visible
+		eventOpts = append(eventOpts, history.VisibleAt(c.clock.Now().Add(options.StartDelay)))
+	}
+
+	var executionDeadline *time.Time
+	if options.ExecutionTimeout > 0 {
+		deadline := c.clock.Now().Add(options.StartDelay + options.ExecutionTimeout)
+		executionDeadline = &deadline
+	}
+
 	startedEvent := history.NewPendingEvent(
 		c.clock.Now(),
 		history.EventType_WorkflowExecutionStarted,
 		&history.ExecutionStartedAttributes{
-			Metadata: metadata,
-			Name:     workflowName,
-			Inputs:   inputs,
-		})
-
-	if err := c.backend.CreateWorkflowInstance(ctx, wfi, startedEvent); err != nil {
+			Metadata:              metadata,
+			Name:                  workflowName,
+			Inputs:                inputs,
+			Priority:              options.Priority,
+			Queue:                 options.Queue,
+			SearchAttributes:      options.SearchAttributes,
+			Memo:                  options.Memo,
+			InstanceIDReusePolicy: options.InstanceIDReusePolicy,
+			ExecutionDeadline:     executionDeadline,
+		}, eventOpts...)
+
+	if err := c.createInstance(ctx, wfi, startedEvent, options.WaitForConcurrencySlot); err != nil {
 		return nil, fmt.Errorf("creating workflow instance: %w", err)
 	}
 
@@ -103,7 +295,44 @@ func (c *client) CreateWorkflowInstance(ctx context.Context, options WorkflowIns
 	return wfi, nil
 }
 
+// createInstance creates the instance in the backend. If waitForConcurrencySlot is set, creating the instance is
+// retried while the workflow is at its concurrency limit.
+func (c *client) createInstance(ctx context.Context, wfi *workflow.Instance, event *history.Event, waitForConcurrencySlot bool) error {
+	if !waitForConcurrencySlot {
+		return c.backend.CreateWorkflowInstance(ctx, wfi, event)
+	}
+
+	b := backoff.ExponentialBackOff{
+		InitialInterval:     time.Millisecond * 100,
+		MaxInterval:         time.Second * 5,
+		Multiplier:          1.5,
+		RandomizationFactor: 0.5,
+		Stop:                backoff.Stop,
+		Clock:               c.clock,
+	}
+	b.Reset()
+
+	return backoff.Retry(func() error {
+		err := c.backend.CreateWorkflowInstance(ctx, wfi, event)
+		if err != nil && !errors.Is(err, backend.ErrConcurrencyLimitReached) {
+			return backoff.Permanent(err)
+		}
+
+		return err
+	}, backoff.WithContext(&b, ctx))
+}
+
 func (c *client) CancelWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	info := &WorkflowInstanceInfo{Instance: instance}
+
+	return interceptErr(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) error) error {
+		return i.CancelWorkflowInstance(ctx, info, next)
+	}, func(ctx context.Context) error {
+		return c.cancelWorkflowInstance(ctx, info.Instance)
+	})
+}
+
+func (c *client) cancelWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
 	ctx, span := c.backend.Tracer().Start(ctx, "CancelWorkflowInstance", trace.WithAttributes(
 		attribute.String(log.InstanceIDKey, instance.InstanceID),
 	))
@@ -113,7 +342,101 @@ func (c *client) CancelWorkflowInstance(ctx context.Context, instance *workflow.
 	return c.backend.CancelWorkflowInstance(ctx, instance, cancellationEvent)
 }
 
-func (c *client) SignalWorkflow(ctx context.Context, instanceID string, name string, arg interface{}) error {
+func (c *client) TerminateWorkflowInstance(ctx context.Context, instance *workflow.Instance, reason string) error {
+	info := &TerminateWorkflowInstanceInfo{Instance: instance, Reason: reason}
+
+	return interceptErr(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) error) error {
+		return i.TerminateWorkflowInstance(ctx, info, next)
+	}, func(ctx context.Context) error {
+		return c.terminateWorkflowInstance(ctx, info.Instance, info.Reason)
+	})
+}
+
+func (c *client) terminateWorkflowInstance(ctx context.Context, instance *workflow.Instance, reason string) error {
+	ctx, span := c.backend.Tracer().Start(ctx, "TerminateWorkflowInstance", trace.WithAttributes(
+		attribute.String(log.InstanceIDKey, instance.InstanceID),
+	))
+	defer span.End()
+
+	terminationEvent := history.NewWorkflowTerminationEvent(c.clock.Now(), reason)
+	return c.backend.TerminateWorkflowInstance(ctx, instance, terminationEvent)
+}
+
+func (c *client) PauseWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	info := &WorkflowInstanceInfo{Instance: instance}
+
+	return interceptErr(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) error) error {
+		return i.PauseWorkflowInstance(ctx, info, next)
+	}, func(ctx context.Context) error {
+		return c.pauseWorkflowInstance(ctx, info.Instance)
+	})
+}
+
+func (c *client) pauseWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	ctx, span := c.backend.Tracer().Start(ctx, "PauseWorkflowInstance", trace.WithAttributes(
+		attribute.String(log.InstanceIDKey, instance.InstanceID),
+	))
+	defer span.End()
+
+	pauseEvent := history.NewWorkflowPausedEvent(c.clock.Now())
+	return c.backend.PauseWorkflowInstance(ctx, instance, pauseEvent)
+}
+
+func (c *client) ResumeWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	info := &WorkflowInstanceInfo{Instance: instance}
+
+	return interceptErr(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) error) error {
+		return i.ResumeWorkflowInstance(ctx, info, next)
+	}, func(ctx context.Context) error {
+		return c.resumeWorkflowInstance(ctx, info.Instance)
+	})
+}
+
+func (c *client) resumeWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	ctx, span := c.backend.Tracer().Start(ctx, "ResumeWorkflowInstance", trace.WithAttributes(
+		attribute.String(log.InstanceIDKey, instance.InstanceID),
+	))
+	defer span.End()
+
+	resumeEvent := history.NewWorkflowResumedEvent(c.clock.Now())
+	return c.backend.ResumeWorkflowInstance(ctx, instance, resumeEvent)
+}
+
+func (c *client) RetryWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	info := &WorkflowInstanceInfo{Instance: instance}
+
+	return interceptErr(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) error) error {
+		return i.RetryWorkflowInstance(ctx, info, next)
+	}, func(ctx context.Context) error {
+		return c.retryWorkflowInstance(ctx, info.Instance)
+	})
+}
+
+func (c *client) retryWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	ctx, span := c.backend.Tracer().Start(ctx, "RetryWorkflowInstance", trace.WithAttributes(
+		attribute.String(log.InstanceIDKey, instance.InstanceID),
+	))
+	defer span.End()
+
+	return c.backend.RetryWorkflowInstance(ctx, instance)
+}
+
+func (c *client) SignalWorkflow(ctx context.Context, instanceID string, name string, arg interface{}, opts ...SignalOption) error {
+	info := &SignalWorkflowInfo{InstanceID: instanceID, Name: name, Arg: arg}
+
+	return interceptErr(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) error) error {
+		return i.SignalWorkflow(ctx, info, next)
+	}, func(ctx context.Context) error {
+		return c.signalWorkflow(ctx, info.InstanceID, info.Name, info.Arg, opts...)
+	})
+}
+
+func (c *client) signalWorkflow(ctx context.Context, instanceID string, name string, arg interface{}, opts ...SignalOption) error {
+	var options SignalOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	ctx, span := c.backend.Tracer().Start(ctx, "SignalWorkflow", trace.WithAttributes(
 		attribute.String(log.InstanceIDKey, instanceID),
 		attribute.String(log.SignalNameKey, name),
@@ -125,6 +448,12 @@ func (c *client) SignalWorkflow(ctx context.Context, instanceID string, name str
 		return fmt.Errorf("converting arguments: %w", err)
 	}
 
+	var eventOpts []history.HistoryEventOption
+	if options.DeliverAt.After(c.clock.Now()) {
+		// Backend holds the signal until it becomes visible
+		eventOpts = append(eventOpts, history.VisibleAt(options.DeliverAt))
+	}
+
 	signalEvent := history.NewPendingEvent(
 		c.clock.Now(),
 		history.EventType_SignalReceived,
@@ -132,6 +461,7 @@ func (c *client) SignalWorkflow(ctx context.Context, instanceID string, name str
 			Name: name,
 			Arg:  input,
 		},
+		eventOpts...,
 	)
 
 	err = c.backend.SignalWorkflow(ctx, instanceID, signalEvent)
@@ -234,6 +564,10 @@ func GetWorkflowResult[T any](ctx context.Context, c Client, instance *workflow.
 			return *new(T), ErrWorkflowCanceled
 
 		case history.EventType_WorkflowExecutionTerminated:
+			if event.Attributes.(*history.ExecutionTerminatedAttributes).TimedOut {
+				return *new(T), workflowerrors.NewTimeoutError(workflowerrors.TimeoutKindExecution)
+			}
+
 			return *new(T), ErrWorkflowTerminated
 		}
 	}
@@ -242,6 +576,16 @@ func GetWorkflowResult[T any](ctx context.Context, c Client, instance *workflow.
 }
 
 func (c *client) RemoveWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance) error {
+	info := &WorkflowInstanceInfo{Instance: instance}
+
+	return interceptErr(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) error) error {
+		return i.RemoveWorkflowInstance(ctx, info, next)
+	}, func(ctx context.Context) error {
+		return c.removeWorkflowInstance(ctx, info.Instance)
+	})
+}
+
+func (c *client) removeWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance) error {
 	ctx, span := c.backend.Tracer().Start(ctx, "RemoveWorkflowInstance", trace.WithAttributes(
 		attribute.String(log.InstanceIDKey, instance.InstanceID),
 	))
@@ -249,3 +593,12 @@ func (c *client) RemoveWorkflowInstance(ctx context.Context, instance *core.Work
 
 	return c.backend.RemoveWorkflowInstance(ctx, instance)
 }
+
+func (c *client) RemoveFinishedWorkflowInstances(ctx context.Context, olderThan time.Duration, workflowName string) (int, error) {
+	ctx, span := c.backend.Tracer().Start(ctx, "RemoveFinishedWorkflowInstances", trace.WithAttributes(
+		attribute.String(log.WorkflowNameKey, workflowName),
+	))
+	defer span.End()
+
+	return backend.RemoveFinishedWorkflowInstances(ctx, c.backend, c.clock.Now().Add(-olderThan), workflowName)
+}
@@ -3,15 +3,21 @@ package client
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/benbjohnson/clock"
 	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
 	"github.com/cschleiden/go-workflows/internal/converter"
 	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/fn"
 	"github.com/cschleiden/go-workflows/internal/history"
 	"github.com/cschleiden/go-workflows/internal/logger"
+	"github.com/cschleiden/go-workflows/internal/metrics"
 	"github.com/cschleiden/go-workflows/workflow"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -150,3 +156,255 @@ func Test_Client_SignalWorkflow_WithArgs(t *testing.T) {
 	require.Nil(t, err)
 	b.AssertExpectations(t)
 }
+
+func Test_Client_SignalWorkflow_DeliverAt(t *testing.T) {
+	instanceID := uuid.NewString()
+
+	ctx := context.Background()
+
+	mockClock := clock.NewMock()
+	deliverAt := mockClock.Now().Add(time.Hour)
+
+	b := &backend.MockBackend{}
+	b.On("Tracer").Return(trace.NewNoopTracerProvider().Tracer("test"))
+	b.On("Logger").Return(logger.NewDefaultLogger())
+	b.On("Converter").Return(converter.DefaultConverter)
+	b.On("SignalWorkflow", mock.Anything, instanceID, mock.MatchedBy(func(event *history.Event) bool {
+		return event.Type == history.EventType_SignalReceived &&
+			event.VisibleAt != nil && event.VisibleAt.Equal(deliverAt)
+	})).Return(nil)
+
+	c := &client{
+		backend: b,
+		clock:   mockClock,
+	}
+
+	err := c.SignalWorkflow(ctx, instanceID, "test", "signal", WithDeliverAt(deliverAt))
+
+	require.Nil(t, err)
+	b.AssertExpectations(t)
+}
+
+func Test_Client_CreateWorkflowInstance_InstanceIDTemplate(t *testing.T) {
+	wf := func(workflow.Context) error {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	pattern := regexp.MustCompile(`^order-[0-9a-f-]{36}$`)
+
+	b := &backend.MockBackend{}
+	b.On("Tracer").Return(trace.NewNoopTracerProvider().Tracer("test"))
+	b.On("Logger").Return(logger.NewDefaultLogger())
+	b.On("Converter").Return(converter.DefaultConverter)
+	b.On("ContextPropagators").Return(nil)
+	b.On("Metrics").Return(metrics.NewNoopMetricsClient())
+	b.On("CreateWorkflowInstance", mock.Anything, mock.MatchedBy(func(instance *core.WorkflowInstance) bool {
+		return pattern.MatchString(instance.InstanceID)
+	}), mock.Anything).Return(nil)
+
+	c := New(b, WithInstanceIDTemplate("order-{uuid}"), WithInstanceIDPattern(pattern))
+
+	instance, err := c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{}, wf)
+	require.NoError(t, err)
+	require.Regexp(t, pattern, instance.InstanceID)
+	b.AssertExpectations(t)
+}
+
+func Test_Client_CreateWorkflowInstance_IDGenerator(t *testing.T) {
+	wf := func(workflow.Context) error {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	b := &backend.MockBackend{}
+	b.On("Tracer").Return(trace.NewNoopTracerProvider().Tracer("test"))
+	b.On("Logger").Return(logger.NewDefaultLogger())
+	b.On("Converter").Return(converter.DefaultConverter)
+	b.On("ContextPropagators").Return(nil)
+	b.On("Metrics").Return(metrics.NewNoopMetricsClient())
+	b.On("CreateWorkflowInstance", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	n := 0
+	c := New(b, WithIDGenerator(func() string {
+		n++
+		return fmt.Sprintf("id-%d", n)
+	}))
+
+	instance, err := c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{}, wf)
+	require.NoError(t, err)
+	require.Equal(t, "id-1", instance.InstanceID)
+	require.Equal(t, "id-2", instance.ExecutionID)
+
+	c = New(b, WithIDGenerator(func() string { return "tenant" }), WithInstanceIDTemplate("{id}-{workflow}"))
+
+	instance, err = c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{}, "order")
+	require.NoError(t, err)
+	require.Equal(t, "tenant-order", instance.InstanceID)
+	b.AssertExpectations(t)
+}
+
+func Test_Client_CreateWorkflowInstance_InvalidInstanceID(t *testing.T) {
+	wf := func(workflow.Context) error {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	b := &backend.MockBackend{}
+	b.On("Converter").Return(converter.DefaultConverter)
+
+	c := New(b, WithInstanceIDPattern(regexp.MustCompile(`^order-`)))
+
+	instance, err := c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{
+		InstanceID: "invoice-1234",
+	}, wf)
+	require.Nil(t, instance)
+	require.ErrorIs(t, err, ErrInvalidInstanceID)
+	b.AssertExpectations(t)
+}
+
+func Test_Client_CreateWorkflowInstance_WaitForConcurrencySlot(t *testing.T) {
+	wf := func(workflow.Context) error {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	b := &backend.MockBackend{}
+	b.On("Tracer").Return(trace.NewNoopTracerProvider().Tracer("test"))
+	b.On("Logger").Return(logger.NewDefaultLogger())
+	b.On("Converter").Return(converter.DefaultConverter)
+	b.On("ContextPropagators").Return(nil)
+	b.On("Metrics").Return(metrics.NewNoopMetricsClient())
+	b.On("CreateWorkflowInstance", mock.Anything, mock.Anything, mock.Anything).Return(backend.ErrConcurrencyLimitReached).Twice()
+	b.On("CreateWorkflowInstance", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	c := New(b)
+
+	instance, err := c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{
+		InstanceID:             "id",
+		WaitForConcurrencySlot: true,
+	}, wf)
+	require.NoError(t, err)
+	require.Equal(t, "id", instance.InstanceID)
+	b.AssertExpectations(t)
+}
+
+func Test_Client_CreateWorkflowInstance_ConcurrencyLimitReached(t *testing.T) {
+	wf := func(workflow.Context) error {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	b := &backend.MockBackend{}
+	b.On("Tracer").Return(trace.NewNoopTracerProvider().Tracer("test"))
+	b.On("Converter").Return(converter.DefaultConverter)
+	b.On("ContextPropagators").Return(nil)
+	b.On("CreateWorkflowInstance", mock.Anything, mock.Anything, mock.Anything).Return(backend.ErrConcurrencyLimitReached).Once()
+
+	c := New(b)
+
+	_, err := c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{InstanceID: "id"}, wf)
+	require.ErrorIs(t, err, ErrConcurrencyLimitReached)
+	b.AssertExpectations(t)
+}
+
+func Test_Client_CreateWorkflowInstance_Schedule(t *testing.T) {
+	wf := func(workflow.Context, int) error {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	b := sqlite.NewInMemoryBackend()
+	c := New(b)
+
+	instance, err := c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{
+		InstanceID: "nightly",
+		Cron:       "0 2 * * *",
+	}, wf, 42)
+	require.NoError(t, err)
+	require.Equal(t, &workflow.Instance{InstanceID: "nightly"}, instance)
+
+	schedules, err := b.ListSchedules(ctx)
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	require.Equal(t, "nightly", schedules[0].ID)
+	require.Len(t, schedules[0].Inputs, 1)
+	require.True(t, schedules[0].NextRunAt.After(time.Now()))
+	require.Equal(t, 2, schedules[0].NextRunAt.UTC().Hour())
+
+	_, err = c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{InstanceID: "nightly", Cron: "@daily"}, wf, 42)
+	require.ErrorIs(t, err, ErrScheduleAlreadyExists)
+
+	_, err = c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{InstanceID: "invalid", Cron: "0 25 * * *"}, wf, 42)
+	require.Error(t, err)
+
+	_, err = c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{InstanceID: "both", Cron: "@daily", Interval: time.Hour}, wf, 42)
+	require.Error(t, err)
+
+	require.NoError(t, c.DeleteSchedule(ctx, "nightly"))
+	require.ErrorIs(t, c.DeleteSchedule(ctx, "nightly"), backend.ErrScheduleNotFound)
+}
+
+type tenantInterceptor struct {
+	NoopInterceptor
+
+	name  string
+	calls *[]string
+}
+
+func (i *tenantInterceptor) CreateWorkflowInstance(ctx context.Context, info *CreateWorkflowInstanceInfo, next func(ctx context.Context) (*workflow.Instance, error)) (*workflow.Instance, error) {
+	*i.calls = append(*i.calls, i.name+":"+info.WorkflowName)
+	info.Options.InstanceID = i.name + "-" + info.Options.InstanceID
+
+	return next(ctx)
+}
+
+func (i *tenantInterceptor) SignalWorkflow(ctx context.Context, info *SignalWorkflowInfo, next func(ctx context.Context) error) error {
+	*i.calls = append(*i.calls, i.name+":"+info.Name)
+	if info.Name == "forbidden" {
+		return errors.New("signal not allowed")
+	}
+
+	return next(ctx)
+}
+
+func Test_Client_Interceptors(t *testing.T) {
+	wf := func(workflow.Context) error {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	var calls []string
+	b := sqlite.NewInMemoryBackend()
+	c := New(b, WithInterceptors(
+		&tenantInterceptor{name: "outer", calls: &calls},
+		&tenantInterceptor{name: "inner", calls: &calls},
+	))
+
+	instance, err := c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{InstanceID: "id"}, "workflow")
+	require.NoError(t, err)
+	require.Equal(t, "inner-outer-id", instance.InstanceID)
+
+	_, err = c.CreateWorkflowInstance(ctx, WorkflowInstanceOptions{InstanceID: "id"}, wf, 42)
+	require.EqualError(t, err, "mismatched argument count: expected 0, got 1")
+
+	require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "allowed", 1))
+	require.EqualError(t, c.SignalWorkflow(ctx, instance.InstanceID, "forbidden", 1), "signal not allowed")
+
+	require.Equal(t, []string{
+		"outer:workflow", "inner:workflow",
+		"outer:" + fn.Name(wf), "inner:" + fn.Name(wf),
+		"outer:allowed", "inner:allowed",
+		"outer:forbidden",
+	}, calls)
+
+	// Operations without custom interception are passed through
+	require.NoError(t, c.CancelWorkflowInstance(ctx, instance))
+}
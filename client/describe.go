@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/cschleiden/go-workflows/workflow"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WorkflowInstanceDescription describes a workflow instance as returned by DescribeWorkflowInstance
+type WorkflowInstanceDescription struct {
+	// Instance is the described instance. For sub-workflows, Instance.Parent is the parent instance.
+	Instance     *workflow.Instance
+	WorkflowName string
+	State        core.WorkflowInstanceState
+	CreatedAt    time.Time
+	CompletedAt  *time.Time
+
+	// DeadLetterReason is the reason the instance was moved to the dead-letter state, empty if it isn't dead-lettered
+	DeadLetterReason string
+
+	// SearchAttributes are the current search attributes of the instance, including upserts by the workflow
+	SearchAttributes workflow.SearchAttributes
+
+	Memo workflow.Memo
+
+	// PendingActivities are the activities that have been scheduled but whose results have not been processed by the
+	// workflow yet
+	PendingActivities []*PendingActivity
+
+	// PendingTimers are the timers that have been scheduled but have neither fired nor been canceled yet
+	PendingTimers []*PendingTimer
+}
+
+type PendingActivity struct {
+	ActivityID  string
+	Name        string
+	ScheduledAt time.Time
+
+	// Attempt of the activity, starting at 0 for the first attempt
+	Attempt int
+}
+
+type PendingTimer struct {
+	ScheduledAt time.Time
+	FireAt      time.Time
+}
+
+func (c *client) DescribeWorkflowInstance(ctx context.Context, instance *workflow.Instance) (*WorkflowInstanceDescription, error) {
+	ctx, span := c.backend.Tracer().Start(ctx, "DescribeWorkflowInstance", trace.WithAttributes(
+		attribute.String(log.InstanceIDKey, instance.InstanceID),
+	))
+	defer span.End()
+
+	info, err := c.backend.GetWorkflowInstanceInfo(ctx, instance)
+	if err != nil {
+		return nil, fmt.Errorf("getting workflow instance: %w", err)
+	}
+
+	h, err := c.backend.GetWorkflowInstanceHistory(ctx, instance, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting workflow history: %w", err)
+	}
+
+	d := &WorkflowInstanceDescription{
+		Instance:         info.Instance,
+		WorkflowName:     info.WorkflowName,
+		State:            info.State,
+		CreatedAt:        info.CreatedAt,
+		CompletedAt:      info.CompletedAt,
+		DeadLetterReason: info.DeadLetterReason,
+		SearchAttributes: workflow.SearchAttributes{},
+		Memo:             info.Memo,
+	}
+
+	// Scheduled activities and timers by schedule event ID, removed again when their outcome is part of the history
+	activities := map[int64]*PendingActivity{}
+	timers := map[int64]*PendingTimer{}
+	var activityOrder, timerOrder []int64
+
+	for _, event := range h {
+		switch event.Type {
+		case history.EventType_WorkflowExecutionStarted:
+			for name, value := range event.Attributes.(*history.ExecutionStartedAttributes).SearchAttributes {
+				d.SearchAttributes[name] = value
+			}
+
+		case history.EventType_SearchAttributesUpserted:
+			for name, value := range event.Attributes.(*history.SearchAttributesUpsertedAttributes).SearchAttributes {
+				d.SearchAttributes[name] = value
+			}
+
+		case history.EventType_ActivityScheduled:
+			a := event.Attributes.(*history.ActivityScheduledAttributes)
+			activities[event.ScheduleEventID] = &PendingActivity{
+				ActivityID:  event.ID,
+				Name:        a.Name,
+				ScheduledAt: event.Timestamp,
+				Attempt:     a.Attempt,
+			}
+			activityOrder = append(activityOrder, event.ScheduleEventID)
+
+		case history.EventType_ActivityCompleted, history.EventType_ActivityFailed:
+			delete(activities, event.ScheduleEventID)
+
+		case history.EventType_TimerScheduled:
+			timers[event.ScheduleEventID] = &PendingTimer{
+				ScheduledAt: event.Timestamp,
+				FireAt:      event.Attributes.(*history.TimerScheduledAttributes).At,
+			}
+			timerOrder = append(timerOrder, event.ScheduleEventID)
+
+		case history.EventType_TimerFired, history.EventType_TimerCanceled:
+			delete(timers, event.ScheduleEventID)
+		}
+	}
+
+	// Finished executions don't wait for anything anymore
+	if info.CompletedAt == nil {
+		for _, id := range activityOrder {
+			if a, ok := activities[id]; ok {
+				d.PendingActivities = append(d.PendingActivities, a)
+			}
+		}
+
+		for _, id := range timerOrder {
+			if t, ok := timers[id]; ok {
+				d.PendingTimers = append(d.PendingTimers, t)
+			}
+		}
+	}
+
+	return d, nil
+}
@@ -0,0 +1,43 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader is the header APIKeyAuth reads the API key from. Alternatively, the key can be passed as bearer token
+// in the Authorization header.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuth returns middleware rejecting requests that don't carry an API key accepted by validate
+func APIKeyAuth(validate func(r *http.Request, key string) bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if auth := r.Header.Get("Authorization"); key == "" && strings.HasPrefix(auth, "Bearer ") {
+				key = strings.TrimPrefix(auth, "Bearer ")
+			}
+
+			if key == "" || !validate(r, key) {
+				writeError(w, http.StatusUnauthorized, "invalid API key")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StaticAPIKeys returns a validator for APIKeyAuth accepting the given keys
+func StaticAPIKeys(keys ...string) func(r *http.Request, key string) bool {
+	return func(_ *http.Request, key string) bool {
+		for _, k := range keys {
+			if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+				return true
+			}
+		}
+
+		return false
+	}
+}
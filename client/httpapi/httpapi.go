@@ -0,0 +1,292 @@
+// Package httpapi exposes client operations as JSON over HTTP, so that services not written in Go can start, signal,
+// and cancel workflow instances and retrieve their results.
+//
+// Endpoints:
+//
+//	POST /instances                                        start a workflow instance
+//	POST /instances/{instanceID}/signals/{name}            signal the active execution of a workflow instance
+//	POST /instances/{instanceID}/{executionID}/cancel      cancel a workflow instance
+//	GET  /instances/{instanceID}/{executionID}/result      wait for a workflow instance to finish, return its result
+//
+// Arguments, signal values, and results are JSON values. They are passed to the converter of the client's backend as
+// json.RawMessage, which the default JSON converter stores unchanged.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// maxBodySize limits the size of request bodies
+const maxBodySize = 4 << 20
+
+type Middleware func(next http.Handler) http.Handler
+
+type options struct {
+	middleware []Middleware
+}
+
+type Option func(o *options)
+
+// WithMiddleware wraps the handler with the given middleware, for example to authenticate requests with APIKeyAuth.
+// Middleware is applied in the given order, the first one sees requests first.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, mw...)
+	}
+}
+
+type handler struct {
+	client client.Client
+}
+
+// NewHandler returns an http.Handler serving the API for the given client. Mount it under a prefix with
+// http.StripPrefix if needed.
+func NewHandler(c client.Client, opts ...Option) http.Handler {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var h http.Handler = &handler{client: c}
+	for i := len(o.middleware) - 1; i >= 0; i-- {
+		h = o.middleware[i](h)
+	}
+
+	return h
+}
+
+type instance struct {
+	InstanceID  string `json:"instance_id"`
+	ExecutionID string `json:"execution_id"`
+}
+
+type createInstanceRequest struct {
+	// InstanceID of the new instance. If empty, a random UUID is used.
+	InstanceID string            `json:"instance_id,omitempty"`
+	Workflow   string            `json:"workflow"`
+	Args       []json.RawMessage `json:"args,omitempty"`
+}
+
+type createInstanceResponse struct {
+	Instance instance `json:"instance"`
+}
+
+type workflowResultResponse struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *workflowError  `json:"error,omitempty"`
+}
+
+type workflowError struct {
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if segments[0] != "instances" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch {
+	// /instances
+	case len(segments) == 1:
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+
+		h.createInstance(w, r)
+
+	// /instances/{instanceID}/signals/{name}
+	case len(segments) == 4 && segments[2] == "signals":
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+
+		h.signal(w, r, segments[1], segments[3])
+
+	// /instances/{instanceID}/{executionID}/cancel
+	case len(segments) == 4 && segments[3] == "cancel":
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+
+		h.cancel(w, r, core.NewWorkflowInstance(segments[1], segments[2]))
+
+	// /instances/{instanceID}/{executionID}/result
+	case len(segments) == 4 && segments[3] == "result":
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+
+		h.result(w, r, core.NewWorkflowInstance(segments[1], segments[2]))
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *handler) createInstance(w http.ResponseWriter, r *http.Request) {
+	var req createInstanceRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Workflow == "" {
+		writeError(w, http.StatusBadRequest, "workflow is required")
+		return
+	}
+
+	args := make([]interface{}, 0, len(req.Args))
+	for _, arg := range req.Args {
+		args = append(args, arg)
+	}
+
+	wfi, err := h.client.CreateWorkflowInstance(r.Context(), client.WorkflowInstanceOptions{
+		InstanceID: req.InstanceID,
+	}, req.Workflow, args...)
+	if err != nil {
+		writeError(w, statusCode(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, &createInstanceResponse{
+		Instance: instance{InstanceID: wfi.InstanceID, ExecutionID: wfi.ExecutionID},
+	})
+}
+
+func (h *handler) signal(w http.ResponseWriter, r *http.Request, instanceID, name string) {
+	if instanceID == "" || name == "" {
+		writeError(w, http.StatusBadRequest, "instance ID and signal name are required")
+		return
+	}
+
+	// The body is the signal value, and optional
+	var arg json.RawMessage
+	if err := decodeBody(r, &arg); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.client.SignalWorkflow(r.Context(), instanceID, name, arg); err != nil {
+		writeError(w, statusCode(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) cancel(w http.ResponseWriter, r *http.Request, instance *workflow.Instance) {
+	if instance.InstanceID == "" || instance.ExecutionID == "" {
+		writeError(w, http.StatusBadRequest, "instance ID and execution ID are required")
+		return
+	}
+
+	if err := h.client.CancelWorkflowInstance(r.Context(), instance); err != nil {
+		writeError(w, statusCode(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) result(w http.ResponseWriter, r *http.Request, instance *workflow.Instance) {
+	if instance.InstanceID == "" || instance.ExecutionID == "" {
+		writeError(w, http.StatusBadRequest, "instance ID and execution ID are required")
+		return
+	}
+
+	var timeout time.Duration
+	if t := r.URL.Query().Get("timeout"); t != "" {
+		var err error
+		timeout, err = time.ParseDuration(t)
+		if err != nil || timeout < 0 {
+			writeError(w, http.StatusBadRequest, "invalid timeout")
+			return
+		}
+	}
+
+	if err := h.client.WaitForWorkflowInstance(r.Context(), instance, timeout); err != nil {
+		if errors.Is(err, backend.ErrInstanceNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+
+		writeError(w, http.StatusRequestTimeout, err.Error())
+		return
+	}
+
+	// The instance has finished, errors returned from here on are errors the workflow failed with
+	result, err := client.GetWorkflowResult[json.RawMessage](r.Context(), h.client, instance, timeout)
+	if err != nil {
+		we := &workflowError{Message: err.Error()}
+
+		var e *workflow.Error
+		if errors.As(err, &e) {
+			we.Type = e.Type
+		}
+
+		writeJSON(w, http.StatusOK, &workflowResultResponse{Error: we})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &workflowResultResponse{Result: result})
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return false
+	}
+
+	return true
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	d := json.NewDecoder(io.LimitReader(r.Body, maxBodySize))
+	d.DisallowUnknownFields()
+
+	return d.Decode(v)
+}
+
+// statusCode maps client errors to HTTP status codes
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, backend.ErrInstanceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, client.ErrInvalidInstanceID):
+		return http.StatusBadRequest
+	case errors.Is(err, backend.ErrInstanceAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, backend.ErrConcurrencyLimitReached), errors.Is(err, backend.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &errorResponse{Error: message})
+}
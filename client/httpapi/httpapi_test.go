@@ -0,0 +1,164 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func greetWorkflow(ctx workflow.Context, name string) (string, error) {
+	greeting, _ := workflow.NewSignalChannel[string](ctx, "greeting").Receive(ctx)
+
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+
+	return greeting + " " + name, nil
+}
+
+func sleepWorkflow(ctx workflow.Context) error {
+	return workflow.Sleep(ctx, time.Hour)
+}
+
+func newTestServer(t *testing.T, opts ...Option) *httptest.Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	b := sqlite.NewInMemoryBackend()
+
+	w := worker.New(b, nil)
+	require.NoError(t, w.RegisterWorkflow(greetWorkflow))
+	require.NoError(t, w.RegisterWorkflow(sleepWorkflow))
+	require.NoError(t, w.Start(ctx))
+
+	s := httptest.NewServer(NewHandler(client.New(b), opts...))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func do(t *testing.T, s *httptest.Server, method, path, body string, v interface{}) int {
+	req, err := http.NewRequest(method, s.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(APIKeyHeader, "key")
+
+	res, err := s.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	if v != nil {
+		require.NoError(t, json.Unmarshal(b, v))
+	}
+
+	return res.StatusCode
+}
+
+func Test_Handler_CreateSignalGetResult(t *testing.T) {
+	s := newTestServer(t)
+
+	var created createInstanceResponse
+	status := do(t, s, http.MethodPost, "/instances", `{"instance_id": "instance", "workflow": "greetWorkflow", "args": ["gopher"]}`, &created)
+	require.Equal(t, http.StatusCreated, status)
+	require.Equal(t, "instance", created.Instance.InstanceID)
+	require.NotEmpty(t, created.Instance.ExecutionID)
+
+	status = do(t, s, http.MethodPost, "/instances", `{"instance_id": "instance", "workflow": "greetWorkflow", "args": ["gopher"]}`, nil)
+	require.Equal(t, http.StatusConflict, status)
+
+	status = do(t, s, http.MethodPost, "/instances/instance/signals/greeting", `"hello"`, nil)
+	require.Equal(t, http.StatusNoContent, status)
+
+	var result workflowResultResponse
+	status = do(t, s, http.MethodGet, "/instances/instance/"+created.Instance.ExecutionID+"/result?timeout=10s", "", &result)
+	require.Equal(t, http.StatusOK, status)
+	require.Nil(t, result.Error)
+	require.JSONEq(t, `"hello gopher"`, string(result.Result))
+}
+
+func Test_Handler_WorkflowError(t *testing.T) {
+	s := newTestServer(t)
+
+	var created createInstanceResponse
+	require.Equal(t, http.StatusCreated, do(t, s, http.MethodPost, "/instances", `{"workflow": "greetWorkflow", "args": [""]}`, &created))
+	require.NotEmpty(t, created.Instance.InstanceID)
+
+	require.Equal(t, http.StatusNoContent, do(t, s, http.MethodPost, "/instances/"+created.Instance.InstanceID+"/signals/greeting", `"hello"`, nil))
+
+	var result workflowResultResponse
+	status := do(t, s, http.MethodGet, "/instances/"+created.Instance.InstanceID+"/"+created.Instance.ExecutionID+"/result?timeout=10s", "", &result)
+	require.Equal(t, http.StatusOK, status)
+	require.NotNil(t, result.Error)
+	require.Equal(t, "name is required", result.Error.Message)
+}
+
+func Test_Handler_Cancel(t *testing.T) {
+	s := newTestServer(t)
+
+	var created createInstanceResponse
+	require.Equal(t, http.StatusCreated, do(t, s, http.MethodPost, "/instances", `{"workflow": "sleepWorkflow"}`, &created))
+
+	path := "/instances/" + created.Instance.InstanceID + "/" + created.Instance.ExecutionID
+	require.Equal(t, http.StatusNoContent, do(t, s, http.MethodPost, path+"/cancel", "", nil))
+
+	var result workflowResultResponse
+	require.Equal(t, http.StatusOK, do(t, s, http.MethodGet, path+"/result?timeout=10s", "", &result))
+	require.NotNil(t, result.Error)
+	require.Equal(t, workflow.Canceled.Error(), result.Error.Message)
+}
+
+func Test_Handler_Validation(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPost, "/instances", `{"args": []}`, http.StatusBadRequest},
+		{http.MethodPost, "/instances", `{"workflow": "greetWorkflow", "unknown": true}`, http.StatusBadRequest},
+		{http.MethodPost, "/instances", `not json`, http.StatusBadRequest},
+		{http.MethodGet, "/instances", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/instances/unknown/signals/greeting", `"hello"`, http.StatusNotFound},
+		{http.MethodGet, "/instances/unknown/unknown/result?timeout=1s", "", http.StatusNotFound},
+		{http.MethodGet, "/instances/instance/execution/result?timeout=invalid", "", http.StatusBadRequest},
+		{http.MethodPost, "/instances/instance//cancel", "", http.StatusBadRequest},
+		{http.MethodGet, "/unknown", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			var res errorResponse
+			require.Equal(t, tt.status, do(t, s, tt.method, tt.path, tt.body, &res))
+			require.NotEmpty(t, res.Error)
+		})
+	}
+}
+
+func Test_Handler_APIKeyAuth(t *testing.T) {
+	s := newTestServer(t, WithMiddleware(APIKeyAuth(StaticAPIKeys("key"))))
+
+	require.Equal(t, http.StatusCreated, do(t, s, http.MethodPost, "/instances", `{"workflow": "sleepWorkflow"}`, nil))
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/instances", strings.NewReader(`{"workflow": "sleepWorkflow"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer other")
+
+	res, err := s.Client().Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// CreateWorkflowInstanceInfo describes a workflow instance being created. Interceptors can modify the options and
+// arguments before calling next.
+type CreateWorkflowInstanceInfo struct {
+	Options      *WorkflowInstanceOptions
+	WorkflowName string
+	Args         []interface{}
+}
+
+// SignalWorkflowInfo describes a signal being sent to a workflow instance. Interceptors can replace the argument
+// before calling next.
+type SignalWorkflowInfo struct {
+	InstanceID string
+	Name       string
+	Arg        interface{}
+}
+
+// WorkflowInstanceInfo describes the workflow instance an operation like cancellation or removal applies to
+type WorkflowInstanceInfo struct {
+	Instance *workflow.Instance
+}
+
+// TerminateWorkflowInstanceInfo describes a workflow instance being terminated
+type TerminateWorkflowInstanceInfo struct {
+	Instance *workflow.Instance
+	Reason   string
+}
+
+// QueryWorkflowInfo describes a query sent to a workflow instance
+type QueryWorkflowInfo struct {
+	InstanceID string
+	QueryName  string
+	Args       []interface{}
+}
+
+// UpdateWorkflowInfo describes an update sent to a workflow instance. Interceptors can replace the arguments before
+// calling next.
+type UpdateWorkflowInfo struct {
+	Instance *workflow.Instance
+	Name     string
+	Args     []interface{}
+}
+
+// CompleteActivityInfo describes the completion of an asynchronous activity
+type CompleteActivityInfo struct {
+	TaskToken string
+	Result    interface{}
+	Err       error
+}
+
+// DeleteScheduleInfo describes a schedule being deleted
+type DeleteScheduleInfo struct {
+	ScheduleID string
+}
+
+// Interceptor wraps the operations of the client. Each method has to call next to continue, returning without calling
+// next rejects the operation. Values added to the context passed to next are available to the backend and the
+// configured context propagators.
+type Interceptor interface {
+	CreateWorkflowInstance(ctx context.Context, info *CreateWorkflowInstanceInfo, next func(ctx context.Context) (*workflow.Instance, error)) (*workflow.Instance, error)
+	SignalWorkflow(ctx context.Context, info *SignalWorkflowInfo, next func(ctx context.Context) error) error
+	CancelWorkflowInstance(ctx context.Context, info *WorkflowInstanceInfo, next func(ctx context.Context) error) error
+	TerminateWorkflowInstance(ctx context.Context, info *TerminateWorkflowInstanceInfo, next func(ctx context.Context) error) error
+	PauseWorkflowInstance(ctx context.Context, info *WorkflowInstanceInfo, next func(ctx context.Context) error) error
+	ResumeWorkflowInstance(ctx context.Context, info *WorkflowInstanceInfo, next func(ctx context.Context) error) error
+	RetryWorkflowInstance(ctx context.Context, info *WorkflowInstanceInfo, next func(ctx context.Context) error) error
+	RemoveWorkflowInstance(ctx context.Context, info *WorkflowInstanceInfo, next func(ctx context.Context) error) error
+	QueryWorkflow(ctx context.Context, info *QueryWorkflowInfo, next func(ctx context.Context) (*QueryValue, error)) (*QueryValue, error)
+	UpdateWorkflow(ctx context.Context, info *UpdateWorkflowInfo, next func(ctx context.Context) (*UpdateValue, error)) (*UpdateValue, error)
+	CompleteActivity(ctx context.Context, info *CompleteActivityInfo, next func(ctx context.Context) error) error
+	DeleteSchedule(ctx context.Context, info *DeleteScheduleInfo, next func(ctx context.Context) error) error
+}
+
+// NoopInterceptor calls next for every operation. Embed it to implement only some of the methods of Interceptor.
+type NoopInterceptor struct{}
+
+var _ Interceptor = NoopInterceptor{}
+
+func (NoopInterceptor) CreateWorkflowInstance(ctx context.Context, info *CreateWorkflowInstanceInfo, next func(ctx context.Context) (*workflow.Instance, error)) (*workflow.Instance, error) {
+	return next(ctx)
+}
+
+func (NoopInterceptor) SignalWorkflow(ctx context.Context, info *SignalWorkflowInfo, next func(ctx context.Context) error) error {
+	return next(ctx)
+}
+
+func (NoopInterceptor) CancelWorkflowInstance(ctx context.Context, info *WorkflowInstanceInfo, next func(ctx context.Context) error) error {
+	return next(ctx)
+}
+
+func (NoopInterceptor) TerminateWorkflowInstance(ctx context.Context, info *TerminateWorkflowInstanceInfo, next func(ctx context.Context) error) error {
+	return next(ctx)
+}
+
+func (NoopInterceptor) PauseWorkflowInstance(ctx context.Context, info *WorkflowInstanceInfo, next func(ctx context.Context) error) error {
+	return next(ctx)
+}
+
+func (NoopInterceptor) ResumeWorkflowInstance(ctx context.Context, info *WorkflowInstanceInfo, next func(ctx context.Context) error) error {
+	return next(ctx)
+}
+
+func (NoopInterceptor) RetryWorkflowInstance(ctx context.Context, info *WorkflowInstanceInfo, next func(ctx context.Context) error) error {
+	return next(ctx)
+}
+
+func (NoopInterceptor) RemoveWorkflowInstance(ctx context.Context, info *WorkflowInstanceInfo, next func(ctx context.Context) error) error {
+	return next(ctx)
+}
+
+func (NoopInterceptor) QueryWorkflow(ctx context.Context, info *QueryWorkflowInfo, next func(ctx context.Context) (*QueryValue, error)) (*QueryValue, error) {
+	return next(ctx)
+}
+
+func (NoopInterceptor) UpdateWorkflow(ctx context.Context, info *UpdateWorkflowInfo, next func(ctx context.Context) (*UpdateValue, error)) (*UpdateValue, error) {
+	return next(ctx)
+}
+
+func (NoopInterceptor) CompleteActivity(ctx context.Context, info *CompleteActivityInfo, next func(ctx context.Context) error) error {
+	return next(ctx)
+}
+
+func (NoopInterceptor) DeleteSchedule(ctx context.Context, info *DeleteScheduleInfo, next func(ctx context.Context) error) error {
+	return next(ctx)
+}
+
+// intercept calls the given interceptors in order around next. call invokes the method of the interceptor for the
+// operation.
+func intercept[T any](
+	ctx context.Context,
+	interceptors []Interceptor,
+	call func(i Interceptor, ctx context.Context, next func(ctx context.Context) (T, error)) (T, error),
+	next func(ctx context.Context) (T, error),
+) (T, error) {
+	if len(interceptors) == 0 {
+		return next(ctx)
+	}
+
+	return call(interceptors[0], ctx, func(ctx context.Context) (T, error) {
+		return intercept(ctx, interceptors[1:], call, next)
+	})
+}
+
+// interceptErr is intercept for operations that only return an error
+func interceptErr(
+	ctx context.Context,
+	interceptors []Interceptor,
+	call func(i Interceptor, ctx context.Context, next func(ctx context.Context) error) error,
+	next func(ctx context.Context) error,
+) error {
+	if len(interceptors) == 0 {
+		return next(ctx)
+	}
+
+	return call(interceptors[0], ctx, func(ctx context.Context) error {
+		return interceptErr(ctx, interceptors[1:], call, next)
+	})
+}
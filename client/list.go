@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+func (c *client) ListWorkflowInstances(ctx context.Context, query *backend.ListWorkflowInstancesQuery) (*backend.ListWorkflowInstancesResult, error) {
+	ctx, span := c.backend.Tracer().Start(ctx, "ListWorkflowInstances")
+	defer span.End()
+
+	if query == nil {
+		query = &backend.ListWorkflowInstancesQuery{}
+	}
+
+	return c.backend.ListWorkflowInstances(ctx, query)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidInstanceID is returned by CreateWorkflowInstance if the instance ID doesn't match the pattern configured
+// with WithInstanceIDPattern
+var ErrInvalidInstanceID = errors.New("invalid instance ID")
+
+type Options struct {
+	// InstanceIDTemplate is used to generate instance IDs for workflow instances created without an explicit
+	// instance ID. The placeholders `{id}`, `{uuid}`, and `{workflow}` are replaced with a new ID from the
+	// IDGenerator, a new UUID, and the name of the workflow, respectively.
+	InstanceIDTemplate string
+
+	// IDGenerator generates the execution IDs of new workflow instances, and their instance IDs if neither an
+	// instance ID nor an InstanceIDTemplate is given. If not explicitly set, the IDGenerator of the backend is used.
+	IDGenerator func() string
+
+	// InstanceIDPattern, if set, is matched against the instance IDs of all workflow instances created by the
+	// client, including generated ones.
+	InstanceIDPattern *regexp.Regexp
+
+	// Interceptors wrap the operations of the client, the first interceptor is the outermost
+	Interceptors []Interceptor
+}
+
+type Option func(*Options)
+
+// WithInstanceIDTemplate sets the template used to generate instance IDs when no instance ID is given, for example
+// `order-{uuid}`. See Options.InstanceIDTemplate.
+func WithInstanceIDTemplate(template string) Option {
+	return func(o *Options) {
+		o.InstanceIDTemplate = template
+	}
+}
+
+// WithInstanceIDPattern rejects workflow instances whose instance ID doesn't match the given pattern with
+// ErrInvalidInstanceID. Use anchors to match the full instance ID, for example `^order-[0-9a-f-]{36}$`.
+func WithInstanceIDPattern(pattern *regexp.Regexp) Option {
+	return func(o *Options) {
+		o.InstanceIDPattern = pattern
+	}
+}
+
+// WithIDGenerator sets the function used to generate instance and execution IDs, for example ULIDs. See
+// Options.IDGenerator.
+func WithIDGenerator(generator func() string) Option {
+	return func(o *Options) {
+		o.IDGenerator = generator
+	}
+}
+
+// WithInterceptors adds interceptors wrapping the operations of the client. See Interceptor.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(o *Options) {
+		o.Interceptors = append(o.Interceptors, interceptors...)
+	}
+}
+
+type SignalOptions struct {
+	// DeliverAt delays delivery of the signal until the given time. The signal is delivered to the execution of the
+	// workflow instance that is active when the signal is sent. If zero or in the past, the signal is delivered
+	// immediately.
+	DeliverAt time.Time
+}
+
+type SignalOption func(*SignalOptions)
+
+// WithDeliverAt delays delivery of the signal until the given time. See SignalOptions.DeliverAt.
+func WithDeliverAt(deliverAt time.Time) SignalOption {
+	return func(o *SignalOptions) {
+		o.DeliverAt = deliverAt
+	}
+}
+
+// instanceID returns the instance ID to create a new instance of the given workflow with
+func (o *Options) instanceID(instanceID, workflowName string) (string, error) {
+	if instanceID == "" {
+		if o.InstanceIDTemplate != "" {
+			instanceID = strings.NewReplacer(
+				"{id}", o.IDGenerator(),
+				"{uuid}", uuid.NewString(),
+				"{workflow}", workflowName,
+			).Replace(o.InstanceIDTemplate)
+		} else {
+			instanceID = o.IDGenerator()
+		}
+	}
+
+	if o.InstanceIDPattern != nil && !o.InstanceIDPattern.MatchString(instanceID) {
+		return "", fmt.Errorf("%w: %q does not match %v", ErrInvalidInstanceID, instanceID, o.InstanceIDPattern)
+	}
+
+	return instanceID, nil
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	a "github.com/cschleiden/go-workflows/internal/args"
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultQueryTimeout is the time QueryWorkflow waits for an answer if the context doesn't have an earlier deadline
+const DefaultQueryTimeout = backend.DefaultQueryTimeout
+
+// QueryValue is the answer to a workflow query
+type QueryValue struct {
+	converter converter.Converter
+	result    payload.Payload
+}
+
+// Get decodes the answer into the given pointer
+func (v *QueryValue) Get(valuePtr interface{}) error {
+	return v.converter.From(v.result, valuePtr)
+}
+
+func (c *client) QueryWorkflow(ctx context.Context, instanceID string, queryName string, args ...interface{}) (*QueryValue, error) {
+	info := &QueryWorkflowInfo{InstanceID: instanceID, QueryName: queryName, Args: args}
+
+	return intercept(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) (*QueryValue, error)) (*QueryValue, error) {
+		return i.QueryWorkflow(ctx, info, next)
+	}, func(ctx context.Context) (*QueryValue, error) {
+		return c.queryWorkflow(ctx, info.InstanceID, info.QueryName, info.Args...)
+	})
+}
+
+func (c *client) queryWorkflow(ctx context.Context, instanceID string, queryName string, args ...interface{}) (*QueryValue, error) {
+	ctx, span := c.backend.Tracer().Start(ctx, "QueryWorkflow", trace.WithAttributes(
+		attribute.String(log.InstanceIDKey, instanceID),
+		attribute.String("query", queryName),
+	))
+	defer span.End()
+
+	q, ok := c.backend.(backend.Querier)
+	if !ok {
+		return nil, backend.ErrQueriesNotSupported
+	}
+
+	inputs, err := a.ArgsToInputs(c.backend.Converter(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("converting arguments: %w", err)
+	}
+
+	deadline := c.clock.Now().Add(DefaultQueryTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	query := &backend.Query{
+		ID:         uuid.NewString(),
+		InstanceID: instanceID,
+		Name:       queryName,
+		Args:       inputs,
+		Deadline:   deadline,
+	}
+
+	r, err := backend.RunQuery(ctx, q, c.clock, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Error != nil {
+		return nil, workflowerrors.ToError(r.Error)
+	}
+
+	return &QueryValue{converter: c.backend.Converter(), result: r.Result}, nil
+}
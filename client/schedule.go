@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/cschleiden/go-workflows/workflow"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrScheduleAlreadyExists is returned by CreateWorkflowInstance if a schedule with the same ID already exists
+var ErrScheduleAlreadyExists = backend.ErrScheduleAlreadyExists
+
+func (c *client) createSchedule(
+	ctx context.Context, options WorkflowInstanceOptions, scheduleID, workflowName string, inputs []payload.Payload,
+) (*workflow.Instance, error) {
+	ctx, span := c.backend.Tracer().Start(ctx, fmt.Sprintf("CreateSchedule: %s", workflowName), trace.WithAttributes(
+		attribute.String("schedule", scheduleID),
+		attribute.String(log.WorkflowNameKey, workflowName),
+	))
+	defer span.End()
+
+	ss, ok := c.backend.(backend.ScheduleStore)
+	if !ok {
+		return nil, backend.ErrSchedulesNotSupported
+	}
+
+	// Context propagated from the creation of the schedule is passed to every scheduled instance
+	metadata := &workflow.Metadata{}
+	for _, propagator := range c.backend.ContextPropagators() {
+		propagator.Inject(ctx, metadata)
+	}
+
+	s := &backend.Schedule{
+		ID:            scheduleID,
+		Cron:          options.Cron,
+		Interval:      options.Interval,
+		OverlapPolicy: options.OverlapPolicy,
+		WorkflowName:  workflowName,
+		Inputs:        inputs,
+		Metadata:      metadata,
+		Priority:      options.Priority,
+		Queue:         options.Queue,
+
+		ExecutionTimeout: options.ExecutionTimeout,
+		Memo:             options.Memo,
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	next, err := s.Next(c.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.NextRunAt = next
+
+	if err := ss.CreateSchedule(ctx, s); err != nil {
+		return nil, fmt.Errorf("creating schedule: %w", err)
+	}
+
+	c.backend.Logger().Debug("Created schedule", "schedule", scheduleID, log.WorkflowNameKey, workflowName)
+
+	return &workflow.Instance{InstanceID: scheduleID}, nil
+}
+
+func (c *client) DeleteSchedule(ctx context.Context, scheduleID string) error {
+	info := &DeleteScheduleInfo{ScheduleID: scheduleID}
+
+	return interceptErr(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) error) error {
+		return i.DeleteSchedule(ctx, info, next)
+	}, func(ctx context.Context) error {
+		return c.deleteSchedule(ctx, info.ScheduleID)
+	})
+}
+
+func (c *client) deleteSchedule(ctx context.Context, scheduleID string) error {
+	ctx, span := c.backend.Tracer().Start(ctx, "DeleteSchedule", trace.WithAttributes(
+		attribute.String("schedule", scheduleID),
+	))
+	defer span.End()
+
+	ss, ok := c.backend.(backend.ScheduleStore)
+	if !ok {
+		return backend.ErrSchedulesNotSupported
+	}
+
+	return ss.DeleteSchedule(ctx, scheduleID)
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	a "github.com/cschleiden/go-workflows/internal/args"
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultUpdateTimeout is the time UpdateWorkflow waits for an update to be handled if the context doesn't have an
+// earlier deadline
+const DefaultUpdateTimeout = 10 * time.Second
+
+// ErrUpdateNotHandled is returned by UpdateWorkflow if the execution of the workflow instance finished before it
+// handled the update
+var ErrUpdateNotHandled = errors.New("workflow instance finished before handling the update")
+
+// UpdateValue is the result of a workflow update
+type UpdateValue struct {
+	converter converter.Converter
+	result    payload.Payload
+}
+
+// Get decodes the result into the given pointer
+func (v *UpdateValue) Get(valuePtr interface{}) error {
+	return v.converter.From(v.result, valuePtr)
+}
+
+func (c *client) UpdateWorkflow(ctx context.Context, instance *workflow.Instance, name string, args ...interface{}) (*UpdateValue, error) {
+	info := &UpdateWorkflowInfo{Instance: instance, Name: name, Args: args}
+
+	return intercept(ctx, c.options.Interceptors, func(i Interceptor, ctx context.Context, next func(ctx context.Context) (*UpdateValue, error)) (*UpdateValue, error) {
+		return i.UpdateWorkflow(ctx, info, next)
+	}, func(ctx context.Context) (*UpdateValue, error) {
+		return c.updateWorkflow(ctx, info.Instance, info.Name, info.Args...)
+	})
+}
+
+func (c *client) updateWorkflow(ctx context.Context, instance *workflow.Instance, name string, args ...interface{}) (*UpdateValue, error) {
+	ctx, span := c.backend.Tracer().Start(ctx, "UpdateWorkflow", trace.WithAttributes(
+		attribute.String(log.InstanceIDKey, instance.InstanceID),
+		attribute.String(log.UpdateNameKey, name),
+	))
+	defer span.End()
+
+	inputs, err := a.ArgsToInputs(c.backend.Converter(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("converting arguments: %w", err)
+	}
+
+	deadline := c.clock.Now().Add(DefaultUpdateTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	updateID := uuid.NewString()
+
+	updateEvent := history.NewPendingEvent(
+		c.clock.Now(),
+		history.EventType_WorkflowUpdateRequested,
+		&history.WorkflowUpdateRequestedAttributes{
+			UpdateID: updateID,
+			Name:     name,
+			Args:     inputs,
+		},
+	)
+
+	// Updates are delivered to the active execution like signals
+	if err := c.backend.SignalWorkflow(ctx, instance.InstanceID, updateEvent); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("sending update: %w", err)
+	}
+
+	b := backoff.ExponentialBackOff{
+		InitialInterval:     time.Millisecond * 1,
+		MaxInterval:         time.Millisecond * 100,
+		Multiplier:          1.5,
+		RandomizationFactor: 0.5,
+		MaxElapsedTime:      deadline.Sub(c.clock.Now()),
+		Stop:                backoff.Stop,
+		Clock:               c.clock,
+	}
+	b.Reset()
+
+	ticker := backoff.NewTicker(&b)
+	defer ticker.Stop()
+
+	var lastSequenceID *int64
+
+	for range ticker.C {
+		h, err := c.backend.GetWorkflowInstanceHistory(ctx, instance, lastSequenceID)
+		if err != nil {
+			return nil, fmt.Errorf("getting workflow history: %w", err)
+		}
+
+		for _, event := range h {
+			switch event.Type {
+			case history.EventType_WorkflowUpdateCompleted:
+				a := event.Attributes.(*history.WorkflowUpdateCompletedAttributes)
+				if a.UpdateID != updateID {
+					continue
+				}
+
+				if a.Error != nil {
+					return nil, workflowerrors.ToError(a.Error)
+				}
+
+				return &UpdateValue{converter: c.backend.Converter(), result: a.Result}, nil
+
+			case history.EventType_WorkflowExecutionFinished, history.EventType_WorkflowExecutionContinuedAsNew:
+				return nil, ErrUpdateNotHandled
+			}
+		}
+
+		if len(h) > 0 {
+			lastSequenceID = &h[len(h)-1].SequenceID
+		}
+	}
+
+	return nil, errors.New("update was not handled in time")
+}
@@ -0,0 +1,141 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// referenceMarker prefixes payloads that have been offloaded to a blob store, followed by the key of the blob
+var referenceMarker = []byte{0x00, 'g', 'w', 'r'}
+
+// ErrBlobNotFound is returned by blob stores if there is no blob for the given key
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores large payloads outside of the backend, for example, in S3, GCS, or on a shared filesystem
+type BlobStore interface {
+	// Put stores the given data under the given key. Keys are derived from the data, storing the same key again
+	// stores the same data.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Get returns the data stored under the given key, or ErrBlobNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+type claimCheckCodec struct {
+	store     BlobStore
+	threshold int
+}
+
+// NewClaimCheckCodec returns a codec that offloads payloads larger than threshold bytes to the given blob store, and
+// replaces them with a reference in the history. References are resolved transparently when payloads are decoded,
+// for example by activities or when reading the result of a workflow instance.
+//
+// Blobs are keyed by the SHA-256 hash of their data, identical payloads are stored once. Blobs aren't removed
+// together with workflow instances, expire them in the blob store instead, for example, with a lifecycle policy.
+func NewClaimCheckCodec(store BlobStore, threshold int) PayloadCodec {
+	return &claimCheckCodec{
+		store:     store,
+		threshold: threshold,
+	}
+}
+
+func (c *claimCheckCodec) Encode(p Payload) (Payload, error) {
+	if len(p) <= c.threshold {
+		return p, nil
+	}
+
+	h := sha256.Sum256(p)
+	key := hex.EncodeToString(h[:])
+
+	if err := c.store.Put(context.Background(), key, p); err != nil {
+		return nil, fmt.Errorf("offloading payload: %w", err)
+	}
+
+	r := make([]byte, 0, len(referenceMarker)+len(key))
+	r = append(r, referenceMarker...)
+	r = append(r, key...)
+
+	return r, nil
+}
+
+func (c *claimCheckCodec) Decode(data Payload) (Payload, error) {
+	if !bytes.HasPrefix(data, referenceMarker) || len(data) <= len(referenceMarker) {
+		return data, nil
+	}
+
+	key := string(data[len(referenceMarker):])
+
+	p, err := c.store.Get(context.Background(), key)
+	if err != nil {
+		return nil, fmt.Errorf("resolving offloaded payload %v: %w", key, err)
+	}
+
+	return p, nil
+}
+
+type fileBlobStore struct {
+	dir string
+}
+
+var _ BlobStore = (*fileBlobStore)(nil)
+
+// NewFileBlobStore returns a blob store storing blobs as files in the given directory. All workers and clients need
+// to be able to access the directory, for example on a network share.
+func NewFileBlobStore(dir string) BlobStore {
+	return &fileBlobStore{dir: dir}
+}
+
+func (s *fileBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	p := filepath.Join(s.dir, filepath.Base(key))
+
+	if _, err := os.Stat(p); err == nil {
+		// Blobs are keyed by their data, it has been stored already
+		return nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("creating blob directory: %w", err)
+	}
+
+	// Write to a temporary file first, readers never see partially written blobs
+	f, err := os.CreateTemp(s.dir, ".blob-*")
+	if err != nil {
+		return fmt.Errorf("creating blob file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("writing blob file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing blob file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), p); err != nil {
+		return fmt.Errorf("moving blob file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *fileBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.Base(key)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrBlobNotFound
+		}
+
+		return nil, fmt.Errorf("reading blob file: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,63 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ClaimCheckCodec(t *testing.T) {
+	dir := t.TempDir()
+	c := NewCodecConverter(DefaultConverter, NewClaimCheckCodec(NewFileBlobStore(dir), 100))
+
+	small, err := c.To("hello")
+	require.NoError(t, err)
+	require.Equal(t, Payload(`"hello"`), small)
+
+	value := strings.Repeat("hello", 100)
+	large, err := c.To(value)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(large, referenceMarker))
+	require.Less(t, len(large), 100)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	var v string
+	require.NoError(t, c.From(large, &v))
+	require.Equal(t, value, v)
+
+	// Identical payloads are stored once
+	_, err = c.To(value)
+	require.NoError(t, err)
+
+	files, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
+
+func Test_ClaimCheckCodec_MissingBlob(t *testing.T) {
+	codec := NewClaimCheckCodec(NewFileBlobStore(t.TempDir()), 0)
+
+	_, err := codec.Decode(append(append(Payload{}, referenceMarker...), "missing"...))
+	require.ErrorIs(t, err, ErrBlobNotFound)
+}
+
+func Test_FileBlobStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewFileBlobStore(t.TempDir())
+
+	require.NoError(t, s.Put(ctx, "key", []byte("data")))
+
+	data, err := s.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("data"), data)
+
+	_, err = s.Get(ctx, "other")
+	require.ErrorIs(t, err, ErrBlobNotFound)
+}
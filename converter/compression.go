@@ -0,0 +1,126 @@
+package converter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// compressionMarker prefixes compressed payloads, followed by the ID of the compressor. JSON payloads never start
+// with a NUL byte, so payloads written before compression was enabled are still recognized as uncompressed.
+var compressionMarker = []byte{0x00, 'g', 'w', 'c'}
+
+// Compressor is a compression algorithm used by the compressing converter
+type Compressor interface {
+	// ID identifies the algorithm in compressed payloads. It must not change once payloads have been written.
+	ID() byte
+
+	Compress(data []byte) ([]byte, error)
+
+	Decompress(data []byte) ([]byte, error)
+}
+
+// Gzip compresses payloads using gzip with the default compression level. Payloads compressed with gzip can always
+// be decoded by a compressing converter, independent of the configured compressor.
+var Gzip Compressor = &gzipCompressor{}
+
+type gzipCompressor struct{}
+
+func (*gzipCompressor) ID() byte {
+	return 1
+}
+
+func (*gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (*gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+type compressionCodec struct {
+	compressor Compressor
+	minSize    int
+}
+
+// NewCompressionCodec returns a codec that compresses payloads using the given compressor. Payloads smaller than
+// minSize bytes are stored uncompressed.
+//
+// Compressed payloads are marked, so uncompressed payloads, for example written before compression was enabled, are
+// decoded unchanged. Other algorithms like zstd can be used by implementing Compressor.
+func NewCompressionCodec(compressor Compressor, minSize int) PayloadCodec {
+	return &compressionCodec{
+		compressor: compressor,
+		minSize:    minSize,
+	}
+}
+
+// NewCompressingConverter returns a converter that compresses the payloads of the inner converter, see
+// NewCompressionCodec.
+func NewCompressingConverter(inner Converter, compressor Compressor, minSize int) Converter {
+	if inner == nil {
+		inner = DefaultConverter
+	}
+
+	return NewCodecConverter(inner, NewCompressionCodec(compressor, minSize))
+}
+
+func (c *compressionCodec) Encode(p Payload) (Payload, error) {
+	if len(p) < c.minSize {
+		return p, nil
+	}
+
+	compressed, err := c.compressor.Compress(p)
+	if err != nil {
+		return nil, fmt.Errorf("compressing payload: %w", err)
+	}
+
+	r := make([]byte, 0, len(compressionMarker)+1+len(compressed))
+	r = append(r, compressionMarker...)
+	r = append(r, c.compressor.ID())
+	r = append(r, compressed...)
+
+	return r, nil
+}
+
+func (c *compressionCodec) Decode(data Payload) (Payload, error) {
+	if !bytes.HasPrefix(data, compressionMarker) || len(data) <= len(compressionMarker) {
+		return data, nil
+	}
+
+	id := data[len(compressionMarker)]
+
+	var compressor Compressor
+	switch id {
+	case c.compressor.ID():
+		compressor = c.compressor
+	case Gzip.ID():
+		compressor = Gzip
+	default:
+		return nil, fmt.Errorf("payload compressed with unknown compressor %d", id)
+	}
+
+	p, err := compressor.Decompress(data[len(compressionMarker)+1:])
+	if err != nil {
+		return nil, fmt.Errorf("decompressing payload: %w", err)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,94 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CompressingConverter(t *testing.T) {
+	c := NewCompressingConverter(DefaultConverter, Gzip, 100)
+
+	tests := []struct {
+		name       string
+		value      string
+		compressed bool
+	}{
+		{
+			name:       "small payload stays uncompressed",
+			value:      "hello",
+			compressed: false,
+		},
+		{
+			name:       "large payload is compressed",
+			value:      strings.Repeat("hello", 100),
+			compressed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := c.To(tt.value)
+			require.NoError(t, err)
+			require.Equal(t, tt.compressed, bytes.HasPrefix(p, compressionMarker))
+
+			var v string
+			require.NoError(t, c.From(p, &v))
+			require.Equal(t, tt.value, v)
+		})
+	}
+}
+
+func Test_CompressingConverter_DecodesUncompressedPayloads(t *testing.T) {
+	value := strings.Repeat("hello", 100)
+
+	p, err := DefaultConverter.To(value)
+	require.NoError(t, err)
+
+	var v string
+	require.NoError(t, NewCompressingConverter(DefaultConverter, Gzip, 0).From(p, &v))
+	require.Equal(t, value, v)
+}
+
+type reverseCompressor struct{}
+
+func (*reverseCompressor) ID() byte {
+	return 42
+}
+
+func (*reverseCompressor) Compress(data []byte) ([]byte, error) {
+	r := make([]byte, len(data))
+	for i, b := range data {
+		r[len(data)-1-i] = b
+	}
+
+	return r, nil
+}
+
+func (rc *reverseCompressor) Decompress(data []byte) ([]byte, error) {
+	return rc.Compress(data)
+}
+
+func Test_CompressingConverter_CustomCompressor(t *testing.T) {
+	c := NewCompressingConverter(DefaultConverter, &reverseCompressor{}, 0)
+
+	p, err := c.To("hello")
+	require.NoError(t, err)
+	require.Equal(t, append(append([]byte{}, compressionMarker...), 42, '"', 'o', 'l', 'l', 'e', 'h', '"'), []byte(p))
+
+	var v string
+	require.NoError(t, c.From(p, &v))
+	require.Equal(t, "hello", v)
+
+	// Payloads compressed with gzip can still be decoded after switching compressors
+	p, err = NewCompressingConverter(DefaultConverter, Gzip, 0).To("hello")
+	require.NoError(t, err)
+	require.NoError(t, c.From(p, &v))
+	require.Equal(t, "hello", v)
+
+	// Unknown compressors are rejected
+	p[len(compressionMarker)] = 7
+	require.ErrorContains(t, c.From(p, &v), "unknown compressor")
+}
@@ -0,0 +1,24 @@
+// Package converter contains converters and payload codecs that can be passed to a backend with
+// backend.WithConverter and backend.WithPayloadCodecs. Codecs transform the payloads of another converter, by
+// default converter.DefaultConverter, for example to compress or encrypt them.
+package converter
+
+import (
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/payload"
+)
+
+type (
+	Converter    = converter.Converter
+	PayloadCodec = converter.PayloadCodec
+	Payload      = payload.Payload
+)
+
+// DefaultConverter encodes values as JSON
+var DefaultConverter = converter.DefaultConverter
+
+// NewCodecConverter returns a converter passing payloads of the inner converter through the given codecs. Codecs
+// encode payloads in the given order, and decode them in reverse order.
+func NewCodecConverter(inner Converter, codecs ...PayloadCodec) Converter {
+	return converter.NewCodecConverter(inner, codecs...)
+}
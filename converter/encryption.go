@@ -0,0 +1,104 @@
+package converter
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// encryptionMarker prefixes encrypted payloads, followed by the length of the key ID, the key ID, the nonce, and the
+// ciphertext
+var encryptionMarker = []byte{0x00, 'g', 'w', 'e'}
+
+type aesGCMCodec struct {
+	keyID string
+	aeads map[string]cipher.AEAD
+}
+
+// NewAESGCMCodec returns a codec encrypting payloads with AES-GCM. keys maps key IDs to AES keys of 16, 24, or 32
+// bytes. New payloads are encrypted with the key identified by keyID, which is stored alongside the ciphertext.
+//
+// To rotate keys, add a new key and switch keyID to it, while keeping the previous keys to decrypt existing payloads.
+// Payloads that are not encrypted, for example written before encryption was enabled, are decoded unchanged.
+func NewAESGCMCodec(keyID string, keys map[string][]byte) (PayloadCodec, error) {
+	if len(keyID) == 0 || len(keyID) > 255 {
+		return nil, errors.New("key ID must be between 1 and 255 bytes long")
+	}
+
+	if _, ok := keys[keyID]; !ok {
+		return nil, fmt.Errorf("no key with ID %q", keyID)
+	}
+
+	aeads := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("creating cipher for key %q: %w", id, err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("creating cipher for key %q: %w", id, err)
+		}
+
+		aeads[id] = aead
+	}
+
+	return &aesGCMCodec{
+		keyID: keyID,
+		aeads: aeads,
+	}, nil
+}
+
+func (c *aesGCMCodec) Encode(p Payload) (Payload, error) {
+	aead := c.aeads[c.keyID]
+
+	header := make([]byte, 0, len(encryptionMarker)+1+len(c.keyID)+aead.NonceSize())
+	header = append(header, encryptionMarker...)
+	header = append(header, byte(len(c.keyID)))
+	header = append(header, c.keyID...)
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	// Authenticate the header, so that the key ID can't be swapped
+	return aead.Seal(append(header, nonce...), nonce, p, header), nil
+}
+
+func (c *aesGCMCodec) Decode(data Payload) (Payload, error) {
+	if !bytes.HasPrefix(data, encryptionMarker) || len(data) <= len(encryptionMarker) {
+		return data, nil
+	}
+
+	keyIDLen := int(data[len(encryptionMarker)])
+	headerLen := len(encryptionMarker) + 1 + keyIDLen
+	if len(data) < headerLen {
+		return nil, errors.New("decrypting payload: invalid payload")
+	}
+
+	keyID := string(data[len(encryptionMarker)+1 : headerLen])
+	aead, ok := c.aeads[keyID]
+	if !ok {
+		return nil, fmt.Errorf("decrypting payload: no key with ID %q", keyID)
+	}
+
+	if len(data) < headerLen+aead.NonceSize() {
+		return nil, errors.New("decrypting payload: invalid payload")
+	}
+
+	header := data[:headerLen]
+	nonce := data[headerLen : headerLen+aead.NonceSize()]
+
+	p, err := aead.Open(nil, nonce, data[headerLen+aead.NonceSize():], header)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting payload: %w", err)
+	}
+
+	return p, nil
+}
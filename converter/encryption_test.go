@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AESGCMCodec(t *testing.T) {
+	keys := map[string][]byte{
+		"v1": []byte("0123456789abcdef"),
+		"v2": []byte("0123456789abcdef0123456789abcdef"),
+	}
+
+	v1, err := NewAESGCMCodec("v1", keys)
+	require.NoError(t, err)
+
+	p, err := v1.Encode([]byte(`"secret"`))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(p, encryptionMarker))
+	require.NotContains(t, string(p), "secret")
+
+	d, err := v1.Decode(p)
+	require.NoError(t, err)
+	require.Equal(t, `"secret"`, string(d))
+
+	// After rotating keys, payloads encrypted with the previous key can still be decrypted
+	v2, err := NewAESGCMCodec("v2", keys)
+	require.NoError(t, err)
+
+	d, err = v2.Decode(p)
+	require.NoError(t, err)
+	require.Equal(t, `"secret"`, string(d))
+
+	p2, err := v2.Encode([]byte(`"secret"`))
+	require.NoError(t, err)
+	require.Contains(t, string(p2), "v2")
+
+	// Removed keys can't decrypt payloads anymore
+	v2Only, err := NewAESGCMCodec("v2", map[string][]byte{"v2": keys["v2"]})
+	require.NoError(t, err)
+
+	_, err = v2Only.Decode(p)
+	require.ErrorContains(t, err, `no key with ID "v1"`)
+
+	// Unencrypted payloads are passed through
+	d, err = v1.Decode([]byte(`"plain"`))
+	require.NoError(t, err)
+	require.Equal(t, `"plain"`, string(d))
+}
+
+func Test_AESGCMCodec_DetectsTampering(t *testing.T) {
+	c, err := NewAESGCMCodec("v1", map[string][]byte{"v1": []byte("0123456789abcdef")})
+	require.NoError(t, err)
+
+	p, err := c.Encode([]byte(`"secret"`))
+	require.NoError(t, err)
+
+	p[len(p)-1] ^= 0xff
+
+	_, err = c.Decode(p)
+	require.ErrorContains(t, err, "decrypting payload")
+}
+
+func Test_NewAESGCMCodec_ValidatesKeys(t *testing.T) {
+	_, err := NewAESGCMCodec("v2", map[string][]byte{"v1": []byte("0123456789abcdef")})
+	require.ErrorContains(t, err, `no key with ID "v2"`)
+
+	_, err = NewAESGCMCodec("v1", map[string][]byte{"v1": []byte("short")})
+	require.Error(t, err)
+}
+
+func Test_CodecConverter_CompressesBeforeEncrypting(t *testing.T) {
+	encryption, err := NewAESGCMCodec("v1", map[string][]byte{"v1": []byte("0123456789abcdef")})
+	require.NoError(t, err)
+
+	c := NewCodecConverter(DefaultConverter, NewCompressionCodec(Gzip, 0), encryption)
+
+	p, err := c.To("hello")
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(p, encryptionMarker))
+
+	var v string
+	require.NoError(t, c.From(p, &v))
+	require.Equal(t, "hello", v)
+}
@@ -0,0 +1,235 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// PayloadMetadata describes how the data of a payload has been encoded
+type PayloadMetadata = payload.Metadata
+
+const (
+	MetadataEncoding    = payload.MetadataEncoding
+	MetadataContentType = payload.MetadataContentType
+)
+
+// NewPayload returns a payload carrying the given metadata and data
+func NewPayload(metadata PayloadMetadata, data []byte) (Payload, error) {
+	return payload.New(metadata, data)
+}
+
+// SplitPayload returns the metadata and data of the given payload. Payloads without metadata are returned unchanged
+// with nil metadata.
+func SplitPayload(p Payload) (PayloadMetadata, []byte, error) {
+	return payload.Split(p)
+}
+
+// PayloadConverter encodes the values it supports with a single encoding. Payload converters are combined with
+// NewMetadataConverter.
+type PayloadConverter interface {
+	// Encoding identifies the encoding in the payload metadata, for example "json/plain". It must not change once
+	// payloads have been written.
+	Encoding() string
+
+	// ContentType is the MIME type of encoded values
+	ContentType() string
+
+	// CanEncode returns true if the converter can encode the given value
+	CanEncode(v interface{}) bool
+
+	// To encodes the given value
+	To(v interface{}) ([]byte, error)
+
+	// From decodes the given data into the value vptr points to
+	From(data []byte, vptr interface{}) error
+}
+
+type metadataConverter struct {
+	converters []PayloadConverter
+	byEncoding map[string]PayloadConverter
+}
+
+// NewMetadataConverter returns a converter that encodes values with the first of the given payload converters that
+// can encode them, and records its encoding in the payload metadata. Payloads are decoded by the converter matching
+// their encoding, so converters can be added or reordered without affecting payloads already written, as long as
+// all deployed workers and clients know the encodings in use.
+//
+// Payloads without metadata, for example written by DefaultConverter before metadata was recorded, are decoded as
+// JSON. Codecs applied to payloads afterwards, like compression or encryption, mark their output themselves.
+func NewMetadataConverter(converters ...PayloadConverter) Converter {
+	if len(converters) == 0 {
+		converters = DefaultPayloadConverters
+	}
+
+	byEncoding := make(map[string]PayloadConverter, len(converters))
+	for _, c := range converters {
+		byEncoding[c.Encoding()] = c
+	}
+
+	return &metadataConverter{
+		converters: converters,
+		byEncoding: byEncoding,
+	}
+}
+
+func (c *metadataConverter) To(v interface{}) (Payload, error) {
+	for _, pc := range c.converters {
+		if !pc.CanEncode(v) {
+			continue
+		}
+
+		data, err := pc.To(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return payload.New(PayloadMetadata{
+			MetadataEncoding:    pc.Encoding(),
+			MetadataContentType: pc.ContentType(),
+		}, data)
+	}
+
+	return nil, fmt.Errorf("no payload converter for value of type %T", v)
+}
+
+func (c *metadataConverter) From(p Payload, vptr interface{}) error {
+	metadata, data, err := payload.Split(p)
+	if err != nil {
+		return err
+	}
+
+	if metadata == nil {
+		return DefaultConverter.From(data, vptr)
+	}
+
+	encoding := metadata[MetadataEncoding]
+
+	pc, ok := c.byEncoding[encoding]
+	if !ok {
+		return fmt.Errorf("no payload converter for encoding %q", encoding)
+	}
+
+	return pc.From(data, vptr)
+}
+
+// DefaultPayloadConverters store byte slices unchanged, protobuf messages in the binary wire format, and all other
+// values as JSON
+var DefaultPayloadConverters = []PayloadConverter{
+	BinaryPayloadConverter,
+	NewProtoPayloadConverter(ProtoBinary),
+	JSONPayloadConverter,
+}
+
+// JSONPayloadConverter encodes any value as JSON
+var JSONPayloadConverter PayloadConverter = &jsonPayloadConverter{}
+
+type jsonPayloadConverter struct{}
+
+func (*jsonPayloadConverter) Encoding() string {
+	return "json/plain"
+}
+
+func (*jsonPayloadConverter) ContentType() string {
+	return "application/json"
+}
+
+func (*jsonPayloadConverter) CanEncode(v interface{}) bool {
+	return true
+}
+
+func (*jsonPayloadConverter) To(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (*jsonPayloadConverter) From(data []byte, vptr interface{}) error {
+	return json.Unmarshal(data, vptr)
+}
+
+// BinaryPayloadConverter stores byte slices unchanged, instead of base64 encoding them into JSON
+var BinaryPayloadConverter PayloadConverter = &binaryPayloadConverter{}
+
+type binaryPayloadConverter struct{}
+
+func (*binaryPayloadConverter) Encoding() string {
+	return "binary/plain"
+}
+
+func (*binaryPayloadConverter) ContentType() string {
+	return "application/octet-stream"
+}
+
+func (*binaryPayloadConverter) CanEncode(v interface{}) bool {
+	_, ok := v.([]byte)
+	return ok
+}
+
+func (*binaryPayloadConverter) To(v interface{}) ([]byte, error) {
+	return v.([]byte), nil
+}
+
+func (*binaryPayloadConverter) From(data []byte, vptr interface{}) error {
+	b, ok := vptr.(*[]byte)
+	if !ok {
+		return fmt.Errorf("cannot decode binary payload into %T", vptr)
+	}
+
+	*b = append([]byte(nil), data...)
+
+	return nil
+}
+
+type protoPayloadConverter struct {
+	encoding ProtoEncoding
+}
+
+// NewProtoPayloadConverter returns a payload converter encoding protobuf messages with the given encoding
+func NewProtoPayloadConverter(encoding ProtoEncoding) PayloadConverter {
+	return &protoPayloadConverter{encoding: encoding}
+}
+
+func (c *protoPayloadConverter) Encoding() string {
+	if c.encoding == ProtoJSON {
+		return "proto/json"
+	}
+
+	return "proto/binary"
+}
+
+func (c *protoPayloadConverter) ContentType() string {
+	if c.encoding == ProtoJSON {
+		return "application/json"
+	}
+
+	return "application/x-protobuf"
+}
+
+func (c *protoPayloadConverter) CanEncode(v interface{}) bool {
+	_, ok := v.(proto.Message)
+	return ok && !reflect.ValueOf(v).IsNil()
+}
+
+func (c *protoPayloadConverter) To(v interface{}) ([]byte, error) {
+	if c.encoding == ProtoJSON {
+		return protojson.Marshal(v.(proto.Message))
+	}
+
+	return proto.Marshal(v.(proto.Message))
+}
+
+func (c *protoPayloadConverter) From(data []byte, vptr interface{}) error {
+	m, err := protoTarget(vptr)
+	if err != nil {
+		return err
+	}
+
+	if c.encoding == ProtoJSON {
+		return protojson.Unmarshal(data, m)
+	}
+
+	return proto.Unmarshal(data, m)
+}
@@ -0,0 +1,104 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func Test_MetadataConverter(t *testing.T) {
+	c := NewMetadataConverter()
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		encoding string
+		decode   func(p Payload) (interface{}, error)
+	}{
+		{
+			name:     "bytes are stored unchanged",
+			value:    []byte{0x01, 0x02, 0x03},
+			encoding: "binary/plain",
+			decode: func(p Payload) (interface{}, error) {
+				var v []byte
+				err := c.From(p, &v)
+				return v, err
+			},
+		},
+		{
+			name:     "proto message",
+			value:    wrapperspb.String("hello"),
+			encoding: "proto/binary",
+			decode: func(p Payload) (interface{}, error) {
+				var v *wrapperspb.StringValue
+				err := c.From(p, &v)
+				return v.GetValue(), err
+			},
+		},
+		{
+			name:     "other values are stored as JSON",
+			value:    map[string]int{"count": 42},
+			encoding: "json/plain",
+			decode: func(p Payload) (interface{}, error) {
+				var v map[string]int
+				err := c.From(p, &v)
+				return v, err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := c.To(tt.value)
+			require.NoError(t, err)
+
+			metadata, data, err := SplitPayload(p)
+			require.NoError(t, err)
+			require.Equal(t, tt.encoding, metadata[MetadataEncoding])
+
+			if b, ok := tt.value.([]byte); ok {
+				require.Equal(t, b, data)
+			}
+
+			v, err := tt.decode(p)
+			require.NoError(t, err)
+
+			if m, ok := tt.value.(*wrapperspb.StringValue); ok {
+				require.Equal(t, m.GetValue(), v)
+			} else {
+				require.Equal(t, tt.value, v)
+			}
+		})
+	}
+}
+
+func Test_MetadataConverter_PayloadsWithoutMetadata(t *testing.T) {
+	p, err := DefaultConverter.To("hello")
+	require.NoError(t, err)
+
+	var v string
+	require.NoError(t, NewMetadataConverter().From(p, &v))
+	require.Equal(t, "hello", v)
+}
+
+func Test_MetadataConverter_UnknownEncoding(t *testing.T) {
+	p, err := NewMetadataConverter(NewProtoPayloadConverter(ProtoJSON)).To(wrapperspb.String("hello"))
+	require.NoError(t, err)
+
+	var v *wrapperspb.StringValue
+	require.ErrorContains(t, NewMetadataConverter().From(p, &v), `no payload converter for encoding "proto/json"`)
+}
+
+func Test_SplitPayload(t *testing.T) {
+	p, err := NewPayload(PayloadMetadata{MetadataEncoding: "custom"}, []byte("data"))
+	require.NoError(t, err)
+
+	metadata, data, err := SplitPayload(p)
+	require.NoError(t, err)
+	require.Equal(t, PayloadMetadata{MetadataEncoding: "custom"}, metadata)
+	require.Equal(t, []byte("data"), data)
+
+	_, _, err = SplitPayload(p[:6])
+	require.Error(t, err)
+}
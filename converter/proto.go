@@ -0,0 +1,132 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// protoMarker prefixes payloads of protobuf messages, followed by the ProtoEncoding of the message. Like compressed
+// payloads, they start with a NUL byte, so they can't be confused with JSON payloads of the inner converter.
+var protoMarker = []byte{0x00, 'g', 'w', 'p'}
+
+// ProtoEncoding is the wire format protobuf messages are encoded with
+type ProtoEncoding byte
+
+const (
+	// ProtoBinary encodes messages in the binary protobuf wire format
+	ProtoBinary ProtoEncoding = 1
+
+	// ProtoJSON encodes messages as JSON using protojson, which keeps payloads readable in the history
+	ProtoJSON ProtoEncoding = 2
+)
+
+// ProtoConverter encodes protobuf messages in the binary wire format, and all other values as JSON
+var ProtoConverter = NewProtoConverter(DefaultConverter, ProtoBinary)
+
+type protoConverter struct {
+	inner    Converter
+	encoding ProtoEncoding
+}
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// NewProtoConverter returns a converter that encodes values implementing proto.Message with the given encoding, and
+// passes all other values to the inner converter.
+//
+// Payloads are marked with the encoding of the message, so messages are decoded independently of the configured
+// encoding, and changing it doesn't affect payloads already written. Unmarked payloads, for example written by the
+// inner converter before protobuf messages were used, are decoded by the inner converter.
+func NewProtoConverter(inner Converter, encoding ProtoEncoding) Converter {
+	if inner == nil {
+		inner = DefaultConverter
+	}
+
+	return &protoConverter{
+		inner:    inner,
+		encoding: encoding,
+	}
+}
+
+func (c *protoConverter) To(v interface{}) (Payload, error) {
+	m, ok := v.(proto.Message)
+	if !ok || reflect.ValueOf(v).IsNil() {
+		return c.inner.To(v)
+	}
+
+	var data []byte
+	var err error
+
+	switch c.encoding {
+	case ProtoBinary:
+		data, err = proto.Marshal(m)
+	case ProtoJSON:
+		data, err = protojson.Marshal(m)
+	default:
+		return nil, fmt.Errorf("unknown proto encoding %d", c.encoding)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marshaling proto message: %w", err)
+	}
+
+	r := make([]byte, 0, len(protoMarker)+1+len(data))
+	r = append(r, protoMarker...)
+	r = append(r, byte(c.encoding))
+	r = append(r, data...)
+
+	return r, nil
+}
+
+func (c *protoConverter) From(data Payload, vptr interface{}) error {
+	if !bytes.HasPrefix(data, protoMarker) || len(data) <= len(protoMarker) {
+		return c.inner.From(data, vptr)
+	}
+
+	m, err := protoTarget(vptr)
+	if err != nil {
+		return err
+	}
+
+	encoding := ProtoEncoding(data[len(protoMarker)])
+	data = data[len(protoMarker)+1:]
+
+	switch encoding {
+	case ProtoBinary:
+		err = proto.Unmarshal(data, m)
+	case ProtoJSON:
+		err = protojson.Unmarshal(data, m)
+	default:
+		return fmt.Errorf("payload encoded with unknown proto encoding %d", encoding)
+	}
+	if err != nil {
+		return fmt.Errorf("unmarshaling proto message: %w", err)
+	}
+
+	return nil
+}
+
+// protoTarget returns the message to decode into for the given pointer. Pointers to message pointers, like the
+// pointers passed for results of type *pb.Message, are set to a new message.
+func protoTarget(vptr interface{}) (proto.Message, error) {
+	if m, ok := vptr.(proto.Message); ok {
+		return m, nil
+	}
+
+	v := reflect.ValueOf(vptr)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, fmt.Errorf("cannot decode proto message into %T", vptr)
+	}
+
+	t := v.Elem().Type()
+	if t.Kind() != reflect.Pointer || !t.Implements(protoMessageType) {
+		return nil, fmt.Errorf("cannot decode proto message into %T", vptr)
+	}
+
+	m := reflect.New(t.Elem())
+	v.Elem().Set(m)
+
+	return m.Interface().(proto.Message), nil
+}
@@ -0,0 +1,67 @@
+package converter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func Test_ProtoConverter(t *testing.T) {
+	for _, encoding := range []ProtoEncoding{ProtoBinary, ProtoJSON} {
+		c := NewProtoConverter(DefaultConverter, encoding)
+
+		msg, err := structpb.NewStruct(map[string]interface{}{"name": "gopher", "count": 42})
+		require.NoError(t, err)
+
+		p, err := c.To(msg)
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(p, protoMarker))
+		require.Equal(t, byte(encoding), p[len(protoMarker)])
+
+		// Decode into a message
+		got := &structpb.Struct{}
+		require.NoError(t, c.From(p, got))
+		require.True(t, proto.Equal(msg, got))
+
+		// Decode into a pointer to a message pointer, like results of type *structpb.Struct
+		var gotPtr *structpb.Struct
+		require.NoError(t, c.From(p, &gotPtr))
+		require.True(t, proto.Equal(msg, gotPtr))
+	}
+}
+
+func Test_ProtoConverter_DecodesIndependentOfEncoding(t *testing.T) {
+	p, err := NewProtoConverter(DefaultConverter, ProtoJSON).To(wrapperspb.String("hello"))
+	require.NoError(t, err)
+
+	var v *wrapperspb.StringValue
+	require.NoError(t, NewProtoConverter(DefaultConverter, ProtoBinary).From(p, &v))
+	require.Equal(t, "hello", v.GetValue())
+}
+
+func Test_ProtoConverter_OtherValues(t *testing.T) {
+	p, err := ProtoConverter.To("hello")
+	require.NoError(t, err)
+	require.Equal(t, Payload(`"hello"`), p)
+
+	var v string
+	require.NoError(t, ProtoConverter.From(p, &v))
+	require.Equal(t, "hello", v)
+
+	var msg *wrapperspb.StringValue
+	p, err = ProtoConverter.To(msg)
+	require.NoError(t, err)
+	require.Equal(t, Payload("null"), p)
+}
+
+func Test_ProtoConverter_InvalidTarget(t *testing.T) {
+	p, err := ProtoConverter.To(wrapperspb.String("hello"))
+	require.NoError(t, err)
+
+	var v string
+	require.ErrorContains(t, ProtoConverter.From(p, &v), "cannot decode proto message")
+}
@@ -0,0 +1,43 @@
+// Command devserver runs the embedded backend, the diagnostics web app, and the gRPC service of the devserver package.
+// Workers and clients in other processes connect to the same SQLite database using sqlite.NewSqliteBackend, clients
+// can also use the gRPC service.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/cschleiden/go-workflows/devserver"
+)
+
+var path = flag.String("db", "workflows.sqlite", "Path of the SQLite database")
+var addr = flag.String("addr", ":3000", "Address to serve the diagnostics web app on")
+var grpcAddr = flag.String("grpc-addr", ":3001", "Address to serve the gRPC service on, empty to disable it")
+
+func main() {
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	s := devserver.New(
+		devserver.WithPath(*path), devserver.WithAddr(*addr), devserver.WithGRPCAddr(*grpcAddr), devserver.WithoutWorker(),
+	)
+	if err := s.Start(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Storing workflows in %v, diagnostics web app available at http://%v", *path, s.Addr())
+	if *grpcAddr != "" {
+		log.Printf("gRPC service available at %v", s.GRPCAddr())
+	}
+
+	<-ctx.Done()
+
+	if err := s.WaitForCompletion(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,193 @@
+// Package devserver runs an embedded SQLite backend, a worker, the diagnostics web app, and the gRPC service of package
+// grpcserver in a single process. It's intended for trying out the library and for local development, not for
+// production use.
+package devserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/diag"
+	"github.com/cschleiden/go-workflows/service/grpcserver"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"google.golang.org/grpc"
+)
+
+type Server struct {
+	options Options
+
+	backend diag.Backend
+	client  client.Client
+	worker  worker.Worker
+
+	listener net.Listener
+	server   *http.Server
+
+	grpcListener net.Listener
+	grpcServer   *grpc.Server
+
+	wg sync.WaitGroup
+}
+
+var _ worker.Registry = (*Server)(nil)
+
+func New(opts ...Option) *Server {
+	options := DefaultOptions
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var b diag.Backend
+	if options.Path == "" {
+		b = sqlite.NewInMemoryBackend(options.BackendOptions...)
+	} else {
+		b = sqlite.NewSqliteBackend(options.Path, options.BackendOptions...)
+	}
+
+	s := &Server{
+		options: options,
+		backend: b,
+		client:  client.New(b),
+		worker:  worker.New(b, options.WorkerOptions),
+		server: &http.Server{
+			Handler: diag.NewServeMux(b),
+		},
+	}
+
+	if options.GRPCAddr != "" {
+		s.grpcServer = grpc.NewServer()
+		grpcserver.Register(s.grpcServer, b)
+	}
+
+	return s
+}
+
+// Backend returns the embedded backend
+func (s *Server) Backend() backend.Backend {
+	return s.backend
+}
+
+// Client returns a client for the embedded backend
+func (s *Server) Client() client.Client {
+	return s.client
+}
+
+// RegisterWorkflow registers a workflow with the embedded worker
+func (s *Server) RegisterWorkflow(w workflow.Workflow, opts ...worker.RegisterOption) error {
+	return s.worker.RegisterWorkflow(w, opts...)
+}
+
+// RegisterActivity registers an activity with the embedded worker
+func (s *Server) RegisterActivity(a interface{}, opts ...worker.RegisterOption) error {
+	return s.worker.RegisterActivity(a, opts...)
+}
+
+// Start starts the worker, and serves the diagnostics web app and the gRPC service. To stop the server, cancel the
+// context passed to Start. To wait for the worker, the web app, and the gRPC service to shut down, call
+// WaitForCompletion.
+func (s *Server) Start(ctx context.Context) error {
+	l, err := net.Listen("tcp", s.options.Addr)
+	if err != nil {
+		return fmt.Errorf("listening on %v: %w", s.options.Addr, err)
+	}
+
+	s.listener = l
+
+	if s.grpcServer != nil {
+		gl, err := net.Listen("tcp", s.options.GRPCAddr)
+		if err != nil {
+			l.Close()
+			return fmt.Errorf("listening on %v: %w", s.options.GRPCAddr, err)
+		}
+
+		s.grpcListener = gl
+	}
+
+	if !s.options.DisableWorker {
+		if err := s.worker.Start(ctx); err != nil {
+			l.Close()
+			if s.grpcListener != nil {
+				s.grpcListener.Close()
+			}
+
+			return fmt.Errorf("starting worker: %w", err)
+		}
+	}
+
+	if s.grpcServer != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+
+			if err := s.grpcServer.Serve(s.grpcListener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				s.backend.Logger().Error("serving gRPC service", "error", err)
+			}
+		}()
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		if err := s.server.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.backend.Logger().Error("serving diagnostics web app", "error", err)
+		}
+	}()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		<-ctx.Done()
+
+		if err := s.server.Shutdown(context.Background()); err != nil {
+			s.backend.Logger().Error("shutting down diagnostics web app", "error", err)
+		}
+
+		if s.grpcServer != nil {
+			s.grpcServer.GracefulStop()
+		}
+	}()
+
+	return nil
+}
+
+// Addr returns the address the diagnostics web app is served on, once the server has been started
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.options.Addr
+	}
+
+	return s.listener.Addr().String()
+}
+
+// GRPCAddr returns the address the gRPC service is served on, once the server has been started. It's empty if the
+// gRPC service is disabled.
+func (s *Server) GRPCAddr() string {
+	if s.grpcListener == nil {
+		return s.options.GRPCAddr
+	}
+
+	return s.grpcListener.Addr().String()
+}
+
+func (s *Server) WaitForCompletion() error {
+	if !s.options.DisableWorker {
+		if err := s.worker.WaitForCompletion(); err != nil {
+			return err
+		}
+	}
+
+	s.wg.Wait()
+
+	return nil
+}
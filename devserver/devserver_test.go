@@ -0,0 +1,78 @@
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/diag"
+	"github.com/cschleiden/go-workflows/service/grpcclient"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func devWorkflow(ctx workflow.Context, name string) (string, error) {
+	return "hello " + name, nil
+}
+
+func Test_Server(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := New(WithAddr("127.0.0.1:0"), WithGRPCAddr("127.0.0.1:0"))
+	require.NoError(t, s.RegisterWorkflow(devWorkflow))
+	require.NoError(t, s.Start(ctx))
+
+	instance, err := s.Client().CreateWorkflowInstance(ctx, client.WorkflowInstanceOptions{
+		InstanceID: uuid.NewString(),
+	}, devWorkflow, "dev")
+	require.NoError(t, err)
+
+	r, err := client.GetWorkflowResult[string](ctx, s.Client(), instance, time.Second*10)
+	require.NoError(t, err)
+	require.Equal(t, "hello dev", r)
+
+	// Diagnostics API lists the instance
+	res, err := http.Get("http://" + s.Addr() + "/api/")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var instances []*diag.WorkflowInstanceRef
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&instances))
+	require.Len(t, instances, 1)
+	require.Equal(t, instance.InstanceID, instances[0].Instance.InstanceID)
+
+	// The gRPC service creates instances executed by the embedded worker
+	conn, err := grpc.DialContext(ctx, s.GRPCAddr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	gc := grpcclient.New(conn)
+
+	instance, err = gc.CreateWorkflowInstance(ctx, "", devWorkflow, "grpc")
+	require.NoError(t, err)
+
+	r, err = grpcclient.GetWorkflowResult[string](ctx, gc, instance, time.Second*10)
+	require.NoError(t, err)
+	require.Equal(t, "hello grpc", r)
+
+	cancel()
+	require.NoError(t, s.WaitForCompletion())
+}
+
+func Test_Server_WithoutGRPC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := New(WithAddr("127.0.0.1:0"), WithoutGRPC())
+	require.NoError(t, s.Start(ctx))
+	require.Empty(t, s.GRPCAddr())
+
+	cancel()
+	require.NoError(t, s.WaitForCompletion())
+}
@@ -0,0 +1,86 @@
+package devserver
+
+import (
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/worker"
+)
+
+type Options struct {
+	// Path is the path of the SQLite database. If empty, the dev server uses an in-memory database, which is lost when
+	// the process exits.
+	Path string
+
+	// Addr is the address the diagnostics web app is served on. Defaults to ":3000".
+	Addr string
+
+	// GRPCAddr is the address the gRPC service of package grpcserver is served on. Defaults to ":3001", if empty, the
+	// gRPC service is disabled.
+	GRPCAddr string
+
+	// BackendOptions are passed to the embedded backend
+	BackendOptions []backend.BackendOption
+
+	// WorkerOptions are passed to the embedded worker. If nil, the default worker options are used.
+	WorkerOptions *worker.Options
+
+	// DisableWorker disables the embedded worker, for example, when workers run in other processes
+	DisableWorker bool
+}
+
+var DefaultOptions = Options{
+	Addr:     ":3000",
+	GRPCAddr: ":3001",
+}
+
+type Option func(*Options)
+
+// WithPath stores workflow instances in the SQLite database at the given path. Other processes can connect using
+// sqlite.NewSqliteBackend with the same path.
+func WithPath(path string) Option {
+	return func(o *Options) {
+		o.Path = path
+	}
+}
+
+// WithAddr sets the address the diagnostics web app is served on. Use ":0" to pick a free port, see Server.Addr.
+func WithAddr(addr string) Option {
+	return func(o *Options) {
+		o.Addr = addr
+	}
+}
+
+// WithGRPCAddr sets the address the gRPC service is served on. Use ":0" to pick a free port, see Server.GRPCAddr.
+func WithGRPCAddr(addr string) Option {
+	return func(o *Options) {
+		o.GRPCAddr = addr
+	}
+}
+
+// WithoutGRPC disables the gRPC service
+func WithoutGRPC() Option {
+	return func(o *Options) {
+		o.GRPCAddr = ""
+	}
+}
+
+// WithBackendOptions adds options for the embedded backend
+func WithBackendOptions(opts ...backend.BackendOption) Option {
+	return func(o *Options) {
+		o.BackendOptions = append(o.BackendOptions, opts...)
+	}
+}
+
+// WithoutWorker disables the embedded worker. Use this when workflows are executed by workers in other processes,
+// the embedded worker would otherwise pick up their tasks without having their workflows registered.
+func WithoutWorker() Option {
+	return func(o *Options) {
+		o.DisableWorker = true
+	}
+}
+
+// WithWorkerOptions sets the options for the embedded worker
+func WithWorkerOptions(options *worker.Options) Option {
+	return func(o *Options) {
+		o.WorkerOptions = options
+	}
+}
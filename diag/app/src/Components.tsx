@@ -64,10 +64,17 @@ export const WorkflowInstance: React.FC<{ instance: Instance }> = ({
   );
 };
 
-export const WorkflowInstanceState: React.FC<{ state: number }> = ({
-  state,
-}) => {
-  if (state === 0) {
+export const WorkflowInstanceState: React.FC<{
+  state: number;
+  deadLetterReason?: string;
+}> = ({ state, deadLetterReason }) => {
+  if (deadLetterReason) {
+    return (
+      <Badge bg="danger" title={deadLetterReason}>
+        DeadLettered
+      </Badge>
+    );
+  } else if (state === 0) {
     return <Badge bg="info">Active</Badge>;
   } else if (state === 1) {
     return (
@@ -154,6 +161,9 @@ function eventColor(event: string): [Color, string] {
       return ["light", "success"];
     case "WorkflowExecutionContinuedAsNew":
       return ["dark", "light"];
+    case "WorkflowExecutionPaused":
+    case "WorkflowExecutionResumed":
+      return ["dark", "danger"];
 
     default:
       return ["dark", "light"];
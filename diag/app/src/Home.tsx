@@ -1,11 +1,11 @@
-import { Pagination, Table } from "react-bootstrap";
-import { Link, useLocation } from "react-router-dom";
+import { Form, Pagination, Table } from "react-bootstrap";
+import { Link, useLocation, useNavigate } from "react-router-dom";
 
 import React from "react";
 import useFetch from "react-fetch-hook";
 import { LinkContainer } from "react-router-bootstrap";
 import { WorkflowInstance, WorkflowInstanceState } from "./Components";
-import { WorkflowInstanceRef } from "./client";
+import { namespaceQuery, WorkflowInstanceRef } from "./client";
 
 function useQuery() {
   const { search } = useLocation();
@@ -19,11 +19,20 @@ function Home() {
   const query = useQuery();
   const afterId = query.get("after");
   const page = +(query.get("page") || 1);
+  const search = query.getAll("search");
+  const searchQuery = search
+    .map((s) => `&search=${encodeURIComponent(s)}`)
+    .join("");
+
+  const navigate = useNavigate();
+  const [searchInput, setSearchInput] = React.useState(search.join(" "));
 
   const { isLoading, data } = useFetch<WorkflowInstanceRef[]>(
     document.location.pathname +
       `api/?count=${count}` +
-      (afterId ? `&after=${afterId}` : "")
+      (afterId ? `&after=${afterId}` : "") +
+      searchQuery +
+      namespaceQuery("&")
   );
 
   return (
@@ -32,6 +41,28 @@ function Home() {
         <h2>Instances</h2>
       </header>
 
+      <Form
+        className="mb-3"
+        onSubmit={(e) => {
+          e.preventDefault();
+
+          const params = new URLSearchParams();
+          searchInput
+            .split(" ")
+            .filter((s) => s !== "")
+            .forEach((s) => params.append("search", s));
+
+          navigate(`/?${params.toString()}`);
+        }}
+      >
+        <Form.Control
+          type="search"
+          placeholder="Filter by search attributes, e.g. customer=contoso"
+          value={searchInput}
+          onChange={(e) => setSearchInput(e.target.value)}
+        />
+      </Form>
+
       {isLoading && <div>Loading...</div>}
 
       {!isLoading && (
@@ -72,7 +103,10 @@ function Home() {
                     <code>{i.completed_at}</code>
                   </td>
                   <td style={{ textAlign: "center" }}>
-                    <WorkflowInstanceState state={i.state} />
+                    <WorkflowInstanceState
+                      state={i.state}
+                      deadLetterReason={i.dead_letter_reason}
+                    />
                   </td>
                 </tr>
               ))}
@@ -81,7 +115,7 @@ function Home() {
 
           <div className="d-flex justify-content-center">
             <Pagination>
-              <LinkContainer to="/?">
+              <LinkContainer to={`/?${searchQuery.substring(1)}`}>
                 <Pagination.First disabled={!afterId} />
               </LinkContainer>
               <Pagination.Item active>{page}</Pagination.Item>
@@ -92,7 +126,7 @@ function Home() {
                       data[data.length - 1].instance.execution_id
                     }`) ||
                   ""
-                }&page=${page + 1}`}
+                }&page=${page + 1}${searchQuery}`}
               >
                 <Pagination.Next disabled={!data || data.length < count} />
               </LinkContainer>
@@ -1,3 +1,4 @@
+import React from "react";
 import { Accordion, Alert, Card } from "react-bootstrap";
 import { Link, useParams } from "react-router-dom";
 import {
@@ -14,6 +15,7 @@ import {
   ExecutionStartedAttributes,
   HistoryEvent,
   WorkflowInstanceInfo,
+  namespaceQuery,
 } from "./client";
 
 import useFetch from "react-fetch-hook";
@@ -30,7 +32,12 @@ function Instance() {
     data: instance,
     error,
   } = useFetch<WorkflowInstanceInfo>(
-    document.location.pathname + "api/" + instanceId + "/" + executionId
+    document.location.pathname +
+      "api/" +
+      instanceId +
+      "/" +
+      executionId +
+      namespaceQuery("?")
   );
 
   if (isLoading) {
@@ -102,9 +109,21 @@ function Instance() {
 
         <dt className="col-sm-4">State</dt>
         <dd className="col-sm-8">
-          <WorkflowInstanceState state={instance.state} />
+          <WorkflowInstanceState
+            state={instance.state}
+            deadLetterReason={instance.dead_letter_reason}
+          />
         </dd>
 
+        {instance.dead_letter_reason && (
+          <>
+            <dt className="col-sm-4">Dead-letter reason</dt>
+            <dd className="col-sm-8">
+              <code>{instance.dead_letter_reason}</code>
+            </dd>
+          </>
+        )}
+
         <dt className="col-sm-4">Created at</dt>
         <dd className="col-sm-8">{instance.created_at}</dd>
 
@@ -112,6 +131,16 @@ function Instance() {
         <dd className="col-sm-8">
           {!instance.completed_at ? <i>pending</i> : instance.completed_at}
         </dd>
+
+        {instance.memo &&
+          Object.entries(instance.memo).map(([key, value]) => (
+            <React.Fragment key={key}>
+              <dt className="col-sm-4">
+                Memo: <code>{key}</code>
+              </dt>
+              <dd className="col-sm-8">{value}</dd>
+            </React.Fragment>
+          ))}
       </dl>
 
       <Card>
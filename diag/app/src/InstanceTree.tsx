@@ -5,7 +5,7 @@ import { Point } from "react-d3-tree/lib/types/types/common";
 import useFetch from "react-fetch-hook";
 import { Link } from "react-router-dom";
 import { WorkflowInstanceState } from "./Components";
-import { WorkflowInstanceTree } from "./client";
+import { WorkflowInstanceTree, namespaceQuery } from "./client";
 
 function useCenteredTree(
   data: unknown
@@ -41,7 +41,8 @@ export const InstanceTree: React.FC<{
       instanceId +
       "/" +
       executionId +
-      "/tree"
+      "/tree" +
+      namespaceQuery("?")
   );
 
   const [translate, containerRef] = useCenteredTree(instanceTree);
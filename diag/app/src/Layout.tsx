@@ -10,6 +10,8 @@ import { Outlet, useNavigate } from "react-router-dom";
 import React, { useState } from "react";
 
 import { LinkContainer } from "react-router-bootstrap";
+import useFetch from "react-fetch-hook";
+import { getNamespace, setNamespace } from "./client";
 
 function Layout() {
   const navigate = useNavigate();
@@ -19,6 +21,15 @@ function Layout() {
     navigate(`/${input}`);
   };
 
+  const { data: namespaces } = useFetch<string[]>(
+    document.location.pathname + "api/namespaces"
+  );
+  const onNamespaceChange = (e: React.ChangeEvent<HTMLSelectElement>) => {
+    setNamespace(e.target.value);
+    navigate("/");
+    document.location.reload();
+  };
+
   return (
     <>
       <header>
@@ -37,6 +48,20 @@ function Layout() {
                 </LinkContainer>
               </Nav>
               <Form className="d-flex">
+                {namespaces && namespaces.length > 1 && (
+                  <Form.Select
+                    className="me-2"
+                    aria-label="Namespace"
+                    value={getNamespace() || ""}
+                    onChange={onNamespaceChange}
+                  >
+                    {namespaces.map((namespace) => (
+                      <option key={namespace} value={namespace}>
+                        {namespace}
+                      </option>
+                    ))}
+                  </Form.Select>
+                )}
                 <FormControl
                   type="search"
                   placeholder="InstanceID"
@@ -11,6 +11,10 @@ export interface WorkflowInstanceRef {
   completed_at?: string;
 
   state: number;
+
+  dead_letter_reason?: string;
+
+  memo?: { [key: string]: string };
 }
 
 export type WorkflowInstanceInfo = WorkflowInstanceRef & {
@@ -45,3 +49,21 @@ export type WorkflowInstanceTree = WorkflowInstanceRef & {
   workflow_name: string;
   children: WorkflowInstanceTree[];
 };
+
+const namespaceStorageKey = "namespace";
+
+export function getNamespace(): string | null {
+  return window.localStorage.getItem(namespaceStorageKey);
+}
+
+export function setNamespace(namespace: string) {
+  window.localStorage.setItem(namespaceStorageKey, namespace);
+}
+
+// namespaceQuery returns the query parameter selecting the current namespace, prefixed with the given separator
+export function namespaceQuery(separator: "?" | "&"): string {
+  const namespace = getNamespace();
+  return namespace
+    ? `${separator}namespace=${encodeURIComponent(namespace)}`
+    : "";
+}
@@ -15,6 +15,12 @@ type WorkflowInstanceRef struct {
 	CreatedAt   time.Time                  `json:"created_at,omitempty"`
 	CompletedAt *time.Time                 `json:"completed_at,omitempty"`
 	State       core.WorkflowInstanceState `json:"state"`
+
+	// DeadLetterReason is set if the instance is in the dead-letter state
+	DeadLetterReason string `json:"dead_letter_reason,omitempty"`
+
+	// Memo given when the instance was created
+	Memo core.Memo `json:"memo,omitempty"`
 }
 
 type Event struct {
@@ -44,6 +50,9 @@ type WorkflowInstanceTree struct {
 type Backend interface {
 	backend.Backend
 
+	// Namespace returns the namespace the backend is scoped to
+	Namespace() string
+
 	GetWorkflowInstance(ctx context.Context, instance *core.WorkflowInstance) (*WorkflowInstanceRef, error)
 	GetWorkflowInstances(ctx context.Context, afterInstanceID, afterExecutionID string, count int) ([]*WorkflowInstanceRef, error)
 	GetWorkflowTree(ctx context.Context, instance *core.WorkflowInstance) (*WorkflowInstanceTree, error)
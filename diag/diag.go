@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io/fs"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -14,9 +15,35 @@ import (
 //go:embed app/build
 var embeddedFiles embed.FS
 
+type options struct {
+	backends map[string]Backend
+}
+
+type Option func(o *options)
+
+// WithNamespace makes the instances of the given backend's namespace available in the diagnostics web app and API.
+func WithNamespace(backend Backend) Option {
+	return func(o *options) {
+		o.backends[backend.Namespace()] = backend
+	}
+}
+
 // NewServeMux returns an *http.ServeMux that serves the diagnostics web app at / and the diagnostics API at /api which is
 // used by the web app.
-func NewServeMux(backend Backend) *http.ServeMux {
+//
+// API requests are served from the given backend unless a different namespace is selected with the namespace query
+// parameter. /api/namespaces lists all namespaces available to switch between.
+func NewServeMux(defaultBackend Backend, opts ...Option) *http.ServeMux {
+	o := &options{
+		backends: map[string]Backend{
+			defaultBackend.Namespace(): defaultBackend,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	mux := http.NewServeMux()
 
 	// API
@@ -29,6 +56,33 @@ func NewServeMux(backend Backend) *http.ServeMux {
 
 		relativeURL := strings.TrimPrefix(r.URL.Path, "/api/")
 
+		// /api/namespaces
+		if relativeURL == "namespaces" {
+			namespaces := make([]string, 0, len(o.backends))
+			for namespace := range o.backends {
+				namespaces = append(namespaces, namespace)
+			}
+			sort.Strings(namespaces)
+
+			w.Header().Add("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(namespaces); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			return
+		}
+
+		backend := defaultBackend
+		if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+			var ok bool
+			backend, ok = o.backends[namespace]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		}
+
 		// /api/
 		if relativeURL == "" {
 			// Index
@@ -52,10 +106,31 @@ func NewServeMux(backend Backend) *http.ServeMux {
 				afterExecutionID = segments[1]
 			}
 
-			instances, err := backend.GetWorkflowInstances(r.Context(), afterInstanceID, afterExecutionID, count)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
+			var instances []*WorkflowInstanceRef
+			if search := query["search"]; len(search) > 0 {
+				attributes, err := parseSearchAttributes(search)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				var afterInstance *core.WorkflowInstance
+				if afterInstanceID != "" {
+					afterInstance = core.NewWorkflowInstance(afterInstanceID, afterExecutionID)
+				}
+
+				instances, err = searchWorkflowInstances(r.Context(), backend, attributes, afterInstance, count)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			} else {
+				var err error
+				instances, err = backend.GetWorkflowInstances(r.Context(), afterInstanceID, afterExecutionID, count)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
 			}
 
 			w.Header().Add("Content-Type", "application/json")
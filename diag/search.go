@@ -0,0 +1,69 @@
+package diag
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+)
+
+// parseSearchAttributes parses search query parameters of the form name=value. Values are matched as bool, int, or
+// time attributes if they parse as such, otherwise as string attributes.
+func parseSearchAttributes(params []string) (core.SearchAttributes, error) {
+	attributes := make(core.SearchAttributes, len(params))
+
+	for _, param := range params {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok || name == "" {
+			return nil, errors.New("search attribute filter needs to be of the form name=value")
+		}
+
+		if value == "true" || value == "false" {
+			attributes[name] = core.NewBoolSearchAttribute(value == "true")
+		} else if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			attributes[name] = core.NewIntSearchAttribute(i)
+		} else if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			attributes[name] = core.NewTimeSearchAttribute(t)
+		} else {
+			attributes[name] = core.NewStringSearchAttribute(value)
+		}
+	}
+
+	return attributes, attributes.Validate()
+}
+
+// searchWorkflowInstances returns up to count instances matching the given search attributes, continuing after the
+// given instance if set
+func searchWorkflowInstances(ctx context.Context, b Backend, attributes core.SearchAttributes, after *core.WorkflowInstance, count int) ([]*WorkflowInstanceRef, error) {
+	query := &backend.ListWorkflowInstancesQuery{
+		SearchAttributes: attributes,
+		PageSize:         count,
+	}
+
+	if after != nil {
+		query.PageToken = backend.EncodePageToken(after)
+	}
+
+	result, err := b.ListWorkflowInstances(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]*WorkflowInstanceRef, 0, len(result.Instances))
+	for _, i := range result.Instances {
+		instances = append(instances, &WorkflowInstanceRef{
+			Instance:         i.Instance,
+			CreatedAt:        i.CreatedAt,
+			CompletedAt:      i.CompletedAt,
+			State:            i.State,
+			DeadLetterReason: i.DeadLetterReason,
+			Memo:             i.Memo,
+		})
+	}
+
+	return instances, nil
+}
@@ -3,12 +3,16 @@ module github.com/cschleiden/go-workflows
 go 1.19
 
 require (
+	github.com/go-errors/errors v1.4.2
 	github.com/go-sql-driver/mysql v1.6.0
 	github.com/golangci/golangci-lint v1.50.0
 	github.com/google/uuid v1.3.0
 	github.com/jellydator/ttlcache/v3 v3.0.0
 	github.com/jstemmer/go-junit-report/v2 v2.0.0-beta1
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.12
+	github.com/prometheus/client_golang v1.12.1
+	github.com/prometheus/common v0.32.1
 	github.com/redis/go-redis/v9 v9.0.2
 	github.com/stretchr/testify v1.8.3
 	go.opentelemetry.io/otel v1.16.0
@@ -17,6 +21,7 @@ require (
 	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.16.0
 	go.opentelemetry.io/otel/trace v1.16.0
 	golang.org/x/tools v0.6.0
+	google.golang.org/grpc v1.55.0
 )
 
 require (
@@ -26,7 +31,6 @@ require (
 	github.com/breml/bidichk v0.2.3 // indirect
 	github.com/curioswitch/go-reassign v0.2.0 // indirect
 	github.com/firefart/nonamedreturns v1.0.4 // indirect
-	github.com/go-errors/errors v1.4.2 // indirect
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.7.0 // indirect
 	github.com/kkHAIKE/contextcheck v1.1.2 // indirect
 	github.com/lufeee/execinquery v1.2.1 // indirect
@@ -47,7 +51,6 @@ require (
 	golang.org/x/exp v0.0.0-20220722155223-a9213eeb770e // indirect
 	golang.org/x/exp/typeparams v0.0.0-20220827204233-334a2380cb91 // indirect
 	google.golang.org/genproto v0.0.0-20230306155012-7f2fa6fef1f4 // indirect
-	google.golang.org/grpc v1.55.0 // indirect
 )
 
 require (
@@ -148,9 +151,7 @@ require (
 	github.com/phayes/checkstyle v0.0.0-20170904204023-bfd46e6a821d // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/polyfloyd/go-errorlint v1.0.5 // indirect
-	github.com/prometheus/client_golang v1.12.1 // indirect
 	github.com/prometheus/client_model v0.2.0 // indirect
-	github.com/prometheus/common v0.32.1 // indirect
 	github.com/prometheus/procfs v0.7.3 // indirect
 	github.com/quasilyte/go-ruleguard v0.3.18 // indirect
 	github.com/quasilyte/gogrep v0.0.0-20220828223005-86e4605de09f // indirect
@@ -189,7 +190,7 @@ require (
 	golang.org/x/sync v0.1.0 // indirect
 	golang.org/x/sys v0.8.0 // indirect
 	golang.org/x/text v0.8.0 // indirect
-	google.golang.org/protobuf v1.30.0 // indirect
+	google.golang.org/protobuf v1.30.0
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
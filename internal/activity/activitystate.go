@@ -2,7 +2,10 @@ package activity
 
 import (
 	"context"
+	"sync"
 
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/payload"
 	"github.com/cschleiden/go-workflows/log"
 	"github.com/cschleiden/go-workflows/workflow"
 )
@@ -11,17 +14,74 @@ type ActivityState struct {
 	ActivityID string
 	Instance   *workflow.Instance
 	Logger     log.Logger
+
+	// Converter is used to convert checkpoints. If nil, the default converter is used.
+	Converter converter.Converter
+
+	// Attempt of the activity, starting at 0 for the first attempt
+	Attempt int
+
+	// TaskToken identifies the activity task when completing it asynchronously
+	TaskToken string
+
+	// HeartbeatRecorder persists heartbeats of the activity, if set
+	HeartbeatRecorder func(ctx context.Context, details payload.Payload) error
+
+	mu         sync.Mutex
+	checkpoint payload.Payload
+	heartbeats chan struct{}
 }
 
 func NewActivityState(activityID string, instance *workflow.Instance, logger log.Logger) *ActivityState {
 	return &ActivityState{
-		activityID,
-		instance,
-		logger.With(
+		ActivityID: activityID,
+		Instance:   instance,
+		Logger: logger.With(
 			log.ActivityIDKey, activityID,
 			log.InstanceIDKey, instance.InstanceID,
 			log.ExecutionIDKey, instance.ExecutionID,
-		)}
+		),
+		heartbeats: make(chan struct{}, 1),
+	}
+}
+
+// SetCheckpoint records the latest checkpoint of the activity attempt
+func (as *ActivityState) SetCheckpoint(checkpoint payload.Payload) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.checkpoint = checkpoint
+}
+
+// Checkpoint returns the latest checkpoint recorded by this or a previous attempt of the activity
+func (as *ActivityState) Checkpoint() payload.Payload {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	return as.checkpoint
+}
+
+// Heartbeat records a heartbeat of the activity attempt. Non-nil details replace the checkpoint of the attempt.
+func (as *ActivityState) Heartbeat(ctx context.Context, details payload.Payload) error {
+	if details != nil {
+		as.SetCheckpoint(details)
+	}
+
+	select {
+	case as.heartbeats <- struct{}{}:
+	default:
+	}
+
+	if as.HeartbeatRecorder != nil {
+		return as.HeartbeatRecorder(ctx, as.Checkpoint())
+	}
+
+	return nil
+}
+
+// Heartbeats returns a channel that receives a value whenever the activity records a heartbeat
+func (as *ActivityState) Heartbeats() <-chan struct{} {
+	return as.heartbeats
 }
 
 type key int
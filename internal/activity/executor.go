@@ -5,13 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/cschleiden/go-workflows/internal/args"
+	"github.com/cschleiden/go-workflows/internal/command"
 	"github.com/cschleiden/go-workflows/internal/contextpropagation"
 	"github.com/cschleiden/go-workflows/internal/converter"
 	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/interceptor"
 	"github.com/cschleiden/go-workflows/internal/payload"
 	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/internal/tracing"
 	"github.com/cschleiden/go-workflows/internal/workflow"
 	"github.com/cschleiden/go-workflows/internal/workflowerrors"
 	"github.com/cschleiden/go-workflows/log"
@@ -25,6 +29,12 @@ type Executor struct {
 	converter   converter.Converter
 	propagators []contextpropagation.ContextPropagator
 	r           *workflow.Registry
+
+	heartbeatRecorder func(ctx context.Context, task *task.Activity, details payload.Payload) error
+
+	querier Querier
+
+	interceptors []interceptor.ActivityInterceptor
 }
 
 func NewExecutor(logger log.Logger, tracer trace.Tracer, converter converter.Converter, propagators []contextpropagation.ContextPropagator, r *workflow.Registry) *Executor {
@@ -37,22 +47,47 @@ func NewExecutor(logger log.Logger, tracer trace.Tracer, converter converter.Con
 	}
 }
 
-func (e *Executor) ExecuteActivity(ctx context.Context, task *task.Activity) (payload.Payload, error) {
+// SetHeartbeatRecorder sets the function used to persist heartbeats recorded by activities
+func (e *Executor) SetHeartbeatRecorder(recorder func(ctx context.Context, task *task.Activity, details payload.Payload) error) {
+	e.heartbeatRecorder = recorder
+}
+
+// Querier evaluates the query with the given name of a workflow instance
+type Querier func(ctx context.Context, instanceID string, name string, args []payload.Payload) (payload.Payload, error)
+
+// SetQuerier sets the function used to evaluate queries of workflows on their sub-workflows
+func (e *Executor) SetQuerier(querier Querier) {
+	e.querier = querier
+}
+
+// SetInterceptors sets the interceptors wrapping the execution of activities
+func (e *Executor) SetInterceptors(interceptors []interceptor.ActivityInterceptor) {
+	e.interceptors = interceptors
+}
+
+// ExecuteActivity executes the activity of the given task. If the activity fails, checkpoint is the latest checkpoint
+// recorded by the activity or one of its previous attempts.
+func (e *Executor) ExecuteActivity(ctx context.Context, task *task.Activity) (result payload.Payload, checkpoint payload.Payload, err error) {
 	a := task.Event.Attributes.(*history.ActivityScheduledAttributes)
 
+	if a.Name == command.QuerySubWorkflowActivityName {
+		result, err := e.querySubWorkflow(ctx, a.Inputs)
+		return result, nil, err
+	}
+
 	activity, err := e.r.GetActivity(a.Name)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	activityFn := reflect.ValueOf(activity)
 	if activityFn.Type().Kind() != reflect.Func {
-		return nil, workflowerrors.NewPermanentError(errors.New("activity not a function"))
+		return nil, nil, workflowerrors.NewPermanentError(errors.New("activity not a function"))
 	}
 
 	args, addContext, err := args.InputsToArgs(e.converter, activityFn, a.Inputs)
 	if err != nil {
-		return nil, workflowerrors.NewPermanentError(fmt.Errorf("converting activity inputs: %w", err))
+		return nil, nil, workflowerrors.NewPermanentError(fmt.Errorf("converting activity inputs: %w", err))
 	}
 
 	// Add activity state to context
@@ -60,58 +95,178 @@ func (e *Executor) ExecuteActivity(ctx context.Context, task *task.Activity) (pa
 		task.Event.ID,
 		task.WorkflowInstance,
 		e.logger)
+	as.Converter = e.converter
+	as.Attempt = a.Attempt
+	as.SetCheckpoint(a.Checkpoint)
+	if task.HeartbeatDetails != nil {
+		// Resume from the last heartbeat of a previous delivery of this attempt
+		as.SetCheckpoint(task.HeartbeatDetails)
+	}
+	if e.heartbeatRecorder != nil {
+		as.HeartbeatRecorder = func(ctx context.Context, details payload.Payload) error {
+			return e.heartbeatRecorder(ctx, task, details)
+		}
+	}
+	as.TaskToken, err = (&TaskToken{
+		Instance:        task.WorkflowInstance,
+		ActivityID:      task.ID,
+		ScheduleEventID: task.Event.ScheduleEventID,
+	}).Encode()
+	if err != nil {
+		return nil, nil, workflowerrors.NewPermanentError(err)
+	}
+
 	activityCtx := WithActivityState(ctx, as)
 
 	for _, propagator := range e.propagators {
 		activityCtx, err = propagator.Extract(activityCtx, a.Metadata)
 		if err != nil {
-			return nil, workflowerrors.NewPermanentError(fmt.Errorf("extracting context from propagator: %w", err))
+			return nil, nil, workflowerrors.NewPermanentError(fmt.Errorf("extracting context from propagator: %w", err))
 		}
 	}
 
-	activityCtx, span := e.tracer.Start(activityCtx, fmt.Sprintf("ActivityTaskExecution: %s", a.Name), trace.WithAttributes(
+	spanOpts := []trace.SpanStartOption{trace.WithAttributes(
 		attribute.String(log.ActivityNameKey, a.Name),
 		attribute.String(log.InstanceIDKey, task.WorkflowInstance.InstanceID),
 		attribute.String(log.ActivityIDKey, task.ID),
-	))
+		attribute.Int(log.AttemptKey, a.Attempt),
+	)}
+
+	// Link to the span of the workflow scheduling the activity, which might have been recorded by another worker
+	if sc := tracing.LinkFromMetadata(a.Metadata); sc.IsValid() {
+		spanOpts = append(spanOpts, trace.WithLinks(trace.Link{
+			SpanContext: sc,
+			Attributes:  []attribute.KeyValue{attribute.String(log.SpanLinkTypeKey, "scheduled")},
+		}))
+	}
+
+	activityCtx, span := e.tracer.Start(activityCtx, fmt.Sprintf("ActivityTaskExecution: %s", a.Name), spanOpts...)
 	defer span.End()
 
-	// Execute activity
-	if addContext {
-		args[0] = reflect.ValueOf(activityCtx)
+	// Let activities observe the timeout via their context
+	var deadlineCtx context.Context
+	if a.StartToCloseTimeout > 0 {
+		var cancel context.CancelFunc
+		deadlineCtx, cancel = context.WithTimeout(activityCtx, a.StartToCloseTimeout)
+		defer cancel()
+
+		activityCtx = deadlineCtx
+	}
+
+	// Stop activities that miss their heartbeats
+	if a.HeartbeatTimeout > 0 {
+		var cancel context.CancelFunc
+		activityCtx, cancel = context.WithCancel(activityCtx)
+		defer cancel()
 	}
 
 	done := make(chan struct{})
 	var rv []reflect.Value
 
+	// Execute activity
+	call := func(ctx context.Context) error {
+		if addContext {
+			args[0] = reflect.ValueOf(ctx)
+		}
+
+		rv = activityFn.Call(args)
+
+		if len(rv) > 0 {
+			if err, ok := rv[len(rv)-1].Interface().(error); ok {
+				return err
+			}
+		}
+
+		return nil
+	}
+
 	go func() {
 		// Recover any panic encountered during activity execution
 		defer func() {
 			if r := recover(); r != nil {
-				err = workflowerrors.NewPanicError(fmt.Sprintf("panic: %v", r))
-				rv = []reflect.Value{reflect.ValueOf(err)}
+				var perr error = workflowerrors.NewPanicError(fmt.Sprintf("panic: %v", r))
+				if e.r.GetActivityOptions(a.Name).PanicPolicy == workflow.PanicPolicyFailWorkflow {
+					// Don't retry the activity, the panic is returned to the workflow right away
+					perr = workflowerrors.NewPermanentError(perr)
+				}
+
+				rv = []reflect.Value{reflect.ValueOf(perr)}
 			}
 
 			close(done)
 		}()
 
-		rv = activityFn.Call(args)
+		if len(e.interceptors) == 0 {
+			call(activityCtx)
+			return
+		}
+
+		ierr := interceptor.ExecuteActivity(activityCtx, e.interceptors, &interceptor.ActivityInfo{
+			Instance:   task.WorkflowInstance,
+			ActivityID: task.ID,
+			Name:       a.Name,
+			Attempt:    a.Attempt,
+		}, call)
+		rv = interceptedResult(activityFn.Type(), rv, ierr)
 	}()
 
-	<-done
+	var timeout <-chan time.Time
+	if a.StartToCloseTimeout > 0 {
+		timer := time.NewTimer(a.StartToCloseTimeout)
+		defer timer.Stop()
 
-	if len(rv) < 1 || len(rv) > 2 {
-		return nil, workflowerrors.NewPermanentError(errors.New("activity has to return either (error) or (<result>, error)"))
+		timeout = timer.C
+	}
+
+	var heartbeatTimer *time.Timer
+	var heartbeatTimeout <-chan time.Time
+	if a.HeartbeatTimeout > 0 {
+		heartbeatTimer = time.NewTimer(a.HeartbeatTimeout)
+		defer heartbeatTimer.Stop()
+
+		heartbeatTimeout = heartbeatTimer.C
 	}
 
-	var result payload.Payload
+wait:
+	for {
+		select {
+		case <-done:
+			break wait
+
+		case <-timeout:
+			// Abandon the activity execution, its result will be ignored
+			return nil, as.Checkpoint(), workflowerrors.NewTimeoutError(workflowerrors.TimeoutKindStartToClose)
+
+		case <-as.Heartbeats():
+			if heartbeatTimer != nil {
+				if !heartbeatTimer.Stop() {
+					<-heartbeatTimer.C
+				}
+
+				heartbeatTimer.Reset(a.HeartbeatTimeout)
+			}
+
+		case <-heartbeatTimeout:
+			// Abandon the activity execution, its context is canceled when returning
+			return nil, as.Checkpoint(), workflowerrors.NewTimeoutError(workflowerrors.TimeoutKindHeartbeat)
+		}
+	}
+
+	if deadlineCtx != nil && deadlineCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		// Activity stopped because its deadline passed, report this as a timeout independent of its result
+		return nil, as.Checkpoint(), workflowerrors.NewTimeoutError(workflowerrors.TimeoutKindStartToClose)
+	}
+
+	if len(rv) < 1 || len(rv) > 2 {
+		return nil, nil, workflowerrors.NewPermanentError(errors.New("activity has to return either (error) or (<result>, error)"))
+	}
 
 	// Convert activity result to payload. We always expect at least an error
 	if len(rv) > 1 {
 		var err error
 		result, err = e.converter.To(rv[0].Interface())
 		if err != nil {
-			return nil, workflowerrors.NewPermanentError(fmt.Errorf("converting activity result: %w", err))
+			return nil, nil, workflowerrors.NewPermanentError(fmt.Errorf("converting activity result: %w", err))
 		}
 	}
 
@@ -119,13 +274,61 @@ func (e *Executor) ExecuteActivity(ctx context.Context, task *task.Activity) (pa
 	errResult := rv[len(rv)-1]
 	if errResult.IsNil() {
 		// No error from activity execution
-		return result, nil
+		return result, nil, nil
 	}
 
 	err, ok := errResult.Interface().(error)
 	if !ok {
-		return nil, workflowerrors.NewPermanentError(fmt.Errorf("activity error result does not satisfy error interface (%T): %v", errResult, errResult))
+		return nil, as.Checkpoint(), workflowerrors.NewPermanentError(fmt.Errorf("activity error result does not satisfy error interface (%T): %v", errResult, errResult))
+	}
+
+	// The activity will be completed asynchronously
+	if errors.Is(err, ErrResultPending) {
+		return nil, nil, ErrResultPending
+	}
+
+	return result, as.Checkpoint(), workflowerrors.FromError(err)
+}
+
+// interceptedResult returns the return values of an activity whose execution was wrapped by interceptors. The error
+// returned by the interceptors replaces the error of the activity, the result is zero if the activity wasn't executed.
+func interceptedResult(fnType reflect.Type, rv []reflect.Value, err error) []reflect.Value {
+	if fnType.NumOut() < 1 || fnType.NumOut() > 2 {
+		return rv
+	}
+
+	result := make([]reflect.Value, fnType.NumOut())
+	for i := range result {
+		if rv != nil {
+			result[i] = rv[i]
+		} else {
+			result[i] = reflect.Zero(fnType.Out(i))
+		}
+	}
+
+	// Keep the error an interface value, so that it can be checked for nil
+	result[len(result)-1] = reflect.ValueOf(&err).Elem()
+
+	return result
+}
+
+func (e *Executor) querySubWorkflow(ctx context.Context, inputs []payload.Payload) (payload.Payload, error) {
+	if e.querier == nil {
+		return nil, workflowerrors.NewPermanentError(errors.New("backend does not support queries"))
+	}
+
+	if len(inputs) < 2 {
+		return nil, workflowerrors.NewPermanentError(errors.New("missing sub-workflow instance or query name"))
+	}
+
+	var instanceID, name string
+	if err := e.converter.From(inputs[0], &instanceID); err != nil {
+		return nil, workflowerrors.NewPermanentError(fmt.Errorf("converting sub-workflow instance: %w", err))
+	}
+
+	if err := e.converter.From(inputs[1], &name); err != nil {
+		return nil, workflowerrors.NewPermanentError(fmt.Errorf("converting query name: %w", err))
 	}
 
-	return result, workflowerrors.FromError(err)
+	return e.querier(ctx, instanceID, name, inputs[2:])
 }
@@ -7,17 +7,22 @@ import (
 	"time"
 
 	"github.com/cschleiden/go-workflows/internal/args"
+	"github.com/cschleiden/go-workflows/internal/contextpropagation"
 	"github.com/cschleiden/go-workflows/internal/converter"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/fn"
 	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/interceptor"
 	"github.com/cschleiden/go-workflows/internal/logger"
 	"github.com/cschleiden/go-workflows/internal/payload"
 	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/internal/tracing"
 	"github.com/cschleiden/go-workflows/internal/workflow"
 	"github.com/cschleiden/go-workflows/internal/workflowerrors"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -102,6 +107,55 @@ func TestExecutor_ExecuteActivity(t *testing.T) {
 				require.ErrorAs(t, err, &expectedErr)
 				e := err.(*workflowerrors.Error)
 				require.Equal(t, e.Type, "PanicError")
+				require.Contains(t, e.Stack(), "executor_test.go")
+			},
+		},
+		{
+			name: "start to close timeout",
+			setup: func(t *testing.T, r *workflow.Registry) *history.ActivityScheduledAttributes {
+				a := func(context.Context) error {
+					time.Sleep(time.Second)
+					return nil
+				}
+				require.NoError(t, r.RegisterActivity(a))
+
+				return &history.ActivityScheduledAttributes{
+					Name:                fn.Name(a),
+					StartToCloseTimeout: time.Millisecond * 10,
+				}
+			},
+			result: func(t *testing.T, result payload.Payload, err error) {
+				require.Nil(t, result)
+
+				var expectedErr *workflowerrors.TimeoutError
+				require.ErrorAs(t, err, &expectedErr)
+				require.Equal(t, workflowerrors.TimeoutKindStartToClose, expectedErr.Kind)
+			},
+		},
+		{
+			name: "start to close timeout sets context deadline",
+			setup: func(t *testing.T, r *workflow.Registry) *history.ActivityScheduledAttributes {
+				a := func(ctx context.Context) error {
+					if _, ok := ctx.Deadline(); !ok {
+						return errors.New("no deadline set")
+					}
+
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				require.NoError(t, r.RegisterActivity(a))
+
+				return &history.ActivityScheduledAttributes{
+					Name:                fn.Name(a),
+					StartToCloseTimeout: time.Millisecond * 10,
+				}
+			},
+			result: func(t *testing.T, result payload.Payload, err error) {
+				require.Nil(t, result)
+
+				var expectedErr *workflowerrors.TimeoutError
+				require.ErrorAs(t, err, &expectedErr)
+				require.Equal(t, workflowerrors.TimeoutKindStartToClose, expectedErr.Kind)
 			},
 		},
 	}
@@ -116,7 +170,7 @@ func TestExecutor_ExecuteActivity(t *testing.T) {
 				converter: converter.DefaultConverter,
 				tracer:    trace.NewNoopTracerProvider().Tracer(""),
 			}
-			got, err := e.ExecuteActivity(context.Background(), &task.Activity{
+			got, _, err := e.ExecuteActivity(context.Background(), &task.Activity{
 				ID:               uuid.NewString(),
 				WorkflowInstance: core.NewWorkflowInstance("instanceID", "executionID"),
 				Event:            history.NewHistoryEvent(1, time.Now(), history.EventType_ActivityScheduled, attr),
@@ -125,3 +179,127 @@ func TestExecutor_ExecuteActivity(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutor_ExecuteActivity_Interceptors(t *testing.T) {
+	type ctxKey struct{}
+
+	newExecutor := func(t *testing.T, a interface{}, interceptors ...interceptor.ActivityInterceptor) (*Executor, *task.Activity) {
+		r := workflow.NewRegistry()
+		require.NoError(t, r.RegisterActivity(a))
+
+		e := &Executor{
+			logger:       logger.NewDefaultLogger(),
+			r:            r,
+			converter:    converter.DefaultConverter,
+			tracer:       trace.NewNoopTracerProvider().Tracer(""),
+			interceptors: interceptors,
+		}
+
+		return e, &task.Activity{
+			ID:               uuid.NewString(),
+			WorkflowInstance: core.NewWorkflowInstance("instanceID", "executionID"),
+			Event: history.NewHistoryEvent(1, time.Now(), history.EventType_ActivityScheduled, &history.ActivityScheduledAttributes{
+				Name: fn.Name(a),
+			}),
+		}
+	}
+
+	t.Run("wraps activity in order", func(t *testing.T) {
+		var calls []string
+		record := func(name string) interceptor.ActivityInterceptor {
+			return interceptor.ActivityInterceptorFunc(func(ctx context.Context, info *interceptor.ActivityInfo, next func(ctx context.Context) error) error {
+				calls = append(calls, name+":"+info.Name)
+				return next(context.WithValue(ctx, ctxKey{}, name))
+			})
+		}
+
+		a := func(ctx context.Context) (string, error) {
+			return ctx.Value(ctxKey{}).(string), nil
+		}
+
+		e, at := newExecutor(t, a, record("outer"), record("inner"))
+		result, _, err := e.ExecuteActivity(context.Background(), at)
+		require.NoError(t, err)
+
+		var r string
+		require.NoError(t, converter.DefaultConverter.From(result, &r))
+		require.Equal(t, "inner", r)
+		require.Equal(t, []string{"outer:" + fn.Name(a), "inner:" + fn.Name(a)}, calls)
+	})
+
+	t.Run("replaces activity error", func(t *testing.T) {
+		a := func(ctx context.Context) (int, error) {
+			return 0, errors.New("activity error")
+		}
+
+		e, at := newExecutor(t, a, interceptor.ActivityInterceptorFunc(func(ctx context.Context, info *interceptor.ActivityInfo, next func(ctx context.Context) error) error {
+			require.EqualError(t, next(ctx), "activity error")
+			return nil
+		}))
+
+		result, _, err := e.ExecuteActivity(context.Background(), at)
+		require.NoError(t, err)
+
+		var r int
+		require.NoError(t, converter.DefaultConverter.From(result, &r))
+		require.Equal(t, 0, r)
+	})
+
+	t.Run("rejects activity", func(t *testing.T) {
+		executed := false
+		a := func(ctx context.Context) (int, error) {
+			executed = true
+			return 42, nil
+		}
+
+		e, at := newExecutor(t, a, interceptor.ActivityInterceptorFunc(func(ctx context.Context, info *interceptor.ActivityInfo, next func(ctx context.Context) error) error {
+			return errors.New("not allowed")
+		}))
+
+		_, _, err := e.ExecuteActivity(context.Background(), at)
+		require.ErrorContains(t, err, "not allowed")
+		require.False(t, executed)
+	})
+}
+
+func TestExecutor_ExecuteActivity_Tracing(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+
+	a := func(ctx context.Context) error {
+		return nil
+	}
+
+	r := workflow.NewRegistry()
+	require.NoError(t, r.RegisterActivity(a))
+
+	e := &Executor{
+		logger:      logger.NewDefaultLogger(),
+		r:           r,
+		converter:   converter.DefaultConverter,
+		tracer:      tracer,
+		propagators: []contextpropagation.ContextPropagator{&tracing.TracingContextPropagator{}},
+	}
+
+	// Spans of the workflow execution and of the workflow scheduling the activity
+	_, workflowSpan := tracer.Start(context.Background(), "Workflow")
+	_, scheduleSpan := tracer.Start(context.Background(), "ExecuteActivity")
+	metadata := tracing.MetadataFromSpanContext(workflowSpan.SpanContext())
+	tracing.InjectLink(scheduleSpan.SpanContext(), metadata)
+
+	_, _, err := e.ExecuteActivity(context.Background(), &task.Activity{
+		ID:               uuid.NewString(),
+		WorkflowInstance: core.NewWorkflowInstance("instanceID", "executionID"),
+		Event: history.NewHistoryEvent(1, time.Now(), history.EventType_ActivityScheduled, &history.ActivityScheduledAttributes{
+			Name:     fn.Name(a),
+			Metadata: metadata,
+		}),
+	})
+	require.NoError(t, err)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, workflowSpan.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	require.Len(t, spans[0].Links(), 1)
+	require.Equal(t, scheduleSpan.SpanContext().SpanID(), spans[0].Links()[0].SpanContext.SpanID())
+}
@@ -0,0 +1,49 @@
+package activity
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+)
+
+// ErrResultPending is returned by activities that are completed asynchronously
+var ErrResultPending = errors.New("activity result pending")
+
+// TaskToken identifies an activity task to complete asynchronously
+type TaskToken struct {
+	Instance        *core.WorkflowInstance `json:"instance"`
+	ActivityID      string                 `json:"activity_id"`
+	ScheduleEventID int64                  `json:"schedule_event_id"`
+}
+
+// Encode returns the opaque string representation of the token
+func (t *TaskToken) Encode() (string, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return "", fmt.Errorf("marshaling task token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeTaskToken parses a token returned by Encode
+func DecodeTaskToken(token string) (*TaskToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("decoding task token: %w", err)
+	}
+
+	t := &TaskToken{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, fmt.Errorf("unmarshaling task token: %w", err)
+	}
+
+	if t.Instance == nil || t.ActivityID == "" {
+		return nil, errors.New("invalid task token")
+	}
+
+	return t, nil
+}
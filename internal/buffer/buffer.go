@@ -0,0 +1,219 @@
+// Package buffer implements the in-memory buffer shared by the decorators streaming committed changes to external
+// systems in the background, like the replication backend and the history event feed. Items are numbered in the order
+// they are added and published in batches, failed batches are retried until they succeed.
+package buffer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cschleiden/go-workflows/log"
+)
+
+// ErrFull is returned when no space could be reserved in the buffer within the timeout
+var ErrFull = errors.New("buffer is full")
+
+type Options struct {
+	// Size is the maximum number of items added but not yet published
+	Size int
+
+	// BatchSize is the maximum number of items published at once
+	BatchSize int
+
+	// Timeout is the maximum time to wait for space in a full buffer
+	Timeout time.Duration
+
+	// RetryInterval is the time to wait before publishing a batch again after a failure
+	RetryInterval time.Duration
+
+	Logger log.Logger
+
+	// Message is logged when publishing a batch fails
+	Message string
+}
+
+type entry[T any] struct {
+	sequence uint64
+	item     T
+}
+
+// Buffer publishes items in the background, in the order they were added
+type Buffer[T any] struct {
+	publish func(ctx context.Context, items []T) error
+	options Options
+
+	entries chan entry[T]
+
+	// slots holds a token for every item reserved or added but not yet published, which bounds them to the size
+	slots chan struct{}
+
+	// mu serializes adding items, so that they are queued in sequence order
+	mu       sync.Mutex
+	sequence atomic.Uint64
+
+	published atomic.Uint64
+
+	wg sync.WaitGroup
+}
+
+// New returns a buffer passing batches of items to publish once started
+func New[T any](publish func(ctx context.Context, items []T) error, options Options) *Buffer[T] {
+	if options.Size < 1 {
+		options.Size = 1
+	}
+
+	if options.BatchSize < 1 {
+		options.BatchSize = 1
+	}
+
+	return &Buffer[T]{
+		publish: publish,
+		options: options,
+		entries: make(chan entry[T], options.Size),
+		slots:   make(chan struct{}, options.Size),
+	}
+}
+
+// Start starts publishing items in the background until the context is canceled
+func (b *Buffer[T]) Start(ctx context.Context) {
+	b.wg.Add(1)
+	go b.run(ctx)
+}
+
+// WaitForCompletion waits until publishing has stopped after the context passed to Start was canceled
+func (b *Buffer[T]) WaitForCompletion() {
+	b.wg.Wait()
+}
+
+// Lag returns the number of items added but not yet published
+func (b *Buffer[T]) Lag() uint64 {
+	return b.sequence.Load() - b.published.Load()
+}
+
+// Flush waits until all items added so far have been published
+func (b *Buffer[T]) Flush(ctx context.Context) error {
+	target := b.sequence.Load()
+
+	t := time.NewTicker(time.Millisecond * 10)
+	defer t.Stop()
+
+	for b.published.Load() < target {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	return nil
+}
+
+// Reserve reserves space for n items, waiting up to the timeout for items to be published. Reserve space before
+// committing the changes the items describe, so that adding them never waits.
+func (b *Buffer[T]) Reserve(ctx context.Context, n int) error {
+	if n > b.options.Size {
+		return ErrFull
+	}
+
+	var t *time.Timer
+
+	for i := 0; i < n; i++ {
+		select {
+		case b.slots <- struct{}{}:
+			continue
+		default:
+		}
+
+		if t == nil {
+			t = time.NewTimer(b.options.Timeout)
+			defer t.Stop()
+		}
+
+		select {
+		case b.slots <- struct{}{}:
+		case <-t.C:
+			b.Release(i)
+			return ErrFull
+		case <-ctx.Done():
+			b.Release(i)
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Release releases space for n reserved items that won't be added
+func (b *Buffer[T]) Release(n int) {
+	for i := 0; i < n; i++ {
+		<-b.slots
+	}
+}
+
+// Add adds n items, for which space has been reserved. build is called with the sequence number of every item.
+func (b *Buffer[T]) Add(n int, build func(i int, sequence uint64) T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		sequence := b.sequence.Add(1)
+
+		// Doesn't block, the reserved space guarantees room in the channel
+		b.entries <- entry[T]{sequence: sequence, item: build(i, sequence)}
+	}
+}
+
+func (b *Buffer[T]) run(ctx context.Context) {
+	defer b.wg.Done()
+
+	for {
+		var batch []entry[T]
+
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-b.entries:
+			batch = append(batch, e)
+		}
+
+	collect:
+		for len(batch) < b.options.BatchSize {
+			select {
+			case e := <-b.entries:
+				batch = append(batch, e)
+			default:
+				break collect
+			}
+		}
+
+		items := make([]T, len(batch))
+		for i, e := range batch {
+			items[i] = e.item
+		}
+
+		for {
+			err := b.publish(ctx, items)
+			if err == nil {
+				break
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			b.options.Logger.Error(b.options.Message, log.ErrorKey, err, "items", len(items))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(b.options.RetryInterval):
+			}
+		}
+
+		b.published.Store(batch[len(batch)-1].sequence)
+		b.Release(len(batch))
+	}
+}
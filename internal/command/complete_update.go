@@ -0,0 +1,56 @@
+package command
+
+import (
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+)
+
+// CompleteUpdateCommand records the result of an update handled by the workflow instance. Like version markers, it
+// doesn't take up a schedule event id.
+type CompleteUpdateCommand struct {
+	command
+
+	UpdateID string
+	Result   payload.Payload
+	Error    *workflowerrors.Error
+}
+
+var _ Command = (*CompleteUpdateCommand)(nil)
+
+func NewCompleteUpdateCommand(updateID string, result payload.Payload, err *workflowerrors.Error) *CompleteUpdateCommand {
+	return &CompleteUpdateCommand{
+		command: command{
+			name:  "CompleteUpdate",
+			state: CommandState_Pending,
+		},
+		UpdateID: updateID,
+		Result:   result,
+		Error:    err,
+	}
+}
+
+func (c *CompleteUpdateCommand) Execute(clock clock.Clock) *CommandResult {
+	switch c.state {
+	case CommandState_Pending:
+		// Update results are only added to the history, transition to Done
+		c.state = CommandState_Done
+
+		return &CommandResult{
+			Events: []*history.Event{
+				history.NewPendingEvent(
+					clock.Now(),
+					history.EventType_WorkflowUpdateCompleted,
+					&history.WorkflowUpdateCompletedAttributes{
+						UpdateID: c.UpdateID,
+						Result:   c.Result,
+						Error:    c.Error,
+					},
+				),
+			},
+		}
+	}
+
+	return nil
+}
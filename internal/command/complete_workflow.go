@@ -14,6 +14,9 @@ type CompleteWorkflowCommand struct {
 	Instance *core.WorkflowInstance
 	Result   payload.Payload
 	Error    *workflowerrors.Error
+
+	// Metadata is sent to the parent of a sub-workflow instance, see SubWorkflowCompletedAttributes.Metadata
+	Metadata *core.WorkflowMetadata
 }
 
 var _ Command = (*CompleteWorkflowCommand)(nil)
@@ -71,7 +74,8 @@ func (c *CompleteWorkflowCommand) Execute(clock clock.Clock) *CommandResult {
 					clock.Now(),
 					history.EventType_SubWorkflowFailed,
 					&history.SubWorkflowFailedAttributes{
-						Error: c.Error,
+						Error:    c.Error,
+						Metadata: c.Metadata,
 					},
 					// Ensure the message gets sent back to the parent workflow with the right schedule event ID
 					history.ScheduleEventID(c.Instance.ParentEventID),
@@ -81,7 +85,8 @@ func (c *CompleteWorkflowCommand) Execute(clock clock.Clock) *CommandResult {
 					clock.Now(),
 					history.EventType_SubWorkflowCompleted,
 					&history.SubWorkflowCompletedAttributes{
-						Result: c.Result,
+						Result:   c.Result,
+						Metadata: c.Metadata,
 					},
 					// Ensure the message gets sent back to the parent workflow with the right schedule event ID
 					history.ScheduleEventID(c.Instance.ParentEventID),
@@ -1,26 +1,42 @@
 package command
 
 import (
+	"time"
+
 	"github.com/benbjohnson/clock"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
 	"github.com/cschleiden/go-workflows/internal/payload"
-	"github.com/google/uuid"
 )
 
 type ContinueAsNewCommand struct {
 	command
 
 	Instance *core.WorkflowInstance
+
+	// ContinuedExecutionID is the execution ID of the new execution
+	ContinuedExecutionID string
+
 	Name     string
 	Metadata *core.WorkflowMetadata
 	Inputs   []payload.Payload
 	Result   payload.Payload
+	Priority core.Priority
+	Queue    core.Queue
+
+	// Signals received but not consumed by the current execution, delivered to the new execution
+	Signals []*history.SignalReceivedAttributes
+
+	// ExecutionDeadline of the current execution, inherited by the new execution
+	ExecutionDeadline *time.Time
+
+	// Memo of the current execution, inherited by the new execution
+	Memo core.Memo
 }
 
 var _ Command = (*ContinueAsNewCommand)(nil)
 
-func NewContinueAsNewCommand(id int64, instance *core.WorkflowInstance, result payload.Payload, name string, metadata *core.WorkflowMetadata, inputs []payload.Payload) *ContinueAsNewCommand {
+func NewContinueAsNewCommand(id int64, instance *core.WorkflowInstance, continuedExecutionID string, result payload.Payload, name string, metadata *core.WorkflowMetadata, inputs []payload.Payload, priority core.Priority, queue core.Queue, signals []*history.SignalReceivedAttributes, executionDeadline *time.Time, memo core.Memo) *ContinueAsNewCommand {
 	return &ContinueAsNewCommand{
 		command: command{
 			id:    id,
@@ -28,17 +44,26 @@ func NewContinueAsNewCommand(id int64, instance *core.WorkflowInstance, result p
 			state: CommandState_Pending,
 		},
 		Instance: instance,
+
+		ContinuedExecutionID: continuedExecutionID,
+
 		Name:     name,
 		Metadata: metadata,
 		Inputs:   inputs,
 		Result:   result,
+		Priority: priority,
+		Queue:    queue,
+		Signals:  signals,
+
+		ExecutionDeadline: executionDeadline,
+		Memo:              memo,
 	}
 }
 
 func (c *ContinueAsNewCommand) Execute(clock clock.Clock) *CommandResult {
 	switch c.state {
 	case CommandState_Pending:
-		continuedExecutionID := uuid.NewString()
+		continuedExecutionID := c.ContinuedExecutionID
 
 		var continuedInstance *core.WorkflowInstance
 		if c.Instance.SubWorkflow() {
@@ -50,6 +75,33 @@ func (c *ContinueAsNewCommand) Execute(clock clock.Clock) *CommandResult {
 			continuedInstance = core.NewWorkflowInstance(c.Instance.InstanceID, continuedExecutionID)
 		}
 
+		workflowEvents := []history.WorkflowEvent{
+			// Schedule a new workflow execution
+			{
+				WorkflowInstance: continuedInstance,
+				HistoryEvent: history.NewPendingEvent(
+					clock.Now(),
+					history.EventType_WorkflowExecutionStarted,
+					&history.ExecutionStartedAttributes{
+						Name:              c.Name,
+						Metadata:          c.Metadata,
+						Inputs:            c.Inputs,
+						Priority:          c.Priority,
+						Queue:             c.Queue,
+						ExecutionDeadline: c.ExecutionDeadline,
+						Memo:              c.Memo,
+					},
+				),
+			},
+		}
+
+		for _, s := range c.Signals {
+			workflowEvents = append(workflowEvents, history.WorkflowEvent{
+				WorkflowInstance: continuedInstance,
+				HistoryEvent:     history.NewPendingEvent(clock.Now(), history.EventType_SignalReceived, s),
+			})
+		}
+
 		c.state = CommandState_Committed
 		return &CommandResult{
 			State: core.WorkflowInstanceStateContinuedAsNew,
@@ -64,21 +116,7 @@ func (c *ContinueAsNewCommand) Execute(clock clock.Clock) *CommandResult {
 					},
 				),
 			},
-			WorkflowEvents: []history.WorkflowEvent{
-				// Schedule a new workflow execution
-				{
-					WorkflowInstance: continuedInstance,
-					HistoryEvent: history.NewPendingEvent(
-						clock.Now(),
-						history.EventType_WorkflowExecutionStarted,
-						&history.ExecutionStartedAttributes{
-							Name:     c.Name,
-							Metadata: c.Metadata,
-							Inputs:   c.Inputs,
-						},
-					),
-				},
-			},
+			WorkflowEvents: workflowEvents,
 		}
 	}
 
@@ -1,23 +1,54 @@
 package command
 
 import (
+	"time"
+
 	"github.com/benbjohnson/clock"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
 	"github.com/cschleiden/go-workflows/internal/payload"
 )
 
+// QuerySubWorkflowActivityName is the name of the activity scheduled by workflows querying a sub-workflow. Its inputs
+// are the instance ID of the sub-workflow and the name of the query, followed by the arguments of the query.
+const QuerySubWorkflowActivityName = "go-workflows:QuerySubWorkflow"
+
+// ActivityOptions are the options of a scheduled activity which are persisted in its ActivityScheduled event
+type ActivityOptions struct {
+	ScheduleToStartTimeout time.Duration
+	StartToCloseTimeout    time.Duration
+	HeartbeatTimeout       time.Duration
+
+	AtMostOnce bool
+
+	// Queue the activity task is routed to
+	Queue core.Queue
+
+	// Checkpoint is made available to the activity, it's recorded by a previous attempt
+	Checkpoint payload.Payload
+
+	// Attempt of the activity, starting at 0
+	Attempt int
+}
+
 type ScheduleActivityCommand struct {
 	command
 
 	Name     string
 	Inputs   []payload.Payload
 	Metadata *core.WorkflowMetadata
+
+	ActivityOptions
+
+	// FailedCheckpoint is the checkpoint recorded by the attempt, set once the attempt has failed
+	FailedCheckpoint payload.Payload
 }
 
 var _ Command = (*ScheduleActivityCommand)(nil)
 
-func NewScheduleActivityCommand(id int64, name string, inputs []payload.Payload, metadata *core.WorkflowMetadata) *ScheduleActivityCommand {
+func NewScheduleActivityCommand(
+	id int64, name string, inputs []payload.Payload, metadata *core.WorkflowMetadata, options ActivityOptions,
+) *ScheduleActivityCommand {
 	return &ScheduleActivityCommand{
 		command: command{
 			id:    id,
@@ -27,6 +58,8 @@ func NewScheduleActivityCommand(id int64, name string, inputs []payload.Payload,
 		Name:     name,
 		Inputs:   inputs,
 		Metadata: metadata,
+
+		ActivityOptions: options,
 	}
 }
 
@@ -42,6 +75,15 @@ func (c *ScheduleActivityCommand) Execute(clock clock.Clock) *CommandResult {
 				Name:     c.Name,
 				Inputs:   c.Inputs,
 				Metadata: c.Metadata,
+				Queue:    c.Queue,
+
+				ScheduleToStartTimeout: c.ScheduleToStartTimeout,
+				StartToCloseTimeout:    c.StartToCloseTimeout,
+				HeartbeatTimeout:       c.HeartbeatTimeout,
+
+				AtMostOnce: c.AtMostOnce,
+				Checkpoint: c.Checkpoint,
+				Attempt:    c.Attempt,
 			},
 			history.ScheduleEventID(c.id))
 
@@ -1,11 +1,12 @@
 package command
 
 import (
+	"time"
+
 	"github.com/benbjohnson/clock"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
 	"github.com/cschleiden/go-workflows/internal/payload"
-	"github.com/google/uuid"
 )
 
 type ScheduleSubWorkflowCommand struct {
@@ -14,18 +15,27 @@ type ScheduleSubWorkflowCommand struct {
 	Instance *core.WorkflowInstance
 	Metadata *core.WorkflowMetadata
 
-	Name   string
-	Inputs []payload.Payload
+	Name     string
+	Inputs   []payload.Payload
+	Priority core.Priority
+	Queue    core.Queue
+
+	ParentClosePolicy core.ParentClosePolicy
+
+	// ExecutionTimeout of the sub-workflow instance, measured from scheduling it
+	ExecutionTimeout time.Duration
+
+	scheduled bool
+	abandoned bool
 }
 
 var _ CancelableCommand = (*ScheduleSubWorkflowCommand)(nil)
 
 func NewScheduleSubWorkflowCommand(
-	id int64, parentInstance *core.WorkflowInstance, subWorkflowInstanceID, name string, inputs []payload.Payload, metadata *core.WorkflowMetadata,
+	id int64, parentInstance *core.WorkflowInstance, subWorkflowInstanceID, subWorkflowExecutionID, name string, inputs []payload.Payload,
+	metadata *core.WorkflowMetadata, priority core.Priority, queue core.Queue, parentClosePolicy core.ParentClosePolicy,
+	executionTimeout time.Duration,
 ) *ScheduleSubWorkflowCommand {
-	if subWorkflowInstanceID == "" {
-		subWorkflowInstanceID = uuid.New().String()
-	}
 
 	return &ScheduleSubWorkflowCommand{
 		cancelableCommand: cancelableCommand{
@@ -36,18 +46,52 @@ func NewScheduleSubWorkflowCommand(
 			},
 		},
 
-		Instance: core.NewSubWorkflowInstance(subWorkflowInstanceID, uuid.NewString(), parentInstance, id),
+		Instance: core.NewSubWorkflowInstance(subWorkflowInstanceID, subWorkflowExecutionID, parentInstance, id),
 		Metadata: metadata,
 
-		Name:   name,
-		Inputs: inputs,
+		Name:     name,
+		Inputs:   inputs,
+		Priority: priority,
+		Queue:    queue,
+
+		ParentClosePolicy: parentClosePolicy,
+		ExecutionTimeout:  executionTimeout,
 	}
 }
 
+func (c *ScheduleSubWorkflowCommand) Commit() {
+	c.cancelableCommand.Commit()
+	c.scheduled = true
+}
+
+// Running returns true if the sub-workflow instance has been scheduled and its result hasn't been received yet
+func (c *ScheduleSubWorkflowCommand) Running() bool {
+	return c.scheduled && c.state != CommandState_Done
+}
+
+// Abandon detaches a scheduled sub-workflow instance from the workflow. The sub-workflow keeps running, its result
+// is discarded when it arrives.
+func (c *ScheduleSubWorkflowCommand) Abandon() {
+	c.abandoned = true
+}
+
+// Abandoned returns true if the sub-workflow instance has been abandoned
+func (c *ScheduleSubWorkflowCommand) Abandoned() bool {
+	return c.abandoned
+}
+
 func (c *ScheduleSubWorkflowCommand) Execute(clock clock.Clock) *CommandResult {
 	switch c.state {
 	case CommandState_Pending:
 		c.state = CommandState_Committed
+		c.scheduled = true
+
+		var executionDeadline *time.Time
+		if c.ExecutionTimeout > 0 {
+			deadline := clock.Now().Add(c.ExecutionTimeout)
+			executionDeadline = &deadline
+		}
+
 		return &CommandResult{
 			// Record scheduled sub-workflow for source workflow instance
 			Events: []*history.Event{
@@ -59,6 +103,7 @@ func (c *ScheduleSubWorkflowCommand) Execute(clock clock.Clock) *CommandResult {
 						Metadata:            c.Metadata,
 						Name:                c.Name,
 						Inputs:              c.Inputs,
+						ParentClosePolicy:   c.ParentClosePolicy,
 					},
 					history.ScheduleEventID(c.id),
 				),
@@ -74,6 +119,10 @@ func (c *ScheduleSubWorkflowCommand) Execute(clock clock.Clock) *CommandResult {
 							Name:     c.Name,
 							Inputs:   c.Inputs,
 							Metadata: c.Metadata,
+							Priority: c.Priority,
+							Queue:    c.Queue,
+
+							ExecutionDeadline: executionDeadline,
 						},
 					),
 				},
@@ -96,7 +96,7 @@ func TestScheduleSubWorkflowCommand_StateTransitions(t *testing.T) {
 
 			parentInstance := core.NewWorkflowInstance(uuid.NewString(), "")
 
-			cmd := NewScheduleSubWorkflowCommand(1, parentInstance, uuid.NewString(), "SubWorkflow", []payload.Payload{}, &core.WorkflowMetadata{})
+			cmd := NewScheduleSubWorkflowCommand(1, parentInstance, uuid.NewString(), uuid.NewString(), "SubWorkflow", []payload.Payload{}, &core.WorkflowMetadata{}, core.PriorityNormal, core.QueueDefault, core.ParentClosePolicyRequestCancel, 0)
 
 			tt.f(t, cmd, clock)
 		})
@@ -4,18 +4,20 @@ import (
 	"time"
 
 	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
 )
 
 type ScheduleTimerCommand struct {
 	cancelableCommand
 
-	at time.Time
+	at       time.Time
+	metadata *core.WorkflowMetadata
 }
 
 var _ CancelableCommand = (*ScheduleTimerCommand)(nil)
 
-func NewScheduleTimerCommand(id int64, at time.Time) *ScheduleTimerCommand {
+func NewScheduleTimerCommand(id int64, at time.Time, metadata *core.WorkflowMetadata) *ScheduleTimerCommand {
 	return &ScheduleTimerCommand{
 		cancelableCommand: cancelableCommand{
 			command: command{
@@ -24,7 +26,8 @@ func NewScheduleTimerCommand(id int64, at time.Time) *ScheduleTimerCommand {
 				state: CommandState_Pending,
 			},
 		},
-		at: at,
+		at:       at,
+		metadata: metadata,
 	}
 }
 
@@ -50,7 +53,8 @@ func (c *ScheduleTimerCommand) Execute(clock clock.Clock) *CommandResult {
 					clock.Now(),
 					history.EventType_TimerFired,
 					&history.TimerFiredAttributes{
-						At: c.at,
+						At:       c.at,
+						Metadata: c.metadata,
 					},
 					history.ScheduleEventID(c.id),
 					history.VisibleAt(c.at),
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+)
+
+// UpsertSearchAttributesCommand adds or updates search attributes of the workflow instance. Like version markers, it
+// doesn't take up a schedule event id.
+type UpsertSearchAttributesCommand struct {
+	command
+
+	SearchAttributes core.SearchAttributes
+}
+
+var _ Command = (*UpsertSearchAttributesCommand)(nil)
+
+func NewUpsertSearchAttributesCommand(attributes core.SearchAttributes) *UpsertSearchAttributesCommand {
+	return &UpsertSearchAttributesCommand{
+		command: command{
+			name:  "UpsertSearchAttributes",
+			state: CommandState_Pending,
+		},
+		SearchAttributes: attributes,
+	}
+}
+
+func (c *UpsertSearchAttributesCommand) Execute(clock clock.Clock) *CommandResult {
+	switch c.state {
+	case CommandState_Pending:
+		// Search attributes are only added to the history, transition to Done
+		c.state = CommandState_Done
+
+		return &CommandResult{
+			Events: []*history.Event{
+				history.NewPendingEvent(
+					clock.Now(),
+					history.EventType_SearchAttributesUpserted,
+					&history.SearchAttributesUpsertedAttributes{
+						SearchAttributes: c.SearchAttributes,
+					},
+				),
+			},
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package command
+
+import (
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/internal/history"
+)
+
+// VersionMarkerCommand records the version of a change to the workflow code. Markers don't take up a schedule event
+// id, so that histories recorded before a change was made still replay.
+type VersionMarkerCommand struct {
+	command
+
+	ChangeID string
+	Version  int
+}
+
+var _ Command = (*VersionMarkerCommand)(nil)
+
+func NewVersionMarkerCommand(changeID string, version int) *VersionMarkerCommand {
+	return &VersionMarkerCommand{
+		command: command{
+			name:  "VersionMarker",
+			state: CommandState_Pending,
+		},
+		ChangeID: changeID,
+		Version:  version,
+	}
+}
+
+func (c *VersionMarkerCommand) Execute(clock clock.Clock) *CommandResult {
+	switch c.state {
+	case CommandState_Pending:
+		// Markers are only added to the history, transition to Done
+		c.state = CommandState_Done
+
+		return &CommandResult{
+			Events: []*history.Event{
+				history.NewPendingEvent(
+					clock.Now(),
+					history.EventType_VersionMarker,
+					&history.VersionMarkerAttributes{
+						ChangeID: c.ChangeID,
+						Version:  c.Version,
+					},
+				),
+			},
+		}
+	}
+
+	return nil
+}
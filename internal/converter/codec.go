@@ -0,0 +1,57 @@
+package converter
+
+import (
+	"github.com/cschleiden/go-workflows/internal/payload"
+)
+
+// PayloadCodec transforms payloads after they have been encoded by a converter, for example to compress or encrypt
+// them.
+type PayloadCodec interface {
+	// Encode transforms a payload before it is stored
+	Encode(p payload.Payload) (payload.Payload, error)
+
+	// Decode reverses Encode
+	Decode(p payload.Payload) (payload.Payload, error)
+}
+
+type codecConverter struct {
+	inner  Converter
+	codecs []PayloadCodec
+}
+
+// NewCodecConverter returns a converter passing payloads of the inner converter through the given codecs. Codecs
+// encode payloads in the given order, and decode them in reverse order.
+func NewCodecConverter(inner Converter, codecs ...PayloadCodec) Converter {
+	return &codecConverter{
+		inner:  inner,
+		codecs: codecs,
+	}
+}
+
+func (c *codecConverter) To(v interface{}) (payload.Payload, error) {
+	p, err := c.inner.To(v)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, codec := range c.codecs {
+		p, err = codec.Encode(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (c *codecConverter) From(data payload.Payload, v interface{}) error {
+	for i := len(c.codecs) - 1; i >= 0; i-- {
+		var err error
+		data, err = c.codecs[i].Decode(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.inner.From(data, v)
+}
@@ -0,0 +1,5 @@
+package core
+
+// Memo holds arbitrary key/values describing a workflow instance. Unlike search attributes, memos are not indexed and
+// cannot be used to filter instances, they are returned when listing instances.
+type Memo map[string]string
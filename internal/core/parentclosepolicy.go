@@ -0,0 +1,29 @@
+package core
+
+// ParentClosePolicy determines what happens to a running sub-workflow when its parent workflow is canceled
+type ParentClosePolicy int
+
+const (
+	// ParentClosePolicyRequestCancel cancels the sub-workflow, the parent still receives its result
+	ParentClosePolicyRequestCancel ParentClosePolicy = iota
+
+	// ParentClosePolicyAbandon leaves the sub-workflow running. The parent doesn't wait for it, and gets
+	// a canceled result immediately.
+	ParentClosePolicyAbandon
+
+	// ParentClosePolicyWait leaves the sub-workflow running, the parent keeps waiting for its result
+	ParentClosePolicyWait
+)
+
+func (p ParentClosePolicy) String() string {
+	switch p {
+	case ParentClosePolicyRequestCancel:
+		return "request_cancel"
+	case ParentClosePolicyAbandon:
+		return "abandon"
+	case ParentClosePolicyWait:
+		return "wait"
+	}
+
+	return "unknown"
+}
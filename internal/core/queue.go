@@ -0,0 +1,16 @@
+package core
+
+// Queue is the name of a task queue. Workers only receive the tasks of the queues they poll.
+type Queue string
+
+// QueueDefault is the queue of workflow instances and activities without an explicit queue
+const QueueDefault Queue = "default"
+
+// OrDefault returns the queue, or QueueDefault if no queue is set
+func (q Queue) OrDefault() Queue {
+	if q == "" {
+		return QueueDefault
+	}
+
+	return q
+}
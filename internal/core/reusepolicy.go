@@ -0,0 +1,36 @@
+package core
+
+// InstanceIDReusePolicy determines whether a new workflow instance can be created with the instance ID of an existing
+// instance
+type InstanceIDReusePolicy int
+
+const (
+	// InstanceIDReusePolicyAllowDuplicate allows reusing the instance ID once no execution with it is active
+	InstanceIDReusePolicyAllowDuplicate InstanceIDReusePolicy = iota
+
+	// InstanceIDReusePolicyAllowDuplicateFailedOnly allows reusing the instance ID only if its most recent execution
+	// failed, was canceled, or was terminated
+	InstanceIDReusePolicyAllowDuplicateFailedOnly
+
+	// InstanceIDReusePolicyRejectDuplicate never allows reusing an instance ID
+	InstanceIDReusePolicyRejectDuplicate
+
+	// InstanceIDReusePolicyTerminateExisting terminates an active execution with the same instance ID before the new
+	// one is created
+	InstanceIDReusePolicyTerminateExisting
+)
+
+func (p InstanceIDReusePolicy) String() string {
+	switch p {
+	case InstanceIDReusePolicyAllowDuplicate:
+		return "allow_duplicate"
+	case InstanceIDReusePolicyAllowDuplicateFailedOnly:
+		return "allow_duplicate_failed_only"
+	case InstanceIDReusePolicyRejectDuplicate:
+		return "reject_duplicate"
+	case InstanceIDReusePolicyTerminateExisting:
+		return "terminate_existing"
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// SearchAttributeType is the type of the value of a search attribute
+type SearchAttributeType int
+
+const (
+	SearchAttributeTypeString SearchAttributeType = iota
+	SearchAttributeTypeInt
+	SearchAttributeTypeBool
+	SearchAttributeTypeTime
+)
+
+const (
+	// MaxSearchAttributeNameLength is the maximum length of the name of a search attribute, in characters
+	MaxSearchAttributeNameLength = 128
+
+	// MaxSearchAttributeValueLength is the maximum length of the encoded value of a search attribute, in characters
+	MaxSearchAttributeValueLength = 255
+)
+
+// SearchAttribute is a typed value indexed by the backend. Instances can be filtered by their search attributes when
+// listing them. Two search attributes are equal if they have the same type and value.
+type SearchAttribute struct {
+	Type SearchAttributeType `json:"type"`
+
+	// Value is the canonical encoding of the value, used for indexing
+	Value string `json:"value"`
+}
+
+// SearchAttributes are the search attributes of a workflow instance, keyed by name
+type SearchAttributes map[string]SearchAttribute
+
+func NewStringSearchAttribute(v string) SearchAttribute {
+	return SearchAttribute{Type: SearchAttributeTypeString, Value: v}
+}
+
+func NewIntSearchAttribute(v int64) SearchAttribute {
+	return SearchAttribute{Type: SearchAttributeTypeInt, Value: strconv.FormatInt(v, 10)}
+}
+
+func NewBoolSearchAttribute(v bool) SearchAttribute {
+	return SearchAttribute{Type: SearchAttributeTypeBool, Value: strconv.FormatBool(v)}
+}
+
+// NewTimeSearchAttribute returns a search attribute for the given time. Times are indexed in UTC.
+func NewTimeSearchAttribute(v time.Time) SearchAttribute {
+	return SearchAttribute{Type: SearchAttributeTypeTime, Value: v.UTC().Format(time.RFC3339Nano)}
+}
+
+// AsString returns the value of a string search attribute
+func (a SearchAttribute) AsString() (string, bool) {
+	return a.Value, a.Type == SearchAttributeTypeString
+}
+
+// AsInt returns the value of an int search attribute
+func (a SearchAttribute) AsInt() (int64, bool) {
+	if a.Type != SearchAttributeTypeInt {
+		return 0, false
+	}
+
+	v, err := strconv.ParseInt(a.Value, 10, 64)
+	return v, err == nil
+}
+
+// AsBool returns the value of a bool search attribute
+func (a SearchAttribute) AsBool() (bool, bool) {
+	if a.Type != SearchAttributeTypeBool {
+		return false, false
+	}
+
+	v, err := strconv.ParseBool(a.Value)
+	return v, err == nil
+}
+
+// AsTime returns the value of a time search attribute
+func (a SearchAttribute) AsTime() (time.Time, bool) {
+	if a.Type != SearchAttributeTypeTime {
+		return time.Time{}, false
+	}
+
+	v, err := time.Parse(time.RFC3339Nano, a.Value)
+	return v, err == nil
+}
+
+// Validate returns an error if a name or value of the search attributes can't be indexed
+func (s SearchAttributes) Validate() error {
+	for name, a := range s {
+		if name == "" {
+			return errors.New("search attribute name must not be empty")
+		}
+
+		if utf8.RuneCountInString(name) > MaxSearchAttributeNameLength {
+			return fmt.Errorf("search attribute name %q exceeds %d characters", name, MaxSearchAttributeNameLength)
+		}
+
+		if utf8.RuneCountInString(a.Value) > MaxSearchAttributeValueLength {
+			return fmt.Errorf("value of search attribute %q exceeds %d characters", name, MaxSearchAttributeValueLength)
+		}
+	}
+
+	return nil
+}
+
+// Matches returns true if the search attributes contain all of the given attributes
+func (s SearchAttributes) Matches(filter SearchAttributes) bool {
+	for name, a := range filter {
+		if v, ok := s[name]; !ok || v != a {
+			return false
+		}
+	}
+
+	return true
+}
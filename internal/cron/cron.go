@@ -0,0 +1,216 @@
+// Package cron parses standard five-field cron expressions and calculates their next activation time.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLookahead bounds the search for the next activation, expressions like "0 0 30 2 *" never match
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+// Expression is a parsed cron expression. Each field is a bit set of the values it matches.
+type Expression struct {
+	minute, hour, dom, month, dow uint64
+
+	// domRestricted and dowRestricted are set if the respective field is not "*". If both are restricted, days
+	// matching either of them match, as in most cron implementations.
+	domRestricted, dowRestricted bool
+}
+
+type field struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{0, 59, nil}
+	hourField   = field{0, 23, nil}
+	domField    = field{1, 31, nil}
+	monthField  = field{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{0, 6, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses an expression of the form "minute hour day-of-month month day-of-week". Fields support "*", values,
+// ranges ("1-5"), lists ("1,3,5"), and steps ("*/15", "0-30/10"). Months and days of the week can be given by their
+// three-letter names, Sunday is 0 or 7. The descriptors @yearly, @monthly, @weekly, @daily, and @hourly are
+// supported as well.
+func Parse(expr string) (*Expression, error) {
+	if d, ok := descriptors[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = d
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	e := &Expression{
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}
+
+	var err error
+	if e.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, fmt.Errorf("cron expression %q: minute: %w", expr, err)
+	}
+
+	if e.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, fmt.Errorf("cron expression %q: hour: %w", expr, err)
+	}
+
+	if e.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, fmt.Errorf("cron expression %q: day of month: %w", expr, err)
+	}
+
+	if e.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, fmt.Errorf("cron expression %q: month: %w", expr, err)
+	}
+
+	// Allow 7 for Sunday
+	if e.dow, err = (field{0, 7, dowField.names}).parse(fields[4]); err != nil {
+		return nil, fmt.Errorf("cron expression %q: day of week: %w", expr, err)
+	}
+
+	if e.dow&(1<<7) != 0 {
+		e.dow |= 1
+	}
+
+	return e, nil
+}
+
+func (f field) parse(s string) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(s, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		var from, to int
+		switch {
+		case rangePart == "*":
+			from, to = f.min, f.max
+
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+
+			var err error
+			if from, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+
+			if to, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+
+			if from > to {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+
+		default:
+			var err error
+			if from, err = f.value(rangePart); err != nil {
+				return 0, err
+			}
+
+			to = from
+			if step > 1 {
+				// "5/10" is short for "5-max/10"
+				to = f.max
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+// ErrNoActivation is returned by Next if the expression doesn't match any time in the foreseeable future
+var ErrNoActivation = errors.New("cron expression has no upcoming activation")
+
+// Next returns the first time matching the expression strictly after t, in the location of t
+func (e *Expression) Next(t time.Time) (time.Time, error) {
+	end := t.Add(maxLookahead)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(end) {
+		if e.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !e.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if e.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if e.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t, nil
+	}
+
+	return time.Time{}, ErrNoActivation
+}
+
+func (e *Expression) matchesDay(t time.Time) bool {
+	dom := e.dom&(1<<uint(t.Day())) != 0
+	dow := e.dow&(1<<uint(t.Weekday())) != 0
+
+	if e.domRestricted && e.dowRestricted {
+		return dom || dow
+	}
+
+	return dom && dow
+}
@@ -0,0 +1,71 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Next(t *testing.T) {
+	// Wednesday
+	base := time.Date(2023, 3, 15, 10, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2023, 3, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2023, 3, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2023, 3, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2023, 3, 15, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * mon", time.Date(2023, 3, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2023, 3, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 jan,jul *", time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Day of month or day of week
+		{"0 0 20 * fri", time.Date(2023, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2023, 3, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2023, 3, 16, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := Parse(tt.expr)
+			require.NoError(t, err)
+
+			got, err := e.Next(base)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Next_NoActivation(t *testing.T) {
+	e, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+
+	_, err = e.Next(time.Now())
+	require.ErrorIs(t, err, ErrNoActivation)
+}
+
+func Test_Parse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Parse(expr)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// Package decorator provides the base of backend decorators, which passes the optional backend interfaces through
+// to the wrapped backend.
+package decorator
+
+import (
+	"context"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+// Base wraps a backend. Embed it in decorators and override the methods they change, all other methods of the
+// backend and its optional interfaces are passed through. Optional interfaces the wrapped backend doesn't implement
+// behave like they do for callers checking for them with backend helpers: they return the corresponding "not
+// supported" error, or do nothing.
+type Base struct {
+	backend.Backend
+
+	// Before, if set, is called before passing a call through to an optional interface of the wrapped backend
+	Before func(ctx context.Context)
+}
+
+var _ backend.Backend = (*Base)(nil)
+var _ backend.RateLimiter = (*Base)(nil)
+var _ backend.Leaser = (*Base)(nil)
+var _ backend.LoadReporter = (*Base)(nil)
+var _ backend.Querier = (*Base)(nil)
+var _ backend.ScheduleStore = (*Base)(nil)
+var _ backend.AsyncActivityCompleter = (*Base)(nil)
+var _ backend.ActivityHeartbeater = (*Base)(nil)
+var _ backend.ActivityTaskReleaser = (*Base)(nil)
+var _ backend.QueuePoller = (*Base)(nil)
+var _ backend.Retainer = (*Base)(nil)
+var _ backend.IDGenerator = (*Base)(nil)
+var _ backend.InstancePurger = (*Base)(nil)
+var _ backend.ArchiveRetrier = (*Base)(nil)
+
+func (b *Base) before(ctx context.Context) {
+	if b.Before != nil {
+		b.Before(ctx)
+	}
+}
+
+func (b *Base) GetWorkflowTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Workflow, error) {
+	qp, ok := b.Backend.(backend.QueuePoller)
+	if !ok {
+		return nil, backend.ErrQueuesNotSupported
+	}
+
+	b.before(ctx)
+
+	return qp.GetWorkflowTaskFromQueues(ctx, queues)
+}
+
+func (b *Base) GetActivityTaskFromQueues(ctx context.Context, queues []core.Queue) (*task.Activity, error) {
+	qp, ok := b.Backend.(backend.QueuePoller)
+	if !ok {
+		return nil, backend.ErrQueuesNotSupported
+	}
+
+	b.before(ctx)
+
+	return qp.GetActivityTaskFromQueues(ctx, queues)
+}
+
+// RecordActivityHeartbeat does nothing if the wrapped backend doesn't persist heartbeats, they are only tracked by
+// the worker executing the activity then
+func (b *Base) RecordActivityHeartbeat(ctx context.Context, activityID string, details payload.Payload) error {
+	ah, ok := b.Backend.(backend.ActivityHeartbeater)
+	if !ok {
+		return nil
+	}
+
+	b.before(ctx)
+
+	return ah.RecordActivityHeartbeat(ctx, activityID, details)
+}
+
+// ReleaseActivityTask does nothing if the wrapped backend doesn't support it, the lock of the task expires instead
+func (b *Base) ReleaseActivityTask(ctx context.Context, activityID string) error {
+	ar, ok := b.Backend.(backend.ActivityTaskReleaser)
+	if !ok {
+		return nil
+	}
+
+	b.before(ctx)
+
+	return ar.ReleaseActivityTask(ctx, activityID)
+}
+
+func (b *Base) AcquireActivityRateLimit(ctx context.Context, activityName string) (time.Duration, error) {
+	rl, ok := b.Backend.(backend.RateLimiter)
+	if !ok {
+		return 0, nil
+	}
+
+	b.before(ctx)
+
+	return rl.AcquireActivityRateLimit(ctx, activityName)
+}
+
+func (b *Base) AcquireRateLimit(ctx context.Context, name string, limit backend.RateLimit) (time.Duration, error) {
+	rl, ok := b.Backend.(backend.RateLimiter)
+	if !ok {
+		return 0, nil
+	}
+
+	b.before(ctx)
+
+	return rl.AcquireRateLimit(ctx, name, limit)
+}
+
+func (b *Base) AcquireLease(ctx context.Context, name, holder string, duration time.Duration) (bool, error) {
+	l, ok := b.Backend.(backend.Leaser)
+	if !ok {
+		return false, backend.ErrLeasesNotSupported
+	}
+
+	b.before(ctx)
+
+	return l.AcquireLease(ctx, name, holder, duration)
+}
+
+func (b *Base) ReleaseLease(ctx context.Context, name, holder string) error {
+	l, ok := b.Backend.(backend.Leaser)
+	if !ok {
+		return backend.ErrLeasesNotSupported
+	}
+
+	b.before(ctx)
+
+	return l.ReleaseLease(ctx, name, holder)
+}
+
+func (b *Base) Load(ctx context.Context) (float64, error) {
+	lr, ok := b.Backend.(backend.LoadReporter)
+	if !ok {
+		return 0, nil
+	}
+
+	b.before(ctx)
+
+	return lr.Load(ctx)
+}
+
+func (b *Base) QueueQuery(ctx context.Context, q *backend.Query) error {
+	qr, ok := b.Backend.(backend.Querier)
+	if !ok {
+		return backend.ErrQueriesNotSupported
+	}
+
+	b.before(ctx)
+
+	return qr.QueueQuery(ctx, q)
+}
+
+func (b *Base) GetQueryTask(ctx context.Context) (*backend.QueryTask, error) {
+	qr, ok := b.Backend.(backend.Querier)
+	if !ok {
+		return nil, backend.ErrQueriesNotSupported
+	}
+
+	b.before(ctx)
+
+	return qr.GetQueryTask(ctx)
+}
+
+func (b *Base) CompleteQueryTask(ctx context.Context, queryID string, result *backend.QueryResult) error {
+	qr, ok := b.Backend.(backend.Querier)
+	if !ok {
+		return backend.ErrQueriesNotSupported
+	}
+
+	b.before(ctx)
+
+	return qr.CompleteQueryTask(ctx, queryID, result)
+}
+
+func (b *Base) GetQueryResult(ctx context.Context, queryID string) (*backend.QueryResult, error) {
+	qr, ok := b.Backend.(backend.Querier)
+	if !ok {
+		return nil, backend.ErrQueriesNotSupported
+	}
+
+	b.before(ctx)
+
+	return qr.GetQueryResult(ctx, queryID)
+}
+
+func (b *Base) SetActivityTaskPending(ctx context.Context, instance *workflow.Instance, activityID string) error {
+	ac, ok := b.Backend.(backend.AsyncActivityCompleter)
+	if !ok {
+		return backend.ErrAsyncActivitiesNotSupported
+	}
+
+	b.before(ctx)
+
+	return ac.SetActivityTaskPending(ctx, instance, activityID)
+}
+
+func (b *Base) CompletePendingActivityTask(ctx context.Context, instance *workflow.Instance, activityID string, event *history.Event) error {
+	ac, ok := b.Backend.(backend.AsyncActivityCompleter)
+	if !ok {
+		return backend.ErrAsyncActivitiesNotSupported
+	}
+
+	b.before(ctx)
+
+	return ac.CompletePendingActivityTask(ctx, instance, activityID, event)
+}
+
+func (b *Base) CreateSchedule(ctx context.Context, s *backend.Schedule) error {
+	ss, ok := b.Backend.(backend.ScheduleStore)
+	if !ok {
+		return backend.ErrSchedulesNotSupported
+	}
+
+	b.before(ctx)
+
+	return ss.CreateSchedule(ctx, s)
+}
+
+func (b *Base) UpdateSchedule(ctx context.Context, s *backend.Schedule) error {
+	ss, ok := b.Backend.(backend.ScheduleStore)
+	if !ok {
+		return backend.ErrSchedulesNotSupported
+	}
+
+	b.before(ctx)
+
+	return ss.UpdateSchedule(ctx, s)
+}
+
+func (b *Base) DeleteSchedule(ctx context.Context, id string) error {
+	ss, ok := b.Backend.(backend.ScheduleStore)
+	if !ok {
+		return backend.ErrSchedulesNotSupported
+	}
+
+	b.before(ctx)
+
+	return ss.DeleteSchedule(ctx, id)
+}
+
+func (b *Base) ListSchedules(ctx context.Context) ([]*backend.Schedule, error) {
+	ss, ok := b.Backend.(backend.ScheduleStore)
+	if !ok {
+		return nil, backend.ErrSchedulesNotSupported
+	}
+
+	b.before(ctx)
+
+	return ss.ListSchedules(ctx)
+}
+
+// Retention returns 0 if the wrapped backend can't be configured with a retention period
+func (b *Base) Retention() time.Duration {
+	r, ok := b.Backend.(backend.Retainer)
+	if !ok {
+		return 0
+	}
+
+	return r.Retention()
+}
+
+// RemoveFinishedWorkflowInstances returns backend.ErrPurgeNotSupported if the wrapped backend doesn't support bulk
+// removal, backend.RemoveFinishedWorkflowInstances then removes instances one by one
+func (b *Base) RemoveFinishedWorkflowInstances(ctx context.Context, finishedBefore time.Time, workflowName string) (int, error) {
+	p, ok := b.Backend.(backend.InstancePurger)
+	if !ok {
+		return 0, backend.ErrPurgeNotSupported
+	}
+
+	b.before(ctx)
+
+	return p.RemoveFinishedWorkflowInstances(ctx, finishedBefore, workflowName)
+}
+
+func (b *Base) ArchiveWorkflowInstanceHistories(ctx context.Context) (int, error) {
+	r, ok := b.Backend.(backend.ArchiveRetrier)
+	if !ok {
+		return 0, nil
+	}
+
+	b.before(ctx)
+
+	return r.ArchiveWorkflowInstanceHistories(ctx)
+}
+
+func (b *Base) NewID() string {
+	return backend.NewID(b.Backend)
+}
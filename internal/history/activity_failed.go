@@ -1,7 +1,13 @@
 package history
 
-import "github.com/cschleiden/go-workflows/internal/workflowerrors"
+import (
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+)
 
 type ActivityFailedAttributes struct {
 	Error *workflowerrors.Error `json:"error,omitempty"`
+
+	// Checkpoint is the latest checkpoint recorded by the failed attempt, if any
+	Checkpoint payload.Payload `json:"checkpoint,omitempty"`
 }
@@ -1,6 +1,8 @@
 package history
 
 import (
+	"time"
+
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/payload"
 )
@@ -11,4 +13,23 @@ type ActivityScheduledAttributes struct {
 	Inputs []payload.Payload `json:"inputs,omitempty"`
 
 	Metadata *core.WorkflowMetadata `json:"metadata,omitempty"`
+
+	// Queue the activity task is routed to, empty for the default queue
+	Queue core.Queue `json:"queue,omitempty"`
+
+	ScheduleToStartTimeout time.Duration `json:"schedule_to_start_timeout,omitempty"`
+
+	StartToCloseTimeout time.Duration `json:"start_to_close_timeout,omitempty"`
+
+	// HeartbeatTimeout is the maximum time between heartbeats of a running activity
+	HeartbeatTimeout time.Duration `json:"heartbeat_timeout,omitempty"`
+
+	// AtMostOnce indicates that the activity must not be executed again if a delivered task is redelivered
+	AtMostOnce bool `json:"at_most_once,omitempty"`
+
+	// Checkpoint is the checkpoint recorded by a previous attempt of the activity, if any
+	Checkpoint payload.Payload `json:"checkpoint,omitempty"`
+
+	// Attempt of the activity, starting at 0 for the first attempt
+	Attempt int `json:"attempt,omitempty"`
 }
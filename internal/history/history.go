@@ -1,6 +1,7 @@
 package history
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 
@@ -55,6 +56,22 @@ const (
 
 	// Recorded result of a side-efect
 	EventType_SideEffectResult
+
+	// Workflow has been paused. No workflow tasks are executed until the workflow is resumed.
+	EventType_WorkflowExecutionPaused
+	// Workflow has been resumed after being paused
+	EventType_WorkflowExecutionResumed
+
+	// Recorded version of a change to the workflow code
+	EventType_VersionMarker
+
+	// Search attributes of the workflow instance have been added or updated
+	EventType_SearchAttributesUpserted
+
+	// Workflow has received an update
+	EventType_WorkflowUpdateRequested
+	// Workflow has handled an update. Records the result or the rejection of the update.
+	EventType_WorkflowUpdateCompleted
 )
 
 func (et EventType) String() string {
@@ -102,11 +119,38 @@ func (et EventType) String() string {
 	case EventType_SideEffectResult:
 		return "SideEffectResult"
 
+	case EventType_WorkflowExecutionPaused:
+		return "WorkflowExecutionPaused"
+	case EventType_WorkflowExecutionResumed:
+		return "WorkflowExecutionResumed"
+
+	case EventType_VersionMarker:
+		return "VersionMarker"
+
+	case EventType_SearchAttributesUpserted:
+		return "SearchAttributesUpserted"
+
+	case EventType_WorkflowUpdateRequested:
+		return "WorkflowUpdateRequested"
+	case EventType_WorkflowUpdateCompleted:
+		return "WorkflowUpdateCompleted"
+
 	default:
 		return "Unknown"
 	}
 }
 
+// ParseEventType returns the event type for the given name, as returned by EventType.String()
+func ParseEventType(name string) (EventType, error) {
+	for et := EventType_WorkflowExecutionStarted; et.String() != "Unknown"; et++ {
+		if et.String() == name {
+			return et, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown event type: %v", name)
+}
+
 type Event struct {
 	// ID is a unique identifier for this event
 	ID string `json:"id,omitempty"`
@@ -171,3 +215,25 @@ func NewPendingEvent(timestamp time.Time, eventType EventType, attributes interf
 func NewWorkflowCancellationEvent(timestamp time.Time) *Event {
 	return NewPendingEvent(timestamp, EventType_WorkflowExecutionCanceled, &ExecutionCanceledAttributes{})
 }
+
+func NewWorkflowPausedEvent(timestamp time.Time) *Event {
+	return NewPendingEvent(timestamp, EventType_WorkflowExecutionPaused, &ExecutionPausedAttributes{})
+}
+
+func NewWorkflowResumedEvent(timestamp time.Time) *Event {
+	return NewPendingEvent(timestamp, EventType_WorkflowExecutionResumed, &ExecutionResumedAttributes{})
+}
+
+func NewWorkflowTerminationEvent(timestamp time.Time, reason string) *Event {
+	return NewPendingEvent(timestamp, EventType_WorkflowExecutionTerminated, &ExecutionTerminatedAttributes{Reason: reason})
+}
+
+// NewWorkflowTimeoutEvent returns a termination event that becomes visible at the given execution deadline
+func NewWorkflowTimeoutEvent(timestamp time.Time, deadline time.Time) *Event {
+	return NewPendingEvent(
+		timestamp,
+		EventType_WorkflowExecutionTerminated,
+		&ExecutionTerminatedAttributes{Reason: "execution timeout exceeded", TimedOut: true},
+		VisibleAt(deadline),
+	)
+}
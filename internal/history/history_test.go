@@ -0,0 +1,18 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventType(t *testing.T) {
+	for et := EventType_WorkflowExecutionStarted; et <= EventType_SideEffectResult; et++ {
+		parsed, err := ParseEventType(et.String())
+		require.NoError(t, err)
+		require.Equal(t, et, parsed)
+	}
+
+	_, err := ParseEventType("NotAnEventType")
+	require.Error(t, err)
+}
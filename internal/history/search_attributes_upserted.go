@@ -0,0 +1,7 @@
+package history
+
+import "github.com/cschleiden/go-workflows/internal/core"
+
+type SearchAttributesUpsertedAttributes struct {
+	SearchAttributes core.SearchAttributes `json:"search_attributes,omitempty"`
+}
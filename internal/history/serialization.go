@@ -42,6 +42,12 @@ func DeserializeAttributes(eventType EventType, attributes []byte) (attr interfa
 		attr = &ExecutionCompletedAttributes{}
 	case EventType_WorkflowExecutionCanceled:
 		attr = &ExecutionCanceledAttributes{}
+	case EventType_WorkflowExecutionTerminated:
+		attr = &ExecutionTerminatedAttributes{}
+	case EventType_WorkflowExecutionPaused:
+		attr = &ExecutionPausedAttributes{}
+	case EventType_WorkflowExecutionResumed:
+		attr = &ExecutionResumedAttributes{}
 
 	case EventType_WorkflowTaskStarted:
 		attr = &WorkflowTaskStartedAttributes{}
@@ -59,6 +65,17 @@ func DeserializeAttributes(eventType EventType, attributes []byte) (attr interfa
 	case EventType_SideEffectResult:
 		attr = &SideEffectResultAttributes{}
 
+	case EventType_VersionMarker:
+		attr = &VersionMarkerAttributes{}
+
+	case EventType_SearchAttributesUpserted:
+		attr = &SearchAttributesUpsertedAttributes{}
+
+	case EventType_WorkflowUpdateRequested:
+		attr = &WorkflowUpdateRequestedAttributes{}
+	case EventType_WorkflowUpdateCompleted:
+		attr = &WorkflowUpdateCompletedAttributes{}
+
 	case EventType_TimerScheduled:
 		attr = &TimerScheduledAttributes{}
 	case EventType_TimerFired:
@@ -1,7 +1,13 @@
 package history
 
-import "github.com/cschleiden/go-workflows/internal/payload"
+import (
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/payload"
+)
 
 type SubWorkflowCompletedAttributes struct {
 	Result payload.Payload `json:"result,omitempty"`
+
+	// Metadata propagates the span context of the finished sub-workflow to the parent
+	Metadata *core.WorkflowMetadata `json:"metadata,omitempty"`
 }
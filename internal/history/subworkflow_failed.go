@@ -1,7 +1,13 @@
 package history
 
-import "github.com/cschleiden/go-workflows/internal/workflowerrors"
+import (
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+)
 
 type SubWorkflowFailedAttributes struct {
 	Error *workflowerrors.Error `json:"error,omitempty"`
+
+	// Metadata propagates the span context of the finished sub-workflow to the parent
+	Metadata *core.WorkflowMetadata `json:"metadata,omitempty"`
 }
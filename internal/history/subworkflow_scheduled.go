@@ -13,4 +13,6 @@ type SubWorkflowScheduledAttributes struct {
 	Inputs []payload.Payload `json:"inputs,omitempty"`
 
 	Metadata *core.WorkflowMetadata `json:"metadata,omitempty"`
+
+	ParentClosePolicy core.ParentClosePolicy `json:"parent_close_policy,omitempty"`
 }
@@ -1,7 +1,14 @@
 package history
 
-import "time"
+import (
+	"time"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+)
 
 type TimerFiredAttributes struct {
 	At time.Time `json:"at,omitempty"`
+
+	// Metadata links to the span that scheduled the timer
+	Metadata *core.WorkflowMetadata `json:"metadata,omitempty"`
 }
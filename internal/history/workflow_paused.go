@@ -0,0 +1,7 @@
+package history
+
+type ExecutionPausedAttributes struct {
+}
+
+type ExecutionResumedAttributes struct {
+}
@@ -1,6 +1,8 @@
 package history
 
 import (
+	"time"
+
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/payload"
 )
@@ -11,4 +13,21 @@ type ExecutionStartedAttributes struct {
 	Metadata *core.WorkflowMetadata `json:"metadata,omitempty"`
 
 	Inputs []payload.Payload `json:"inputs,omitempty"`
+
+	Priority core.Priority `json:"priority,omitempty"`
+
+	// Queue the tasks of the instance are routed to, empty for the default queue
+	Queue core.Queue `json:"queue,omitempty"`
+
+	SearchAttributes core.SearchAttributes `json:"search_attributes,omitempty"`
+
+	// Memo is stored with the instance by the backend. Executions continued as new inherit the memo.
+	Memo core.Memo `json:"memo,omitempty"`
+
+	// InstanceIDReusePolicy is enforced by the backend when creating the instance
+	InstanceIDReusePolicy core.InstanceIDReusePolicy `json:"instance_id_reuse_policy,omitempty"`
+
+	// ExecutionDeadline is the time after which the instance is timed out. Executions continued as new inherit the
+	// deadline.
+	ExecutionDeadline *time.Time `json:"execution_deadline,omitempty"`
 }
@@ -1,4 +1,9 @@
 package history
 
+import "github.com/cschleiden/go-workflows/internal/core"
+
 type WorkflowTaskStartedAttributes struct {
+	// Metadata propagates the span of the workflow execution. It's set for the first workflow task of an execution,
+	// later tasks and replays restore the span from it.
+	Metadata *core.WorkflowMetadata `json:"metadata,omitempty"`
 }
@@ -0,0 +1,8 @@
+package history
+
+type ExecutionTerminatedAttributes struct {
+	Reason string `json:"reason,omitempty"`
+
+	// TimedOut is set if the execution was terminated because it exceeded its execution deadline
+	TimedOut bool `json:"timed_out,omitempty"`
+}
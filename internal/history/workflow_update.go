@@ -0,0 +1,18 @@
+package history
+
+import (
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+)
+
+type WorkflowUpdateRequestedAttributes struct {
+	UpdateID string            `json:"id,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	Args     []payload.Payload `json:"args,omitempty"`
+}
+
+type WorkflowUpdateCompletedAttributes struct {
+	UpdateID string                `json:"id,omitempty"`
+	Result   payload.Payload       `json:"result,omitempty"`
+	Error    *workflowerrors.Error `json:"error,omitempty"`
+}
@@ -0,0 +1,150 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/sync"
+)
+
+// WorkflowTaskInfo describes the workflow task being executed
+type WorkflowTaskInfo struct {
+	Instance *core.WorkflowInstance
+
+	// Attempt is the number of times the task has been handed out, including this one
+	Attempt int
+}
+
+// SignalInfo describes a signal received by a workflow instance
+type SignalInfo struct {
+	Instance *core.WorkflowInstance
+	Name     string
+}
+
+// SubWorkflowInfo describes a sub-workflow being scheduled by a workflow instance
+type SubWorkflowInfo struct {
+	Instance *core.WorkflowInstance
+	Name     string
+
+	// SubWorkflowInstanceID is the instance ID requested for the sub-workflow. If empty, an instance ID is generated.
+	SubWorkflowInstanceID string
+}
+
+// ActivityInfo describes the activity being executed
+type ActivityInfo struct {
+	Instance   *core.WorkflowInstance
+	ActivityID string
+	Name       string
+	Attempt    int
+}
+
+// WorkflowInterceptor wraps the execution of workflows. Each method has to call next to continue, the error it returns
+// is the outcome of the wrapped operation.
+type WorkflowInterceptor interface {
+	// ExecuteWorkflowTask wraps the execution of a workflow task by the worker. Returning an error fails the task.
+	ExecuteWorkflowTask(ctx context.Context, info *WorkflowTaskInfo, next func(ctx context.Context) error) error
+
+	// HandleSignal wraps the delivery of a received signal to the workflow. It's called while replaying, too, and
+	// has to be deterministic and must not block. Not calling next discards the signal, returning an error fails the
+	// workflow.
+	HandleSignal(ctx sync.Context, info *SignalInfo, next func(ctx sync.Context) error) error
+
+	// ScheduleSubWorkflow wraps scheduling a sub-workflow from workflow code. It's called while replaying, too, and
+	// has to be deterministic. Values added to the context passed to next are propagated to the sub-workflow by the
+	// configured context propagators. Returning an error without calling next fails the sub-workflow.
+	ScheduleSubWorkflow(ctx sync.Context, info *SubWorkflowInfo, next func(ctx sync.Context) error) error
+}
+
+// ActivityInterceptor wraps the execution of activities
+type ActivityInterceptor interface {
+	// ExecuteActivity wraps the execution of an activity. The context passed to next is passed to the activity. The
+	// returned error is the result of the activity, replacing the error returned by the activity itself.
+	ExecuteActivity(ctx context.Context, info *ActivityInfo, next func(ctx context.Context) error) error
+}
+
+// ActivityInterceptorFunc is an ActivityInterceptor implemented by a function
+type ActivityInterceptorFunc func(ctx context.Context, info *ActivityInfo, next func(ctx context.Context) error) error
+
+func (f ActivityInterceptorFunc) ExecuteActivity(ctx context.Context, info *ActivityInfo, next func(ctx context.Context) error) error {
+	return f(ctx, info, next)
+}
+
+// NoopWorkflowInterceptor calls next for every operation. Embed it to implement only some of the methods of
+// WorkflowInterceptor.
+type NoopWorkflowInterceptor struct{}
+
+var _ WorkflowInterceptor = NoopWorkflowInterceptor{}
+
+func (NoopWorkflowInterceptor) ExecuteWorkflowTask(ctx context.Context, info *WorkflowTaskInfo, next func(ctx context.Context) error) error {
+	return next(ctx)
+}
+
+func (NoopWorkflowInterceptor) HandleSignal(ctx sync.Context, info *SignalInfo, next func(ctx sync.Context) error) error {
+	return next(ctx)
+}
+
+func (NoopWorkflowInterceptor) ScheduleSubWorkflow(ctx sync.Context, info *SubWorkflowInfo, next func(ctx sync.Context) error) error {
+	return next(ctx)
+}
+
+// ExecuteWorkflowTask calls the given interceptors in order around next
+func ExecuteWorkflowTask(ctx context.Context, interceptors []WorkflowInterceptor, info *WorkflowTaskInfo, next func(ctx context.Context) error) error {
+	if len(interceptors) == 0 {
+		return next(ctx)
+	}
+
+	return interceptors[0].ExecuteWorkflowTask(ctx, info, func(ctx context.Context) error {
+		return ExecuteWorkflowTask(ctx, interceptors[1:], info, next)
+	})
+}
+
+// HandleSignal calls the given interceptors in order around next
+func HandleSignal(ctx sync.Context, interceptors []WorkflowInterceptor, info *SignalInfo, next func(ctx sync.Context) error) error {
+	if len(interceptors) == 0 {
+		return next(ctx)
+	}
+
+	return interceptors[0].HandleSignal(ctx, info, func(ctx sync.Context) error {
+		return HandleSignal(ctx, interceptors[1:], info, next)
+	})
+}
+
+// ScheduleSubWorkflow calls the given interceptors in order around next
+func ScheduleSubWorkflow(ctx sync.Context, interceptors []WorkflowInterceptor, info *SubWorkflowInfo, next func(ctx sync.Context) error) error {
+	if len(interceptors) == 0 {
+		return next(ctx)
+	}
+
+	return interceptors[0].ScheduleSubWorkflow(ctx, info, func(ctx sync.Context) error {
+		return ScheduleSubWorkflow(ctx, interceptors[1:], info, next)
+	})
+}
+
+// ExecuteActivity calls the given interceptors in order around next
+func ExecuteActivity(ctx context.Context, interceptors []ActivityInterceptor, info *ActivityInfo, next func(ctx context.Context) error) error {
+	if len(interceptors) == 0 {
+		return next(ctx)
+	}
+
+	return interceptors[0].ExecuteActivity(ctx, info, func(ctx context.Context) error {
+		return ExecuteActivity(ctx, interceptors[1:], info, next)
+	})
+}
+
+type interceptorsKey int
+
+var workflowInterceptorsCtxKey interceptorsKey
+
+// WithWorkflowInterceptors makes the given interceptors available to workflow code
+func WithWorkflowInterceptors(ctx sync.Context, interceptors []WorkflowInterceptor) sync.Context {
+	return sync.WithValue(ctx, workflowInterceptorsCtxKey, interceptors)
+}
+
+func WorkflowInterceptors(ctx sync.Context) []WorkflowInterceptor {
+	interceptors, ok := ctx.Value(workflowInterceptorsCtxKey).([]WorkflowInterceptor)
+	if !ok {
+		return nil
+	}
+
+	return interceptors
+}
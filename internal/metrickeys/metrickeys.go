@@ -10,13 +10,43 @@ const (
 	WorkflowTaskProcessed = Prefix + "workflow.task.processed"
 	WorkflowTaskDelay     = Prefix + "workflow.task.time_in_queue"
 
+	// Workflow tasks that failed and are retried, and instances moved to the dead-letter state after failing too often
+	WorkflowTaskFailed       = Prefix + "workflow.task.failed"
+	WorkflowTaskDeadLettered = Prefix + "workflow.task.dead_lettered"
+
 	WorkflowInstanceCacheSize     = Prefix + "workflow.cache.size"
 	WorkflowInstanceCacheEviction = Prefix + "workflow.cache.eviction"
+	WorkflowInstanceCacheHit      = Prefix + "workflow.cache.hit"
+	WorkflowInstanceCacheMiss     = Prefix + "workflow.cache.miss"
+
+	// Workflow tasks for which the executor had to replay the full history, for example, after a cache miss
+	WorkflowInstanceFullReplay = Prefix + "workflow.replay.full"
+
+	// Number of history events replayed by workflow tasks that had to replay history
+	WorkflowReplayedEvents = Prefix + "workflow.replay.events"
+
+	// Number of events in the history of an execution after each workflow task
+	WorkflowHistoryLength = Prefix + "workflow.history.events"
 
 	// Activities
 	ActivityTaskScheduled = Prefix + "activity.task.scheduled"
 	ActivityTaskProcessed = Prefix + "activity.task.processed"
 	ActivityTaskDelay     = Prefix + "activity.task.time_in_queue"
+
+	// Time spent executing the activity code, excluding rate limiting and completing the task
+	ActivityExecutionDuration = Prefix + "activity.execution.duration"
+
+	// History events dropped by the event feed because its sink didn't keep up
+	HistoryEventsDropped = Prefix + "events.dropped"
+
+	// Backend stats
+	ActiveWorkflowInstances = Prefix + "workflow.active"
+	PendingActivities       = Prefix + "activity.pending"
+	PendingWorkflowTasks    = Prefix + "workflow.task.pending"
+	LockedWorkflowTasks     = Prefix + "workflow.task.locked"
+	PendingActivityTasks    = Prefix + "activity.task.pending"
+	LockedActivityTasks     = Prefix + "activity.task.locked"
+	PendingTimers           = Prefix + "timer.pending"
 )
 
 // Tag names
@@ -24,6 +54,9 @@ const (
 	// Backend being used
 	Backend = "backend"
 
+	// Namespace of the backend being used
+	Namespace = "namespace"
+
 	// Reason for evicting an entry from the workflow instance cache
 	EvictionReason = "reason"
 
@@ -32,4 +65,7 @@ const (
 
 	ActivityName = "activity"
 	EventName    = "event"
+
+	// Outcome of an activity execution: completed, failed, or pending for asynchronously completed activities
+	Outcome = "outcome"
 )
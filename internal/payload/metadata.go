@@ -0,0 +1,62 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// metadataMarker prefixes payloads carrying metadata, followed by the length of the metadata as uvarint, the
+// metadata as JSON, and the data. Like other markers, it starts with a NUL byte, which JSON payloads never do.
+var metadataMarker = []byte{0x00, 'g', 'w', 'm'}
+
+// Metadata describes how the data of a payload has been encoded
+type Metadata map[string]string
+
+const (
+	// MetadataEncoding identifies the converter that encoded the data, for example "json/plain"
+	MetadataEncoding = "encoding"
+
+	// MetadataContentType is the MIME type of the data, if known
+	MetadataContentType = "content-type"
+)
+
+// New returns a payload carrying the given metadata and data
+func New(metadata Metadata, data []byte) (Payload, error) {
+	md, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("encoding payload metadata: %w", err)
+	}
+
+	p := make([]byte, 0, len(metadataMarker)+binary.MaxVarintLen64+len(md)+len(data))
+	p = append(p, metadataMarker...)
+	p = binary.AppendUvarint(p, uint64(len(md)))
+	p = append(p, md...)
+	p = append(p, data...)
+
+	return p, nil
+}
+
+// Split returns the metadata and the data of the given payload. Payloads without metadata, for example written by
+// converters that don't record any, are returned unchanged with nil metadata.
+func Split(p Payload) (Metadata, []byte, error) {
+	if !bytes.HasPrefix(p, metadataMarker) {
+		return nil, p, nil
+	}
+
+	rest := p[len(metadataMarker):]
+
+	n, l := binary.Uvarint(rest)
+	if l <= 0 || n > uint64(len(rest)-l) {
+		return nil, nil, errors.New("malformed payload metadata")
+	}
+
+	var metadata Metadata
+	if err := json.Unmarshal(rest[l:l+int(n)], &metadata); err != nil {
+		return nil, nil, fmt.Errorf("decoding payload metadata: %w", err)
+	}
+
+	return metadata, rest[l+int(n):], nil
+}
@@ -8,6 +8,13 @@ type Signaler interface {
 	SignalWorkflow(ctx context.Context, instanceID string, name string, arg interface{}) error
 }
 
+// SignalerFunc adapts a function to the Signaler interface
+type SignalerFunc func(ctx context.Context, instanceID string, name string, arg interface{}) error
+
+func (f SignalerFunc) SignalWorkflow(ctx context.Context, instanceID string, name string, arg interface{}) error {
+	return f(ctx, instanceID, name, arg)
+}
+
 type Activities struct {
 	Signaler Signaler
 }
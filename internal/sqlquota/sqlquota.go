@@ -0,0 +1,62 @@
+// Package sqlquota checks the active instance limits of the SQL backends in the transaction creating an instance.
+package sqlquota
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+)
+
+// Dialect holds the statements of a backend for checking active instance limits
+type Dialect struct {
+	// Lock serializes creating instances subject to the same limit, so that concurrent transactions count each
+	// other's instances. Its arguments are the namespace and the key of the limit. Empty if the backend serializes
+	// transactions creating instances already.
+	Lock string
+
+	// CountActive counts the active instances of the namespace. Its arguments are the namespace and the active state.
+	CountActive string
+
+	// CountActiveTenant counts the active instances of a tenant. Its arguments are the namespace, the active state, and
+	// the name, type, and value of the search attribute holding the tenant.
+	CountActiveTenant string
+}
+
+// Check returns the error of the first limit that creating another instance would exceed
+func (d Dialect) Check(ctx context.Context, tx *sql.Tx, namespace string, limits []backend.ActiveInstanceLimit) error {
+	for _, l := range limits {
+		if l.Max <= 0 {
+			continue
+		}
+
+		if d.Lock != "" {
+			if _, err := tx.ExecContext(ctx, d.Lock, namespace, "quota:"+l.Tenant); err != nil {
+				return fmt.Errorf("locking quota: %w", err)
+			}
+		}
+
+		var row *sql.Row
+		if l.Tenant == "" {
+			row = tx.QueryRowContext(ctx, d.CountActive, namespace, core.WorkflowInstanceStateActive)
+		} else {
+			tenant := core.NewStringSearchAttribute(l.Tenant)
+			row = tx.QueryRowContext(
+				ctx, d.CountActiveTenant, namespace, core.WorkflowInstanceStateActive, l.TenantSearchAttribute, tenant.Type, tenant.Value,
+			)
+		}
+
+		var active int
+		if err := row.Scan(&active); err != nil {
+			return fmt.Errorf("counting active instances: %w", err)
+		}
+
+		if active >= l.Max {
+			return l.Exceeded()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+// Package sqlsignals implements signal deduplication for the SQL backends, which record signals in their
+// signal_deduplication table.
+package sqlsignals
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/history"
+)
+
+// Dialect holds the statements of a backend for the signal_deduplication table
+type Dialect struct {
+	// DeleteExpired removes the expired signals of an instance. Its arguments are the namespace, the instance ID,
+	// and the current time.
+	DeleteExpired string
+
+	// Insert records a signal unless an identical signal has been recorded already. Its arguments are the namespace,
+	// the instance ID, the hash of the signal, and the time the signal expires.
+	Insert string
+
+	// DeleteAllExpired removes the expired signals of all instances. Its arguments are the namespace and the current
+	// time.
+	DeleteAllExpired string
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// IsDuplicate records the given signal and returns true if an identical signal was delivered to the instance
+// within the deduplication window
+func (d Dialect) IsDuplicate(
+	ctx context.Context, tx *sql.Tx, namespace, instanceID string, event *history.Event, window time.Duration, now time.Time,
+) (bool, error) {
+	// Remove expired signals before checking for duplicates
+	if _, err := tx.ExecContext(ctx, d.DeleteExpired, namespace, instanceID, now.UnixNano()); err != nil {
+		return false, fmt.Errorf("removing expired signals: %w", err)
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		d.Insert,
+		namespace,
+		instanceID,
+		backend.SignalHash(event.Attributes.(*history.SignalReceivedAttributes)),
+		now.Add(window).UnixNano(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("recording signal: %w", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("recording signal: %w", err)
+	}
+
+	return inserted == 0, nil
+}
+
+// Prune removes the expired signals of all instances in the namespace. Signals are otherwise only removed when the
+// next signal is delivered to their instance, which never happens for removed instances.
+func (d Dialect) Prune(ctx context.Context, db execer, namespace string, now time.Time) error {
+	if _, err := db.ExecContext(ctx, d.DeleteAllExpired, namespace, now.UnixNano()); err != nil {
+		return fmt.Errorf("removing expired signals: %w", err)
+	}
+
+	return nil
+}
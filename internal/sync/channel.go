@@ -44,6 +44,16 @@ func NewBufferedChannel[T any](size int) Channel[T] {
 	}
 }
 
+// NewUnboundedChannel creates a buffered channel without a capacity limit. Sending to it never blocks.
+func NewUnboundedChannel[T any]() Channel[T] {
+	return &channel[T]{
+		c:    make([]T, 0),
+		size: unbounded,
+	}
+}
+
+const unbounded = -1
+
 type channel[T any] struct {
 	c         []T
 	receivers []*Receiver[T]
@@ -228,7 +238,7 @@ func (c *channel[T]) tryReceive() (v T, ok bool, rok bool) {
 }
 
 func (c *channel[T]) hasCapacity() bool {
-	return len(c.c) < c.size
+	return c.size == unbounded || len(c.c) < c.size
 }
 
 func (c *channel[T]) AddReceiveCallback(cb *Receiver[T]) {
@@ -7,6 +7,8 @@ import (
 	"runtime"
 	"sync/atomic"
 	"time"
+
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
 )
 
 const DeadlockDetection = 40 * time.Second
@@ -58,17 +60,30 @@ type coState struct {
 	deadlockDetection time.Duration
 
 	creator CoroutineCreator
+
+	guard       bool         // determinism guard is enabled
+	goroutineID atomic.Int64 // id of the goroutine executing the coroutine
+	guardErr    atomic.Value // violation detected by the determinism guard
 }
 
 func NewCoroutine(ctx Context, fn func(ctx Context) error) Coroutine {
 	s := newState()
+	s.guard = determinismGuardEnabled(ctx)
 	ctx = withCoState(ctx, s)
 
 	go func() {
+		if s.guard {
+			id := currentGoroutineID()
+			s.goroutineID.Store(id)
+
+			registerGuarded(id, s)
+			defer unregisterGuarded(id)
+		}
+
 		defer s.finish() // Ensure we always mark the coroutine as finished
 		defer func() {
 			if r := recover(); r != nil {
-				s.err = fmt.Errorf("panic: %v", r)
+				s.err = workflowerrors.NewPanicError(fmt.Sprintf("panic: %v", r))
 			}
 		}()
 
@@ -176,15 +191,55 @@ func (s *coState) Execute() {
 
 	runtime.Gosched()
 
+	// Only inspect the coroutine while it's running when the determinism guard is enabled
+	var guardC <-chan time.Time
+	if s.guard {
+		guardT := time.NewTicker(DeterminismGuardInterval)
+		defer guardT.Stop()
+		guardC = guardT.C
+	}
+
 	// Run until blocked (which is also true when finished)
-	select {
-	case <-s.blocking:
-		s.logger.Println("execute: blocked")
-	case <-t.C:
-		panic("coroutine timed out")
+	for {
+		select {
+		case <-s.blocking:
+			s.logger.Println("execute: blocked")
+
+			if s.guard {
+				if err := checkSpawned(s.goroutineID.Load()); err != nil {
+					s.setGuardErr(err)
+				}
+			}
+
+			return
+
+		case <-guardC:
+			blocked, err := checkBlocked(s.goroutineID.Load())
+			if err == nil {
+				continue
+			}
+
+			s.setGuardErr(err)
+
+			if blocked {
+				// The coroutine is blocked outside of our control and might never continue. Abandon it and treat
+				// it as finished, so that the error is surfaced and the scheduler doesn't wait for it.
+				s.logger.Println("execute: abandoned")
+				s.finished.Store(true)
+				return
+			}
+
+		case <-t.C:
+			panic("coroutine timed out")
+		}
 	}
 }
 
+func (s *coState) setGuardErr(err error) {
+	// Keep the first violation
+	s.guardErr.CompareAndSwap(nil, err)
+}
+
 func (s *coState) Exit() {
 	s.logger.Println("exit")
 
@@ -197,6 +252,10 @@ func (s *coState) Exit() {
 }
 
 func (s *coState) Error() error {
+	if err, ok := s.guardErr.Load().(error); ok {
+		return err
+	}
+
 	return s.err
 }
 
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -176,4 +177,8 @@ func Test_Coroutine_Panic(t *testing.T) {
 	require.True(t, c.Finished())
 	require.Error(t, c.Error())
 	require.Equal(t, c.Error().Error(), "panic: test panic")
+
+	var perr *workflowerrors.PanicError
+	require.ErrorAs(t, c.Error(), &perr)
+	require.Contains(t, perr.Stack(), "Test_Coroutine_Panic")
 }
@@ -0,0 +1,226 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	gosync "sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDeterminismViolation is returned when the determinism guard detects workflow code that is not deterministic
+var ErrDeterminismViolation = errors.New("determinism guard")
+
+// DeterminismGuardInterval is the interval in which the determinism guard inspects a running coroutine
+var DeterminismGuardInterval = 250 * time.Millisecond
+
+type guardKey int
+
+var determinismGuardCtxKey guardKey
+
+// WithDeterminismGuard enables the determinism guard for all coroutines started with the returned context.
+//
+// When enabled, coroutines are inspected while and after they run. Blocking on native channel operations, select
+// statements, or time.Sleep, as well as starting goroutines with the go statement, fail the coroutine with an error
+// wrapping ErrDeterminismViolation. Inspecting coroutines requires capturing the stacks of all goroutines, which is
+// expensive. The guard is intended for development and testing.
+//
+// Reading wall-clock time doesn't block and can't be detected by inspecting stacks. Clocks calling CheckWallClock
+// fail the coroutine calling them instead.
+func WithDeterminismGuard(ctx Context) Context {
+	return WithValue(ctx, determinismGuardCtxKey, true)
+}
+
+func determinismGuardEnabled(ctx Context) bool {
+	v, ok := ctx.Value(determinismGuardCtxKey).(bool)
+	return ok && v
+}
+
+// guarded holds the running coroutines with the determinism guard enabled by the id of their goroutine
+var guarded = struct {
+	gosync.Mutex
+	states map[int64]*coState
+}{states: map[int64]*coState{}}
+
+// guardedCount is the number of coroutines in guarded, so that clocks don't look up goroutines unless required
+var guardedCount atomic.Int64
+
+// coroutineLabel is the profiler label identifying the guarded coroutine a goroutine was started by. Goroutines
+// inherit the labels of the goroutine starting them.
+const coroutineLabel = "workflows.coroutine"
+
+// registerGuarded registers the coroutine running on the calling goroutine, with the given id
+func registerGuarded(id int64, s *coState) {
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels(coroutineLabel, strconv.FormatInt(id, 10))))
+
+	guarded.Lock()
+	defer guarded.Unlock()
+
+	guarded.states[id] = s
+	guardedCount.Add(1)
+}
+
+func unregisterGuarded(id int64) {
+	guarded.Lock()
+	defer guarded.Unlock()
+
+	delete(guarded.states, id)
+	guardedCount.Add(-1)
+}
+
+// CheckWallClock fails the calling coroutine with an error wrapping ErrDeterminismViolation if it's running with the
+// determinism guard enabled. op is the name of the clock operation reading wall-clock time. Outside of guarded
+// coroutines, CheckWallClock does nothing.
+func CheckWallClock(op string) {
+	if guardedCount.Load() == 0 {
+		return
+	}
+
+	guarded.Lock()
+	s := guarded.states[currentGoroutineID()]
+	guarded.Unlock()
+
+	if s == nil {
+		return
+	}
+
+	buf := make([]byte, 64*1024)
+	buf = buf[:runtime.Stack(buf, false)]
+
+	s.setGuardErr(fmt.Errorf("%w: workflow code is reading wall-clock time with %v, use workflow.Now, workflow.Sleep, or workflow.ScheduleTimer instead:\n%s",
+		ErrDeterminismViolation, op, buf))
+}
+
+// goroutine is a single goroutine parsed from a full stack dump
+type goroutine struct {
+	id    int64
+	state string
+	stack string
+}
+
+func currentGoroutineID() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+
+	// goroutine 123 [running]:
+	id, _ := strconv.ParseInt(string(bytes.Fields(buf)[1]), 10, 64)
+	return id
+}
+
+func allGoroutines() []goroutine {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+
+		buf = make([]byte, len(buf)*2)
+	}
+
+	var goroutines []goroutine
+
+	for _, block := range strings.Split(string(buf), "\n\n") {
+		lines := strings.Split(block, "\n")
+
+		// goroutine 123 [chan receive, 2 minutes]:
+		header := lines[0]
+		if !strings.HasPrefix(header, "goroutine ") {
+			continue
+		}
+
+		fields := strings.SplitN(strings.TrimPrefix(header, "goroutine "), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+
+		id, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		state := strings.TrimSuffix(strings.TrimPrefix(fields[1], "["), "]:")
+		if i := strings.Index(state, ","); i >= 0 {
+			state = state[:i]
+		}
+
+		goroutines = append(goroutines, goroutine{
+			id:    id,
+			state: state,
+			stack: block,
+		})
+	}
+
+	return goroutines
+}
+
+// checkBlocked returns an error if the coroutine with the given goroutine id is blocked outside of the coroutine
+// scheduling, e.g., on a native channel. blocked is true if the coroutine might never continue.
+func checkBlocked(id int64) (blocked bool, err error) {
+	for _, g := range allGoroutines() {
+		if g.id != id {
+			continue
+		}
+
+		if strings.Contains(g.stack, "internal/sync.(*coState).yield") {
+			// Regularly yielding
+			return false, nil
+		}
+
+		switch g.state {
+		case "chan receive", "chan send", "chan receive (nil chan)", "chan send (nil chan)":
+			return true, fmt.Errorf("%w: workflow code is blocked on a native channel operation, use workflow.Channel instead:\n%s",
+				ErrDeterminismViolation, g.stack)
+
+		case "select", "select (no cases)":
+			return true, fmt.Errorf("%w: workflow code is blocked in a native select statement, use workflow.Select instead:\n%s",
+				ErrDeterminismViolation, g.stack)
+
+		case "sleep":
+			// Sleeping goroutines continue eventually, keep waiting for the coroutine
+			return false, fmt.Errorf("%w: workflow code is using wall-clock time to sleep, use workflow.Sleep or workflow.ScheduleTimer instead:\n%s",
+				ErrDeterminismViolation, g.stack)
+		}
+	}
+
+	return false, nil
+}
+
+// checkSpawned returns an error if the coroutine with the given goroutine id started goroutines which are still
+// running, other than coroutines. Stack dumps only name the parent goroutine since Go 1.21, so goroutines are matched
+// by the profiler label they inherit from the coroutine instead.
+func checkSpawned(id int64) error {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil
+	}
+
+	// Goroutines with identical stacks and labels are grouped:
+	//
+	//	1 @ 0x43a1b6 0x46a2e5
+	//	# labels: {"workflows.coroutine":"12"}
+	//	#	0x46a2e4	main.main.func1+0x24	/app/main.go:10
+	label := fmt.Sprintf("%q:%q", coroutineLabel, strconv.FormatInt(id, 10))
+	for _, record := range strings.Split(buf.String(), "\n\n") {
+		if !strings.Contains(record, label) {
+			continue
+		}
+
+		// The coroutine itself, and coroutines it started which haven't registered their own label yet
+		if strings.Contains(record, "internal/sync.NewCoroutine") {
+			continue
+		}
+
+		return fmt.Errorf("%w: workflow code started a goroutine using the go statement, use workflow.Go instead:\n%s",
+			ErrDeterminismViolation, record)
+	}
+
+	return nil
+}
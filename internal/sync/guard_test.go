@@ -0,0 +1,129 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func withGuardInterval(t *testing.T, d time.Duration) {
+	prev := DeterminismGuardInterval
+	DeterminismGuardInterval = d
+	t.Cleanup(func() {
+		DeterminismGuardInterval = prev
+	})
+}
+
+func Test_DeterminismGuard(t *testing.T) {
+	withGuardInterval(t, time.Millisecond*10)
+
+	tests := []struct {
+		name    string
+		fn      func(ctx Context) error
+		wantErr string
+	}{
+		{
+			name: "workflow primitives",
+			fn: func(ctx Context) error {
+				c := NewChannel[int]()
+
+				Go(ctx, func(ctx Context) {
+					c.Send(ctx, 42)
+				})
+
+				c.Receive(ctx)
+
+				return nil
+			},
+		},
+		{
+			name: "native channel",
+			fn: func(ctx Context) error {
+				c := make(chan int)
+				<-c
+
+				return nil
+			},
+			wantErr: "native channel operation",
+		},
+		{
+			name: "native select",
+			fn: func(ctx Context) error {
+				c1, c2 := make(chan int), make(chan int)
+				select {
+				case <-c1:
+				case <-c2:
+				}
+
+				return nil
+			},
+			wantErr: "native select statement",
+		},
+		{
+			name: "time.Sleep",
+			fn: func(ctx Context) error {
+				time.Sleep(time.Millisecond * 100)
+
+				return nil
+			},
+			wantErr: "wall-clock time",
+		},
+		{
+			name: "guarded clock",
+			fn: func(ctx Context) error {
+				CheckWallClock("Now")
+
+				return nil
+			},
+			wantErr: "reading wall-clock time with Now",
+		},
+		{
+			name: "go statement",
+			fn: func(ctx Context) error {
+				done := make(chan struct{})
+				t.Cleanup(func() { close(done) })
+
+				go func() {
+					<-done
+				}()
+
+				getCoState(ctx).Yield()
+
+				return nil
+			},
+			wantErr: "go statement",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduler()
+			s.NewCoroutine(WithDeterminismGuard(Background()), tt.fn)
+
+			err := s.Execute()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				require.Equal(t, 0, s.RunningCoroutines())
+				return
+			}
+
+			require.ErrorIs(t, err, ErrDeterminismViolation)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func Test_DeterminismGuard_Disabled(t *testing.T) {
+	withGuardInterval(t, time.Millisecond*10)
+
+	s := NewScheduler()
+	s.NewCoroutine(Background(), func(ctx Context) error {
+		time.Sleep(time.Millisecond * 50)
+		CheckWallClock("Now")
+
+		return nil
+	})
+
+	require.NoError(t, s.Execute())
+}
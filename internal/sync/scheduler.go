@@ -41,6 +41,11 @@ func (s *Scheduler) Execute() error {
 					return err
 				}
 			} else {
+				if err := c.Error(); err != nil {
+					// Determinism guard detected a violation while the coroutine was running
+					return err
+				}
+
 				// Determine if coroutine made any progress or if it stayed blocked
 				allBlocked = allBlocked && !c.Progress()
 			}
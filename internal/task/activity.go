@@ -1,8 +1,11 @@
 package task
 
 import (
+	"time"
+
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/payload"
 )
 
 type Activity struct {
@@ -11,4 +14,14 @@ type Activity struct {
 	WorkflowInstance *core.WorkflowInstance
 
 	Event *history.Event
+
+	// Redelivered is true if the task has been handed out before, for example, because the lock held by a previous
+	// worker expired.
+	Redelivered bool
+
+	// LastHeartbeat is the time of the last heartbeat recorded by a previous delivery of the task, if any
+	LastHeartbeat time.Time
+
+	// HeartbeatDetails are the details of the last heartbeat recorded by a previous delivery of the task, if any
+	HeartbeatDetails payload.Payload
 }
@@ -22,6 +22,10 @@ type Workflow struct {
 	// NewEvents are new events since the last task execution
 	NewEvents []*history.Event
 
+	// Attempt is the number of times a task has been handed out for the instance since its last completed task,
+	// including this one. It's greater than 1 if previous attempts failed or their lock expired.
+	Attempt int
+
 	// Backend specific data, only the producer of the task should rely on this.
 	CustomData any
 }
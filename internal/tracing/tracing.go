@@ -2,6 +2,7 @@ package tracing
 
 import (
 	"context"
+	"strings"
 
 	"github.com/cschleiden/go-workflows/internal/contextpropagation"
 	"github.com/cschleiden/go-workflows/internal/core"
@@ -53,3 +54,54 @@ func (*TracingContextPropagator) ExtractToWorkflow(ctx sync.Context, metadata *c
 
 	return workflowtracer.ContextWithSpan(ctx, span), nil
 }
+
+// SpanContextFromMetadata returns the span context propagated in the given metadata. The returned span context is
+// invalid if the metadata doesn't contain one.
+func SpanContextFromMetadata(metadata *core.WorkflowMetadata) trace.SpanContext {
+	if metadata == nil {
+		return trace.SpanContext{}
+	}
+
+	return trace.SpanContextFromContext(extractSpan(context.Background(), metadata))
+}
+
+// MetadataFromSpanContext returns metadata propagating the given span context
+func MetadataFromSpanContext(sc trace.SpanContext) *core.WorkflowMetadata {
+	metadata := &core.WorkflowMetadata{}
+	injectSpan(trace.ContextWithSpanContext(context.Background(), sc), metadata)
+
+	return metadata
+}
+
+// linkKeyPrefix prefixes the keys of a span context propagated as a link, keeping it apart from the propagated parent
+// span context
+const linkKeyPrefix = "link-"
+
+// InjectLink adds the given span context to the metadata, to be recorded as a link by the receiver of the metadata.
+// Invalid span contexts are ignored.
+func InjectLink(sc trace.SpanContext, metadata *core.WorkflowMetadata) {
+	if !sc.IsValid() {
+		return
+	}
+
+	for k, v := range *MetadataFromSpanContext(sc) {
+		metadata.Set(linkKeyPrefix+k, v)
+	}
+}
+
+// LinkFromMetadata returns the span context added to the given metadata with InjectLink. The returned span context is
+// invalid if the metadata doesn't contain one.
+func LinkFromMetadata(metadata *core.WorkflowMetadata) trace.SpanContext {
+	if metadata == nil {
+		return trace.SpanContext{}
+	}
+
+	link := &core.WorkflowMetadata{}
+	for k, v := range *metadata {
+		if strings.HasPrefix(k, linkKeyPrefix) {
+			link.Set(strings.TrimPrefix(k, linkKeyPrefix), v)
+		}
+	}
+
+	return SpanContextFromMetadata(link)
+}
@@ -0,0 +1,49 @@
+// Package webhook implements POSTing payloads to webhooks, shared by the packages publishing to them.
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Poster POSTs payloads to a webhook
+type Poster struct {
+	URL     string
+	Client  *http.Client
+	Headers http.Header
+}
+
+// New returns a poster for the given URL, sending JSON with the default client
+func New(url string) *Poster {
+	return &Poster{
+		URL:     url,
+		Client:  http.DefaultClient,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+	}
+}
+
+// Post sends the given body to the webhook. Responses with a status code other than 2xx are returned as errors.
+func (p *Poster) Post(ctx context.Context, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	for key, values := range p.Headers {
+		req.Header[key] = values
+	}
+
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling webhook: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/cschleiden/go-workflows/internal/task"
 	"github.com/cschleiden/go-workflows/internal/workflow"
 	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+	lg "github.com/cschleiden/go-workflows/log"
 	"github.com/cschleiden/go-workflows/metrics"
 )
 
@@ -27,32 +28,72 @@ type ActivityWorker struct {
 	activityTaskQueue    chan *task.Activity
 	activityTaskExecutor *activity.Executor
 
+	backpressure *backpressure
+
+	pollers *pollers
+
+	rateLimiter *rateLimiter
+
+	// abortCtx is canceled when running activities are aborted, see Abort
+	abortCtx context.Context
+	abort    context.CancelFunc
+
+	mu       sync.Mutex
+	inflight map[string]*task.Activity
+
 	wg        sync.WaitGroup
 	pollersWg sync.WaitGroup
 
 	clock clock.Clock
 }
 
-func NewActivityWorker(backend backend.Backend, registry *workflow.Registry, clock clock.Clock, options *Options) *ActivityWorker {
-	return &ActivityWorker{
-		backend: backend,
+func NewActivityWorker(b backend.Backend, registry *workflow.Registry, clock clock.Clock, options *Options) *ActivityWorker {
+	abortCtx, abort := context.WithCancel(context.Background())
+
+	aw := &ActivityWorker{
+		backend: b,
 
 		options: options,
 
 		activityTaskQueue:    make(chan *task.Activity),
-		activityTaskExecutor: activity.NewExecutor(backend.Logger(), backend.Tracer(), backend.Converter(), backend.ContextPropagators(), registry),
+		activityTaskExecutor: activity.NewExecutor(b.Logger(), b.Tracer(), b.Converter(), b.ContextPropagators(), registry),
+
+		backpressure: newBackpressure(b, clock, options),
+
+		rateLimiter: newRateLimiter(options.ActivityRateLimitPerSecond, clock),
+
+		abortCtx: abortCtx,
+		abort:    abort,
+		inflight: map[string]*task.Activity{},
 
 		clock: clock,
 	}
-}
 
-func (aw *ActivityWorker) Start(ctx context.Context) error {
-	aw.pollersWg.Add(aw.options.ActivityPollers)
+	aw.pollers = newPollers("activity", options.ActivityPollers, options.MaxActivityPollers, b, clock, options, &aw.pollersWg,
+		pendingActivityTasks, aw.runPoll)
+
+	aw.activityTaskExecutor.SetInterceptors(options.ActivityInterceptors)
 
-	for i := 0; i < aw.options.ActivityPollers; i++ {
-		go aw.runPoll(ctx)
+	// Persist heartbeats recorded by activities, so redelivered tasks carry the last heartbeat
+	if ah, ok := b.(backend.ActivityHeartbeater); ok {
+		aw.activityTaskExecutor.SetHeartbeatRecorder(func(ctx context.Context, task *task.Activity, details payload.Payload) error {
+			return ah.RecordActivityHeartbeat(ctx, task.ID, details)
+		})
 	}
 
+	// Evaluate queries of workflows on their sub-workflows
+	if q, ok := b.(backend.Querier); ok {
+		aw.activityTaskExecutor.SetQuerier(func(ctx context.Context, instanceID string, name string, args []payload.Payload) (payload.Payload, error) {
+			return aw.query(ctx, q, instanceID, name, args)
+		})
+	}
+
+	return aw
+}
+
+func (aw *ActivityWorker) Start(ctx context.Context) error {
+	aw.pollers.start(ctx)
+
 	go aw.runDispatcher(context.Background())
 
 	return nil
@@ -69,13 +110,35 @@ func (aw *ActivityWorker) WaitForCompletion() error {
 	return nil
 }
 
-func (aw *ActivityWorker) runPoll(ctx context.Context) {
-	defer aw.pollersWg.Done()
+// Abort cancels the contexts of all running activities and releases their tasks, so other workers can pick them up
+// right away. Results of aborted activities are discarded. Tasks handed to the worker afterwards are released without
+// executing them.
+func (aw *ActivityWorker) Abort() {
+	aw.mu.Lock()
+	aw.abort()
+
+	tasks := make([]*task.Activity, 0, len(aw.inflight))
+	for _, t := range aw.inflight {
+		tasks = append(tasks, t)
+	}
+	aw.mu.Unlock()
+
+	for _, t := range tasks {
+		aw.releaseTask(t)
+	}
+}
 
+func (aw *ActivityWorker) runPoll(ctx context.Context, poller int, stop <-chan struct{}) {
 	for {
+		aw.backpressure.wait(ctx, poller, aw.pollers.count())
+
 		select {
 		case <-ctx.Done():
 			return
+
+		case <-stop:
+			return
+
 		default:
 			task, err := aw.poll(ctx, 30*time.Second)
 			if err != nil {
@@ -83,7 +146,10 @@ func (aw *ActivityWorker) runPoll(ctx context.Context) {
 				continue
 			}
 
+			aw.pollers.observePoll(task != nil)
+
 			if task != nil {
+				aw.wg.Add(1)
 				aw.activityTaskQueue <- task
 			}
 		}
@@ -103,7 +169,6 @@ func (aw *ActivityWorker) runDispatcher(ctx context.Context) {
 
 		task := task
 
-		aw.wg.Add(1)
 		go func() {
 			defer aw.wg.Done()
 
@@ -119,6 +184,15 @@ func (aw *ActivityWorker) runDispatcher(ctx context.Context) {
 }
 
 func (aw *ActivityWorker) handleTask(ctx context.Context, task *task.Activity) {
+	if !aw.startTask(task) {
+		aw.releaseTask(task)
+		return
+	}
+	defer aw.finishTask(task)
+
+	// Context for executing the activity, canceled when the worker aborts running activities
+	execCtx := aw.abortCtx
+
 	a := task.Event.Attributes.(*history.ActivityScheduledAttributes)
 	ametrics := aw.backend.Metrics().WithTags(metrics.Tags{metrickeys.ActivityName: a.Name})
 
@@ -126,10 +200,45 @@ func (aw *ActivityWorker) handleTask(ctx context.Context, task *task.Activity) {
 	scheduledAt := task.Event.Timestamp
 	timeInQueue := time.Since(scheduledAt)
 	ametrics.Distribution(metrickeys.ActivityTaskDelay, metrics.Tags{}, float64(timeInQueue/time.Millisecond))
+	aw.pollers.observeLatency(timeInQueue)
+
+	// At-most-once activities must not be executed again, the previous delivery might have executed it already
+	if a.AtMostOnce && task.Redelivered {
+		aw.backend.Logger().Warn("at-most-once activity task delivered again, not executing",
+			lg.ActivityNameKey, a.Name, lg.ActivityIDKey, task.ID)
+
+		event := aw.resultToEvent(task.Event.ScheduleEventID, nil, nil, workflowerrors.NewPermanentError(workflowerrors.ErrActivityOutcomeUnknown))
+		if err := aw.backend.CompleteActivityTask(ctx, task.WorkflowInstance, task.ID, event); err != nil {
+			aw.backend.Logger().Panic("completing activity task", "error", err)
+		}
+
+		return
+	}
+
+	// Fail the attempt without executing the activity if it waited too long for a worker
+	if a.ScheduleToStartTimeout > 0 && timeInQueue > a.ScheduleToStartTimeout {
+		event := aw.resultToEvent(task.Event.ScheduleEventID, nil, nil, workflowerrors.NewTimeoutError(workflowerrors.TimeoutKindScheduleToStart))
+		if err := aw.backend.CompleteActivityTask(ctx, task.WorkflowInstance, task.ID, event); err != nil {
+			aw.backend.Logger().Panic("completing activity task", "error", err)
+		}
+
+		return
+	}
+
+	// Fail the attempt without executing the activity if a previous delivery stopped heartbeating, for example, because
+	// its worker crashed. The details of the last heartbeat are handed to the next attempt.
+	if a.HeartbeatTimeout > 0 && !task.LastHeartbeat.IsZero() && aw.clock.Since(task.LastHeartbeat) > a.HeartbeatTimeout {
+		event := aw.resultToEvent(task.Event.ScheduleEventID, nil, task.HeartbeatDetails, workflowerrors.NewTimeoutError(workflowerrors.TimeoutKindHeartbeat))
+		if err := aw.backend.CompleteActivityTask(ctx, task.WorkflowInstance, task.ID, event); err != nil {
+			aw.backend.Logger().Panic("completing activity task", "error", err)
+		}
+
+		return
+	}
 
 	// Start heartbeat while activity is running
 	if aw.options.ActivityHeartbeatInterval > 0 {
-		heartbeatCtx, cancelHeartbeat := context.WithCancel(ctx)
+		heartbeatCtx, cancelHeartbeat := context.WithCancel(execCtx)
 		defer cancelHeartbeat()
 
 		go func(ctx context.Context) {
@@ -149,24 +258,144 @@ func (aw *ActivityWorker) handleTask(ctx context.Context, task *task.Activity) {
 		}(heartbeatCtx)
 	}
 
+	// Wait until the rate limit of the activity, shared by all workers of the backend, allows it to execute
+	if rl, ok := aw.backend.(backend.RateLimiter); ok {
+		if !aw.acquireRateLimit(ctx, execCtx, rl, task, a.Name) {
+			return
+		}
+	}
+
+	// Wait until the rate limit of this worker allows the activity to execute. Waiting only fails when the activity
+	// is aborted.
+	if aw.rateLimiter != nil {
+		if err := aw.rateLimiter.wait(execCtx); err != nil {
+			return
+		}
+	}
+
 	timer := metrics.Timer(ametrics, metrickeys.ActivityTaskProcessed, metrics.Tags{})
 	defer timer.Stop()
 
-	result, err := aw.activityTaskExecutor.ExecuteActivity(ctx, task)
-	event := aw.resultToEvent(task.Event.ScheduleEventID, result, err)
+	executionStart := time.Now()
+	result, checkpoint, err := aw.activityTaskExecutor.ExecuteActivity(execCtx, task)
+	ametrics.Timing(metrickeys.ActivityExecutionDuration, metrics.Tags{
+		metrickeys.Outcome: activityOutcome(err),
+	}, time.Since(executionStart))
+
+	// The task has been released when the activity was aborted, another worker executes it again
+	if execCtx.Err() != nil {
+		return
+	}
+
+	if errors.Is(err, activity.ErrResultPending) {
+		if ac, ok := aw.backend.(backend.AsyncActivityCompleter); ok {
+			if err := ac.SetActivityTaskPending(ctx, task.WorkflowInstance, task.ID); err != nil {
+				aw.backend.Logger().Panic("setting activity task pending", "error", err)
+			}
+
+			return
+		}
+
+		err = workflowerrors.NewPermanentError(backend.ErrAsyncActivitiesNotSupported)
+	}
+
+	event := aw.resultToEvent(task.Event.ScheduleEventID, result, checkpoint, err)
 
 	if err := aw.backend.CompleteActivityTask(ctx, task.WorkflowInstance, task.ID, event); err != nil {
 		aw.backend.Logger().Panic("completing activity task", "error", err)
 	}
 }
 
-func (aw *ActivityWorker) resultToEvent(ScheduleEventID int64, result payload.Payload, err error) *history.Event {
+// acquireRateLimit waits until the rate limit of the given activity allows executing it. Errors acquiring the rate
+// limit are retried, if they persist, the task is released, so that it's delivered again. It returns false if the
+// activity must not be executed.
+func (aw *ActivityWorker) acquireRateLimit(
+	ctx, execCtx context.Context, rl backend.RateLimiter, t *task.Activity, activityName string,
+) bool {
+	for {
+		var wait time.Duration
+		err := retry(execCtx, rateLimitRetryPolicy, func() error {
+			var err error
+			wait, err = rl.AcquireActivityRateLimit(ctx, activityName)
+			return err
+		}, func(attempt int, err error) {
+			aw.backend.Logger().Error("could not acquire activity rate limit, retrying",
+				lg.ActivityNameKey, activityName, lg.ActivityIDKey, t.ID, "error", err, "attempt", attempt+1)
+		})
+		if err != nil {
+			// Aborted tasks have been released already
+			if execCtx.Err() == nil {
+				aw.backend.Logger().Error("could not acquire activity rate limit, releasing task",
+					lg.ActivityNameKey, activityName, lg.ActivityIDKey, t.ID, "error", err)
+				aw.releaseTask(t)
+			}
+
+			return false
+		}
+
+		if wait <= 0 {
+			return true
+		}
+
+		select {
+		case <-execCtx.Done():
+			return false
+		case <-aw.clock.After(wait):
+		}
+	}
+}
+
+// startTask tracks the task as running, it returns false if running activities have been aborted
+func (aw *ActivityWorker) startTask(t *task.Activity) bool {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	if aw.abortCtx.Err() != nil {
+		return false
+	}
+
+	aw.inflight[t.ID] = t
+	return true
+}
+
+func (aw *ActivityWorker) finishTask(t *task.Activity) {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	delete(aw.inflight, t.ID)
+}
+
+func (aw *ActivityWorker) releaseTask(t *task.Activity) {
+	ar, ok := aw.backend.(backend.ActivityTaskReleaser)
+	if !ok {
+		// The lock of the task expires instead
+		return
+	}
+
+	if err := ar.ReleaseActivityTask(context.Background(), t.ID); err != nil {
+		aw.backend.Logger().Warn("could not release activity task", lg.ActivityIDKey, t.ID, "error", err)
+	}
+}
+
+func activityOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "completed"
+	case errors.Is(err, activity.ErrResultPending):
+		return "pending"
+	default:
+		return "failed"
+	}
+}
+
+func (aw *ActivityWorker) resultToEvent(ScheduleEventID int64, result, checkpoint payload.Payload, err error) *history.Event {
 	if err != nil {
 		return history.NewPendingEvent(
 			aw.clock.Now(),
 			history.EventType_ActivityFailed,
 			&history.ActivityFailedAttributes{
-				Error: workflowerrors.FromError(err),
+				Error:      workflowerrors.FromError(err),
+				Checkpoint: checkpoint,
 			},
 			history.ScheduleEventID(ScheduleEventID),
 		)
@@ -189,7 +418,13 @@ func (aw *ActivityWorker) poll(ctx context.Context, timeout time.Duration) (*tas
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	task, err := aw.backend.GetActivityTask(ctx)
+	var task *task.Activity
+	var err error
+	if qp, ok := aw.backend.(backend.QueuePoller); ok && !defaultQueueOnly(aw.options.Queues) {
+		task, err = qp.GetActivityTaskFromQueues(ctx, aw.options.Queues)
+	} else {
+		task, err = aw.backend.GetActivityTask(ctx)
+	}
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, nil
@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+// backpressure slows down pollers while the load reported by the backend is above the configured threshold. Between
+// the threshold and full load, pollers wait increasingly long before polling and fewer of them are active, down to a
+// single poller at full load.
+type backpressure struct {
+	backend  backend.Backend
+	reporter backend.LoadReporter
+
+	options *Options
+	clock   clock.Clock
+
+	mu        sync.Mutex
+	load      float64
+	checkedAt time.Time
+}
+
+func newBackpressure(b backend.Backend, clock clock.Clock, options *Options) *backpressure {
+	reporter, _ := b.(backend.LoadReporter)
+	if options.BackpressureLoadThreshold >= 1 {
+		// Disabled
+		reporter = nil
+	}
+
+	return &backpressure{
+		backend:  b,
+		reporter: reporter,
+		options:  options,
+		clock:    clock,
+	}
+}
+
+// wait blocks the poller with the given index, out of the given number of pollers, according to the current load.
+// It returns early when the context is canceled.
+func (bp *backpressure) wait(ctx context.Context, poller, pollers int) {
+	if bp.reporter == nil {
+		return
+	}
+
+	pressure := bp.pressure(ctx)
+	if pressure <= 0 {
+		return
+	}
+
+	var d time.Duration
+	if active := int(math.Max(1, math.Ceil(float64(pollers)*(1-pressure)))); poller >= active {
+		// Poller is inactive for now, check again after the load has been updated
+		d = bp.options.BackpressureCheckInterval
+	} else {
+		d = time.Duration(pressure * float64(bp.options.BackpressureMaxPollDelay))
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-bp.clock.After(d):
+	}
+}
+
+// pressure returns how far the load exceeds the threshold, between 0 and 1
+func (bp *backpressure) pressure(ctx context.Context) float64 {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	if now := bp.clock.Now(); now.Sub(bp.checkedAt) >= bp.options.BackpressureCheckInterval {
+		bp.checkedAt = now
+
+		load, err := bp.reporter.Load(ctx)
+		if err != nil && ctx.Err() == nil {
+			bp.backend.Logger().Warn("could not get backend load", "error", err)
+		}
+
+		if load >= bp.options.BackpressureLoadThreshold && bp.load < bp.options.BackpressureLoadThreshold {
+			bp.backend.Logger().Warn("backend load is high, slowing down polling", "load", load)
+		}
+
+		bp.load = load
+	}
+
+	threshold := bp.options.BackpressureLoadThreshold
+	if bp.load <= threshold {
+		return 0
+	}
+
+	return math.Min(1, (bp.load-threshold)/(1-threshold))
+}
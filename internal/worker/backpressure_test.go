@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/stretchr/testify/require"
+)
+
+type loadBackend struct {
+	backend.Backend
+
+	load float64
+}
+
+func (b *loadBackend) Load(ctx context.Context) (float64, error) {
+	return b.load, nil
+}
+
+func Test_backpressure(t *testing.T) {
+	options := DefaultOptions
+
+	tests := []struct {
+		name         string
+		load         float64
+		wantPressure float64
+	}{
+		{name: "below threshold", load: 0.5, wantPressure: 0},
+		{name: "above threshold", load: 0.9, wantPressure: 0.5},
+		{name: "full load", load: 1, wantPressure: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp := newBackpressure(&loadBackend{Backend: sqlite.NewInMemoryBackend(), load: tt.load}, clock.NewMock(), &options)
+
+			require.InDelta(t, tt.wantPressure, bp.pressure(context.Background()), 0.0001)
+		})
+	}
+}
+
+func Test_backpressure_wait(t *testing.T) {
+	options := DefaultOptions
+
+	c := clock.NewMock()
+	b := &loadBackend{Backend: sqlite.NewInMemoryBackend(), load: 1}
+	bp := newBackpressure(b, c, &options)
+
+	// At full load only the first poller is active, and waits for the max poll delay
+	done := make(chan struct{})
+	go func() {
+		bp.wait(context.Background(), 0, 2)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		c.Add(time.Second)
+
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+	require.GreaterOrEqual(t, c.Now().Sub(time.Unix(0, 0)), options.BackpressureMaxPollDelay)
+
+	// Pollers don't wait once the load recovers
+	b.load = 0
+	c.Add(options.BackpressureCheckInterval)
+	bp.wait(context.Background(), 1, 2)
+}
+
+func Test_backpressure_Disabled(t *testing.T) {
+	options := DefaultOptions
+	options.BackpressureLoadThreshold = 1
+
+	bp := newBackpressure(&loadBackend{Backend: sqlite.NewInMemoryBackend(), load: 1}, clock.NewMock(), &options)
+
+	// Returns immediately, the mock clock never advances
+	bp.wait(context.Background(), 0, 1)
+}
@@ -3,13 +3,24 @@ package worker
 import (
 	"time"
 
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/interceptor"
 	"github.com/cschleiden/go-workflows/internal/workflow"
+	"github.com/cschleiden/go-workflows/maintenance"
 )
 
 type Options struct {
 	// WorkflowsPollers is the number of pollers to start. Defaults to 2.
 	WorkflowPollers int
 
+	// DisableWorkflowTasks stops the worker from polling for workflow tasks, it then only executes activities.
+	DisableWorkflowTasks bool
+
+	// MaxWorkflowPollers enables autoscaling of the workflow pollers when larger than WorkflowPollers. The worker then
+	// runs between WorkflowPollers and MaxWorkflowPollers pollers, as decided by PollerScaler. The default is 0 which
+	// disables autoscaling.
+	MaxWorkflowPollers int
+
 	// MaxParallelWorkflowTasks determines the maximum number of concurrent workflow tasks processed
 	// by the worker. The default is 0 which is no limit.
 	MaxParallelWorkflowTasks int
@@ -17,10 +28,52 @@ type Options struct {
 	// ActivityPollers is the number of pollers to start. Defaults to 2.
 	ActivityPollers int
 
+	// DisableActivityTasks stops the worker from polling for activity tasks, it then only executes workflows.
+	DisableActivityTasks bool
+
+	// Queues are the queues the worker polls for workflow and activity tasks. Defaults to only the default queue. To
+	// keep receiving tasks without an explicit queue, include core.QueueDefault.
+	Queues []core.Queue
+
+	// MaxActivityPollers enables autoscaling of the activity pollers when larger than ActivityPollers. The worker then
+	// runs between ActivityPollers and MaxActivityPollers pollers, as decided by PollerScaler. The default is 0 which
+	// disables autoscaling.
+	MaxActivityPollers int
+
+	// PollerScaler decides how many workflow and activity pollers to run when autoscaling is enabled. Defaults to a
+	// DefaultPollerScaler adding pollers when tasks wait longer than one second in the queue.
+	PollerScaler PollerScaler
+
+	// PollerScaleInterval is the interval in which the number of pollers is adjusted when autoscaling is enabled.
+	// Defaults to 10 seconds.
+	PollerScaleInterval time.Duration
+
 	// MaxParallelActivityTasks determines the maximum number of concurrent activity tasks processed
 	// by the worker. The default is 0 which is no limit.
 	MaxParallelActivityTasks int
 
+	// ActivityRateLimitPerSecond limits the number of activity tasks the worker starts executing per second. Unlike
+	// the rate limits configured on the backend, it only applies to this worker and to all activities. The default is
+	// 0 which is no limit.
+	ActivityRateLimitPerSecond float64
+
+	// BackpressureLoadThreshold is the load reported by backends implementing backend.LoadReporter above which
+	// pollers slow down. Between the threshold and full load, pollers wait increasingly long before polling for new
+	// tasks and fewer of them are active, down to a single poller at full load. Defaults to 0.8, set to 1 to
+	// disable.
+	BackpressureLoadThreshold float64
+
+	// BackpressureCheckInterval is the interval in which the load of the backend is checked. Defaults to 5 seconds.
+	BackpressureCheckInterval time.Duration
+
+	// BackpressureMaxPollDelay is the time pollers wait before polling at full load. Defaults to 5 seconds.
+	BackpressureMaxPollDelay time.Duration
+
+	// ShutdownTimeout limits how long Shutdown waits for running tasks to finish, in addition to the context passed to
+	// Shutdown. Activities still running afterwards are canceled and their tasks released. The default is 0 which
+	// only uses the context.
+	ShutdownTimeout time.Duration
+
 	// ActivityHeartbeatInterval is the interval between heartbeat attempts for activity tasks. Defaults
 	// to 25 seconds
 	ActivityHeartbeatInterval time.Duration
@@ -42,6 +95,57 @@ type Options struct {
 	// WorkflowExecutorCache is the cache to use for workflow executors. If nil, a default cache implementation
 	// will be used.
 	WorkflowExecutorCache workflow.ExecutorCache
+
+	// WorkflowTaskCompletionRetryPolicy determines how often completing a workflow task is retried when the
+	// backend returns an error, for example, due to a transient connection issue. The completion is retried with
+	// the same result. Errors which retrying won't resolve, like backend.ErrWorkflowTaskLost, are not retried. If all
+	// attempts fail, the task is abandoned and delivered again once its lock expires. Defaults to 5 attempts with
+	// exponential backoff.
+	WorkflowTaskCompletionRetryPolicy RetryPolicy
+
+	// MaxWorkflowTaskAttempts is the number of times a workflow task for an instance is attempted before the instance
+	// is moved to the dead-letter state. Workflow tasks fail, for example, when replaying the history detects
+	// non-deterministic workflow code or the workflow isn't registered with the worker. Failed attempts are retried
+	// once the lock of the task expires. Dead-lettered instances can be retried or terminated with the client.
+	// Defaults to 0, which stops the worker process on the first failed attempt.
+	MaxWorkflowTaskAttempts int
+
+	// DeterminismGuard enables the runtime determinism guard for workflow executions. When enabled, workflow tasks
+	// fail if workflow code blocks on native channels, select statements, or time.Sleep, or starts goroutines with
+	// the go statement. The guard inspects goroutine stacks and is expensive, it's intended for development and
+	// testing. Reading wall-clock time can't be detected this way, only via clocks created with
+	// workflow.NewGuardedClock.
+	DeterminismGuard bool
+
+	// ContinueAsNewHistoryEvents is the number of history events after which workflow.ShouldContinueAsNew returns
+	// true for a workflow execution. Defaults to 0, which disables the threshold.
+	ContinueAsNewHistoryEvents int
+
+	// ContinueAsNewHistoryBytes is the size of the serialized event attributes of a workflow execution's history after
+	// which workflow.ShouldContinueAsNew returns true. Defaults to 0, which disables the threshold.
+	ContinueAsNewHistoryBytes int
+
+	// WorkflowInterceptors wrap the execution of workflow tasks, the delivery of signals, and the scheduling of
+	// sub-workflows. The first interceptor is the outermost one.
+	WorkflowInterceptors []interceptor.WorkflowInterceptor
+
+	// ActivityInterceptors wrap the execution of activities. The first interceptor is the outermost one.
+	ActivityInterceptors []interceptor.ActivityInterceptor
+
+	// MaintenanceJobs are executed periodically by a maintenance runner started with the worker. When multiple workers
+	// share the same backend storage, only one of them executes the jobs at a time. See the maintenance package.
+	MaintenanceJobs []maintenance.Job
+
+	// ScheduleCheckInterval is the interval in which the worker starts workflow instances for due schedules, for
+	// backends implementing backend.ScheduleStore. Schedules are checked by the maintenance runner, only one worker
+	// sharing the same backend storage starts instances at a time. Defaults to 1 second, set to a negative value to
+	// disable.
+	ScheduleCheckInterval time.Duration
+
+	// RetentionCheckInterval is the interval in which the worker removes expired workflow instances, for backends
+	// configured with backend.WithRetention. Only one worker sharing the same backend storage removes instances at a
+	// time. Defaults to 1 minute, set to a negative value to disable.
+	RetentionCheckInterval time.Duration
 }
 
 var DefaultOptions = Options{
@@ -52,7 +156,35 @@ var DefaultOptions = Options{
 	ActivityHeartbeatInterval: 25 * time.Second,
 	WorkflowHeartbeatInterval: 25 * time.Second,
 
+	PollerScaler:        &DefaultPollerScaler{TaskLatencyThreshold: time.Second},
+	PollerScaleInterval: 10 * time.Second,
+
+	BackpressureLoadThreshold: 0.8,
+	BackpressureCheckInterval: 5 * time.Second,
+	BackpressureMaxPollDelay:  5 * time.Second,
+
 	WorkflowExecutorCacheSize: 128,
 	WorkflowExecutorCacheTTL:  time.Second * 10,
 	WorkflowExecutorCache:     nil,
+
+	ScheduleCheckInterval:  time.Second,
+	RetentionCheckInterval: time.Minute,
+
+	WorkflowTaskCompletionRetryPolicy: RetryPolicy{
+		MaxAttempts:        5,
+		FirstRetryInterval: time.Millisecond * 100,
+		MaxRetryInterval:   time.Second * 5,
+		BackoffCoefficient: 2,
+	},
+}
+
+// defaultQueueOnly returns true if a worker polling the given queues only receives tasks of the default queue
+func defaultQueueOnly(queues []core.Queue) bool {
+	for _, q := range queues {
+		if q.OrDefault() != core.QueueDefault {
+			return false
+		}
+	}
+
+	return true
 }
@@ -0,0 +1,255 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+// PollerStats are the observations of the workflow or activity pollers of a worker since the last scaling decision
+type PollerStats struct {
+	// Kind is either "workflow" or "activity"
+	Kind string
+
+	// Pollers is the number of currently running pollers
+	Pollers int
+
+	// MinPollers and MaxPollers are the configured bounds for the number of pollers
+	MinPollers int
+	MaxPollers int
+
+	// Polls is the number of completed polls, Tasks is the number of polls that returned a task
+	Polls int
+	Tasks int
+
+	// TaskLatency is the average time the polled tasks waited in the queue before they were picked up
+	TaskLatency time.Duration
+
+	// QueueDepth is the number of tasks waiting for a worker, as reported by the stats of the backend
+	QueueDepth int64
+}
+
+// PollerScaler decides how many pollers a worker runs when poller autoscaling is enabled
+type PollerScaler interface {
+	// Pollers returns the number of pollers to run until the next decision. The result is clamped to the configured
+	// bounds.
+	Pollers(ctx context.Context, stats *PollerStats) int
+}
+
+type PollerScalerFunc func(ctx context.Context, stats *PollerStats) int
+
+func (f PollerScalerFunc) Pollers(ctx context.Context, stats *PollerStats) int {
+	return f(ctx, stats)
+}
+
+// DefaultPollerScaler adds a poller when tasks wait in the queue longer than TaskLatencyThreshold or more tasks are
+// waiting than there are pollers, and removes one when the queue is empty and most polls didn't return a task.
+type DefaultPollerScaler struct {
+	TaskLatencyThreshold time.Duration
+}
+
+var _ PollerScaler = (*DefaultPollerScaler)(nil)
+
+func (s *DefaultPollerScaler) Pollers(_ context.Context, stats *PollerStats) int {
+	switch {
+	case stats.QueueDepth > int64(stats.Pollers) || stats.TaskLatency > s.TaskLatencyThreshold:
+		return stats.Pollers + 1
+
+	case stats.QueueDepth == 0 && stats.Tasks*2 < stats.Polls:
+		return stats.Pollers - 1
+	}
+
+	return stats.Pollers
+}
+
+// pollers runs a number of poller goroutines between min and max. When max is larger than min, the number is adjusted
+// periodically by the scaler.
+type pollers struct {
+	kind     string
+	min, max int
+	scaler   PollerScaler
+	interval time.Duration
+
+	backend backend.Backend
+	clock   clock.Clock
+
+	// queueDepth returns the number of tasks waiting for this kind of poller
+	queueDepth func(s *backend.Stats) int64
+
+	// run runs a single poller until the context is canceled or stop is closed
+	run func(ctx context.Context, poller int, stop <-chan struct{})
+
+	wg *sync.WaitGroup
+
+	mu      sync.Mutex
+	stops   []chan struct{}
+	polls   int
+	tasks   int
+	latency time.Duration
+	picked  int
+}
+
+func newPollers(kind string, min, max int, b backend.Backend, clock clock.Clock, options *Options, wg *sync.WaitGroup,
+	queueDepth func(s *backend.Stats) int64, run func(ctx context.Context, poller int, stop <-chan struct{})) *pollers {
+	if max < min {
+		max = min
+	}
+
+	scaler := options.PollerScaler
+	if scaler == nil {
+		scaler = DefaultOptions.PollerScaler
+	}
+
+	interval := options.PollerScaleInterval
+	if interval <= 0 {
+		interval = DefaultOptions.PollerScaleInterval
+	}
+
+	return &pollers{
+		kind:       kind,
+		min:        min,
+		max:        max,
+		scaler:     scaler,
+		interval:   interval,
+		backend:    b,
+		clock:      clock,
+		queueDepth: queueDepth,
+		run:        run,
+		wg:         wg,
+	}
+}
+
+func pendingWorkflowTasks(s *backend.Stats) int64 {
+	return s.PendingWorkflowTasks
+}
+
+func pendingActivityTasks(s *backend.Stats) int64 {
+	return s.PendingActivityTasks
+}
+
+func (p *pollers) start(ctx context.Context) {
+	p.scale(ctx, p.min)
+
+	if p.max > p.min {
+		p.wg.Add(1)
+		go p.runScaler(ctx)
+	}
+}
+
+// count returns the number of running pollers
+func (p *pollers) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.stops)
+}
+
+// observePoll records the outcome of a poll
+func (p *pollers) observePoll(gotTask bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.polls++
+	if gotTask {
+		p.tasks++
+	}
+}
+
+// observeLatency records how long a polled task waited in the queue
+func (p *pollers) observeLatency(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.latency += d
+	p.picked++
+}
+
+// scale starts or stops pollers until n are running. Stopped pollers finish their current poll first.
+func (p *pollers) scale(ctx context.Context, n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.stops) < n {
+		stop := make(chan struct{})
+		poller := len(p.stops)
+		p.stops = append(p.stops, stop)
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+
+			p.run(ctx, poller, stop)
+		}()
+	}
+
+	for len(p.stops) > n {
+		close(p.stops[len(p.stops)-1])
+		p.stops = p.stops[:len(p.stops)-1]
+	}
+}
+
+func (p *pollers) runScaler(ctx context.Context) {
+	defer p.wg.Done()
+
+	t := p.clock.Ticker(p.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-t.C:
+			stats := p.collect(ctx)
+
+			n := p.scaler.Pollers(ctx, stats)
+			if n < p.min {
+				n = p.min
+			} else if n > p.max {
+				n = p.max
+			}
+
+			if n != stats.Pollers {
+				p.backend.Logger().Debug("scaling pollers", "kind", p.kind, "from", stats.Pollers, "to", n)
+			}
+
+			p.scale(ctx, n)
+		}
+	}
+}
+
+// collect returns the observations since the last call, and resets them
+func (p *pollers) collect(ctx context.Context) *PollerStats {
+	var queueDepth int64
+	if s, err := p.backend.GetStats(ctx); err != nil {
+		if ctx.Err() == nil {
+			p.backend.Logger().Warn("could not get backend stats for scaling pollers", "error", err)
+		}
+	} else {
+		queueDepth = p.queueDepth(s)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := &PollerStats{
+		Kind:       p.kind,
+		Pollers:    len(p.stops),
+		MinPollers: p.min,
+		MaxPollers: p.max,
+		Polls:      p.polls,
+		Tasks:      p.tasks,
+		QueueDepth: queueDepth,
+	}
+
+	if p.picked > 0 {
+		stats.TaskLatency = p.latency / time.Duration(p.picked)
+	}
+
+	p.polls, p.tasks, p.latency, p.picked = 0, 0, 0, 0
+
+	return stats
+}
@@ -0,0 +1,127 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DefaultPollerScaler(t *testing.T) {
+	s := &DefaultPollerScaler{TaskLatencyThreshold: time.Second}
+
+	tests := []struct {
+		name  string
+		stats PollerStats
+		want  int
+	}{
+		{name: "queue deeper than pollers", stats: PollerStats{Pollers: 2, QueueDepth: 5, Polls: 10, Tasks: 10}, want: 3},
+		{name: "high latency", stats: PollerStats{Pollers: 2, TaskLatency: 2 * time.Second, Polls: 10, Tasks: 10}, want: 3},
+		{name: "idle", stats: PollerStats{Pollers: 2, Polls: 10, Tasks: 1}, want: 1},
+		{name: "busy", stats: PollerStats{Pollers: 2, QueueDepth: 1, Polls: 10, Tasks: 10}, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, s.Pollers(context.Background(), &tt.stats))
+		})
+	}
+}
+
+func Test_pollers_Scale(t *testing.T) {
+	options := DefaultOptions
+	options.PollerScaleInterval = time.Second
+
+	var target int
+	var mu sync.Mutex
+	var got *PollerStats
+	options.PollerScaler = PollerScalerFunc(func(ctx context.Context, stats *PollerStats) int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		got = stats
+		return target
+	})
+
+	c := clock.NewMock()
+	wg := &sync.WaitGroup{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var running sync.Map
+	p := newPollers("activity", 1, 3, sqlite.NewInMemoryBackend(), c, &options, wg, pendingActivityTasks,
+		func(ctx context.Context, poller int, stop <-chan struct{}) {
+			running.Store(poller, true)
+			defer running.Delete(poller)
+
+			select {
+			case <-ctx.Done():
+			case <-stop:
+			}
+		})
+
+	p.start(ctx)
+	require.Equal(t, 1, p.count())
+
+	p.observePoll(true)
+	p.observeLatency(2 * time.Second)
+
+	scaleTo := func(n int) {
+		mu.Lock()
+		target = n
+		mu.Unlock()
+
+		require.Eventually(t, func() bool {
+			c.Add(options.PollerScaleInterval)
+
+			return p.count() == n
+		}, time.Second, time.Millisecond)
+	}
+
+	// Results are clamped to the bounds
+	scaleTo(3)
+
+	mu.Lock()
+	require.Equal(t, "activity", got.Kind)
+	require.Equal(t, 1, got.Polls)
+	require.Equal(t, 1, got.Tasks)
+	require.Equal(t, 2*time.Second, got.TaskLatency)
+	require.Equal(t, 1, got.MinPollers)
+	require.Equal(t, 3, got.MaxPollers)
+	target = 10
+	mu.Unlock()
+
+	c.Add(options.PollerScaleInterval)
+	require.Equal(t, 3, p.count())
+
+	scaleTo(1)
+
+	require.Eventually(t, func() bool {
+		_, ok := running.Load(2)
+		return !ok
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	wg.Wait()
+}
+
+func Test_pollers_Disabled(t *testing.T) {
+	options := DefaultOptions
+
+	wg := &sync.WaitGroup{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := newPollers("workflow", 2, 0, sqlite.NewInMemoryBackend(), clock.NewMock(), &options, wg, pendingWorkflowTasks,
+		func(ctx context.Context, poller int, stop <-chan struct{}) {
+			<-ctx.Done()
+		})
+
+	p.start(ctx)
+	require.Equal(t, 2, p.count())
+
+	cancel()
+	wg.Wait()
+}
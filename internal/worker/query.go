@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/workflow"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+	"github.com/cschleiden/go-workflows/log"
+)
+
+// queryPollInterval is the time to wait before polling again when there was no query
+const queryPollInterval = 100 * time.Millisecond
+
+// runQueryPoll polls for and answers queries, one at a time
+func (ww *WorkflowWorker) runQueryPoll(ctx context.Context, q backend.Querier) {
+	defer ww.pollersWg.Done()
+
+	t := time.NewTimer(0)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-t.C:
+		}
+
+		qt, err := q.GetQueryTask(ctx)
+		if err != nil {
+			if errors.Is(err, backend.ErrQueriesNotSupported) {
+				// Backend is wrapped by a decorator but doesn't support queries itself
+				return
+			}
+
+			if !errors.Is(err, context.Canceled) {
+				ww.logger.Error("error while polling for query", log.ErrorKey, err)
+			}
+		}
+
+		if qt == nil {
+			t.Reset(queryPollInterval)
+			continue
+		}
+
+		result := ww.handleQuery(ctx, qt)
+
+		if err := q.CompleteQueryTask(ctx, qt.ID, result); err != nil {
+			ww.logger.Error("could not complete query", log.ErrorKey, err, log.InstanceIDKey, qt.InstanceID)
+		}
+
+		// Look for the next query right away
+		t.Reset(0)
+	}
+}
+
+func (ww *WorkflowWorker) handleQuery(ctx context.Context, qt *backend.QueryTask) *backend.QueryResult {
+	// Queries are evaluated by a new executor, cached executors might be processing workflow tasks concurrently
+	h, err := ww.backend.GetWorkflowInstanceHistory(ctx, qt.Instance, nil)
+	if err != nil {
+		return &backend.QueryResult{
+			Error: workflowerrors.FromError(fmt.Errorf("reading workflow instance history: %w", err)),
+		}
+	}
+
+	r, err := workflow.ExecuteQuery(
+		ctx, ww.backend.Logger(), ww.backend.Tracer(), ww.registry, ww.backend.Converter(), ww.backend.ContextPropagators(),
+		qt.Instance, h, qt.Name, qt.Args)
+	if err != nil {
+		return &backend.QueryResult{Error: workflowerrors.FromError(err)}
+	}
+
+	return &backend.QueryResult{Result: r}
+}
+
+func (aw *ActivityWorker) query(ctx context.Context, q backend.Querier, instanceID string, name string, args []payload.Payload) (payload.Payload, error) {
+	deadline := aw.clock.Now().Add(backend.DefaultQueryTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	r, err := backend.RunQuery(ctx, q, aw.clock, &backend.Query{
+		ID:         backend.NewID(aw.backend),
+		InstanceID: instanceID,
+		Name:       name,
+		Args:       args,
+		Deadline:   deadline,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Error != nil {
+		return nil, workflowerrors.ToError(r.Error)
+	}
+
+	return r.Result, nil
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+// rateLimitRetryPolicy determines how often acquiring a rate limit from the backend is retried before the task is
+// released
+var rateLimitRetryPolicy = RetryPolicy{
+	MaxAttempts:        5,
+	FirstRetryInterval: time.Millisecond * 100,
+	MaxRetryInterval:   time.Second * 5,
+	BackoffCoefficient: 2,
+}
+
+// rateLimiter limits the rate of operations within a single worker. It's a token bucket holding tokens for up to one
+// second of operations, at least one.
+type rateLimiter struct {
+	clock clock.Clock
+
+	mu        sync.Mutex
+	limit     backend.RateLimit
+	tokens    float64
+	updatedAt time.Time
+}
+
+// newRateLimiter returns a rate limiter allowing the given number of operations per second, or nil if perSecond is
+// not positive
+func newRateLimiter(perSecond float64, clock clock.Clock) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	burst := math.Max(1, math.Ceil(perSecond))
+
+	return &rateLimiter{
+		clock: clock,
+		limit: backend.RateLimit{
+			Limit:    int(burst),
+			Interval: time.Duration(burst / perSecond * float64(time.Second)),
+		},
+		tokens:    burst,
+		updatedAt: clock.Now(),
+	}
+}
+
+// wait blocks until a token could be taken or the context is canceled
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	for {
+		rl.mu.Lock()
+		now := rl.clock.Now()
+		var wait time.Duration
+		rl.tokens, wait = rl.limit.Take(rl.tokens, rl.updatedAt, now)
+		rl.updatedAt = now
+		rl.mu.Unlock()
+
+		if wait <= 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-rl.clock.After(wait):
+		}
+	}
+}
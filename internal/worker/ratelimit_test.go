@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/internal/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_rateLimiter(t *testing.T) {
+	c := clock.NewMock()
+	rl := newRateLimiter(2, c)
+
+	// Bucket starts out full
+	require.NoError(t, rl.wait(context.Background()))
+	require.NoError(t, rl.wait(context.Background()))
+
+	done := make(chan struct{})
+	go func() {
+		require.NoError(t, rl.wait(context.Background()))
+		close(done)
+	}()
+
+	// Wait for the goroutine to block on the clock
+	time.Sleep(10 * time.Millisecond)
+
+	c.Add(400 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("token taken before it was available")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.Add(100 * time.Millisecond)
+	require.Eventually(t, func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+}
+
+func Test_rateLimiter_Disabled(t *testing.T) {
+	require.Nil(t, newRateLimiter(0, clock.NewMock()))
+}
+
+func Test_rateLimiter_Canceled(t *testing.T) {
+	rl := newRateLimiter(0.5, clock.NewMock())
+	require.NoError(t, rl.wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.ErrorIs(t, rl.wait(ctx), context.Canceled)
+}
+
+type rateLimitBackend struct {
+	backend.Backend
+
+	// failures is the number of calls failing before the rate limit is acquired
+	failures int
+
+	acquireCalls int
+	completed    bool
+	released     bool
+}
+
+func (b *rateLimitBackend) AcquireActivityRateLimit(ctx context.Context, activityName string) (time.Duration, error) {
+	b.acquireCalls++
+	if b.acquireCalls <= b.failures {
+		return 0, errors.New("connection reset")
+	}
+
+	return 0, nil
+}
+
+func (b *rateLimitBackend) AcquireRateLimit(ctx context.Context, name string, limit backend.RateLimit) (time.Duration, error) {
+	return 0, nil
+}
+
+func (b *rateLimitBackend) CompleteActivityTask(ctx context.Context, instance *core.WorkflowInstance, activityID string, event *history.Event) error {
+	b.completed = true
+	return nil
+}
+
+func (b *rateLimitBackend) ReleaseActivityTask(ctx context.Context, activityID string) error {
+	b.released = true
+	return nil
+}
+
+func Test_ActivityWorker_RateLimitErrors(t *testing.T) {
+	prev := rateLimitRetryPolicy
+	rateLimitRetryPolicy = RetryPolicy{MaxAttempts: 3, FirstRetryInterval: time.Millisecond, BackoffCoefficient: 1}
+	t.Cleanup(func() { rateLimitRetryPolicy = prev })
+
+	tests := []struct {
+		name          string
+		failures      int
+		wantCalls     int
+		wantCompleted bool
+		wantReleased  bool
+	}{
+		{name: "transient error", failures: 2, wantCalls: 3, wantCompleted: true},
+		{name: "persistent error", failures: 3, wantCalls: 3, wantReleased: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &rateLimitBackend{Backend: sqlite.NewInMemoryBackend(), failures: tt.failures}
+			aw := NewActivityWorker(b, workflow.NewRegistry(), clock.New(), &DefaultOptions)
+
+			// Errors acquiring the rate limit don't stop the worker
+			aw.handleTask(context.Background(), &task.Activity{
+				ID:               "activity",
+				WorkflowInstance: core.NewWorkflowInstance("instance", "execution"),
+				Event: history.NewPendingEvent(time.Now(), history.EventType_ActivityScheduled,
+					&history.ActivityScheduledAttributes{Name: "activity"}),
+			})
+
+			require.Equal(t, tt.wantCalls, b.acquireCalls)
+			require.Equal(t, tt.wantCompleted, b.completed)
+			require.Equal(t, tt.wantReleased, b.released)
+		})
+	}
+}
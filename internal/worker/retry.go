@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one
+	MaxAttempts int
+
+	// FirstRetryInterval is the time to wait before the first retry
+	FirstRetryInterval time.Duration
+
+	// MaxRetryInterval is the maximum time to wait between retries
+	MaxRetryInterval time.Duration
+
+	// BackoffCoefficient is the factor by which the retry interval increases after every attempt
+	BackoffCoefficient float64
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := time.Duration(float64(p.FirstRetryInterval) * math.Pow(p.BackoffCoefficient, float64(attempt)))
+	if p.MaxRetryInterval > 0 && d > p.MaxRetryInterval {
+		d = p.MaxRetryInterval
+	}
+
+	return d
+}
+
+// retry calls fn until it succeeds, the attempts of the policy are exhausted, or the context is canceled. onError
+// is called for every failed attempt that will be retried. It returns the error of the last attempt.
+func retry(ctx context.Context, p RetryPolicy, fn func() error, onError func(attempt int, err error)) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if attempt+1 >= p.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		onError(attempt, err)
+
+		t := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
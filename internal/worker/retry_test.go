@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_retry(t *testing.T) {
+	p := RetryPolicy{
+		MaxAttempts:        3,
+		FirstRetryInterval: time.Millisecond,
+		BackoffCoefficient: 2,
+	}
+
+	tests := []struct {
+		name           string
+		failures       int
+		wantErr        bool
+		wantAttempts   int
+		wantOnErrCalls int
+	}{
+		{name: "success", failures: 0, wantAttempts: 1},
+		{name: "transient error", failures: 2, wantAttempts: 3, wantOnErrCalls: 2},
+		{name: "attempts exhausted", failures: 5, wantErr: true, wantAttempts: 3, wantOnErrCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			onErrCalls := 0
+
+			err := retry(context.Background(), p, func() error {
+				attempts++
+				if attempts <= tt.failures {
+					return errors.New("transient")
+				}
+
+				return nil
+			}, func(attempt int, err error) {
+				onErrCalls++
+			})
+
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.wantAttempts, attempts)
+			require.Equal(t, tt.wantOnErrCalls, onErrCalls)
+		})
+	}
+}
+
+func Test_retry_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	err := retry(ctx, RetryPolicy{MaxAttempts: 5}, func() error {
+		attempts++
+		return errors.New("transient")
+	}, func(int, error) {})
+
+	require.Error(t, err)
+	require.Equal(t, 1, attempts)
+}
+
+func Test_RetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{
+		FirstRetryInterval: time.Second,
+		MaxRetryInterval:   time.Second * 3,
+		BackoffCoefficient: 2,
+	}
+
+	require.Equal(t, time.Second, p.backoff(0))
+	require.Equal(t, time.Second*2, p.backoff(1))
+	require.Equal(t, time.Second*3, p.backoff(2))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"runtime/debug"
 	"sync"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/cschleiden/go-workflows/backend"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/interceptor"
 	"github.com/cschleiden/go-workflows/internal/metrickeys"
 	"github.com/cschleiden/go-workflows/internal/task"
 	"github.com/cschleiden/go-workflows/internal/workflow"
@@ -30,6 +32,10 @@ type WorkflowWorker struct {
 
 	workflowTaskQueue chan *task.Workflow
 
+	backpressure *backpressure
+
+	pollers *pollers
+
 	logger log.Logger
 
 	pollersWg sync.WaitGroup
@@ -44,7 +50,7 @@ func NewWorkflowWorker(backend backend.Backend, registry *workflow.Registry, opt
 		c = cache.NewWorkflowExecutorLRUCache(backend.Metrics(), options.WorkflowExecutorCacheSize, options.WorkflowExecutorCacheTTL)
 	}
 
-	return &WorkflowWorker{
+	ww := &WorkflowWorker{
 		backend: backend,
 
 		options: options,
@@ -54,15 +60,23 @@ func NewWorkflowWorker(backend backend.Backend, registry *workflow.Registry, opt
 
 		cache: c,
 
+		backpressure: newBackpressure(backend, clock.New(), options),
+
 		logger: backend.Logger(),
 	}
+
+	ww.pollers = newPollers("workflow", options.WorkflowPollers, options.MaxWorkflowPollers, backend, clock.New(), options, &ww.pollersWg,
+		pendingWorkflowTasks, ww.runPoll)
+
+	return ww
 }
 
 func (ww *WorkflowWorker) Start(ctx context.Context) error {
-	ww.pollersWg.Add(ww.options.WorkflowPollers)
+	ww.pollers.start(ctx)
 
-	for i := 0; i < ww.options.WorkflowPollers; i++ {
-		go ww.runPoll(ctx)
+	if q, ok := ww.backend.(backend.Querier); ok {
+		ww.pollersWg.Add(1)
+		go ww.runQueryPoll(ctx, q)
 	}
 
 	go ww.runDispatcher()
@@ -81,14 +95,17 @@ func (ww *WorkflowWorker) WaitForCompletion() error {
 	return nil
 }
 
-func (ww *WorkflowWorker) runPoll(ctx context.Context) {
-	defer ww.pollersWg.Done()
-
+func (ww *WorkflowWorker) runPoll(ctx context.Context, poller int, stop <-chan struct{}) {
 	for {
+		ww.backpressure.wait(ctx, poller, ww.pollers.count())
+
 		select {
 		case <-ctx.Done():
 			return
 
+		case <-stop:
+			return
+
 		default:
 			task, err := ww.poll(ctx, 30*time.Second)
 			if err != nil {
@@ -96,6 +113,8 @@ func (ww *WorkflowWorker) runPoll(ctx context.Context) {
 				continue
 			}
 
+			ww.pollers.observePoll(task != nil)
+
 			if task != nil {
 				ww.wg.Add(1)
 				ww.workflowTaskQueue <- task
@@ -149,6 +168,7 @@ func (ww *WorkflowWorker) handle(ctx context.Context, t *task.Workflow) {
 	ww.backend.Metrics().Distribution(metrickeys.WorkflowTaskDelay, metrics.Tags{
 		metrickeys.EventName: eventName,
 	}, float64(timeInQueue/time.Millisecond))
+	ww.pollers.observeLatency(timeInQueue)
 
 	timer := metrics.Timer(ww.backend.Metrics(), metrickeys.WorkflowTaskProcessed, metrics.Tags{
 		metrickeys.EventName: eventName,
@@ -156,6 +176,11 @@ func (ww *WorkflowWorker) handle(ctx context.Context, t *task.Workflow) {
 
 	result, err := ww.handleTask(ctx, t)
 	if err != nil {
+		if ww.options.MaxWorkflowTaskAttempts > 0 {
+			ww.handleFailedTask(ctx, t, err)
+			return
+		}
+
 		ww.logger.Panic("could not handle workflow task", "error", err)
 	}
 
@@ -174,17 +199,73 @@ func (ww *WorkflowWorker) handle(ctx context.Context, t *task.Workflow) {
 	}
 
 	ww.backend.Metrics().Counter(metrickeys.ActivityTaskScheduled, metrics.Tags{}, int64(len(result.ActivityEvents)))
+	ww.backend.Metrics().Distribution(metrickeys.WorkflowHistoryLength, metrics.Tags{}, float64(result.HistoryLength))
+	if result.ReplayedEvents > 0 {
+		ww.backend.Metrics().Distribution(metrickeys.WorkflowReplayedEvents, metrics.Tags{}, float64(result.ReplayedEvents))
+	}
+
+	ww.completeTask(ctx, t, result)
+}
+
+// completeTask completes the workflow task with the given result. Transient errors are retried with the same result,
+// if the completion still fails, the task is abandoned and delivered again once its lock expires.
+func (ww *WorkflowWorker) completeTask(ctx context.Context, t *task.Workflow, result *workflow.ExecutionResult) {
+	var permanentErr error
+	err := retry(ctx, ww.options.WorkflowTaskCompletionRetryPolicy, func() error {
+		err := ww.backend.CompleteWorkflowTask(
+			ctx, t, t.WorkflowInstance, result.State, result.Executed, result.ActivityEvents, result.TimerEvents, result.WorkflowEvents)
+		if permanentCompletionError(err) {
+			// Retrying won't help
+			permanentErr = err
+			return nil
+		}
+
+		return err
+	}, func(attempt int, err error) {
+		ww.logger.Error("could not complete workflow task, retrying", "error", err, "attempt", attempt+1)
+	})
+
+	switch {
+	case err != nil:
+		ww.logger.Error("could not complete workflow task, abandoning it",
+			log.InstanceIDKey, t.WorkflowInstance.InstanceID,
+			log.ExecutionIDKey, t.WorkflowInstance.ExecutionID,
+			"error", err)
+		ww.backend.Metrics().Counter(metrickeys.WorkflowTaskFailed, metrics.Tags{}, 1)
+
+	case permanentErr != nil:
+		ww.logger.Warn("discarding workflow task",
+			log.InstanceIDKey, t.WorkflowInstance.InstanceID,
+			log.ExecutionIDKey, t.WorkflowInstance.ExecutionID,
+			"error", permanentErr)
+
+	default:
+		return
+	}
 
-	if err := ww.backend.CompleteWorkflowTask(
-		ctx, t, t.WorkflowInstance, state, result.Executed, result.ActivityEvents, result.TimerEvents, result.WorkflowEvents); err != nil {
-		ww.logger.Panic("could not complete workflow task", "error", err)
+	// The cached executor is ahead of the history
+	if err := ww.cache.Evict(ctx, t.WorkflowInstance); err != nil {
+		ww.logger.Error("could not evict workflow task executor", "error", err)
 	}
 }
 
+// permanentCompletionError returns true for errors completing a workflow task which retrying won't resolve
+func permanentCompletionError(err error) bool {
+	return errors.Is(err, backend.ErrWorkflowTaskLost) || errors.Is(err, backend.ErrInstanceNotFound)
+}
+
 func (ww *WorkflowWorker) handleTask(
 	ctx context.Context,
 	t *task.Workflow,
-) (*workflow.ExecutionResult, error) {
+) (_ *workflow.ExecutionResult, err error) {
+	// Panics in workflow code are handled by the executor, this covers failures of the executor itself
+	defer func() {
+		if r := recover(); r != nil {
+			ww.logger.Error("workflow task panicked", "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("workflow task panicked: %v", r)
+		}
+	}()
+
 	executor, err := ww.getExecutor(ctx, t)
 	if err != nil {
 		return nil, err
@@ -197,14 +278,61 @@ func (ww *WorkflowWorker) handleTask(
 		go ww.heartbeatTask(heartbeatCtx, t)
 	}
 
-	result, err := executor.ExecuteTask(ctx, t)
-	if err != nil {
+	var result *workflow.ExecutionResult
+	if err := interceptor.ExecuteWorkflowTask(ctx, ww.options.WorkflowInterceptors, &interceptor.WorkflowTaskInfo{
+		Instance: t.WorkflowInstance,
+		Attempt:  t.Attempt,
+	}, func(ctx context.Context) error {
+		var err error
+		result, err = executor.ExecuteTask(ctx, t)
+		return err
+	}); err != nil {
 		return nil, fmt.Errorf("executing workflow task: %w", err)
 	}
 
+	if result == nil {
+		return nil, errors.New("workflow interceptor did not execute the workflow task")
+	}
+
 	return result, nil
 }
 
+// handleFailedTask gives up on a workflow task that couldn't be handled. The task is attempted again once its lock
+// expires, until the instance is moved to the dead-letter state after MaxWorkflowTaskAttempts attempts.
+func (ww *WorkflowWorker) handleFailedTask(ctx context.Context, t *task.Workflow, taskErr error) {
+	// The state of the cached executor might not match the history anymore
+	if err := ww.cache.Evict(ctx, t.WorkflowInstance); err != nil {
+		ww.logger.Error("could not evict workflow task executor", "error", err)
+	}
+
+	if t.Attempt < ww.options.MaxWorkflowTaskAttempts {
+		ww.logger.Error("could not handle workflow task, retrying",
+			log.InstanceIDKey, t.WorkflowInstance.InstanceID,
+			log.ExecutionIDKey, t.WorkflowInstance.ExecutionID,
+			"error", taskErr, "attempt", t.Attempt)
+		ww.backend.Metrics().Counter(metrickeys.WorkflowTaskFailed, metrics.Tags{}, 1)
+
+		return
+	}
+
+	ww.logger.Error("could not handle workflow task, moving instance to dead-letter state",
+		log.InstanceIDKey, t.WorkflowInstance.InstanceID,
+		log.ExecutionIDKey, t.WorkflowInstance.ExecutionID,
+		"error", taskErr, "attempt", t.Attempt)
+
+	if err := retry(ctx, ww.options.WorkflowTaskCompletionRetryPolicy, func() error {
+		return ww.backend.DeadLetterWorkflowTask(ctx, t, taskErr.Error())
+	}, func(attempt int, err error) {
+		ww.logger.Error("could not dead-letter workflow task, retrying", "error", err, "attempt", attempt+1)
+	}); err != nil {
+		// The task is attempted again once its lock expires, and dead-lettered again
+		ww.logger.Error("could not dead-letter workflow task", "error", err)
+		return
+	}
+
+	ww.backend.Metrics().Counter(metrickeys.WorkflowTaskDeadLettered, metrics.Tags{}, 1)
+}
+
 func (ww *WorkflowWorker) getExecutor(ctx context.Context, t *task.Workflow) (workflow.WorkflowExecutor, error) {
 	// Try to get a cached executor
 	executor, ok, err := ww.cache.Get(ctx, t.WorkflowInstance)
@@ -213,12 +341,35 @@ func (ww *WorkflowWorker) getExecutor(ctx context.Context, t *task.Workflow) (wo
 	}
 
 	if !ok {
+		var opts []workflow.ExecutorOption
+		if ww.options.DeterminismGuard {
+			opts = append(opts, workflow.WithDeterminismGuard())
+		}
+
+		if len(ww.options.WorkflowInterceptors) > 0 {
+			opts = append(opts, workflow.WithWorkflowInterceptors(ww.options.WorkflowInterceptors))
+		}
+
+		if g, ok := ww.backend.(backend.IDGenerator); ok {
+			opts = append(opts, workflow.WithIDGenerator(g.NewID))
+		}
+
+		if ww.options.ContinueAsNewHistoryEvents > 0 || ww.options.ContinueAsNewHistoryBytes > 0 {
+			opts = append(opts, workflow.WithContinueAsNewThresholds(
+				ww.options.ContinueAsNewHistoryEvents, ww.options.ContinueAsNewHistoryBytes))
+		}
+
 		executor, err = workflow.NewExecutor(
-			ww.backend.Logger(), ww.backend.Tracer(), ww.registry, ww.backend.Converter(), ww.backend.ContextPropagators(), ww.backend, t.WorkflowInstance, t.Metadata, clock.New(),
+			ww.backend.Logger(), ww.backend.Tracer(), ww.registry, ww.backend.Converter(), ww.backend.ContextPropagators(), ww.backend, t.WorkflowInstance, t.Metadata, clock.New(), opts...,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("creating workflow task executor: %w", err)
 		}
+
+		if t.LastSequenceID > 0 {
+			// New executors have to replay the full history of running instances
+			ww.backend.Metrics().Counter(metrickeys.WorkflowInstanceFullReplay, metrics.Tags{}, 1)
+		}
 	}
 
 	// Cache executor instance for future continuation tasks, or refresh last access time
@@ -253,7 +404,13 @@ func (ww *WorkflowWorker) poll(ctx context.Context, timeout time.Duration) (*tas
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	task, err := ww.backend.GetWorkflowTask(ctx)
+	var task *task.Workflow
+	var err error
+	if qp, ok := ww.backend.(backend.QueuePoller); ok && !defaultQueueOnly(ww.options.Queues) {
+		task, err = qp.GetWorkflowTaskFromQueues(ctx, ww.options.Queues)
+	} else {
+		task, err = ww.backend.GetWorkflowTask(ctx)
+	}
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, nil
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/internal/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+type completionBackend struct {
+	backend.Backend
+
+	err   error
+	calls int
+}
+
+func (b *completionBackend) CompleteWorkflowTask(
+	ctx context.Context, t *task.Workflow, instance *core.WorkflowInstance, state core.WorkflowInstanceState,
+	executedEvents, activityEvents, timerEvents []*history.Event, workflowEvents []history.WorkflowEvent,
+) error {
+	b.calls++
+	return b.err
+}
+
+func Test_WorkflowWorker_completeTask(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "transient error", err: errors.New("connection reset"), wantCalls: 3},
+		{name: "task lost", err: backend.ErrWorkflowTaskLost, wantCalls: 1},
+		{name: "instance not found", err: backend.ErrInstanceNotFound, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions
+			options.WorkflowTaskCompletionRetryPolicy = RetryPolicy{
+				MaxAttempts:        3,
+				FirstRetryInterval: time.Millisecond,
+				BackoffCoefficient: 1,
+			}
+
+			b := &completionBackend{Backend: sqlite.NewInMemoryBackend(), err: tt.err}
+			ww := NewWorkflowWorker(b, workflow.NewRegistry(), &options)
+
+			// Failed completions abandon the task instead of stopping the worker
+			ww.completeTask(context.Background(), &task.Workflow{
+				WorkflowInstance: core.NewWorkflowInstance("instance", "execution"),
+			}, &workflow.ExecutionResult{State: core.WorkflowInstanceStateActive})
+
+			require.Equal(t, tt.wantCalls, b.calls)
+		})
+	}
+}
@@ -32,9 +32,12 @@ func NewWorkflowExecutorLRUCache(mc metrics.Client, size int, expiration time.Du
 			reason = "expired"
 		case ttlcache.EvictionReasonCapacityReached:
 			reason = "capacity"
+		case ttlcache.EvictionReasonDeleted:
+			reason = "deleted"
 		}
 
 		mc.Counter(metrickeys.WorkflowInstanceCacheEviction, metrics.Tags{metrickeys.EvictionReason: reason}, 1)
+		mc.Gauge(metrickeys.WorkflowInstanceCacheSize, metrics.Tags{}, int64(c.Len()))
 	})
 
 	return &LruCache{
@@ -46,9 +49,12 @@ func NewWorkflowExecutorLRUCache(mc metrics.Client, size int, expiration time.Du
 func (lc *LruCache) Get(ctx context.Context, instance *core.WorkflowInstance) (workflow.WorkflowExecutor, bool, error) {
 	e := lc.c.Get(getKey(instance))
 	if e != nil {
+		lc.mc.Counter(metrickeys.WorkflowInstanceCacheHit, metrics.Tags{}, 1)
 		return e.Value(), true, nil
 	}
 
+	lc.mc.Counter(metrickeys.WorkflowInstanceCacheMiss, metrics.Tags{}, 1)
+
 	return nil, false, nil
 }
 
@@ -60,6 +66,12 @@ func (lc *LruCache) Store(ctx context.Context, instance *core.WorkflowInstance,
 	return nil
 }
 
+func (lc *LruCache) Evict(ctx context.Context, instance *core.WorkflowInstance) error {
+	lc.c.Delete(getKey(instance))
+
+	return nil
+}
+
 func (lc *LruCache) StartEviction(ctx context.Context) {
 	go lc.c.Start()
 
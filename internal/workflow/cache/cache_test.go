@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,8 +14,10 @@ import (
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
 	"github.com/cschleiden/go-workflows/internal/logger"
+	"github.com/cschleiden/go-workflows/internal/metrickeys"
 	"github.com/cschleiden/go-workflows/internal/metrics"
 	wf "github.com/cschleiden/go-workflows/internal/workflow"
+	m "github.com/cschleiden/go-workflows/metrics"
 	"github.com/cschleiden/go-workflows/workflow"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/trace"
@@ -84,6 +87,40 @@ func Test_Cache_Evict(t *testing.T) {
 	require.Nil(t, e2)
 }
 
+func Test_Cache_Metrics(t *testing.T) {
+	mc := &recordingMetricsClient{
+		Client:   metrics.NewNoopMetricsClient(),
+		counters: map[string]int64{},
+		gauges:   map[string]int64{},
+	}
+	c := NewWorkflowExecutorLRUCache(mc, 1, time.Second*10)
+
+	r := wf.NewRegistry()
+	r.RegisterWorkflow(workflowWithActivity)
+
+	i := core.NewWorkflowInstance("instanceID", "executionID")
+	e, err := wf.NewExecutor(
+		logger.NewDefaultLogger(), trace.NewNoopTracerProvider().Tracer(backend.TracerName), r, converter.DefaultConverter,
+		[]contextpropagation.ContextPropagator{}, &testHistoryProvider{}, i, &core.WorkflowMetadata{}, clock.New(),
+	)
+	require.NoError(t, err)
+
+	_, _, err = c.Get(context.Background(), i)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Store(context.Background(), i, e))
+
+	_, _, err = c.Get(context.Background(), i)
+	require.NoError(t, err)
+
+	mc.Lock()
+	defer mc.Unlock()
+
+	require.Equal(t, int64(1), mc.counters[metrickeys.WorkflowInstanceCacheHit])
+	require.Equal(t, int64(1), mc.counters[metrickeys.WorkflowInstanceCacheMiss])
+	require.Equal(t, int64(1), mc.gauges[metrickeys.WorkflowInstanceCacheSize])
+}
+
 func workflowWithActivity(ctx workflow.Context) (int, error) {
 	r, err := workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
 		RetryOptions: workflow.RetryOptions{
@@ -108,3 +145,25 @@ type testHistoryProvider struct {
 func (t *testHistoryProvider) GetWorkflowInstanceHistory(ctx context.Context, instance *core.WorkflowInstance, lastSequenceID *int64) ([]*history.Event, error) {
 	return t.history, nil
 }
+
+type recordingMetricsClient struct {
+	sync.Mutex
+	m.Client
+
+	counters map[string]int64
+	gauges   map[string]int64
+}
+
+func (mc *recordingMetricsClient) Counter(name string, tags m.Tags, value int64) {
+	mc.Lock()
+	defer mc.Unlock()
+
+	mc.counters[name] += value
+}
+
+func (mc *recordingMetricsClient) Gauge(name string, tags m.Tags, value int64) {
+	mc.Lock()
+	defer mc.Unlock()
+
+	mc.gauges[name] = value
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/benbjohnson/clock"
 	"github.com/cschleiden/go-workflows/internal/command"
@@ -13,13 +14,16 @@ import (
 	"github.com/cschleiden/go-workflows/internal/converter"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/interceptor"
 	"github.com/cschleiden/go-workflows/internal/payload"
 	"github.com/cschleiden/go-workflows/internal/sync"
 	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/internal/tracing"
 	"github.com/cschleiden/go-workflows/internal/workflowerrors"
 	"github.com/cschleiden/go-workflows/internal/workflowstate"
 	"github.com/cschleiden/go-workflows/internal/workflowtracer"
 	"github.com/cschleiden/go-workflows/log"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -29,6 +33,12 @@ type ExecutionResult struct {
 	ActivityEvents []*history.Event
 	TimerEvents    []*history.Event
 	WorkflowEvents []history.WorkflowEvent
+
+	// HistoryLength is the number of events in the history of the execution after the task
+	HistoryLength int64
+
+	// ReplayedEvents is the number of history events replayed before executing the new events of the task
+	ReplayedEvents int
 }
 
 type WorkflowHistoryProvider interface {
@@ -41,6 +51,53 @@ type WorkflowExecutor interface {
 	Close()
 }
 
+type executorOptions struct {
+	determinismGuard bool
+
+	interceptors []interceptor.WorkflowInterceptor
+
+	continueAsNewHistoryEvents int
+	continueAsNewHistoryBytes  int
+
+	idGenerator func() string
+}
+
+type ExecutorOption func(o *executorOptions)
+
+// WithDeterminismGuard enables the runtime determinism guard for all coroutines of the workflow. See
+// sync.WithDeterminismGuard for the checks performed.
+func WithDeterminismGuard() ExecutorOption {
+	return func(o *executorOptions) {
+		o.determinismGuard = true
+	}
+}
+
+// WithWorkflowInterceptors wraps signal delivery and sub-workflow scheduling of the workflow with the given
+// interceptors
+func WithWorkflowInterceptors(interceptors []interceptor.WorkflowInterceptor) ExecutorOption {
+	return func(o *executorOptions) {
+		o.interceptors = interceptors
+	}
+}
+
+// WithIDGenerator sets the function generating the instance and execution IDs of sub-workflows and continued
+// executions
+func WithIDGenerator(generator func() string) ExecutorOption {
+	return func(o *executorOptions) {
+		o.idGenerator = generator
+	}
+}
+
+// WithContinueAsNewThresholds configures the history size after which workflow.ShouldContinueAsNew returns true.
+// events is the number of history events, bytes the size of their serialized attributes. A value of 0 disables the
+// respective threshold.
+func WithContinueAsNewThresholds(events, bytes int) ExecutorOption {
+	return func(o *executorOptions) {
+		o.continueAsNewHistoryEvents = events
+		o.continueAsNewHistoryBytes = bytes
+	}
+}
+
 type executor struct {
 	registry          *Registry
 	historyProvider   WorkflowHistoryProvider
@@ -56,6 +113,23 @@ type executor struct {
 	tracer            trace.Tracer
 	lastSequenceID    int64
 	parentSpan        trace.Span
+	interceptors      []interceptor.WorkflowInterceptor
+
+	// executionDeadline is the deadline of the execution, if it has an execution timeout
+	executionDeadline *time.Time
+
+	// memo of the execution, inherited when continuing as new
+	memo core.Memo
+
+	continueAsNewHistoryEvents int
+	continueAsNewHistoryBytes  int
+
+	// historySize is the size of the serialized attributes of all events in the history, only tracked when a byte
+	// threshold is configured
+	historySize int64
+
+	// panicPolicy determines how panics in the workflow are handled, it's set with the registration of the workflow
+	panicPolicy PanicPolicy
 }
 
 func NewExecutor(
@@ -68,8 +142,17 @@ func NewExecutor(
 	instance *core.WorkflowInstance,
 	metadata *core.WorkflowMetadata,
 	clock clock.Clock,
+	opts ...ExecutorOption,
 ) (WorkflowExecutor, error) {
+	var options executorOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	s := workflowstate.NewWorkflowState(instance, logger, clock)
+	if options.idGenerator != nil {
+		s.SetIDGenerator(options.idGenerator)
+	}
 
 	wfTracer := workflowtracer.New(tracer)
 
@@ -78,6 +161,10 @@ func NewExecutor(
 	wfCtx = workflowtracer.WithWorkflowTracer(wfCtx, wfTracer)
 	wfCtx = workflowstate.WithWorkflowState(wfCtx, s)
 	wfCtx = contextpropagation.WithPropagators(wfCtx, propagators)
+	wfCtx = interceptor.WithWorkflowInterceptors(wfCtx, options.interceptors)
+	if options.determinismGuard {
+		wfCtx = sync.WithDeterminismGuard(wfCtx)
+	}
 	wfCtx, cancel := sync.WithCancel(wfCtx)
 
 	for _, propagator := range propagators {
@@ -103,6 +190,10 @@ func NewExecutor(
 		logger:            logger,
 		tracer:            tracer,
 		parentSpan:        parentSpan,
+		interceptors:      options.interceptors,
+
+		continueAsNewHistoryEvents: options.continueAsNewHistoryEvents,
+		continueAsNewHistoryBytes:  options.continueAsNewHistoryBytes,
 	}, nil
 }
 
@@ -133,6 +224,7 @@ func (e *executor) ExecuteTask(ctx context.Context, t *task.Workflow) (*Executio
 	}
 
 	skipNewEvents := false
+	replayedEvents := 0
 
 	if t.LastSequenceID > e.lastSequenceID {
 		logger.Debug("Task has newer history than current state, fetching and replaying history",
@@ -144,9 +236,15 @@ func (e *executor) ExecuteTask(ctx context.Context, t *task.Workflow) (*Executio
 			return nil, fmt.Errorf("getting workflow history: %w", err)
 		}
 
+		replayedEvents = len(h)
+
 		if err := e.replayHistory(h); err != nil {
 			logger.Error("Error while replaying history", "error", err)
 
+			if e.panicFailsTask(err) {
+				return nil, err
+			}
+
 			// Fail workflow with an error. Skip executing new events, but still go through the commands
 			e.workflowCompleted(nil, err)
 			skipNewEvents = true
@@ -164,8 +262,27 @@ func (e *executor) ExecuteTask(ctx context.Context, t *task.Workflow) (*Executio
 		return nil, fmt.Errorf("task has older history than current state, cannot execute")
 	}
 
+	// Terminate without executing new events, the workflow doesn't get a chance to react
+	if te := terminationEvent(t.NewEvents); te != nil {
+		logger.Debug("Terminating workflow instance")
+
+		r := e.terminate(t.NewEvents, te)
+		r.ReplayedEvents = replayedEvents
+		return r, nil
+	}
+
 	// Always add a WorkflowTaskStarted event before executing new tasks
-	toExecute := []*history.Event{e.createNewEvent(history.EventType_WorkflowTaskStarted, &history.WorkflowTaskStartedAttributes{})}
+	taskStarted := &history.WorkflowTaskStartedAttributes{}
+	if !skipNewEvents && startsExecution(t.NewEvents) {
+		span := e.startWorkflowSpan(t.NewEvents)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			taskStarted.Metadata = tracing.MetadataFromSpanContext(sc)
+		}
+	}
+
+	toExecute := []*history.Event{e.createNewEvent(history.EventType_WorkflowTaskStarted, taskStarted)}
 	executedEvents := toExecute
 
 	toExecute = append(toExecute, t.NewEvents...)
@@ -177,6 +294,10 @@ func (e *executor) ExecuteTask(ctx context.Context, t *task.Workflow) (*Executio
 		if err != nil {
 			logger.Error("Error while executing new events", "error", err)
 
+			if e.panicFailsTask(err) {
+				return nil, err
+			}
+
 			e.workflowCompleted(nil, err)
 		}
 	}
@@ -210,9 +331,15 @@ func (e *executor) ExecuteTask(ctx context.Context, t *task.Workflow) (*Executio
 	// Events from commands don't have to be executed again, add them to the executed events.
 	executedEvents = append(executedEvents, newCommandEvents...)
 
+	// The first workflow task of an execution schedules its timeout. The timeout fires as a termination event
+	if state == core.WorkflowInstanceStateActive && e.executionDeadline != nil && startsExecution(t.NewEvents) {
+		timerEvents = append(timerEvents, history.NewWorkflowTimeoutEvent(e.clock.Now(), *e.executionDeadline))
+	}
+
 	// Set SequenceIDs for all executed events
 	for i := range executedEvents {
 		executedEvents[i].SequenceID = e.nextSequenceID()
+		e.trackHistorySize(executedEvents[i])
 	}
 
 	logger.Debug("Finished workflow task",
@@ -227,10 +354,20 @@ func (e *executor) ExecuteTask(ctx context.Context, t *task.Workflow) (*Executio
 		ActivityEvents: activityEvents,
 		TimerEvents:    timerEvents,
 		WorkflowEvents: workflowEvents,
+		HistoryLength:  e.lastSequenceID,
+		ReplayedEvents: replayedEvents,
 	}, nil
 }
 
 func (e *executor) replayHistory(h []*history.Event) error {
+	// Workflow code asks for versions before their markers are replayed, record them upfront
+	for _, event := range h {
+		if event.Type == history.EventType_VersionMarker {
+			a := event.Attributes.(*history.VersionMarkerAttributes)
+			e.workflowState.SetVersion(a.ChangeID, a.Version)
+		}
+	}
+
 	e.workflowState.SetReplaying(true)
 	for _, event := range h {
 		if event.SequenceID < e.lastSequenceID {
@@ -242,6 +379,7 @@ func (e *executor) replayHistory(h []*history.Event) error {
 		}
 
 		e.lastSequenceID = event.SequenceID
+		e.trackHistorySize(event)
 	}
 
 	return nil
@@ -257,6 +395,10 @@ func (e *executor) executeNewEvents(newEvents []*history.Event) ([]*history.Even
 	}
 
 	if e.workflow.Completed() {
+		if e.workflow.Panicked() && e.panicPolicy == PanicPolicyFailTask {
+			return newEvents, e.workflow.Error()
+		}
+
 		// TODO: Is this too early? We haven't committed some of the commands
 		if e.workflowState.HasPendingFutures() {
 			e.logger.Panic("workflow completed, but there are still pending futures")
@@ -272,6 +414,13 @@ func (e *executor) executeNewEvents(newEvents []*history.Event) ([]*history.Even
 	return newEvents, nil
 }
 
+// panicFailsTask returns true if the given error is a panic in the workflow that fails the workflow task instead of
+// the workflow
+func (e *executor) panicFailsTask(err error) bool {
+	var perr *workflowerrors.PanicError
+	return e.panicPolicy == PanicPolicyFailTask && errors.As(err, &perr)
+}
+
 func (e *executor) Close() {
 	if e.workflow != nil {
 		e.logger.Debug("Stopping workflow executor", log.InstanceIDKey, e.workflowState.Instance().InstanceID)
@@ -307,9 +456,21 @@ func (e *executor) executeEvent(event *history.Event) error {
 	case history.EventType_WorkflowExecutionFinished:
 	// Ignore
 
+	case history.EventType_WorkflowExecutionContinuedAsNew:
+	// Ignore
+
 	case history.EventType_WorkflowExecutionCanceled:
 		err = e.handleWorkflowCanceled()
 
+	case history.EventType_WorkflowExecutionTerminated:
+	// Ignore
+
+	case history.EventType_WorkflowExecutionPaused:
+	// Ignore
+
+	case history.EventType_WorkflowExecutionResumed:
+	// Ignore
+
 	case history.EventType_WorkflowTaskStarted:
 		err = e.handleWorkflowTaskStarted(event, event.Attributes.(*history.WorkflowTaskStartedAttributes))
 
@@ -337,6 +498,18 @@ func (e *executor) executeEvent(event *history.Event) error {
 	case history.EventType_SideEffectResult:
 		err = e.handleSideEffectResult(event, event.Attributes.(*history.SideEffectResultAttributes))
 
+	case history.EventType_VersionMarker:
+	// Ignore, versions are recorded before replaying the history
+
+	case history.EventType_SearchAttributesUpserted:
+	// Ignore, search attributes are only indexed by the backend
+
+	case history.EventType_WorkflowUpdateRequested:
+		err = e.handleWorkflowUpdateRequested(event, event.Attributes.(*history.WorkflowUpdateRequestedAttributes))
+
+	case history.EventType_WorkflowUpdateCompleted:
+	// Ignore, update results are only read by the client
+
 	case history.EventType_SubWorkflowScheduled:
 		err = e.handleSubWorkflowScheduled(event, event.Attributes.(*history.SubWorkflowScheduledAttributes))
 	case history.EventType_SubWorkflowCancellationRequested:
@@ -355,6 +528,10 @@ func (e *executor) executeEvent(event *history.Event) error {
 
 func (e *executor) handleWorkflowExecutionStarted(a *history.ExecutionStartedAttributes) error {
 	e.workflowName = a.Name
+	e.workflowState.SetPriority(a.Priority)
+	e.workflowState.SetQueue(a.Queue)
+	e.executionDeadline = a.ExecutionDeadline
+	e.memo = a.Memo
 
 	wfFn, err := e.registry.GetWorkflow(a.Name)
 	if err != nil {
@@ -362,6 +539,12 @@ func (e *executor) handleWorkflowExecutionStarted(a *history.ExecutionStartedAtt
 	}
 
 	e.workflow = NewWorkflow(reflect.ValueOf(wfFn))
+	e.panicPolicy = e.registry.GetWorkflowOptions(a.Name).PanicPolicy
+
+	if e.workflowState.Instance().SubWorkflow() {
+		// Link the sub-workflow execution to the span of the parent that scheduled it
+		e.traceLinked(fmt.Sprintf("SubWorkflowStarted: %s", a.Name), e.parentSpanContext(), "parent")
+	}
 
 	return e.workflow.Execute(e.workflowCtx, a.Inputs)
 }
@@ -375,6 +558,17 @@ func (e *executor) handleWorkflowCanceled() error {
 func (e *executor) handleWorkflowTaskStarted(event *history.Event, a *history.WorkflowTaskStartedAttributes) error {
 	e.workflowState.SetTime(event.Timestamp)
 
+	// Spans recorded by the workflow are part of the span of the workflow execution
+	if sc := tracing.SpanContextFromMetadata(a.Metadata); sc.IsValid() {
+		span := trace.SpanFromContext(trace.ContextWithRemoteSpanContext(context.Background(), sc))
+		e.workflowCtx = workflowtracer.ContextWithSpan(e.workflowCtx, span)
+	}
+
+	// The history up to this event is the same when replaying, so the suggestion is deterministic
+	e.workflowState.SetContinueAsNewSuggested(
+		(e.continueAsNewHistoryEvents > 0 && e.lastSequenceID >= int64(e.continueAsNewHistoryEvents)) ||
+			(e.continueAsNewHistoryBytes > 0 && e.historySize >= int64(e.continueAsNewHistoryBytes)))
+
 	return nil
 }
 
@@ -450,6 +644,9 @@ func (e *executor) handleActivityFailed(event *history.Event, a *history.Activit
 		return fmt.Errorf("previous workflow execution scheduled an activity, not: %v", c.Type())
 	}
 
+	// Make the checkpoint available to a retry of the activity
+	sac.FailedCheckpoint = a.Checkpoint
+
 	sac.Done()
 
 	return e.workflow.Continue()
@@ -494,6 +691,8 @@ func (e *executor) handleTimerFired(event *history.Event, a *history.TimerFiredA
 
 	c.Done()
 
+	e.traceLinked("TimerFired", tracing.LinkFromMetadata(a.Metadata), "scheduled")
+
 	return e.workflow.Continue()
 }
 
@@ -569,6 +768,10 @@ func (e *executor) handleSubWorkflowCancellationRequest(event *history.Event, a
 func (e *executor) handleSubWorkflowFailed(event *history.Event, a *history.SubWorkflowFailedAttributes) error {
 	f, ok := e.workflowState.FutureByScheduleEventID(event.ScheduleEventID)
 	if !ok {
+		if e.handleAbandonedSubWorkflowResult(event) {
+			return nil
+		}
+
 		return errors.New("no pending future found for sub workflow failed event")
 	}
 
@@ -585,18 +788,25 @@ func (e *executor) handleSubWorkflowFailed(event *history.Event, a *history.SubW
 		return fmt.Errorf("previous workflow execution scheduled a sub-workflow execution")
 	}
 
-	if _, ok := c.(*command.ScheduleSubWorkflowCommand); !ok {
+	swc, ok := c.(*command.ScheduleSubWorkflowCommand)
+	if !ok {
 		return fmt.Errorf("previous workflow execution cancelled a sub-workflow execution, not: %v", c.Type())
 	}
 
 	c.Done()
 
+	e.traceLinked(fmt.Sprintf("SubWorkflowFailed: %s", swc.Name), tracing.SpanContextFromMetadata(a.Metadata), "child")
+
 	return e.workflow.Continue()
 }
 
 func (e *executor) handleSubWorkflowCompleted(event *history.Event, a *history.SubWorkflowCompletedAttributes) error {
 	f, ok := e.workflowState.FutureByScheduleEventID(event.ScheduleEventID)
 	if !ok {
+		if e.handleAbandonedSubWorkflowResult(event) {
+			return nil
+		}
+
 		return errors.New("no pending future found for sub workflow completed event")
 	}
 
@@ -611,18 +821,60 @@ func (e *executor) handleSubWorkflowCompleted(event *history.Event, a *history.S
 		return fmt.Errorf("previous workflow execution cancelled a sub-workflow execution")
 	}
 
-	if _, ok := c.(*command.ScheduleSubWorkflowCommand); !ok {
+	swc, ok := c.(*command.ScheduleSubWorkflowCommand)
+	if !ok {
 		return fmt.Errorf("previous workflow execution cancelled a sub-workflow execution, not: %v", c.Type())
 	}
 
 	c.Done()
 
+	e.traceLinked(fmt.Sprintf("SubWorkflowCompleted: %s", swc.Name), tracing.SpanContextFromMetadata(a.Metadata), "child")
+
 	return e.workflow.Continue()
 }
 
+// handleAbandonedSubWorkflowResult discards the result of an abandoned sub-workflow. It returns false if the event
+// isn't for an abandoned sub-workflow.
+func (e *executor) handleAbandonedSubWorkflowResult(event *history.Event) bool {
+	c := e.workflowState.CommandByScheduleEventID(event.ScheduleEventID)
+	if swc, ok := c.(*command.ScheduleSubWorkflowCommand); ok && swc.Abandoned() {
+		swc.Done()
+		return true
+	}
+
+	return false
+}
+
 func (e *executor) handleSignalReceived(event *history.Event, a *history.SignalReceivedAttributes) error {
-	// Send signal to workflow channel
-	workflowstate.ReceiveSignal(e.workflowState, a.Name, a.Arg)
+	if err := interceptor.HandleSignal(e.workflowCtx, e.interceptors, &interceptor.SignalInfo{
+		Instance: e.workflowState.Instance(),
+		Name:     a.Name,
+	}, func(ctx sync.Context) error {
+		// Send signal to workflow channel
+		workflowstate.ReceiveSignal(e.workflowState, a.Name, a.Arg)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("handling signal %v: %w", a.Name, err)
+	}
+
+	return e.workflow.Continue()
+}
+
+func (e *executor) handleWorkflowUpdateRequested(event *history.Event, a *history.WorkflowUpdateRequestedAttributes) error {
+	var result payload.Payload
+	var err error
+
+	handler, ok := e.workflowState.UpdateHandler(a.Name)
+	if ok {
+		result, err = handler(a.Args)
+	} else {
+		err = fmt.Errorf("%w: %v", ErrUnknownUpdate, a.Name)
+	}
+
+	// Results of updates handled by previous executions of the workflow code are part of the history already
+	if !e.workflowState.Replaying() {
+		e.workflowState.AddCommand(command.NewCompleteUpdateCommand(a.UpdateID, result, workflowerrors.FromError(err)))
+	}
 
 	return e.workflow.Continue()
 }
@@ -658,18 +910,192 @@ func (e *executor) workflowCompleted(result payload.Payload, wfErr error) error
 	eventId := e.workflowState.GetNextScheduleEventID()
 
 	cmd := command.NewCompleteWorkflowCommand(eventId, e.workflowState.Instance(), result, workflowerrors.FromError(wfErr))
+
+	if e.workflowState.Instance().SubWorkflow() {
+		// Propagate the span of the finished sub-workflow, so the parent can link to it
+		sc := e.traceLinked(fmt.Sprintf("SubWorkflowFinished: %s", e.workflowName), e.parentSpanContext(), "parent")
+		cmd.Metadata = tracing.MetadataFromSpanContext(sc)
+	}
+
 	e.workflowState.AddCommand(cmd)
 
 	return nil
 }
 
+func terminationEvent(events []*history.Event) *history.Event {
+	for _, event := range events {
+		if event.Type == history.EventType_WorkflowExecutionTerminated {
+			return event
+		}
+	}
+
+	return nil
+}
+
+func startsExecution(events []*history.Event) bool {
+	for _, event := range events {
+		if event.Type == history.EventType_WorkflowExecutionStarted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// terminate finishes the execution with the termination event in the given new events. Other new events are
+// discarded, except for the start of the execution. Running sub-workflows are terminated as well, unless their parent
+// close policy is to abandon them, and the parent of a sub-workflow receives ErrTerminated, or a TimeoutError if the
+// execution timed out, as its result.
+func (e *executor) terminate(newEvents []*history.Event, event *history.Event) *ExecutionResult {
+	a := event.Attributes.(*history.ExecutionTerminatedAttributes)
+	instance := e.workflowState.Instance()
+
+	executedEvents := []*history.Event{
+		e.createNewEvent(history.EventType_WorkflowTaskStarted, &history.WorkflowTaskStartedAttributes{}),
+	}
+
+	for _, ne := range newEvents {
+		// Keep the history well-formed for instances terminated before their first workflow task
+		if ne.Type == history.EventType_WorkflowExecutionStarted {
+			executedEvents = append(executedEvents, ne)
+		}
+	}
+
+	executedEvents = append(executedEvents, event)
+
+	workflowEvents := make([]history.WorkflowEvent, 0)
+
+	for _, c := range e.workflowState.Commands() {
+		if sswc, ok := c.(*command.ScheduleSubWorkflowCommand); ok && sswc.Running() && sswc.ParentClosePolicy != core.ParentClosePolicyAbandon {
+			workflowEvents = append(workflowEvents, history.WorkflowEvent{
+				WorkflowInstance: sswc.Instance,
+				HistoryEvent:     history.NewWorkflowTerminationEvent(e.clock.Now(), a.Reason),
+			})
+		}
+	}
+
+	if instance.SubWorkflow() {
+		var err error = workflowerrors.ErrTerminated
+		if a.TimedOut {
+			err = workflowerrors.NewTimeoutError(workflowerrors.TimeoutKindExecution)
+		}
+
+		workflowEvents = append(workflowEvents, history.WorkflowEvent{
+			WorkflowInstance: instance.Parent,
+			HistoryEvent: history.NewPendingEvent(
+				e.clock.Now(),
+				history.EventType_SubWorkflowFailed,
+				&history.SubWorkflowFailedAttributes{
+					Error: workflowerrors.FromError(err),
+				},
+				history.ScheduleEventID(instance.ParentEventID),
+			),
+		})
+	}
+
+	for i := range executedEvents {
+		executedEvents[i].SequenceID = e.nextSequenceID()
+		e.trackHistorySize(executedEvents[i])
+	}
+
+	return &ExecutionResult{
+		State:          core.WorkflowInstanceStateFinished,
+		Executed:       executedEvents,
+		ActivityEvents: make([]*history.Event, 0),
+		TimerEvents:    make([]*history.Event, 0),
+		WorkflowEvents: workflowEvents,
+		HistoryLength:  e.lastSequenceID,
+	}
+}
+
+// startWorkflowSpan starts the span of the workflow execution started by the given events. The span ends with the
+// first workflow task, spans recorded by later workflow tasks, activities, timers, and sub-workflows are its children.
+func (e *executor) startWorkflowSpan(events []*history.Event) trace.Span {
+	var name string
+	for _, event := range events {
+		if event.Type == history.EventType_WorkflowExecutionStarted {
+			name = event.Attributes.(*history.ExecutionStartedAttributes).Name
+		}
+	}
+
+	instance := e.workflowState.Instance()
+
+	// The span of the client or parent workflow creating the instance, propagated in the metadata of the instance
+	ctx := context.Background()
+	if e.parentSpan != nil {
+		ctx = trace.ContextWithSpan(ctx, e.parentSpan)
+	}
+
+	_, span := e.tracer.Start(ctx, fmt.Sprintf("Workflow: %s", name), trace.WithAttributes(
+		attribute.String(log.WorkflowNameKey, name),
+		attribute.String(log.InstanceIDKey, instance.InstanceID),
+		attribute.String(log.ExecutionIDKey, instance.ExecutionID),
+	))
+
+	return span
+}
+
+func (e *executor) parentSpanContext() trace.SpanContext {
+	if e.parentSpan == nil {
+		return trace.SpanContext{}
+	}
+
+	return e.parentSpan.SpanContext()
+}
+
+// traceLinked records a span in the workflow trace with a link to the given span context, if it is valid. Links
+// connect the spans of parent and sub-workflow executions in both directions.
+func (e *executor) traceLinked(name string, sc trace.SpanContext, linkType string) trace.SpanContext {
+	opts := []trace.SpanStartOption{
+		trace.WithAttributes(attribute.String(log.InstanceIDKey, e.workflowState.Instance().InstanceID)),
+	}
+
+	if sc.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{
+			SpanContext: sc,
+			Attributes:  []attribute.KeyValue{attribute.String(log.SpanLinkTypeKey, linkType)},
+		}))
+	}
+
+	_, span := e.workflowTracer.Start(e.workflowCtx, name, opts...)
+	span.End()
+
+	return span.SpanContext()
+}
+
 func (e *executor) workflowRestarted(result payload.Payload, continueAsNew *continueasnew.Error) {
 	eventId := e.workflowState.GetNextScheduleEventID()
 
-	cmd := command.NewContinueAsNewCommand(eventId, e.workflowState.Instance(), result, e.workflowName, continueAsNew.Metadata, continueAsNew.Inputs)
+	// Signals the workflow hasn't consumed are delivered to the new execution, so that they aren't lost
+	pending, err := workflowstate.DrainPendingSignals(e.workflowState)
+	if err != nil {
+		e.workflowCompleted(nil, fmt.Errorf("carrying over pending signals: %w", err))
+		return
+	}
+
+	signals := make([]*history.SignalReceivedAttributes, 0, len(pending))
+	for _, s := range pending {
+		signals = append(signals, &history.SignalReceivedAttributes{Name: s.Name, Arg: s.Arg})
+	}
+
+	cmd := command.NewContinueAsNewCommand(eventId, e.workflowState.Instance(), e.workflowState.NewID(), result, e.workflowName, continueAsNew.Metadata, continueAsNew.Inputs, e.workflowState.Priority(), e.workflowState.Queue(), signals, e.executionDeadline, e.memo)
 	e.workflowState.AddCommand(cmd)
 }
 
+func (e *executor) trackHistorySize(event *history.Event) {
+	if e.continueAsNewHistoryBytes <= 0 {
+		return
+	}
+
+	a, err := history.SerializeAttributes(event.Attributes)
+	if err != nil {
+		e.logger.Error("Could not serialize event attributes to track history size", "error", err)
+		return
+	}
+
+	e.historySize += int64(len(a))
+}
+
 func (e *executor) nextSequenceID() int64 {
 	e.lastSequenceID++
 	return e.lastSequenceID
@@ -706,6 +1132,11 @@ func getAttributesLoggingFields(event *history.Event) []any {
 		return []any{
 			log.ActivityNameKey, attributes.Name,
 		}
+	case history.EventType_WorkflowUpdateRequested:
+		attributes := event.Attributes.(*history.WorkflowUpdateRequestedAttributes)
+		return []any{
+			log.UpdateNameKey, attributes.Name,
+		}
 	default:
 		return nil
 	}
@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"log"
 	"testing"
 	"time"
@@ -14,13 +15,18 @@ import (
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/fn"
 	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/interceptor"
 	"github.com/cschleiden/go-workflows/internal/logger"
 	"github.com/cschleiden/go-workflows/internal/payload"
 	"github.com/cschleiden/go-workflows/internal/sync"
 	"github.com/cschleiden/go-workflows/internal/task"
+	"github.com/cschleiden/go-workflows/internal/tracing"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
 	wf "github.com/cschleiden/go-workflows/workflow"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -487,6 +493,47 @@ func Test_Executor(t *testing.T) {
 				require.Len(t, e.workflowState.Commands(), 1)
 				require.Len(t, pendingCommands(e.workflowState.Commands()), 0)
 				require.Equal(t, core.WorkflowInstanceStateFinished, r1.State)
+
+				// Stack trace of the panic is recorded in the history
+				finished := r1.Executed[len(r1.Executed)-1]
+				require.Equal(t, history.EventType_WorkflowExecutionFinished, finished.Type)
+				werr := finished.Attributes.(*history.ExecutionCompletedAttributes).Error
+				require.Equal(t, "PanicError", werr.Type)
+				require.Contains(t, werr.Stacktrace, "executor_test.go")
+			},
+		},
+		{
+			name: "Fails workflow task on panic with fail task policy",
+			f: func(t *testing.T, r *Registry, e *executor, i *core.WorkflowInstance, hp *testHistoryProvider) {
+				workflowPanic := func(ctx sync.Context) error {
+					panic("wf error")
+				}
+
+				r.RegisterWorkflow(workflowPanic, WithPanicPolicy(PanicPolicyFailTask))
+
+				task1 := &task.Workflow{
+					ID:               "taskid",
+					WorkflowInstance: core.NewWorkflowInstance("instanceID", "executionID"),
+					Metadata:         &core.WorkflowMetadata{},
+					NewEvents: []*history.Event{
+						history.NewPendingEvent(
+							time.Now(),
+							history.EventType_WorkflowExecutionStarted,
+							&history.ExecutionStartedAttributes{
+								Name:   fn.Name(workflowPanic),
+								Inputs: []payload.Payload{},
+							},
+						),
+					},
+				}
+
+				// The workflow isn't completed, the task is attempted again
+				r1, err := e.ExecuteTask(context.Background(), task1)
+				require.Nil(t, r1)
+
+				var perr *workflowerrors.PanicError
+				require.ErrorAs(t, err, &perr)
+				require.Contains(t, perr.Stack(), "executor_test.go")
 			},
 		},
 		{
@@ -583,6 +630,70 @@ func Test_Executor(t *testing.T) {
 				require.True(t, e.workflow.Completed())
 			},
 		},
+		{
+			name: "GetVersion records marker for new executions",
+			f: func(t *testing.T, r *Registry, e *executor, i *core.WorkflowInstance, hp *testHistoryProvider) {
+				var version int
+				workflow := func(ctx wf.Context) error {
+					var err error
+					version, err = wf.GetVersion(ctx, "change", wf.DefaultVersion, 2)
+					return err
+				}
+
+				r.RegisterWorkflow(workflow)
+
+				result, err := e.ExecuteTask(context.Background(), startWorkflowTask("instanceID", workflow))
+				require.NoError(t, err)
+				require.True(t, e.workflow.Completed())
+				require.Equal(t, 2, version)
+
+				var marker *history.Event
+				for _, event := range result.Executed {
+					if event.Type == history.EventType_VersionMarker {
+						marker = event
+					}
+				}
+				require.NotNil(t, marker)
+				require.Equal(t, &history.VersionMarkerAttributes{ChangeID: "change", Version: 2}, marker.Attributes)
+			},
+		},
+		{
+			name: "GetVersion returns default version when replaying history without marker",
+			f: func(t *testing.T, r *Registry, e *executor, i *core.WorkflowInstance, hp *testHistoryProvider) {
+				var version int
+				workflow := func(ctx wf.Context) error {
+					var err error
+					version, err = wf.GetVersion(ctx, "change", wf.DefaultVersion, 1)
+					if err != nil {
+						return err
+					}
+
+					_, err = wf.ScheduleTimer(ctx, time.Second).Get(ctx)
+					return err
+				}
+
+				r.RegisterWorkflow(workflow)
+
+				hp.history = []*history.Event{
+					history.NewHistoryEvent(1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+						Name:   fn.Name(workflow),
+						Inputs: []payload.Payload{},
+					}),
+					history.NewHistoryEvent(2, time.Now(), history.EventType_TimerScheduled, &history.TimerScheduledAttributes{
+						At: time.Now().Add(time.Second),
+					}, history.ScheduleEventID(1)),
+				}
+
+				_, err := e.ExecuteTask(context.Background(), continueTask("instanceID", []*history.Event{
+					history.NewPendingEvent(time.Now(), history.EventType_TimerFired, &history.TimerFiredAttributes{
+						At: time.Now().Add(time.Second),
+					}, history.ScheduleEventID(1)),
+				}, 2))
+				require.NoError(t, err)
+				require.True(t, e.workflow.Completed())
+				require.Equal(t, wf.DefaultVersion, version)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -640,3 +751,357 @@ func pendingCommands(commands []command.Command) []command.Command {
 	}
 	return pending
 }
+
+func Test_Executor_SubWorkflowTraceLinks(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+	propagators := []contextpropagation.ContextPropagator{&tracing.TracingContextPropagator{}}
+
+	subworkflow := func(ctx wf.Context) error {
+		return nil
+	}
+
+	workflow := func(ctx wf.Context) error {
+		_, err := wf.CreateSubWorkflowInstance[any](ctx, wf.SubWorkflowOptions{
+			InstanceID: "subworkflow",
+		}, subworkflow).Get(ctx)
+
+		return err
+	}
+
+	r := NewRegistry()
+	r.RegisterWorkflow(workflow)
+	r.RegisterWorkflow(subworkflow)
+
+	hp := &testHistoryProvider{}
+	pe, err := NewExecutor(logger.NewDefaultLogger(), tracer, r, converter.DefaultConverter, propagators, hp, core.NewWorkflowInstance("instanceID", "executionID"), &core.WorkflowMetadata{}, clock.New())
+	require.NoError(t, err)
+
+	result, err := pe.ExecuteTask(context.Background(), startWorkflowTask("instanceID", workflow))
+	require.NoError(t, err)
+	require.Len(t, result.WorkflowEvents, 1)
+
+	// Run the sub-workflow to completion
+	subWorkflowStarted := result.WorkflowEvents[0]
+	metadata := subWorkflowStarted.HistoryEvent.Attributes.(*history.ExecutionStartedAttributes).Metadata
+	se, err := NewExecutor(logger.NewDefaultLogger(), tracer, r, converter.DefaultConverter, propagators, &testHistoryProvider{}, subWorkflowStarted.WorkflowInstance, metadata, clock.New())
+	require.NoError(t, err)
+
+	subResult, err := se.ExecuteTask(context.Background(), &task.Workflow{
+		ID:               uuid.NewString(),
+		WorkflowInstance: subWorkflowStarted.WorkflowInstance,
+		Metadata:         metadata,
+		NewEvents:        []*history.Event{subWorkflowStarted.HistoryEvent},
+	})
+	require.NoError(t, err)
+	require.Len(t, subResult.WorkflowEvents, 1)
+	require.Equal(t, history.EventType_SubWorkflowCompleted, subResult.WorkflowEvents[0].HistoryEvent.Type)
+
+	// Deliver the sub-workflow result to the parent
+	hp.history = append(hp.history, result.Executed...)
+	_, err = pe.ExecuteTask(context.Background(), continueTask("instanceID", []*history.Event{
+		subResult.WorkflowEvents[0].HistoryEvent,
+	}, result.Executed[len(result.Executed)-1].SequenceID))
+	require.NoError(t, err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range sr.Ended() {
+		spans[span.Name()] = span
+	}
+
+	name := fn.Name(subworkflow)
+	scheduled := spans["CreateSubworkflowInstance: "+name]
+	started := spans["SubWorkflowStarted: "+name]
+	finished := spans["SubWorkflowFinished: "+name]
+	completed := spans["SubWorkflowCompleted: "+name]
+	require.NotNil(t, scheduled)
+	require.NotNil(t, started)
+	require.NotNil(t, finished)
+	require.NotNil(t, completed)
+
+	// Sub-workflow links to the span of the parent that scheduled it
+	require.Len(t, started.Links(), 1)
+	require.Equal(t, scheduled.SpanContext(), started.Links()[0].SpanContext.WithRemote(false))
+
+	// Parent links to the span of the finished sub-workflow
+	require.Len(t, completed.Links(), 1)
+	require.Equal(t, finished.SpanContext(), completed.Links()[0].SpanContext.WithRemote(false))
+
+	require.Equal(t, scheduled.SpanContext().TraceID(), finished.SpanContext().TraceID())
+}
+
+func Test_Executor_ShouldContinueAsNew(t *testing.T) {
+	var suggested []bool
+
+	workflow := func(ctx wf.Context) error {
+		c := wf.NewSignalChannel[int](ctx, "signal")
+		for i := 0; i < 3; i++ {
+			c.Receive(ctx)
+			suggested = append(suggested, wf.ShouldContinueAsNew(ctx))
+		}
+
+		return nil
+	}
+
+	r := NewRegistry()
+	r.RegisterWorkflow(workflow)
+
+	newExecutor := func(hp WorkflowHistoryProvider) *executor {
+		e, err := NewExecutor(
+			logger.NewDefaultLogger(), trace.NewNoopTracerProvider().Tracer("test"), r, converter.DefaultConverter,
+			[]contextpropagation.ContextPropagator{}, hp, core.NewWorkflowInstance("instanceID", "executionID"),
+			&core.WorkflowMetadata{}, clock.New(), WithContinueAsNewThresholds(4, 0))
+		require.NoError(t, err)
+
+		return e.(*executor)
+	}
+
+	signal := func() *history.Event {
+		arg, _ := converter.DefaultConverter.To(42)
+		return history.NewPendingEvent(time.Now(), history.EventType_SignalReceived, &history.SignalReceivedAttributes{
+			Name: "signal",
+			Arg:  arg,
+		})
+	}
+
+	e := newExecutor(&testHistoryProvider{})
+
+	var h []*history.Event
+	result, err := e.ExecuteTask(context.Background(), startWorkflowTask("instanceID", workflow))
+	require.NoError(t, err)
+	h = append(h, result.Executed...)
+
+	// History has 2 events before this task
+	result, err = e.ExecuteTask(context.Background(), continueTask("instanceID", []*history.Event{signal()}, e.lastSequenceID))
+	require.NoError(t, err)
+	h = append(h, result.Executed...)
+
+	// History has 4 events before this task
+	result, err = e.ExecuteTask(context.Background(), continueTask("instanceID", []*history.Event{signal()}, e.lastSequenceID))
+	require.NoError(t, err)
+	h = append(h, result.Executed...)
+	require.Equal(t, []bool{false, true}, suggested)
+
+	// Replaying the history results in the same values
+	suggested = nil
+	re := newExecutor(&testHistoryProvider{history: h})
+	_, err = re.ExecuteTask(context.Background(), continueTask("instanceID", []*history.Event{signal()}, e.lastSequenceID))
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, true, true}, suggested)
+	require.True(t, re.workflow.Completed())
+}
+
+func Test_Executor_ContinueAsNew_CarriesPendingSignals(t *testing.T) {
+	workflow := func(ctx wf.Context, run int) error {
+		if run == 0 {
+			// Only receive the first signal, the second one is pending when continuing as new
+			wf.NewSignalChannel[int](ctx, "signal").Receive(ctx)
+			return wf.ContinueAsNew(ctx, run+1)
+		}
+
+		return nil
+	}
+
+	r := NewRegistry()
+	r.RegisterWorkflow(workflow)
+
+	e, err := newExecutor(r, core.NewWorkflowInstance("instanceID", "executionID"), &testHistoryProvider{})
+	require.NoError(t, err)
+
+	_, err = e.ExecuteTask(context.Background(), startWorkflowTask("instanceID", workflow, 0))
+	require.NoError(t, err)
+
+	arg1, _ := converter.DefaultConverter.To(1)
+	arg2, _ := converter.DefaultConverter.To(2)
+	result, err := e.ExecuteTask(context.Background(), continueTask("instanceID", []*history.Event{
+		history.NewPendingEvent(time.Now(), history.EventType_SignalReceived, &history.SignalReceivedAttributes{Name: "signal", Arg: arg1}),
+		history.NewPendingEvent(time.Now(), history.EventType_SignalReceived, &history.SignalReceivedAttributes{Name: "signal", Arg: arg2}),
+		history.NewPendingEvent(time.Now(), history.EventType_SignalReceived, &history.SignalReceivedAttributes{Name: "other", Arg: arg1}),
+	}, e.lastSequenceID))
+	require.NoError(t, err)
+	require.Equal(t, core.WorkflowInstanceStateContinuedAsNew, result.State)
+
+	require.Len(t, result.WorkflowEvents, 3)
+	require.Equal(t, history.EventType_WorkflowExecutionStarted, result.WorkflowEvents[0].HistoryEvent.Type)
+	require.Equal(t, history.EventType_SignalReceived, result.WorkflowEvents[1].HistoryEvent.Type)
+	require.Equal(t, &history.SignalReceivedAttributes{Name: "other", Arg: arg1}, result.WorkflowEvents[1].HistoryEvent.Attributes)
+	require.Equal(t, &history.SignalReceivedAttributes{Name: "signal", Arg: arg2}, result.WorkflowEvents[2].HistoryEvent.Attributes)
+	require.Equal(t, result.WorkflowEvents[0].WorkflowInstance, result.WorkflowEvents[2].WorkflowInstance)
+}
+
+type testWorkflowInterceptor struct {
+	interceptor.NoopWorkflowInterceptor
+
+	signals      []string
+	subWorkflows []string
+}
+
+func (i *testWorkflowInterceptor) HandleSignal(ctx sync.Context, info *interceptor.SignalInfo, next func(ctx sync.Context) error) error {
+	i.signals = append(i.signals, info.Name)
+
+	// Discard signals with the wrong name
+	if info.Name != "signal" {
+		return nil
+	}
+
+	return next(ctx)
+}
+
+func (i *testWorkflowInterceptor) ScheduleSubWorkflow(ctx sync.Context, info *interceptor.SubWorkflowInfo, next func(ctx sync.Context) error) error {
+	i.subWorkflows = append(i.subWorkflows, info.SubWorkflowInstanceID)
+
+	if info.SubWorkflowInstanceID == "rejected" {
+		return errors.New("sub-workflow rejected")
+	}
+
+	return next(ctx)
+}
+
+func Test_Executor_Interceptors(t *testing.T) {
+	var received []int
+	var subWorkflowErr error
+
+	subworkflow := func(ctx wf.Context) error {
+		return nil
+	}
+
+	workflow := func(ctx wf.Context) error {
+		_, subWorkflowErr = wf.CreateSubWorkflowInstance[any](ctx, wf.SubWorkflowOptions{
+			InstanceID: "rejected",
+		}, subworkflow).Get(ctx)
+
+		f := wf.CreateSubWorkflowInstance[any](ctx, wf.SubWorkflowOptions{
+			InstanceID: "accepted",
+		}, subworkflow)
+
+		v, _ := wf.NewSignalChannel[int](ctx, "signal").Receive(ctx)
+		received = append(received, v)
+
+		_, err := f.Get(ctx)
+		return err
+	}
+
+	r := NewRegistry()
+	r.RegisterWorkflow(workflow)
+	r.RegisterWorkflow(subworkflow)
+
+	i := &testWorkflowInterceptor{}
+	e, err := NewExecutor(
+		logger.NewDefaultLogger(), trace.NewNoopTracerProvider().Tracer("test"), r, converter.DefaultConverter,
+		[]contextpropagation.ContextPropagator{}, &testHistoryProvider{}, core.NewWorkflowInstance("instanceID", "executionID"),
+		&core.WorkflowMetadata{}, clock.New(), WithWorkflowInterceptors([]interceptor.WorkflowInterceptor{i}))
+	require.NoError(t, err)
+
+	result, err := e.ExecuteTask(context.Background(), startWorkflowTask("instanceID", workflow))
+	require.NoError(t, err)
+	require.EqualError(t, subWorkflowErr, "sub-workflow rejected")
+	require.Equal(t, []string{"rejected", "accepted"}, i.subWorkflows)
+
+	// Only the accepted sub-workflow is scheduled
+	require.Len(t, result.WorkflowEvents, 1)
+	require.Equal(t, "accepted", result.WorkflowEvents[0].WorkflowInstance.InstanceID)
+
+	arg1, _ := converter.DefaultConverter.To(1)
+	arg2, _ := converter.DefaultConverter.To(2)
+	result, err = e.ExecuteTask(context.Background(), continueTask("instanceID", []*history.Event{
+		history.NewPendingEvent(time.Now(), history.EventType_SignalReceived, &history.SignalReceivedAttributes{Name: "other", Arg: arg1}),
+		history.NewPendingEvent(time.Now(), history.EventType_SignalReceived, &history.SignalReceivedAttributes{Name: "signal", Arg: arg2}),
+	}, e.(*executor).lastSequenceID))
+	require.NoError(t, err)
+	require.Equal(t, core.WorkflowInstanceStateActive, result.State)
+	require.Equal(t, []string{"other", "signal"}, i.signals)
+	require.Equal(t, []int{2}, received)
+}
+
+func Test_Executor_WorkflowSpan(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+	propagators := []contextpropagation.ContextPropagator{&tracing.TracingContextPropagator{}}
+
+	workflow := func(ctx wf.Context) error {
+		if _, err := wf.ExecuteActivity[int](ctx, wf.DefaultActivityOptions, activity1, 42).Get(ctx); err != nil {
+			return err
+		}
+
+		_, err := wf.ScheduleTimer(ctx, time.Second).Get(ctx)
+		return err
+	}
+
+	r := NewRegistry()
+	r.RegisterWorkflow(workflow)
+	r.RegisterActivity(activity1)
+
+	// Span of the client creating the instance
+	_, clientSpan := tracer.Start(context.Background(), "CreateWorkflowInstance")
+	clientSpan.End()
+	metadata := tracing.MetadataFromSpanContext(clientSpan.SpanContext())
+
+	instance := core.NewWorkflowInstance("instanceID", "executionID")
+	e, err := NewExecutor(logger.NewDefaultLogger(), tracer, r, converter.DefaultConverter, propagators, &testHistoryProvider{}, instance, metadata, clock.New())
+	require.NoError(t, err)
+
+	task1 := startWorkflowTask(instance.InstanceID, workflow)
+	task1.Metadata = metadata
+	result, err := e.ExecuteTask(context.Background(), task1)
+	require.NoError(t, err)
+	require.Len(t, result.ActivityEvents, 1)
+
+	// Continue on another worker, replaying the history of the first task
+	hp := &testHistoryProvider{history: result.Executed}
+	e, err = NewExecutor(logger.NewDefaultLogger(), tracer, r, converter.DefaultConverter, propagators, hp, instance, metadata, clock.New())
+	require.NoError(t, err)
+
+	activityResult, err := converter.DefaultConverter.To(42)
+	require.NoError(t, err)
+
+	scheduled := result.ActivityEvents[0]
+	result, err = e.ExecuteTask(context.Background(), continueTask(instance.InstanceID, []*history.Event{
+		history.NewPendingEvent(time.Now(), history.EventType_ActivityCompleted, &history.ActivityCompletedAttributes{
+			Result: activityResult,
+		}, history.ScheduleEventID(scheduled.ScheduleEventID)),
+	}, result.Executed[len(result.Executed)-1].SequenceID))
+	require.NoError(t, err)
+	require.Len(t, result.TimerEvents, 1)
+
+	_, err = e.ExecuteTask(context.Background(), continueTask(instance.InstanceID, []*history.Event{
+		result.TimerEvents[0],
+	}, result.Executed[len(result.Executed)-1].SequenceID))
+	require.NoError(t, err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	workflowSpans := 0
+	for _, span := range sr.Ended() {
+		spans[span.Name()] = span
+
+		if span.Name() == "Workflow: "+fn.Name(workflow) {
+			workflowSpans++
+		}
+	}
+
+	workflowSpan := spans["Workflow: "+fn.Name(workflow)]
+	executeActivity := spans["ExecuteActivity: "+fn.Name(activity1)]
+	scheduleTimer := spans["ScheduleTimer"]
+	timerFired := spans["TimerFired"]
+	require.NotNil(t, workflowSpan)
+	require.NotNil(t, executeActivity)
+	require.NotNil(t, scheduleTimer)
+	require.NotNil(t, timerFired)
+
+	// Only the first workflow task records the workflow span, it's the child of the client span
+	require.Equal(t, 1, workflowSpans)
+	require.Equal(t, clientSpan.SpanContext().SpanID(), workflowSpan.Parent().SpanID())
+
+	// Spans of all workflow tasks are children of the workflow span
+	require.Equal(t, workflowSpan.SpanContext().SpanID(), executeActivity.Parent().SpanID())
+	require.Equal(t, workflowSpan.SpanContext().SpanID(), scheduleTimer.Parent().SpanID())
+	require.Equal(t, workflowSpan.SpanContext().SpanID(), timerFired.Parent().SpanID())
+
+	// The activity execution is a child of the workflow span and links to the span scheduling it
+	a := scheduled.Attributes.(*history.ActivityScheduledAttributes)
+	require.Equal(t, workflowSpan.SpanContext().SpanID(), tracing.SpanContextFromMetadata(a.Metadata).SpanID())
+	require.Equal(t, executeActivity.SpanContext().SpanID(), tracing.LinkFromMetadata(a.Metadata).SpanID())
+
+	// The fired timer links to the span scheduling it
+	require.Len(t, timerFired.Links(), 1)
+	require.Equal(t, scheduleTimer.SpanContext(), timerFired.Links()[0].SpanContext.WithRemote(false))
+}
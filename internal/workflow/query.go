@@ -0,0 +1,67 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/internal/contextpropagation"
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrUnknownQuery = errors.New("unknown query")
+
+// ErrUnknownUpdate rejects updates for which the workflow hasn't registered a handler
+var ErrUnknownUpdate = errors.New("unknown update")
+
+// ExecuteQuery replays the given history of a workflow instance and evaluates the query handler with the given name
+// against the resulting workflow state. Nothing is recorded, the replay doesn't produce any new events.
+func ExecuteQuery(
+	ctx context.Context,
+	logger log.Logger,
+	tracer trace.Tracer,
+	registry *Registry,
+	cv converter.Converter,
+	propagators []contextpropagation.ContextPropagator,
+	instance *core.WorkflowInstance,
+	h []*history.Event,
+	name string,
+	args []payload.Payload,
+) (payload.Payload, error) {
+	var started *history.ExecutionStartedAttributes
+	for _, event := range h {
+		if event.Type == history.EventType_WorkflowExecutionStarted {
+			started = event.Attributes.(*history.ExecutionStartedAttributes)
+			break
+		}
+	}
+
+	if started == nil {
+		return nil, errors.New("workflow instance has not started yet")
+	}
+
+	we, err := NewExecutor(logger, tracer, registry, cv, propagators, &replayHistoryProvider{h}, instance, started.Metadata, clock.NewMock())
+	if err != nil {
+		return nil, fmt.Errorf("creating workflow executor: %w", err)
+	}
+	defer we.Close()
+
+	e := we.(*executor)
+
+	if err := e.replayHistory(h); err != nil {
+		return nil, fmt.Errorf("replaying history: %w", err)
+	}
+
+	handler, ok := e.workflowState.QueryHandler(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownQuery, name)
+	}
+
+	return handler(args)
+}
@@ -0,0 +1,121 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/internal/contextpropagation"
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/logger"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/sync"
+	wf "github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func query(r *Registry, h []*history.Event, name string, args ...interface{}) (payload.Payload, error) {
+	inputs := make([]payload.Payload, 0, len(args))
+	for _, arg := range args {
+		input, _ := converter.DefaultConverter.To(arg)
+		inputs = append(inputs, input)
+	}
+
+	return ExecuteQuery(
+		context.Background(),
+		logger.NewDefaultLogger(),
+		trace.NewNoopTracerProvider().Tracer("test"),
+		r,
+		converter.DefaultConverter,
+		[]contextpropagation.ContextPropagator{},
+		core.NewWorkflowInstance("instanceID", "executionID"),
+		h,
+		name,
+		inputs,
+	)
+}
+
+func Test_ExecuteQuery(t *testing.T) {
+	workflowWithQuery := func(ctx sync.Context) (int, error) {
+		var result int
+		if err := wf.SetQueryHandler(ctx, "result", func(offset int) (int, error) {
+			return result + offset, nil
+		}); err != nil {
+			return 0, err
+		}
+
+		r, err := wf.ExecuteActivity[int](ctx, wf.DefaultActivityOptions, activity1, 42).Get(ctx)
+		result = r
+
+		return r, err
+	}
+
+	r := NewRegistry()
+	r.RegisterWorkflowByName("wf", workflowWithQuery)
+	r.RegisterActivity(activity1)
+
+	h := recordWorkflowHistory(t, r, "wf", 42)
+
+	// Answered from the state at the end of the history
+	p, err := query(r, h, "result", 1)
+	require.NoError(t, err)
+
+	var v int
+	require.NoError(t, converter.DefaultConverter.From(p, &v))
+	require.Equal(t, 43, v)
+
+	// Answered from the state after the first workflow task
+	p, err = query(r, h[:3], "result", 1)
+	require.NoError(t, err)
+	require.NoError(t, converter.DefaultConverter.From(p, &v))
+	require.Equal(t, 1, v)
+
+	_, err = query(r, h, "unknown")
+	require.ErrorIs(t, err, ErrUnknownQuery)
+
+	_, err = query(r, nil, "result", 1)
+	require.Error(t, err)
+}
+
+func Test_SetQueryHandler_ValidatesHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler interface{}
+	}{
+		{"not a function", 42},
+		{"workflow context", func(ctx sync.Context) error { return nil }},
+		{"context", func(ctx context.Context) error { return nil }},
+		{"no error", func() int { return 0 }},
+		{"too many results", func() (int, int, error) { return 0, 0, nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handlerErr error
+			workflowWithInvalidQuery := func(ctx sync.Context) error {
+				handlerErr = wf.SetQueryHandler(ctx, "q", tt.handler)
+				return nil
+			}
+
+			r := NewRegistry()
+			r.RegisterWorkflowByName("wf", workflowWithInvalidQuery)
+
+			i := core.NewWorkflowInstance("instanceID", "executionID")
+			e, err := newExecutor(r, i, &testHistoryProvider{})
+			require.NoError(t, err)
+			defer e.Close()
+
+			_, err = e.ExecuteTask(context.Background(), continueTask(i.InstanceID, []*history.Event{
+				history.NewPendingEvent(time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+					Name:     "wf",
+					Metadata: &core.WorkflowMetadata{},
+				}),
+			}, 0))
+			require.NoError(t, err)
+			require.Error(t, handlerErr)
+		})
+	}
+}
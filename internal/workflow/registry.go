@@ -16,14 +16,59 @@ type Registry struct {
 
 	workflowMap map[string]Workflow
 	activityMap map[string]interface{}
+
+	workflowOptions map[string]RegisterOptions
+	activityOptions map[string]RegisterOptions
 }
 
 func NewRegistry() *Registry {
 	return &Registry{
-		Mutex:       sync.Mutex{},
-		workflowMap: make(map[string]Workflow),
-		activityMap: make(map[string]interface{}),
+		Mutex:           sync.Mutex{},
+		workflowMap:     make(map[string]Workflow),
+		activityMap:     make(map[string]interface{}),
+		workflowOptions: make(map[string]RegisterOptions),
+		activityOptions: make(map[string]RegisterOptions),
+	}
+}
+
+// PanicPolicy determines how a panic in a workflow or activity is handled
+type PanicPolicy int
+
+const (
+	// PanicPolicyDefault fails the workflow for panics in workflows, and the activity task for panics in activities
+	PanicPolicyDefault PanicPolicy = iota
+
+	// PanicPolicyFailTask fails the task the panic occurred in. Failed workflow tasks are attempted again until the
+	// instance is moved to the dead-letter state, failed activity tasks are retried according to the retry options
+	// of the activity.
+	PanicPolicyFailTask
+
+	// PanicPolicyFailWorkflow fails the workflow with the panic. Panics in activities are returned as permanent
+	// errors, without retrying the activity.
+	PanicPolicyFailWorkflow
+)
+
+// RegisterOptions are the options a workflow or activity has been registered with
+type RegisterOptions struct {
+	PanicPolicy PanicPolicy
+}
+
+type RegisterOption func(o *RegisterOptions)
+
+// WithPanicPolicy sets how panics in the registered workflow or activity are handled
+func WithPanicPolicy(policy PanicPolicy) RegisterOption {
+	return func(o *RegisterOptions) {
+		o.PanicPolicy = policy
+	}
+}
+
+func registerOptions(opts []RegisterOption) RegisterOptions {
+	var o RegisterOptions
+	for _, opt := range opts {
+		opt(&o)
 	}
+
+	return o
 }
 
 type ErrInvalidWorkflow struct {
@@ -42,7 +87,7 @@ func (e *ErrInvalidActivity) Error() string {
 	return e.msg
 }
 
-func (r *Registry) RegisterWorkflowByName(name string, workflow Workflow) error {
+func (r *Registry) RegisterWorkflowByName(name string, workflow Workflow, opts ...RegisterOption) error {
 	wfType := reflect.TypeOf(workflow)
 	if wfType.Kind() != reflect.Func {
 		return &ErrInvalidWorkflow{"workflow is not a function"}
@@ -74,21 +119,22 @@ func (r *Registry) RegisterWorkflowByName(name string, workflow Workflow) error
 	defer r.Unlock()
 
 	r.workflowMap[name] = workflow
+	r.workflowOptions[name] = registerOptions(opts)
 
 	return nil
 }
 
-func (r *Registry) RegisterWorkflow(workflow Workflow) error {
+func (r *Registry) RegisterWorkflow(workflow Workflow, opts ...RegisterOption) error {
 	name := fn.Name(workflow)
-	return r.RegisterWorkflowByName(name, workflow)
+	return r.RegisterWorkflowByName(name, workflow, opts...)
 }
 
-func (r *Registry) RegisterActivityByName(name string, activity interface{}) error {
+func (r *Registry) RegisterActivityByName(name string, activity interface{}, opts ...RegisterOption) error {
 	t := reflect.TypeOf(activity)
 
 	// Activities on struct
 	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
-		return r.registerActivitiesFromStruct(activity)
+		return r.registerActivitiesFromStruct(activity, registerOptions(opts))
 	}
 
 	// Activity as function
@@ -100,16 +146,17 @@ func (r *Registry) RegisterActivityByName(name string, activity interface{}) err
 	defer r.Unlock()
 
 	r.activityMap[name] = activity
+	r.activityOptions[name] = registerOptions(opts)
 
 	return nil
 }
 
-func (r *Registry) RegisterActivity(activity interface{}) error {
+func (r *Registry) RegisterActivity(activity interface{}, opts ...RegisterOption) error {
 	name := fn.Name(activity)
-	return r.RegisterActivityByName(name, activity)
+	return r.RegisterActivityByName(name, activity, opts...)
 }
 
-func (r *Registry) registerActivitiesFromStruct(a interface{}) error {
+func (r *Registry) registerActivitiesFromStruct(a interface{}, options RegisterOptions) error {
 	// Enumerate functions defined on a
 	v := reflect.ValueOf(a)
 	t := v.Type()
@@ -128,6 +175,7 @@ func (r *Registry) registerActivitiesFromStruct(a interface{}) error {
 
 		name := mt.Name
 		r.activityMap[name] = mv.Interface()
+		r.activityOptions[name] = options
 	}
 
 	return nil
@@ -171,3 +219,19 @@ func (r *Registry) GetActivity(name string) (interface{}, error) {
 
 	return nil, errors.New("activity not found")
 }
+
+// GetWorkflowOptions returns the options the workflow with the given name has been registered with
+func (r *Registry) GetWorkflowOptions(name string) RegisterOptions {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.workflowOptions[name]
+}
+
+// GetActivityOptions returns the options the activity with the given name has been registered with
+func (r *Registry) GetActivityOptions(name string) RegisterOptions {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.activityOptions[name]
+}
@@ -201,3 +201,17 @@ func Test_ActivityRegistrationOnStruct_Invalid(t *testing.T) {
 	err := r.RegisterActivity(a)
 	require.Error(t, err)
 }
+
+func Test_RegistrationOptions(t *testing.T) {
+	r := NewRegistry()
+
+	require.NoError(t, r.RegisterWorkflow(reg_workflow1, WithPanicPolicy(PanicPolicyFailTask)))
+	require.Equal(t, PanicPolicyFailTask, r.GetWorkflowOptions("reg_workflow1").PanicPolicy)
+
+	a := &reg_activities{}
+	require.NoError(t, r.RegisterActivity(a, WithPanicPolicy(PanicPolicyFailWorkflow)))
+	require.Equal(t, PanicPolicyFailWorkflow, r.GetActivityOptions(fn.Name(a.Activity1)).PanicPolicy)
+
+	// Defaults for unknown registrations
+	require.Equal(t, PanicPolicyDefault, r.GetActivityOptions("unknown").PanicPolicy)
+}
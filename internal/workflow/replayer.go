@@ -0,0 +1,107 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/internal/command"
+	"github.com/cschleiden/go-workflows/internal/contextpropagation"
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type replayHistoryProvider struct {
+	history []*history.Event
+}
+
+func (p *replayHistoryProvider) GetWorkflowInstanceHistory(ctx context.Context, instance *core.WorkflowInstance, lastSequenceID *int64) ([]*history.Event, error) {
+	return p.history, nil
+}
+
+// ReplayHistory replays the given, previously recorded history of a workflow instance against the workflow code
+// in the given registry. It returns an error if the workflow code does not reproduce the history, this indicates
+// a non-deterministic workflow or a change to the workflow code that is not backwards compatible.
+func ReplayHistory(
+	ctx context.Context,
+	logger log.Logger,
+	tracer trace.Tracer,
+	registry *Registry,
+	cv converter.Converter,
+	propagators []contextpropagation.ContextPropagator,
+	instance *core.WorkflowInstance,
+	metadata *core.WorkflowMetadata,
+	h []*history.Event,
+) error {
+	var started *history.ExecutionStartedAttributes
+	finished := false
+	lastTaskStarted := 0
+
+	for i, event := range h {
+		switch event.Type {
+		case history.EventType_WorkflowExecutionStarted:
+			started = event.Attributes.(*history.ExecutionStartedAttributes)
+
+		case history.EventType_WorkflowTaskStarted:
+			lastTaskStarted = i
+
+		case history.EventType_WorkflowExecutionFinished, history.EventType_WorkflowExecutionContinuedAsNew:
+			finished = true
+
+		case history.EventType_WorkflowExecutionTerminated:
+			// The workflow code didn't run in the task that terminated the execution, only replay the history before
+			h = h[:lastTaskStarted]
+		}
+	}
+
+	if started == nil {
+		return fmt.Errorf("history does not contain %v event", history.EventType_WorkflowExecutionStarted)
+	}
+
+	if len(h) == 0 {
+		// Terminated before the first workflow task
+		return nil
+	}
+
+	if metadata == nil {
+		metadata = started.Metadata
+	}
+
+	we, err := NewExecutor(logger, tracer, registry, cv, propagators, &replayHistoryProvider{h}, instance, metadata, clock.NewMock())
+	if err != nil {
+		return fmt.Errorf("creating workflow executor: %w", err)
+	}
+	defer we.Close()
+
+	e := we.(*executor)
+
+	if err := e.replayHistory(h); err != nil {
+		return fmt.Errorf("replaying history: %w", err)
+	}
+
+	// All commands created by the workflow have to be recorded in the history. Anything left over means the
+	// workflow code took a different path than the original execution.
+	for _, c := range e.workflowState.Commands() {
+		switch c.State() {
+		case command.CommandState_Pending:
+			return fmt.Errorf("workflow code created command %v (id %v) which is not in the history", c.Type(), c.ID())
+
+		case command.CommandState_CancelPending:
+			return fmt.Errorf("workflow code canceled command %v (id %v) which is not canceled in the history", c.Type(), c.ID())
+		}
+	}
+
+	if finished && !e.workflow.Completed() {
+		return errors.New("history contains the workflow completion, but workflow did not complete during replay")
+	}
+
+	if !finished && e.workflow.Completed() {
+		return errors.New("workflow completed during replay, but history does not contain the workflow completion")
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/internal/contextpropagation"
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/logger"
+	"github.com/cschleiden/go-workflows/internal/sync"
+	wf "github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func recordWorkflowHistory(t *testing.T, r *Registry, name string, result int) []*history.Event {
+	i := core.NewWorkflowInstance("instanceID", "executionID")
+	hp := &testHistoryProvider{}
+
+	e, err := newExecutor(r, i, hp)
+	require.NoError(t, err)
+	defer e.Close()
+
+	task := continueTask(i.InstanceID, []*history.Event{
+		history.NewPendingEvent(
+			time.Now(),
+			history.EventType_WorkflowExecutionStarted,
+			&history.ExecutionStartedAttributes{
+				Name:     name,
+				Metadata: &core.WorkflowMetadata{},
+			},
+		),
+	}, 0)
+
+	res, err := e.ExecuteTask(context.Background(), task)
+	require.NoError(t, err)
+	hp.history = append(hp.history, res.Executed...)
+	require.Len(t, res.ActivityEvents, 1)
+
+	r1, _ := converter.DefaultConverter.To(result)
+	res, err = e.ExecuteTask(context.Background(), continueTask(i.InstanceID, []*history.Event{
+		history.NewPendingEvent(
+			time.Now(),
+			history.EventType_ActivityCompleted,
+			&history.ActivityCompletedAttributes{
+				Result: r1,
+			},
+			history.ScheduleEventID(res.ActivityEvents[0].ScheduleEventID),
+		),
+	}, hp.history[len(hp.history)-1].SequenceID))
+	require.NoError(t, err)
+
+	return append(hp.history, res.Executed...)
+}
+
+func replay(r *Registry, h []*history.Event) error {
+	return ReplayHistory(
+		context.Background(),
+		logger.NewDefaultLogger(),
+		trace.NewNoopTracerProvider().Tracer("test"),
+		r,
+		converter.DefaultConverter,
+		[]contextpropagation.ContextPropagator{},
+		core.NewWorkflowInstance("instanceID", "executionID"),
+		nil,
+		h,
+	)
+}
+
+func Test_ReplayHistory(t *testing.T) {
+	workflowWithActivity := func(ctx sync.Context) (int, error) {
+		return wf.ExecuteActivity[int](ctx, wf.DefaultActivityOptions, activity1, 42).Get(ctx)
+	}
+
+	r := NewRegistry()
+	r.RegisterWorkflowByName("wf", workflowWithActivity)
+	r.RegisterActivity(activity1)
+
+	h := recordWorkflowHistory(t, r, "wf", 42)
+
+	require.NoError(t, replay(r, h))
+}
+
+func Test_ReplayHistory_DetectsChanges(t *testing.T) {
+	workflowWithActivity := func(ctx sync.Context) (int, error) {
+		return wf.ExecuteActivity[int](ctx, wf.DefaultActivityOptions, activity1, 42).Get(ctx)
+	}
+
+	r := NewRegistry()
+	r.RegisterWorkflowByName("wf", workflowWithActivity)
+	r.RegisterActivity(activity1)
+
+	h := recordWorkflowHistory(t, r, "wf", 42)
+
+	tests := []struct {
+		name string
+		wf   interface{}
+	}{
+		{
+			name: "additional activity",
+			wf: func(ctx sync.Context) (int, error) {
+				r, err := wf.ExecuteActivity[int](ctx, wf.DefaultActivityOptions, activity1, 42).Get(ctx)
+				if err != nil {
+					return 0, err
+				}
+
+				return wf.ExecuteActivity[int](ctx, wf.DefaultActivityOptions, activity1, r).Get(ctx)
+			},
+		},
+		{
+			name: "timer instead of activity",
+			wf: func(ctx sync.Context) (int, error) {
+				_, err := wf.ScheduleTimer(ctx, time.Second).Get(ctx)
+				return 0, err
+			},
+		},
+		{
+			name: "no activity",
+			wf: func(ctx sync.Context) (int, error) {
+				return 42, nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			r.RegisterWorkflowByName("wf", tt.wf)
+			r.RegisterActivity(activity1)
+
+			require.Error(t, replay(r, h))
+		})
+	}
+}
+
+func Test_ReplayHistory_Terminated(t *testing.T) {
+	workflowWithActivity := func(ctx sync.Context) (int, error) {
+		return wf.ExecuteActivity[int](ctx, wf.DefaultActivityOptions, activity1, 42).Get(ctx)
+	}
+
+	r := NewRegistry()
+	r.RegisterWorkflowByName("wf", workflowWithActivity)
+	r.RegisterActivity(activity1)
+
+	i := core.NewWorkflowInstance("instanceID", "executionID")
+	hp := &testHistoryProvider{}
+
+	e, err := newExecutor(r, i, hp)
+	require.NoError(t, err)
+	defer e.Close()
+
+	res, err := e.ExecuteTask(context.Background(), continueTask(i.InstanceID, []*history.Event{
+		history.NewPendingEvent(time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{
+			Name:     "wf",
+			Metadata: &core.WorkflowMetadata{},
+		}),
+	}, 0))
+	require.NoError(t, err)
+	hp.history = append(hp.history, res.Executed...)
+
+	// The terminating task doesn't execute the activity result
+	res, err = e.ExecuteTask(context.Background(), continueTask(i.InstanceID, []*history.Event{
+		history.NewPendingEvent(time.Now(), history.EventType_ActivityCompleted, &history.ActivityCompletedAttributes{},
+			history.ScheduleEventID(res.ActivityEvents[0].ScheduleEventID)),
+		history.NewWorkflowTerminationEvent(time.Now(), "reason"),
+	}, hp.history[len(hp.history)-1].SequenceID))
+	require.NoError(t, err)
+	require.Equal(t, core.WorkflowInstanceStateFinished, res.State)
+	require.Equal(t, history.EventType_WorkflowExecutionTerminated, res.Executed[len(res.Executed)-1].Type)
+
+	require.NoError(t, replay(r, append(hp.history, res.Executed...)))
+}
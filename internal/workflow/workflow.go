@@ -15,10 +15,11 @@ import (
 type Workflow interface{}
 
 type workflow struct {
-	s      *sync.Scheduler
-	fn     reflect.Value
-	result payload.Payload
-	err    error
+	s        *sync.Scheduler
+	fn       reflect.Value
+	result   payload.Payload
+	err      error
+	panicked bool
 }
 
 func NewWorkflow(workflowFn reflect.Value) *workflow {
@@ -48,6 +49,7 @@ func (w *workflow) Execute(ctx sync.Context, inputs []payload.Payload) error {
 		defer func() {
 			if r := recover(); r != nil {
 				w.err = workflowerrors.NewPanicError(fmt.Sprintf("panic in workflow: %v", r))
+				w.panicked = true
 			}
 		}()
 
@@ -110,6 +112,11 @@ func (w *workflow) Error() error {
 	return w.err
 }
 
+// Panicked returns true if the workflow function panicked, its error is the panic then
+func (w *workflow) Panicked() bool {
+	return w.panicked
+}
+
 func (w *workflow) Close() {
 	// End coroutine execution to prevent goroutine leaks
 	w.s.Exit()
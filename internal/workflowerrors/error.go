@@ -1,8 +1,11 @@
 package workflowerrors
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
 )
 
 type Error struct {
@@ -12,6 +15,13 @@ type Error struct {
 	Permanent  bool   `json:"permanent,omitempty"`
 	Cause      error  `json:"cause,omitempty"`
 	Stacktrace string `json:"stacktrace,omitempty"`
+
+	// Details are the serialized exported fields of the original error, if any. They are used to restore the
+	// original error when using errors.As.
+	Details json.RawMessage `json:"details,omitempty"`
+
+	// Payload holds additional data attached to the error via NewErrorWithPayload, serialized as JSON
+	Payload json.RawMessage `json:"payload,omitempty"`
 }
 
 func (e *Error) UnmarshalJSON(b []byte) error {
@@ -26,7 +36,12 @@ func (e *Error) UnmarshalJSON(b []byte) error {
 	}
 
 	*e = *(*Error)(a.Alias)
-	e.Cause = a.Cause
+
+	// Avoid storing a typed nil in the error interface, which would break unwrapping
+	e.Cause = nil
+	if a.Cause != nil {
+		e.Cause = a.Cause
+	}
 
 	return nil
 }
@@ -43,6 +58,81 @@ func (we *Error) Stack() string {
 	return we.Stacktrace
 }
 
+// Is reports whether the original error matches the given target. Errors are considered a match if they are of
+// the same type and have the same message. This allows checking for sentinel errors with errors.Is after an error
+// has been serialized.
+func (we *Error) Is(target error) bool {
+	if target == nil {
+		return false
+	}
+
+	if _, ok := target.(*Error); ok {
+		return false
+	}
+
+	return getErrorType(target) == we.Type && target.Error() == we.Message
+}
+
+// As restores the original error if target points to a value of the same type as the original error. Exported
+// fields of the original error are restored from the serialized details.
+func (we *Error) As(target interface{}) bool {
+	if we.Type == "" || target == nil {
+		return false
+	}
+
+	// Panic errors don't have exported fields, restore them from the message and stack trace
+	if pe, ok := target.(**PanicError); ok {
+		if we.Type != getErrorType(&PanicError{}) {
+			return false
+		}
+
+		*pe = &PanicError{message: we.Message, stacktrace: we.Stacktrace}
+		return true
+	}
+
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return false
+	}
+
+	t := v.Type().Elem()
+	if !t.Implements(errorType) {
+		return false
+	}
+
+	et := t
+	for et.Kind() == reflect.Ptr {
+		et = et.Elem()
+	}
+
+	if et.Kind() == reflect.Interface || et.Name() != we.Type {
+		return false
+	}
+
+	var n reflect.Value
+	if t.Kind() == reflect.Ptr {
+		n = reflect.New(t.Elem())
+	} else {
+		n = reflect.New(t)
+	}
+
+	if len(we.Details) > 0 {
+		if err := json.Unmarshal(we.Details, n.Interface()); err != nil {
+			return false
+		}
+	}
+
+	if t.Kind() == reflect.Ptr {
+		v.Elem().Set(n)
+	} else {
+		v.Elem().Set(n.Elem())
+	}
+
+	return true
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 var _ error = (*Error)(nil)
 
 // FromError wraps the given error into a workflow error which can be persisted and restored
@@ -61,10 +151,17 @@ func FromError(err error) *Error {
 		Message: err.Error(),
 	}
 
-	if stackTracer, ok := err.(interface{ Stack() string }); ok {
-		e.Stacktrace = stackTracer.Stack()
+	switch stackTracer := err.(type) {
+	case interface{ Stack() string }:
+		e.Stacktrace = truncateStack(stackTracer.Stack())
+
+	case interface{ Stack() []byte }:
+		// Errors created via github.com/go-errors/errors
+		e.Stacktrace = truncateStack(string(stackTracer.Stack()))
 	}
 
+	e.Details = errorDetails(err)
+
 	if cause := errors.Unwrap(err); cause != nil {
 		e.Cause = FromError(cause)
 	}
@@ -72,6 +169,26 @@ func FromError(err error) *Error {
 	return e
 }
 
+// errorDetails serializes the exported fields of the given error. It returns nil if the error does not have any
+// exported fields or cannot be serialized.
+func errorDetails(err error) json.RawMessage {
+	t := reflect.TypeOf(err)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	d, jerr := json.Marshal(err)
+	if jerr != nil || bytes.Equal(d, []byte("{}")) {
+		return nil
+	}
+
+	return d
+}
+
 // ToError attempts to convert the given workflow error into a regular error. It will create concrete errors for known error types
 // and maintain the Error for unknown ones
 func ToError(err *Error) error {
@@ -83,26 +200,108 @@ func ToError(err *Error) error {
 
 	switch err.Type {
 	case getErrorType(&PanicError{}):
+		if e.Permanent {
+			// Keep the workflow error so that the panic is not retried, it still matches *PanicError
+			return &e
+		}
+
 		return &PanicError{message: e.Message, stacktrace: e.Stacktrace}
 
+	case getErrorType(&TimeoutError{}):
+		te := &TimeoutError{}
+		if e.As(&te) {
+			return te
+		}
+
+		return &e
+
 	default:
 		// Keep *Error
 		return &e
 	}
 }
 
+// NewErrorWithPayload wraps the given error into a workflow error carrying the given payload. The payload is
+// serialized as JSON and can be retrieved from the error chain with DecodePayload.
+func NewErrorWithPayload(err error, payload interface{}) (*Error, error) {
+	p, jerr := json.Marshal(payload)
+	if jerr != nil {
+		return nil, fmt.Errorf("serializing error payload: %w", jerr)
+	}
+
+	// Copy, the given error might be a workflow error shared with other callers
+	e := *FromError(err)
+	e.Payload = p
+
+	return &e, nil
+}
+
+// DecodePayload decodes the payload of the first workflow error in the chain of err that carries one into v. Returns
+// false if there is no payload.
+func DecodePayload(err error, v interface{}) (bool, error) {
+	for err != nil {
+		if e, ok := err.(*Error); ok && len(e.Payload) > 0 {
+			if err := json.Unmarshal(e.Payload, v); err != nil {
+				return true, fmt.Errorf("deserializing error payload: %w", err)
+			}
+
+			return true, nil
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return false, nil
+}
+
 func NewPermanentError(err error) *Error {
 	e := FromError(err)
 	e.Permanent = true
 	return e
 }
 
-// CanRetry returns true if the given error is retryable
+// NewNonRetryableError wraps the given error into a workflow error which will not be automatically retried. It is
+// the same as NewPermanentError.
+func NewNonRetryableError(err error) *Error {
+	return NewPermanentError(err)
+}
+
+// CanRetry returns true if the given error is retryable. An error is not retryable if it, or any error in its
+// chain, is a permanent error.
 func CanRetry(err error) bool {
-	if e, ok := err.(*Error); ok {
-		return !e.Permanent
+	for err != nil {
+		if e, ok := err.(*Error); ok && e.Permanent {
+			return false
+		}
+
+		err = errors.Unwrap(err)
 	}
 
 	// Retry errors by default
 	return true
 }
+
+// HasType returns true if the given error, or any error in its chain, is of one of the given error types. For
+// workflow errors the original type of the wrapped error is used.
+func HasType(err error, types ...string) bool {
+	if len(types) == 0 {
+		return false
+	}
+
+	for err != nil {
+		errorType := getErrorType(err)
+		if e, ok := err.(*Error); ok {
+			errorType = e.Type
+		}
+
+		for _, t := range types {
+			if errorType != "" && errorType == t {
+				return true
+			}
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return false
+}
@@ -1,9 +1,12 @@
 package workflowerrors
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 
+	goerrors "github.com/go-errors/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -51,6 +54,17 @@ func Test_RoundTrip(t *testing.T) {
 	require.Equal(t, input, output)
 }
 
+func Test_RoundTrip_PermanentPanic(t *testing.T) {
+	e := NewPermanentError(NewPanicError("foo"))
+
+	output := ToError(e)
+	require.False(t, CanRetry(output))
+
+	var perr *PanicError
+	require.ErrorAs(t, output, &perr)
+	require.Equal(t, "foo", perr.Error())
+}
+
 func TestCanRetry(t *testing.T) {
 	tests := []struct {
 		name string
@@ -67,6 +81,21 @@ func TestCanRetry(t *testing.T) {
 			err:  NewPermanentError(errors.New("foo")),
 			want: false,
 		},
+		{
+			name: "NonRetryable",
+			err:  NewNonRetryableError(errors.New("foo")),
+			want: false,
+		},
+		{
+			name: "Wrapped permanent",
+			err:  fmt.Errorf("wrapped: %w", NewPermanentError(errors.New("foo"))),
+			want: false,
+		},
+		{
+			name: "Serialized wrapped permanent",
+			err:  FromError(fmt.Errorf("wrapped: %w", NewPermanentError(errors.New("foo")))),
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -76,3 +105,146 @@ func TestCanRetry(t *testing.T) {
 		})
 	}
 }
+
+func TestHasType(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		types []string
+		want  bool
+	}{
+		{
+			name:  "No types",
+			err:   &CustomError{msg: "foo"},
+			types: nil,
+			want:  false,
+		},
+		{
+			name:  "String error",
+			err:   errors.New("foo"),
+			types: []string{""},
+			want:  false,
+		},
+		{
+			name:  "Matching type",
+			err:   &CustomError{msg: "foo"},
+			types: []string{"OtherError", "CustomError"},
+			want:  true,
+		},
+		{
+			name:  "Different type",
+			err:   &CustomError{msg: "foo"},
+			types: []string{"OtherError"},
+			want:  false,
+		},
+		{
+			name:  "Workflow error",
+			err:   FromError(&CustomError{msg: "foo"}),
+			types: []string{"CustomError"},
+			want:  true,
+		},
+		{
+			name:  "Wrapped",
+			err:   FromError(fmt.Errorf("wrapped: %w", &CustomError{msg: "foo"})),
+			types: []string{"CustomError"},
+			want:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, HasType(tt.err, tt.types...))
+		})
+	}
+}
+
+var errSentinel = errors.New("sentinel")
+
+type DetailedError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func (e *DetailedError) Error() string {
+	return e.Msg
+}
+
+func roundTrip(t *testing.T, err error) error {
+	b, jerr := json.Marshal(FromError(err))
+	require.NoError(t, jerr)
+
+	var e *Error
+	require.NoError(t, json.Unmarshal(b, &e))
+
+	return ToError(e)
+}
+
+func Test_ErrorChain_Is(t *testing.T) {
+	err := roundTrip(t, fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", errSentinel)))
+
+	require.True(t, errors.Is(err, errSentinel))
+	require.False(t, errors.Is(err, errors.New("other")))
+	require.Equal(t, "outer: inner: sentinel", err.Error())
+}
+
+func Test_ErrorChain_As(t *testing.T) {
+	err := roundTrip(t, fmt.Errorf("outer: %w", &DetailedError{Code: 42, Msg: "detailed"}))
+
+	var de *DetailedError
+	require.True(t, errors.As(err, &de))
+	require.Equal(t, 42, de.Code)
+	require.Equal(t, "detailed", de.Msg)
+
+	var ce *CustomError
+	require.False(t, errors.As(err, &ce))
+
+	var we *Error
+	require.True(t, errors.As(err, &we))
+	require.Equal(t, "outer: detailed", we.Message)
+}
+
+func Test_ErrorChain_Stack(t *testing.T) {
+	err := roundTrip(t, fmt.Errorf("outer: %w", goerrors.New("inner")))
+
+	var we *Error
+	require.True(t, errors.As(err, &we))
+	require.Empty(t, we.Stack())
+
+	require.True(t, errors.As(we.Cause, &we))
+	require.Contains(t, we.Stack(), "Test_ErrorChain_Stack")
+}
+
+func Test_ErrorChain_Panic(t *testing.T) {
+	pe := NewPanicError("panic: boom")
+	err := roundTrip(t, fmt.Errorf("outer: %w", pe))
+
+	var rpe *PanicError
+	require.True(t, errors.As(err, &rpe))
+	require.Equal(t, "panic: boom", rpe.Error())
+	require.Equal(t, pe.Stack(), rpe.Stack())
+}
+
+func Test_ErrorPayload(t *testing.T) {
+	type details struct {
+		Retries int `json:"retries"`
+	}
+
+	e, err := NewErrorWithPayload(errSentinel, details{Retries: 3})
+	require.NoError(t, err)
+
+	restored := roundTrip(t, fmt.Errorf("outer: %w", NewPermanentError(e)))
+	require.True(t, errors.Is(restored, errSentinel))
+	require.False(t, CanRetry(restored))
+
+	var d details
+	ok, err := DecodePayload(restored, &d)
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.Equal(t, 3, d.Retries)
+
+	ok, err = DecodePayload(roundTrip(t, errSentinel), &d)
+	require.False(t, ok)
+	require.NoError(t, err)
+
+	_, err = NewErrorWithPayload(errSentinel, func() {})
+	require.Error(t, err)
+}
@@ -0,0 +1,10 @@
+package workflowerrors
+
+import "errors"
+
+// ErrActivityOutcomeUnknown is returned for at-most-once activities if their task was delivered again. The previous
+// delivery might or might not have executed the activity.
+var ErrActivityOutcomeUnknown = errors.New("activity execution outcome unknown")
+
+// ErrTerminated is the error sub-workflows fail with if they have been terminated
+var ErrTerminated = errors.New("workflow terminated")
@@ -9,6 +9,11 @@ import (
 
 const MaxStackDepth = 50
 
+// MaxStackSize is the maximum size in bytes of stack traces stored with errors. Longer stack traces are truncated.
+const MaxStackSize = 8 * 1024
+
+const truncatedStackSuffix = "\n...(truncated)"
+
 // stack returns a stacktrace formatted via go-errors/errors
 func stack(skip int) string {
 	// get stack
@@ -30,5 +35,14 @@ func stack(skip int) string {
 		buf.WriteString(frame.String())
 	}
 
-	return string(buf.Bytes())
+	return truncateStack(string(buf.Bytes()))
+}
+
+// truncateStack limits the given stack trace to MaxStackSize bytes
+func truncateStack(s string) string {
+	if len(s) <= MaxStackSize {
+		return s
+	}
+
+	return s[:MaxStackSize-len(truncatedStackSuffix)] + truncatedStackSuffix
 }
@@ -1,6 +1,7 @@
 package workflowerrors
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -22,3 +23,11 @@ func foo(fn func()) {
 func bar(fn func()) {
 	fn()
 }
+
+func Test_truncateStack(t *testing.T) {
+	s := truncateStack(strings.Repeat("a", MaxStackSize*2))
+	require.Len(t, s, MaxStackSize)
+	require.True(t, strings.HasSuffix(s, truncatedStackSuffix))
+
+	require.Equal(t, "short", truncateStack("short"))
+}
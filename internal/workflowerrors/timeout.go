@@ -0,0 +1,46 @@
+package workflowerrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+type TimeoutKind string
+
+const (
+	// TimeoutKindScheduleToStart indicates that an activity was not picked up by a worker in time
+	TimeoutKindScheduleToStart TimeoutKind = "ScheduleToStart"
+
+	// TimeoutKindStartToClose indicates that an activity did not complete in time after it was started
+	TimeoutKindStartToClose TimeoutKind = "StartToClose"
+
+	// TimeoutKindHeartbeat indicates that an activity did not send a heartbeat in time
+	TimeoutKindHeartbeat TimeoutKind = "Heartbeat"
+
+	// TimeoutKindExecution indicates that a workflow instance did not complete in time
+	TimeoutKindExecution TimeoutKind = "Execution"
+)
+
+// TimeoutError is returned when an operation did not complete within a configured timeout. Kind describes which
+// timeout fired.
+type TimeoutError struct {
+	Kind TimeoutKind `json:"kind"`
+}
+
+// ErrExecutionTimedOut matches timeout errors of kind TimeoutKindExecution
+var ErrExecutionTimedOut = errors.New("workflow timed out")
+
+func (te *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timeout", te.Kind)
+}
+
+// Is matches ErrExecutionTimedOut for execution timeouts
+func (te *TimeoutError) Is(target error) bool {
+	return target == ErrExecutionTimedOut && te.Kind == TimeoutKindExecution
+}
+
+func NewTimeoutError(kind TimeoutKind) *TimeoutError {
+	return &TimeoutError{
+		Kind: kind,
+	}
+}
@@ -0,0 +1,32 @@
+package workflowerrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TimeoutError_RoundTrip(t *testing.T) {
+	input := NewTimeoutError(TimeoutKindStartToClose)
+
+	output := ToError(FromError(input))
+	require.Equal(t, input, output)
+}
+
+func Test_TimeoutError_Wrapped(t *testing.T) {
+	err := roundTrip(t, fmt.Errorf("activity failed: %w", NewTimeoutError(TimeoutKindScheduleToStart)))
+
+	var te *TimeoutError
+	require.True(t, errors.As(err, &te))
+	require.Equal(t, TimeoutKindScheduleToStart, te.Kind)
+	require.Equal(t, "ScheduleToStart timeout", te.Error())
+}
+
+func Test_TimeoutError_MatchesExecutionTimedOut(t *testing.T) {
+	err := roundTrip(t, NewTimeoutError(TimeoutKindExecution))
+	require.ErrorIs(t, err, ErrExecutionTimedOut)
+
+	require.NotErrorIs(t, NewTimeoutError(TimeoutKindStartToClose), ErrExecutionTimedOut)
+}
@@ -0,0 +1,16 @@
+package workflowstate
+
+import "github.com/cschleiden/go-workflows/internal/payload"
+
+// QueryHandler answers a query with the given arguments from the current state of the workflow
+type QueryHandler func(args []payload.Payload) (payload.Payload, error)
+
+// SetQueryHandler registers the handler for the given query, replacing any handler previously registered for it
+func (wf *WfState) SetQueryHandler(name string, handler QueryHandler) {
+	wf.queryHandlers[name] = handler
+}
+
+func (wf *WfState) QueryHandler(name string) (QueryHandler, bool) {
+	h, ok := wf.queryHandlers[name]
+	return h, ok
+}
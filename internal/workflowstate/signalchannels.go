@@ -1,6 +1,8 @@
 package workflowstate
 
 import (
+	"sort"
+
 	"github.com/cschleiden/go-workflows/internal/converter"
 	"github.com/cschleiden/go-workflows/internal/payload"
 	"github.com/cschleiden/go-workflows/internal/sync"
@@ -29,8 +31,9 @@ func GetSignalChannel[T any](ctx sync.Context, wf *WfState, name string) sync.Ch
 		return sc.channel.(sync.Channel[T])
 	}
 
-	// Otherwise, create new channel
-	c := sync.NewBufferedChannel[T](100)
+	// Otherwise, create new channel. Signals are never dropped, so the channel needs to be able to buffer any
+	// number of signals that have not been received yet.
+	c := sync.NewUnboundedChannel[T]()
 
 	converter := converter.GetConverter(ctx)
 
@@ -42,25 +45,39 @@ func GetSignalChannel[T any](ctx sync.Context, wf *WfState, name string) sync.Ch
 				panic(err)
 			}
 
-			// Channel is buffered, so we can just send without waiting and potentially
+			// Channel is unbounded, so we can just send without waiting and potentially
 			// blocking on a Yield.
 			c.SendNonblocking(t)
 		},
+		drain: func() ([]payload.Payload, error) {
+			var payloads []payload.Payload
+			for {
+				v, ok := c.ReceiveNonBlocking()
+				if !ok {
+					return payloads, nil
+				}
+
+				p, err := converter.To(v)
+				if err != nil {
+					return nil, err
+				}
+
+				payloads = append(payloads, p)
+			}
+		},
 		channel: c,
 	}
 
-	// Check for any pending signals, if there are, send to the channel in reverse order
+	// Deliver any signals received before the channel was created, in the order they were received
 	pendingSignals, ok := wf.pendingSignals[name]
 	if ok {
-		for i := len(pendingSignals) - 1; i >= 0; i-- {
-			payload := pendingSignals[i]
-
+		for _, payload := range pendingSignals {
 			var s T
 			if err := converter.From(payload, &s); err != nil {
 				panic(err)
 			}
 
-			c.Send(ctx, s)
+			c.SendNonblocking(s)
 		}
 
 		delete(wf.pendingSignals, name)
@@ -68,3 +85,43 @@ func GetSignalChannel[T any](ctx sync.Context, wf *WfState, name string) sync.Ch
 
 	return c
 }
+
+// PendingSignal is a signal that has been received by the workflow, but not been consumed yet
+type PendingSignal struct {
+	Name string
+	Arg  payload.Payload
+}
+
+// DrainPendingSignals removes and returns all signals that have been received but not consumed by the workflow. They
+// are ordered by name and, for each name, in the order they were received.
+func DrainPendingSignals(wf *WfState) ([]PendingSignal, error) {
+	names := make([]string, 0, len(wf.pendingSignals)+len(wf.signalChannels))
+	for name := range wf.pendingSignals {
+		names = append(names, name)
+	}
+	for name := range wf.signalChannels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var signals []PendingSignal
+	for _, name := range names {
+		// Signals are either pending or delivered to a channel, never both
+		args := wf.pendingSignals[name]
+		delete(wf.pendingSignals, name)
+
+		if sc, ok := wf.signalChannels[name]; ok {
+			var err error
+			args, err = sc.drain()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, arg := range args {
+			signals = append(signals, PendingSignal{Name: name, Arg: arg})
+		}
+	}
+
+	return signals, nil
+}
@@ -0,0 +1,65 @@
+package workflowstate
+
+import (
+	"testing"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/logger"
+	"github.com/cschleiden/go-workflows/internal/sync"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Signals_BufferedBeforeChannel(t *testing.T) {
+	i := core.NewWorkflowInstance(uuid.NewString(), "")
+	wfState := NewWorkflowState(i, logger.NewDefaultLogger(), clock.New())
+
+	const signals = 250
+
+	send := func(v int) {
+		p, err := converter.DefaultConverter.To(v)
+		require.NoError(t, err)
+
+		ReceiveSignal(wfState, "signal", p)
+	}
+
+	// Signals received before the workflow created the channel
+	for v := 0; v < signals; v++ {
+		send(v)
+	}
+
+	ctx := converter.WithConverter(sync.Background(), converter.DefaultConverter)
+
+	var received []int
+
+	c := sync.NewCoroutine(ctx, func(ctx sync.Context) error {
+		sc := GetSignalChannel[int](ctx, wfState, "signal")
+
+		for j := 0; j < signals*2; j++ {
+			v, ok := sc.Receive(ctx)
+			require.True(t, ok)
+
+			received = append(received, v)
+		}
+
+		return nil
+	})
+
+	c.Execute()
+	require.Len(t, received, signals)
+
+	// Signals received after the channel has been created
+	for v := signals; v < signals*2; v++ {
+		send(v)
+	}
+
+	c.Execute()
+	require.True(t, c.Finished())
+	require.NoError(t, c.Error())
+
+	for v := 0; v < signals*2; v++ {
+		require.Equal(t, v, received[v])
+	}
+}
@@ -0,0 +1,16 @@
+package workflowstate
+
+import "github.com/cschleiden/go-workflows/internal/payload"
+
+// UpdateHandler validates and applies an update with the given arguments to the state of the workflow
+type UpdateHandler func(args []payload.Payload) (payload.Payload, error)
+
+// SetUpdateHandler registers the handler for the given update, replacing any handler previously registered for it
+func (wf *WfState) SetUpdateHandler(name string, handler UpdateHandler) {
+	wf.updateHandlers[name] = handler
+}
+
+func (wf *WfState) UpdateHandler(name string) (UpdateHandler, bool) {
+	h, ok := wf.updateHandlers[name]
+	return h, ok
+}
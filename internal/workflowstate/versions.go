@@ -0,0 +1,11 @@
+package workflowstate
+
+// SetVersion records the version of the given change to the workflow code
+func (wf *WfState) SetVersion(changeID string, version int) {
+	wf.versions[changeID] = version
+}
+
+func (wf *WfState) Version(changeID string) (int, bool) {
+	v, ok := wf.versions[changeID]
+	return v, ok
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/cschleiden/go-workflows/internal/payload"
 	"github.com/cschleiden/go-workflows/internal/sync"
 	"github.com/cschleiden/go-workflows/log"
+	"github.com/google/uuid"
 )
 
 type key int
@@ -42,11 +43,14 @@ func AsDecodingSettable[T any](cv converter.Converter, f sync.SettableFuture[T])
 
 type signalChannel struct {
 	receive func(payload.Payload)
+	drain   func() ([]payload.Payload, error)
 	channel interface{}
 }
 
 type WfState struct {
 	instance        *core.WorkflowInstance
+	priority        core.Priority
+	queue           core.Queue
 	scheduleEventID int64
 	commands        []command.Command
 	pendingFutures  map[int64]DecodingSettable
@@ -55,8 +59,18 @@ type WfState struct {
 	pendingSignals map[string][]payload.Payload
 	signalChannels map[string]*signalChannel
 
+	queryHandlers  map[string]QueryHandler
+	updateHandlers map[string]UpdateHandler
+
+	versions map[string]int
+
+	continueAsNewSuggested bool
+
 	logger log.Logger
 
+	// newID generates the instance and execution IDs of sub-workflows and continued executions
+	newID func() string
+
 	clock clock.Clock
 	time  time.Time
 }
@@ -71,6 +85,13 @@ func NewWorkflowState(instance *core.WorkflowInstance, logger log.Logger, clock
 		pendingSignals: map[string][]payload.Payload{},
 		signalChannels: make(map[string]*signalChannel),
 
+		queryHandlers:  map[string]QueryHandler{},
+		updateHandlers: map[string]UpdateHandler{},
+
+		versions: map[string]int{},
+
+		newID: uuid.NewString,
+
 		clock: clock,
 	}
 
@@ -150,6 +171,45 @@ func (wf *WfState) Instance() *core.WorkflowInstance {
 	return wf.instance
 }
 
+// SetPriority sets the priority of the workflow instance, which sub-workflows and continued executions inherit
+func (wf *WfState) SetPriority(priority core.Priority) {
+	wf.priority = priority
+}
+
+func (wf *WfState) Priority() core.Priority {
+	return wf.priority
+}
+
+// SetQueue sets the queue of the workflow instance, which sub-workflows, continued executions, and activities inherit
+func (wf *WfState) SetQueue(queue core.Queue) {
+	wf.queue = queue
+}
+
+func (wf *WfState) Queue() core.Queue {
+	return wf.queue
+}
+
 func (wf *WfState) Logger() log.Logger {
 	return wf.logger
 }
+
+// SetIDGenerator sets the function generating the instance and execution IDs of sub-workflows and continued
+// executions
+func (wf *WfState) SetIDGenerator(newID func() string) {
+	wf.newID = newID
+}
+
+// NewID returns a new instance or execution ID
+func (wf *WfState) NewID() string {
+	return wf.newID()
+}
+
+// SetContinueAsNewSuggested records whether the history of the execution has grown large enough that the workflow
+// should continue as new
+func (wf *WfState) SetContinueAsNewSuggested(suggested bool) {
+	wf.continueAsNewSuggested = suggested
+}
+
+func (wf *WfState) ContinueAsNewSuggested() bool {
+	return wf.continueAsNewSuggested
+}
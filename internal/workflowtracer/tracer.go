@@ -77,3 +77,7 @@ func (s *Span) End() {
 		s.span.End()
 	}
 }
+
+func (s *Span) SpanContext() trace.SpanContext {
+	return s.span.SpanContext()
+}
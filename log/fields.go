@@ -13,6 +13,7 @@ const (
 	WorkflowNameKey = NamespaceKey + ".workflow.name"
 
 	SignalNameKey = NamespaceKey + ".signal.name"
+	UpdateNameKey = NamespaceKey + ".update.name"
 
 	SeqIDKey       = NamespaceKey + ".seq_id"
 	IsReplayingKey = NamespaceKey + ".is_replaying"
@@ -29,6 +30,10 @@ const (
 	ExecutedEventsKey        = NamespaceKey + ".task.executed_events"
 	NewEventsKey             = NamespaceKey + ".task.new_events"
 
+	// SpanLinkTypeKey describes whether a span link points to the parent or a child workflow execution, or to the span
+	// that scheduled an activity or timer
+	SpanLinkTypeKey = NamespaceKey + ".link.type"
+
 	AttemptKey  = NamespaceKey + ".attempt"
 	DurationKey = NamespaceKey + ".duration_ms"
 
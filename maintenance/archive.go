@@ -0,0 +1,26 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+// ArchiveJob periodically archives the histories of finished workflow instances that couldn't be archived when the
+// instances finished, for backends configured with an archiver via backend.WithArchiver.
+func ArchiveJob(interval time.Duration) Job {
+	return Job{
+		Name:     "archive",
+		Interval: interval,
+		Run: func(ctx context.Context, b backend.Backend) error {
+			r, ok := b.(backend.ArchiveRetrier)
+			if !ok {
+				return nil
+			}
+
+			_, err := r.ArchiveWorkflowInstanceHistories(ctx)
+			return err
+		},
+	}
+}
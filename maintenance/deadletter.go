@@ -0,0 +1,47 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+// DeadLetterRedriveJob periodically retries dead-lettered workflow instances for which redrive returns true, for
+// example, instances dead-lettered because of a transient failure. A nil redrive retries all dead-lettered instances.
+// Instances that keep failing are dead-lettered again after worker.Options.MaxWorkflowTaskAttempts attempts, and
+// retried again by the next run of the job.
+func DeadLetterRedriveJob(interval time.Duration, redrive func(*backend.WorkflowInstanceInfo) bool) Job {
+	return Job{
+		Name:     "dead-letter-redrive",
+		Interval: interval,
+		Run: func(ctx context.Context, b backend.Backend) error {
+			q := &backend.ListWorkflowInstancesQuery{
+				State: backend.InstanceStateDeadLettered,
+			}
+
+			for {
+				r, err := b.ListWorkflowInstances(ctx, q)
+				if err != nil {
+					return err
+				}
+
+				for _, i := range r.Instances {
+					if redrive != nil && !redrive(i) {
+						continue
+					}
+
+					if err := b.RetryWorkflowInstance(ctx, i.Instance); err != nil {
+						return err
+					}
+				}
+
+				if r.NextPageToken == "" {
+					return nil
+				}
+
+				q.PageToken = r.NextPageToken
+			}
+		},
+	}
+}
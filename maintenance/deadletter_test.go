@@ -0,0 +1,39 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DeadLetterRedriveJob(t *testing.T) {
+	ctx := context.Background()
+	b := sqlite.NewInMemoryBackend()
+
+	for _, id := range []string{"a", "b"} {
+		require.NoError(t, b.CreateWorkflowInstance(ctx, core.NewWorkflowInstance(id, id), history.NewHistoryEvent(
+			1, time.Now(), history.EventType_WorkflowExecutionStarted, &history.ExecutionStartedAttributes{})))
+
+		task, err := b.GetWorkflowTask(ctx)
+		require.NoError(t, err)
+		require.NoError(t, b.DeadLetterWorkflowTask(ctx, task, "failed "+id))
+	}
+
+	job := DeadLetterRedriveJob(time.Second, func(i *backend.WorkflowInstanceInfo) bool {
+		return i.DeadLetterReason == "failed a"
+	})
+	require.NoError(t, job.Run(ctx, b))
+
+	r, err := b.ListWorkflowInstances(ctx, &backend.ListWorkflowInstancesQuery{
+		State: backend.InstanceStateDeadLettered,
+	})
+	require.NoError(t, err)
+	require.Len(t, r.Instances, 1)
+	require.Equal(t, "b", r.Instances[0].Instance.InstanceID)
+}
@@ -0,0 +1,34 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/metrickeys"
+	"github.com/cschleiden/go-workflows/metrics"
+)
+
+// StatsJob periodically records the stats of the backend as gauges via the metrics client of the backend
+func StatsJob(interval time.Duration) Job {
+	return Job{
+		Name:     "stats",
+		Interval: interval,
+		Run: func(ctx context.Context, b backend.Backend) error {
+			s, err := b.GetStats(ctx)
+			if err != nil {
+				return err
+			}
+
+			b.Metrics().Gauge(metrickeys.ActiveWorkflowInstances, metrics.Tags{}, s.ActiveWorkflowInstances)
+			b.Metrics().Gauge(metrickeys.PendingActivities, metrics.Tags{}, s.PendingActivities)
+			b.Metrics().Gauge(metrickeys.PendingWorkflowTasks, metrics.Tags{}, s.PendingWorkflowTasks)
+			b.Metrics().Gauge(metrickeys.LockedWorkflowTasks, metrics.Tags{}, s.LockedWorkflowTasks)
+			b.Metrics().Gauge(metrickeys.PendingActivityTasks, metrics.Tags{}, s.PendingActivityTasks)
+			b.Metrics().Gauge(metrickeys.LockedActivityTasks, metrics.Tags{}, s.LockedActivityTasks)
+			b.Metrics().Gauge(metrickeys.PendingTimers, metrics.Tags{}, s.PendingTimers)
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,165 @@
+// Package maintenance runs periodic maintenance jobs against a backend. Runners can be started standalone or as part
+// of a worker. When multiple runners share the same storage and namespace, only the runner holding the maintenance
+// lease executes jobs.
+//
+// There is no job expiring stale task locks: the backends reclaim tasks whose lock has expired when workers poll for
+// tasks.
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/google/uuid"
+)
+
+// LeaseName is the name of the lease used to elect the runner executing maintenance jobs
+const LeaseName = "maintenance"
+
+// Job is a maintenance job executed periodically by a Runner
+type Job struct {
+	// Name of the job, used for logging
+	Name string
+
+	// Interval between executions of the job
+	Interval time.Duration
+
+	// Run executes the job. Errors are logged, the job is executed again after the next interval.
+	Run func(ctx context.Context, b backend.Backend) error
+}
+
+type Runner struct {
+	backend backend.Backend
+
+	options Options
+
+	// holder identifies this runner when acquiring the lease
+	holder string
+
+	leader atomic.Bool
+
+	wg sync.WaitGroup
+}
+
+func New(b backend.Backend, opts ...Option) *Runner {
+	options := DefaultOptions
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &Runner{
+		backend: b,
+		options: options,
+		holder:  fmt.Sprintf("maintenance-%v", uuid.NewString()),
+	}
+}
+
+// Start starts the runner. To stop the runner, cancel the context passed to Start. To wait for running jobs to
+// finish, call WaitForCompletion.
+func (r *Runner) Start(ctx context.Context) error {
+	if r.options.LeaseDuration <= 0 {
+		return errors.New("maintenance lease duration must be positive")
+	}
+
+	for _, job := range r.options.Jobs {
+		if job.Interval <= 0 {
+			return fmt.Errorf("maintenance job %v: interval must be positive", job.Name)
+		}
+	}
+
+	r.wg.Add(1)
+	go r.runLeaderElection(ctx)
+
+	for _, job := range r.options.Jobs {
+		r.wg.Add(1)
+		go r.runJob(ctx, job)
+	}
+
+	return nil
+}
+
+func (r *Runner) WaitForCompletion() error {
+	r.wg.Wait()
+
+	return nil
+}
+
+// IsLeader returns true if this runner currently executes maintenance jobs
+func (r *Runner) IsLeader() bool {
+	return r.leader.Load()
+}
+
+func (r *Runner) runLeaderElection(ctx context.Context) {
+	defer r.wg.Done()
+
+	l, ok := r.backend.(backend.Leaser)
+	if !ok {
+		r.runWithoutLease()
+		return
+	}
+
+	// Renew the lease well before it expires
+	t := time.NewTicker(r.options.LeaseDuration / 3)
+	defer t.Stop()
+
+	for {
+		acquired, err := l.AcquireLease(ctx, LeaseName, r.holder, r.options.LeaseDuration)
+		if errors.Is(err, backend.ErrLeasesNotSupported) {
+			// Decorators implement Leaser regardless of the backend they wrap
+			r.runWithoutLease()
+			return
+		}
+
+		if err != nil && ctx.Err() == nil {
+			r.backend.Logger().Error("acquiring maintenance lease", "error", err)
+		}
+
+		r.leader.Store(acquired && err == nil)
+
+		select {
+		case <-ctx.Done():
+			if r.leader.Swap(false) {
+				// Allow another runner to take over immediately
+				if err := l.ReleaseLease(context.Background(), LeaseName, r.holder); err != nil {
+					r.backend.Logger().Error("releasing maintenance lease", "error", err)
+				}
+			}
+
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (r *Runner) runWithoutLease() {
+	r.backend.Logger().Warn("backend does not support leases, maintenance jobs run in every runner")
+	r.leader.Store(true)
+}
+
+func (r *Runner) runJob(ctx context.Context, job Job) {
+	defer r.wg.Done()
+
+	t := time.NewTicker(job.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if !r.IsLeader() {
+				continue
+			}
+
+			if err := job.Run(ctx, r.backend); err != nil && ctx.Err() == nil {
+				r.backend.Logger().Error("running maintenance job", "job", job.Name, "error", err)
+			}
+		}
+	}
+}
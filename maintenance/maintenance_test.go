@@ -0,0 +1,92 @@
+package maintenance
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/hooks"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/stretchr/testify/require"
+)
+
+func countingJob(runs *atomic.Int32) Job {
+	return Job{
+		Name:     "count",
+		Interval: time.Millisecond * 10,
+		Run: func(ctx context.Context, b backend.Backend) error {
+			runs.Add(1)
+			return nil
+		},
+	}
+}
+
+func Test_Runner_OnlyLeaderRunsJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maintenance.sqlite")
+
+	var runsA, runsB atomic.Int32
+	a := New(sqlite.NewSqliteBackend(path), WithJobs(countingJob(&runsA)), WithLeaseDuration(time.Millisecond*300))
+	b := New(sqlite.NewSqliteBackend(path), WithJobs(countingJob(&runsB)), WithLeaseDuration(time.Millisecond*300))
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	defer cancelA()
+	require.NoError(t, a.Start(ctxA))
+
+	require.Eventually(t, a.IsLeader, time.Second, time.Millisecond*10)
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	require.NoError(t, b.Start(ctxB))
+
+	time.Sleep(time.Millisecond * 200)
+
+	require.False(t, b.IsLeader())
+	require.Greater(t, runsA.Load(), int32(0))
+	require.Zero(t, runsB.Load())
+
+	// Stopping the leader releases the lease, the other runner takes over
+	cancelA()
+	require.NoError(t, a.WaitForCompletion())
+
+	require.Eventually(t, b.IsLeader, time.Second, time.Millisecond*10)
+	require.Eventually(t, func() bool { return runsB.Load() > 0 }, time.Second, time.Millisecond*10)
+
+	cancelB()
+	require.NoError(t, b.WaitForCompletion())
+}
+
+func Test_Runner_DecoratedBackendWithoutLeases(t *testing.T) {
+	// Hide the leases of the sqlite backend, the decorator still implements backend.Leaser
+	b := hooks.NewBackend(struct{ backend.Backend }{sqlite.NewInMemoryBackend()})
+
+	_, err := b.(backend.Leaser).AcquireLease(context.Background(), LeaseName, "holder", time.Minute)
+	require.ErrorIs(t, err, backend.ErrLeasesNotSupported)
+
+	var runs atomic.Int32
+	r := New(b, WithJobs(countingJob(&runs)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, r.Start(ctx))
+
+	require.Eventually(t, r.IsLeader, time.Second, time.Millisecond*10)
+	require.Eventually(t, func() bool { return runs.Load() > 0 }, time.Second, time.Millisecond*10)
+
+	cancel()
+	require.NoError(t, r.WaitForCompletion())
+}
+
+func Test_Runner_InvalidInterval(t *testing.T) {
+	r := New(sqlite.NewInMemoryBackend(), WithJobs(Job{Name: "invalid"}))
+
+	require.Error(t, r.Start(context.Background()))
+}
+
+func Test_Runner_InvalidLeaseDuration(t *testing.T) {
+	r := New(sqlite.NewInMemoryBackend(), WithLeaseDuration(0))
+
+	require.Error(t, r.Start(context.Background()))
+}
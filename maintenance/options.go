@@ -0,0 +1,32 @@
+package maintenance
+
+import "time"
+
+type Options struct {
+	// Jobs are the maintenance jobs executed by the runner
+	Jobs []Job
+
+	// LeaseDuration determines how long a runner keeps executing jobs without being able to renew its lease, and how
+	// long it takes for another runner to take over if the leader stops unexpectedly. Defaults to 30 seconds.
+	LeaseDuration time.Duration
+}
+
+var DefaultOptions = Options{
+	LeaseDuration: 30 * time.Second,
+}
+
+type Option func(*Options)
+
+// WithJobs adds the given jobs to the runner
+func WithJobs(jobs ...Job) Option {
+	return func(o *Options) {
+		o.Jobs = append(o.Jobs, jobs...)
+	}
+}
+
+// WithLeaseDuration sets the duration of the maintenance lease. See Options.LeaseDuration.
+func WithLeaseDuration(duration time.Duration) Option {
+	return func(o *Options) {
+		o.LeaseDuration = duration
+	}
+}
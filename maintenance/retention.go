@@ -0,0 +1,26 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+)
+
+// RetentionJob periodically removes finished workflow instances of backends configured with a retention period via
+// backend.WithRetention, once they have been finished for longer than the period.
+func RetentionJob(interval time.Duration) Job {
+	return Job{
+		Name:     "retention",
+		Interval: interval,
+		Run: func(ctx context.Context, b backend.Backend) error {
+			r, ok := b.(backend.Retainer)
+			if !ok || r.Retention() <= 0 {
+				return nil
+			}
+
+			_, err := backend.RemoveFinishedWorkflowInstances(ctx, b, time.Now().Add(-r.Retention()), "")
+			return err
+		},
+	}
+}
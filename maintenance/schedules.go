@@ -0,0 +1,130 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/log"
+)
+
+// SchedulesJob periodically starts workflow instances for due schedules of backends implementing
+// backend.ScheduleStore. Runs missed while no runner executed the job, for example, because all workers were
+// stopped, are skipped.
+func SchedulesJob(interval time.Duration) Job {
+	return Job{
+		Name:     "schedules",
+		Interval: interval,
+		Run: func(ctx context.Context, b backend.Backend) error {
+			ss, ok := b.(backend.ScheduleStore)
+			if !ok {
+				return nil
+			}
+
+			schedules, err := ss.ListSchedules(ctx)
+			if err != nil {
+				if errors.Is(err, backend.ErrSchedulesNotSupported) {
+					// Backend decorator wrapping a backend without schedules
+					return nil
+				}
+
+				return fmt.Errorf("listing schedules: %w", err)
+			}
+
+			now := time.Now()
+
+			for _, s := range schedules {
+				if s.NextRunAt.After(now) {
+					continue
+				}
+
+				// A failing schedule shouldn't hold up the others, it's tried again in the next interval
+				if err := runSchedule(ctx, b, ss, s, now); err != nil && ctx.Err() == nil {
+					b.Logger().Error("running schedule", "schedule", s.ID, log.ErrorKey, err)
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+func runSchedule(ctx context.Context, b backend.Backend, ss backend.ScheduleStore, s *backend.Schedule, now time.Time) error {
+	if s.LastInstance != nil {
+		state, err := b.GetWorkflowInstanceState(ctx, s.LastInstance)
+		if err != nil && !errors.Is(err, backend.ErrInstanceNotFound) {
+			return fmt.Errorf("getting state of previous instance: %w", err)
+		}
+
+		if err == nil && state == core.WorkflowInstanceStateActive {
+			switch s.OverlapPolicy {
+			case backend.OverlapSkip:
+				return advanceSchedule(ctx, ss, s, now)
+
+			case backend.OverlapBuffer:
+				// Stay due until the previous instance has finished
+				return nil
+
+			case backend.OverlapCancelPrevious:
+				if err := b.CancelWorkflowInstance(ctx, s.LastInstance, history.NewWorkflowCancellationEvent(now)); err != nil &&
+					!errors.Is(err, backend.ErrInstanceNotFound) {
+					return fmt.Errorf("canceling previous instance: %w", err)
+				}
+			}
+		}
+	}
+
+	// Derive the instance ID from the run, so a run isn't started again while its instance is active if updating the
+	// schedule fails
+	instance := core.NewWorkflowInstance(fmt.Sprintf("%v-%v", s.ID, s.NextRunAt.Unix()), backend.NewID(b))
+
+	var executionDeadline *time.Time
+	if s.ExecutionTimeout > 0 {
+		deadline := now.Add(s.ExecutionTimeout)
+		executionDeadline = &deadline
+	}
+
+	startedEvent := history.NewPendingEvent(
+		now,
+		history.EventType_WorkflowExecutionStarted,
+		&history.ExecutionStartedAttributes{
+			Metadata:          s.Metadata,
+			Name:              s.WorkflowName,
+			Inputs:            s.Inputs,
+			Priority:          s.Priority,
+			Queue:             s.Queue,
+			ExecutionDeadline: executionDeadline,
+			Memo:              s.Memo,
+		})
+
+	if err := b.CreateWorkflowInstance(ctx, instance, startedEvent); err != nil {
+		if !errors.Is(err, backend.ErrInstanceAlreadyExists) {
+			return fmt.Errorf("creating workflow instance: %w", err)
+		}
+	} else {
+		s.LastInstance = instance
+
+		b.Logger().Debug("Started scheduled workflow instance", "schedule", s.ID, log.InstanceIDKey, instance.InstanceID)
+	}
+
+	return advanceSchedule(ctx, ss, s, now)
+}
+
+func advanceSchedule(ctx context.Context, ss backend.ScheduleStore, s *backend.Schedule, now time.Time) error {
+	next, err := s.Next(now)
+	if err != nil {
+		return fmt.Errorf("calculating next run: %w", err)
+	}
+
+	s.NextRunAt = next
+
+	if err := ss.UpdateSchedule(ctx, s); err != nil && !errors.Is(err, backend.ErrScheduleNotFound) {
+		return fmt.Errorf("updating schedule: %w", err)
+	}
+
+	return nil
+}
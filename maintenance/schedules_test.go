@@ -0,0 +1,109 @@
+package maintenance
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SchedulesJob(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    backend.OverlapPolicy
+		startsNew bool
+		staysDue  bool
+	}{
+		{"skip", backend.OverlapSkip, false, false},
+		{"buffer", backend.OverlapBuffer, false, true},
+		{"cancel previous", backend.OverlapCancelPrevious, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			b := sqlite.NewInMemoryBackend()
+			var ss backend.ScheduleStore = b
+			job := SchedulesJob(time.Second)
+
+			due := time.Now().Add(-time.Minute).Truncate(time.Second).UTC()
+			require.NoError(t, ss.CreateSchedule(ctx, &backend.Schedule{
+				ID:            "schedule",
+				Interval:      time.Hour,
+				OverlapPolicy: tt.policy,
+				WorkflowName:  "wf",
+				NextRunAt:     due,
+			}))
+
+			get := func() *backend.Schedule {
+				schedules, err := ss.ListSchedules(ctx)
+				require.NoError(t, err)
+				require.Len(t, schedules, 1)
+
+				return schedules[0]
+			}
+
+			// First run starts an instance and advances the schedule
+			require.NoError(t, job.Run(ctx, b))
+
+			s := get()
+			require.NotNil(t, s.LastInstance)
+			require.Equal(t, "schedule-"+formatUnix(due), s.LastInstance.InstanceID)
+			require.Equal(t, due.Add(time.Hour), s.NextRunAt)
+
+			state, err := b.GetWorkflowInstanceState(ctx, s.LastInstance)
+			require.NoError(t, err)
+			require.Equal(t, core.WorkflowInstanceStateActive, state)
+
+			// Make the schedule due again while the first instance is still active
+			first := s.LastInstance
+			s.NextRunAt = due.Add(time.Second)
+			require.NoError(t, ss.UpdateSchedule(ctx, s))
+
+			require.NoError(t, job.Run(ctx, b))
+
+			s = get()
+			if tt.startsNew {
+				require.NotEqual(t, first.InstanceID, s.LastInstance.InstanceID)
+			} else {
+				require.Equal(t, first, s.LastInstance)
+			}
+
+			if tt.staysDue {
+				require.Equal(t, due.Add(time.Second), s.NextRunAt)
+			} else {
+				require.True(t, s.NextRunAt.After(time.Now()))
+			}
+		})
+	}
+}
+
+func Test_SchedulesJob_NotDue(t *testing.T) {
+	ctx := context.Background()
+	b := sqlite.NewInMemoryBackend()
+	var ss backend.ScheduleStore = b
+
+	next := time.Now().Add(time.Hour).Truncate(time.Second)
+	require.NoError(t, ss.CreateSchedule(ctx, &backend.Schedule{
+		ID:           "schedule",
+		Cron:         "@daily",
+		WorkflowName: "wf",
+		NextRunAt:    next,
+	}))
+
+	require.NoError(t, SchedulesJob(time.Second).Run(ctx, b))
+
+	schedules, err := ss.ListSchedules(ctx)
+	require.NoError(t, err)
+	require.Nil(t, schedules[0].LastInstance)
+	require.True(t, next.Equal(schedules[0].NextRunAt))
+}
+
+func formatUnix(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
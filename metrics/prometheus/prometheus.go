@@ -0,0 +1,334 @@
+// Package prometheus implements metrics.Client on top of the Prometheus Go client, and exposes the recorded metrics
+// to Prometheus.
+package prometheus
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cschleiden/go-workflows/metrics"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/expfmt"
+)
+
+// DefaultBuckets are the upper bounds of the histogram buckets for distributions. Distributions recorded by the
+// library are durations in milliseconds or sizes.
+var DefaultBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 300000}
+
+// DefaultTimingBuckets are the upper bounds of the histogram buckets for timings, in seconds
+var DefaultTimingBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+type options struct {
+	defaultBuckets []float64
+	timingBuckets  []float64
+	buckets        map[string][]float64
+}
+
+type Option func(o *options)
+
+// WithDefaultBuckets sets the histogram buckets used for distributions without buckets configured via WithBuckets
+func WithDefaultBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.defaultBuckets = buckets
+	}
+}
+
+// WithTimingBuckets sets the histogram buckets, in seconds, used for timings without buckets configured via
+// WithBuckets
+func WithTimingBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.timingBuckets = buckets
+	}
+}
+
+// WithBuckets sets the histogram buckets for the distribution or timing with the given name, for example
+// "workflows.workflow.history.events"
+func WithBuckets(name string, buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets[name] = buckets
+	}
+}
+
+type metricType int
+
+const (
+	counterType metricType = iota
+	gaugeType
+	histogramType
+)
+
+// collector holds the vector of a metric. Only the field of its type is set, none if it couldn't be registered.
+type collector struct {
+	typ        metricType
+	labelNames []string
+
+	counter   *prom.CounterVec
+	gauge     *prom.GaugeVec
+	histogram *prom.HistogramVec
+}
+
+type registry struct {
+	options *options
+
+	reg     *prom.Registry
+	handler http.Handler
+
+	mu         sync.Mutex
+	collectors map[string]*collector
+}
+
+// Client records metrics in a Prometheus registry. It implements http.Handler, serving the current values of all
+// metrics to Prometheus.
+type Client struct {
+	r    *registry
+	tags metrics.Tags
+}
+
+var _ metrics.Client = (*Client)(nil)
+var _ http.Handler = (*Client)(nil)
+
+// New returns a new client. Pass it to the backend with backend.WithMetrics and serve it on the metrics endpoint
+// scraped by Prometheus.
+func New(opts ...Option) *Client {
+	o := &options{
+		defaultBuckets: DefaultBuckets,
+		timingBuckets:  DefaultTimingBuckets,
+		buckets:        map[string][]float64{},
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	reg := prom.NewRegistry()
+
+	return &Client{
+		r: &registry{
+			options:    o,
+			reg:        reg,
+			handler:    promhttp.HandlerFor(reg, promhttp.HandlerOpts{}),
+			collectors: map[string]*collector{},
+		},
+		tags: metrics.Tags{},
+	}
+}
+
+// Gatherer returns the registry holding the recorded metrics, for example, to serve them together with other metrics
+// via prometheus.Gatherers
+func (c *Client) Gatherer() prom.Gatherer {
+	return c.r.reg
+}
+
+// Counter adds the value to the counter with the given name. Counters are exposed with a _total suffix. Negative
+// values are dropped, counters only increase.
+func (c *Client) Counter(name string, tags metrics.Tags, value int64) {
+	if value < 0 {
+		return
+	}
+
+	labels := c.labels(tags)
+	if col := c.r.collector(sanitizeName(name)+"_total", name, counterType, labels, nil); col != nil {
+		col.counter.With(labels).Add(float64(value))
+	}
+}
+
+// Distribution records the value in a histogram with the given name
+func (c *Client) Distribution(name string, tags metrics.Tags, value float64) {
+	labels := c.labels(tags)
+	if col := c.r.collector(sanitizeName(name), name, histogramType, labels, c.r.bucketsFor(name, c.r.options.defaultBuckets)); col != nil {
+		col.histogram.With(labels).Observe(value)
+	}
+}
+
+// Gauge sets the gauge with the given name to the value
+func (c *Client) Gauge(name string, tags metrics.Tags, value int64) {
+	labels := c.labels(tags)
+	if col := c.r.collector(sanitizeName(name), name, gaugeType, labels, nil); col != nil {
+		col.gauge.With(labels).Set(float64(value))
+	}
+}
+
+// Timing records the duration in a histogram with the given name. Timings are exposed in seconds with a _seconds
+// suffix.
+func (c *Client) Timing(name string, tags metrics.Tags, duration time.Duration) {
+	labels := c.labels(tags)
+	if col := c.r.collector(sanitizeName(name)+"_seconds", name, histogramType, labels, c.r.bucketsFor(name, c.r.options.timingBuckets)); col != nil {
+		col.histogram.With(labels).Observe(duration.Seconds())
+	}
+}
+
+// WithTags returns a client adding the given tags to all metrics, sharing the recorded metrics with c
+func (c *Client) WithTags(tags metrics.Tags) metrics.Client {
+	return &Client{
+		r:    c.r,
+		tags: mergeTags(c.tags, tags),
+	}
+}
+
+// ServeHTTP serves the current values of all metrics in the exposition format negotiated with Prometheus
+func (c *Client) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.r.handler.ServeHTTP(w, r)
+}
+
+// Write writes the current values of all metrics in the Prometheus text-based exposition format
+func (c *Client) Write(w io.Writer) error {
+	families, err := c.r.reg.Gather()
+	if err != nil {
+		return err
+	}
+
+	enc := expfmt.NewEncoder(w, expfmt.FmtText)
+	for _, f := range families {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) labels(tags metrics.Tags) prom.Labels {
+	tags = mergeTags(c.tags, tags)
+
+	labels := make(prom.Labels, len(tags))
+	for k, v := range tags {
+		labels[sanitizeName(k)] = v
+	}
+
+	return labels
+}
+
+func (r *registry) bucketsFor(name string, defaultBuckets []float64) []float64 {
+	if buckets, ok := r.options.buckets[name]; ok {
+		return buckets
+	}
+
+	return defaultBuckets
+}
+
+// collector returns the collector of the metric with the given name, registering it on first use. The type and
+// label names of the first value recorded for a name are kept, values recorded with a different type or different
+// tags are dropped, and nil is returned for them. Histograms can't have an "le" label, their values are dropped, too.
+func (r *registry) collector(name, help string, typ metricType, labels prom.Labels, buckets []float64) *collector {
+	if _, ok := labels["le"]; ok && typ == histogramType {
+		return nil
+	}
+
+	labelNames := make([]string, 0, len(labels))
+	for k := range labels {
+		labelNames = append(labelNames, k)
+	}
+	sort.Strings(labelNames)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	col, ok := r.collectors[name]
+	if !ok {
+		col = newCollector(name, help, typ, labelNames, buckets)
+		r.collectors[name] = col
+
+		if err := r.reg.Register(col.vec()); err != nil {
+			col.counter, col.gauge, col.histogram = nil, nil, nil
+		}
+	}
+
+	if col.typ != typ || col.vec() == nil || !equalNames(col.labelNames, labelNames) {
+		return nil
+	}
+
+	return col
+}
+
+func newCollector(name, help string, typ metricType, labelNames []string, buckets []float64) *collector {
+	col := &collector{typ: typ, labelNames: labelNames}
+
+	switch typ {
+	case counterType:
+		col.counter = prom.NewCounterVec(prom.CounterOpts{Name: name, Help: help}, labelNames)
+	case gaugeType:
+		col.gauge = prom.NewGaugeVec(prom.GaugeOpts{Name: name, Help: help}, labelNames)
+	case histogramType:
+		col.histogram = prom.NewHistogramVec(prom.HistogramOpts{Name: name, Help: help, Buckets: normalizeBuckets(buckets)}, labelNames)
+	}
+
+	return col
+}
+
+func (col *collector) vec() prom.Collector {
+	switch {
+	case col.counter != nil:
+		return col.counter
+	case col.gauge != nil:
+		return col.gauge
+	case col.histogram != nil:
+		return col.histogram
+	}
+
+	return nil
+}
+
+// normalizeBuckets sorts the given bucket bounds and removes duplicates, the Prometheus client requires them to be
+// strictly increasing
+func normalizeBuckets(buckets []float64) []float64 {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	normalized := sorted[:0]
+	for _, b := range sorted {
+		if len(normalized) == 0 || b != normalized[len(normalized)-1] {
+			normalized = append(normalized, b)
+		}
+	}
+
+	return normalized
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func mergeTags(a, b metrics.Tags) metrics.Tags {
+	tags := make(metrics.Tags, len(a)+len(b))
+	for k, v := range a {
+		tags[k] = v
+	}
+
+	for k, v := range b {
+		tags[k] = v
+	}
+
+	return tags
+}
+
+// sanitizeName replaces all characters not allowed in Prometheus metric and label names with underscores
+func sanitizeName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r >= '0' && r <= '9' && i > 0:
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package prometheus
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Client_Write(t *testing.T) {
+	c := New(WithDefaultBuckets(10, 100), WithTimingBuckets(1))
+
+	tc := c.WithTags(metrics.Tags{"backend": "sqlite"})
+
+	tc.Counter("workflows.workflow.created", metrics.Tags{}, 1)
+	tc.Counter("workflows.workflow.created", metrics.Tags{}, 2)
+	tc.Gauge("workflows.workflow.active", metrics.Tags{}, 5)
+	tc.Gauge("workflows.workflow.active", metrics.Tags{}, 3)
+	tc.Distribution("workflows.workflow.history.events", metrics.Tags{}, 5)
+	tc.Distribution("workflows.workflow.history.events", metrics.Tags{}, 50)
+	tc.Distribution("workflows.workflow.history.events", metrics.Tags{}, 500)
+	tc.Timing("workflows.activity.execution.duration", metrics.Tags{"activity": `a"b`}, 500*time.Millisecond)
+
+	var sb strings.Builder
+	require.NoError(t, c.Write(&sb))
+
+	require.Equal(t, `# HELP workflows_activity_execution_duration_seconds workflows.activity.execution.duration
+# TYPE workflows_activity_execution_duration_seconds histogram
+workflows_activity_execution_duration_seconds_bucket{activity="a\"b",backend="sqlite",le="1"} 1
+workflows_activity_execution_duration_seconds_bucket{activity="a\"b",backend="sqlite",le="+Inf"} 1
+workflows_activity_execution_duration_seconds_sum{activity="a\"b",backend="sqlite"} 0.5
+workflows_activity_execution_duration_seconds_count{activity="a\"b",backend="sqlite"} 1
+# HELP workflows_workflow_active workflows.workflow.active
+# TYPE workflows_workflow_active gauge
+workflows_workflow_active{backend="sqlite"} 3
+# HELP workflows_workflow_created_total workflows.workflow.created
+# TYPE workflows_workflow_created_total counter
+workflows_workflow_created_total{backend="sqlite"} 3
+# HELP workflows_workflow_history_events workflows.workflow.history.events
+# TYPE workflows_workflow_history_events histogram
+workflows_workflow_history_events_bucket{backend="sqlite",le="10"} 1
+workflows_workflow_history_events_bucket{backend="sqlite",le="100"} 2
+workflows_workflow_history_events_bucket{backend="sqlite",le="+Inf"} 3
+workflows_workflow_history_events_sum{backend="sqlite"} 555
+workflows_workflow_history_events_count{backend="sqlite"} 3
+`, sb.String())
+}
+
+func Test_Client_WithBuckets(t *testing.T) {
+	c := New(WithBuckets("workflows.workflow.task.time_in_queue", 1000))
+
+	c.Distribution("workflows.workflow.task.time_in_queue", metrics.Tags{}, 10)
+
+	var sb strings.Builder
+	require.NoError(t, c.Write(&sb))
+
+	require.Equal(t, `# HELP workflows_workflow_task_time_in_queue workflows.workflow.task.time_in_queue
+# TYPE workflows_workflow_task_time_in_queue histogram
+workflows_workflow_task_time_in_queue_bucket{le="1000"} 1
+workflows_workflow_task_time_in_queue_bucket{le="+Inf"} 1
+workflows_workflow_task_time_in_queue_sum 10
+workflows_workflow_task_time_in_queue_count 1
+`, sb.String())
+}
+
+func Test_Client_DropsInconsistentValues(t *testing.T) {
+	c := New(WithDefaultBuckets(10))
+
+	c.Gauge("workflows.workflow.active", metrics.Tags{"queue": "default"}, 1)
+
+	// Different tags, and a different type, than the first value recorded for the name
+	c.Gauge("workflows.workflow.active", metrics.Tags{}, 2)
+	c.Distribution("workflows.workflow.active", metrics.Tags{"queue": "default"}, 3)
+
+	// Histograms can't have an le label, counters can't decrease
+	c.Distribution("workflows.workflow.history.events", metrics.Tags{"le": "1"}, 4)
+	c.Counter("workflows.workflow.created", metrics.Tags{}, -1)
+
+	var sb strings.Builder
+	require.NoError(t, c.Write(&sb))
+
+	require.Equal(t, `# HELP workflows_workflow_active workflows.workflow.active
+# TYPE workflows_workflow_active gauge
+workflows_workflow_active{queue="default"} 1
+`, sb.String())
+}
+
+func Test_Client_ServeHTTP(t *testing.T) {
+	c := New()
+	c.Counter("workflows.workflow.created", metrics.Tags{}, 1)
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Body.String(), "workflows_workflow_created_total 1\n")
+}
+
+func Test_sanitizeName(t *testing.T) {
+	require.Equal(t, "workflows_workflow_task_time_in_queue", sanitizeName("workflows.workflow.task.time_in_queue"))
+	require.Equal(t, "_abc_d", sanitizeName("1abc-d"))
+}
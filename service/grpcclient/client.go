@@ -0,0 +1,167 @@
+// Package grpcclient is a thin client for the gRPC service exposed by package grpcserver. Arguments and results are
+// converted like with the regular client, using the default converter unless configured otherwise.
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/fn"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+	"github.com/cschleiden/go-workflows/service/grpcserver/workflowspb"
+	"github.com/cschleiden/go-workflows/workflow"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type Client struct {
+	service   workflowspb.WorkflowServiceClient
+	converter converter.Converter
+}
+
+type Option func(*Client)
+
+// WithConverter sets the converter used for arguments and results. It needs to match the converter of the backend
+// the server is using.
+func WithConverter(converter converter.Converter) Option {
+	return func(c *Client) {
+		c.converter = converter
+	}
+}
+
+// New returns a client calling the service over the given connection
+func New(conn grpc.ClientConnInterface, opts ...Option) *Client {
+	c := &Client{
+		service:   workflowspb.NewWorkflowServiceClient(conn),
+		converter: converter.DefaultConverter,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// CreateWorkflowInstance creates a new instance of the given workflow. The workflow can be passed as a function, or
+// by name. If instanceID is empty, the server generates an instance ID.
+func (c *Client) CreateWorkflowInstance(ctx context.Context, instanceID string, wf workflow.Workflow, args ...interface{}) (*workflow.Instance, error) {
+	workflowName, ok := wf.(string)
+	if !ok {
+		workflowName = fn.Name(wf)
+	}
+
+	req := &workflowspb.CreateWorkflowInstanceRequest{
+		InstanceId:   instanceID,
+		WorkflowName: workflowName,
+	}
+
+	for _, arg := range args {
+		input, err := c.converter.To(arg)
+		if err != nil {
+			return nil, fmt.Errorf("converting arguments: %w", err)
+		}
+
+		req.Args = append(req.Args, input)
+	}
+
+	res, err := c.service.CreateWorkflowInstance(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return core.NewWorkflowInstance(res.Instance.InstanceId, res.Instance.ExecutionId), nil
+}
+
+func (c *Client) SignalWorkflow(ctx context.Context, instanceID string, name string, arg interface{}) error {
+	input, err := c.converter.To(arg)
+	if err != nil {
+		return fmt.Errorf("converting arguments: %w", err)
+	}
+
+	_, err = c.service.SignalWorkflow(ctx, &workflowspb.SignalWorkflowRequest{
+		InstanceId: instanceID,
+		Name:       name,
+		Arg:        input,
+	})
+
+	return err
+}
+
+func (c *Client) CancelWorkflowInstance(ctx context.Context, instance *workflow.Instance) error {
+	_, err := c.service.CancelWorkflowInstance(ctx, &workflowspb.CancelWorkflowInstanceRequest{
+		Instance: toInstance(instance),
+	})
+
+	return err
+}
+
+// GetWorkflowInstanceState returns the state of the given workflow instance
+func (c *Client) GetWorkflowInstanceState(ctx context.Context, instance *workflow.Instance) (workflowspb.WorkflowInstanceState, error) {
+	res, err := c.service.GetWorkflowInstanceState(ctx, &workflowspb.GetWorkflowInstanceStateRequest{
+		Instance: toInstance(instance),
+	})
+	if err != nil {
+		return workflowspb.WorkflowInstanceState_WORKFLOW_INSTANCE_STATE_UNSPECIFIED, err
+	}
+
+	return res.State, nil
+}
+
+// ListWorkflowInstances returns a page of workflow instances, most recently created first. Pass the returned token to
+// retrieve the next page, it's empty if there are no more instances.
+func (c *Client) ListWorkflowInstances(ctx context.Context, pageSize int, pageToken string) ([]*workflowspb.WorkflowInstanceInfo, string, error) {
+	res, err := c.service.ListWorkflowInstances(ctx, &workflowspb.ListWorkflowInstancesRequest{
+		PageSize:  int32(pageSize),
+		PageToken: pageToken,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return res.Instances, res.NextPageToken, nil
+}
+
+// GetWorkflowResult waits for the workflow instance to finish and returns its result. Errors the workflow failed with
+// can be matched with errors.Is like with client.GetWorkflowResult.
+func GetWorkflowResult[T any](ctx context.Context, c *Client, instance *workflow.Instance, timeout time.Duration) (T, error) {
+	res, err := c.service.GetWorkflowResult(ctx, &workflowspb.GetWorkflowResultRequest{
+		Instance: toInstance(instance),
+		Timeout:  durationpb.New(timeout),
+	})
+	if err != nil {
+		return *new(T), err
+	}
+
+	if res.Error != nil {
+		switch res.Error.Message {
+		case client.ErrWorkflowCanceled.Error():
+			return *new(T), client.ErrWorkflowCanceled
+		case client.ErrWorkflowTerminated.Error():
+			return *new(T), client.ErrWorkflowTerminated
+		}
+
+		return *new(T), workflowerrors.ToError(&workflowerrors.Error{
+			Type:    res.Error.Type,
+			Message: res.Error.Message,
+		})
+	}
+
+	var r T
+	if err := c.converter.From(res.Result, &r); err != nil {
+		return *new(T), fmt.Errorf("converting result: %w", err)
+	}
+
+	return r, nil
+}
+
+func toInstance(instance *workflow.Instance) *workflowspb.WorkflowInstance {
+	return &workflowspb.WorkflowInstance{
+		InstanceId:  instance.InstanceID,
+		ExecutionId: instance.ExecutionID,
+	}
+}
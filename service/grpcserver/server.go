@@ -0,0 +1,287 @@
+// Package grpcserver exposes client operations over gRPC, so that services not written in Go can start, signal, and
+// cancel workflow instances without direct access to the backend. The service is defined in workflowspb/workflows.proto.
+package grpcserver
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend"
+	"github.com/cschleiden/go-workflows/client"
+	"github.com/cschleiden/go-workflows/diag"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/cschleiden/go-workflows/service/grpcserver/workflowspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const defaultPageSize = 25
+
+type server struct {
+	workflowspb.UnimplementedWorkflowServiceServer
+
+	backend backend.Backend
+	client  client.Client
+}
+
+var _ workflowspb.WorkflowServiceServer = (*server)(nil)
+
+// New returns the gRPC service for the given backend. Register it with a gRPC server:
+//
+//	s := grpc.NewServer()
+//	workflowspb.RegisterWorkflowServiceServer(s, grpcserver.New(b))
+func New(b backend.Backend) workflowspb.WorkflowServiceServer {
+	return &server{
+		backend: b,
+		client:  client.New(b),
+	}
+}
+
+// Register registers the service for the given backend with the gRPC server
+func Register(s grpc.ServiceRegistrar, b backend.Backend) {
+	workflowspb.RegisterWorkflowServiceServer(s, New(b))
+}
+
+func (s *server) CreateWorkflowInstance(ctx context.Context, req *workflowspb.CreateWorkflowInstanceRequest) (*workflowspb.CreateWorkflowInstanceResponse, error) {
+	if req.WorkflowName == "" {
+		return nil, status.Error(codes.InvalidArgument, "workflow name is required")
+	}
+
+	instanceID := req.InstanceId
+	if instanceID == "" {
+		instanceID = backend.NewID(s.backend)
+	}
+
+	inputs := make([]payload.Payload, 0, len(req.Args))
+	for _, arg := range req.Args {
+		inputs = append(inputs, payload.Payload(arg))
+	}
+
+	instance := core.NewWorkflowInstance(instanceID, backend.NewID(s.backend))
+	startedEvent := history.NewPendingEvent(
+		time.Now(),
+		history.EventType_WorkflowExecutionStarted,
+		&history.ExecutionStartedAttributes{
+			Metadata: &core.WorkflowMetadata{},
+			Name:     req.WorkflowName,
+			Inputs:   inputs,
+		})
+
+	if err := s.backend.CreateWorkflowInstance(ctx, instance, startedEvent); err != nil {
+		return nil, toStatus(err)
+	}
+
+	s.backend.Logger().Debug("Created workflow instance via gRPC", log.InstanceIDKey, instance.InstanceID, log.WorkflowNameKey, req.WorkflowName)
+
+	return &workflowspb.CreateWorkflowInstanceResponse{
+		Instance: toInstance(instance),
+	}, nil
+}
+
+func (s *server) SignalWorkflow(ctx context.Context, req *workflowspb.SignalWorkflowRequest) (*workflowspb.SignalWorkflowResponse, error) {
+	if req.InstanceId == "" || req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "instance ID and signal name are required")
+	}
+
+	signalEvent := history.NewPendingEvent(
+		time.Now(),
+		history.EventType_SignalReceived,
+		&history.SignalReceivedAttributes{
+			Name: req.Name,
+			Arg:  payload.Payload(req.Arg),
+		})
+
+	if err := s.backend.SignalWorkflow(ctx, req.InstanceId, signalEvent); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &workflowspb.SignalWorkflowResponse{}, nil
+}
+
+func (s *server) CancelWorkflowInstance(ctx context.Context, req *workflowspb.CancelWorkflowInstanceRequest) (*workflowspb.CancelWorkflowInstanceResponse, error) {
+	instance, err := fromInstance(req.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.client.CancelWorkflowInstance(ctx, instance); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &workflowspb.CancelWorkflowInstanceResponse{}, nil
+}
+
+func (s *server) GetWorkflowInstanceState(ctx context.Context, req *workflowspb.GetWorkflowInstanceStateRequest) (*workflowspb.GetWorkflowInstanceStateResponse, error) {
+	instance, err := fromInstance(req.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := s.backend.GetWorkflowInstanceState(ctx, instance)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &workflowspb.GetWorkflowInstanceStateResponse{State: toState(state)}, nil
+}
+
+func (s *server) GetWorkflowResult(ctx context.Context, req *workflowspb.GetWorkflowResultRequest) (*workflowspb.GetWorkflowResultResponse, error) {
+	instance, err := fromInstance(req.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.client.WaitForWorkflowInstance(ctx, instance, req.Timeout.AsDuration()); err != nil {
+		if errors.Is(err, backend.ErrInstanceNotFound) {
+			return nil, toStatus(err)
+		}
+
+		return nil, status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	h, err := s.backend.GetWorkflowInstanceHistory(ctx, instance, nil)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	// Iterate over history backwards, see client.GetWorkflowResult
+	for i := len(h) - 1; i >= 0; i-- {
+		switch a := h[i].Attributes.(type) {
+		case *history.ExecutionCompletedAttributes:
+			res := &workflowspb.GetWorkflowResultResponse{Result: a.Result}
+			if a.Error != nil {
+				res.Error = &workflowspb.WorkflowError{Type: a.Error.Type, Message: a.Error.Message}
+			}
+
+			return res, nil
+
+		case *history.ExecutionContinuedAsNewAttributes:
+			return &workflowspb.GetWorkflowResultResponse{Result: a.Result}, nil
+		}
+
+		switch h[i].Type {
+		case history.EventType_WorkflowExecutionCanceled:
+			return &workflowspb.GetWorkflowResultResponse{
+				Error: &workflowspb.WorkflowError{Message: client.ErrWorkflowCanceled.Error()},
+			}, nil
+
+		case history.EventType_WorkflowExecutionTerminated:
+			return &workflowspb.GetWorkflowResultResponse{
+				Error: &workflowspb.WorkflowError{Message: client.ErrWorkflowTerminated.Error()},
+			}, nil
+		}
+	}
+
+	return nil, status.Error(codes.Internal, "workflow finished, but could not find result event")
+}
+
+type pageToken struct {
+	InstanceID  string `json:"i"`
+	ExecutionID string `json:"e"`
+}
+
+func (s *server) ListWorkflowInstances(ctx context.Context, req *workflowspb.ListWorkflowInstancesRequest) (*workflowspb.ListWorkflowInstancesResponse, error) {
+	db, ok := s.backend.(diag.Backend)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "backend does not support listing workflow instances")
+	}
+
+	var after pageToken
+	if req.PageToken != "" {
+		b, err := base64.RawURLEncoding.DecodeString(req.PageToken)
+		if err != nil || json.Unmarshal(b, &after) != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	refs, err := db.GetWorkflowInstances(ctx, after.InstanceID, after.ExecutionID, pageSize)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &workflowspb.ListWorkflowInstancesResponse{}
+	for _, ref := range refs {
+		info := &workflowspb.WorkflowInstanceInfo{
+			Instance:  toInstance(ref.Instance),
+			State:     toState(ref.State),
+			CreatedAt: timestamppb.New(ref.CreatedAt),
+		}
+
+		if ref.CompletedAt != nil {
+			info.CompletedAt = timestamppb.New(*ref.CompletedAt)
+		}
+
+		res.Instances = append(res.Instances, info)
+	}
+
+	if len(refs) == pageSize {
+		last := refs[len(refs)-1].Instance
+		b, err := json.Marshal(pageToken{InstanceID: last.InstanceID, ExecutionID: last.ExecutionID})
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		res.NextPageToken = base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	return res, nil
+}
+
+func toInstance(instance *core.WorkflowInstance) *workflowspb.WorkflowInstance {
+	return &workflowspb.WorkflowInstance{
+		InstanceId:  instance.InstanceID,
+		ExecutionId: instance.ExecutionID,
+	}
+}
+
+func fromInstance(instance *workflowspb.WorkflowInstance) (*core.WorkflowInstance, error) {
+	if instance.GetInstanceId() == "" || instance.GetExecutionId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "instance ID and execution ID are required")
+	}
+
+	return core.NewWorkflowInstance(instance.InstanceId, instance.ExecutionId), nil
+}
+
+func toState(state core.WorkflowInstanceState) workflowspb.WorkflowInstanceState {
+	switch state {
+	case core.WorkflowInstanceStateActive:
+		return workflowspb.WorkflowInstanceState_WORKFLOW_INSTANCE_STATE_ACTIVE
+	case core.WorkflowInstanceStateContinuedAsNew:
+		return workflowspb.WorkflowInstanceState_WORKFLOW_INSTANCE_STATE_CONTINUED_AS_NEW
+	case core.WorkflowInstanceStateFinished:
+		return workflowspb.WorkflowInstanceState_WORKFLOW_INSTANCE_STATE_FINISHED
+	default:
+		return workflowspb.WorkflowInstanceState_WORKFLOW_INSTANCE_STATE_UNSPECIFIED
+	}
+}
+
+// toStatus maps backend errors to gRPC status errors
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, backend.ErrInstanceNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, backend.ErrInstanceAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, backend.ErrConcurrencyLimitReached), errors.Is(err, backend.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,161 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/backend/sqlite"
+	"github.com/cschleiden/go-workflows/service/grpcclient"
+	"github.com/cschleiden/go-workflows/service/grpcserver/workflowspb"
+	"github.com/cschleiden/go-workflows/worker"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func greetWorkflow(ctx workflow.Context, name string) (string, error) {
+	greeting, _ := workflow.NewSignalChannel[string](ctx, "greeting").Receive(ctx)
+
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+
+	return greeting + " " + name, nil
+}
+
+func sleepWorkflow(ctx workflow.Context) error {
+	return workflow.Sleep(ctx, time.Hour)
+}
+
+func newTestClient(t *testing.T) *grpcclient.Client {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	b := sqlite.NewInMemoryBackend()
+
+	w := worker.New(b, nil)
+	require.NoError(t, w.RegisterWorkflow(greetWorkflow))
+	require.NoError(t, w.RegisterWorkflow(sleepWorkflow))
+	require.NoError(t, w.Start(ctx))
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	Register(s, b)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return grpcclient.New(conn)
+}
+
+func Test_Server_CreateSignalGetResult(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	instance, err := c.CreateWorkflowInstance(ctx, "instance", "greetWorkflow", "gopher")
+	require.NoError(t, err)
+	require.Equal(t, "instance", instance.InstanceID)
+
+	_, err = c.CreateWorkflowInstance(ctx, "instance", greetWorkflow, "gopher")
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "greeting", "hello"))
+
+	r, err := grpcclient.GetWorkflowResult[string](ctx, c, instance, time.Second*10)
+	require.NoError(t, err)
+	require.Equal(t, "hello gopher", r)
+}
+
+func Test_Server_WorkflowError(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	instance, err := c.CreateWorkflowInstance(ctx, "", greetWorkflow, "")
+	require.NoError(t, err)
+	require.NotEmpty(t, instance.InstanceID)
+
+	require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "greeting", "hello"))
+
+	_, err = grpcclient.GetWorkflowResult[string](ctx, c, instance, time.Second*10)
+	require.EqualError(t, err, "name is required")
+}
+
+func Test_Server_Cancel(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	instance, err := c.CreateWorkflowInstance(ctx, "", sleepWorkflow)
+	require.NoError(t, err)
+
+	require.NoError(t, c.CancelWorkflowInstance(ctx, instance))
+
+	_, err = grpcclient.GetWorkflowResult[string](ctx, c, instance, time.Second*10)
+	require.ErrorIs(t, err, workflow.Canceled)
+}
+
+func Test_Server_GetWorkflowInstanceState(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	instance, err := c.CreateWorkflowInstance(ctx, "", greetWorkflow, "gopher")
+	require.NoError(t, err)
+
+	state, err := c.GetWorkflowInstanceState(ctx, instance)
+	require.NoError(t, err)
+	require.Equal(t, workflowspb.WorkflowInstanceState_WORKFLOW_INSTANCE_STATE_ACTIVE, state)
+
+	require.NoError(t, c.SignalWorkflow(ctx, instance.InstanceID, "greeting", "hello"))
+
+	_, err = grpcclient.GetWorkflowResult[string](ctx, c, instance, time.Second*10)
+	require.NoError(t, err)
+
+	state, err = c.GetWorkflowInstanceState(ctx, instance)
+	require.NoError(t, err)
+	require.Equal(t, workflowspb.WorkflowInstanceState_WORKFLOW_INSTANCE_STATE_FINISHED, state)
+
+	_, err = c.GetWorkflowInstanceState(ctx, &workflow.Instance{InstanceID: "unknown", ExecutionID: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func Test_Server_ListWorkflowInstances(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.CreateWorkflowInstance(ctx, "", greetWorkflow, "gopher")
+		require.NoError(t, err)
+	}
+
+	instances, token, err := c.ListWorkflowInstances(ctx, 2, "")
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	require.NotEmpty(t, token)
+
+	instances, _, err = c.ListWorkflowInstances(ctx, 2, token)
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+}
+
+func Test_Server_InvalidArguments(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	_, err := c.CreateWorkflowInstance(ctx, "", "", "gopher")
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	err = c.CancelWorkflowInstance(ctx, &workflow.Instance{InstanceID: "instance"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
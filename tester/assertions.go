@@ -0,0 +1,180 @@
+package tester
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cschleiden/go-workflows/internal/fn"
+	"github.com/cschleiden/go-workflows/internal/history"
+)
+
+// TestingT is the subset of *testing.T used by the history assertions
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+	Helper()
+}
+
+type countExpectation struct {
+	min int
+	max int
+}
+
+func (c countExpectation) matches(n int) bool {
+	return n >= c.min && (c.max < 0 || n <= c.max)
+}
+
+func (c countExpectation) String() string {
+	switch {
+	case c.max < 0:
+		return fmt.Sprintf("at least %d", c.min)
+	case c.min == c.max:
+		return fmt.Sprintf("exactly %d", c.min)
+	default:
+		return fmt.Sprintf("between %d and %d", c.min, c.max)
+	}
+}
+
+type AssertionOption func(*countExpectation)
+
+// Times expects the event to occur exactly n times
+func Times(n int) AssertionOption {
+	return func(c *countExpectation) {
+		c.min = n
+		c.max = n
+	}
+}
+
+// Never expects the event to not occur at all
+func Never() AssertionOption {
+	return Times(0)
+}
+
+// AtLeast expects the event to occur at least n times
+func AtLeast(n int) AssertionOption {
+	return func(c *countExpectation) {
+		c.min = n
+		c.max = -1
+	}
+}
+
+// AssertActivityScheduled asserts that the given activity was scheduled in the history. activity can either be
+// the activity function or its registered name. By default, the activity is expected to be scheduled at least once.
+func AssertActivityScheduled(t TestingT, h []*history.Event, activity interface{}, opts ...AssertionOption) bool {
+	t.Helper()
+
+	name := nameOf(activity)
+
+	return assertCount(t, h, fmt.Sprintf("activity %q to be scheduled", name), func(e *history.Event) bool {
+		if e.Type != history.EventType_ActivityScheduled {
+			return false
+		}
+
+		return e.Attributes.(*history.ActivityScheduledAttributes).Name == name
+	}, opts...)
+}
+
+// AssertSubWorkflowScheduled asserts that the given sub-workflow was scheduled in the history. workflow can either
+// be the workflow function or its registered name. By default, the sub-workflow is expected to be scheduled at
+// least once.
+func AssertSubWorkflowScheduled(t TestingT, h []*history.Event, workflow interface{}, opts ...AssertionOption) bool {
+	t.Helper()
+
+	name := nameOf(workflow)
+
+	return assertCount(t, h, fmt.Sprintf("sub-workflow %q to be scheduled", name), func(e *history.Event) bool {
+		if e.Type != history.EventType_SubWorkflowScheduled {
+			return false
+		}
+
+		return e.Attributes.(*history.SubWorkflowScheduledAttributes).Name == name
+	}, opts...)
+}
+
+// AssertTimerScheduled asserts that a timer with the given duration was scheduled in the history. By default, the
+// timer is expected to be scheduled at least once.
+func AssertTimerScheduled(t TestingT, h []*history.Event, delay time.Duration, opts ...AssertionOption) bool {
+	t.Helper()
+
+	return assertCount(t, h, fmt.Sprintf("timer for %v to be scheduled", delay), func(e *history.Event) bool {
+		if e.Type != history.EventType_TimerScheduled {
+			return false
+		}
+
+		return e.Attributes.(*history.TimerScheduledAttributes).At.Sub(e.Timestamp) == delay
+	}, opts...)
+}
+
+// AssertSignalHandled asserts that the workflow received the given signal. By default, the signal is expected to
+// be received at least once.
+func AssertSignalHandled(t TestingT, h []*history.Event, signalName string, opts ...AssertionOption) bool {
+	t.Helper()
+
+	return assertCount(t, h, fmt.Sprintf("signal %q to be handled", signalName), func(e *history.Event) bool {
+		if e.Type != history.EventType_SignalReceived {
+			return false
+		}
+
+		return e.Attributes.(*history.SignalReceivedAttributes).Name == signalName
+	}, opts...)
+}
+
+func assertCount(t TestingT, h []*history.Event, what string, match func(*history.Event) bool, opts ...AssertionOption) bool {
+	t.Helper()
+
+	expected := countExpectation{min: 1, max: -1}
+	for _, opt := range opts {
+		opt(&expected)
+	}
+
+	n := 0
+	for _, e := range h {
+		if match(e) {
+			n++
+		}
+	}
+
+	if !expected.matches(n) {
+		t.Errorf("expected %s %s time(s), but found %d\n\nHistory:\n%s", what, expected, n, formatHistory(h))
+		return false
+	}
+
+	return true
+}
+
+func nameOf(v interface{}) string {
+	if name, ok := v.(string); ok {
+		return name
+	}
+
+	return fn.Name(v)
+}
+
+func formatHistory(h []*history.Event) string {
+	var sb strings.Builder
+
+	for _, e := range h {
+		fmt.Fprintf(&sb, "  %3d %v", e.SequenceID, e.Type)
+
+		switch a := e.Attributes.(type) {
+		case *history.ExecutionStartedAttributes:
+			fmt.Fprintf(&sb, " (%s)", a.Name)
+		case *history.ActivityScheduledAttributes:
+			fmt.Fprintf(&sb, " (%s)", a.Name)
+		case *history.SubWorkflowScheduledAttributes:
+			fmt.Fprintf(&sb, " (%s)", a.Name)
+		case *history.SignalReceivedAttributes:
+			fmt.Fprintf(&sb, " (%s)", a.Name)
+		case *history.TimerScheduledAttributes:
+			fmt.Fprintf(&sb, " (%v)", a.At.Sub(e.Timestamp))
+		}
+
+		if e.ScheduleEventID != 0 {
+			fmt.Fprintf(&sb, " [schedule event %d]", e.ScheduleEventID)
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
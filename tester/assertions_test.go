@@ -0,0 +1,74 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingT) Helper() {}
+
+func SendEmail(ctx context.Context, to string) error {
+	return nil
+}
+
+func Test_HistoryAssertions(t *testing.T) {
+	subWorkflow := func(ctx workflow.Context) error {
+		return nil
+	}
+
+	wf := func(ctx workflow.Context) error {
+		workflow.NewSignalChannel[string](ctx, "approve").Receive(ctx)
+
+		for _, to := range []string{"a", "b"} {
+			if _, err := workflow.ExecuteActivity[any](ctx, workflow.DefaultActivityOptions, SendEmail, to).Get(ctx); err != nil {
+				return err
+			}
+		}
+
+		workflow.ScheduleTimer(ctx, time.Hour*24).Get(ctx)
+
+		_, err := workflow.CreateSubWorkflowInstance[any](ctx, workflow.DefaultSubWorkflowOptions, subWorkflow).Get(ctx)
+		return err
+	}
+
+	wt := NewWorkflowTester[any](wf)
+	wt.Registry().RegisterWorkflow(subWorkflow)
+	wt.OnActivity(SendEmail, mock.Anything, mock.Anything).Return(nil)
+	wt.SignalWorkflowAt(time.Minute, "approve", "yes")
+
+	wt.Execute(context.Background())
+	require.True(t, wt.WorkflowFinished())
+
+	h := wt.History()
+
+	require.True(t, AssertActivityScheduled(t, h, SendEmail, Times(2)))
+	require.True(t, AssertActivityScheduled(t, h, "SendEmail", AtLeast(1)))
+	require.True(t, AssertActivityScheduled(t, h, "OtherActivity", Never()))
+	require.True(t, AssertTimerScheduled(t, h, time.Hour*24, Times(1)))
+	require.True(t, AssertSignalHandled(t, h, "approve"))
+	require.True(t, AssertSubWorkflowScheduled(t, h, subWorkflow, Times(1)))
+
+	rt := &recordingT{}
+	require.False(t, AssertActivityScheduled(rt, h, SendEmail, Times(1)))
+	require.False(t, AssertTimerScheduled(rt, h, time.Hour))
+	require.False(t, AssertSignalHandled(rt, h, "reject"))
+
+	require.Len(t, rt.errors, 3)
+	require.Contains(t, rt.errors[0], `expected activity "SendEmail" to be scheduled exactly 1 time(s), but found 2`)
+	require.Contains(t, rt.errors[0], "ActivityScheduled (SendEmail)")
+	require.Contains(t, rt.errors[1], "TimerScheduled (24h0m0s)")
+}
@@ -0,0 +1,218 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/workflow"
+)
+
+var errInjectedActivityFailure = errors.New("injected activity failure")
+
+type fuzzOptions struct {
+	Iterations          int
+	Seed                int64
+	ActivityFailureRate float64
+	MaxActivityDelay    time.Duration
+	MaxCallbackJitter   time.Duration
+	TesterOptions       []WorkflowTesterOption
+}
+
+type FuzzOption func(*fuzzOptions)
+
+// WithFuzzIterations sets how often the workflow is executed. Defaults to 50.
+func WithFuzzIterations(iterations int) FuzzOption {
+	return func(o *fuzzOptions) {
+		o.Iterations = iterations
+	}
+}
+
+// WithFuzzSeed sets the initial seed. Every iteration uses the initial seed incremented by the iteration number. Use
+// this to reproduce a failing iteration, the seed is part of the sub-test name.
+func WithFuzzSeed(seed int64) FuzzOption {
+	return func(o *fuzzOptions) {
+		o.Seed = seed
+	}
+}
+
+// WithActivityFailureRate sets the probability (0..1) with which an activity execution is skipped and fails with an
+// injected error instead. Defaults to 0.
+//
+// Note: failed executions do not call activity mocks, expectations requiring an exact number of calls might not
+// be met when failures are injected.
+func WithActivityFailureRate(rate float64) FuzzOption {
+	return func(o *fuzzOptions) {
+		o.ActivityFailureRate = rate
+	}
+}
+
+// WithMaxActivityDelay sets the maximum wall-clock delay added to activity executions. Random delays change
+// the order in which concurrently running activities complete. Defaults to 5ms.
+func WithMaxActivityDelay(delay time.Duration) FuzzOption {
+	return func(o *fuzzOptions) {
+		o.MaxActivityDelay = delay
+	}
+}
+
+// WithMaxCallbackJitter sets the maximum delay in workflow time that's added to callbacks scheduled via
+// ScheduleCallback or SignalWorkflowAt. Defaults to 0.
+func WithMaxCallbackJitter(jitter time.Duration) FuzzOption {
+	return func(o *fuzzOptions) {
+		o.MaxCallbackJitter = jitter
+	}
+}
+
+// WithFuzzTesterOptions sets options for the workflow testers created for every iteration.
+func WithFuzzTesterOptions(opts ...WorkflowTesterOption) FuzzOption {
+	return func(o *fuzzOptions) {
+		o.TesterOptions = append(o.TesterOptions, opts...)
+	}
+}
+
+// Fuzz executes the given workflow repeatedly with randomized activity completion order, callback and signal
+// timing, and, optionally, injected activity failures.
+//
+// For every iteration a new tester is created and passed to run, which is expected to set up mocks, call Execute,
+// and verify the result. After run returns, the history of every workflow instance executed by the tester is
+// replayed, and the iteration fails if the replay does not reproduce the recorded history.
+func Fuzz[TResult any](t *testing.T, wf interface{}, run func(t *testing.T, wt WorkflowTester[TResult]), opts ...FuzzOption) {
+	o := &fuzzOptions{
+		Iterations:       50,
+		Seed:             time.Now().UnixNano(),
+		MaxActivityDelay: time.Millisecond * 5,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	for i := 0; i < o.Iterations; i++ {
+		seed := o.Seed + int64(i)
+
+		t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+			testerOpts := append([]WorkflowTesterOption{}, o.TesterOptions...)
+			testerOpts = append(testerOpts, withFuzzer(newFuzzer(seed, o)))
+
+			wt := NewWorkflowTester[TResult](wf, testerOpts...)
+
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("workflow execution failed: %v", r)
+					}
+				}()
+
+				run(t, wt)
+			}()
+
+			if err := wt.replayHistories(context.Background()); err != nil {
+				t.Errorf("workflow is not deterministic: %v", err)
+			}
+		})
+	}
+}
+
+func withFuzzer(f *fuzzer) WorkflowTesterOption {
+	return func(o *options) {
+		o.fuzzer = f
+	}
+}
+
+type fuzzer struct {
+	mu sync.Mutex
+	r  *rand.Rand
+
+	activityFailureRate float64
+	maxActivityDelay    time.Duration
+	maxCallbackJitter   time.Duration
+}
+
+func newFuzzer(seed int64, o *fuzzOptions) *fuzzer {
+	return &fuzzer{
+		r:                   rand.New(rand.NewSource(seed)),
+		activityFailureRate: o.ActivityFailureRate,
+		maxActivityDelay:    o.MaxActivityDelay,
+		maxCallbackJitter:   o.MaxCallbackJitter,
+	}
+}
+
+func (f *fuzzer) intn(n int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.r.Intn(n)
+}
+
+func (f *fuzzer) duration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return time.Duration(f.r.Int63n(int64(max)))
+}
+
+func (f *fuzzer) failActivity() bool {
+	if f.activityFailureRate <= 0 {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.r.Float64() < f.activityFailureRate
+}
+
+// pickCallback returns a random callback out of all callbacks that are ready to be processed. Callbacks not picked
+// are put back into the channel.
+func (f *fuzzer) pickCallback(callback func() *history.WorkflowEvent, callbacks chan func() *history.WorkflowEvent) func() *history.WorkflowEvent {
+	ready := []func() *history.WorkflowEvent{callback}
+
+drain:
+	for {
+		select {
+		case c := <-callbacks:
+			ready = append(ready, c)
+		default:
+			break drain
+		}
+	}
+
+	picked := f.intn(len(ready))
+	for i, c := range ready {
+		if i != picked {
+			callbacks <- c
+		}
+	}
+
+	return ready[picked]
+}
+
+// replayHistories replays the recorded histories of all workflow instances executed by the tester.
+func (wt *workflowTester[TResult]) replayHistories(ctx context.Context) error {
+	wt.mtw.RLock()
+	defer wt.mtw.RUnlock()
+
+	for _, tw := range wt.testWorkflows {
+		registry := wt.registry
+		if tw.registry != nil {
+			registry = tw.registry
+		}
+
+		if err := workflow.ReplayHistory(
+			ctx, wt.logger, wt.tracer, registry, wt.converter, wt.propagators, tw.instance, tw.metadata, tw.history,
+		); err != nil {
+			return fmt.Errorf("instance %v: %w", tw.instance.InstanceID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package tester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Fuzz(t *testing.T) {
+	activity1 := func() (int, error) {
+		return 1, nil
+	}
+	activity2 := func() (int, error) {
+		return 2, nil
+	}
+
+	wf := func(ctx workflow.Context) (int, error) {
+		f1 := workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, activity1)
+		f2 := workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, activity2)
+
+		// Wait for both activities, in whatever order they complete
+		sum := 0
+		pending := map[workflow.Future[int]]int{f1: 1, f2: 10}
+		for len(pending) > 0 {
+			cases := []workflow.SelectCase{}
+			for _, f := range []workflow.Future[int]{f1, f2} {
+				if _, ok := pending[f]; ok {
+					cases = append(cases, workflow.Await(f, func(ctx workflow.Context, f workflow.Future[int]) {
+						r, _ := f.Get(ctx)
+						sum += r * pending[f]
+						delete(pending, f)
+					}))
+				}
+			}
+
+			workflow.Select(ctx, cases...)
+		}
+
+		r, _ := workflow.NewSignalChannel[int](ctx, "signal").Receive(ctx)
+
+		return sum + r, nil
+	}
+
+	Fuzz(t, wf, func(t *testing.T, wt WorkflowTester[int]) {
+		wt.OnActivity(activity1).Return(1, nil)
+		wt.OnActivity(activity2).Return(2, nil)
+		wt.SignalWorkflowAt(time.Minute, "signal", 100)
+
+		wt.Execute(context.Background())
+
+		require.True(t, wt.WorkflowFinished())
+		r, err := wt.WorkflowResult()
+		require.NoError(t, err)
+		require.Equal(t, 121, r)
+	}, WithFuzzIterations(10), WithMaxCallbackJitter(time.Minute))
+}
+
+func Test_Fuzz_InjectedActivityFailures(t *testing.T) {
+	activity1 := func() (int, error) {
+		return 42, nil
+	}
+
+	wf := func(ctx workflow.Context) (int, error) {
+		return workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+			RetryOptions: workflow.RetryOptions{
+				MaxAttempts: 10,
+			},
+		}, activity1).Get(ctx)
+	}
+
+	Fuzz(t, wf, func(t *testing.T, wt WorkflowTester[int]) {
+		wt.OnActivity(activity1).Return(42, nil).Maybe()
+
+		wt.Execute(context.Background())
+
+		require.True(t, wt.WorkflowFinished())
+
+		// Workflow either succeeds eventually or fails with the injected error
+		r, err := wt.WorkflowResult()
+		if err != nil {
+			require.EqualError(t, err, errInjectedActivityFailure.Error())
+		} else {
+			require.Equal(t, 42, r)
+		}
+	}, WithFuzzIterations(10), WithFuzzSeed(1), WithActivityFailureRate(0.5))
+}
+
+func Test_ReplayHistories_DetectsNonDeterminism(t *testing.T) {
+	activity1 := func() (int, error) {
+		return 42, nil
+	}
+
+	useTimer := false
+
+	wf := func(ctx workflow.Context) error {
+		if useTimer {
+			_, err := workflow.ScheduleTimer(ctx, time.Second).Get(ctx)
+			return err
+		}
+
+		_, err := workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, activity1).Get(ctx)
+		return err
+	}
+
+	wt := NewWorkflowTester[any](wf)
+	wt.OnActivity(activity1, mock.Anything).Return(42, nil)
+
+	wt.Execute(context.Background())
+	require.True(t, wt.WorkflowFinished())
+
+	require.NoError(t, wt.replayHistories(context.Background()))
+
+	useTimer = true
+
+	require.Error(t, wt.replayHistories(context.Background()))
+}
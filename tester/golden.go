@@ -0,0 +1,75 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/workflow"
+)
+
+var updateGolden = flag.Bool("tester.update-golden", false, "update golden workflow histories")
+
+func (wt *workflowTester[TResult]) AssertGoldenHistory(t *testing.T, path string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("reading golden history %v: %v", path, err)
+	}
+
+	if *updateGolden || errors.Is(err, fs.ErrNotExist) {
+		wt.writeGoldenHistory(t, path)
+		return
+	}
+
+	if err := wt.replayExportedHistory(data); err != nil {
+		t.Errorf("golden history %v cannot be replayed, workflow change is not backwards compatible: %v\n"+
+			"If the change is intended, run the test with -tester.update-golden to update the golden history.", path, err)
+	}
+}
+
+func (wt *workflowTester[TResult]) writeGoldenHistory(t *testing.T, path string) {
+	t.Helper()
+
+	tw := wt.getWorkflow(wt.wfi)
+	if tw == nil || len(tw.history) == 0 {
+		t.Fatalf("cannot record golden history %v, workflow under test has not been executed", path)
+	}
+
+	state := core.WorkflowInstanceStateActive
+	if wt.workflowFinished {
+		state = core.WorkflowInstanceStateFinished
+	}
+
+	data, err := marshalHistory(tw.instance, state, tw.history)
+	if err != nil {
+		t.Fatalf("serializing golden history: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("creating directory for golden history: %v", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("writing golden history %v: %v", path, err)
+	}
+
+	t.Logf("recorded golden history %v", path)
+}
+
+func (wt *workflowTester[TResult]) replayExportedHistory(data []byte) error {
+	instance, h, err := unmarshalHistory(data)
+	if err != nil {
+		return err
+	}
+
+	return workflow.ReplayHistory(
+		context.Background(), wt.logger, wt.tracer, wt.registry, wt.converter, wt.propagators, instance, nil, h,
+	)
+}
@@ -0,0 +1,85 @@
+package tester
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GoldenHistory(t *testing.T) {
+	activity1 := func(ctx context.Context, input string) (string, error) {
+		return input + " world", nil
+	}
+
+	useTimer := false
+
+	wf := func(ctx workflow.Context, input string) (string, error) {
+		if useTimer {
+			workflow.ScheduleTimer(ctx, time.Second).Get(ctx)
+		}
+
+		workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+
+		return workflow.ExecuteActivity[string](ctx, workflow.DefaultActivityOptions, activity1, input).Get(ctx)
+	}
+
+	path := filepath.Join(t.TempDir(), "testdata", "workflow.golden.json")
+
+	run := func() *workflowTester[string] {
+		wt := NewWorkflowTester[string](wf)
+		wt.OnActivity(activity1, mock.Anything, "hello").Return("hello world", nil)
+		wt.SignalWorkflowAt(time.Minute, "signal", "s")
+
+		wt.Execute(context.Background(), "hello")
+		require.True(t, wt.WorkflowFinished())
+
+		return wt
+	}
+
+	// First run records the history
+	run().AssertGoldenHistory(t, path)
+	require.FileExists(t, path)
+
+	// Second run replays it
+	run().AssertGoldenHistory(t, path)
+
+	// Incompatible change to the workflow
+	useTimer = true
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Error(t, run().replayExportedHistory(data))
+}
+
+func Test_UnmarshalHistory_Roundtrip(t *testing.T) {
+	wf := func(ctx workflow.Context) (int, error) {
+		workflow.ScheduleTimer(ctx, time.Second).Get(ctx)
+		return 42, nil
+	}
+
+	wt := NewWorkflowTester[int](wf)
+	wt.Execute(context.Background())
+
+	tw := wt.getWorkflow(wt.wfi)
+
+	data, err := marshalHistory(tw.instance, 0, tw.history)
+	require.NoError(t, err)
+
+	instance, h, err := unmarshalHistory(data)
+	require.NoError(t, err)
+	require.Equal(t, tw.instance, instance)
+	require.Len(t, h, len(tw.history))
+
+	for i := range h {
+		require.Equal(t, tw.history[i].ID, h[i].ID)
+		require.Equal(t, tw.history[i].Type, h[i].Type)
+		require.Equal(t, tw.history[i].SequenceID, h[i].SequenceID)
+		require.IsType(t, tw.history[i].Attributes, h[i].Attributes)
+	}
+}
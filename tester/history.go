@@ -0,0 +1,121 @@
+package tester
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cschleiden/go-workflows/diag"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/history"
+)
+
+// historyExport is the format of an exported workflow instance history. It matches the format returned by the
+// diagnostics API for a single workflow instance.
+type historyExport struct {
+	Instance *core.WorkflowInstance `json:"instance,omitempty"`
+
+	History []*historyExportEvent `json:"history,omitempty"`
+}
+
+type historyExportEvent struct {
+	*diag.Event
+
+	Attributes json.RawMessage `json:"attributes,omitempty"`
+}
+
+func marshalHistory(instance *core.WorkflowInstance, state core.WorkflowInstanceState, h []*history.Event) ([]byte, error) {
+	events := make([]*diag.Event, 0, len(h))
+	for _, event := range h {
+		events = append(events, &diag.Event{
+			ID:              event.ID,
+			SequenceID:      event.SequenceID,
+			Type:            event.Type.String(),
+			Timestamp:       event.Timestamp,
+			ScheduleEventID: event.ScheduleEventID,
+			Attributes:      event.Attributes,
+			VisibleAt:       event.VisibleAt,
+		})
+	}
+
+	var createdAt time.Time
+	if len(h) > 0 {
+		createdAt = h[0].Timestamp
+	}
+
+	return json.MarshalIndent(&diag.WorkflowInstanceInfo{
+		WorkflowInstanceRef: &diag.WorkflowInstanceRef{
+			Instance:  instance,
+			CreatedAt: createdAt,
+			State:     state,
+		},
+		History: events,
+	}, "", "  ")
+}
+
+// unmarshalHistory reads a history exported via the diagnostics API, or a plain list of serialized history events.
+func unmarshalHistory(data []byte) (*core.WorkflowInstance, []*history.Event, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var events []*history.Event
+		if err := json.Unmarshal(data, &events); err != nil {
+			return nil, nil, fmt.Errorf("unmarshaling history: %w", err)
+		}
+
+		if len(events) == 0 {
+			return nil, nil, errors.New("history is empty")
+		}
+
+		return core.NewWorkflowInstance("replay", "replay"), events, nil
+	}
+
+	var export historyExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, nil, fmt.Errorf("unmarshaling history: %w", err)
+	}
+
+	if len(export.History) == 0 {
+		return nil, nil, errors.New("history is empty")
+	}
+
+	events := make([]*history.Event, 0, len(export.History))
+	for _, e := range export.History {
+		if e.Event == nil {
+			return nil, nil, errors.New("history contains empty event")
+		}
+
+		et, err := history.ParseEventType(e.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rawAttributes := e.Attributes
+		if len(rawAttributes) == 0 {
+			rawAttributes = json.RawMessage("{}")
+		}
+
+		attributes, err := history.DeserializeAttributes(et, rawAttributes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("deserializing attributes for event %v: %w", e.ID, err)
+		}
+
+		events = append(events, &history.Event{
+			ID:              e.ID,
+			SequenceID:      e.SequenceID,
+			Type:            et,
+			Timestamp:       e.Timestamp,
+			ScheduleEventID: e.ScheduleEventID,
+			Attributes:      attributes,
+			VisibleAt:       e.VisibleAt,
+		})
+	}
+
+	instance := export.Instance
+	if instance == nil {
+		instance = core.NewWorkflowInstance("replay", "replay")
+	}
+
+	return instance, events, nil
+}
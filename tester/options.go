@@ -13,6 +13,14 @@ type options struct {
 	Logger      log.Logger
 	Converter   converter.Converter
 	Propagators []contextpropagation.ContextPropagator
+
+	DeterminismGuard bool
+
+	ContinueAsNewHistoryEvents int
+	ContinueAsNewHistoryBytes  int
+
+	// fuzzer randomizes the execution, only set when running via Fuzz
+	fuzzer *fuzzer
 }
 
 type WorkflowTesterOption func(*options)
@@ -40,3 +48,21 @@ func WithTestTimeout(timeout time.Duration) WorkflowTesterOption {
 		o.TestTimeout = timeout
 	}
 }
+
+// WithDeterminismGuard enables the runtime determinism guard. Workflows fail if they block on native channels, select
+// statements, or time.Sleep, or start goroutines with the go statement. Workflows reading wall-clock time only fail if
+// they use a clock created with workflow.NewGuardedClock.
+func WithDeterminismGuard() WorkflowTesterOption {
+	return func(o *options) {
+		o.DeterminismGuard = true
+	}
+}
+
+// WithContinueAsNewThresholds sets the history size after which workflow.ShouldContinueAsNew returns true, see the
+// worker options of the same name.
+func WithContinueAsNewThresholds(events, bytes int) WorkflowTesterOption {
+	return func(o *options) {
+		o.ContinueAsNewHistoryEvents = events
+		o.ContinueAsNewHistoryBytes = bytes
+	}
+}
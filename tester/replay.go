@@ -0,0 +1,72 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/history"
+	"github.com/cschleiden/go-workflows/internal/logger"
+	"github.com/cschleiden/go-workflows/internal/workflow"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ReplayHistory replays an exported workflow instance history against the given workflow and fails the test if the
+// workflow code cannot reproduce it. historyJSON can either be the response of the diagnostics API for a workflow
+// instance, or a JSON list of history events.
+//
+// The workflow is registered with the name recorded in the history, so histories remain replayable after renaming
+// the workflow function. Activities and sub-workflows are not executed during replay and do not need to be registered.
+func ReplayHistory(t *testing.T, wf interface{}, historyJSON []byte, opts ...WorkflowTesterOption) {
+	t.Helper()
+
+	if err := replayHistory(wf, historyJSON, opts...); err != nil {
+		t.Fatalf("replaying history: %v", err)
+	}
+}
+
+func replayHistory(wf interface{}, historyJSON []byte, opts ...WorkflowTesterOption) error {
+	options := &options{
+		Logger:    logger.NewDefaultLogger(),
+		Converter: converter.DefaultConverter,
+	}
+
+	for _, o := range opts {
+		o(options)
+	}
+
+	instance, h, err := unmarshalHistory(historyJSON)
+	if err != nil {
+		return err
+	}
+
+	var name string
+	for _, event := range h {
+		if event.Type == history.EventType_WorkflowExecutionStarted {
+			name = event.Attributes.(*history.ExecutionStartedAttributes).Name
+			break
+		}
+	}
+
+	if name == "" {
+		return errors.New("history does not contain a workflow execution started event")
+	}
+
+	registry := workflow.NewRegistry()
+	if err := registry.RegisterWorkflowByName(name, wf); err != nil {
+		return err
+	}
+
+	return workflow.ReplayHistory(
+		context.Background(),
+		options.Logger.With("source", "tester"),
+		trace.NewNoopTracerProvider().Tracer("workflow-tester"),
+		registry,
+		options.Converter,
+		options.Propagators,
+		instance,
+		nil,
+		h,
+	)
+}
@@ -0,0 +1,67 @@
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReplayHistory(t *testing.T) {
+	activity1 := func(ctx context.Context) (int, error) {
+		return 42, nil
+	}
+
+	wf := func(ctx workflow.Context) (int, error) {
+		workflow.ScheduleTimer(ctx, time.Hour).Get(ctx)
+
+		return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, activity1).Get(ctx)
+	}
+
+	wt := NewWorkflowTester[int](wf)
+	wt.OnActivity(activity1, mock.Anything).Return(42, nil)
+	wt.Execute(context.Background())
+	require.True(t, wt.WorkflowFinished())
+
+	tw := wt.getWorkflow(wt.wfi)
+
+	diagExport, err := marshalHistory(tw.instance, 0, tw.history)
+	require.NoError(t, err)
+
+	events, err := json.Marshal(tw.history)
+	require.NoError(t, err)
+
+	// Renamed workflow function with the same logic
+	renamedWf := func(ctx workflow.Context) (int, error) {
+		workflow.ScheduleTimer(ctx, time.Hour).Get(ctx)
+
+		return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, activity1).Get(ctx)
+	}
+
+	changedWf := func(ctx workflow.Context) (int, error) {
+		return workflow.ExecuteActivity[int](ctx, workflow.DefaultActivityOptions, activity1).Get(ctx)
+	}
+
+	for name, data := range map[string][]byte{"diag": diagExport, "events": events} {
+		t.Run(name, func(t *testing.T) {
+			ReplayHistory(t, wf, data)
+			ReplayHistory(t, renamedWf, data)
+
+			require.Error(t, replayHistory(changedWf, data))
+		})
+	}
+}
+
+func Test_ReplayHistory_InvalidHistory(t *testing.T) {
+	wf := func(ctx workflow.Context) error {
+		return nil
+	}
+
+	require.Error(t, replayHistory(wf, []byte(`[]`)))
+	require.Error(t, replayHistory(wf, []byte(`{"history": [{"type": "Unknown"}]}`)))
+	require.Error(t, replayHistory(wf, []byte(`{"history": [{"type": "WorkflowTaskStarted", "attributes": {}}]}`)))
+}
@@ -72,6 +72,10 @@ type testWorkflow struct {
 	metadata      *core.WorkflowMetadata
 	history       []*history.Event
 	pendingEvents []*history.Event
+
+	// registry is used to execute this workflow instance, if nil the tester's registry is used. Set when a
+	// mocked sub-workflow is replaced with a simplified implementation.
+	registry *workflow.Registry
 }
 
 type WorkflowTester[TResult any] interface {
@@ -86,21 +90,40 @@ type WorkflowTester[TResult any] interface {
 
 	OnActivityByName(name string, activity workflow.Activity, args ...interface{}) *mock.Call
 
+	// OnSubWorkflow mocks the given sub-workflow. The returned call can either return a result and an error, only an
+	// error, or a workflow function with the same signature as the mocked workflow. A returned function is executed
+	// instead of the original implementation.
+	//
+	// To simulate a sub-workflow that was canceled, return workflow.Canceled as the error.
 	OnSubWorkflow(workflow workflow.Workflow, args ...interface{}) *mock.Call
 
 	OnSubWorkflowByName(name string, workflow workflow.Workflow, args ...interface{}) *mock.Call
 
 	SignalWorkflow(signalName string, value interface{})
 
+	// SignalWorkflowAt sends the given signal to the workflow under test after the given delay in workflow time
+	// (not wall clock).
+	SignalWorkflowAt(delay time.Duration, signalName string, value interface{})
+
 	SignalWorkflowInstance(wfi *core.WorkflowInstance, signalName string, value interface{}) error
 
 	WorkflowFinished() bool
 
 	WorkflowResult() (TResult, error)
 
+	// History returns the history of the workflow under test. Use this, for example, with the AssertActivityScheduled
+	// family of assertions.
+	History() []*history.Event
+
 	// AssertExpectations asserts any assertions set up for mock activities and sub-workflow
 	AssertExpectations(t *testing.T)
 
+	// AssertGoldenHistory replays the golden history stored at path against the current workflow code, and fails
+	// the test if the workflow code is not able to replay it. If the file does not exist, or the test is run with
+	// -tester.update-golden, the history of the workflow under test is recorded to path instead. Call this after
+	// Execute.
+	AssertGoldenHistory(t *testing.T, path string)
+
 	// ScheduleCallback schedules the given callback after the given delay in workflow time (not wall clock).
 	ScheduleCallback(delay time.Duration, callback func())
 
@@ -133,6 +156,10 @@ type workflowTester[TResult any] struct {
 	mw              *mock.Mock
 	mockedWorkflows map[string]bool
 
+	// mockedWorkflowInstances contains all sub-workflow instances for which a mocked result was returned. Events
+	// sent to these instances, e.g., cancellation requests from the parent, are dropped.
+	mockedWorkflowInstances map[string]bool
+
 	workflowHistory []*history.Event
 	clock           *clock.Mock
 	wallClock       clock.Clock
@@ -201,8 +228,9 @@ func NewWorkflowTester[TResult any](wf interface{}, opts ...WorkflowTesterOption
 		ma:               &mock.Mock{},
 		mockedActivities: make(map[string]bool),
 
-		mw:              &mock.Mock{},
-		mockedWorkflows: make(map[string]bool),
+		mw:                      &mock.Mock{},
+		mockedWorkflows:         make(map[string]bool),
+		mockedWorkflowInstances: make(map[string]bool),
 
 		workflowHistory: make([]*history.Event, 0),
 		clock:           c,
@@ -239,7 +267,11 @@ func (wt *workflowTester[TResult]) Registry() *workflow.Registry {
 }
 
 func (wt *workflowTester[TResult]) ScheduleCallback(delay time.Duration, callback func()) {
-	wt.timers = append(wt.timers, &testTimer{
+	if wt.options.fuzzer != nil {
+		delay += wt.options.fuzzer.duration(wt.options.fuzzer.maxCallbackJitter)
+	}
+
+	wt.addTimer(&testTimer{
 		At:         wt.clock.Now().Add(delay),
 		Callback:   &callback,
 		TimerEvent: nil,
@@ -296,7 +328,7 @@ func (wt *workflowTester[TResult]) Execute(ctx context.Context, args ...interfac
 
 	// Start workflow under test
 	initialEvent := wt.getInitialEvent(wt.wf, args)
-	wt.addWorkflow(wt.wfi, wt.wfm, initialEvent)
+	wt.addWorkflow(wt.wfi, wt.wfm, initialEvent, nil)
 
 	for !wt.workflowFinished {
 		// Execute all workflows until no more events
@@ -312,8 +344,23 @@ func (wt *workflowTester[TResult]) Execute(ctx context.Context, args ...interfac
 			t := getNextWorkflowTask(tw.instance, tw.history, tw.pendingEvents)
 			tw.pendingEvents = tw.pendingEvents[:0]
 
+			registry := wt.registry
+			if tw.registry != nil {
+				registry = tw.registry
+			}
+
+			var opts []workflow.ExecutorOption
+			if wt.options.DeterminismGuard {
+				opts = append(opts, workflow.WithDeterminismGuard())
+			}
+
+			if wt.options.ContinueAsNewHistoryEvents > 0 || wt.options.ContinueAsNewHistoryBytes > 0 {
+				opts = append(opts, workflow.WithContinueAsNewThresholds(
+					wt.options.ContinueAsNewHistoryEvents, wt.options.ContinueAsNewHistoryBytes))
+			}
+
 			// Execute task
-			e, err := workflow.NewExecutor(wt.logger, wt.tracer, wt.registry, wt.converter, wt.propagators, &testHistoryProvider{tw.history}, tw.instance, tw.metadata, wt.clock)
+			e, err := workflow.NewExecutor(wt.logger, wt.tracer, registry, wt.converter, wt.propagators, &testHistoryProvider{tw.history}, tw.instance, tw.metadata, wt.clock, opts...)
 			if err != nil {
 				panic(fmt.Errorf("could not create workflow executor: %v", err))
 			}
@@ -356,6 +403,12 @@ func (wt *workflowTester[TResult]) Execute(ctx context.Context, args ...interfac
 					wt.scheduleSubWorkflow(workflowEvent)
 
 				default:
+					if wt.mockedWorkflowInstances[workflowEvent.WorkflowInstance.InstanceID] {
+						// Mocked sub-workflows have already completed, nothing to deliver the event to
+						wt.logger.Debug("Dropping event for mocked sub-workflow", log.InstanceIDKey, workflowEvent.WorkflowInstance.InstanceID)
+						continue
+					}
+
 					wt.sendEvent(workflowEvent.WorkflowInstance, workflowEvent.HistoryEvent)
 				}
 			}
@@ -383,7 +436,7 @@ func (wt *workflowTester[TResult]) Execute(ctx context.Context, args ...interfac
 			// No new events left and workflows aren't finished yet. Check for callbacks
 			select {
 			case callback := <-wt.callbacks:
-				event := callback()
+				event := wt.pickCallback(callback)()
 				if event != nil {
 					wt.sendEvent(event.WorkflowInstance, event.HistoryEvent)
 					gotNewEvents = true
@@ -403,7 +456,7 @@ func (wt *workflowTester[TResult]) Execute(ctx context.Context, args ...interfac
 
 			select {
 			case callback := <-wt.callbacks:
-				event := callback()
+				event := wt.pickCallback(callback)()
 				if event != nil {
 					wt.sendEvent(event.WorkflowInstance, event.HistoryEvent)
 					gotNewEvents = true
@@ -417,6 +470,14 @@ func (wt *workflowTester[TResult]) Execute(ctx context.Context, args ...interfac
 	}
 }
 
+func (wt *workflowTester[TResult]) pickCallback(callback func() *history.WorkflowEvent) func() *history.WorkflowEvent {
+	if wt.options.fuzzer == nil {
+		return callback
+	}
+
+	return wt.options.fuzzer.pickCallback(callback, wt.callbacks)
+}
+
 func (wt *workflowTester[TResult]) fireTimer() bool {
 	if len(wt.timers) == 0 {
 		// No timers to fire
@@ -450,10 +511,13 @@ func (wt *workflowTester[TResult]) fireTimer() bool {
 			t := wt.timers[0]
 			wt.timers = wt.timers[1:]
 
-			wt.logger.Debug("Advancing workflow clock to fire timer", log.ToKey, t.At)
+			// Advance workflow clock and fire the timer. Never move the clock backwards, timers that are
+			// already due fire at the current time.
+			if t.At.After(wt.clock.Now()) {
+				wt.logger.Debug("Advancing workflow clock to fire timer", log.ToKey, t.At)
+				wt.clock.Set(t.At)
+			}
 
-			// Advance workflow clock and fire the timer
-			wt.clock.Set(t.At)
 			wt.callbacks <- t.fire
 			return true
 		}
@@ -475,13 +539,19 @@ func (wt *workflowTester[TResult]) fireTimer() bool {
 			// Schedule timer
 			wt.wallClockTimer = wt.wallClock.AfterFunc(remainingTime, func() {
 				wt.callbacks <- func() *history.WorkflowEvent {
-					// Remove timer
-					wt.timers = wt.timers[1:]
+					// Remove timer. Other timers might have been scheduled or canceled in the meantime, so
+					// look it up instead of assuming it's still the first one.
+					if !wt.removeTimer(t) {
+						// Timer was canceled while we were waiting for it
+						return nil
+					}
+
 					wt.wallClockTimer = nil
 
 					return t.fire()
 				}
 			})
+			t.wallClockTimer = wt.wallClockTimer
 		}
 	}
 
@@ -511,6 +581,12 @@ func (wt *workflowTester[TResult]) SignalWorkflow(name string, value interface{}
 	wt.SignalWorkflowInstance(wt.wfi, name, value)
 }
 
+func (wt *workflowTester[TResult]) SignalWorkflowAt(delay time.Duration, name string, value interface{}) {
+	wt.ScheduleCallback(delay, func() {
+		wt.SignalWorkflow(name, value)
+	})
+}
+
 func (wt *workflowTester[TResult]) SignalWorkflowInstance(wfi *core.WorkflowInstance, name string, value interface{}) error {
 	if wt.getWorkflow(wfi) == nil {
 		return backend.ErrInstanceNotFound
@@ -556,8 +632,18 @@ func (wt *workflowTester[TResult]) WorkflowResult() (TResult, error) {
 	return r, err
 }
 
+func (wt *workflowTester[TResult]) History() []*history.Event {
+	tw := wt.getWorkflow(wt.wfi)
+	if tw == nil {
+		return nil
+	}
+
+	return tw.history
+}
+
 func (wt *workflowTester[TResult]) AssertExpectations(t *testing.T) {
 	wt.ma.AssertExpectations(t)
+	wt.mw.AssertExpectations(t)
 }
 
 func (wt *workflowTester[TResult]) scheduleActivity(wfi *core.WorkflowInstance, wfm *core.WorkflowMetadata, event *history.Event) {
@@ -570,9 +656,16 @@ func (wt *workflowTester[TResult]) scheduleActivity(wfi *core.WorkflowInstance,
 
 		var activityErr error
 		var activityResult payload.Payload
+		var activityCheckpoint payload.Payload
+
+		if f := wt.options.fuzzer; f != nil {
+			time.Sleep(f.duration(f.maxActivityDelay))
+		}
 
-		// Execute mocked activity. If an activity is mocked once, we'll never fall back to the original implementation
-		if wt.mockedActivities[e.Name] {
+		if wt.options.fuzzer != nil && wt.options.fuzzer.failActivity() {
+			activityErr = errInjectedActivityFailure
+		} else if wt.mockedActivities[e.Name] {
+			// Execute mocked activity. If an activity is mocked once, we'll never fall back to the original implementation
 			afn, err := wt.registry.GetActivity(e.Name)
 			if err != nil {
 				panic("Could not find activity " + e.Name + " in registry")
@@ -629,7 +722,7 @@ func (wt *workflowTester[TResult]) scheduleActivity(wfi *core.WorkflowInstance,
 
 		} else {
 			executor := activity.NewExecutor(wt.logger, wt.tracer, wt.converter, wt.propagators, wt.registry)
-			activityResult, activityErr = executor.ExecuteActivity(context.Background(), &task.Activity{
+			activityResult, activityCheckpoint, activityErr = executor.ExecuteActivity(context.Background(), &task.Activity{
 				ID:               uuid.NewString(),
 				WorkflowInstance: wfi,
 				Event:            event,
@@ -646,7 +739,8 @@ func (wt *workflowTester[TResult]) scheduleActivity(wfi *core.WorkflowInstance,
 					wt.clock.Now(),
 					history.EventType_ActivityFailed,
 					&history.ActivityFailedAttributes{
-						Error: aerr,
+						Error:      aerr,
+						Checkpoint: activityCheckpoint,
 					},
 					history.ScheduleEventID(event.ScheduleEventID),
 				)
@@ -672,7 +766,7 @@ func (wt *workflowTester[TResult]) scheduleActivity(wfi *core.WorkflowInstance,
 func (wt *workflowTester[TResult]) scheduleTimer(instance *core.WorkflowInstance, event *history.Event) {
 	e := event.Attributes.(*history.TimerFiredAttributes)
 
-	wt.timers = append(wt.timers, &testTimer{
+	wt.addTimer(&testTimer{
 		Instance:        instance,
 		ScheduleEventID: event.ScheduleEventID,
 		At:              e.At,
@@ -681,18 +775,39 @@ func (wt *workflowTester[TResult]) scheduleTimer(instance *core.WorkflowInstance
 			HistoryEvent:     event,
 		},
 	})
+}
+
+// addTimer adds the given timer to the list of pending timers. Timers are kept ordered by their due time, so that
+// the tester can always skip ahead to the next timer when workflows are idle.
+func (wt *workflowTester[TResult]) addTimer(t *testTimer) {
+	wt.timers = append(wt.timers, t)
 
 	sort.SliceStable(wt.timers, func(i, j int) bool {
 		return wt.timers[i].At.Before(wt.timers[j].At)
 	})
 }
 
+func (wt *workflowTester[TResult]) removeTimer(t *testTimer) bool {
+	for i, pt := range wt.timers {
+		if pt == t {
+			wt.timers = append(wt.timers[:i], wt.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
 func (wt *workflowTester[TResult]) cancelTimer(instance *core.WorkflowInstance, event *history.Event) {
 	for i, t := range wt.timers {
 		if t.Instance != nil && t.Instance.InstanceID == instance.InstanceID && t.ScheduleEventID == event.ScheduleEventID {
 			// If this was the next timer to fire, stop the timer
 			if t.wallClockTimer != nil {
 				t.wallClockTimer.Stop()
+
+				if wt.wallClockTimer == t.wallClockTimer {
+					wt.wallClockTimer = nil
+				}
 			}
 
 			wt.timers = append(wt.timers[:i], wt.timers[i+1:]...)
@@ -708,7 +823,7 @@ func (wt *workflowTester[TResult]) getWorkflow(instance *core.WorkflowInstance)
 	return wt.testWorkflowsByInstanceID[instance.InstanceID]
 }
 
-func (wt *workflowTester[TResult]) addWorkflow(instance *core.WorkflowInstance, metadata *core.WorkflowMetadata, initialEvent *history.Event) *testWorkflow {
+func (wt *workflowTester[TResult]) addWorkflow(instance *core.WorkflowInstance, metadata *core.WorkflowMetadata, initialEvent *history.Event, registry *workflow.Registry) *testWorkflow {
 	wt.mtw.Lock()
 	defer wt.mtw.Unlock()
 
@@ -717,6 +832,7 @@ func (wt *workflowTester[TResult]) addWorkflow(instance *core.WorkflowInstance,
 		metadata:      metadata,
 		pendingEvents: []*history.Event{initialEvent},
 		history:       make([]*history.Event, 0),
+		registry:      registry,
 	}
 	wt.testWorkflows = append(wt.testWorkflows, tw)
 	wt.testWorkflowsByInstanceID[instance.InstanceID] = tw
@@ -754,7 +870,7 @@ func (wt *workflowTester[TResult]) scheduleSubWorkflow(event history.WorkflowEve
 
 	if !wt.mockedWorkflows[a.Name] {
 		// Workflow not mocked, allow event to be processed
-		wt.addWorkflow(event.WorkflowInstance, a.Metadata, event.HistoryEvent)
+		wt.addWorkflow(event.WorkflowInstance, a.Metadata, event.HistoryEvent, nil)
 		return
 	}
 
@@ -763,6 +879,19 @@ func (wt *workflowTester[TResult]) scheduleSubWorkflow(event history.WorkflowEve
 
 	results := wt.mw.MethodCalled(a.Name, args...)
 
+	if len(results) == 1 && reflect.TypeOf(results.Get(0)) != nil && reflect.TypeOf(results.Get(0)).Kind() == reflect.Func {
+		// Mock returned a simplified implementation for the sub-workflow, execute that instead of the real one
+		registry := workflow.NewRegistry()
+		if err := registry.RegisterWorkflowByName(a.Name, results.Get(0)); err != nil {
+			panic("Could not register implementation for mocked workflow " + a.Name + ": " + err.Error())
+		}
+
+		wt.addWorkflow(event.WorkflowInstance, a.Metadata, event.HistoryEvent, registry)
+		return
+	}
+
+	wt.mockedWorkflowInstances[event.WorkflowInstance.InstanceID] = true
+
 	switch len(results) {
 	case 1:
 		// Expect only error
@@ -3,8 +3,10 @@ package tester
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/cschleiden/go-workflows/activity"
 	"github.com/cschleiden/go-workflows/internal/sync"
 	"github.com/cschleiden/go-workflows/internal/workflowerrors"
 	"github.com/cschleiden/go-workflows/workflow"
@@ -45,3 +47,109 @@ func Test_withRetries_permanent(t *testing.T) {
 
 	require.Equal(t, 1, attempts)
 }
+
+type validationError struct{}
+
+func (*validationError) Error() string {
+	return "invalid input"
+}
+
+func Test_withRetries_NonRetryableErrorTypes(t *testing.T) {
+	calls := 0
+	activity1 := func(ctx context.Context) (int, error) {
+		calls++
+		return 0, fmt.Errorf("validating: %w", &validationError{})
+	}
+
+	wf := func(ctx workflow.Context) (int, error) {
+		return workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+			RetryOptions: workflow.RetryOptions{
+				MaxAttempts:            5,
+				NonRetryableErrorTypes: []string{"validationError"},
+			},
+		}, activity1).Get(ctx)
+	}
+
+	tester := NewWorkflowTester[int](wf)
+	tester.Registry().RegisterActivity(activity1)
+
+	tester.Execute(context.Background())
+	require.True(t, tester.WorkflowFinished())
+
+	_, err := tester.WorkflowResult()
+	require.ErrorContains(t, err, "invalid input")
+	require.Equal(t, 1, calls)
+}
+
+func Test_withRetries_NonRetryableError(t *testing.T) {
+	calls := 0
+	activity1 := func(ctx context.Context) (int, error) {
+		calls++
+		return 0, fmt.Errorf("validating: %w", workflow.NewNonRetryableError(errors.New("invalid input")))
+	}
+
+	wf := func(ctx workflow.Context) (int, error) {
+		return workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+			RetryOptions: workflow.RetryOptions{
+				MaxAttempts: 5,
+			},
+		}, activity1).Get(ctx)
+	}
+
+	tester := NewWorkflowTester[int](wf)
+	tester.Registry().RegisterActivity(activity1)
+
+	tester.Execute(context.Background())
+	require.True(t, tester.WorkflowFinished())
+
+	_, err := tester.WorkflowResult()
+	require.ErrorContains(t, err, "invalid input")
+	require.Equal(t, 1, calls)
+}
+
+func Test_withRetries_ApplicationError(t *testing.T) {
+	type details struct {
+		Reason string `json:"reason"`
+	}
+
+	for _, nonRetryable := range []bool{true, false} {
+		t.Run(fmt.Sprintf("nonRetryable=%v", nonRetryable), func(t *testing.T) {
+			calls := 0
+			activity1 := func(ctx context.Context) (int, error) {
+				calls++
+				return 0, activity.NewApplicationError("card declined", details{Reason: "expired"}, nonRetryable)
+			}
+
+			wf := func(ctx workflow.Context) (string, error) {
+				_, err := workflow.ExecuteActivity[int](ctx, workflow.ActivityOptions{
+					RetryOptions: workflow.RetryOptions{
+						MaxAttempts: 3,
+					},
+				}, activity1).Get(ctx)
+
+				var d details
+				if ok, perr := workflow.ErrorPayload(err, &d); !ok || perr != nil {
+					return "", fmt.Errorf("no payload: %w", err)
+				}
+
+				return d.Reason, nil
+			}
+
+			tester := NewWorkflowTester[string](wf)
+			tester.Registry().RegisterActivity(activity1)
+
+			tester.Execute(context.Background())
+			require.True(t, tester.WorkflowFinished())
+
+			reason, err := tester.WorkflowResult()
+			require.NoError(t, err)
+			require.Equal(t, "expired", reason)
+
+			if nonRetryable {
+				require.Equal(t, 1, calls)
+			} else {
+				require.Equal(t, 3, calls)
+			}
+		})
+	}
+}
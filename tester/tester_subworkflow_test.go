@@ -152,3 +152,99 @@ func Test_SubWorkflow_Signals(t *testing.T) {
 	require.Equal(t, "hello42", wfR)
 	tester.AssertExpectations(t)
 }
+
+func Test_SubWorkflow_Mocked_Implementation(t *testing.T) {
+	subWorkflow := func(ctx workflow.Context, input string) (string, error) {
+		panic("should not call this")
+	}
+
+	wf := func(ctx workflow.Context, input string) (string, error) {
+		return workflow.CreateSubWorkflowInstance[string](
+			ctx,
+			workflow.DefaultSubWorkflowOptions,
+			subWorkflow,
+			input,
+		).Get(ctx)
+	}
+
+	tester := NewWorkflowTester[string](wf)
+	tester.OnSubWorkflow(subWorkflow, mock.Anything, "hello").Return(func(ctx workflow.Context, input string) (string, error) {
+		workflow.ScheduleTimer(ctx, time.Hour).Get(ctx)
+
+		return input + " from fake", nil
+	})
+
+	tester.Execute(context.Background(), "hello")
+
+	require.True(t, tester.WorkflowFinished())
+
+	wfR, wfE := tester.WorkflowResult()
+	require.NoError(t, wfE)
+	require.Equal(t, "hello from fake", wfR)
+	tester.AssertExpectations(t)
+}
+
+func Test_SubWorkflow_Mocked_Canceled(t *testing.T) {
+	subWorkflow := func(ctx workflow.Context, input string) (string, error) {
+		panic("should not call this")
+	}
+
+	wf := func(ctx workflow.Context, input string) (string, error) {
+		_, err := workflow.CreateSubWorkflowInstance[string](
+			ctx,
+			workflow.DefaultSubWorkflowOptions,
+			subWorkflow,
+			input,
+		).Get(ctx)
+		if err != nil {
+			return "child canceled: " + err.Error(), nil
+		}
+
+		return "", nil
+	}
+
+	tester := NewWorkflowTester[string](wf)
+	tester.OnSubWorkflow(subWorkflow, mock.Anything, mock.Anything).Return(nil, workflow.Canceled)
+
+	tester.Execute(context.Background(), "hello")
+
+	require.True(t, tester.WorkflowFinished())
+
+	wfR, wfE := tester.WorkflowResult()
+	require.NoError(t, wfE)
+	require.Equal(t, "child canceled: context canceled", wfR)
+	tester.AssertExpectations(t)
+}
+
+func Test_SubWorkflow_Mocked_CancelAfterCompletion(t *testing.T) {
+	subWorkflow := func(ctx workflow.Context) (string, error) {
+		panic("should not call this")
+	}
+
+	wf := func(ctx workflow.Context) (string, error) {
+		sctx, cancel := workflow.WithCancel(ctx)
+
+		f := workflow.CreateSubWorkflowInstance[string](sctx, workflow.DefaultSubWorkflowOptions, subWorkflow)
+
+		workflow.ScheduleTimer(ctx, time.Minute).Get(ctx)
+
+		// Mocked sub-workflow has already completed at this point
+		cancel()
+
+		return f.Get(ctx)
+	}
+
+	tester := NewWorkflowTester[string](wf)
+	tester.OnSubWorkflow(subWorkflow, mock.Anything).Return("sresult", nil)
+
+	require.NotPanics(t, func() {
+		tester.Execute(context.Background())
+	})
+
+	require.True(t, tester.WorkflowFinished())
+
+	wfR, wfE := tester.WorkflowResult()
+	require.NoError(t, wfE)
+	require.Equal(t, "sresult", wfR)
+	tester.AssertExpectations(t)
+}
@@ -3,9 +3,11 @@ package tester
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/cschleiden/go-workflows/backend"
 	"github.com/cschleiden/go-workflows/internal/sync"
 	"github.com/cschleiden/go-workflows/workflow"
@@ -176,6 +178,80 @@ func Test_Signals(t *testing.T) {
 	tester.AssertExpectations(t)
 }
 
+func Test_SignalWorkflowAt(t *testing.T) {
+	wf := func(ctx workflow.Context) (string, error) {
+		start := workflow.Now(ctx)
+
+		// Wait for approval, or give up after a day
+		tctx, cancel := workflow.WithCancel(ctx)
+		defer cancel()
+
+		var approval string
+
+		workflow.Select(ctx,
+			workflow.Receive(workflow.NewSignalChannel[string](ctx, "approval"), func(ctx workflow.Context, v string, ok bool) {
+				approval = v
+			}),
+			workflow.Await(workflow.ScheduleTimer(tctx, 24*time.Hour), func(ctx workflow.Context, f workflow.Future[struct{}]) {
+				approval = "timeout"
+			}),
+		)
+
+		return fmt.Sprintf("%s after %v", approval, workflow.Now(ctx).Sub(start)), nil
+	}
+
+	tester := NewWorkflowTester[string](wf)
+	tester.SignalWorkflowAt(2*time.Hour, "approval", "approved")
+
+	tester.Execute(context.Background())
+
+	require.True(t, tester.WorkflowFinished())
+
+	wfR, wfE := tester.WorkflowResult()
+	require.NoError(t, wfE)
+	require.Equal(t, "approved after 2h0m0s", wfR)
+}
+
+func Test_WaitForSignal(t *testing.T) {
+	wf := func(ctx workflow.Context) (string, error) {
+		return workflow.WaitForSignal[string](ctx, "approval", 24*time.Hour)
+	}
+
+	tester := NewWorkflowTester[string](wf)
+	tester.SignalWorkflowAt(2*time.Hour, "approval", "approved")
+
+	tester.Execute(context.Background())
+
+	require.True(t, tester.WorkflowFinished())
+
+	wfR, wfE := tester.WorkflowResult()
+	require.NoError(t, wfE)
+	require.Equal(t, "approved", wfR)
+}
+
+func Test_WaitForSignal_Timeout(t *testing.T) {
+	wf := func(ctx workflow.Context) (string, error) {
+		start := workflow.Now(ctx)
+
+		_, err := workflow.WaitForSignal[string](ctx, "approval", 24*time.Hour)
+		if !errors.Is(err, workflow.ErrSignalTimeout) {
+			return "", err
+		}
+
+		return fmt.Sprintf("timeout after %v", workflow.Now(ctx).Sub(start)), nil
+	}
+
+	tester := NewWorkflowTester[string](wf)
+
+	tester.Execute(context.Background())
+
+	require.True(t, tester.WorkflowFinished())
+
+	wfR, wfE := tester.WorkflowResult()
+	require.NoError(t, wfE)
+	require.Equal(t, "timeout after 24h0m0s", wfR)
+}
+
 func workflowSignal(ctx workflow.Context) (string, error) {
 	sc := workflow.NewSignalChannel[string](ctx, "signal")
 
@@ -253,3 +329,62 @@ func waitForSignal(ctx workflow.Context) (int, error) {
 
 	return 42, nil
 }
+
+func Test_DeterminismGuard(t *testing.T) {
+	prev := sync.DeterminismGuardInterval
+	sync.DeterminismGuardInterval = time.Millisecond * 10
+	defer func() { sync.DeterminismGuardInterval = prev }()
+
+	c := make(chan int)
+	t.Cleanup(func() { close(c) })
+
+	wf := func(ctx workflow.Context) (int, error) {
+		return <-c, nil
+	}
+
+	tester := NewWorkflowTester[int](wf, WithDeterminismGuard())
+	tester.Execute(context.Background())
+
+	require.True(t, tester.WorkflowFinished())
+	_, err := tester.WorkflowResult()
+	require.ErrorContains(t, err, "native channel operation")
+}
+
+func Test_DeterminismGuard_GuardedClock(t *testing.T) {
+	c := workflow.NewGuardedClock(clock.New())
+
+	wf := func(ctx workflow.Context) (time.Time, error) {
+		return c.Now(), nil
+	}
+
+	tester := NewWorkflowTester[time.Time](wf, WithDeterminismGuard())
+	tester.Execute(context.Background())
+
+	require.True(t, tester.WorkflowFinished())
+	_, err := tester.WorkflowResult()
+	require.ErrorContains(t, err, "reading wall-clock time with Now")
+
+	// Outside of workflows, the clock can be used
+	require.False(t, c.Now().IsZero())
+}
+
+func Test_ShouldContinueAsNew(t *testing.T) {
+	wf := func(ctx workflow.Context) (int, error) {
+		for i := 0; ; i++ {
+			workflow.Sleep(ctx, time.Second)
+
+			if workflow.ShouldContinueAsNew(ctx) {
+				return i, nil
+			}
+		}
+	}
+
+	// Every iteration adds 3 events to the history
+	tester := NewWorkflowTester[int](wf, WithContinueAsNewThresholds(10, 0))
+	tester.Execute(context.Background())
+
+	require.True(t, tester.WorkflowFinished())
+	r, err := tester.WorkflowResult()
+	require.NoError(t, err)
+	require.Equal(t, 3, r)
+}
@@ -243,3 +243,55 @@ func Test_Timers_MultipleTimers(t *testing.T) {
 	require.Empty(t, werr)
 	tester.AssertExpectations(t)
 }
+
+func Test_Timers_LongTimerSkipsAhead(t *testing.T) {
+	wf := func(ctx workflow.Context) (time.Time, error) {
+		// Reminder in 30 days
+		workflow.ScheduleTimer(ctx, time.Hour*24*30).Get(ctx)
+
+		return workflow.Now(ctx), nil
+	}
+
+	tester := NewWorkflowTester[time.Time](wf, WithTestTimeout(time.Second))
+	start := tester.Now()
+	wallStart := time.Now()
+
+	tester.Execute(context.Background())
+
+	require.True(t, tester.WorkflowFinished())
+	wr, werr := tester.WorkflowResult()
+	require.NoError(t, werr)
+
+	e := start.Add(time.Hour * 24 * 30)
+	require.True(t, e.Equal(wr), "expected %v, got %v", e, wr)
+	require.Less(t, time.Since(wallStart), time.Second)
+}
+
+func Test_Timers_CallbacksAndTimersFireInOrder(t *testing.T) {
+	wf := func(ctx workflow.Context) (string, error) {
+		workflow.ScheduleTimer(ctx, time.Hour).Get(ctx)
+
+		r, _ := workflow.NewSignalChannel[string](ctx, "signal").Receive(ctx)
+
+		return r, nil
+	}
+
+	tester := NewWorkflowTester[string](wf)
+	start := tester.Now()
+
+	var callbackTime time.Time
+
+	// Scheduled before the workflow timer is known but due later, callbacks need to be ordered with timers
+	tester.ScheduleCallback(time.Hour*2, func() {
+		callbackTime = tester.Now()
+		tester.SignalWorkflow("signal", "done")
+	})
+
+	tester.Execute(context.Background())
+
+	require.True(t, tester.WorkflowFinished())
+	wr, werr := tester.WorkflowResult()
+	require.NoError(t, werr)
+	require.Equal(t, "done", wr)
+	require.True(t, start.Add(time.Hour*2).Equal(callbackTime), "expected %v, got %v", start.Add(time.Hour*2), callbackTime)
+}
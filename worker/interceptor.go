@@ -0,0 +1,17 @@
+package worker
+
+import "github.com/cschleiden/go-workflows/internal/interceptor"
+
+type (
+	WorkflowInterceptor = interceptor.WorkflowInterceptor
+	ActivityInterceptor = interceptor.ActivityInterceptor
+
+	// NoopWorkflowInterceptor can be embedded to implement only some of the methods of WorkflowInterceptor
+	NoopWorkflowInterceptor = interceptor.NoopWorkflowInterceptor
+	ActivityInterceptorFunc = interceptor.ActivityInterceptorFunc
+
+	WorkflowTaskInfo = interceptor.WorkflowTaskInfo
+	SignalInfo       = interceptor.SignalInfo
+	SubWorkflowInfo  = interceptor.SubWorkflowInfo
+	ActivityInfo     = interceptor.ActivityInfo
+)
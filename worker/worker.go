@@ -2,8 +2,10 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/benbjohnson/clock"
 	"github.com/cschleiden/go-workflows/backend"
@@ -11,15 +13,42 @@ import (
 	"github.com/cschleiden/go-workflows/internal/signals"
 	internal "github.com/cschleiden/go-workflows/internal/worker"
 	workflowinternal "github.com/cschleiden/go-workflows/internal/workflow"
+	"github.com/cschleiden/go-workflows/maintenance"
 	"github.com/cschleiden/go-workflows/workflow"
 )
 
 type WorkflowRegistry interface {
-	RegisterWorkflow(w workflow.Workflow) error
+	RegisterWorkflow(w workflow.Workflow, opts ...RegisterOption) error
 }
 
 type ActivityRegistry interface {
-	RegisterActivity(a interface{}) error
+	RegisterActivity(a interface{}, opts ...RegisterOption) error
+}
+
+// RegisterOption configures the registration of a workflow or activity
+type RegisterOption = workflowinternal.RegisterOption
+
+// PanicPolicy determines how a panic in a workflow or activity is handled. Panics are recovered and recorded as
+// workflow.PanicError including the stack trace.
+type PanicPolicy = workflowinternal.PanicPolicy
+
+const (
+	// PanicPolicyDefault fails the workflow for panics in workflows, and the activity task for panics in activities
+	PanicPolicyDefault = workflowinternal.PanicPolicyDefault
+
+	// PanicPolicyFailTask fails the task the panic occurred in. Failed workflow tasks are attempted again until the
+	// instance is moved to the dead-letter state, so a fixed version of the workflow can continue it. Failed activity
+	// tasks are retried according to the retry options of the activity.
+	PanicPolicyFailTask = workflowinternal.PanicPolicyFailTask
+
+	// PanicPolicyFailWorkflow fails the workflow with the panic. Panics in activities are returned as permanent
+	// errors to the workflow, without retrying the activity.
+	PanicPolicyFailWorkflow = workflowinternal.PanicPolicyFailWorkflow
+)
+
+// WithPanicPolicy sets how panics in the registered workflow or activity are handled
+func WithPanicPolicy(policy PanicPolicy) RegisterOption {
+	return workflowinternal.WithPanicPolicy(policy)
 }
 
 type Registry interface {
@@ -36,27 +65,53 @@ type Worker interface {
 	// work items, call `WaitForCompletion`.
 	Start(ctx context.Context) error
 
-	// WaitForCompletion
+	// WaitForCompletion waits until the pollers have stopped and all running tasks have finished, after the context
+	// passed to Start has been canceled.
 	WaitForCompletion() error
+
+	// Shutdown stops polling for new tasks and waits for running tasks to finish. If ctx is done, or the
+	// ShutdownTimeout of the options expires, before all tasks have finished, running activities are canceled and
+	// their tasks are released, so other workers can pick them up right away, and the error of the context is
+	// returned. Workflow tasks are short and are not interrupted.
+	Shutdown(ctx context.Context) error
 }
 
 type worker struct {
 	backend backend.Backend
 
+	options *Options
+
 	done chan struct{}
 	wg   *sync.WaitGroup
 
+	// cancel stops the pollers started by Start
+	cancel context.CancelFunc
+
+	waitOnce sync.Once
+	waitErr  error
+
 	registry *workflowinternal.Registry
 
 	workflowWorker *internal.WorkflowWorker
 	activityWorker *internal.ActivityWorker
 
+	maintenance *maintenance.Runner
+
 	workflows  map[string]interface{}
 	activities map[string]interface{}
 }
 
 type Options = internal.Options
 
+type RetryPolicy = internal.RetryPolicy
+
+type (
+	PollerScaler        = internal.PollerScaler
+	PollerScalerFunc    = internal.PollerScalerFunc
+	PollerStats         = internal.PollerStats
+	DefaultPollerScaler = internal.DefaultPollerScaler
+)
+
 var DefaultWorkerOptions = internal.DefaultOptions
 
 func New(backend backend.Backend, options *Options) Worker {
@@ -72,52 +127,236 @@ func New(backend backend.Backend, options *Options) Worker {
 		options.WorkflowExecutorCacheTTL = internal.DefaultOptions.WorkflowExecutorCacheTTL
 	}
 
+	if options.PollerScaler == nil {
+		options.PollerScaler = internal.DefaultOptions.PollerScaler
+	}
+
+	if options.PollerScaleInterval == 0 {
+		options.PollerScaleInterval = internal.DefaultOptions.PollerScaleInterval
+	}
+
+	if options.BackpressureLoadThreshold == 0 {
+		options.BackpressureLoadThreshold = internal.DefaultOptions.BackpressureLoadThreshold
+	}
+
+	if options.BackpressureCheckInterval == 0 {
+		options.BackpressureCheckInterval = internal.DefaultOptions.BackpressureCheckInterval
+	}
+
+	if options.BackpressureMaxPollDelay == 0 {
+		options.BackpressureMaxPollDelay = internal.DefaultOptions.BackpressureMaxPollDelay
+	}
+
+	if options.ScheduleCheckInterval == 0 {
+		options.ScheduleCheckInterval = internal.DefaultOptions.ScheduleCheckInterval
+	}
+
+	if options.RetentionCheckInterval == 0 {
+		options.RetentionCheckInterval = internal.DefaultOptions.RetentionCheckInterval
+	}
+
+	if options.WorkflowTaskCompletionRetryPolicy.MaxAttempts == 0 {
+		options.WorkflowTaskCompletionRetryPolicy = internal.DefaultOptions.WorkflowTaskCompletionRetryPolicy
+	}
+
 	registry := workflowinternal.NewRegistry()
 
 	// Register internal activities
-	registry.RegisterActivity(&signals.Activities{Signaler: client.New(backend)})
+	c := client.New(backend)
+	registry.RegisterActivity(&signals.Activities{
+		Signaler: signals.SignalerFunc(func(ctx context.Context, instanceID string, name string, arg interface{}) error {
+			return c.SignalWorkflow(ctx, instanceID, name, arg)
+		}),
+	})
+
+	jobs := options.MaintenanceJobs
+	if storesSchedules(backend) && options.ScheduleCheckInterval > 0 {
+		jobs = append(jobs[:len(jobs):len(jobs)], maintenance.SchedulesJob(options.ScheduleCheckInterval))
+	}
+
+	if retention(backend) > 0 && options.RetentionCheckInterval > 0 {
+		jobs = append(jobs[:len(jobs):len(jobs)], maintenance.RetentionJob(options.RetentionCheckInterval))
+	}
+
+	var maintenanceRunner *maintenance.Runner
+	if len(jobs) > 0 {
+		maintenanceRunner = maintenance.New(backend, maintenance.WithJobs(jobs...))
+	}
+
+	var workflowWorker *internal.WorkflowWorker
+	if !options.DisableWorkflowTasks {
+		workflowWorker = internal.NewWorkflowWorker(backend, registry, options)
+	}
+
+	var activityWorker *internal.ActivityWorker
+	if !options.DisableActivityTasks {
+		activityWorker = internal.NewActivityWorker(backend, registry, clock.New(), options)
+	}
 
 	return &worker{
 		backend: backend,
+		options: options,
 
 		done: make(chan struct{}),
 		wg:   &sync.WaitGroup{},
 
-		workflowWorker: internal.NewWorkflowWorker(backend, registry, options),
-		activityWorker: internal.NewActivityWorker(backend, registry, clock.New(), options),
+		workflowWorker: workflowWorker,
+		activityWorker: activityWorker,
+
+		maintenance: maintenanceRunner,
 
 		registry: registry,
 	}
 }
 
+// NewWorkflowWorker creates a worker that only executes workflows. Their activities are executed by workers created
+// with New or NewActivityWorker for the same backend.
+func NewWorkflowWorker(backend backend.Backend, options *Options) Worker {
+	o := copyOptions(options)
+	o.DisableActivityTasks = true
+
+	return New(backend, o)
+}
+
+// NewActivityWorker creates a worker that only executes activities, for example, to run heavy activities on separate
+// machines. Workflows are executed by workers created with New or NewWorkflowWorker for the same backend.
+func NewActivityWorker(backend backend.Backend, options *Options) Worker {
+	o := copyOptions(options)
+	o.DisableWorkflowTasks = true
+
+	return New(backend, o)
+}
+
+func copyOptions(options *Options) *Options {
+	if options == nil {
+		options = &internal.DefaultOptions
+	}
+
+	o := *options
+	return &o
+}
+
+// storesSchedules returns true if the given backend persists schedules
+func storesSchedules(b backend.Backend) bool {
+	_, ok := b.(backend.ScheduleStore)
+	return ok
+}
+
+// retention returns the retention period configured for the given backend, zero if finished instances are kept
+func retention(b backend.Backend) time.Duration {
+	if r, ok := b.(backend.Retainer); ok {
+		return r.Retention()
+	}
+
+	return 0
+}
+
+// pollsQueues returns true if the given backend routes tasks to named queues
+func pollsQueues(b backend.Backend) bool {
+	_, ok := b.(backend.QueuePoller)
+	return ok
+}
+
 func (w *worker) Start(ctx context.Context) error {
-	if err := w.workflowWorker.Start(ctx); err != nil {
-		return fmt.Errorf("starting workflow worker: %w", err)
+	if w.workflowWorker == nil && w.activityWorker == nil {
+		return errors.New("workflow and activity tasks are disabled")
+	}
+
+	if !pollsQueues(w.backend) {
+		for _, q := range w.options.Queues {
+			if q.OrDefault() != workflow.QueueDefault {
+				return backend.ErrQueuesNotSupported
+			}
+		}
 	}
 
-	if err := w.activityWorker.Start(ctx); err != nil {
-		return fmt.Errorf("starting activity worker: %w", err)
+	ctx, w.cancel = context.WithCancel(ctx)
+
+	if w.workflowWorker != nil {
+		if err := w.workflowWorker.Start(ctx); err != nil {
+			return fmt.Errorf("starting workflow worker: %w", err)
+		}
+	}
+
+	if w.activityWorker != nil {
+		if err := w.activityWorker.Start(ctx); err != nil {
+			return fmt.Errorf("starting activity worker: %w", err)
+		}
+	}
+
+	if w.maintenance != nil {
+		if err := w.maintenance.Start(ctx); err != nil {
+			return fmt.Errorf("starting maintenance runner: %w", err)
+		}
 	}
 
 	return nil
 }
 
 func (w *worker) WaitForCompletion() error {
-	if err := w.workflowWorker.WaitForCompletion(); err != nil {
-		return err
+	// Closing the task queues of the workers again panics, only wait once
+	w.waitOnce.Do(func() {
+		w.waitErr = w.waitForCompletion()
+	})
+
+	return w.waitErr
+}
+
+func (w *worker) Shutdown(ctx context.Context) error {
+	if w.cancel != nil {
+		w.cancel()
+	}
+
+	if w.options.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.options.ShutdownTimeout)
+		defer cancel()
 	}
 
-	if err := w.activityWorker.WaitForCompletion(); err != nil {
+	done := make(chan error, 1)
+	go func() {
+		done <- w.WaitForCompletion()
+	}()
+
+	select {
+	case err := <-done:
 		return err
+
+	case <-ctx.Done():
+		if w.activityWorker != nil {
+			w.activityWorker.Abort()
+		}
+
+		return fmt.Errorf("waiting for running tasks: %w", ctx.Err())
+	}
+}
+
+func (w *worker) waitForCompletion() error {
+	if w.workflowWorker != nil {
+		if err := w.workflowWorker.WaitForCompletion(); err != nil {
+			return err
+		}
+	}
+
+	if w.activityWorker != nil {
+		if err := w.activityWorker.WaitForCompletion(); err != nil {
+			return err
+		}
+	}
+
+	if w.maintenance != nil {
+		if err := w.maintenance.WaitForCompletion(); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (w *worker) RegisterWorkflow(wf workflow.Workflow) error {
-	return w.registry.RegisterWorkflow(wf)
+func (w *worker) RegisterWorkflow(wf workflow.Workflow, opts ...RegisterOption) error {
+	return w.registry.RegisterWorkflow(wf, opts...)
 }
 
-func (w *worker) RegisterActivity(a interface{}) error {
-	return w.registry.RegisterActivity(a)
+func (w *worker) RegisterActivity(a interface{}, opts ...RegisterOption) error {
+	return w.registry.RegisterActivity(a, opts...)
 }
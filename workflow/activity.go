@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"fmt"
+	"time"
 
 	a "github.com/cschleiden/go-workflows/internal/args"
 	"github.com/cschleiden/go-workflows/internal/command"
@@ -9,7 +10,9 @@ import (
 	"github.com/cschleiden/go-workflows/internal/converter"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/fn"
+	"github.com/cschleiden/go-workflows/internal/payload"
 	"github.com/cschleiden/go-workflows/internal/sync"
+	"github.com/cschleiden/go-workflows/internal/tracing"
 	"github.com/cschleiden/go-workflows/internal/workflowstate"
 	"github.com/cschleiden/go-workflows/internal/workflowtracer"
 	"github.com/cschleiden/go-workflows/log"
@@ -19,6 +22,31 @@ import (
 
 type ActivityOptions struct {
 	RetryOptions RetryOptions
+
+	// ScheduleToStartTimeout is the maximum time an activity task can wait for a worker to pick it up. If the
+	// timeout expires, the activity attempt fails with a TimeoutError of kind TimeoutKindScheduleToStart. Zero
+	// means no timeout.
+	ScheduleToStartTimeout time.Duration
+
+	// StartToCloseTimeout is the maximum time a single activity attempt can run. If the timeout expires, the
+	// activity attempt fails with a TimeoutError of kind TimeoutKindStartToClose. Zero means no timeout.
+	StartToCloseTimeout time.Duration
+
+	// HeartbeatTimeout is the maximum time between two heartbeats recorded with activity.RecordHeartbeat. If the
+	// activity stops heartbeating, the attempt fails with a TimeoutError of kind TimeoutKindHeartbeat and the details
+	// of the last heartbeat are handed to the next attempt as its checkpoint. Zero means no timeout.
+	HeartbeatTimeout time.Duration
+
+	// AtMostOnce ensures the activity is never executed more than once per attempt. If the task of an attempt is
+	// delivered again, for example, because the worker executing it crashed, the activity is not executed again and
+	// the attempt fails with ErrActivityOutcomeUnknown. Use this for activities with side effects which must not be
+	// repeated, like charging a credit card. Failures of at-most-once activities are not retried automatically once
+	// their outcome is unknown.
+	AtMostOnce bool
+
+	// Queue routes the activity task to the workers polling the queue, for example, workers with special hardware.
+	// Defaults to the queue of the workflow instance.
+	Queue Queue
 }
 
 var DefaultActivityOptions = ActivityOptions{
@@ -27,36 +55,48 @@ var DefaultActivityOptions = ActivityOptions{
 
 // ExecuteActivity schedules the given activity to be executed
 func ExecuteActivity[TResult any](ctx Context, options ActivityOptions, activity interface{}, args ...interface{}) Future[TResult] {
+	// Hand the checkpoint of a failed attempt to the next attempt
+	var lastAttempt *command.ScheduleActivityCommand
+	var checkpoint payload.Payload
+
 	return WithRetries(ctx, options.RetryOptions, func(ctx sync.Context, attempt int) Future[TResult] {
-		return executeActivity[TResult](ctx, options, attempt, activity, args...)
+		if lastAttempt != nil && lastAttempt.FailedCheckpoint != nil {
+			checkpoint = lastAttempt.FailedCheckpoint
+		}
+
+		var f Future[TResult]
+		f, lastAttempt = executeActivity[TResult](ctx, options, attempt, checkpoint, activity, args...)
+		return f
 	})
 }
 
-func executeActivity[TResult any](ctx Context, options ActivityOptions, attempt int, activity interface{}, args ...interface{}) Future[TResult] {
+func executeActivity[TResult any](
+	ctx Context, options ActivityOptions, attempt int, checkpoint payload.Payload, activity interface{}, args ...interface{},
+) (Future[TResult], *command.ScheduleActivityCommand) {
 	f := sync.NewFuture[TResult]()
 
 	if ctx.Err() != nil {
 		f.Set(*new(TResult), ctx.Err())
-		return f
+		return f, nil
 	}
 
 	// Check return type
 	if err := a.ReturnTypeMatch[TResult](activity); err != nil {
 		f.Set(*new(TResult), err)
-		return f
+		return f, nil
 	}
 
 	// Check arguments
 	if err := a.ParamsMatch(activity, args...); err != nil {
 		f.Set(*new(TResult), err)
-		return f
+		return f, nil
 	}
 
 	cv := converter.GetConverter(ctx)
 	inputs, err := a.ArgsToInputs(cv, args...)
 	if err != nil {
 		f.Set(*new(TResult), fmt.Errorf("converting activity input: %w", err))
-		return f
+		return f, nil
 	}
 
 	wfState := workflowstate.WorkflowState(ctx)
@@ -64,18 +104,14 @@ func executeActivity[TResult any](ctx Context, options ActivityOptions, attempt
 
 	name := fn.Name(activity)
 
-	// Capture context
+	// Capture context. The activity execution is part of the span of the workflow and links to the span scheduling it.
 	propagators := contextpropagation.Propagators(ctx)
 	metadata := &core.WorkflowMetadata{}
 	if err := contextpropagation.InjectFromWorkflow(ctx, metadata, propagators); err != nil {
 		f.Set(*new(TResult), fmt.Errorf("injecting workflow context: %w", err))
-		return f
+		return f, nil
 	}
 
-	cmd := command.NewScheduleActivityCommand(scheduleEventID, name, inputs, metadata)
-	wfState.AddCommand(cmd)
-	wfState.TrackFuture(scheduleEventID, workflowstate.AsDecodingSettable(cv, f))
-
 	ctx, span := workflowtracer.Tracer(ctx).Start(ctx,
 		fmt.Sprintf("ExecuteActivity: %s", name),
 		trace.WithAttributes(
@@ -85,6 +121,25 @@ func executeActivity[TResult any](ctx Context, options ActivityOptions, attempt
 		))
 	defer span.End()
 
+	tracing.InjectLink(span.SpanContext(), metadata)
+
+	queue := options.Queue
+	if queue == "" {
+		queue = wfState.Queue()
+	}
+
+	cmd := command.NewScheduleActivityCommand(scheduleEventID, name, inputs, metadata, command.ActivityOptions{
+		ScheduleToStartTimeout: options.ScheduleToStartTimeout,
+		StartToCloseTimeout:    options.StartToCloseTimeout,
+		HeartbeatTimeout:       options.HeartbeatTimeout,
+		AtMostOnce:             options.AtMostOnce,
+		Queue:                  queue,
+		Checkpoint:             checkpoint,
+		Attempt:                attempt,
+	})
+	wfState.AddCommand(cmd)
+	wfState.TrackFuture(scheduleEventID, workflowstate.AsDecodingSettable(cv, f))
+
 	// Handle cancellation
 	if d := ctx.Done(); d != nil {
 		if c, ok := d.(sync.ChannelInternal[struct{}]); ok {
@@ -99,5 +154,5 @@ func executeActivity[TResult any](ctx Context, options ActivityOptions, attempt
 		}
 	}
 
-	return f
+	return f, cmd
 }
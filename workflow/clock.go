@@ -0,0 +1,79 @@
+package workflow
+
+import (
+	"context"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/cschleiden/go-workflows/internal/sync"
+)
+
+// NewGuardedClock returns a clock delegating to c, which fails workflows calling it when the determinism guard is
+// enabled. Reading wall-clock time, like calling time.Now, doesn't block and can't be detected by the guard itself.
+// Inject the returned clock into code shared between workflows and activities to detect it being called from
+// workflows. Outside of workflows, or without the guard, the clock behaves like c. Direct calls to time.Now are still
+// not detected, the analyzer reports them.
+func NewGuardedClock(c clock.Clock) clock.Clock {
+	return &guardedClock{c: c}
+}
+
+type guardedClock struct {
+	c clock.Clock
+}
+
+var _ clock.Clock = (*guardedClock)(nil)
+
+func (g *guardedClock) After(d time.Duration) <-chan time.Time {
+	sync.CheckWallClock("After")
+	return g.c.After(d)
+}
+
+func (g *guardedClock) AfterFunc(d time.Duration, f func()) *clock.Timer {
+	sync.CheckWallClock("AfterFunc")
+	return g.c.AfterFunc(d, f)
+}
+
+func (g *guardedClock) Now() time.Time {
+	sync.CheckWallClock("Now")
+	return g.c.Now()
+}
+
+func (g *guardedClock) Since(t time.Time) time.Duration {
+	sync.CheckWallClock("Since")
+	return g.c.Since(t)
+}
+
+func (g *guardedClock) Until(t time.Time) time.Duration {
+	sync.CheckWallClock("Until")
+	return g.c.Until(t)
+}
+
+func (g *guardedClock) Sleep(d time.Duration) {
+	sync.CheckWallClock("Sleep")
+	g.c.Sleep(d)
+}
+
+func (g *guardedClock) Tick(d time.Duration) <-chan time.Time {
+	sync.CheckWallClock("Tick")
+	return g.c.Tick(d)
+}
+
+func (g *guardedClock) Ticker(d time.Duration) *clock.Ticker {
+	sync.CheckWallClock("Ticker")
+	return g.c.Ticker(d)
+}
+
+func (g *guardedClock) Timer(d time.Duration) *clock.Timer {
+	sync.CheckWallClock("Timer")
+	return g.c.Timer(d)
+}
+
+func (g *guardedClock) WithDeadline(parent context.Context, d time.Time) (context.Context, context.CancelFunc) {
+	sync.CheckWallClock("WithDeadline")
+	return g.c.WithDeadline(parent, d)
+}
+
+func (g *guardedClock) WithTimeout(parent context.Context, t time.Duration) (context.Context, context.CancelFunc) {
+	sync.CheckWallClock("WithTimeout")
+	return g.c.WithTimeout(parent, t)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/cschleiden/go-workflows/internal/continueasnew"
 	"github.com/cschleiden/go-workflows/internal/converter"
 	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/workflowstate"
 )
 
 // ContinueAsNew restarts the current workflow with the given arguments
@@ -27,3 +28,11 @@ func ContinueAsNew(ctx Context, args ...interface{}) error {
 
 	return continueasnew.NewError(metadata, inputs)
 }
+
+// ShouldContinueAsNew returns true when the history of the current workflow execution has grown beyond the thresholds
+// configured for the worker. Workflows can check it to decide when to call ContinueAsNew. The value only changes
+// between workflow tasks and is deterministic across replays.
+func ShouldContinueAsNew(ctx Context) bool {
+	wfState := workflowstate.WorkflowState(ctx)
+	return wfState.ContinueAsNewSuggested()
+}
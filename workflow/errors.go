@@ -1,22 +1,67 @@
 package workflow
 
-import "github.com/cschleiden/go-workflows/internal/workflowerrors"
+import (
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+)
 
 type (
-	Error      = workflowerrors.Error
-	PanicError = workflowerrors.PanicError
+	Error        = workflowerrors.Error
+	PanicError   = workflowerrors.PanicError
+	TimeoutError = workflowerrors.TimeoutError
+	TimeoutKind  = workflowerrors.TimeoutKind
+)
+
+const (
+	TimeoutKindScheduleToStart = workflowerrors.TimeoutKindScheduleToStart
+	TimeoutKindStartToClose    = workflowerrors.TimeoutKindStartToClose
+	TimeoutKindHeartbeat       = workflowerrors.TimeoutKindHeartbeat
+	TimeoutKindExecution       = workflowerrors.TimeoutKindExecution
 )
 
+// ErrActivityOutcomeUnknown is returned for at-most-once activities whose task was delivered again. The activity
+// might or might not have been executed.
+var ErrActivityOutcomeUnknown = workflowerrors.ErrActivityOutcomeUnknown
+
+// ErrTerminated is returned for sub-workflows that have been terminated
+var ErrTerminated = workflowerrors.ErrTerminated
+
 // NewError wraps the given error into a workflow error which will be automatically retried
 func NewError(err error) error {
 	return workflowerrors.FromError(err)
 }
 
+// NewErrorWithPayload wraps the given error into a workflow error carrying additional data, for example details for
+// the caller to act on. The payload is serialized as JSON and kept when the error is passed from activities to
+// workflows and from workflows to the client. Retrieve it with ErrorPayload.
+func NewErrorWithPayload(err error, payload interface{}) error {
+	e, perr := workflowerrors.NewErrorWithPayload(err, payload)
+	if perr != nil {
+		// Keep the original error, the payload is lost
+		return workflowerrors.FromError(fmt.Errorf("%w (%v)", err, perr))
+	}
+
+	return e
+}
+
+// ErrorPayload decodes the payload attached via NewErrorWithPayload to the given error, or any error in its chain,
+// into v. Returns false if none of the errors carries a payload.
+func ErrorPayload(err error, v interface{}) (bool, error) {
+	return workflowerrors.DecodePayload(err, v)
+}
+
 // NewPermanentError wraps the given error into a workflow error which will not be automatically retried
 func NewPermanentError(err error) error {
 	return workflowerrors.NewPermanentError(err)
 }
 
+// NewNonRetryableError wraps the given error into a workflow error which will not be automatically retried. Use this
+// for errors where retrying can never succeed, for example, validation failures.
+func NewNonRetryableError(err error) error {
+	return workflowerrors.NewNonRetryableError(err)
+}
+
 // CanRetry returns true if the given error is retryable
 func CanRetry(err error) bool {
 	return workflowerrors.CanRetry(err)
@@ -0,0 +1,7 @@
+package workflow
+
+import "github.com/cschleiden/go-workflows/internal/core"
+
+// Memo holds arbitrary key/values describing a workflow instance. Memos are stored with the instance and returned when
+// listing instances, but are not indexed and not passed to the workflow.
+type Memo = core.Memo
@@ -0,0 +1,13 @@
+package workflow
+
+import "github.com/cschleiden/go-workflows/internal/core"
+
+// ParentClosePolicy determines what happens to a running sub-workflow when its parent workflow is canceled. See
+// SubWorkflowOptions.ParentClosePolicy.
+type ParentClosePolicy = core.ParentClosePolicy
+
+const (
+	ParentClosePolicyRequestCancel = core.ParentClosePolicyRequestCancel
+	ParentClosePolicyAbandon       = core.ParentClosePolicyAbandon
+	ParentClosePolicyWait          = core.ParentClosePolicyWait
+)
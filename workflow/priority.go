@@ -0,0 +1,13 @@
+package workflow
+
+import "github.com/cschleiden/go-workflows/internal/core"
+
+// Priority determines the order in which the tasks of a workflow instance and its activities are handed out to
+// workers. Sub-workflows and continued executions inherit the priority of their workflow instance.
+type Priority = core.Priority
+
+const (
+	PriorityLow    = core.PriorityLow
+	PriorityNormal = core.PriorityNormal
+	PriorityHigh   = core.PriorityHigh
+)
@@ -0,0 +1,132 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/cschleiden/go-workflows/internal/args"
+	"github.com/cschleiden/go-workflows/internal/command"
+	"github.com/cschleiden/go-workflows/internal/contextpropagation"
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/sync"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+	"github.com/cschleiden/go-workflows/internal/workflowstate"
+)
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// SetQueryHandler registers a handler answering the query with the given name. Handlers have to return either
+// (error) or (result, error) and do not receive a workflow context: they are evaluated against the current state
+// of the workflow, must not block, and must not modify the workflow state.
+//
+// Queries are answered after replaying the history of the workflow instance, a handler registered later in the
+// workflow replaces an earlier handler for the same query.
+func SetQueryHandler(ctx Context, name string, handler interface{}) error {
+	fn := reflect.ValueOf(handler)
+	if fn.Kind() != reflect.Func {
+		return errors.New("query handler must be a function")
+	}
+
+	fnT := fn.Type()
+
+	if fnT.NumIn() > 0 && (args.IsOwnContext(fnT.In(0)) || fnT.In(0).Implements(contextType)) {
+		return errors.New("query handler must not accept a context")
+	}
+
+	if fnT.NumOut() < 1 || fnT.NumOut() > 2 || !fnT.Out(fnT.NumOut()-1).Implements(errorType) {
+		return errors.New("query handler has to return either (error) or (result, error)")
+	}
+
+	cv := converter.GetConverter(ctx)
+
+	workflowstate.WorkflowState(ctx).SetQueryHandler(name, func(inputs []payload.Payload) (result payload.Payload, err error) {
+		argValues, _, err := args.InputsToArgs(cv, fn, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("converting query arguments: %w", err)
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = workflowerrors.NewPanicError(fmt.Sprintf("panic in query handler: %v", r))
+			}
+		}()
+
+		r := fn.Call(argValues)
+
+		if errResult := r[len(r)-1]; !errResult.IsNil() {
+			return nil, errResult.Interface().(error)
+		}
+
+		if len(r) > 1 {
+			result, err = cv.To(r[0].Interface())
+		} else {
+			result, err = cv.To(nil)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("converting query result: %w", err)
+		}
+
+		return result, nil
+	})
+
+	return nil
+}
+
+// QuerySubWorkflow evaluates the query handler with the given name of the sub-workflow instance with the given ID,
+// use SubWorkflowOptions.InstanceID to know the ID of a sub-workflow. The query is scheduled like an activity and
+// its answer is recorded in the history of the workflow, replays return the recorded answer without querying the
+// sub-workflow again.
+func QuerySubWorkflow[TResult any](ctx Context, instanceID string, queryName string, args ...interface{}) Future[TResult] {
+	f := sync.NewFuture[TResult]()
+
+	if ctx.Err() != nil {
+		f.Set(*new(TResult), ctx.Err())
+		return f
+	}
+
+	cv := converter.GetConverter(ctx)
+	inputs, err := argsToQueryInputs(cv, instanceID, queryName, args...)
+	if err != nil {
+		f.Set(*new(TResult), err)
+		return f
+	}
+
+	propagators := contextpropagation.Propagators(ctx)
+	metadata := &core.WorkflowMetadata{}
+	if err := contextpropagation.InjectFromWorkflow(ctx, metadata, propagators); err != nil {
+		f.Set(*new(TResult), fmt.Errorf("injecting workflow context: %w", err))
+		return f
+	}
+
+	wfState := workflowstate.WorkflowState(ctx)
+	scheduleEventID := wfState.GetNextScheduleEventID()
+
+	cmd := command.NewScheduleActivityCommand(scheduleEventID, command.QuerySubWorkflowActivityName, inputs, metadata, command.ActivityOptions{
+		Queue: wfState.Queue(),
+	})
+	wfState.AddCommand(cmd)
+	wfState.TrackFuture(scheduleEventID, workflowstate.AsDecodingSettable(cv, f))
+
+	return f
+}
+
+func argsToQueryInputs(cv converter.Converter, instanceID string, queryName string, queryArgs ...interface{}) ([]payload.Payload, error) {
+	inputs, err := args.ArgsToInputs(cv, instanceID, queryName)
+	if err != nil {
+		return nil, fmt.Errorf("converting query: %w", err)
+	}
+
+	queryInputs, err := args.ArgsToInputs(cv, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("converting query arguments: %w", err)
+	}
+
+	return append(inputs, queryInputs...), nil
+}
@@ -0,0 +1,9 @@
+package workflow
+
+import "github.com/cschleiden/go-workflows/internal/core"
+
+// Queue routes the tasks of workflow instances and activities to the workers polling it. Sub-workflows, continued
+// executions, and activities use the queue of their workflow instance unless their options specify another one.
+type Queue = core.Queue
+
+const QueueDefault = core.QueueDefault
@@ -23,6 +23,15 @@ type RetryOptions struct {
 
 	// Timeout after which retries are aborted
 	RetryTimeout time.Duration
+
+	// Jitter randomly shortens each retry delay by up to the given fraction of the delay, between 0 and 1. Use this to
+	// spread out retries of many workflow instances failing at the same time.
+	Jitter float64
+
+	// NonRetryableErrorTypes is a list of error types which are not retried. The type of an error is the name of
+	// its Go type, for example `ValidationError` for an error of type `*ValidationError`. The whole error chain is
+	// checked.
+	NonRetryableErrorTypes []string
 }
 
 var DefaultRetryOptions = RetryOptions{
@@ -69,12 +78,24 @@ func WithRetries[T any](ctx Context, retryOptions RetryOptions, fn func(ctx Cont
 				break
 			}
 
+			// Abort retries for error types that should never be retried
+			if workflowerrors.HasType(err, retryOptions.NonRetryableErrorTypes...) {
+				break
+			}
+
 			backoffDuration := time.Duration(float64(retryOptions.FirstRetryInterval) * math.Pow(retryOptions.BackoffCoefficient, float64(attempt)))
 			if retryOptions.MaxRetryInterval > 0 {
 				backoffDuration = time.Duration(math.Min(float64(backoffDuration), float64(retryOptions.MaxRetryInterval)))
 			}
 
-			if err := Sleep(ctx, backoffDuration); err != nil {
+			var timerOpts []TimerOption
+			if retryOptions.Jitter > 0 {
+				maxJitter := time.Duration(float64(backoffDuration) * math.Min(retryOptions.Jitter, 1))
+				backoffDuration -= maxJitter
+				timerOpts = append(timerOpts, WithTimerJitter(maxJitter))
+			}
+
+			if err := Sleep(ctx, backoffDuration, timerOpts...); err != nil {
 				r.Set(*new(T), err)
 				return
 			}
@@ -0,0 +1,14 @@
+package workflow
+
+import "github.com/cschleiden/go-workflows/internal/core"
+
+// InstanceIDReusePolicy determines whether a new workflow instance can be created with the instance ID of an existing
+// instance. See client.WorkflowInstanceOptions.InstanceIDReusePolicy.
+type InstanceIDReusePolicy = core.InstanceIDReusePolicy
+
+const (
+	InstanceIDReusePolicyAllowDuplicate           = core.InstanceIDReusePolicyAllowDuplicate
+	InstanceIDReusePolicyAllowDuplicateFailedOnly = core.InstanceIDReusePolicyAllowDuplicateFailedOnly
+	InstanceIDReusePolicyRejectDuplicate          = core.InstanceIDReusePolicyRejectDuplicate
+	InstanceIDReusePolicyTerminateExisting        = core.InstanceIDReusePolicyTerminateExisting
+)
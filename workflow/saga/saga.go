@@ -0,0 +1,110 @@
+// Package saga implements the saga pattern for workflows. Compensations are registered as the steps of the saga
+// complete and are executed in reverse order if a later step fails.
+package saga
+
+import (
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/fn"
+	"github.com/cschleiden/go-workflows/log"
+	"github.com/cschleiden/go-workflows/workflow"
+)
+
+type Options struct {
+	// ActivityOptions are used to execute compensation activities, including their retry options
+	ActivityOptions workflow.ActivityOptions
+
+	// Parallel executes all compensations at the same time, instead of one after another in reverse order
+	Parallel bool
+
+	// ContinueOnError executes the remaining compensations if one of them fails. Parallel compensations always all
+	// execute.
+	ContinueOnError bool
+}
+
+var DefaultOptions = Options{
+	ActivityOptions: workflow.DefaultActivityOptions,
+}
+
+type compensation struct {
+	activity interface{}
+	args     []interface{}
+}
+
+// Saga tracks the compensations of the completed steps of a saga
+type Saga struct {
+	options       Options
+	compensations []compensation
+}
+
+// New returns a saga without any compensations
+func New(options Options) *Saga {
+	return &Saga{
+		options: options,
+	}
+}
+
+// AddCompensation registers the given activity to undo a completed step. Compensation activities must only return an
+// error.
+func (s *Saga) AddCompensation(activity interface{}, args ...interface{}) {
+	s.compensations = append(s.compensations, compensation{activity: activity, args: args})
+}
+
+// Compensate executes the registered compensations, most recently added first. Compensations are executed even if the
+// workflow has been canceled. If a compensation fails, the returned error wraps the error of the first failed
+// compensation in execution order.
+//
+// Registered compensations are removed, so calling Compensate again only executes compensations added since.
+func (s *Saga) Compensate(ctx workflow.Context) error {
+	// Undo completed steps even when the workflow is being canceled
+	ctx = workflow.NewDisconnectedContext(ctx)
+
+	compensations := s.compensations
+	s.compensations = nil
+
+	if s.options.Parallel {
+		futures := make([]workflow.Future[any], len(compensations))
+		for i := len(compensations) - 1; i >= 0; i-- {
+			futures[i] = s.execute(ctx, compensations[i])
+		}
+
+		var firstErr error
+		for i := len(compensations) - 1; i >= 0; i-- {
+			if _, err := futures[i].Get(ctx); err != nil {
+				err = s.failed(ctx, compensations[i], err)
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+
+		return firstErr
+	}
+
+	var firstErr error
+	for i := len(compensations) - 1; i >= 0; i-- {
+		if _, err := s.execute(ctx, compensations[i]).Get(ctx); err != nil {
+			err = s.failed(ctx, compensations[i], err)
+			if !s.options.ContinueOnError {
+				return err
+			}
+
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+func (s *Saga) execute(ctx workflow.Context, c compensation) workflow.Future[any] {
+	return workflow.ExecuteActivity[any](ctx, s.options.ActivityOptions, c.activity, c.args...)
+}
+
+func (s *Saga) failed(ctx workflow.Context, c compensation, err error) error {
+	name := fn.Name(c.activity)
+	workflow.Logger(ctx).Error("Compensation failed", log.ActivityNameKey, name, "error", err)
+
+	return fmt.Errorf("compensating with activity %s: %w", name, err)
+}
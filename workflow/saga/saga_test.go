@@ -0,0 +1,145 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cschleiden/go-workflows/tester"
+	"github.com/cschleiden/go-workflows/workflow"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func book(ctx context.Context, step string) error {
+	return nil
+}
+
+func undo(ctx context.Context, step string) error {
+	return nil
+}
+
+func sagaWorkflow(options Options) func(ctx workflow.Context) ([]string, error) {
+	return func(ctx workflow.Context) ([]string, error) {
+		s := New(options)
+
+		for _, step := range []string{"flight", "hotel", "car"} {
+			if _, err := workflow.ExecuteActivity[any](ctx, workflow.DefaultActivityOptions, book, step).Get(ctx); err != nil {
+				return nil, s.Compensate(ctx)
+			}
+
+			s.AddCompensation(undo, step)
+		}
+
+		return nil, s.Compensate(ctx)
+	}
+}
+
+// expectUndo records the compensated steps in undone, compensating the failing step returns an error
+func expectUndo(wft tester.WorkflowTester[[]string], undone *[]string, failing string) {
+	for _, step := range []string{"flight", "hotel", "car"} {
+		var err error
+		if step == failing {
+			err = errors.New("undo failed")
+		}
+
+		wft.OnActivity(undo, mock.Anything, step).Run(func(args mock.Arguments) {
+			*undone = append(*undone, args.String(1))
+		}).Return(err)
+	}
+}
+
+func Test_Compensate_ReverseOrder(t *testing.T) {
+	wft := tester.NewWorkflowTester[[]string](sagaWorkflow(DefaultOptions))
+
+	wft.OnActivity(book, mock.Anything, "flight").Return(nil)
+	wft.OnActivity(book, mock.Anything, "hotel").Return(nil)
+	wft.OnActivity(book, mock.Anything, "car").Return(errors.New("no cars"))
+
+	var undone []string
+	expectUndo(wft, &undone, "")
+
+	wft.Execute(context.Background())
+
+	require.True(t, wft.WorkflowFinished())
+	_, err := wft.WorkflowResult()
+	require.NoError(t, err)
+	require.Equal(t, []string{"hotel", "flight"}, undone)
+}
+
+func Test_Compensate_StopsOnError(t *testing.T) {
+	options := DefaultOptions
+	options.ActivityOptions.RetryOptions.MaxAttempts = 1
+
+	wft := tester.NewWorkflowTester[[]string](sagaWorkflow(options))
+
+	wft.OnActivity(book, mock.Anything, mock.Anything).Return(nil)
+
+	var undone []string
+	expectUndo(wft, &undone, "hotel")
+
+	wft.Execute(context.Background())
+
+	require.True(t, wft.WorkflowFinished())
+	_, err := wft.WorkflowResult()
+	require.ErrorContains(t, err, "undo failed")
+	require.Equal(t, []string{"car", "hotel"}, undone)
+}
+
+func Test_Compensate_ContinueOnError(t *testing.T) {
+	options := DefaultOptions
+	options.ActivityOptions.RetryOptions.MaxAttempts = 1
+	options.ContinueOnError = true
+
+	wft := tester.NewWorkflowTester[[]string](sagaWorkflow(options))
+
+	wft.OnActivity(book, mock.Anything, mock.Anything).Return(nil)
+
+	var undone []string
+	expectUndo(wft, &undone, "hotel")
+
+	wft.Execute(context.Background())
+
+	require.True(t, wft.WorkflowFinished())
+	_, err := wft.WorkflowResult()
+	require.ErrorContains(t, err, "undo failed")
+	require.Equal(t, []string{"car", "hotel", "flight"}, undone)
+}
+
+func Test_Compensate_RetriesCompensations(t *testing.T) {
+	wft := tester.NewWorkflowTester[[]string](sagaWorkflow(DefaultOptions))
+
+	wft.OnActivity(book, mock.Anything, mock.Anything).Return(nil)
+
+	wft.OnActivity(undo, mock.Anything, "car").Return(nil)
+	wft.OnActivity(undo, mock.Anything, "hotel").Return(errors.New("undo failed")).Once()
+	wft.OnActivity(undo, mock.Anything, "hotel").Return(nil).Once()
+	wft.OnActivity(undo, mock.Anything, "flight").Return(nil)
+
+	wft.Execute(context.Background())
+
+	require.True(t, wft.WorkflowFinished())
+	_, err := wft.WorkflowResult()
+	require.NoError(t, err)
+	wft.AssertExpectations(t)
+}
+
+func Test_Compensate_Parallel(t *testing.T) {
+	options := DefaultOptions
+	options.ActivityOptions.RetryOptions.MaxAttempts = 1
+	options.Parallel = true
+
+	wft := tester.NewWorkflowTester[[]string](sagaWorkflow(options))
+
+	wft.OnActivity(book, mock.Anything, mock.Anything).Return(nil)
+
+	var undone []string
+	expectUndo(wft, &undone, "car")
+
+	wft.Execute(context.Background())
+
+	require.True(t, wft.WorkflowFinished())
+	_, err := wft.WorkflowResult()
+	require.ErrorContains(t, err, "undo failed")
+	require.ElementsMatch(t, []string{"car", "hotel", "flight"}, undone)
+}
@@ -0,0 +1,58 @@
+package workflow
+
+import (
+	"time"
+
+	"github.com/cschleiden/go-workflows/internal/command"
+	"github.com/cschleiden/go-workflows/internal/core"
+	"github.com/cschleiden/go-workflows/internal/workflowstate"
+)
+
+// SearchAttribute is a typed value indexed by the backend, see StringAttribute, IntAttribute, BoolAttribute, and
+// TimeAttribute
+type SearchAttribute = core.SearchAttribute
+
+// SearchAttributes are search attributes of a workflow instance, keyed by name. Instances can be filtered by their
+// search attributes when listing them.
+type SearchAttributes = core.SearchAttributes
+
+func StringAttribute(v string) SearchAttribute {
+	return core.NewStringSearchAttribute(v)
+}
+
+func IntAttribute(v int64) SearchAttribute {
+	return core.NewIntSearchAttribute(v)
+}
+
+func BoolAttribute(v bool) SearchAttribute {
+	return core.NewBoolSearchAttribute(v)
+}
+
+// TimeAttribute returns a search attribute for the given time. Times are indexed in UTC.
+func TimeAttribute(v time.Time) SearchAttribute {
+	return core.NewTimeSearchAttribute(v)
+}
+
+// UpsertSearchAttributes adds the given search attributes to the workflow instance, or updates their values if they
+// have been set before. Attributes not given keep their values. The attributes are indexed once the current workflow
+// task has completed.
+func UpsertSearchAttributes(ctx Context, attributes SearchAttributes) error {
+	if err := attributes.Validate(); err != nil {
+		return err
+	}
+
+	// Upserts made by previous executions of the workflow code are part of the history already
+	if Replaying(ctx) || len(attributes) == 0 {
+		return nil
+	}
+
+	upserted := make(SearchAttributes, len(attributes))
+	for name, a := range attributes {
+		upserted[name] = a
+	}
+
+	wfState := workflowstate.WorkflowState(ctx)
+	wfState.AddCommand(command.NewUpsertSearchAttributesCommand(upserted))
+
+	return nil
+}
@@ -1,16 +1,58 @@
 package workflow
 
 import (
+	"errors"
+	"time"
+
 	"github.com/cschleiden/go-workflows/internal/signals"
 	"github.com/cschleiden/go-workflows/internal/workflowstate"
 	"github.com/cschleiden/go-workflows/internal/workflowtracer"
+	"github.com/cschleiden/go-workflows/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrSignalTimeout is returned by WaitForSignal if no signal was received before the timeout expired
+var ErrSignalTimeout = errors.New("timeout waiting for signal")
+
 func NewSignalChannel[T any](ctx Context, name string) Channel[T] {
 	wfState := workflowstate.WorkflowState(ctx)
 	return workflowstate.GetSignalChannel[T](ctx, wfState, name)
 }
 
+// WaitForSignal waits for a signal with the given name and returns its payload. If no signal is received within
+// the timeout, ErrSignalTimeout is returned. If the context is canceled while waiting, Canceled is returned.
+func WaitForSignal[T any](ctx Context, name string, timeout time.Duration) (T, error) {
+	ctx, span := workflowtracer.Tracer(ctx).Start(ctx, "WaitForSignal",
+		trace.WithAttributes(
+			attribute.String(log.SignalNameKey, name),
+			attribute.Int64(log.DurationKey, int64(timeout/time.Millisecond)),
+		))
+	defer span.End()
+
+	tctx, cancel := WithCancel(ctx)
+	defer cancel()
+
+	var result T
+	var err error
+
+	Select(ctx,
+		Receive(NewSignalChannel[T](ctx, name), func(ctx Context, v T, ok bool) {
+			result = v
+		}),
+		Await(ScheduleTimer(tctx, timeout), func(ctx Context, f Future[struct{}]) {
+			if _, terr := f.Get(ctx); terr != nil {
+				err = terr
+				return
+			}
+
+			err = ErrSignalTimeout
+		}),
+	)
+
+	return result, err
+}
+
 func SignalWorkflow[T any](ctx Context, instanceID string, name string, arg T) Future[any] {
 	ctx, span := workflowtracer.Tracer(ctx).Start(ctx, "SignalWorkflow")
 	defer span.End()
@@ -10,12 +10,12 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-func Sleep(ctx sync.Context, d time.Duration) error {
+func Sleep(ctx sync.Context, d time.Duration, opts ...TimerOption) error {
 	ctx, span := workflowtracer.Tracer(ctx).Start(ctx, "Sleep",
 		trace.WithAttributes(attribute.Int64(log.DurationKey, int64(d/time.Millisecond))))
 	defer span.End()
 
-	_, err := ScheduleTimer(ctx, d).Get(ctx)
+	_, err := ScheduleTimer(ctx, d, opts...).Get(ctx)
 
 	return err
 }
@@ -1,7 +1,9 @@
 package workflow
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	a "github.com/cschleiden/go-workflows/internal/args"
 	"github.com/cschleiden/go-workflows/internal/command"
@@ -9,6 +11,7 @@ import (
 	"github.com/cschleiden/go-workflows/internal/converter"
 	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/fn"
+	"github.com/cschleiden/go-workflows/internal/interceptor"
 	"github.com/cschleiden/go-workflows/internal/sync"
 	"github.com/cschleiden/go-workflows/internal/workflowstate"
 	"github.com/cschleiden/go-workflows/internal/workflowtracer"
@@ -21,6 +24,19 @@ type SubWorkflowOptions struct {
 	InstanceID string
 
 	RetryOptions RetryOptions
+
+	// ParentClosePolicy determines what happens to the sub-workflow when the workflow is canceled. Defaults to
+	// ParentClosePolicyRequestCancel.
+	ParentClosePolicy ParentClosePolicy
+
+	// Queue routes the tasks of the sub-workflow to the workers polling the queue. Defaults to the queue of the
+	// workflow instance.
+	Queue Queue
+
+	// ExecutionTimeout limits how long the sub-workflow instance can run, measured from scheduling it and including
+	// executions continued as new. When exceeded, the sub-workflow is terminated and fails with a TimeoutError of
+	// kind TimeoutKindExecution.
+	ExecutionTimeout time.Duration
 }
 
 var (
@@ -82,25 +98,60 @@ func createSubWorkflowInstance[TResult any](ctx sync.Context, options SubWorkflo
 		))
 	defer span.End()
 
-	// Capture context
-	propagators := contextpropagation.Propagators(ctx)
-	metadata := &core.WorkflowMetadata{}
-	if err := contextpropagation.InjectFromWorkflow(ctx, metadata, propagators); err != nil {
-		f.Set(*new(TResult), fmt.Errorf("injecting workflow context: %w", err))
-		return f
-	}
+	var cmd *command.ScheduleSubWorkflowCommand
+	if err := interceptor.ScheduleSubWorkflow(ctx, interceptor.WorkflowInterceptors(ctx), &interceptor.SubWorkflowInfo{
+		Instance:              wfState.Instance(),
+		Name:                  name,
+		SubWorkflowInstanceID: options.InstanceID,
+	}, func(ctx sync.Context) error {
+		// Capture context
+		propagators := contextpropagation.Propagators(ctx)
+		metadata := &core.WorkflowMetadata{}
+		if err := contextpropagation.InjectFromWorkflow(ctx, metadata, propagators); err != nil {
+			return fmt.Errorf("injecting workflow context: %w", err)
+		}
+
+		queue := options.Queue
+		if queue == "" {
+			queue = wfState.Queue()
+		}
 
-	cmd := command.NewScheduleSubWorkflowCommand(scheduleEventID, wfState.Instance(), options.InstanceID, name, inputs, metadata)
+		subWorkflowInstanceID := options.InstanceID
+		if subWorkflowInstanceID == "" {
+			subWorkflowInstanceID = wfState.NewID()
+		}
 
-	wfState.AddCommand(cmd)
-	wfState.TrackFuture(scheduleEventID, workflowstate.AsDecodingSettable(cv, f))
+		cmd = command.NewScheduleSubWorkflowCommand(
+			scheduleEventID, wfState.Instance(), subWorkflowInstanceID, wfState.NewID(), name, inputs, metadata, wfState.Priority(), queue, options.ParentClosePolicy,
+			options.ExecutionTimeout)
 
-	// Check if the channel is cancelable
-	if c, cancelable := ctx.Done().(sync.CancelChannel); cancelable {
+		wfState.AddCommand(cmd)
+		wfState.TrackFuture(scheduleEventID, workflowstate.AsDecodingSettable(cv, f))
+
+		return nil
+	}); err != nil && cmd == nil {
+		f.Set(*new(TResult), err)
+		return f
+	} else if err != nil {
+		// The sub-workflow has already been scheduled, its result is delivered to the future
+		wfState.Logger().Error("Workflow interceptor failed after scheduling sub-workflow", log.WorkflowNameKey, name, "error", err)
+	} else if cmd == nil {
+		f.Set(*new(TResult), errors.New("workflow interceptor did not schedule the sub-workflow"))
+		return f
+	}
+
+	// Check if the channel is cancelable. Sub-workflows the workflow waits for don't need to observe cancellation.
+	if c, cancelable := ctx.Done().(sync.CancelChannel); cancelable && options.ParentClosePolicy != ParentClosePolicyWait {
 		cancelReceiver := &sync.Receiver[struct{}]{
 			Receive: func(v struct{}, ok bool) {
-				cmd.Cancel()
-				if cmd.State() == command.CommandState_Canceled {
+				if options.ParentClosePolicy == ParentClosePolicyAbandon && cmd.State() == command.CommandState_Committed {
+					// Leave the running sub-workflow alone, its result is discarded
+					cmd.Abandon()
+				} else {
+					cmd.Cancel()
+				}
+
+				if cmd.State() == command.CommandState_Canceled || cmd.Abandoned() {
 					// Remove the sub-workflow future from the workflow state and mark it as canceled if it hasn't already fired
 					if fi, ok := f.(sync.FutureInternal[TResult]); ok {
 						if !fi.Ready() {
@@ -1,11 +1,15 @@
 package workflow
 
 import (
+	"encoding/binary"
+	"hash/fnv"
 	"time"
 
 	"github.com/cschleiden/go-workflows/internal/command"
 	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/core"
 	"github.com/cschleiden/go-workflows/internal/sync"
+	"github.com/cschleiden/go-workflows/internal/tracing"
 	"github.com/cschleiden/go-workflows/internal/workflowstate"
 	"github.com/cschleiden/go-workflows/internal/workflowtracer"
 	"github.com/cschleiden/go-workflows/log"
@@ -13,7 +17,28 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-func ScheduleTimer(ctx Context, delay time.Duration) Future[struct{}] {
+type TimerOptions struct {
+	// Jitter is the maximum random delay added to the timer. Use this to spread out timers of many workflow instances
+	// that would otherwise fire at the same time. The delay is derived from the workflow instance and the timer, so
+	// it is stable across replays.
+	Jitter time.Duration
+}
+
+type TimerOption func(*TimerOptions)
+
+// WithTimerJitter adds a random delay of up to maxJitter to the timer. See TimerOptions.Jitter.
+func WithTimerJitter(maxJitter time.Duration) TimerOption {
+	return func(o *TimerOptions) {
+		o.Jitter = maxJitter
+	}
+}
+
+func ScheduleTimer(ctx Context, delay time.Duration, opts ...TimerOption) Future[struct{}] {
+	var options TimerOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	f := sync.NewFuture[struct{}]()
 
 	// If the context is already canceled, return immediately.
@@ -25,9 +50,26 @@ func ScheduleTimer(ctx Context, delay time.Duration) Future[struct{}] {
 	wfState := workflowstate.WorkflowState(ctx)
 
 	scheduleEventID := wfState.GetNextScheduleEventID()
+
+	if options.Jitter > 0 {
+		delay += jitter(wfState.Instance(), scheduleEventID, options.Jitter)
+	}
+
 	at := Now(ctx).Add(delay)
 
-	timerCmd := command.NewScheduleTimerCommand(scheduleEventID, at)
+	ctx, span := workflowtracer.Tracer(ctx).Start(ctx, "ScheduleTimer",
+		trace.WithAttributes(
+			attribute.Int64(log.DurationKey, int64(delay/time.Millisecond)),
+			attribute.String(log.NowKey, Now(ctx).String()),
+			attribute.String(log.AtKey, at.String()),
+		))
+	defer span.End()
+
+	// The timer firing links back to this span
+	metadata := &core.WorkflowMetadata{}
+	tracing.InjectLink(span.SpanContext(), metadata)
+
+	timerCmd := command.NewScheduleTimerCommand(scheduleEventID, at, metadata)
 	wfState.AddCommand(timerCmd)
 	wfState.TrackFuture(scheduleEventID, workflowstate.AsDecodingSettable(converter.GetConverter(ctx), f))
 
@@ -47,14 +89,6 @@ func ScheduleTimer(ctx Context, delay time.Duration) Future[struct{}] {
 		},
 	}
 
-	ctx, span := workflowtracer.Tracer(ctx).Start(ctx, "ScheduleTimer",
-		trace.WithAttributes(
-			attribute.Int64(log.DurationKey, int64(delay/time.Millisecond)),
-			attribute.String(log.NowKey, Now(ctx).String()),
-			attribute.String(log.AtKey, at.String()),
-		))
-	defer span.End()
-
 	// Check if the context is cancelable
 	if c, cancelable := ctx.Done().(sync.CancelChannel); cancelable {
 		// Register a callback for when it's canceled. The only operation on the `Done` channel
@@ -68,3 +102,15 @@ func ScheduleTimer(ctx Context, delay time.Duration) Future[struct{}] {
 
 	return f
 }
+
+// jitter returns a pseudo-random duration in [0, maxJitter) for the given timer. The value only depends on the
+// workflow instance and the timer's schedule event ID, so replays compute the same fire time.
+func jitter(instance *core.WorkflowInstance, scheduleEventID int64, maxJitter time.Duration) time.Duration {
+	h := fnv.New64a()
+	h.Write([]byte(instance.InstanceID))
+	h.Write([]byte{0})
+	h.Write([]byte(instance.ExecutionID))
+	binary.Write(h, binary.LittleEndian, scheduleEventID)
+
+	return time.Duration(h.Sum64() % uint64(maxJitter))
+}
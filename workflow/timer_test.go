@@ -48,3 +48,26 @@ func Test_Timer_Cancellation(t *testing.T) {
 
 	cancel()
 }
+
+func Test_Timer_Jitter(t *testing.T) {
+	a := core.NewWorkflowInstance("a", "1")
+	b := core.NewWorkflowInstance("b", "1")
+
+	maxJitter := time.Minute
+
+	// Jitter is stable across replays
+	require.Equal(t, jitter(a, 1, maxJitter), jitter(a, 1, maxJitter))
+
+	delays := map[time.Duration]bool{}
+	for _, instance := range []*core.WorkflowInstance{a, b} {
+		for scheduleEventID := int64(1); scheduleEventID <= 10; scheduleEventID++ {
+			d := jitter(instance, scheduleEventID, maxJitter)
+			require.GreaterOrEqual(t, d, time.Duration(0))
+			require.Less(t, d, maxJitter)
+
+			delays[d] = true
+		}
+	}
+
+	require.Greater(t, len(delays), 1)
+}
@@ -0,0 +1,116 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/cschleiden/go-workflows/internal/args"
+	"github.com/cschleiden/go-workflows/internal/converter"
+	"github.com/cschleiden/go-workflows/internal/payload"
+	"github.com/cschleiden/go-workflows/internal/workflowerrors"
+	"github.com/cschleiden/go-workflows/internal/workflowstate"
+)
+
+type updateHandlerOptions struct {
+	validator interface{}
+}
+
+type UpdateHandlerOption func(*updateHandlerOptions)
+
+// WithUpdateValidator sets a validator for the update. Validators receive the same arguments as the handler and
+// return an error to reject the update before the handler is called. They must not modify the workflow state.
+func WithUpdateValidator(validator interface{}) UpdateHandlerOption {
+	return func(o *updateHandlerOptions) {
+		o.validator = validator
+	}
+}
+
+// SetUpdateHandler registers a handler applying the update with the given name, sent via client.UpdateWorkflow.
+// Handlers have to return either (error) or (result, error) and do not receive a workflow context: they modify the
+// workflow state and must not block. The client waits until the update has been handled and receives the result, or
+// the error of a rejected update.
+//
+// Updates are recorded in the history of the workflow instance and applied again when the history is replayed, a
+// handler registered later in the workflow replaces an earlier handler for the same update.
+func SetUpdateHandler(ctx Context, name string, handler interface{}, opts ...UpdateHandlerOption) error {
+	var options updateHandlerOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	fn := reflect.ValueOf(handler)
+	if err := checkUpdateFunc(fn, "update handler"); err != nil {
+		return err
+	}
+
+	if fn.Type().NumOut() < 1 || fn.Type().NumOut() > 2 || !fn.Type().Out(fn.Type().NumOut()-1).Implements(errorType) {
+		return errors.New("update handler has to return either (error) or (result, error)")
+	}
+
+	var validator reflect.Value
+	if options.validator != nil {
+		validator = reflect.ValueOf(options.validator)
+		if err := checkUpdateFunc(validator, "update validator"); err != nil {
+			return err
+		}
+
+		if validator.Type().NumOut() != 1 || !validator.Type().Out(0).Implements(errorType) {
+			return errors.New("update validator has to return (error)")
+		}
+	}
+
+	cv := converter.GetConverter(ctx)
+
+	workflowstate.WorkflowState(ctx).SetUpdateHandler(name, func(inputs []payload.Payload) (result payload.Payload, err error) {
+		argValues, _, err := args.InputsToArgs(cv, fn, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("converting update arguments: %w", err)
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = workflowerrors.NewPanicError(fmt.Sprintf("panic in update handler: %v", r))
+			}
+		}()
+
+		if validator.IsValid() {
+			if errResult := validator.Call(argValues)[0]; !errResult.IsNil() {
+				return nil, errResult.Interface().(error)
+			}
+		}
+
+		r := fn.Call(argValues)
+
+		if errResult := r[len(r)-1]; !errResult.IsNil() {
+			return nil, errResult.Interface().(error)
+		}
+
+		if len(r) > 1 {
+			result, err = cv.To(r[0].Interface())
+		} else {
+			result, err = cv.To(nil)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("converting update result: %w", err)
+		}
+
+		return result, nil
+	})
+
+	return nil
+}
+
+func checkUpdateFunc(fn reflect.Value, kind string) error {
+	if fn.Kind() != reflect.Func {
+		return fmt.Errorf("%s must be a function", kind)
+	}
+
+	fnT := fn.Type()
+
+	if fnT.NumIn() > 0 && (args.IsOwnContext(fnT.In(0)) || fnT.In(0).Implements(contextType)) {
+		return fmt.Errorf("%s must not accept a context", kind)
+	}
+
+	return nil
+}
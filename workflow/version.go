@@ -0,0 +1,48 @@
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/cschleiden/go-workflows/internal/command"
+	"github.com/cschleiden/go-workflows/internal/workflowstate"
+)
+
+// DefaultVersion is the version of workflow code before a change was introduced with GetVersion
+const DefaultVersion = -1
+
+// GetVersion returns the version of the change with the given id for the workflow instance. The first time it's
+// called for a change, the maximum version is recorded in the history and returned from then on. Instances that ran
+// the code before the change was introduced get DefaultVersion, so they keep replaying the old code path:
+//
+//	v, err := workflow.GetVersion(ctx, "add-notification", workflow.DefaultVersion, 1)
+//	if err != nil {
+//		return err
+//	}
+//
+//	if v == 1 {
+//		// New code path
+//	}
+//
+// An error is returned if the recorded version is outside of the supported range.
+func GetVersion(ctx Context, changeID string, minVersion, maxVersion int) (int, error) {
+	wfState := workflowstate.WorkflowState(ctx)
+
+	version, ok := wfState.Version(changeID)
+	if !ok {
+		if Replaying(ctx) {
+			// The history was recorded before the change
+			version = DefaultVersion
+		} else {
+			version = maxVersion
+			wfState.AddCommand(command.NewVersionMarkerCommand(changeID, version))
+		}
+
+		wfState.SetVersion(changeID, version)
+	}
+
+	if version < minVersion || version > maxVersion {
+		return version, fmt.Errorf("version %d of change %q is not supported, supported versions are %d to %d", version, changeID, minVersion, maxVersion)
+	}
+
+	return version, nil
+}